@@ -1,8 +1,13 @@
 package cluster
 
-import "central/internal/config"
+import (
+	"net/url"
+
+	"central/internal/config"
+)
 
 type chunkServerConfig struct {
+	WorldSeed   int64                        `json:"worldSeed,omitempty" yaml:"worldSeed,omitempty"`
 	Server      chunkServerServerConfig      `json:"server" yaml:"server"`
 	Chunk       chunkServerChunkConfig       `json:"chunk" yaml:"chunk"`
 	Network     chunkServerNetworkConfig     `json:"network" yaml:"network"`
@@ -11,17 +16,24 @@ type chunkServerConfig struct {
 	Economy     chunkServerEconomyConfig     `json:"economy" yaml:"economy"`
 	Entities    chunkServerEntitiesConfig    `json:"entities" yaml:"entities"`
 	Environment chunkServerEnvironmentConfig `json:"environment" yaml:"environment"`
+	Physics     chunkServerPhysicsConfig     `json:"physics" yaml:"physics"`
 	Blocks      []config.BlockDefinition     `json:"blocks" yaml:"blocks"`
 }
 
 type chunkServerServerConfig struct {
-	ID                 string              `json:"id" yaml:"id"`
-	Description        string              `json:"description" yaml:"description"`
-	GlobalChunkOrigin  chunkServerChunkRef `json:"globalChunkOrigin" yaml:"globalChunkOrigin"`
-	TickRate           string              `json:"tickRate" yaml:"tickRate"`
-	StateStreamRate    string              `json:"stateStreamRate" yaml:"stateStreamRate"`
-	EntityStreamRate   string              `json:"entityStreamRate" yaml:"entityStreamRate"`
-	MaxConcurrentLoads int                 `json:"maxConcurrentLoads" yaml:"maxConcurrentLoads"`
+	ID                  string              `json:"id" yaml:"id"`
+	Description         string              `json:"description" yaml:"description"`
+	GlobalChunkOrigin   chunkServerChunkRef `json:"globalChunkOrigin" yaml:"globalChunkOrigin"`
+	TickRate            string              `json:"tickRate" yaml:"tickRate"`
+	StateStreamRate     string              `json:"stateStreamRate" yaml:"stateStreamRate"`
+	EntityStreamRate    string              `json:"entityStreamRate" yaml:"entityStreamRate"`
+	MaxConcurrentLoads  int                 `json:"maxConcurrentLoads" yaml:"maxConcurrentLoads"`
+	DrainTimeout        string              `json:"drainTimeout" yaml:"drainTimeout"`
+	LogLevel            string              `json:"logLevel" yaml:"logLevel"`
+	Storage             string              `json:"storage,omitempty" yaml:"storage,omitempty"`
+	StorageDir          string              `json:"storageDir,omitempty" yaml:"storageDir,omitempty"`
+	StorageMaxFileBytes int64               `json:"storageMaxFileBytes,omitempty" yaml:"storageMaxFileBytes,omitempty"`
+	ChunkIdleTTL        string              `json:"chunkIdleTTL,omitempty" yaml:"chunkIdleTTL,omitempty"`
 }
 
 type chunkServerChunkConfig struct {
@@ -29,10 +41,13 @@ type chunkServerChunkConfig struct {
 	Depth         int `json:"depth" yaml:"depth"`
 	Height        int `json:"height" yaml:"height"`
 	ChunksPerAxis int `json:"chunksPerAxis" yaml:"chunksPerAxis"`
+	ChunksX       int `json:"chunksX,omitempty" yaml:"chunksX,omitempty"`
+	ChunksY       int `json:"chunksY,omitempty" yaml:"chunksY,omitempty"`
 }
 
 type chunkServerNetworkConfig struct {
 	ListenUDP            string                   `json:"listenUdp" yaml:"listenUdp"`
+	ListenHTTP           string                   `json:"listenHttp,omitempty" yaml:"listenHttp,omitempty"`
 	MainServerEndpoints  []string                 `json:"mainServerEndpoints" yaml:"mainServerEndpoints"`
 	NeighborEndpoints    []chunkServerNeighborRef `json:"neighborEndpoints" yaml:"neighborEndpoints"`
 	HandshakeTimeout     string                   `json:"handshakeTimeout" yaml:"handshakeTimeout"`
@@ -40,6 +55,8 @@ type chunkServerNetworkConfig struct {
 	MaxDatagramSizeBytes int                      `json:"maxDatagramSizeBytes" yaml:"maxDatagramSizeBytes"`
 	DiscoveryInterval    string                   `json:"discoveryInterval" yaml:"discoveryInterval"`
 	TransferRetry        string                   `json:"transferRetry" yaml:"transferRetry"`
+	TransferMaxAttempts  int                      `json:"transferMaxAttempts" yaml:"transferMaxAttempts"`
+	RecordPath           string                   `json:"recordPath,omitempty" yaml:"recordPath,omitempty"`
 }
 
 type chunkServerNeighborRef struct {
@@ -56,13 +73,19 @@ type chunkServerPathfindingConfig struct {
 }
 
 type chunkServerTerrainConfig struct {
-	Seed        int64   `json:"seed" yaml:"seed"`
+	Seed        int64   `json:"seed,omitempty" yaml:"seed,omitempty"`
 	Frequency   float64 `json:"frequency" yaml:"frequency"`
 	Amplitude   float64 `json:"amplitude" yaml:"amplitude"`
 	Octaves     int     `json:"octaves" yaml:"octaves"`
 	Persistence float64 `json:"persistence" yaml:"persistence"`
 	Lacunarity  float64 `json:"lacunarity" yaml:"lacunarity"`
 	Workers     int     `json:"workers" yaml:"workers"`
+
+	WriteBufferBytes   int64 `json:"writeBufferBytes,omitempty" yaml:"writeBufferBytes,omitempty"`
+	WriteBufferColumns int   `json:"writeBufferColumns,omitempty" yaml:"writeBufferColumns,omitempty"`
+	TreesReplaceOre    bool  `json:"treesReplaceOre,omitempty" yaml:"treesReplaceOre,omitempty"`
+
+	ProgressLogThreshold string `json:"progressLogThreshold,omitempty" yaml:"progressLogThreshold,omitempty"`
 }
 
 type chunkServerEconomyConfig struct {
@@ -76,6 +99,8 @@ type chunkServerEntitiesConfig struct {
 	EntityTickRate      string `json:"entityTickRate" yaml:"entityTickRate"`
 	ProjectileTickRate  string `json:"projectileTickRate" yaml:"projectileTickRate"`
 	MovementWorkers     int    `json:"movementWorkers" yaml:"movementWorkers"`
+	SleepAfterTicks     int    `json:"sleepAfterTicks" yaml:"sleepAfterTicks"`
+	AISeed              int64  `json:"aiSeed,omitempty" yaml:"aiSeed,omitempty"`
 }
 
 type chunkServerEnvironmentConfig struct {
@@ -86,7 +111,22 @@ type chunkServerEnvironmentConfig struct {
 	RainChance         float64 `json:"rainChance" yaml:"rainChance"`
 	WindBase           float64 `json:"windBase" yaml:"windBase"`
 	WindVariance       float64 `json:"windVariance" yaml:"windVariance"`
-	Seed               int64   `json:"seed" yaml:"seed"`
+	TransitionHours    float64 `json:"transitionHours" yaml:"transitionHours"`
+	// Seed is left out unless set, so the chunk server derives or defaults
+	// it; an explicit zero seeds the weather from the clock.
+	Seed *int64 `json:"seed,omitempty" yaml:"seed,omitempty"`
+}
+
+type chunkServerPhysicsConfig struct {
+	GroundSupportForce   float64 `json:"groundSupportForce" yaml:"groundSupportForce"`
+	HangingPenalty       float64 `json:"hangingPenalty" yaml:"hangingPenalty"`
+	SupportFactor        float64 `json:"supportFactor" yaml:"supportFactor"`
+	CollapseImpactRadius float64 `json:"collapseImpactRadius" yaml:"collapseImpactRadius"`
+	CollapseImpactDamage float64 `json:"collapseImpactDamage" yaml:"collapseImpactDamage"`
+	Gravity              float64 `json:"gravity" yaml:"gravity"`
+	AirDrag              float64 `json:"airDrag" yaml:"airDrag"`
+	GroundFriction       float64 `json:"groundFriction" yaml:"groundFriction"`
+	MaxFallSpeed         float64 `json:"maxFallSpeed" yaml:"maxFallSpeed"`
 }
 
 type chunkServerChunkRef struct {
@@ -104,6 +144,8 @@ func defaultChunkServerConfig() chunkServerConfig {
 			StateStreamRate:    "200ms",
 			EntityStreamRate:   "50ms",
 			MaxConcurrentLoads: 4,
+			DrainTimeout:       "8s",
+			LogLevel:           "info",
 		},
 		Chunk: chunkServerChunkConfig{
 			Width:         256,
@@ -120,6 +162,7 @@ func defaultChunkServerConfig() chunkServerConfig {
 			MaxDatagramSizeBytes: 1 << 16,
 			DiscoveryInterval:    "10s",
 			TransferRetry:        "2s",
+			TransferMaxAttempts:  5,
 		},
 		Pathfinding: chunkServerPathfindingConfig{
 			MaxSearchNodes:    50_000,
@@ -129,7 +172,6 @@ func defaultChunkServerConfig() chunkServerConfig {
 			QueueTimeout:      "250ms",
 		},
 		Terrain: chunkServerTerrainConfig{
-			Seed:        1337,
 			Frequency:   0.003,
 			Amplitude:   384,
 			Octaves:     4,
@@ -154,6 +196,7 @@ func defaultChunkServerConfig() chunkServerConfig {
 			EntityTickRate:      "33ms",
 			ProjectileTickRate:  "16ms",
 			MovementWorkers:     1,
+			SleepAfterTicks:     90,
 		},
 		Environment: chunkServerEnvironmentConfig{
 			DayLength:          "20m",
@@ -163,7 +206,18 @@ func defaultChunkServerConfig() chunkServerConfig {
 			RainChance:         0.35,
 			WindBase:           3.0,
 			WindVariance:       5.0,
-			Seed:               1337,
+			TransitionHours:    1.0,
+		},
+		Physics: chunkServerPhysicsConfig{
+			GroundSupportForce:   1e6,
+			HangingPenalty:       0.72,
+			SupportFactor:        1.0,
+			CollapseImpactRadius: 3.5,
+			CollapseImpactDamage: 45.0,
+			Gravity:              9.8,
+			AirDrag:              0.4,
+			GroundFriction:       4,
+			MaxFallSpeed:         150,
 		},
 		Blocks: config.DefaultBlocks(),
 	}
@@ -188,10 +242,70 @@ func (c *chunkServerConfig) applyClusterOverrides(cfg *config.Config, cs config.
 	case cs.ChunkSpan.ChunksY > 0:
 		c.Chunk.ChunksPerAxis = cs.ChunkSpan.ChunksY
 	}
+	if cs.ChunkSpan.ChunksX > 0 && cs.ChunkSpan.ChunksY > 0 && cs.ChunkSpan.ChunksX != cs.ChunkSpan.ChunksY {
+		c.Chunk.ChunksX = cs.ChunkSpan.ChunksX
+		c.Chunk.ChunksY = cs.ChunkSpan.ChunksY
+	}
 	if cs.ListenAddress != "" {
 		c.Network.ListenUDP = cs.ListenAddress
 	}
+	c.Network.ListenHTTP = httpListenAddress(cs.HttpAddress)
+	if neighbors := clusterNeighbors(cfg, cs); len(neighbors) > 0 {
+		c.Network.NeighborEndpoints = neighbors
+	}
 	if len(cfg.World.Blocks) > 0 {
 		c.Blocks = cfg.World.Blocks
 	}
 }
+
+// httpListenAddress converts a chunk server's advertised http_address (which may
+// carry a scheme) into the host:port its stats endpoint should bind.
+func httpListenAddress(httpAddress string) string {
+	if httpAddress == "" {
+		return ""
+	}
+	if u, err := url.Parse(httpAddress); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return httpAddress
+}
+
+// clusterNeighbors lists the chunk servers whose regions share an edge with cs,
+// each with its origin offset from cs in chunks and its UDP listen address.
+// Servers that only touch at a corner, or that have no listen address, are
+// left to runtime discovery.
+func clusterNeighbors(cfg *config.Config, cs config.ChunkServer) []chunkServerNeighborRef {
+	var neighbors []chunkServerNeighborRef
+	for _, other := range cfg.ChunkServers {
+		if other.ID == cs.ID || other.ListenAddress == "" || !sharesEdge(cs, other) {
+			continue
+		}
+		neighbors = append(neighbors, chunkServerNeighborRef{
+			ChunkDelta: chunkServerChunkRef{
+				X: other.GlobalOrigin.ChunkX - cs.GlobalOrigin.ChunkX,
+				Y: other.GlobalOrigin.ChunkY - cs.GlobalOrigin.ChunkY,
+			},
+			Endpoint: other.ListenAddress,
+		})
+	}
+	return neighbors
+}
+
+func sharesEdge(a, b config.ChunkServer) bool {
+	ax, ay := a.GlobalOrigin.ChunkX, a.GlobalOrigin.ChunkY
+	bx, by := b.GlobalOrigin.ChunkX, b.GlobalOrigin.ChunkY
+	overlapX := spansOverlap(ax, a.ChunkSpan.ChunksX, bx, b.ChunkSpan.ChunksX)
+	overlapY := spansOverlap(ay, a.ChunkSpan.ChunksY, by, b.ChunkSpan.ChunksY)
+	switch {
+	case bx == ax+a.ChunkSpan.ChunksX || ax == bx+b.ChunkSpan.ChunksX:
+		return overlapY
+	case by == ay+a.ChunkSpan.ChunksY || ay == by+b.ChunkSpan.ChunksY:
+		return overlapX
+	default:
+		return false
+	}
+}
+
+func spansOverlap(startA, lenA, startB, lenB int) bool {
+	return startA < startB+lenB && startB < startA+lenA
+}
@@ -0,0 +1,92 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"central/internal/config"
+)
+
+func TestApplyClusterOverridesWiresAxisNeighbors(t *testing.T) {
+	const span = 16
+	cfg := &config.Config{}
+	for gy := 0; gy < 2; gy++ {
+		for gx := 0; gx < 2; gx++ {
+			cfg.ChunkServers = append(cfg.ChunkServers, config.ChunkServer{
+				ID:            fmt.Sprintf("chunk-%d-%d", gx, gy),
+				GlobalOrigin:  config.ChunkOrigin{ChunkX: gx * span, ChunkY: gy * span},
+				ChunkSpan:     config.ChunkSpan{ChunksX: span, ChunksY: span},
+				ListenAddress: fmt.Sprintf("127.0.0.1:%d", 19000+gy*10+gx),
+			})
+		}
+	}
+
+	want := map[string]map[chunkServerChunkRef]string{
+		"chunk-0-0": {{X: span, Y: 0}: "127.0.0.1:19001", {X: 0, Y: span}: "127.0.0.1:19010"},
+		"chunk-1-0": {{X: -span, Y: 0}: "127.0.0.1:19000", {X: 0, Y: span}: "127.0.0.1:19011"},
+		"chunk-0-1": {{X: span, Y: 0}: "127.0.0.1:19011", {X: 0, Y: -span}: "127.0.0.1:19000"},
+		"chunk-1-1": {{X: -span, Y: 0}: "127.0.0.1:19010", {X: 0, Y: -span}: "127.0.0.1:19001"},
+	}
+
+	for _, cs := range cfg.ChunkServers {
+		chunkCfg := defaultChunkServerConfig()
+		chunkCfg.applyClusterOverrides(cfg, cs)
+
+		got := chunkCfg.Network.NeighborEndpoints
+		expected := want[cs.ID]
+		if len(got) != len(expected) {
+			t.Fatalf("%s: got %d neighbors %+v, want %d", cs.ID, len(got), got, len(expected))
+		}
+		for _, ref := range got {
+			endpoint, ok := expected[ref.ChunkDelta]
+			if !ok {
+				t.Fatalf("%s: unexpected neighbor delta %+v", cs.ID, ref.ChunkDelta)
+			}
+			if ref.Endpoint != endpoint {
+				t.Fatalf("%s: neighbor %+v endpoint = %q, want %q", cs.ID, ref.ChunkDelta, ref.Endpoint, endpoint)
+			}
+		}
+	}
+}
+
+func TestApplyClusterOverridesCarriesAsymmetricSpan(t *testing.T) {
+	cfg := &config.Config{}
+	cs := config.ChunkServer{ID: "wide", ChunkSpan: config.ChunkSpan{ChunksX: 3, ChunksY: 2}}
+
+	chunkCfg := defaultChunkServerConfig()
+	chunkCfg.applyClusterOverrides(cfg, cs)
+	if chunkCfg.Chunk.ChunksX != 3 || chunkCfg.Chunk.ChunksY != 2 {
+		t.Fatalf("chunk span = %dx%d, want 3x2", chunkCfg.Chunk.ChunksX, chunkCfg.Chunk.ChunksY)
+	}
+	if chunkCfg.Chunk.ChunksPerAxis != 3 {
+		t.Fatalf("chunksPerAxis = %d, want the X span for older chunk servers", chunkCfg.Chunk.ChunksPerAxis)
+	}
+
+	square := defaultChunkServerConfig()
+	square.applyClusterOverrides(cfg, config.ChunkServer{ID: "square", ChunkSpan: config.ChunkSpan{ChunksX: 4, ChunksY: 4}})
+	if square.Chunk.ChunksX != 0 || square.Chunk.ChunksY != 0 || square.Chunk.ChunksPerAxis != 4 {
+		t.Fatalf("square span = %+v, want only chunksPerAxis set", square.Chunk)
+	}
+}
+
+func TestEnvironmentSeedKeepsExplicitZero(t *testing.T) {
+	env := defaultChunkServerConfig().Environment
+	data, err := json.Marshal(env)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if strings.Contains(string(data), `"seed"`) {
+		t.Fatalf("expected an unset seed to be left out, got %s", data)
+	}
+
+	clock := int64(0)
+	env.Seed = &clock
+	if data, err = json.Marshal(env); err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(data), `"seed":0`) {
+		t.Fatalf("expected an explicit zero seed to be written, got %s", data)
+	}
+}
@@ -12,10 +12,11 @@ import (
 	imagetypes "github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
 )
 
 type dockerRuntime struct {
-	client *client.Client
+	client client.APIClient
 }
 
 func newDockerRuntime() (*dockerRuntime, error) {
@@ -63,9 +64,12 @@ func (r *dockerRuntime) start(ctx context.Context, cfg *config.Config, cs config
 	}
 
 	proc := newProcess(cs)
+	proc.containerID = resp.ID
+	proc.logs = newLogRing(defaultLogLines)
 	proc.setActiveStatus("running")
 
 	go r.watchContainer(proc, resp.ID)
+	go r.captureLogs(proc, resp.ID)
 
 	proc.stopFn = func(stopCtx context.Context) error {
 		timeout := int((10 * time.Second).Seconds())
@@ -106,6 +110,40 @@ func (r *dockerRuntime) watchContainer(proc *process, containerID string) {
 	}
 }
 
+// captureLogs follows the container's output into the process log ring until
+// the container stops.
+func (r *dockerRuntime) captureLogs(proc *process, containerID string) {
+	reader, err := r.client.ContainerLogs(context.Background(), containerID, containertypes.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+	})
+	if err != nil {
+		return
+	}
+	defer reader.Close()
+	_, _ = stdcopy.StdCopy(proc.logs, proc.logs, reader)
+}
+
+// streamLogs copies the container's recent and live output to w until the
+// container stops or ctx is cancelled.
+func (r *dockerRuntime) streamLogs(ctx context.Context, containerID string, w io.Writer) error {
+	reader, err := r.client.ContainerLogs(ctx, containerID, containertypes.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+		Tail:       fmt.Sprint(defaultLogLines),
+	})
+	if err != nil {
+		return fmt.Errorf("docker container logs: %w", err)
+	}
+	defer reader.Close()
+	if _, err := stdcopy.StdCopy(w, w, reader); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("stream docker container logs: %w", err)
+	}
+	return nil
+}
+
 func (r *dockerRuntime) ensureImage(ctx context.Context, image string) error {
 	_, _, err := r.client.ImageInspectWithRaw(ctx, image)
 	if err == nil {
@@ -0,0 +1,132 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"central/internal/config"
+	containertypes "github.com/docker/docker/api/types/container"
+	imagetypes "github.com/docker/docker/api/types/image"
+	networktypes "github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// fakeDockerClient implements the handful of docker API calls the runtime
+// uses; any other call panics through the nil embedded interface.
+type fakeDockerClient struct {
+	client.APIClient
+
+	logLines []string
+	stopOnce sync.Once
+	stopped  chan struct{}
+}
+
+func newFakeDockerClient(lines ...string) *fakeDockerClient {
+	return &fakeDockerClient{logLines: lines, stopped: make(chan struct{})}
+}
+
+func (f *fakeDockerClient) ImageInspectWithRaw(ctx context.Context, image string) (imagetypes.InspectResponse, []byte, error) {
+	return imagetypes.InspectResponse{}, nil, nil
+}
+
+func (f *fakeDockerClient) ContainerCreate(ctx context.Context, cfg *containertypes.Config, host *containertypes.HostConfig, network *networktypes.NetworkingConfig, platform *ocispec.Platform, name string) (containertypes.CreateResponse, error) {
+	return containertypes.CreateResponse{ID: "container-" + name}, nil
+}
+
+func (f *fakeDockerClient) ContainerStart(ctx context.Context, id string, opts containertypes.StartOptions) error {
+	return nil
+}
+
+func (f *fakeDockerClient) ContainerStop(ctx context.Context, id string, opts containertypes.StopOptions) error {
+	f.stopOnce.Do(func() { close(f.stopped) })
+	return nil
+}
+
+func (f *fakeDockerClient) ContainerWait(ctx context.Context, id string, condition containertypes.WaitCondition) (<-chan containertypes.WaitResponse, <-chan error) {
+	statusCh := make(chan containertypes.WaitResponse, 1)
+	go func() {
+		<-f.stopped
+		statusCh <- containertypes.WaitResponse{StatusCode: 0}
+	}()
+	return statusCh, make(chan error)
+}
+
+func (f *fakeDockerClient) ContainerLogs(ctx context.Context, id string, opts containertypes.LogsOptions) (io.ReadCloser, error) {
+	var buf bytes.Buffer
+	stdout := stdcopy.NewStdWriter(&buf, stdcopy.Stdout)
+	stderr := stdcopy.NewStdWriter(&buf, stdcopy.Stderr)
+	for i, line := range f.logLines {
+		target := stdout
+		if i%2 == 1 {
+			target = stderr
+		}
+		_, _ = io.WriteString(target, line+"\n")
+	}
+	return io.NopCloser(&buf), nil
+}
+
+func (f *fakeDockerClient) Close() error { return nil }
+
+func TestDockerLogsReachWriterAndRingBuffer(t *testing.T) {
+	lines := []string{"booting chunk server", "listening on :19000", "generated chunk 0,0"}
+	fake := newFakeDockerClient(lines...)
+
+	cfg := &config.Config{
+		ChunkServers: []config.ChunkServer{{ID: "chunk-a", ContainerImage: "chunk-server:test"}},
+	}
+	mgr := &Manager{
+		cfg:       cfg,
+		mode:      runtimeDocker,
+		processes: make(map[string]*process),
+		restart:   restartPolicy{},
+		stopping:  make(chan struct{}),
+		docker:    &dockerRuntime{client: fake},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := mgr.StartAll(ctx); err != nil {
+		t.Fatalf("StartAll() error = %v", err)
+	}
+	t.Cleanup(mgr.Shutdown)
+
+	var out bytes.Buffer
+	if err := mgr.Logs("chunk-a", &out); err != nil {
+		t.Fatalf("Logs() error = %v", err)
+	}
+	if got := strings.Split(strings.TrimSpace(out.String()), "\n"); strings.Join(got, "|") != strings.Join(lines, "|") {
+		t.Fatalf("streamed logs = %q, want %q", got, lines)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		recent := mgr.Processes()[0].RecentLogs
+		if strings.Join(recent, "|") == strings.Join(lines, "|") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("RecentLogs = %q, want %q", recent, lines)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := mgr.Logs("missing", &out); err == nil {
+		t.Fatalf("expected an error for an unknown chunk server")
+	}
+}
+
+func TestLogRingKeepsMostRecentLines(t *testing.T) {
+	ring := newLogRing(3)
+	_, _ = io.WriteString(ring, "one\ntwo\nthr")
+	_, _ = io.WriteString(ring, "ee\nfour\n")
+	if got := strings.Join(ring.Lines(), ","); got != "two,three,four" {
+		t.Fatalf("Lines() = %q, want two,three,four", got)
+	}
+}
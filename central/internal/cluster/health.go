@@ -0,0 +1,71 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"central/internal/config"
+)
+
+const (
+	healthPath         = "/healthz"
+	healthProbeTimeout = 2 * time.Second
+)
+
+// healthProbe reports whether a chunk server is ready to serve traffic. A nil
+// error means the server answered its health check.
+type healthProbe func(ctx context.Context, cs config.ChunkServer) error
+
+// httpHealthProbe queries the chunk server's HTTP health endpoint. Servers
+// without an http_address have nothing to probe and are treated as healthy.
+func httpHealthProbe(client *http.Client) healthProbe {
+	if client == nil {
+		client = &http.Client{Timeout: healthProbeTimeout}
+	}
+	return func(ctx context.Context, cs config.ChunkServer) error {
+		if cs.HttpAddress == "" {
+			return nil
+		}
+		base := strings.TrimRight(cs.HttpAddress, "/")
+		if !strings.Contains(base, "://") {
+			base = "http://" + base
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+healthPath, nil)
+		if err != nil {
+			return fmt.Errorf("build health request: %w", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("health check: %w", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("health check returned %s", resp.Status)
+		}
+		return nil
+	}
+}
+
+// setProbeResult folds a health probe outcome into the process status. A
+// process stays pending until its first successful probe; once running, a
+// failed probe marks it unhealthy until the server recovers.
+func (p *process) setProbeResult(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err == nil {
+		p.status = "running"
+		p.lastError = ""
+		p.stoppedAt = nil
+		return
+	}
+	p.lastError = err.Error()
+	switch p.status {
+	case "running", "unhealthy":
+		p.status = "unhealthy"
+	default:
+		p.status = "pending"
+	}
+}
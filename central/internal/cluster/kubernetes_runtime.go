@@ -21,8 +21,10 @@ import (
 )
 
 type kubernetesRuntime struct {
-	clientset *kubernetes.Clientset
-	namespace string
+	clientset    kubernetes.Interface
+	namespace    string
+	probe        healthProbe
+	pollInterval time.Duration
 }
 
 func newKubernetesRuntime() (*kubernetesRuntime, error) {
@@ -53,7 +55,12 @@ func newKubernetesRuntime() (*kubernetesRuntime, error) {
 		namespace = "default"
 	}
 
-	return &kubernetesRuntime{clientset: clientset, namespace: namespace}, nil
+	return &kubernetesRuntime{
+		clientset:    clientset,
+		namespace:    namespace,
+		probe:        httpHealthProbe(nil),
+		pollInterval: 2 * time.Second,
+	}, nil
 }
 
 func (r *kubernetesRuntime) start(ctx context.Context, cfg *config.Config, cs config.ChunkServer) (*process, error) {
@@ -107,7 +114,7 @@ func (r *kubernetesRuntime) start(ctx context.Context, cfg *config.Config, cs co
 	watchCtx, cancel := context.WithCancel(context.Background())
 	proc.cancelWatch = cancel
 
-	go r.monitorPod(watchCtx, proc, pod.Name)
+	go r.monitorPod(watchCtx, proc, cs)
 
 	proc.stopFn = func(stopCtx context.Context) error {
 		grace := int64(10)
@@ -115,14 +122,25 @@ func (r *kubernetesRuntime) start(ctx context.Context, cfg *config.Config, cs co
 		if err != nil && !k8serrors.IsNotFound(err) {
 			return err
 		}
+		// The pod monitor is cancelled before stopFn runs, so record the
+		// final status here rather than waiting for it.
+		proc.setFinalStatus("stopped", nil)
 		return nil
 	}
 
 	return proc, nil
 }
 
-func (r *kubernetesRuntime) monitorPod(ctx context.Context, proc *process, podName string) {
-	ticker := time.NewTicker(2 * time.Second)
+// monitorPod follows the pod phase and, once the pod is running, gates the
+// process status on the chunk server's health probe: the process reports
+// pending until the first probe succeeds and unhealthy when a later probe fails.
+func (r *kubernetesRuntime) monitorPod(ctx context.Context, proc *process, cs config.ChunkServer) {
+	podName := cs.ID
+	interval := r.pollInterval
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
@@ -144,7 +162,7 @@ func (r *kubernetesRuntime) monitorPod(ctx context.Context, proc *process, podNa
 			case corev1.PodPending:
 				proc.setActiveStatus("pending")
 			case corev1.PodRunning:
-				proc.setActiveStatus("running")
+				proc.setProbeResult(r.probeHealth(ctx, cs))
 			case corev1.PodSucceeded:
 				proc.setFinalStatus("exited", nil)
 				return
@@ -156,6 +174,15 @@ func (r *kubernetesRuntime) monitorPod(ctx context.Context, proc *process, podNa
 	}
 }
 
+func (r *kubernetesRuntime) probeHealth(ctx context.Context, cs config.ChunkServer) error {
+	if r.probe == nil {
+		return nil
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+	defer cancel()
+	return r.probe(probeCtx, cs)
+}
+
 func (r *kubernetesRuntime) replacePod(ctx context.Context, podClient typedcorev1.PodInterface, pod *corev1.Pod) error {
 	if err := podClient.Delete(ctx, pod.Name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
 		return fmt.Errorf("delete existing pod: %w", err)
@@ -0,0 +1,118 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"central/internal/config"
+)
+
+func TestKubernetesRuntimeWaitsForHealthyProbe(t *testing.T) {
+	var healthy atomic.Bool
+	clientset := fake.NewSimpleClientset()
+	runtime := &kubernetesRuntime{
+		clientset: clientset,
+		namespace: "default",
+		probe: func(ctx context.Context, cs config.ChunkServer) error {
+			if healthy.Load() {
+				return nil
+			}
+			return errors.New("not ready")
+		},
+		pollInterval: 5 * time.Millisecond,
+	}
+
+	cfg := &config.Config{}
+	cs := config.ChunkServer{ID: "chunk-a", ContainerImage: "chunk-server:test"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	proc, err := runtime.start(ctx, cfg, cs)
+	if err != nil {
+		t.Fatalf("start() error = %v", err)
+	}
+	t.Cleanup(func() { proc.stop(context.Background()) })
+
+	if got := proc.info().Status; got != "pending" {
+		t.Fatalf("status after start = %q, want pending", got)
+	}
+
+	pods := clientset.CoreV1().Pods("default")
+	pod, err := pods.Get(ctx, cs.ID, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get pod: %v", err)
+	}
+	pod.Status.Phase = corev1.PodRunning
+	if _, err := pods.UpdateStatus(ctx, pod, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update pod status: %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	info := proc.info()
+	if info.Status != "pending" {
+		t.Fatalf("status with failing probe = %q, want pending", info.Status)
+	}
+	if info.LastError == "" {
+		t.Fatalf("expected probe failure to be recorded in last_error")
+	}
+
+	healthy.Store(true)
+	waitForStatus(t, proc, "running")
+
+	healthy.Store(false)
+	waitForStatus(t, proc, "unhealthy")
+
+	healthy.Store(true)
+	waitForStatus(t, proc, "running")
+}
+
+func TestHTTPHealthProbe(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != healthPath {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer srv.Close()
+
+	probe := httpHealthProbe(srv.Client())
+	cs := config.ChunkServer{ID: "chunk-a", HttpAddress: srv.URL}
+
+	if err := probe(context.Background(), cs); err == nil {
+		t.Fatalf("expected probe to fail while the server reports unavailable")
+	}
+	status.Store(http.StatusOK)
+	if err := probe(context.Background(), cs); err != nil {
+		t.Fatalf("probe() error = %v", err)
+	}
+	if err := probe(context.Background(), config.ChunkServer{ID: "no-http"}); err != nil {
+		t.Fatalf("expected servers without http_address to pass, got %v", err)
+	}
+}
+
+func waitForStatus(t *testing.T, proc *process, want string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if got := proc.info().Status; got == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("status = %q, want %q", proc.info().Status, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,64 @@
+package cluster
+
+import (
+	"bytes"
+	"sync"
+)
+
+const defaultLogLines = 200
+
+// logRing is an io.Writer that keeps the most recent complete lines written to
+// it. A trailing partial line is held until its newline arrives.
+type logRing struct {
+	mu      sync.Mutex
+	lines   []string
+	next    int
+	full    bool
+	partial []byte
+}
+
+func newLogRing(size int) *logRing {
+	if size <= 0 {
+		size = defaultLogLines
+	}
+	return &logRing{lines: make([]string, size)}
+}
+
+func (r *logRing) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data := p
+	for len(data) > 0 {
+		idx := bytes.IndexByte(data, '\n')
+		if idx < 0 {
+			r.partial = append(r.partial, data...)
+			break
+		}
+		line := append(r.partial, data[:idx]...)
+		r.partial = r.partial[:0]
+		r.push(string(bytes.TrimRight(line, "\r")))
+		data = data[idx+1:]
+	}
+	return len(p), nil
+}
+
+func (r *logRing) push(line string) {
+	r.lines[r.next] = line
+	r.next++
+	if r.next == len(r.lines) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// Lines returns the buffered lines, oldest first.
+func (r *logRing) Lines() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]string(nil), r.lines[:r.next]...)
+	}
+	out := make([]string, 0, len(r.lines))
+	out = append(out, r.lines[r.next:]...)
+	return append(out, r.lines[:r.next]...)
+}
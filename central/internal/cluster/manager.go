@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"sync"
@@ -31,6 +32,17 @@ type Manager struct {
 	mu        sync.RWMutex
 	processes map[string]*process
 
+	restart     restartPolicy
+	runCtx      context.Context
+	closing     bool
+	stopping    chan struct{}
+	supervisors sync.WaitGroup
+
+	probe          healthProbe
+	healthInterval time.Duration
+	// startFn replaces the runtime-specific process start when set.
+	startFn func(context.Context, config.ChunkServer) (*process, error)
+
 	docker *dockerRuntime
 	kube   *kubernetesRuntime
 }
@@ -43,6 +55,8 @@ type ProcessInfo struct {
 	LastError     string     `json:"last_error,omitempty"`
 	ListenAddress string     `json:"listen_address"`
 	HttpAddress   string     `json:"http_address"`
+	Restarts      int        `json:"restarts"`
+	RecentLogs    []string   `json:"recent_logs,omitempty"`
 }
 
 type process struct {
@@ -52,6 +66,11 @@ type process struct {
 	stoppedAt *time.Time
 	status    string
 	lastError string
+	restarts  int
+	retired   bool
+
+	containerID string
+	logs        *logRing
 
 	mu sync.RWMutex
 
@@ -67,6 +86,9 @@ func New(cfg *config.Config) (*Manager, error) {
 		cfg:       cfg,
 		mode:      mode,
 		processes: make(map[string]*process),
+		restart:   defaultRestartPolicy(),
+		probe:     httpHealthProbe(nil),
+		stopping:  make(chan struct{}),
 	}
 
 	switch mode {
@@ -91,6 +113,13 @@ func (m *Manager) StartAll(ctx context.Context) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if m.closing {
+		return errors.New("cluster manager is shut down")
+	}
+	if m.runCtx == nil {
+		m.runCtx = ctx
+	}
+
 	var errs []error
 	for _, cs := range m.cfg.ChunkServers {
 		if _, exists := m.processes[cs.ID]; exists {
@@ -102,11 +131,16 @@ func (m *Manager) StartAll(ctx context.Context) error {
 			continue
 		}
 		m.processes[cs.ID] = proc
+		m.supervisors.Add(1)
+		go m.supervise(ctx, cs, proc)
 	}
 	return errors.Join(errs...)
 }
 
 func (m *Manager) startProcess(ctx context.Context, cs config.ChunkServer) (*process, error) {
+	if m.startFn != nil {
+		return m.startFn(ctx, cs)
+	}
 	switch m.mode {
 	case runtimeDocker:
 		if m.docker == nil {
@@ -174,12 +208,16 @@ func (m *Manager) startLocalProcess(ctx context.Context, cs config.ChunkServer)
 }
 
 func (m *Manager) Shutdown() {
-	m.mu.RLock()
+	m.mu.Lock()
+	if !m.closing {
+		m.closing = true
+		close(m.stopping)
+	}
 	processes := make([]*process, 0, len(m.processes))
 	for _, proc := range m.processes {
 		processes = append(processes, proc)
 	}
-	m.mu.RUnlock()
+	m.mu.Unlock()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -187,6 +225,7 @@ func (m *Manager) Shutdown() {
 	for _, proc := range processes {
 		proc.stop(ctx)
 	}
+	m.supervisors.Wait()
 
 	switch m.mode {
 	case runtimeDocker:
@@ -210,6 +249,41 @@ func (m *Manager) Processes() []ProcessInfo {
 	return out
 }
 
+// Logs writes a chunk server's output to w. Docker containers are streamed live
+// until the container stops or the manager shuts down; other runtimes write the
+// recently captured lines, if any.
+func (m *Manager) Logs(id string, w io.Writer) error {
+	m.mu.RLock()
+	proc, ok := m.processes[id]
+	m.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("unknown chunk server %q", id)
+	}
+
+	if m.mode == runtimeDocker && m.docker != nil && proc.containerID != "" {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-m.stopping:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		return m.docker.streamLogs(ctx, proc.containerID, w)
+	}
+
+	if proc.logs == nil {
+		return nil
+	}
+	for _, line := range proc.logs.Lines() {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (p *process) stop(ctx context.Context) {
 	p.mu.RLock()
 	stopFn := p.stopFn
@@ -243,6 +317,10 @@ func (p *process) info() ProcessInfo {
 		ListenAddress: p.cfg.ListenAddress,
 		HttpAddress:   p.cfg.HttpAddress,
 		LastError:     p.lastError,
+		Restarts:      p.restarts,
+	}
+	if p.logs != nil {
+		info.RecentLogs = p.logs.Lines()
 	}
 	if p.stoppedAt != nil {
 		stopped := *p.stoppedAt
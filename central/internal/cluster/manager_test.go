@@ -111,6 +111,7 @@ func TestStartAllProvidesConfigPayload(t *testing.T) {
 					"OUTPUT_FILE": outputPath,
 				},
 				ListenAddress: "127.0.0.1:9000",
+				HttpAddress:   "http://127.0.0.1:9001",
 				ChunkSpan: config.ChunkSpan{
 					ChunksX: 16,
 					ChunksY: 16,
@@ -169,6 +170,9 @@ func TestStartAllProvidesConfigPayload(t *testing.T) {
 	if jsonCfg.Network.ListenUDP != "127.0.0.1:9000" {
 		t.Fatalf("json payload listen = %q, want %q", jsonCfg.Network.ListenUDP, "127.0.0.1:9000")
 	}
+	if jsonCfg.Network.ListenHTTP != "127.0.0.1:9001" {
+		t.Fatalf("json payload http listen = %q, want %q", jsonCfg.Network.ListenHTTP, "127.0.0.1:9001")
+	}
 	if jsonCfg.Chunk.Width != 32 || jsonCfg.Chunk.Depth != 48 || jsonCfg.Chunk.Height != 1024 {
 		t.Fatalf("json payload chunk dims mismatch: %#v", jsonCfg.Chunk)
 	}
@@ -0,0 +1,148 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"central/internal/config"
+)
+
+const defaultHealthPollInterval = 500 * time.Millisecond
+
+// RollingRestart replaces the chunk servers a batch at a time, waiting for every
+// replacement in a batch to pass its health check before moving to the next, so
+// at most cluster.rolling_batch_size servers are down at once. The rollout stops
+// at the first batch that fails to come back healthy.
+func (m *Manager) RollingRestart(ctx context.Context) error {
+	m.mu.RLock()
+	runCtx := m.runCtx
+	closing := m.closing
+	servers := append([]config.ChunkServer(nil), m.cfg.ChunkServers...)
+	batch := m.cfg.Cluster.RollingBatchSize
+	m.mu.RUnlock()
+
+	if closing {
+		return errors.New("cluster manager is shut down")
+	}
+	if runCtx == nil {
+		return errors.New("cluster has not been started")
+	}
+	if batch <= 0 {
+		batch = 1
+	}
+
+	for start := 0; start < len(servers); start += batch {
+		end := start + batch
+		if end > len(servers) {
+			end = len(servers)
+		}
+		var (
+			wg   sync.WaitGroup
+			mu   sync.Mutex
+			errs []error
+		)
+		for _, cs := range servers[start:end] {
+			wg.Add(1)
+			go func(cs config.ChunkServer) {
+				defer wg.Done()
+				if err := m.restartServer(ctx, runCtx, cs); err != nil {
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("chunk server %s: %w", cs.ID, err))
+					mu.Unlock()
+				}
+			}(cs)
+		}
+		wg.Wait()
+		if err := errors.Join(errs...); err != nil {
+			return fmt.Errorf("rolling restart: %w", err)
+		}
+	}
+	return nil
+}
+
+// restartServer stops the current process for cs, starts its replacement under
+// the manager's run context, and waits for the replacement to become healthy.
+func (m *Manager) restartServer(ctx, runCtx context.Context, cs config.ChunkServer) error {
+	m.mu.RLock()
+	old := m.processes[cs.ID]
+	m.mu.RUnlock()
+
+	if old != nil {
+		old.retire()
+		old.stop(ctx)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	next, err := m.startProcess(runCtx, cs)
+	if err != nil {
+		return fmt.Errorf("start replacement: %w", err)
+	}
+
+	m.mu.Lock()
+	if m.closing {
+		m.mu.Unlock()
+		stopCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		next.stop(stopCtx)
+		cancel()
+		return errors.New("cluster manager is shut down")
+	}
+	m.processes[cs.ID] = next
+	m.supervisors.Add(1)
+	go m.supervise(runCtx, cs, next)
+	m.mu.Unlock()
+
+	return m.waitHealthy(ctx, cs, next)
+}
+
+// waitHealthy polls until proc reports running and the chunk server answers its
+// health probe. It fails if the process exits first or ctx is cancelled.
+func (m *Manager) waitHealthy(ctx context.Context, cs config.ChunkServer, proc *process) error {
+	interval := m.healthInterval
+	if interval <= 0 {
+		interval = defaultHealthPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if proc.info().Status == "running" && m.checkHealth(ctx, cs) == nil {
+			return nil
+		}
+		select {
+		case <-proc.doneCh:
+			info := proc.info()
+			return fmt.Errorf("exited before becoming healthy (%s): %s", info.Status, info.LastError)
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Manager) checkHealth(ctx context.Context, cs config.ChunkServer) error {
+	if m.probe == nil {
+		return nil
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+	defer cancel()
+	return m.probe(probeCtx, cs)
+}
+
+// retire marks a process as deliberately replaced so its supervisor lets it go
+// instead of restarting it.
+func (p *process) retire() {
+	p.mu.Lock()
+	p.retired = true
+	p.mu.Unlock()
+}
+
+func (p *process) isRetired() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.retired
+}
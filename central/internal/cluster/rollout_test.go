@@ -0,0 +1,123 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"central/internal/config"
+)
+
+// fakeFleet starts in-memory processes and tracks how many are up at once.
+type fakeFleet struct {
+	mu      sync.Mutex
+	up      map[string]bool
+	starts  map[string]int
+	minUp   int
+	healthy map[string]bool
+}
+
+func newFakeFleet() *fakeFleet {
+	return &fakeFleet{up: make(map[string]bool), starts: make(map[string]int), healthy: make(map[string]bool)}
+}
+
+func (f *fakeFleet) start(ctx context.Context, cs config.ChunkServer) (*process, error) {
+	f.mu.Lock()
+	f.up[cs.ID] = true
+	f.starts[cs.ID]++
+	f.mu.Unlock()
+
+	proc := newProcess(cs)
+	proc.setActiveStatus("running")
+	proc.stopFn = func(context.Context) error {
+		f.mu.Lock()
+		f.up[cs.ID] = false
+		f.healthy[cs.ID] = false
+		if n := f.countUp(); n < f.minUp {
+			f.minUp = n
+		}
+		f.mu.Unlock()
+		proc.setFinalStatus("stopped", nil)
+		return nil
+	}
+
+	// Replacements report healthy shortly after starting.
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		f.mu.Lock()
+		f.healthy[cs.ID] = f.up[cs.ID]
+		f.mu.Unlock()
+	}()
+	return proc, nil
+}
+
+func (f *fakeFleet) probe(ctx context.Context, cs config.ChunkServer) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.healthy[cs.ID] {
+		return fmt.Errorf("%s not healthy", cs.ID)
+	}
+	return nil
+}
+
+func (f *fakeFleet) countUp() int {
+	n := 0
+	for _, up := range f.up {
+		if up {
+			n++
+		}
+	}
+	return n
+}
+
+func TestRollingRestartBoundsConcurrentDowntime(t *testing.T) {
+	const servers, batch = 5, 2
+	cfg := &config.Config{Cluster: config.ClusterConfig{RollingBatchSize: batch}}
+	for i := 0; i < servers; i++ {
+		cfg.ChunkServers = append(cfg.ChunkServers, config.ChunkServer{ID: fmt.Sprintf("server-%d", i)})
+	}
+
+	fleet := newFakeFleet()
+	fleet.minUp = servers
+	t.Setenv("CENTRAL_CLUSTER_MODE", "local")
+	mgr, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	mgr.restart = restartPolicy{}
+	mgr.probe = fleet.probe
+	mgr.healthInterval = 2 * time.Millisecond
+	mgr.startFn = fleet.start
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := mgr.StartAll(ctx); err != nil {
+		t.Fatalf("StartAll() error = %v", err)
+	}
+	t.Cleanup(mgr.Shutdown)
+
+	if err := mgr.RollingRestart(ctx); err != nil {
+		t.Fatalf("RollingRestart() error = %v", err)
+	}
+
+	fleet.mu.Lock()
+	defer fleet.mu.Unlock()
+	if down := servers - fleet.minUp; down > batch {
+		t.Fatalf("%d servers were down at once, want at most %d", down, batch)
+	}
+	for _, cs := range cfg.ChunkServers {
+		if fleet.starts[cs.ID] != 2 {
+			t.Fatalf("%s started %d times, want 2", cs.ID, fleet.starts[cs.ID])
+		}
+		if !fleet.up[cs.ID] {
+			t.Fatalf("%s is not running after the rollout", cs.ID)
+		}
+	}
+	for _, info := range mgr.Processes() {
+		if info.Status != "running" || info.Restarts != 0 {
+			t.Fatalf("unexpected process state after rollout: %+v", info)
+		}
+	}
+}
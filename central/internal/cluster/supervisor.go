@@ -0,0 +1,155 @@
+package cluster
+
+import (
+	"context"
+	"time"
+
+	"central/internal/config"
+)
+
+// restartPolicy bounds how the manager restarts chunk servers that stop on
+// their own.
+type restartPolicy struct {
+	// MaxRestarts caps consecutive restarts per chunk server. Zero disables
+	// restarts. A server that stays up for MaxBackoff before stopping again
+	// has its count reset, so only crash loops use up the cap.
+	MaxRestarts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+func defaultRestartPolicy() restartPolicy {
+	return restartPolicy{
+		MaxRestarts:    5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+	}
+}
+
+// backoff returns the delay before restart number attempt+1, doubling from
+// InitialBackoff up to MaxBackoff.
+func (p restartPolicy) backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 0; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay
+}
+
+// stable reports whether a server that ran for uptime before stopping counts
+// as having recovered, starting its restarts afresh.
+func (p restartPolicy) stable(uptime time.Duration) bool {
+	return p.MaxBackoff > 0 && uptime >= p.MaxBackoff
+}
+
+// supervise waits for proc to finish and restarts its chunk server with
+// exponential backoff until the restart cap is reached. It gives up as soon as
+// ctx is cancelled or the manager begins shutting down, so it never races
+// Shutdown for the process table.
+func (m *Manager) supervise(ctx context.Context, cs config.ChunkServer, proc *process) {
+	defer m.supervisors.Done()
+	for {
+		select {
+		case <-proc.doneCh:
+		case <-ctx.Done():
+			return
+		case <-m.stopping:
+			return
+		}
+		// Stability is judged once per exit: failed starts below leave proc
+		// dead with a fixed uptime, and must not earn the budget back.
+		if m.restart.stable(proc.uptime()) {
+			proc.setRestartCount(0)
+		}
+		next, ok := m.restartProcess(ctx, cs, proc)
+		if !ok {
+			return
+		}
+		next.setRestartCount(proc.restartCount() + 1)
+
+		m.mu.Lock()
+		if m.closing || m.processes[cs.ID] != proc {
+			m.mu.Unlock()
+			stopCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			next.stop(stopCtx)
+			cancel()
+			return
+		}
+		m.processes[cs.ID] = next
+		m.mu.Unlock()
+		proc = next
+	}
+}
+
+// restartProcess starts a replacement for the exited proc, waiting out the
+// backoff before each attempt. Every attempt, failed or not, counts against
+// MaxRestarts. It gives up once the budget is spent or the manager stops.
+func (m *Manager) restartProcess(ctx context.Context, cs config.ChunkServer, proc *process) (*process, bool) {
+	for {
+		if m.isClosing() || proc.isRetired() {
+			return nil, false
+		}
+		restarts := proc.restartCount()
+		if restarts >= m.restart.MaxRestarts {
+			return nil, false
+		}
+
+		timer := time.NewTimer(m.restart.backoff(restarts))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, false
+		case <-m.stopping:
+			timer.Stop()
+			return nil, false
+		}
+
+		next, err := m.startProcess(ctx, cs)
+		if err != nil {
+			proc.recordRestartFailure(err)
+			continue
+		}
+		return next, true
+	}
+}
+
+func (m *Manager) isClosing() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.closing
+}
+
+func (p *process) restartCount() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.restarts
+}
+
+// uptime returns how long the process ran, up to now if it is still running.
+func (p *process) uptime() time.Duration {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.stoppedAt != nil {
+		return p.stoppedAt.Sub(p.startedAt)
+	}
+	return time.Since(p.startedAt)
+}
+
+func (p *process) setRestartCount(n int) {
+	p.mu.Lock()
+	p.restarts = n
+	p.mu.Unlock()
+}
+
+// recordRestartFailure counts a restart attempt that could not start the
+// server, so failed attempts still use up the restart budget.
+func (p *process) recordRestartFailure(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.restarts++
+	p.lastError = err.Error()
+}
@@ -0,0 +1,174 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"central/internal/config"
+)
+
+func TestSupervisorRestartsCrashedServerUpToCap(t *testing.T) {
+	t.Setenv("CENTRAL_CLUSTER_MODE", "local")
+
+	runsPath := filepath.Join(t.TempDir(), "runs.txt")
+	cfg := &config.Config{
+		ChunkServers: []config.ChunkServer{
+			{
+				ID:         "server-1",
+				Executable: "/bin/sh",
+				Args:       []string{"-c", "echo run >> \"$RUNS_FILE\"; exit 3"},
+				Env:        map[string]string{"RUNS_FILE": runsPath},
+			},
+		},
+	}
+
+	mgr, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	mgr.restart = restartPolicy{MaxRestarts: 3, InitialBackoff: 5 * time.Millisecond, MaxBackoff: 20 * time.Millisecond}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := mgr.StartAll(ctx); err != nil {
+		t.Fatalf("StartAll() error = %v", err)
+	}
+	t.Cleanup(mgr.Shutdown)
+
+	deadline := time.Now().Add(5 * time.Second)
+	var info ProcessInfo
+	for {
+		info = mgr.Processes()[0]
+		if info.Restarts == 3 && info.Status == "stopped" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("process did not reach restart cap: %+v", info)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if again := mgr.Processes()[0]; again.Restarts != 3 || again.Status != "stopped" {
+		t.Fatalf("process restarted past the cap: %+v", again)
+	}
+
+	data, err := os.ReadFile(runsPath)
+	if err != nil {
+		t.Fatalf("read runs file: %v", err)
+	}
+	if runs := strings.Count(string(data), "run"); runs != 4 {
+		t.Fatalf("server ran %d times, want 4 (initial start plus 3 restarts)", runs)
+	}
+}
+
+func TestSupervisorResetsRestartsAfterStableRun(t *testing.T) {
+	t.Setenv("CENTRAL_CLUSTER_MODE", "local")
+
+	runsPath := filepath.Join(t.TempDir(), "runs.txt")
+	cfg := &config.Config{
+		ChunkServers: []config.ChunkServer{
+			{
+				ID:         "server-1",
+				Executable: "/bin/sh",
+				Args:       []string{"-c", "echo run >> \"$RUNS_FILE\"; sleep 0.1; exit 3"},
+				Env:        map[string]string{"RUNS_FILE": runsPath},
+			},
+		},
+	}
+
+	mgr, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	// Every run outlasts MaxBackoff, so the single restart allowed is
+	// granted again after each one.
+	mgr.restart = restartPolicy{MaxRestarts: 1, InitialBackoff: 5 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := mgr.StartAll(ctx); err != nil {
+		t.Fatalf("StartAll() error = %v", err)
+	}
+	t.Cleanup(mgr.Shutdown)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, _ := os.ReadFile(runsPath)
+		if strings.Count(string(data), "run") >= 4 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server stopped being restarted after %d runs: %+v", strings.Count(string(data), "run"), mgr.Processes()[0])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSupervisorCountsFailedStartsAfterStableRun(t *testing.T) {
+	t.Setenv("CENTRAL_CLUSTER_MODE", "local")
+
+	cfg := &config.Config{
+		ChunkServers: []config.ChunkServer{
+			{ID: "server-1", Executable: "/bin/sh", Args: []string{"-c", "sleep 0.1; exit 3"}},
+		},
+	}
+	mgr, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	mgr.restart = restartPolicy{MaxRestarts: 3, InitialBackoff: 5 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+
+	// The first run outlasts MaxBackoff; every start after it fails.
+	var starts atomic.Int32
+	mgr.startFn = func(ctx context.Context, cs config.ChunkServer) (*process, error) {
+		if starts.Add(1) == 1 {
+			return mgr.startLocalProcess(ctx, cs)
+		}
+		return nil, errors.New("image pull failed")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := mgr.StartAll(ctx); err != nil {
+		t.Fatalf("StartAll() error = %v", err)
+	}
+	t.Cleanup(mgr.Shutdown)
+
+	deadline := time.Now().Add(5 * time.Second)
+	var info ProcessInfo
+	for {
+		info = mgr.Processes()[0]
+		if info.Restarts == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("failed starts did not use up the restart budget: %+v after %d starts", info, starts.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	if got := starts.Load(); got != 4 {
+		t.Fatalf("started %d times, want 4 (initial start plus 3 failed restarts)", got)
+	}
+	if again := mgr.Processes()[0]; again.Restarts != 3 || again.LastError != "image pull failed" {
+		t.Fatalf("expected the budget spent on failed starts, got %+v", again)
+	}
+}
+
+func TestRestartPolicyBackoffDoublesUpToMax(t *testing.T) {
+	policy := restartPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for attempt, expected := range want {
+		if got := policy.backoff(attempt); got != expected {
+			t.Fatalf("backoff(%d) = %s, want %s", attempt, got, expected)
+		}
+	}
+}
@@ -21,6 +21,9 @@ type ClusterConfig struct {
 	DefaultBinary string            `yaml:"default_binary"`
 	DataRoot      string            `yaml:"data_root"`
 	Env           map[string]string `yaml:"env"`
+	// RollingBatchSize bounds how many chunk servers a rolling restart takes
+	// down at once. Defaults to 1.
+	RollingBatchSize int `yaml:"rolling_batch_size"`
 }
 
 type ChunkServer struct {
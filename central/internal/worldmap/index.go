@@ -47,8 +47,8 @@ func (idx *Index) LoadFromConfig(cfg *config.Config) {
 }
 
 func (idx *Index) Lookup(blockX, blockY int, chunkWidth, chunkDepth int) (ServerInfo, error) {
-	chunkX := blockX / chunkWidth
-	chunkY := blockY / chunkDepth
+	chunkX := floorDiv(blockX, chunkWidth)
+	chunkY := floorDiv(blockY, chunkDepth)
 
 	idx.mu.RLock()
 	defer idx.mu.RUnlock()
@@ -72,3 +72,16 @@ func (idx *Index) Servers() []ServerInfo {
 	copy(out, idx.entries)
 	return out
 }
+
+// floorDiv maps a block coordinate onto its chunk, rounding towards negative
+// infinity so blocks left of the origin land in negative chunks. It matches
+// world.FloorDiv on the chunk servers.
+func floorDiv(value, size int) int {
+	if size <= 0 {
+		return 0
+	}
+	if value >= 0 {
+		return value / size
+	}
+	return -((-value - 1) / size) - 1
+}
@@ -60,6 +60,47 @@ func TestLookupFindsMatchingServer(t *testing.T) {
 	}
 }
 
+func TestLookupFloorsNegativeBlocks(t *testing.T) {
+	cfg := &config.Config{
+		ChunkServers: []config.ChunkServer{
+			{
+				ID:           "west",
+				GlobalOrigin: config.ChunkOrigin{ChunkX: -1, ChunkY: 0},
+				ChunkSpan:    config.ChunkSpan{ChunksX: 1, ChunksY: 1},
+			},
+			{
+				ID:           "east",
+				GlobalOrigin: config.ChunkOrigin{ChunkX: 0, ChunkY: 0},
+				ChunkSpan:    config.ChunkSpan{ChunksX: 1, ChunksY: 1},
+			},
+		},
+	}
+	idx := NewIndex()
+	idx.LoadFromConfig(cfg)
+
+	cases := []struct {
+		blockX int
+		want   string
+	}{
+		{blockX: -1, want: "west"},
+		{blockX: -16, want: "west"},
+		{blockX: 0, want: "east"},
+		{blockX: 15, want: "east"},
+	}
+	for _, tc := range cases {
+		server, err := idx.Lookup(tc.blockX, 3, 16, 16)
+		if err != nil {
+			t.Fatalf("Lookup(%d) returned error: %v", tc.blockX, err)
+		}
+		if server.ID != tc.want {
+			t.Fatalf("Lookup(%d) returned server %q, want %q", tc.blockX, server.ID, tc.want)
+		}
+	}
+	if _, err := idx.Lookup(-17, 3, 16, 16); err == nil {
+		t.Fatalf("Lookup(-17) = nil, want error")
+	}
+}
+
 func TestLookupNoMatchReturnsError(t *testing.T) {
 	idx := NewIndex()
 	idx.LoadFromConfig(&config.Config{})
@@ -16,8 +16,21 @@ import (
 func main() {
 	var cfgPath string
 	flag.StringVar(&cfgPath, "config", "", "path to chunk server configuration file")
+	validate := flag.Bool("validate", false, "validate the configuration and exit without starting the server")
+	generate := flag.Bool("generate", false, "with -validate, also generate one chunk to check the terrain settings")
+	warmup := flag.Bool("warmup", false, "pre-generate and persist the region before serving")
+	warmupOnly := flag.Bool("warmup-only", false, "like -warmup, but exit once the region is persisted")
+	warmupBox := flag.String("warmup-box", "", "limit warmup to global chunks minX,minY,maxX,maxY")
 	flag.Parse()
 
+	if *validate {
+		os.Exit(validateConfig(os.Stdout, os.Stderr, cfgPath, *generate))
+	}
+	warmFrom, warmTo, err := parseWarmupBox(*warmupBox)
+	if err != nil {
+		log.Fatalf("parse flags: %v", err)
+	}
+
 	wroteConfig, err := writeConfigFromCentral(cfgPath)
 	if err != nil {
 		log.Fatalf("synchronise config from central: %v", err)
@@ -36,15 +49,30 @@ func main() {
 		log.Fatalf("initialise chunk server: %v", err)
 	}
 
-	ctx, cancel := signalContext()
+	ctx, cancel := signalContext(cfg.Server.DrainTimeout.Duration() + shutdownGrace)
 	defer cancel()
 
+	if *warmup || *warmupOnly {
+		if err := srv.Warmup(ctx, warmFrom, warmTo); err != nil {
+			log.Fatalf("warm up region: %v", err)
+		}
+		if *warmupOnly {
+			return
+		}
+	}
+
+	watchReloads(ctx, srv, cfgPath)
+
 	if err := srv.Run(ctx); err != nil {
 		log.Fatalf("server exited with error: %v", err)
 	}
 }
 
-func signalContext() (context.Context, context.CancelFunc) {
+// shutdownGrace is how long past the drain timeout the process may take to exit
+// before it is killed.
+const shutdownGrace = 2 * time.Second
+
+func signalContext(forceAfter time.Duration) (context.Context, context.CancelFunc) {
 	ctx, cancel := context.WithCancel(context.Background())
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
@@ -58,7 +86,7 @@ func signalContext() (context.Context, context.CancelFunc) {
 		}
 
 		// Ensure the process terminates if shutdown stalls.
-		time.AfterFunc(10*time.Second, func() {
+		time.AfterFunc(forceAfter, func() {
 			log.Printf("forced shutdown after timeout")
 			os.Exit(1)
 		})
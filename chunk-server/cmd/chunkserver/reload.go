@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"chunkserver/internal/config"
+)
+
+type reloader interface {
+	Reload(cfg *config.Config) error
+}
+
+// watchReloads re-reads cfgPath whenever the process receives SIGHUP and hands
+// the result to srv. Reload failures are logged and the running configuration
+// is kept.
+func watchReloads(ctx context.Context, srv reloader, cfgPath string) {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hangups)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hangups:
+				reloadConfig(srv, cfgPath)
+			}
+		}
+	}()
+}
+
+func reloadConfig(srv reloader, cfgPath string) {
+	if cfgPath == "" {
+		log.Printf("config reload skipped: no --config path supplied")
+		return
+	}
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		log.Printf("config reload ignored: %v", err)
+		return
+	}
+	if err := srv.Reload(cfg); err != nil {
+		log.Printf("config reload ignored: %v", err)
+		return
+	}
+	log.Printf("config reload scheduled from %s", cfgPath)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"chunkserver/internal/config"
+	"chunkserver/internal/terrain"
+	"chunkserver/internal/world"
+)
+
+// validateConfig loads and validates the configuration at path without
+// starting a server, writing a short report to out. With generate set it also
+// generates the chunk at the region origin so terrain parameters that only fail
+// during generation are caught. It returns the process exit code.
+func validateConfig(out, errOut io.Writer, path string, generate bool) int {
+	cfg, err := config.Load(path)
+	if err != nil {
+		fmt.Fprintf(errOut, "config %s is invalid: %v\n", configName(path), err)
+		return 1
+	}
+	region, err := world.NewServerRegion(cfg)
+	if err != nil {
+		fmt.Fprintf(errOut, "config %s is invalid: %v\n", configName(path), err)
+		return 1
+	}
+	fmt.Fprintf(out, "config %s is valid\n", configName(path))
+	fmt.Fprintf(out, "server: %s\n", cfg.Server.ID)
+	fmt.Fprintf(out, "region: %dx%d chunks from (%d,%d)\n", region.ChunksX, region.ChunksY, region.Origin.X, region.Origin.Y)
+	fmt.Fprintf(out, "chunk: %dx%dx%d blocks\n", region.ChunkDimension.Width, region.ChunkDimension.Depth, region.ChunkDimension.Height)
+	if !generate {
+		return 0
+	}
+
+	generator := terrain.NewNoiseGenerator(cfg.Terrain, cfg.Economy)
+	generator.SetBlockDefinitions(cfg.Blocks)
+	bounds, err := region.ChunkBounds(region.Origin)
+	if err != nil {
+		fmt.Fprintf(errOut, "generate chunk %v: %v\n", region.Origin, err)
+		return 1
+	}
+	// The check chunk is kept in memory so validation never writes chunk files.
+	ctx := world.ContextWithStorageProvider(context.Background(), world.NewMemoryStorageProvider())
+	started := time.Now()
+	if _, err := generator.Generate(ctx, region.Origin, bounds, region.ChunkDimension); err != nil {
+		fmt.Fprintf(errOut, "generate chunk %v: %v\n", region.Origin, err)
+		return 1
+	}
+	fmt.Fprintf(out, "generated chunk (%d,%d) in %s\n", region.Origin.X, region.Origin.Y, time.Since(started).Round(time.Millisecond))
+	return 0
+}
+
+func configName(path string) string {
+	if path == "" {
+		return "(defaults)"
+	}
+	return path
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"chunkserver/internal/config"
+)
+
+func writeTestConfig(t *testing.T, cfg *config.Config) string {
+	t.Helper()
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("marshal config: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func TestValidateConfigAcceptsGoodConfig(t *testing.T) {
+	previous := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(previous) })
+
+	cfg := config.Default()
+	cfg.Server.ID = "validate-ok"
+	cfg.Chunk.Width, cfg.Chunk.Depth, cfg.Chunk.Height = 8, 8, 32
+	cfg.Chunk.ChunksX, cfg.Chunk.ChunksY = 3, 2
+	path := writeTestConfig(t, cfg)
+
+	var out, errOut bytes.Buffer
+	if code := validateConfig(&out, &errOut, path, true); code != 0 {
+		t.Fatalf("validateConfig exit = %d, stderr:\n%s", code, errOut.String())
+	}
+	for _, want := range []string{"is valid", "server: validate-ok", "region: 3x2 chunks", "chunk: 8x8x32 blocks", "generated chunk (0,0)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("report missing %q:\n%s", want, out.String())
+		}
+	}
+	if errOut.Len() != 0 {
+		t.Fatalf("unexpected stderr output:\n%s", errOut.String())
+	}
+}
+
+func TestValidateConfigRejectsBadConfig(t *testing.T) {
+	cfg := config.Default()
+	cfg.Chunk.Width = 0
+	path := writeTestConfig(t, cfg)
+
+	var out, errOut bytes.Buffer
+	if code := validateConfig(&out, &errOut, path, false); code == 0 {
+		t.Fatalf("expected a non-zero exit for an invalid config, stdout:\n%s", out.String())
+	}
+	if !strings.Contains(errOut.String(), "is invalid") || !strings.Contains(errOut.String(), "chunk") {
+		t.Fatalf("stderr does not explain the failure:\n%s", errOut.String())
+	}
+	if out.Len() != 0 {
+		t.Fatalf("invalid config should not print a report:\n%s", out.String())
+	}
+
+	errOut.Reset()
+	if code := validateConfig(&out, &errOut, filepath.Join(t.TempDir(), "missing.json"), false); code == 0 {
+		t.Fatalf("expected a non-zero exit for a missing config")
+	}
+	if !strings.Contains(errOut.String(), "open config") {
+		t.Fatalf("stderr does not mention the missing file:\n%s", errOut.String())
+	}
+}
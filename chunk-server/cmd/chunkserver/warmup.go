@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"chunkserver/internal/world"
+)
+
+// parseWarmupBox reads a -warmup-box value of the form "minX,minY,maxX,maxY"
+// in global chunk coordinates. An empty value covers every chunk, which the
+// server clips to its region.
+func parseWarmupBox(value string) (from, to world.ChunkCoord, err error) {
+	if strings.TrimSpace(value) == "" {
+		return world.ChunkCoord{X: math.MinInt, Y: math.MinInt}, world.ChunkCoord{X: math.MaxInt, Y: math.MaxInt}, nil
+	}
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return from, to, fmt.Errorf("warmup box %q: want minX,minY,maxX,maxY", value)
+	}
+	var bounds [4]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return from, to, fmt.Errorf("warmup box %q: %w", value, err)
+		}
+		bounds[i] = n
+	}
+	if bounds[2] < bounds[0] || bounds[3] < bounds[1] {
+		return from, to, fmt.Errorf("warmup box %q: max corner is below min corner", value)
+	}
+	return world.ChunkCoord{X: bounds[0], Y: bounds[1]}, world.ChunkCoord{X: bounds[2], Y: bounds[3]}, nil
+}
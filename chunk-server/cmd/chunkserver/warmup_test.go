@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"chunkserver/internal/world"
+)
+
+func TestParseWarmupBox(t *testing.T) {
+	from, to, err := parseWarmupBox(" -2, 0,3,4 ")
+	if err != nil {
+		t.Fatalf("parseWarmupBox: %v", err)
+	}
+	if from != (world.ChunkCoord{X: -2, Y: 0}) || to != (world.ChunkCoord{X: 3, Y: 4}) {
+		t.Fatalf("parsed box %v-%v, want (-2,0)-(3,4)", from, to)
+	}
+
+	from, to, err = parseWarmupBox("")
+	if err != nil {
+		t.Fatalf("parseWarmupBox empty: %v", err)
+	}
+	if from.X > -1000 || to.X < 1000 {
+		t.Fatalf("expected an empty box to cover every chunk, got %v-%v", from, to)
+	}
+
+	for _, bad := range []string{"1,2,3", "a,0,1,1", "4,0,1,1"} {
+		if _, _, err := parseWarmupBox(bad); err == nil {
+			t.Errorf("parseWarmupBox(%q) succeeded, want error", bad)
+		}
+	}
+}
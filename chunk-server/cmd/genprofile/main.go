@@ -0,0 +1,142 @@
+// Command genprofile times terrain generation for a deterministic sample of
+// chunks so regressions in the column, forest, and mineral passes show up
+// outside a running server.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"time"
+
+	"chunkserver/internal/config"
+	"chunkserver/internal/terrain"
+	"chunkserver/internal/world"
+)
+
+type options struct {
+	configPath string
+	chunks     int
+	seed       int64
+	width      int
+	depth      int
+	height     int
+}
+
+// profile aggregates the timings of every generated chunk.
+type profile struct {
+	Chunks  int
+	Columns int
+	Elapsed time.Duration
+	Passes  terrain.GenerationSnapshot
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.configPath, "config", "", "path to chunk server configuration file (defaults when empty)")
+	flag.IntVar(&opts.chunks, "chunks", 8, "number of chunks to generate")
+	flag.Int64Var(&opts.seed, "seed", 1, "seed for choosing chunk coordinates")
+	flag.IntVar(&opts.width, "width", 0, "chunk width override (0 uses the config)")
+	flag.IntVar(&opts.depth, "depth", 0, "chunk depth override (0 uses the config)")
+	flag.IntVar(&opts.height, "height", 0, "chunk height override (0 uses the config)")
+	verbose := flag.Bool("v", false, "keep per-chunk generation progress logs")
+	flag.Parse()
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	result, err := run(context.Background(), opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "genprofile: %v\n", err)
+		os.Exit(1)
+	}
+	report(os.Stdout, result)
+}
+
+// run generates opts.chunks distinct chunks, picked at random from the
+// configured region with opts.seed, and returns their combined timings.
+func run(ctx context.Context, opts options) (profile, error) {
+	cfg, err := config.Load(opts.configPath)
+	if err != nil {
+		return profile{}, err
+	}
+	if opts.width > 0 {
+		cfg.Chunk.Width = opts.width
+	}
+	if opts.depth > 0 {
+		cfg.Chunk.Depth = opts.depth
+	}
+	if opts.height > 0 {
+		cfg.Chunk.Height = opts.height
+	}
+	if opts.chunks <= 0 {
+		return profile{}, errors.New("chunks must be positive")
+	}
+	region, err := world.NewServerRegion(cfg)
+	if err != nil {
+		return profile{}, err
+	}
+	available := region.ChunkCount()
+	if opts.chunks > available {
+		return profile{}, fmt.Errorf("region holds %d chunks, cannot generate %d", available, opts.chunks)
+	}
+
+	generator := terrain.NewNoiseGenerator(cfg.Terrain, cfg.Economy)
+	generator.SetBlockDefinitions(cfg.Blocks)
+	metrics := &terrain.GenerationMetrics{}
+	ctx = terrain.ContextWithProfiler(ctx, metrics.Profiler())
+	ctx = world.ContextWithStorageProvider(ctx, world.NewMemoryStorageProvider())
+	rng := rand.New(rand.NewSource(opts.seed))
+	result := profile{}
+	started := time.Now()
+	for _, index := range rng.Perm(available)[:opts.chunks] {
+		coord, err := region.LocalToGlobalChunk(world.LocalChunkIndex{X: index % region.ChunksX, Y: index / region.ChunksX})
+		if err != nil {
+			return profile{}, err
+		}
+		bounds, err := region.ChunkBounds(coord)
+		if err != nil {
+			return profile{}, err
+		}
+		if _, err := generator.Generate(ctx, coord, bounds, region.ChunkDimension); err != nil {
+			return profile{}, fmt.Errorf("generate chunk %v: %w", coord, err)
+		}
+		result.Chunks++
+		result.Columns += region.ChunkDimension.Width * region.ChunkDimension.Depth
+	}
+	result.Elapsed = time.Since(started)
+	result.Passes = metrics.Snapshot()
+	return result, nil
+}
+
+func report(w io.Writer, p profile) {
+	fmt.Fprintf(w, "chunks: %d\n", p.Chunks)
+	fmt.Fprintf(w, "columns: %d\n", p.Columns)
+	fmt.Fprintf(w, "elapsed: %s\n", p.Elapsed)
+	if p.Chunks == 0 || p.Elapsed <= 0 {
+		return
+	}
+	fmt.Fprintf(w, "columns/sec: %.0f\n", float64(p.Columns)/p.Elapsed.Seconds())
+	fmt.Fprintf(w, "avg chunk: %s\n", p.Elapsed/time.Duration(p.Chunks))
+	total := p.Passes.Total()
+	for _, pass := range []struct {
+		name string
+		took time.Duration
+	}{
+		{"columns", p.Passes.Columns},
+		{"forests", p.Passes.Forests},
+		{"minerals", p.Passes.Minerals},
+		{"flush", p.Passes.Flush},
+	} {
+		share := 0.0
+		if total > 0 {
+			share = 100 * float64(pass.took) / float64(total)
+		}
+		fmt.Fprintf(w, "  %-12s avg %-12s %5.1f%%\n", pass.name, pass.took/time.Duration(p.Chunks), share)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestRunGeneratesRequestedChunks(t *testing.T) {
+	previous := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(previous) })
+
+	opts := options{chunks: 2, seed: 7, width: 8, depth: 8, height: 32}
+	result, err := run(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if result.Chunks != 2 {
+		t.Fatalf("generated %d chunks, want 2", result.Chunks)
+	}
+	wantColumns := opts.chunks * opts.width * opts.depth
+	if result.Columns != wantColumns {
+		t.Fatalf("generated %d columns, want %d", result.Columns, wantColumns)
+	}
+	if result.Elapsed <= 0 || result.Passes.Columns <= 0 || result.Passes.Total() <= 0 {
+		t.Fatalf("expected non-zero timings, got elapsed %s passes %+v", result.Elapsed, result.Passes)
+	}
+
+	var out bytes.Buffer
+	report(&out, result)
+	for _, want := range []string{
+		"chunks: 2\n",
+		fmt.Sprintf("columns: %d\n", wantColumns),
+		"columns/sec:",
+		"  columns ",
+		"  forests ",
+		"  minerals ",
+		"  flush ",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("report missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunRejectsMoreChunksThanTheRegion(t *testing.T) {
+	if _, err := run(context.Background(), options{chunks: 1 << 20, width: 8, depth: 8, height: 32}); err == nil {
+		t.Fatalf("expected an error when asking for more chunks than the region holds")
+	}
+}
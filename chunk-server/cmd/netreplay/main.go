@@ -0,0 +1,122 @@
+// Command netreplay prints or replays a network capture written by a chunk
+// server with network.recordPath set, so cross-server exchanges such as
+// migrations and neighbor handshakes can be inspected or reproduced.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"time"
+
+	"chunkserver/internal/network"
+)
+
+type options struct {
+	capturePath string
+	direction   string
+	msgType     string
+	replayTo    string
+	speed       float64
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.capturePath, "capture", "", "path to the capture file")
+	flag.StringVar(&opts.direction, "direction", "", "only include sent or received envelopes (all when empty)")
+	flag.StringVar(&opts.msgType, "type", "", "only include envelopes of this message type")
+	flag.StringVar(&opts.replayTo, "replay", "", "UDP endpoint to resend the envelopes to instead of printing them")
+	flag.Float64Var(&opts.speed, "speed", 1, "replay speed multiplier; 0 sends without pauses")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := run(ctx, opts, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "netreplay: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, opts options, out io.Writer) error {
+	if opts.capturePath == "" {
+		return errors.New("-capture is required")
+	}
+	file, err := os.Open(opts.capturePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	records, err := network.ReadCapture(file)
+	if err != nil {
+		return err
+	}
+	records = filterRecords(records, opts)
+	if opts.replayTo != "" {
+		return replay(ctx, records, opts.replayTo, opts.speed)
+	}
+	for _, rec := range records {
+		printRecord(out, rec)
+	}
+	return nil
+}
+
+func filterRecords(records []network.Record, opts options) []network.Record {
+	kept := records[:0]
+	for _, rec := range records {
+		if opts.direction != "" && string(rec.Direction) != opts.direction {
+			continue
+		}
+		if opts.msgType != "" && string(rec.Envelope.Type) != opts.msgType {
+			continue
+		}
+		kept = append(kept, rec)
+	}
+	return kept
+}
+
+// printRecord writes one capture record per line: time, direction, peer,
+// sequence, message type, and the compacted payload.
+func printRecord(out io.Writer, rec network.Record) {
+	payload := rec.Envelope.Payload
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, payload); err == nil {
+		payload = compact.Bytes()
+	}
+	fmt.Fprintf(out, "%s %-8s %-21s seq=%-6d %-16s %s\n",
+		rec.Time.Format("15:04:05.000"), rec.Direction, rec.Peer, rec.Envelope.Seq, rec.Envelope.Type, payload)
+}
+
+// replay resends each envelope to addr, keeping the original spacing between
+// records divided by speed.
+func replay(ctx context.Context, records []network.Record, addr string, speed float64) error {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	for i, rec := range records {
+		if i > 0 && speed > 0 {
+			wait := time.Duration(float64(rec.Time.Sub(records[i-1].Time)) / speed)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+		data, err := network.Encode(rec.Envelope)
+		if err != nil {
+			return fmt.Errorf("encode record %d: %w", i, err)
+		}
+		if _, err := conn.Write(data); err != nil {
+			return fmt.Errorf("send record %d: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"chunkserver/internal/network"
+)
+
+func writeCapture(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "session.capture")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create capture: %v", err)
+	}
+	rec := network.NewRecorder(file)
+	envelopes := []struct {
+		direction network.Direction
+		env       network.Envelope
+	}{
+		{network.DirectionSent, network.Envelope{Type: network.MessageNeighborHello, Seq: 1, Payload: []byte(`{"serverId": "west"}`)}},
+		{network.DirectionReceived, network.Envelope{Type: network.MessageNeighborAck, Seq: 7, Payload: []byte(`{"serverId":"east"}`)}},
+		{network.DirectionSent, network.Envelope{Type: network.MessageTransferRequest, Seq: 2, Payload: []byte(`{"entityId":"unit-1"}`)}},
+	}
+	for _, e := range envelopes {
+		if err := rec.Record(e.direction, "127.0.0.1:19001", e.env); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	return path
+}
+
+func TestRunPrintsFilteredCapture(t *testing.T) {
+	path := writeCapture(t)
+	var out bytes.Buffer
+	if err := run(context.Background(), options{capturePath: path, direction: "sent"}, &out); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected the two sent records, got:\n%s", out.String())
+	}
+	if !strings.Contains(lines[0], "neighborHello") || !strings.Contains(lines[0], `{"serverId":"west"}`) {
+		t.Fatalf("unexpected first line %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "seq=2") {
+		t.Fatalf("unexpected second line %q", lines[1])
+	}
+}
+
+func TestRunReplaysEnvelopes(t *testing.T) {
+	path := writeCapture(t)
+	target, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer target.Close()
+
+	opts := options{capturePath: path, replayTo: target.LocalAddr().String()}
+	if err := run(context.Background(), opts, &bytes.Buffer{}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	buffer := make([]byte, 65536)
+	for _, want := range []uint64{1, 7, 2} {
+		target.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := target.ReadFrom(buffer)
+		if err != nil {
+			t.Fatalf("read replayed envelope: %v", err)
+		}
+		env, err := network.Decode(buffer[:n])
+		if err != nil {
+			t.Fatalf("decode replayed envelope: %v", err)
+		}
+		if env.Seq != want {
+			t.Fatalf("replayed seq %d, want %d", env.Seq, want)
+		}
+	}
+}
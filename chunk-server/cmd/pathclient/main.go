@@ -1,11 +1,16 @@
 package main
 
 import (
+	"bufio"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"chunkserver/internal/network"
@@ -23,8 +28,33 @@ func main() {
 	clearance := flag.Int("clearance", 0, "required vertical clearance in blocks (0 uses server default)")
 	maxClimb := flag.Int("maxclimb", 0, "maximum upward climb per step (0 uses server default)")
 	maxDrop := flag.Int("maxdrop", 0, "maximum downward drop per step (0 uses server default)")
+	waypointList := flag.String("waypoints", "", "blocks to visit in order between start and end, as x,y,z;x,y,z")
+	batch := flag.String("batch", "", "file of newline-delimited JSON path requests to issue instead of the flag request (- reads stdin)")
+	concurrency := flag.Int("concurrency", 1, "batch requests in flight at once")
+	timeout := flag.Duration("timeout", 3*time.Second, "how long to wait for each response")
 	flag.Parse()
 
+	if *batch != "" {
+		in := os.Stdin
+		if *batch != "-" {
+			file, err := os.Open(*batch)
+			if err != nil {
+				log.Fatalf("batch: %v", err)
+			}
+			defer file.Close()
+			in = file
+		}
+		if err := runBatch(in, os.Stdout, *server, *concurrency, *timeout); err != nil {
+			log.Fatalf("batch: %v", err)
+		}
+		return
+	}
+
+	waypoints, err := parseWaypoints(*waypointList)
+	if err != nil {
+		log.Fatalf("waypoints: %v", err)
+	}
+
 	req := network.PathRequest{
 		EntityID:  "client-test",
 		FromX:     *fromX,
@@ -37,53 +67,154 @@ func main() {
 		Clearance: *clearance,
 		MaxClimb:  *maxClimb,
 		MaxDrop:   *maxDrop,
+		Waypoints: waypoints,
+	}
+	resp, elapsed, err := sendRequest(*server, req, *timeout)
+	if err != nil {
+		log.Fatalf("%v", err)
 	}
-	payload, _ := json.Marshal(req)
-	env := network.Envelope{
+	printResponse(os.Stdout, resp, elapsed)
+}
+
+// sendRequest issues req from its own UDP socket and waits for the matching
+// response, returning it with the round-trip time.
+func sendRequest(server string, req network.PathRequest, timeout time.Duration) (network.PathResponse, time.Duration, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return network.PathResponse{}, 0, fmt.Errorf("encode request: %w", err)
+	}
+	data, err := network.Encode(network.Envelope{
 		Type:      network.MessagePathRequest,
 		Timestamp: time.Now().UTC(),
 		Seq:       1,
 		Payload:   payload,
-	}
-	data, err := network.Encode(env)
+	})
 	if err != nil {
-		log.Fatalf("encode: %v", err)
+		return network.PathResponse{}, 0, fmt.Errorf("encode: %w", err)
 	}
 
 	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4zero, Port: 0})
 	if err != nil {
-		log.Fatalf("listen udp: %v", err)
+		return network.PathResponse{}, 0, fmt.Errorf("listen udp: %w", err)
 	}
 	defer conn.Close()
 
-	target, err := net.ResolveUDPAddr("udp", *server)
+	target, err := net.ResolveUDPAddr("udp", server)
 	if err != nil {
-		log.Fatalf("resolve server: %v", err)
+		return network.PathResponse{}, 0, fmt.Errorf("resolve server: %w", err)
 	}
 
-	conn.SetDeadline(time.Now().Add(3 * time.Second))
+	started := time.Now()
+	conn.SetDeadline(started.Add(timeout))
 	if _, err := conn.WriteToUDP(data, target); err != nil {
-		log.Fatalf("send: %v", err)
+		return network.PathResponse{}, 0, fmt.Errorf("send: %w", err)
 	}
 
 	buf := make([]byte, 65536)
 	n, _, err := conn.ReadFromUDP(buf)
 	if err != nil {
-		log.Fatalf("recv: %v", err)
+		return network.PathResponse{}, 0, fmt.Errorf("recv: %w", err)
 	}
+	elapsed := time.Since(started)
 	envResp, err := network.Decode(buf[:n])
 	if err != nil {
-		log.Fatalf("decode env: %v", err)
+		return network.PathResponse{}, 0, fmt.Errorf("decode env: %w", err)
 	}
 	if envResp.Type != network.MessagePathResponse {
-		log.Fatalf("unexpected response type: %s", envResp.Type)
+		return network.PathResponse{}, 0, fmt.Errorf("unexpected response type: %s", envResp.Type)
 	}
 	var resp network.PathResponse
 	if err := json.Unmarshal(envResp.Payload, &resp); err != nil {
-		log.Fatalf("decode payload: %v", err)
+		return network.PathResponse{}, 0, fmt.Errorf("decode payload: %w", err)
 	}
-	fmt.Printf("Route for %s (blocks):\n", resp.EntityID)
+	return resp, elapsed, nil
+}
+
+func printResponse(out io.Writer, resp network.PathResponse, elapsed time.Duration) {
+	fmt.Fprintf(out, "Status: %s", resp.Status)
+	if resp.Stats != nil {
+		fmt.Fprintf(out, " (%d nodes expanded in %.2fms)", resp.Stats.Expanded, resp.Stats.ElapsedMs)
+	}
+	fmt.Fprintf(out, ", round trip %s\n", elapsed.Round(time.Microsecond))
+	if resp.Error != "" {
+		fmt.Fprintf(out, "No route for %s: %s\n", resp.EntityID, resp.Error)
+		return
+	}
+	fmt.Fprintf(out, "Route for %s (blocks):\n", resp.EntityID)
 	for i, step := range resp.Route {
-		fmt.Printf(" %d: (%d,%d,%d)\n", i, step.X, step.Y, step.Z)
+		fmt.Fprintf(out, " %d: (%d,%d,%d)\n", i, step.X, step.Y, step.Z)
+	}
+}
+
+// runBatch issues every newline-delimited JSON PathRequest read from in,
+// concurrency at a time, and prints each response as it arrives. Requests
+// that fail are reported and do not stop the batch; the returned error counts
+// them.
+func runBatch(in io.Reader, out io.Writer, server string, concurrency int, timeout time.Duration) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var (
+		outMu    sync.Mutex
+		wg       sync.WaitGroup
+		failed   int
+		parseErr error
+	)
+	slots := make(chan struct{}, concurrency)
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var req network.PathRequest
+		if err := json.Unmarshal([]byte(text), &req); err != nil {
+			parseErr = fmt.Errorf("line %d: %w", line, err)
+			break
+		}
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(line int, req network.PathRequest) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			resp, elapsed, err := sendRequest(server, req, timeout)
+			outMu.Lock()
+			defer outMu.Unlock()
+			fmt.Fprintf(out, "[%d] %s: ", line, req.EntityID)
+			if err != nil {
+				failed++
+				fmt.Fprintf(out, "error: %v\n", err)
+				return
+			}
+			printResponse(out, resp, elapsed)
+		}(line, req)
+	}
+	wg.Wait()
+	if parseErr != nil {
+		return parseErr
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read requests: %w", err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d requests failed", failed)
+	}
+	return nil
+}
+
+// parseWaypoints reads a semicolon-separated list of x,y,z block coordinates.
+func parseWaypoints(value string) ([]network.BlockStep, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	var steps []network.BlockStep
+	for _, part := range strings.Split(value, ";") {
+		var step network.BlockStep
+		if _, err := fmt.Sscanf(strings.TrimSpace(part), "%d,%d,%d", &step.X, &step.Y, &step.Z); err != nil {
+			return nil, fmt.Errorf("parse %q: %w", part, err)
+		}
+		steps = append(steps, step)
 	}
+	return steps, nil
 }
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"chunkserver/internal/network"
+)
+
+// fakePathServer answers each path request with a two-step route from the
+// request's start to its goal.
+func fakePathServer(t *testing.T) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 65536)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			env, err := network.Decode(buf[:n])
+			if err != nil {
+				continue
+			}
+			var req network.PathRequest
+			if err := json.Unmarshal(env.Payload, &req); err != nil {
+				continue
+			}
+			payload, _ := json.Marshal(network.PathResponse{
+				EntityID: req.EntityID,
+				Status:   "ok",
+				Route: []network.BlockStep{
+					{X: req.FromX, Y: req.FromY, Z: req.FromZ},
+					{X: req.ToX, Y: req.ToY, Z: req.ToZ},
+				},
+			})
+			data, _ := network.Encode(network.Envelope{Type: network.MessagePathResponse, Payload: payload})
+			conn.WriteTo(data, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestRunBatchIssuesEachRequest(t *testing.T) {
+	server := fakePathServer(t)
+	input := strings.Join([]string{
+		`{"entityId":"scout","fromX":1,"fromY":2,"fromZ":3,"toX":4,"toY":5,"toZ":6,"mode":"ground"}`,
+		``,
+		`{"entityId":"bomber","fromX":7,"fromY":8,"fromZ":9,"toX":1,"toY":1,"toZ":1,"mode":"flying"}`,
+	}, "\n")
+
+	for _, concurrency := range []int{1, 2} {
+		var out bytes.Buffer
+		if err := runBatch(strings.NewReader(input), &out, server, concurrency, 2*time.Second); err != nil {
+			t.Fatalf("concurrency %d: runBatch() error = %v", concurrency, err)
+		}
+		text := out.String()
+		if n := strings.Count(text, "Status: ok"); n != 2 {
+			t.Fatalf("concurrency %d: expected two responses, got %d:\n%s", concurrency, n, text)
+		}
+		for _, want := range []string{"[1] scout", "Route for scout", " 1: (4,5,6)", "[3] bomber", "Route for bomber", " 0: (7,8,9)"} {
+			if !strings.Contains(text, want) {
+				t.Fatalf("concurrency %d: output missing %q:\n%s", concurrency, want, text)
+			}
+		}
+	}
+}
+
+func TestRunBatchRejectsMalformedLines(t *testing.T) {
+	var out bytes.Buffer
+	err := runBatch(strings.NewReader("{not json}\n"), &out, "127.0.0.1:1", 1, time.Second)
+	if err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Fatalf("expected a line 1 parse error, got %v", err)
+	}
+}
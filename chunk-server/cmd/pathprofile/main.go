@@ -0,0 +1,169 @@
+// Command pathprofile times block-level route searches over generated terrain
+// and reports the per-request distribution of node expansions and latency, so
+// regressions in the tail show up alongside the averages.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"sort"
+	"time"
+
+	"chunkserver/internal/config"
+	"chunkserver/internal/pathfinding"
+	"chunkserver/internal/terrain"
+	"chunkserver/internal/world"
+)
+
+type options struct {
+	configPath string
+	requests   int
+	seed       int64
+	distance   int
+	mode       string
+	width      int
+	depth      int
+	height     int
+}
+
+// profile holds the aggregate of every search run.
+type profile struct {
+	Requests pathfinding.RequestSnapshot
+	Metrics  pathfinding.MetricsSnapshot
+	Elapsed  time.Duration
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.configPath, "config", "", "path to chunk server configuration file (defaults when empty)")
+	flag.IntVar(&opts.requests, "requests", 200, "number of route searches to run")
+	flag.Int64Var(&opts.seed, "seed", 1, "seed for choosing route endpoints")
+	flag.IntVar(&opts.distance, "distance", 32, "largest horizontal distance in blocks between a route's endpoints")
+	flag.StringVar(&opts.mode, "mode", "ground", "unit mode to route: ground, flying, or underground")
+	flag.IntVar(&opts.width, "width", 0, "chunk width override (0 uses the config)")
+	flag.IntVar(&opts.depth, "depth", 0, "chunk depth override (0 uses the config)")
+	flag.IntVar(&opts.height, "height", 0, "chunk height override (0 uses the config)")
+	verbose := flag.Bool("v", false, "keep chunk generation progress logs")
+	flag.Parse()
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	result, err := run(context.Background(), opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pathprofile: %v\n", err)
+		os.Exit(1)
+	}
+	report(os.Stdout, result)
+}
+
+// run searches opts.requests routes between surface blocks picked with
+// opts.seed near the region origin and returns their combined stats.
+func run(ctx context.Context, opts options) (profile, error) {
+	cfg, err := config.Load(opts.configPath)
+	if err != nil {
+		return profile{}, err
+	}
+	if opts.width > 0 {
+		cfg.Chunk.Width = opts.width
+	}
+	if opts.depth > 0 {
+		cfg.Chunk.Depth = opts.depth
+	}
+	if opts.height > 0 {
+		cfg.Chunk.Height = opts.height
+	}
+	if opts.requests <= 0 {
+		return profile{}, errors.New("requests must be positive")
+	}
+	if opts.distance <= 0 {
+		return profile{}, errors.New("distance must be positive")
+	}
+
+	region, err := world.NewServerRegion(cfg)
+	if err != nil {
+		return profile{}, err
+	}
+	generator := terrain.NewNoiseGenerator(cfg.Terrain, cfg.Economy)
+	generator.SetBlockDefinitions(cfg.Blocks)
+	manager := world.NewManager(region, generator)
+	manager.SetStorageProvider(world.NewMemoryStorageProvider())
+	navigator := pathfinding.NewBlockNavigator(region, manager)
+	navigator.SetOptions(pathfinding.SearchOptions{
+		MaxNodes:       cfg.Pathfinding.MaxSearchNodes,
+		HeuristicScale: cfg.Pathfinding.HeuristicScale,
+	})
+	unit := pathfinding.DefaultProfile(pathfinding.ModeFromString(opts.mode))
+
+	metrics := &pathfinding.NavigatorMetrics{}
+	ctx = pathfinding.ContextWithProfiler(ctx, metrics.Profiler())
+	bounds := region.GlobalBlockBounds()
+	rng := rand.New(rand.NewSource(opts.seed))
+	var requests pathfinding.RequestStats
+	started := time.Now()
+	for i := 0; i < opts.requests; i++ {
+		startX := bounds.Min.X + rng.Intn(min(opts.distance, bounds.Max.X-bounds.Min.X+1))
+		startY := bounds.Min.Y + rng.Intn(min(opts.distance, bounds.Max.Y-bounds.Min.Y+1))
+		goalX := clamp(startX+rng.Intn(2*opts.distance+1)-opts.distance, bounds.Min.X, bounds.Max.X)
+		goalY := clamp(startY+rng.Intn(2*opts.distance+1)-opts.distance, bounds.Min.Y, bounds.Max.Y)
+		start, err := standingBlock(ctx, manager, startX, startY)
+		if err != nil {
+			return profile{}, err
+		}
+		goal, err := standingBlock(ctx, manager, goalX, goalY)
+		if err != nil {
+			return profile{}, err
+		}
+		_, stats := navigator.FindRouteWithStats(ctx, start, goal, unit)
+		requests.Record(stats)
+	}
+	return profile{
+		Requests: requests.Snapshot(),
+		Metrics:  metrics.Snapshot(),
+		Elapsed:  time.Since(started),
+	}, nil
+}
+
+// standingBlock returns the air block resting on the surface of column (x, y).
+func standingBlock(ctx context.Context, manager *world.Manager, x, y int) (world.BlockCoord, error) {
+	z, ok := manager.SurfaceHeight(ctx, x, y)
+	if !ok {
+		return world.BlockCoord{}, fmt.Errorf("no surface in column (%d,%d)", x, y)
+	}
+	return world.BlockCoord{X: x, Y: y, Z: z + 1}, nil
+}
+
+func clamp(v, lo, hi int) int {
+	return min(max(v, lo), hi)
+}
+
+func report(w io.Writer, p profile) {
+	r := p.Requests
+	fmt.Fprintf(w, "requests: %d\n", r.Requests)
+	fmt.Fprintf(w, "elapsed: %s\n", p.Elapsed)
+	if r.Requests == 0 {
+		return
+	}
+	statuses := make([]string, 0, len(r.ByStatus))
+	for status := range r.ByStatus {
+		statuses = append(statuses, string(status))
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		fmt.Fprintf(w, "  %-18s %d\n", status, r.ByStatus[pathfinding.RouteStatus(status)])
+	}
+	fmt.Fprintf(w, "expanded: avg %d p50 %d p95 %d p99 %d max %d\n",
+		r.Expanded.Mean, r.Expanded.P50, r.Expanded.P95, r.Expanded.P99, r.Expanded.Max)
+	fmt.Fprintf(w, "latency: avg %s p50 %s p95 %s p99 %s max %s\n",
+		r.Latency.Mean, r.Latency.P50, r.Latency.P95, r.Latency.P99, r.Latency.Max)
+	lookups := p.Metrics.CacheHits + p.Metrics.CacheMisses
+	if lookups > 0 {
+		fmt.Fprintf(w, "chunk cache hit rate: %.1f%%\n", 100*float64(p.Metrics.CacheHits)/float64(lookups))
+	}
+}
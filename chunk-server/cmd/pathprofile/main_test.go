@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestRunProfilesRequestedSearches(t *testing.T) {
+	previous := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(previous) })
+
+	result, err := run(context.Background(), options{requests: 12, seed: 3, distance: 8, mode: "flying", width: 16, depth: 16, height: 48})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if result.Requests.Requests != 12 {
+		t.Fatalf("profiled %d requests, want 12", result.Requests.Requests)
+	}
+	if result.Requests.Latency.Max <= 0 || result.Requests.Latency.P50 > result.Requests.Latency.P99 {
+		t.Fatalf("unexpected latency distribution %+v", result.Requests.Latency)
+	}
+
+	var out bytes.Buffer
+	report(&out, result)
+	for _, want := range []string{"requests: 12", "expanded: avg", "p95", "p99", "latency: avg"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("report missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunRejectsNonPositiveRequests(t *testing.T) {
+	if _, err := run(context.Background(), options{requests: 0, distance: 8}); err == nil {
+		t.Fatalf("expected an error for zero requests")
+	}
+}
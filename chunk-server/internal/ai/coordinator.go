@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"hash/crc32"
 	"math"
+	"math/rand"
 	"sort"
 	"sync"
 	"time"
@@ -75,18 +76,33 @@ type Coordinator struct {
 	mu     sync.RWMutex
 	squads map[string]*Squad
 	plans  map[string]*ConstructionPlan
+	rng    *rand.Rand
+
+	// findRoute searches routes for squads. routes holds the route each
+	// squad shares, by squad ID, and memberRoutes the routes of members
+	// that had to leave it.
+	findRoute    routeFinder
+	routes       map[string]*cachedRoute
+	memberRoutes map[entities.ID]*cachedRoute
 }
 
 // NewCoordinator constructs a new AI coordinator.
 func NewCoordinator(region world.ServerRegion, mgr *entities.Manager, nav *pathfinding.BlockNavigator, lookup NeighborLookup) *Coordinator {
-	return &Coordinator{
-		region:    region,
-		entities:  mgr,
-		navigator: nav,
-		lookup:    lookup,
-		squads:    make(map[string]*Squad),
-		plans:     make(map[string]*ConstructionPlan),
-	}
+	c := &Coordinator{
+		region:       region,
+		entities:     mgr,
+		navigator:    nav,
+		lookup:       lookup,
+		squads:       make(map[string]*Squad),
+		plans:        make(map[string]*ConstructionPlan),
+		rng:          rand.New(rand.NewSource(0)),
+		routes:       make(map[string]*cachedRoute),
+		memberRoutes: make(map[entities.ID]*cachedRoute),
+	}
+	if nav != nil {
+		c.findRoute = nav.FindRoute
+	}
+	return c
 }
 
 // Tick evaluates squads and updates entity intents.
@@ -169,6 +185,7 @@ func (c *Coordinator) rebuildSquads() {
 		for id := range squad.Members {
 			if _, ok := seen[id]; !ok {
 				delete(squad.Members, id)
+				delete(c.memberRoutes, id)
 			}
 		}
 	}
@@ -256,7 +273,7 @@ func (c *Coordinator) applyObjectives(squad *Squad) {
 		return
 	}
 	avg := entities.Vec3{X: sumX / count, Y: sumY / count, Z: sumZ / count}
-	anchor := world.BlockCoord{X: int(math.Round(avg.X)), Y: int(math.Round(avg.Y)), Z: int(math.Round(avg.Z))}
+	anchor := world.NearestBlock(avg)
 	squad.Formation.Anchor = anchor
 	squad.Formation.Spacing = spacingForRole(squad.Role)
 	// Determine facing towards objective chunk.
@@ -299,17 +316,20 @@ func (c *Coordinator) applyObjectives(squad *Squad) {
 }
 
 func (c *Coordinator) driveMembers(squad *Squad, members []*SquadMember, delta time.Duration) {
+	shared := c.squadRoute(squad)
+	budget := memberSearchesPerTick
 	for _, member := range members {
 		ent, ok := c.entities.Entity(member.EntityID)
 		if !ok {
 			continue
 		}
 		slotPos := squad.Formation.SlotPosition(member.SlotIndex)
-		// Align vertical position with current entity height to avoid oscillations.
 		current := ent.PositionVec()
+		target := c.memberTarget(squad, shared, member, slotPos, world.BlockFromVec(current), &budget)
+		// Align vertical position with current entity height to avoid oscillations.
 		slotVec := entities.Vec3{
-			X: float64(slotPos.X) + 0.5,
-			Y: float64(slotPos.Y) + 0.5,
+			X: float64(target.X) + 0.5,
+			Y: float64(target.Y) + 0.5,
 			Z: current.Z,
 		}
 		dx := slotVec.X - current.X
@@ -386,8 +406,7 @@ func (c *Coordinator) updateConstructionPlans(delta time.Duration) {
 		if !ok {
 			continue
 		}
-		pos := ent.PositionVec()
-		block := world.BlockCoord{X: int(math.Round(pos.X)), Y: int(math.Round(pos.Y)), Z: int(math.Round(pos.Z))}
+		block := world.NearestBlock(ent.PositionVec())
 		chunk, _ := c.region.LocateBlock(block)
 		active[chunk]++
 		ent.SetAttribute("ai_construction_anchor_x", float64(plan.Anchor.X))
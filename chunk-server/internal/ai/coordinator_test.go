@@ -12,10 +12,13 @@ import (
 
 func TestCoordinatorAssignsSquadsAndPlans(t *testing.T) {
 	cfg := config.Default()
-	region := world.NewServerRegion(cfg)
+	region, err := world.NewServerRegion(cfg)
+	if err != nil {
+		t.Fatalf("region: %v", err)
+	}
 	mgr := entities.NewManager(cfg.Server.ID)
 	nav := pathfinding.NewBlockNavigator(region, nil)
-	baseChunk := world.ChunkCoord{X: region.Origin.X, Y: region.Origin.Y + region.ChunksPerAxis - 1}
+	baseChunk := world.ChunkCoord{X: region.Origin.X, Y: region.Origin.Y + region.ChunksY - 1}
 	lookup := func(chunk world.ChunkCoord) (NeighborOwnership, bool) {
 		if chunk == (world.ChunkCoord{X: baseChunk.X, Y: baseChunk.Y + 1}) {
 			return NeighborOwnership{
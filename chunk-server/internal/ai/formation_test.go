@@ -51,10 +51,3 @@ func TestFormationSlotPositionWedge(t *testing.T) {
 		t.Fatalf("rows should maintain spacing: row1=%+v,%+v row2=%+v,%+v", positions[1], positions[2], positions[3], positions[4])
 	}
 }
-
-func absInt(v int) int {
-	if v < 0 {
-		return -v
-	}
-	return v
-}
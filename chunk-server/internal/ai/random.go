@@ -0,0 +1,47 @@
+package ai
+
+import (
+	"math/rand"
+
+	"chunkserver/internal/entities"
+)
+
+// SetSeed reseeds the coordinator's random source. Every stochastic AI
+// decision draws from it rather than from math/rand's global source, so two
+// coordinators seeded alike make the same choices when given the same inputs
+// in the same order.
+func (c *Coordinator) SetSeed(seed int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rng = rand.New(rand.NewSource(seed))
+}
+
+// The helpers below draw from the coordinator's random source. Callers must
+// hold c.mu, as Tick does.
+
+// randFloat returns a number in [0, 1).
+func (c *Coordinator) randFloat() float64 {
+	return c.rng.Float64()
+}
+
+// randIntn returns a number in [0, n), or 0 when n is not positive.
+func (c *Coordinator) randIntn(n int) int {
+	if n <= 0 {
+		return 0
+	}
+	return c.rng.Intn(n)
+}
+
+// jitter returns a number in [-spread, spread).
+func (c *Coordinator) jitter(spread float64) float64 {
+	return (c.randFloat()*2 - 1) * spread
+}
+
+// pickEntity chooses one of candidates uniformly. ok is false when there are
+// none.
+func (c *Coordinator) pickEntity(candidates []entities.ID) (entities.ID, bool) {
+	if len(candidates) == 0 {
+		return "", false
+	}
+	return candidates[c.randIntn(len(candidates))], true
+}
@@ -0,0 +1,64 @@
+package ai
+
+import (
+	"testing"
+
+	"chunkserver/internal/entities"
+	"chunkserver/internal/world"
+)
+
+func TestSeededCoordinatorsMakeIdenticalChoices(t *testing.T) {
+	region := world.ServerRegion{
+		ChunksX:        1,
+		ChunksY:        1,
+		ChunkDimension: world.Dimensions{Width: 4, Depth: 4, Height: 4},
+	}
+	candidates := []entities.ID{"alpha", "bravo", "charlie", "delta", "echo"}
+	type choice struct {
+		target entities.ID
+		jitter float64
+		roll   int
+	}
+	decide := func(seed int64) []choice {
+		coord := NewCoordinator(region, entities.NewManager("test"), nil, nil)
+		coord.SetSeed(seed)
+		coord.mu.Lock()
+		defer coord.mu.Unlock()
+		choices := make([]choice, 32)
+		for i := range choices {
+			target, ok := coord.pickEntity(candidates)
+			if !ok {
+				t.Fatalf("pickEntity found no candidate")
+			}
+			choices[i] = choice{target: target, jitter: coord.jitter(1.5), roll: coord.randIntn(6)}
+		}
+		return choices
+	}
+
+	first, second := decide(42), decide(42)
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("choice %d differs between identically seeded coordinators: %+v vs %+v", i, first[i], second[i])
+		}
+		if first[i].jitter < -1.5 || first[i].jitter >= 1.5 {
+			t.Fatalf("jitter %v outside [-1.5, 1.5)", first[i].jitter)
+		}
+	}
+
+	other := decide(7)
+	same := true
+	for i := range first {
+		if first[i] != other[i] {
+			same = false
+			break
+		}
+	}
+	if same {
+		t.Fatalf("expected a different seed to change the choices")
+	}
+
+	coord := NewCoordinator(region, entities.NewManager("test"), nil, nil)
+	if _, ok := coord.pickEntity(nil); ok {
+		t.Fatalf("expected no pick from an empty candidate list")
+	}
+}
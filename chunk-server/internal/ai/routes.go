@@ -0,0 +1,149 @@
+package ai
+
+import (
+	"context"
+	"math"
+
+	"chunkserver/internal/pathfinding"
+	"chunkserver/internal/world"
+)
+
+const (
+	// routeDrift is how far, in blocks along any axis, a route's start may
+	// wander from the route or its goal may move before it is searched again.
+	routeDrift = 2
+	// memberDetourDistance is how far a member may be from its place on the
+	// squad route before it needs a route of its own.
+	memberDetourDistance = 6.0
+	// memberSearchesPerTick bounds the individual route searches a squad runs
+	// in one tick. Members past the bound steer straight until a later tick.
+	memberSearchesPerTick = 2
+)
+
+// routeFinder searches for a route between two blocks.
+type routeFinder func(ctx context.Context, start, goal world.BlockCoord, profile pathfinding.UnitProfile) []world.BlockCoord
+
+// cachedRoute is a route searched from one block to another. A failed search
+// is cached too, with no path, so it is not retried until either end moves.
+type cachedRoute struct {
+	from world.BlockCoord
+	goal world.BlockCoord
+	path []world.BlockCoord
+}
+
+// fresh reports whether the route still serves a unit at from heading for
+// goal: the goal has not moved past routeDrift and from is still near the
+// route, or near where the search started.
+func (r *cachedRoute) fresh(from, goal world.BlockCoord) bool {
+	if r == nil || !withinDrift(r.goal, goal) {
+		return false
+	}
+	if withinDrift(r.from, from) {
+		return true
+	}
+	_, ok := r.nextWaypoint(from)
+	return ok
+}
+
+// nextWaypoint returns the route node after the one nearest to from. ok is
+// false when the route is empty or from is further than routeDrift from it.
+func (r *cachedRoute) nextWaypoint(from world.BlockCoord) (world.BlockCoord, bool) {
+	if r == nil || len(r.path) == 0 {
+		return world.BlockCoord{}, false
+	}
+	nearest, best := -1, 0
+	for i, node := range r.path {
+		if !withinDrift(node, from) {
+			continue
+		}
+		dx, dy, dz := node.X-from.X, node.Y-from.Y, node.Z-from.Z
+		if dist := dx*dx + dy*dy + dz*dz; nearest < 0 || dist < best {
+			nearest, best = i, dist
+		}
+	}
+	if nearest < 0 {
+		return world.BlockCoord{}, false
+	}
+	if nearest+1 < len(r.path) {
+		nearest++
+	}
+	return r.path[nearest], true
+}
+
+func withinDrift(a, b world.BlockCoord) bool {
+	return absInt(a.X-b.X) <= routeDrift && absInt(a.Y-b.Y) <= routeDrift && absInt(a.Z-b.Z) <= routeDrift
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// squadRoute returns the route the squad shares from its anchor to its
+// objective, searching only when the cached one no longer fits.
+func (c *Coordinator) squadRoute(squad *Squad) *cachedRoute {
+	from, goal := squad.Formation.Anchor, squad.Objective.TargetBlock
+	if route := c.routes[squad.ID]; route.fresh(from, goal) {
+		return route
+	}
+	route := &cachedRoute{from: from, goal: goal}
+	if c.findRoute != nil {
+		route.path = c.findRoute(context.Background(), from, goal, profileForRole(squad.Role))
+	}
+	c.routes[squad.ID] = route
+	return route
+}
+
+// memberTarget returns the block a member steers for this tick. Members
+// follow the squad route shifted by their slot's offset from the anchor, so
+// one search serves the whole squad. A member too far from its place on that
+// route gets a route of its own, while budget allows.
+func (c *Coordinator) memberTarget(squad *Squad, shared *cachedRoute, member *SquadMember, slot world.BlockCoord, pos world.BlockCoord, budget *int) world.BlockCoord {
+	target := slot
+	if waypoint, ok := shared.nextWaypoint(squad.Formation.Anchor); ok {
+		anchor := squad.Formation.Anchor
+		target = world.BlockCoord{
+			X: waypoint.X + slot.X - anchor.X,
+			Y: waypoint.Y + slot.Y - anchor.Y,
+			Z: waypoint.Z + slot.Z - anchor.Z,
+		}
+	}
+	if blockDistance(pos, target) <= memberDetourDistance {
+		delete(c.memberRoutes, member.EntityID)
+		return target
+	}
+
+	route := c.memberRoutes[member.EntityID]
+	if !route.fresh(pos, target) {
+		if *budget <= 0 || c.findRoute == nil {
+			return target
+		}
+		*budget--
+		route = &cachedRoute{from: pos, goal: target}
+		route.path = c.findRoute(context.Background(), pos, target, profileForRole(squad.Role))
+		c.memberRoutes[member.EntityID] = route
+	}
+	if waypoint, ok := route.nextWaypoint(pos); ok {
+		return waypoint
+	}
+	return target
+}
+
+func blockDistance(a, b world.BlockCoord) float64 {
+	dx, dy, dz := float64(a.X-b.X), float64(a.Y-b.Y), float64(a.Z-b.Z)
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
+
+// profileForRole is how members of a squad with role move.
+func profileForRole(role SquadRole) pathfinding.UnitProfile {
+	switch role {
+	case SquadRoleSupport:
+		return pathfinding.DefaultProfile(pathfinding.ModeFlying)
+	case SquadRoleBuilder:
+		return pathfinding.DefaultProfile(pathfinding.ModeUnderground)
+	default:
+		return pathfinding.DefaultProfile(pathfinding.ModeGround)
+	}
+}
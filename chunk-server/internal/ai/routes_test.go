@@ -0,0 +1,91 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"chunkserver/internal/entities"
+	"chunkserver/internal/pathfinding"
+	"chunkserver/internal/world"
+)
+
+// flatGenerator lays a solid floor at z=0.
+type flatGenerator struct{}
+
+func (flatGenerator) Generate(ctx context.Context, coord world.ChunkCoord, bounds world.Bounds, dim world.Dimensions) (*world.Chunk, error) {
+	chunk := world.NewChunk(coord, bounds, dim)
+	for x := 0; x < dim.Width; x++ {
+		for y := 0; y < dim.Depth; y++ {
+			chunk.SetLocalBlock(x, y, 0, world.Block{Type: world.BlockSolid, Material: "stone"})
+		}
+	}
+	return chunk, nil
+}
+
+func TestSquadSharesOneRouteAcrossMembers(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("get working directory: %v", err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("chdir to temp dir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	region := world.ServerRegion{
+		ChunksX:        2,
+		ChunksY:        2,
+		ChunkDimension: world.Dimensions{Width: 16, Depth: 16, Height: 8},
+	}
+	mgr := entities.NewManager("test")
+	nav := pathfinding.NewBlockNavigator(region, world.NewManager(region, flatGenerator{}))
+	coord := NewCoordinator(region, mgr, nav, nil)
+
+	searches := 0
+	coord.findRoute = func(ctx context.Context, start, goal world.BlockCoord, profile pathfinding.UnitProfile) []world.BlockCoord {
+		searches++
+		return nav.FindRoute(ctx, start, goal, profile)
+	}
+
+	const squadSize = 12
+	for i := 0; i < squadSize; i++ {
+		ent := &entities.Entity{
+			ID:       entities.ID(fmt.Sprintf("unit-%02d", i)),
+			Kind:     entities.KindUnit,
+			Position: entities.Vec3{X: 10.5 + float64(i%4), Y: 10.5 + float64(i/4), Z: 1},
+			Stats:    entities.Stats{MaxHP: 100, CurrentHP: 100},
+		}
+		if err := mgr.Add(ent); err != nil {
+			t.Fatalf("add %s: %v", ent.ID, err)
+		}
+	}
+
+	const maxSearches = 1 + memberSearchesPerTick
+	for tick := 0; tick < 4; tick++ {
+		searches = 0
+		coord.Tick(33 * time.Millisecond)
+		if searches > maxSearches {
+			t.Fatalf("tick %d ran %d route searches for %d members, want at most %d", tick, searches, squadSize, maxSearches)
+		}
+		if tick == 0 && searches == 0 {
+			t.Fatalf("expected the first tick to search a squad route")
+		}
+	}
+
+	coord.mu.RLock()
+	shared := coord.routes[string(SquadRoleAssault)]
+	coord.mu.RUnlock()
+	if shared == nil || len(shared.path) == 0 {
+		t.Fatalf("expected the assault squad to share a route to its objective")
+	}
+
+	// Once the route is cached and nobody has moved, ticks search nothing.
+	searches = 0
+	coord.Tick(33 * time.Millisecond)
+	if searches != 0 {
+		t.Fatalf("expected a settled squad to reuse its routes, ran %d searches", searches)
+	}
+}
@@ -0,0 +1,175 @@
+// Package clock abstracts the wall clock so time-driven behaviour, such as the
+// tick loop and migration timeouts, can be tested without sleeping.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time and makes tickers.
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers the time on C every period until stopped. Reset restarts
+// it, stopped or not, with a new period.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+	Reset(d time.Duration)
+}
+
+// Real returns the system clock.
+func Real() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+// Manual is a Clock that only moves when Advance is called. Unlike a real
+// ticker, its tickers never drop ticks: Advance hands each one over and waits
+// for it to be received, so everything a tick drives has started by the time
+// the next tick is due.
+type Manual struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*manualTicker
+}
+
+// NewManual returns a Manual clock reading start.
+func NewManual(start time.Time) *Manual {
+	return &Manual{now: start}
+}
+
+func (m *Manual) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+// NewTicker returns a ticker that first fires d after the clock's current
+// time. It panics if d is not positive, like time.NewTicker.
+func (m *Manual) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := &manualTicker{clock: m, c: make(chan time.Time)}
+	t.startLocked(d)
+	return t
+}
+
+// Advance moves the clock forward by d, firing every tick that falls due in
+// order.
+func (m *Manual) Advance(d time.Duration) {
+	m.mu.Lock()
+	target := m.now.Add(d)
+	for {
+		var due *manualTicker
+		for _, t := range m.tickers {
+			if !t.next.After(target) && (due == nil || t.next.Before(due.next)) {
+				due = t
+			}
+		}
+		if due == nil {
+			break
+		}
+		at := due.next
+		m.now = at
+		due.next = at.Add(due.period)
+		done := due.done
+		m.mu.Unlock()
+		select {
+		case due.c <- at:
+		case <-done:
+		}
+		m.mu.Lock()
+	}
+	m.now = target
+	m.mu.Unlock()
+}
+
+// Tickers reports how many tickers are running. Tests use it to wait until
+// the code they drive has started its tickers before advancing the clock.
+func (m *Manual) Tickers() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.tickers)
+}
+
+func (m *Manual) removeLocked(t *manualTicker) {
+	for i, candidate := range m.tickers {
+		if candidate == t {
+			m.tickers = append(m.tickers[:i], m.tickers[i+1:]...)
+			return
+		}
+	}
+}
+
+type manualTicker struct {
+	clock  *Manual
+	period time.Duration
+	next   time.Time
+	c      chan time.Time
+	// done is closed when the ticker stops, releasing a tick Advance is
+	// still trying to deliver. Reset replaces it. Nil while stopped.
+	done chan struct{}
+}
+
+func (t *manualTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *manualTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.stopLocked()
+}
+
+// Reset stops the ticker and starts it again with period d, first firing d
+// after the clock's current time. It panics if d is not positive, like
+// time.Ticker.Reset.
+func (t *manualTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clock: non-positive interval for Ticker.Reset")
+	}
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.stopLocked()
+	t.startLocked(d)
+}
+
+func (t *manualTicker) startLocked(d time.Duration) {
+	t.period = d
+	t.next = t.clock.now.Add(d)
+	t.done = make(chan struct{})
+	t.clock.tickers = append(t.clock.tickers, t)
+}
+
+func (t *manualTicker) stopLocked() {
+	if t.done == nil {
+		return
+	}
+	close(t.done)
+	t.done = nil
+	t.clock.removeLocked(t)
+}
@@ -0,0 +1,83 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestManualTickerFiresEveryPeriodWithoutDropping(t *testing.T) {
+	start := time.Unix(100, 0)
+	clk := NewManual(start)
+	ticker := clk.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	got := make(chan time.Time, 8)
+	go func() {
+		for tick := range ticker.C() {
+			got <- tick
+		}
+	}()
+
+	clk.Advance(3500 * time.Millisecond)
+	for i := 1; i <= 3; i++ {
+		if tick := <-got; !tick.Equal(start.Add(time.Duration(i) * time.Second)) {
+			t.Fatalf("tick %d at %v, want %v", i, tick, start.Add(time.Duration(i)*time.Second))
+		}
+	}
+	if now := clk.Now(); !now.Equal(start.Add(3500 * time.Millisecond)) {
+		t.Fatalf("clock reads %v after advancing, want %v", now, start.Add(3500*time.Millisecond))
+	}
+
+	// The half second carried over makes the next tick due after 500ms.
+	clk.Advance(500 * time.Millisecond)
+	if tick := <-got; !tick.Equal(start.Add(4 * time.Second)) {
+		t.Fatalf("fourth tick at %v, want %v", tick, start.Add(4*time.Second))
+	}
+}
+
+func TestManualAdvanceSkipsStoppedTickers(t *testing.T) {
+	clk := NewManual(time.Unix(0, 0))
+	ticker := clk.NewTicker(time.Millisecond)
+	if n := clk.Tickers(); n != 1 {
+		t.Fatalf("Tickers() = %d with one ticker running, want 1", n)
+	}
+	ticker.Stop()
+	if n := clk.Tickers(); n != 0 {
+		t.Fatalf("Tickers() = %d after stopping, want 0", n)
+	}
+
+	// Nobody reads the stopped ticker, so Advance would block if it still
+	// tried to deliver to it.
+	clk.Advance(time.Second)
+	ticker.Stop()
+}
+
+func TestManualTickerResetRestartsFromNow(t *testing.T) {
+	start := time.Unix(0, 0)
+	clk := NewManual(start)
+	ticker := clk.NewTicker(time.Second)
+	defer ticker.Stop()
+	ticker.Stop()
+
+	got := make(chan time.Time, 8)
+	go func() {
+		for tick := range ticker.C() {
+			got <- tick
+		}
+	}()
+
+	clk.Advance(500 * time.Millisecond)
+	ticker.Reset(2 * time.Second)
+	clk.Advance(4 * time.Second)
+	for i := 1; i <= 2; i++ {
+		want := start.Add(500*time.Millisecond + time.Duration(2*i)*time.Second)
+		if tick := <-got; !tick.Equal(want) {
+			t.Fatalf("tick %d at %v, want %v", i, tick, want)
+		}
+	}
+	select {
+	case tick := <-got:
+		t.Fatalf("unexpected tick at %v", tick)
+	default:
+	}
+}
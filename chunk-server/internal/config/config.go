@@ -7,6 +7,8 @@ import (
 	"io"
 	"os"
 	"time"
+
+	"chunkserver/internal/logging"
 )
 
 // Duration is a JSON-friendly wrapper around time.Duration that accepts human
@@ -66,6 +68,10 @@ func (d *Duration) UnmarshalJSON(b []byte) error {
 
 // Config captures the tunable parameters needed to bootstrap a chunk server.
 type Config struct {
+	// WorldSeed derives the terrain, environment, and AI seeds that the file
+	// leaves out or sets to zero, so one number reproduces a whole world.
+	// Zero derives nothing.
+	WorldSeed   int64             `json:"worldSeed,omitempty"`
 	Server      ServerConfig      `json:"server"`
 	Chunk       ChunkConfig       `json:"chunk"`
 	Network     NetworkConfig     `json:"network"`
@@ -74,28 +80,59 @@ type Config struct {
 	Economy     EconomyConfig     `json:"economy"`
 	Entities    EntityConfig      `json:"entities"`
 	Environment EnvironmentConfig `json:"environment"`
+	Physics     PhysicsConfig     `json:"physics"`
 	Blocks      []BlockDefinition `json:"blocks"`
 }
 
 type ServerConfig struct {
-	ID                 string     `json:"id"`
-	Description        string     `json:"description"`
-	GlobalChunkOrigin  ChunkIndex `json:"globalChunkOrigin"`
-	TickRate           Duration   `json:"tickRate"`           // e.g. "33ms"
-	StateStreamRate    Duration   `json:"stateStreamRate"`    // frequency at which deltas are broadcast
-	EntityStreamRate   Duration   `json:"entityStreamRate"`   // frequency for entity refreshes
-	MaxConcurrentLoads int        `json:"maxConcurrentLoads"` // simultaneous chunk mesh/generation jobs
+	ID                  string     `json:"id"`
+	Description         string     `json:"description"`
+	GlobalChunkOrigin   ChunkIndex `json:"globalChunkOrigin"`
+	TickRate            Duration   `json:"tickRate"`                      // e.g. "33ms"
+	StateStreamRate     Duration   `json:"stateStreamRate"`               // frequency at which deltas are broadcast
+	EntityStreamRate    Duration   `json:"entityStreamRate"`              // frequency for entity refreshes
+	MaxConcurrentLoads  int        `json:"maxConcurrentLoads"`            // simultaneous chunk generations; 0 leaves them unbounded
+	DrainTimeout        Duration   `json:"drainTimeout"`                  // time allowed to flush state on shutdown
+	LogLevel            string     `json:"logLevel"`                      // debug, info, warn, or error
+	Storage             string     `json:"storage,omitempty"`             // "disk" (the default) or "memory"
+	StorageDir          string     `json:"storageDir,omitempty"`          // chunk files for disk storage; defaults to "chunks"
+	StorageMaxFileBytes int64      `json:"storageMaxFileBytes,omitempty"` // data file size before rolling over to a new part; 0 keeps 128MB
+	ChunkIdleTTL        Duration   `json:"chunkIdleTTL,omitempty"`        // unload chunks with no entities left unread this long; 0 keeps them resident; disk storage only
+	SummaryResync       Duration   `json:"summaryResync,omitempty"`       // resend every chunk summary this long after the last full pass; 0 only resends on a main server hello
 }
 
+// Chunk storage backends accepted by server.storage.
+const (
+	StorageDisk   = "disk"
+	StorageMemory = "memory"
+)
+
 type ChunkConfig struct {
 	Width         int `json:"width"`
 	Depth         int `json:"depth"`
 	Height        int `json:"height"`
 	ChunksPerAxis int `json:"chunksPerAxis"`
+	// ChunksX and ChunksY size a region that is not square; either one left
+	// at zero falls back to ChunksPerAxis.
+	ChunksX int `json:"chunksX,omitempty"`
+	ChunksY int `json:"chunksY,omitempty"`
+}
+
+// RegionSize returns how many chunks the server owns along X and Y.
+func (c ChunkConfig) RegionSize() (x, y int) {
+	x, y = c.ChunksX, c.ChunksY
+	if x <= 0 {
+		x = c.ChunksPerAxis
+	}
+	if y <= 0 {
+		y = c.ChunksPerAxis
+	}
+	return x, y
 }
 
 type NetworkConfig struct {
 	ListenUDP            string        `json:"listenUdp"`            // ":9000"
+	ListenHTTP           string        `json:"listenHttp"`           // optional stats/health endpoint, e.g. ":9001"
 	MainServerEndpoints  []string      `json:"mainServerEndpoints"`  // list of UDP endpoints to stream to
 	NeighborEndpoints    []NeighborRef `json:"neighborEndpoints"`    // optional explicit neighbor override
 	HandshakeTimeout     Duration      `json:"handshakeTimeout"`     // e.g. "3s"
@@ -103,6 +140,8 @@ type NetworkConfig struct {
 	MaxDatagramSizeBytes int           `json:"maxDatagramSizeBytes"` // default to 64 KiB - UDP practical limit
 	DiscoveryInterval    Duration      `json:"discoveryInterval"`    // how often to query for neighbors
 	TransferRetry        Duration      `json:"transferRetry"`        // back-off for failed chunk transfers
+	TransferMaxAttempts  int           `json:"transferMaxAttempts"`  // transfer requests sent before a migration is dead-lettered; 0 retries forever
+	RecordPath           string        `json:"recordPath"`           // optional capture of every envelope sent and received
 }
 
 type NeighborRef struct {
@@ -112,10 +151,31 @@ type NeighborRef struct {
 
 type PathfindingConfig struct {
 	MaxSearchNodes    int      `json:"maxSearchNodes"`
-	HeuristicScale    float64  `json:"heuristicScale"`
+	HeuristicScale    float64  `json:"heuristicScale"` // >1 trades route optimality for faster, greedier search
 	AsyncWorkers      int      `json:"asyncWorkers"`
 	ThrottlePerSecond int      `json:"throttlePerSecond"`
 	QueueTimeout      Duration `json:"queueTimeout"`
+	// Profiles tunes the traversal defaults of each unit mode ("ground",
+	// "flying", "underground"). Fields left out keep the built-in value.
+	Profiles map[string]ProfileOverride `json:"profiles,omitempty"`
+}
+
+// ProfileOverride replaces selected fields of a mode's default unit profile.
+type ProfileOverride struct {
+	Clearance *int  `json:"clearance,omitempty"`
+	MaxClimb  *int  `json:"maxClimb,omitempty"`
+	MaxDrop   *int  `json:"maxDrop,omitempty"`
+	CanDig    *bool `json:"canDig,omitempty"`
+	// TunnelWidth is how many blocks wide a digging unit's passage must be.
+	TunnelWidth *int `json:"tunnelWidth,omitempty"`
+	// AllowDiagonal lets units step diagonally across X/Y.
+	AllowDiagonal *bool `json:"allowDiagonal,omitempty"`
+	// Heuristic is "manhattan", "octile", or "euclidean"; empty picks octile
+	// for diagonal movement and Manhattan otherwise.
+	Heuristic string `json:"heuristic,omitempty"`
+	// WallPenalty is the extra cost per blocked side of a cell, steering
+	// routes away from walls.
+	WallPenalty *float64 `json:"wallPenalty,omitempty"`
 }
 
 type TerrainConfig struct {
@@ -129,12 +189,24 @@ type TerrainConfig struct {
         SurfaceRatio     float64 `json:"surfaceRatio"`
         AmplitudeRatio   float64 `json:"amplitudeRatio"`
         UndergroundRatio float64 `json:"undergroundRatio"`
+        // WriteBufferBytes and WriteBufferColumns bound how much generated
+        // terrain is held before it is written to the chunk. Zero bytes uses
+        // 256MB; zero columns leaves the column count unbounded.
+        WriteBufferBytes   int64 `json:"writeBufferBytes,omitempty"`
+        WriteBufferColumns int   `json:"writeBufferColumns,omitempty"`
+        // TreesReplaceOre lets trees take the place of mineral blocks,
+        // carrying each block's resource yield into the tree. By default
+        // trees grow around ore.
+        TreesReplaceOre bool `json:"treesReplaceOre,omitempty"`
+        // ProgressLogThreshold is how long a chunk must take to generate
+        // before its progress is logged. Zero uses two seconds.
+        ProgressLogThreshold Duration `json:"progressLogThreshold,omitempty"`
 }
 
 type EconomyConfig struct {
 	ResourceSpawnDensity map[string]float64 `json:"resourceSpawnDensity"`
 	MiningLevelGrowth    float64            `json:"miningLevelGrowth"` // multiplier per miner level
-	BaseMiningRate       float64            `json:"baseMiningRate"`    // blocks per second
+	BaseMiningRate       float64            `json:"baseMiningRate"`    // block hit points mined per second at level 0
 }
 
 type EntityConfig struct {
@@ -142,6 +214,8 @@ type EntityConfig struct {
 	EntityTickRate      Duration `json:"entityTickRate"`
 	ProjectileTickRate  Duration `json:"projectileTickRate"`
 	MovementWorkers     int      `json:"movementWorkers"`
+	SleepAfterTicks     int      `json:"sleepAfterTicks"` // idle ticks before a chunk's entities stop ticking; 0 disables
+	AISeed              int64    `json:"aiSeed"`          // seeds random AI decisions so runs replay identically
 }
 
 type EnvironmentConfig struct {
@@ -152,7 +226,36 @@ type EnvironmentConfig struct {
 	RainChance         float64  `json:"rainChance"`
 	WindBase           float64  `json:"windBase"`
 	WindVariance       float64  `json:"windVariance"`
+	TransitionHours    float64  `json:"transitionHours"`
 	Seed               int64    `json:"seed"`
+	// WeatherOverrides pin localized weather over ranges of global chunks.
+	WeatherOverrides []WeatherOverrideConfig `json:"weatherOverrides"`
+}
+
+type WeatherOverrideConfig struct {
+	ID            string     `json:"id"`
+	MinChunk      ChunkIndex `json:"minChunk"`
+	MaxChunk      ChunkIndex `json:"maxChunk"`
+	Kind          string     `json:"kind"`
+	Intensity     float64    `json:"intensity"`
+	WindSpeed     float64    `json:"windSpeed"`
+	WindDirection float64    `json:"windDirection"` // radians
+	Precipitation float64    `json:"precipitation"`
+	BlendChunks   int        `json:"blendChunks"` // width of the edge band that fades into global weather
+}
+
+// PhysicsConfig tunes block stability and how hard collapsing blocks hit the
+// entities around them.
+type PhysicsConfig struct {
+	GroundSupportForce   float64 `json:"groundSupportForce"`   // support the bottom layer draws from bedrock
+	HangingPenalty       float64 `json:"hangingPenalty"`       // support multiplier per block hanging over air, in (0, 1]
+	SupportFactor        float64 `json:"supportFactor"`        // support needed per unit of load for a block to stand
+	CollapseImpactRadius float64 `json:"collapseImpactRadius"` // blocks from a collapse within which entities are hurt
+	CollapseImpactDamage float64 `json:"collapseImpactDamage"` // damage at the collapse point, falling off to zero at the radius
+	Gravity              float64 `json:"gravity"`              // entity fall acceleration in clear weather, blocks/s²
+	AirDrag              float64 `json:"airDrag"`              // velocity damping per second for airborne entities
+	GroundFriction       float64 `json:"groundFriction"`       // velocity damping per second for grounded entities
+	MaxFallSpeed         float64 `json:"maxFallSpeed"`         // terminal downward speed, blocks/s
 }
 
 type ChunkIndex struct {
@@ -194,6 +297,10 @@ func Load(path string) (*Config, error) {
 	if err := json.Unmarshal(data, cfg); err != nil {
 		return nil, fmt.Errorf("parse config: %w", err)
 	}
+	if err := cfg.clearDefaultSeeds(data); err != nil {
+		return nil, fmt.Errorf("parse config: %w", err)
+	}
+	cfg.DeriveSeeds()
 
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("validate config: %w", err)
@@ -211,6 +318,9 @@ func Default() *Config {
 			StateStreamRate:    Duration(200 * time.Millisecond),
 			EntityStreamRate:   Duration(50 * time.Millisecond),
 			MaxConcurrentLoads: 4,
+			DrainTimeout:       Duration(8 * time.Second),
+			LogLevel:           "info",
+			SummaryResync:      Duration(10 * time.Minute),
 		},
                 Chunk: ChunkConfig{
                         Width:         256,
@@ -227,6 +337,7 @@ func Default() *Config {
 			MaxDatagramSizeBytes: 1 << 16,
 			DiscoveryInterval:    Duration(10 * time.Second),
 			TransferRetry:        Duration(2 * time.Second),
+			TransferMaxAttempts:  5,
 		},
 		Pathfinding: PathfindingConfig{
 			MaxSearchNodes:    50_000,
@@ -263,6 +374,7 @@ func Default() *Config {
 			EntityTickRate:      Duration(33 * time.Millisecond),
 			ProjectileTickRate:  Duration(16 * time.Millisecond),
 			MovementWorkers:     1,
+			SleepAfterTicks:     90,
 		},
 		Environment: EnvironmentConfig{
 			DayLength:          Duration(20 * time.Minute),
@@ -272,8 +384,20 @@ func Default() *Config {
 			RainChance:         0.35,
 			WindBase:           3.0,
 			WindVariance:       5.0,
+			TransitionHours:    1.0,
 			Seed:               1337,
 		},
+		Physics: PhysicsConfig{
+			GroundSupportForce:   1e6,
+			HangingPenalty:       0.72,
+			SupportFactor:        1.0,
+			CollapseImpactRadius: 3.5,
+			CollapseImpactDamage: 45.0,
+			Gravity:              9.8,
+			AirDrag:              0.4,
+			GroundFriction:       4,
+			MaxFallSpeed:         150,
+		},
 		Blocks: defaultBlockDefinitions(),
 	}
 }
@@ -282,39 +406,158 @@ func (c *Config) Validate() error {
 	if c.Server.ID == "" {
 		return errors.New("server.id must be set")
 	}
+	if c.Server.MaxConcurrentLoads < 0 {
+		return errors.New("server.maxConcurrentLoads cannot be negative")
+	}
+	if c.Server.DrainTimeout < 0 {
+		return errors.New("server.drainTimeout cannot be negative")
+	}
+	if c.Server.ChunkIdleTTL < 0 {
+		return errors.New("server.chunkIdleTTL cannot be negative")
+	}
+	if c.Server.SummaryResync < 0 {
+		return errors.New("server.summaryResync cannot be negative")
+	}
+	if _, err := logging.ParseLevel(c.Server.LogLevel); err != nil {
+		return fmt.Errorf("server.logLevel: %w", err)
+	}
+	if c.Server.StorageMaxFileBytes < 0 {
+		return errors.New("server.storageMaxFileBytes cannot be negative")
+	}
+	switch c.Server.Storage {
+	case "", StorageDisk, StorageMemory:
+	default:
+		return fmt.Errorf("server.storage must be %q or %q, got %q", StorageDisk, StorageMemory, c.Server.Storage)
+	}
+	if c.Server.Storage == StorageMemory && c.Server.ChunkIdleTTL > 0 {
+		return errors.New("server.chunkIdleTTL requires disk storage; memory storage would lose unloaded chunks")
+	}
 	if c.Chunk.Width <= 0 || c.Chunk.Depth <= 0 || c.Chunk.Height <= 0 {
 		return errors.New("chunk dimensions must be positive")
 	}
-	if c.Chunk.ChunksPerAxis <= 0 {
+	if x, y := c.Chunk.RegionSize(); x <= 0 || y <= 0 {
 		return errors.New("chunk.chunksPerAxis must be positive")
 	}
 	if c.Network.ListenUDP == "" {
 		return errors.New("network.listenUdp must be set")
 	}
+	if c.Network.TransferMaxAttempts < 0 {
+		return errors.New("network.transferMaxAttempts cannot be negative")
+	}
 	if c.Entities.MaxEntitiesPerChunk <= 0 {
 		return errors.New("entities.maxEntitiesPerChunk must be positive")
 	}
 	if c.Entities.MovementWorkers < 0 {
 		return errors.New("entities.movementWorkers cannot be negative")
 	}
+	if c.Entities.SleepAfterTicks < 0 {
+		return errors.New("entities.sleepAfterTicks cannot be negative")
+	}
+	if err := validateProfiles(c.Pathfinding.Profiles); err != nil {
+		return err
+	}
 	if c.Terrain.Workers < 0 {
 		return errors.New("terrain.workers cannot be negative")
 	}
+	if c.Terrain.WriteBufferBytes < 0 || c.Terrain.WriteBufferColumns < 0 {
+		return errors.New("terrain.writeBufferBytes and terrain.writeBufferColumns cannot be negative")
+	}
+	if c.Terrain.ProgressLogThreshold < 0 {
+		return errors.New("terrain.progressLogThreshold cannot be negative")
+	}
 	if c.Environment.WeatherMaxDuration > 0 && c.Environment.WeatherMaxDuration < c.Environment.WeatherMinDuration {
 		return errors.New("environment.weatherMaxDuration must be >= weatherMinDuration")
 	}
+	if c.Environment.TransitionHours <= 0 || c.Environment.TransitionHours > 12 {
+		return errors.New("environment.transitionHours must be greater than 0 and at most 12")
+	}
 	if c.Environment.StormChance < 0 || c.Environment.RainChance < 0 {
 		return errors.New("environment storm/rain chances cannot be negative")
 	}
 	if c.Environment.StormChance+c.Environment.RainChance > 1.0 {
 		return errors.New("environment storm+rain chance must be <= 1")
 	}
+	if err := validateWeatherOverrides(c.Environment.WeatherOverrides); err != nil {
+		return err
+	}
+	if c.Physics.GroundSupportForce < 0 {
+		return errors.New("physics.groundSupportForce cannot be negative")
+	}
+	if c.Physics.HangingPenalty <= 0 || c.Physics.HangingPenalty > 1 {
+		return errors.New("physics.hangingPenalty must be greater than 0 and at most 1")
+	}
+	if c.Physics.SupportFactor <= 0 {
+		return errors.New("physics.supportFactor must be positive")
+	}
+	if c.Physics.CollapseImpactRadius < 0 || c.Physics.CollapseImpactDamage < 0 {
+		return errors.New("physics collapse impact radius and damage cannot be negative")
+	}
+	if c.Physics.Gravity < 0 || c.Physics.AirDrag < 0 || c.Physics.GroundFriction < 0 {
+		return errors.New("physics gravity, air drag, and ground friction cannot be negative")
+	}
+	if c.Physics.MaxFallSpeed <= 0 {
+		return errors.New("physics.maxFallSpeed must be positive")
+	}
 	if err := validateBlocks(c.Blocks); err != nil {
 		return err
 	}
 	return nil
 }
 
+func validateProfiles(profiles map[string]ProfileOverride) error {
+	for mode, override := range profiles {
+		switch mode {
+		case "ground", "flying", "underground":
+		default:
+			return fmt.Errorf("pathfinding.profiles: unknown mode %q (want ground, flying, or underground)", mode)
+		}
+		if override.Clearance != nil && *override.Clearance <= 0 {
+			return fmt.Errorf("pathfinding.profiles.%s.clearance must be positive", mode)
+		}
+		if override.MaxClimb != nil && *override.MaxClimb < 0 {
+			return fmt.Errorf("pathfinding.profiles.%s.maxClimb cannot be negative", mode)
+		}
+		if override.MaxDrop != nil && *override.MaxDrop < 0 {
+			return fmt.Errorf("pathfinding.profiles.%s.maxDrop cannot be negative", mode)
+		}
+		if override.TunnelWidth != nil && *override.TunnelWidth <= 0 {
+			return fmt.Errorf("pathfinding.profiles.%s.tunnelWidth must be positive", mode)
+		}
+		if override.WallPenalty != nil && *override.WallPenalty < 0 {
+			return fmt.Errorf("pathfinding.profiles.%s.wallPenalty cannot be negative", mode)
+		}
+		switch override.Heuristic {
+		case "", "manhattan", "octile", "euclidean":
+		default:
+			return fmt.Errorf("pathfinding.profiles.%s.heuristic must be manhattan, octile, or euclidean", mode)
+		}
+	}
+	return nil
+}
+
+func validateWeatherOverrides(overrides []WeatherOverrideConfig) error {
+	for i, override := range overrides {
+		if override.MaxChunk.X < override.MinChunk.X || override.MaxChunk.Y < override.MinChunk.Y {
+			return fmt.Errorf("environment.weatherOverrides[%d].maxChunk must be >= minChunk", i)
+		}
+		switch override.Kind {
+		case "clear", "rain", "storm":
+		default:
+			return fmt.Errorf("environment.weatherOverrides[%d].kind must be clear, rain, or storm", i)
+		}
+		if override.Intensity < 0 || override.Intensity > 1 || override.Precipitation < 0 || override.Precipitation > 1 {
+			return fmt.Errorf("environment.weatherOverrides[%d] intensity and precipitation must be between 0 and 1", i)
+		}
+		if override.WindSpeed < 0 {
+			return fmt.Errorf("environment.weatherOverrides[%d].windSpeed cannot be negative", i)
+		}
+		if override.BlendChunks < 0 {
+			return fmt.Errorf("environment.weatherOverrides[%d].blendChunks cannot be negative", i)
+		}
+	}
+	return nil
+}
+
 func validateBlocks(blocks []BlockDefinition) error {
 	if len(blocks) == 0 {
 		return errors.New("blocks cannot be empty")
@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestValidateDefaultConfig(t *testing.T) {
@@ -29,6 +30,55 @@ func TestValidateDetectsInvalidConfigurations(t *testing.T) {
 			},
 			wantErr: "server.id must be set",
 		},
+		{
+			name: "negative concurrent loads",
+			mutate: func(cfg *Config) {
+				cfg.Server.MaxConcurrentLoads = -1
+			},
+			wantErr: "server.maxConcurrentLoads cannot be negative",
+		},
+		{
+			name: "negative chunk idle ttl",
+			mutate: func(cfg *Config) {
+				cfg.Server.ChunkIdleTTL = Duration(-time.Second)
+			},
+			wantErr: "server.chunkIdleTTL cannot be negative",
+		},
+		{
+			name: "unknown storage backend",
+			mutate: func(cfg *Config) {
+				cfg.Server.Storage = "tape"
+			},
+			wantErr: `server.storage must be "disk" or "memory", got "tape"`,
+		},
+		{
+			name: "hanging penalty above one",
+			mutate: func(cfg *Config) {
+				cfg.Physics.HangingPenalty = 1.5
+			},
+			wantErr: "physics.hangingPenalty must be greater than 0 and at most 1",
+		},
+		{
+			name: "non positive support factor",
+			mutate: func(cfg *Config) {
+				cfg.Physics.SupportFactor = 0
+			},
+			wantErr: "physics.supportFactor must be positive",
+		},
+		{
+			name: "negative collapse impact radius",
+			mutate: func(cfg *Config) {
+				cfg.Physics.CollapseImpactRadius = -1
+			},
+			wantErr: "physics collapse impact radius and damage cannot be negative",
+		},
+		{
+			name: "zero max fall speed",
+			mutate: func(cfg *Config) {
+				cfg.Physics.MaxFallSpeed = 0
+			},
+			wantErr: "physics.maxFallSpeed must be positive",
+		},
 		{
 			name: "non positive chunk dimensions",
 			mutate: func(cfg *Config) {
@@ -50,6 +100,21 @@ func TestValidateDetectsInvalidConfigurations(t *testing.T) {
 			},
 			wantErr: "network.listenUdp must be set",
 		},
+		{
+			name: "idle unload with memory storage",
+			mutate: func(cfg *Config) {
+				cfg.Server.Storage = StorageMemory
+				cfg.Server.ChunkIdleTTL = Duration(time.Minute)
+			},
+			wantErr: "server.chunkIdleTTL requires disk storage; memory storage would lose unloaded chunks",
+		},
+		{
+			name: "negative transfer attempts",
+			mutate: func(cfg *Config) {
+				cfg.Network.TransferMaxAttempts = -1
+			},
+			wantErr: "network.transferMaxAttempts cannot be negative",
+		},
 		{
 			name: "non positive max entities",
 			mutate: func(cfg *Config) {
@@ -71,6 +136,62 @@ func TestValidateDetectsInvalidConfigurations(t *testing.T) {
 			},
 			wantErr: "terrain.workers cannot be negative",
 		},
+		{
+			name: "inverted weather override range",
+			mutate: func(cfg *Config) {
+				cfg.Environment.WeatherOverrides = []WeatherOverrideConfig{{
+					MinChunk: ChunkIndex{X: 4, Y: 0},
+					MaxChunk: ChunkIndex{X: 2, Y: 3},
+					Kind:     "storm",
+				}}
+			},
+			wantErr: "environment.weatherOverrides[0].maxChunk must be >= minChunk",
+		},
+		{
+			name: "zero transition hours",
+			mutate: func(cfg *Config) {
+				cfg.Environment.TransitionHours = 0
+			},
+			wantErr: "environment.transitionHours must be greater than 0 and at most 12",
+		},
+		{
+			name: "unknown profile mode",
+			mutate: func(cfg *Config) {
+				cfg.Pathfinding.Profiles = map[string]ProfileOverride{"swimming": {}}
+			},
+			wantErr: `pathfinding.profiles: unknown mode "swimming" (want ground, flying, or underground)`,
+		},
+		{
+			name: "negative profile climb",
+			mutate: func(cfg *Config) {
+				climb := -1
+				cfg.Pathfinding.Profiles = map[string]ProfileOverride{"flying": {MaxClimb: &climb}}
+			},
+			wantErr: "pathfinding.profiles.flying.maxClimb cannot be negative",
+		},
+		{
+			name: "zero profile tunnel width",
+			mutate: func(cfg *Config) {
+				width := 0
+				cfg.Pathfinding.Profiles = map[string]ProfileOverride{"underground": {TunnelWidth: &width}}
+			},
+			wantErr: "pathfinding.profiles.underground.tunnelWidth must be positive",
+		},
+		{
+			name: "negative profile wall penalty",
+			mutate: func(cfg *Config) {
+				penalty := -1.0
+				cfg.Pathfinding.Profiles = map[string]ProfileOverride{"ground": {WallPenalty: &penalty}}
+			},
+			wantErr: "pathfinding.profiles.ground.wallPenalty cannot be negative",
+		},
+		{
+			name: "unknown profile heuristic",
+			mutate: func(cfg *Config) {
+				cfg.Pathfinding.Profiles = map[string]ProfileOverride{"ground": {Heuristic: "chebyshev"}}
+			},
+			wantErr: "pathfinding.profiles.ground.heuristic must be manhattan, octile, or euclidean",
+		},
 		{
 			name: "missing block id",
 			mutate: func(cfg *Config) {
@@ -153,3 +274,87 @@ func TestLoadInvalidConfiguration(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestLoadDerivesZeroSeedsFromWorldSeed(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	data := `{"worldSeed": 42, "terrain": {"seed": 0}, "environment": {"seed": 0}, "entities": {"aiSeed": 0}}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	first, err := Load(path)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	second, err := Load(path)
+	if err != nil {
+		t.Fatalf("reload config: %v", err)
+	}
+	seeds := []int64{first.Terrain.Seed, first.Environment.Seed, first.Entities.AISeed}
+	if seeds[0] != second.Terrain.Seed || seeds[1] != second.Environment.Seed || seeds[2] != second.Entities.AISeed {
+		t.Fatalf("the same world seed derived different seeds: %v and %v", seeds,
+			[]int64{second.Terrain.Seed, second.Environment.Seed, second.Entities.AISeed})
+	}
+	if seeds[0] == 0 || seeds[1] == 0 || seeds[2] == 0 || seeds[0] == seeds[1] || seeds[1] == seeds[2] || seeds[0] == seeds[2] {
+		t.Fatalf("expected distinct non-zero subsystem seeds, got %v", seeds)
+	}
+	if DeriveSeed(43, "terrain") == seeds[0] {
+		t.Fatalf("a different world seed derived the same terrain seed")
+	}
+}
+
+func TestLoadDerivesDefaultSeedsFromWorldSeedAlone(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"worldSeed": 42}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if want := DeriveSeed(42, seedTerrain); cfg.Terrain.Seed != want {
+		t.Fatalf("terrain seed = %d, want %d derived from the world seed", cfg.Terrain.Seed, want)
+	}
+	if want := DeriveSeed(42, seedEnvironment); cfg.Environment.Seed != want {
+		t.Fatalf("environment seed = %d, want %d derived from the world seed", cfg.Environment.Seed, want)
+	}
+	if want := DeriveSeed(42, seedAI); cfg.Entities.AISeed != want {
+		t.Fatalf("AI seed = %d, want %d derived from the world seed", cfg.Entities.AISeed, want)
+	}
+}
+
+func TestLoadKeepsSeedsSetInTheFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"worldSeed": 42, "terrain": {"seed": 1337}}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.Terrain.Seed != 1337 {
+		t.Fatalf("explicit terrain seed replaced with %d", cfg.Terrain.Seed)
+	}
+	if want := DeriveSeed(42, seedEnvironment); cfg.Environment.Seed != want {
+		t.Fatalf("environment seed = %d, want %d derived from the world seed", cfg.Environment.Seed, want)
+	}
+}
+
+func TestDeriveSeedsKeepsExplicitSeeds(t *testing.T) {
+	cfg := Default()
+	cfg.WorldSeed = 42
+	cfg.Environment.Seed = 0
+	cfg.DeriveSeeds()
+
+	if cfg.Terrain.Seed != 1337 {
+		t.Fatalf("explicit terrain seed replaced with %d", cfg.Terrain.Seed)
+	}
+	if want := DeriveSeed(42, "environment"); cfg.Environment.Seed != want {
+		t.Fatalf("environment seed = %d, want %d derived from the world seed", cfg.Environment.Seed, want)
+	}
+}
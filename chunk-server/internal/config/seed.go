@@ -0,0 +1,82 @@
+package config
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"hash/fnv"
+)
+
+// Subsystems whose seeds DeriveSeeds fills in from the world seed.
+const (
+	seedTerrain     = "terrain"
+	seedEnvironment = "environment"
+	seedAI          = "ai"
+)
+
+// DeriveSeeds replaces the terrain, environment, and AI seeds that are zero
+// with ones hashed from WorldSeed and the subsystem's name. Explicit seeds are
+// kept, and nothing changes while WorldSeed is zero. Load calls it after
+// reading the file, once seeds the file leaves out are cleared back to zero.
+func (c *Config) DeriveSeeds() {
+	if c.WorldSeed == 0 {
+		return
+	}
+	if c.Terrain.Seed == 0 {
+		c.Terrain.Seed = DeriveSeed(c.WorldSeed, seedTerrain)
+	}
+	if c.Environment.Seed == 0 {
+		c.Environment.Seed = DeriveSeed(c.WorldSeed, seedEnvironment)
+	}
+	if c.Entities.AISeed == 0 {
+		c.Entities.AISeed = DeriveSeed(c.WorldSeed, seedAI)
+	}
+}
+
+// clearDefaultSeeds zeroes the subsystem seeds that data, the raw config file,
+// leaves unset, so DeriveSeeds replaces the built-in defaults instead of
+// keeping them. Without a world seed the defaults stay.
+func (c *Config) clearDefaultSeeds(data []byte) error {
+	if c.WorldSeed == 0 {
+		return nil
+	}
+	var set struct {
+		Terrain struct {
+			Seed *int64 `json:"seed"`
+		} `json:"terrain"`
+		Environment struct {
+			Seed *int64 `json:"seed"`
+		} `json:"environment"`
+		Entities struct {
+			AISeed *int64 `json:"aiSeed"`
+		} `json:"entities"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return err
+	}
+	if set.Terrain.Seed == nil {
+		c.Terrain.Seed = 0
+	}
+	if set.Environment.Seed == nil {
+		c.Environment.Seed = 0
+	}
+	if set.Entities.AISeed == nil {
+		c.Entities.AISeed = 0
+	}
+	return nil
+}
+
+// DeriveSeed hashes world and subsystem into a seed for that subsystem. The
+// result is never zero, since a zero environment seed means "seed from the
+// clock".
+func DeriveSeed(world int64, subsystem string) int64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(world))
+	h.Write(buf[:])
+	h.Write([]byte(subsystem))
+	seed := int64(h.Sum64())
+	if seed == 0 {
+		return 1
+	}
+	return seed
+}
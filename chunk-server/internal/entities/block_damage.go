@@ -0,0 +1,94 @@
+package entities
+
+import "sort"
+
+// VoxelsPerBlock converts entity block offsets, measured in entity voxels, to
+// world blocks.
+const VoxelsPerBlock = 20.0
+
+// BlockPosition returns where block sits in the world when its entity is at
+// pos. Orientation is ignored.
+func BlockPosition(pos Vec3, block EntityBlock) Vec3 {
+	return Vec3{
+		X: pos.X + block.Offset.X/VoxelsPerBlock,
+		Y: pos.Y + block.Offset.Y/VoxelsPerBlock,
+		Z: pos.Z + block.Offset.Z/VoxelsPerBlock,
+	}
+}
+
+// BlockHitPoints returns the hit points a block of an entity starts with: its
+// current hit points, or its maximum when none are recorded.
+func BlockHitPoints(block EntityBlock) float64 {
+	if block.Block.HitPoints > 0 {
+		return block.Block.HitPoints
+	}
+	return block.Block.MaxHitPoints
+}
+
+// BlockDestroyed reports whether blocks[i] has run out of hit points. Blocks
+// built without hit points take no block damage and are never destroyed, and
+// neither are blocks without an entry in hp.
+func BlockDestroyed(blocks []EntityBlock, hp []float64, i int) bool {
+	return i < len(hp) && hp[i] <= 0 && BlockHitPoints(blocks[i]) > 0
+}
+
+// fillBlockHP gives every block without an entry in Stats.BlockHP its
+// starting hit points, so entities built with only their blocks take block
+// damage. Blocks built without hit points get a zero entry, which
+// BlockDestroyed treats as intact rather than destroyed.
+func (e *Entity) fillBlockHP() {
+	for i := len(e.Stats.BlockHP); i < len(e.Blocks); i++ {
+		e.Stats.BlockHP = append(e.Stats.BlockHP, BlockHitPoints(e.Blocks[i]))
+	}
+}
+
+// applyDamageFrom takes amount from the entity's hit points, as applyDamage
+// does, and from its blocks: the surviving block nearest to from absorbs as
+// much as its hit points allow and the rest carries on to the next nearest,
+// so the side facing a blast is destroyed first. Manager.Add gives every block
+// an entry in Stats.BlockHP; blocks without one, and blocks built without hit
+// points, are not damaged. It returns the hit points left.
+func (e *Entity) applyDamageFrom(from Vec3, amount float64) float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	n := min(len(e.Blocks), len(e.Stats.BlockHP))
+	order := make([]int, 0, n)
+	dist := make([]float64, n)
+	for i := 0; i < n; i++ {
+		if e.Stats.BlockHP[i] <= 0 || BlockHitPoints(e.Blocks[i]) <= 0 {
+			continue
+		}
+		order = append(order, i)
+		dist[i] = distance(BlockPosition(e.Position, e.Blocks[i]), from)
+	}
+	sort.SliceStable(order, func(a, b int) bool { return dist[order[a]] < dist[order[b]] })
+	remaining := amount
+	for _, i := range order {
+		if remaining <= 0 {
+			break
+		}
+		taken := min(remaining, e.Stats.BlockHP[i])
+		e.Stats.BlockHP[i] -= taken
+		remaining -= taken
+	}
+	return e.loseHPLocked(amount)
+}
+
+// RoleDestroyed reports whether the entity has blocks with role and every one
+// of them has been destroyed, as BlockDestroyed reports.
+func (e *Entity) RoleDestroyed(role EntityBlockRole) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	found := false
+	for i, block := range e.Blocks {
+		if block.Role != role {
+			continue
+		}
+		if !BlockDestroyed(e.Blocks, e.Stats.BlockHP, i) {
+			return false
+		}
+		found = true
+	}
+	return found
+}
@@ -0,0 +1,73 @@
+package entities
+
+import (
+	"testing"
+
+	"chunkserver/internal/world"
+)
+
+func TestDamageFromSpillsFromNearestBlockOutward(t *testing.T) {
+	m := NewManager("server-a")
+	ent := &Entity{
+		ID: "unit",
+		Blocks: []EntityBlock{
+			{Role: BlockRoleThruster, Offset: Vec3{X: 40}, Block: world.Block{MaxHitPoints: 30}},
+			{Role: BlockRoleThruster, Offset: Vec3{X: -40}, Block: world.Block{MaxHitPoints: 30}},
+			{Role: BlockRoleStructure, Block: world.Block{MaxHitPoints: 30}},
+		},
+		Stats: Stats{MaxHP: 90, CurrentHP: 90, BlockHP: []float64{30, 30, 30}},
+	}
+	if err := m.Add(ent); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	var events []EntityEvent
+	m.OnEvent(func(event EntityEvent) { events = append(events, event) })
+
+	m.DamageFrom(ent, Vec3{X: -10}, 45)
+	if got := ent.Stats.BlockHP; got[0] != 30 || got[1] != 0 || got[2] != 15 {
+		t.Fatalf("block hit points = %v, want [30 0 15]", got)
+	}
+	if ent.Stats.CurrentHP != 45 {
+		t.Fatalf("hit points = %v, want 45", ent.Stats.CurrentHP)
+	}
+	if ent.RoleDestroyed(BlockRoleThruster) {
+		t.Fatalf("one thruster survives, so the role is not destroyed")
+	}
+	if len(events) != 1 || events[0].Type != EventDamaged || events[0].Damage != 45 {
+		t.Fatalf("events = %+v, want one damaged event for 45", events)
+	}
+
+	m.DamageFrom(ent, Vec3{X: 10}, 60)
+	if got := ent.Stats.BlockHP; got[0] != 0 || got[2] != 0 {
+		t.Fatalf("block hit points = %v, want every block destroyed", got)
+	}
+	if !ent.RoleDestroyed(BlockRoleThruster) || !ent.Dying {
+		t.Fatalf("expected every thruster destroyed and the entity dying")
+	}
+}
+
+func TestBlocksWithoutHitPointsTakeNoBlockDamage(t *testing.T) {
+	m := NewManager("server-a")
+	ent := &Entity{
+		ID: "flyer",
+		Blocks: []EntityBlock{
+			{Role: BlockRoleThruster},
+			{Role: BlockRoleWeapon, Block: world.Block{MaxHitPoints: 20}},
+		},
+		Stats: Stats{MaxHP: 100, CurrentHP: 100},
+	}
+	if err := m.Add(ent); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if ent.RoleDestroyed(BlockRoleThruster) {
+		t.Fatalf("a thruster built without hit points should count as intact")
+	}
+
+	m.DamageFrom(ent, Vec3{}, 30)
+	if got := ent.Stats.BlockHP; got[0] != 0 || got[1] != 0 {
+		t.Fatalf("block hit points = %v, want the weapon to absorb 20", got)
+	}
+	if ent.RoleDestroyed(BlockRoleThruster) || !ent.RoleDestroyed(BlockRoleWeapon) {
+		t.Fatalf("expected only the weapon destroyed")
+	}
+}
@@ -0,0 +1,29 @@
+package entities
+
+import (
+	"fmt"
+
+	"chunkserver/internal/world"
+)
+
+// ChunkFullError is returned by Manager.Add and Manager.Transfer when the
+// target chunk already holds as many entities as the manager allows.
+type ChunkFullError struct {
+	Chunk world.ChunkCoord
+	Limit int
+}
+
+func (e *ChunkFullError) Error() string {
+	return fmt.Sprintf("chunk %v is full: %d entities", e.Chunk, e.Limit)
+}
+
+// SetMaxPerChunk caps how many entities Add and Transfer let a chunk hold.
+// Zero removes the cap. Entities already over it are kept.
+func (m *Manager) SetMaxPerChunk(n int) {
+	if n < 0 {
+		n = 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxPerChunk = n
+}
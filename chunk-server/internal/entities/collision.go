@@ -0,0 +1,56 @@
+package entities
+
+// CollisionFilter reports whether a collision or radius query should skip an
+// entity. A nil filter skips nothing.
+type CollisionFilter func(*Entity) bool
+
+// Skips reports whether f excludes ent.
+func (f CollisionFilter) Skips(ent *Entity) bool {
+	return f != nil && f(ent)
+}
+
+// IgnoreFriendly returns a filter that skips source and every entity source
+// does not collide with: its owner, what it owns, entities sharing its owner,
+// and members of its collision group. A nil source skips nothing.
+func IgnoreFriendly(source *Entity) CollisionFilter {
+	if source == nil {
+		return nil
+	}
+	return func(ent *Entity) bool {
+		return !source.CollidesWith(ent)
+	}
+}
+
+// CollidesWith reports whether e and other can collide with or damage each
+// other. An entity never collides with itself, with its owner or the entities
+// it owns, with entities that share its owner, or with entities in the same
+// non-empty collision group.
+func (e *Entity) CollidesWith(other *Entity) bool {
+	if e == other {
+		return false
+	}
+	a, b := e.collisionIdentity(), other.collisionIdentity()
+	switch {
+	case a.id == b.id:
+		return false
+	case a.owner == b.id || b.owner == a.id:
+		return false
+	case a.owner != "" && a.owner == b.owner:
+		return false
+	case a.group != "" && a.group == b.group:
+		return false
+	}
+	return true
+}
+
+type collisionIdentity struct {
+	id    ID
+	owner ID
+	group string
+}
+
+func (e *Entity) collisionIdentity() collisionIdentity {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return collisionIdentity{id: e.ID, owner: e.Owner, group: e.CollisionGroup}
+}
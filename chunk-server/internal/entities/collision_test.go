@@ -0,0 +1,42 @@
+package entities
+
+import "testing"
+
+func TestCollidesWithSkipsFriendlyEntities(t *testing.T) {
+	firer := &Entity{ID: "firer", CollisionGroup: "alpha"}
+	squadmate := &Entity{ID: "squadmate", CollisionGroup: "alpha"}
+	enemy := &Entity{ID: "enemy", CollisionGroup: "bravo"}
+	loner := &Entity{ID: "loner"}
+	drifter := &Entity{ID: "drifter"}
+	shot := &Entity{ID: "shot", Owner: "firer"}
+	secondShot := &Entity{ID: "second-shot", Owner: "firer"}
+
+	cases := []struct {
+		a, b *Entity
+		want bool
+	}{
+		{firer, firer, false},
+		{firer, &Entity{ID: "firer"}, false},
+		{firer, squadmate, false},
+		{firer, enemy, true},
+		{loner, drifter, true},
+		{shot, firer, false},
+		{firer, shot, false},
+		{shot, secondShot, false},
+		{shot, squadmate, true},
+		{shot, enemy, true},
+	}
+	for _, tc := range cases {
+		if got := tc.a.CollidesWith(tc.b); got != tc.want {
+			t.Fatalf("%s.CollidesWith(%s) = %v, want %v", tc.a.ID, tc.b.ID, got, tc.want)
+		}
+	}
+
+	skip := IgnoreFriendly(shot)
+	if !skip.Skips(shot) || !skip.Skips(firer) || skip.Skips(enemy) {
+		t.Fatal("IgnoreFriendly should skip the shot and its firer but not an enemy")
+	}
+	if IgnoreFriendly(nil).Skips(enemy) {
+		t.Fatal("nil filter should skip nothing")
+	}
+}
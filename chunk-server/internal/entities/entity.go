@@ -19,12 +19,9 @@ const (
 	KindFactory    Kind = "factory"
 )
 
-// Vec3 represents positions scaled to world blocks (float precision supports 1/20th block entities).
-type Vec3 struct {
-	X float64
-	Y float64
-	Z float64
-}
+// Vec3 represents positions scaled to world blocks. It is the world package's
+// type so positions convert to blocks without a copy.
+type Vec3 = world.Vec3
 
 type Rotation struct {
 	Yaw   float64
@@ -52,10 +49,28 @@ type Entity struct {
 	Stats        Stats
 	Capabilities Capabilities
 	Attributes   map[string]float64
+	// Inventory holds the resources the entity carries, by name. Change it
+	// through Deposit and Withdraw so InventoryCapacity is respected.
+	Inventory map[string]float64
+	// InventoryCapacity is the most the inventory holds across every
+	// resource. Zero means unlimited.
+	InventoryCapacity float64
+	// Tags group entities by role, such as "turret". Change them through
+	// Manager.SetTags so the manager's tag index stays current.
+	Tags []string
+	// Owner is the entity that spawned this one, such as the unit that fired
+	// a projectile. Owners and what they own do not collide.
+	Owner ID
+	// CollisionGroup puts entities, such as the members of a squad, on one
+	// side: entities sharing a non-empty group do not collide.
+	CollisionGroup string
 
 	LastTick time.Time
 	Dirty    bool
 	Dying    bool
+	// Removed is set only on the final snapshot of an entity reaped by
+	// Manager.ReapDying.
+	Removed bool
 }
 
 type PhysicsParams struct {
@@ -126,6 +141,15 @@ func (e *Entity) Snapshot() Entity {
 			copyEntity.Attributes[k] = v
 		}
 	}
+	if e.Inventory != nil {
+		copyEntity.Inventory = make(map[string]float64, len(e.Inventory))
+		for k, v := range e.Inventory {
+			copyEntity.Inventory[k] = v
+		}
+	}
+	if e.Tags != nil {
+		copyEntity.Tags = append([]string(nil), e.Tags...)
+	}
 	return copyEntity
 }
 
@@ -143,23 +167,40 @@ func (e *Entity) Advance(delta time.Duration) {
 	e.Position.X += e.Velocity.X * seconds
 	e.Position.Y += e.Velocity.Y * seconds
 	e.Position.Z += e.Velocity.Z * seconds
-	e.LastTick = time.Now()
 	e.Dirty = true
 	e.mu.Unlock()
 }
 
+// SetLastTick records when the entity was last simulated.
+func (e *Entity) SetLastTick(now time.Time) {
+	e.mu.Lock()
+	e.LastTick = now
+	e.mu.Unlock()
+}
+
 func (e *Entity) ApplyDamage(amount float64) {
 	if amount <= 0 {
 		return
 	}
+	e.applyDamage(amount)
+}
+
+// applyDamage takes amount from the entity's hit points and returns what is
+// left.
+func (e *Entity) applyDamage(amount float64) float64 {
 	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.loseHPLocked(amount)
+}
+
+func (e *Entity) loseHPLocked(amount float64) float64 {
 	e.Stats.CurrentHP -= amount
 	e.Dirty = true
 	if e.Stats.CurrentHP < 0 {
 		e.Stats.CurrentHP = 0
 		e.Dying = true
 	}
-	e.mu.Unlock()
+	return e.Stats.CurrentHP
 }
 
 func (e *Entity) HealBlocks(blocksPerSecond float64, delta time.Duration) float64 {
@@ -227,6 +268,21 @@ func (e *Entity) ApplyDrag(params PhysicsParams, delta time.Duration) {
 	e.Dirty = true
 }
 
+// ApplyWind pulls the entity's horizontal velocity toward the wind velocity.
+// coupling is the per-second rate at which the entity matches the wind; bodies
+// with more drag or a larger cross-section couple more strongly.
+func (e *Entity) ApplyWind(wind Vec3, coupling float64, delta time.Duration) {
+	if coupling <= 0 || (wind.X == 0 && wind.Y == 0) {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	blend := 1 - math.Exp(-coupling*delta.Seconds())
+	e.Velocity.X += (wind.X - e.Velocity.X) * blend
+	e.Velocity.Y += (wind.Y - e.Velocity.Y) * blend
+	e.Dirty = true
+}
+
 func (e *Entity) ApplyGroundFriction(coeff float64, delta time.Duration) {
 	if coeff <= 0 {
 		return
@@ -251,6 +307,12 @@ func (e *Entity) IsDirty() bool {
 	return e.Dirty
 }
 
+func (e *Entity) IsDying() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.Dying
+}
+
 func (e *Entity) SetPosition(pos Vec3) {
 	e.mu.Lock()
 	e.Position = pos
@@ -295,6 +357,12 @@ func (e *Entity) PositionVec() Vec3 {
 	return e.Position
 }
 
+func (e *Entity) VelocityVec() Vec3 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.Velocity
+}
+
 func (e *Entity) ClampZ(min float64) {
 	e.mu.Lock()
 	if e.Position.Z < min {
@@ -355,6 +423,13 @@ func (e *Entity) SetAttribute(key string, value float64) {
 
 func (e *Entity) SetAttributeIfDifferent(key string, value float64, epsilon float64) {
 	e.mu.Lock()
+	e.setAttributeIfDifferentLocked(key, value, epsilon)
+	e.mu.Unlock()
+}
+
+// setAttributeIfDifferentLocked is SetAttributeIfDifferent for callers that
+// already hold e.mu.
+func (e *Entity) setAttributeIfDifferentLocked(key string, value float64, epsilon float64) {
 	if e.Attributes == nil {
 		e.Attributes = make(map[string]float64)
 	}
@@ -363,11 +438,22 @@ func (e *Entity) SetAttributeIfDifferent(key string, value float64, epsilon floa
 			epsilon = 1e-6
 		}
 		if math.Abs(current-value) <= epsilon {
-			e.mu.Unlock()
 			return
 		}
 	}
 	e.Attributes[key] = value
 	e.Dirty = true
+}
+
+func (e *Entity) tags() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return append([]string(nil), e.Tags...)
+}
+
+func (e *Entity) setTags(tags []string) {
+	e.mu.Lock()
+	e.Tags = append([]string(nil), tags...)
+	e.Dirty = true
 	e.mu.Unlock()
 }
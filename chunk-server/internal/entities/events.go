@@ -0,0 +1,88 @@
+package entities
+
+import "chunkserver/internal/world"
+
+// EventType names an entity lifecycle change reported to observers.
+type EventType string
+
+const (
+	// EventSpawned fires when an entity is added to the manager.
+	EventSpawned EventType = "spawned"
+	// EventDamaged fires when Damage or DamageFrom takes hit points from an
+	// entity.
+	EventDamaged EventType = "damaged"
+	// EventDied fires when ReapDying removes an entity that has died.
+	EventDied EventType = "died"
+	// EventRemoved fires when Remove drops an entity, such as one handed off
+	// to a neighbor.
+	EventRemoved EventType = "removed"
+	// EventTransferred fires when Transfer moves an entity to another chunk.
+	EventTransferred EventType = "transferred"
+)
+
+// EntityEvent describes one lifecycle change. Chunk is where the entity is
+// after the change; From is set for transfers to the chunk it left.
+type EntityEvent struct {
+	Type      EventType
+	ID        ID
+	Kind      Kind
+	Chunk     world.ChunkCoord
+	From      world.ChunkCoord
+	Damage    float64
+	CurrentHP float64
+}
+
+// OnEvent registers fn to be called with every lifecycle event. Observers run
+// on the goroutine that caused the event, after the manager's lock is
+// released, so they may call back into the manager.
+func (m *Manager) OnEvent(fn func(EntityEvent)) {
+	if fn == nil {
+		return
+	}
+	m.observersMu.Lock()
+	m.observers = append(m.observers, fn)
+	m.observersMu.Unlock()
+}
+
+func (m *Manager) emit(events ...EntityEvent) {
+	if len(events) == 0 {
+		return
+	}
+	m.observersMu.RLock()
+	observers := m.observers
+	m.observersMu.RUnlock()
+	for _, event := range events {
+		for _, fn := range observers {
+			fn(event)
+		}
+	}
+}
+
+// Damage applies amount of damage to ent and reports it to observers.
+func (m *Manager) Damage(ent *Entity, amount float64) {
+	if ent == nil || amount <= 0 {
+		return
+	}
+	m.emitDamaged(ent, amount, ent.applyDamage(amount))
+}
+
+// DamageFrom applies amount of damage to ent from a source at from, such as
+// a blast, wearing down the blocks nearest to it first, and reports it to
+// observers like Damage.
+func (m *Manager) DamageFrom(ent *Entity, from Vec3, amount float64) {
+	if ent == nil || amount <= 0 {
+		return
+	}
+	m.emitDamaged(ent, amount, ent.applyDamageFrom(from, amount))
+}
+
+func (m *Manager) emitDamaged(ent *Entity, amount, hp float64) {
+	ent.mu.RLock()
+	event := EntityEvent{Type: EventDamaged, ID: ent.ID, Kind: ent.Kind, Chunk: ent.Chunk.Chunk, Damage: amount, CurrentHP: hp}
+	ent.mu.RUnlock()
+	m.emit(event)
+}
+
+func eventFor(eventType EventType, ent *Entity) EntityEvent {
+	return EntityEvent{Type: eventType, ID: ent.ID, Kind: ent.Kind, Chunk: ent.Chunk.Chunk, CurrentHP: ent.Stats.CurrentHP}
+}
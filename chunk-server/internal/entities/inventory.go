@@ -0,0 +1,72 @@
+package entities
+
+import "math"
+
+// Deposit adds up to amount of resource to the inventory, as much as
+// InventoryCapacity leaves room for, and returns how much was stored.
+func (e *Entity) Deposit(resource string, amount float64) float64 {
+	if amount <= 0 {
+		return 0
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if stored := e.freeLocked(); amount > stored {
+		amount = stored
+	}
+	if amount <= 0 {
+		return 0
+	}
+	if e.Inventory == nil {
+		e.Inventory = make(map[string]float64)
+	}
+	e.Inventory[resource] += amount
+	e.Dirty = true
+	return amount
+}
+
+// Withdraw removes up to amount of resource from the inventory and returns how
+// much was taken, which is less than amount when the entity holds less.
+func (e *Entity) Withdraw(resource string, amount float64) float64 {
+	if amount <= 0 {
+		return 0
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	held := e.Inventory[resource]
+	if amount >= held {
+		amount = held
+		delete(e.Inventory, resource)
+	} else {
+		e.Inventory[resource] = held - amount
+	}
+	if amount > 0 {
+		e.Dirty = true
+	}
+	return amount
+}
+
+// Held reports how much of resource the entity carries.
+func (e *Entity) Held(resource string) float64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.Inventory[resource]
+}
+
+// Free reports how much more the inventory holds before reaching
+// InventoryCapacity. An entity without a capacity has unlimited room.
+func (e *Entity) Free() float64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.freeLocked()
+}
+
+func (e *Entity) freeLocked() float64 {
+	if e.InventoryCapacity <= 0 {
+		return math.Inf(1)
+	}
+	total := 0.0
+	for _, amount := range e.Inventory {
+		total += amount
+	}
+	return math.Max(e.InventoryCapacity-total, 0)
+}
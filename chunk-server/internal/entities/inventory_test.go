@@ -0,0 +1,49 @@
+package entities
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDepositStopsAtCapacity(t *testing.T) {
+	ent := &Entity{InventoryCapacity: 10}
+	if stored := ent.Deposit("iron", 6); stored != 6 {
+		t.Fatalf("expected 6 iron stored, got %v", stored)
+	}
+	ent.MarkClean()
+	if stored := ent.Deposit("stone", 7); stored != 4 {
+		t.Fatalf("expected only 4 stone to fit, got %v", stored)
+	}
+	if !ent.IsDirty() {
+		t.Fatal("expected a deposit to mark the entity dirty")
+	}
+	if free := ent.Free(); free != 0 {
+		t.Fatalf("expected a full inventory, got %v free", free)
+	}
+	if stored := ent.Deposit("iron", 1); stored != 0 || ent.Held("iron") != 6 {
+		t.Fatalf("expected nothing more to fit, stored %v and holding %v iron", stored, ent.Held("iron"))
+	}
+
+	unlimited := &Entity{}
+	if stored := unlimited.Deposit("iron", 1e6); stored != 1e6 || !math.IsInf(unlimited.Free(), 1) {
+		t.Fatalf("expected no capacity to mean unlimited, stored %v with %v free", stored, unlimited.Free())
+	}
+}
+
+func TestWithdrawTakesNoMoreThanHeld(t *testing.T) {
+	ent := &Entity{}
+	ent.Deposit("iron", 5)
+	if taken := ent.Withdraw("iron", 2); taken != 2 || ent.Held("iron") != 3 {
+		t.Fatalf("expected to take 2 and leave 3, took %v and left %v", taken, ent.Held("iron"))
+	}
+	if taken := ent.Withdraw("iron", 8); taken != 3 {
+		t.Fatalf("expected to take the remaining 3, took %v", taken)
+	}
+	if _, ok := ent.Inventory["iron"]; ok {
+		t.Fatalf("expected an emptied resource to be dropped, got %v", ent.Inventory)
+	}
+	ent.MarkClean()
+	if taken := ent.Withdraw("gold", 1); taken != 0 || ent.IsDirty() {
+		t.Fatalf("expected nothing taken from an empty slot, took %v (dirty %t)", taken, ent.IsDirty())
+	}
+}
@@ -11,17 +11,40 @@ type Manager struct {
 	mu       sync.RWMutex
 	entities map[ID]*Entity
 	byChunk  map[world.ChunkCoord]map[ID]*Entity
+	byTag    map[string]map[ID]*Entity
 	serverID string
+
+	sleepAfter  int
+	activity    map[world.ChunkCoord]*chunkActivity
+	spatial     spatialIndex
+	maxPerChunk int
+
+	observersMu sync.RWMutex
+	observers   []func(EntityEvent)
 }
 
 func NewManager(serverID string) *Manager {
 	return &Manager{
 		entities: make(map[ID]*Entity),
 		byChunk:  make(map[world.ChunkCoord]map[ID]*Entity),
+		byTag:    make(map[string]map[ID]*Entity),
 		serverID: serverID,
+		activity: make(map[world.ChunkCoord]*chunkActivity),
+		spatial:  newSpatialIndex(),
 	}
 }
 
+// CountByKind returns the number of tracked entities of each kind.
+func (m *Manager) CountByKind() map[Kind]int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	counts := make(map[Kind]int)
+	for _, ent := range m.entities {
+		counts[ent.Kind]++
+	}
+	return counts
+}
+
 func (m *Manager) Add(entity *Entity) error {
 	if entity == nil {
 		return fmt.Errorf("nil entity")
@@ -29,14 +52,25 @@ func (m *Manager) Add(entity *Entity) error {
 	if entity.ID == "" {
 		return fmt.Errorf("entity missing id")
 	}
+	if err := m.add(entity); err != nil {
+		return err
+	}
+	m.emit(eventFor(EventSpawned, entity))
+	return nil
+}
 
+func (m *Manager) add(entity *Entity) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	if _, exists := m.entities[entity.ID]; exists {
 		return fmt.Errorf("entity %s already registered", entity.ID)
 	}
+	if m.maxPerChunk > 0 && len(m.byChunk[entity.Chunk.Chunk]) >= m.maxPerChunk {
+		return &ChunkFullError{Chunk: entity.Chunk.Chunk, Limit: m.maxPerChunk}
+	}
 	entity.Chunk.ServerID = m.serverID
+	entity.fillBlockHP()
 	entity.Dirty = true
 	m.entities[entity.ID] = entity
 
@@ -46,12 +80,62 @@ func (m *Manager) Add(entity *Entity) error {
 		m.byChunk[entity.Chunk.Chunk] = chunkSet
 	}
 	chunkSet[entity.ID] = entity
+	m.indexTagsLocked(entity.ID, entity.Tags)
+	m.spatial.place(entity, entity.Position)
+	m.wakeLocked(entity.Chunk.Chunk)
 	return nil
 }
 
 func (m *Manager) Remove(id ID) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	entity, ok := m.entities[id]
+	var event EntityEvent
+	if ok {
+		event = eventFor(EventRemoved, entity)
+		m.removeLocked(id)
+	}
+	m.mu.Unlock()
+	if ok {
+		m.emit(event)
+	}
+}
+
+// ReapDying removes every entity flagged as dying, including those in
+// sleeping chunks and those that died after they were ticked, and returns
+// their final snapshots with Removed set. Only dying entities are
+// snapshotted, and the write lock is taken only when there are some.
+func (m *Manager) ReapDying() []Entity {
+	m.mu.RLock()
+	var dying []*Entity
+	for _, entity := range m.entities {
+		if entity.IsDying() {
+			dying = append(dying, entity)
+		}
+	}
+	m.mu.RUnlock()
+	if len(dying) == 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	reaped := make([]Entity, 0, len(dying))
+	events := make([]EntityEvent, 0, len(dying))
+	for _, entity := range dying {
+		if m.entities[entity.ID] != entity {
+			continue
+		}
+		snapshot := entity.Snapshot()
+		snapshot.Removed = true
+		reaped = append(reaped, snapshot)
+		events = append(events, eventFor(EventDied, &snapshot))
+		m.removeLocked(entity.ID)
+	}
+	m.mu.Unlock()
+	m.emit(events...)
+	return reaped
+}
+
+func (m *Manager) removeLocked(id ID) {
 	entity, ok := m.entities[id]
 	if !ok {
 		return
@@ -61,22 +145,87 @@ func (m *Manager) Remove(id ID) {
 		delete(chunkSet, id)
 		if len(chunkSet) == 0 {
 			delete(m.byChunk, entity.Chunk.Chunk)
+			m.wakeLocked(entity.Chunk.Chunk)
 		}
 	}
+	m.unindexTagsLocked(id, entity.tags())
+	m.spatial.remove(id)
 }
 
-func (m *Manager) Transfer(id ID, newChunk world.ChunkCoord, serverID string) {
+// SetTags replaces an entity's tags and updates the tag index. It reports
+// false if the entity is not tracked.
+func (m *Manager) SetTags(id ID, tags ...string) bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	entity, ok := m.entities[id]
 	if !ok {
-		return
+		return false
+	}
+	m.unindexTagsLocked(id, entity.tags())
+	entity.setTags(tags)
+	m.indexTagsLocked(id, tags)
+	return true
+}
+
+// ByTag returns every tracked entity carrying tag.
+func (m *Manager) ByTag(tag string) []*Entity {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	set := m.byTag[tag]
+	if len(set) == 0 {
+		return nil
+	}
+	result := make([]*Entity, 0, len(set))
+	for _, ent := range set {
+		result = append(result, ent)
+	}
+	return result
+}
+
+func (m *Manager) indexTagsLocked(id ID, tags []string) {
+	entity := m.entities[id]
+	for _, tag := range tags {
+		set := m.byTag[tag]
+		if set == nil {
+			set = make(map[ID]*Entity)
+			m.byTag[tag] = set
+		}
+		set[id] = entity
+	}
+}
+
+func (m *Manager) unindexTagsLocked(id ID, tags []string) {
+	for _, tag := range tags {
+		if set := m.byTag[tag]; set != nil {
+			delete(set, id)
+			if len(set) == 0 {
+				delete(m.byTag, tag)
+			}
+		}
+	}
+}
+
+// Transfer moves the entity with id into newChunk. When newChunk already holds
+// as many entities as the cap allows it returns a *ChunkFullError and the
+// entity stays in its current chunk.
+func (m *Manager) Transfer(id ID, newChunk world.ChunkCoord, serverID string) error {
+	m.mu.Lock()
+	entity, ok := m.entities[id]
+	if !ok {
+		m.mu.Unlock()
+		return nil
+	}
+	from := entity.Chunk.Chunk
+	if from != newChunk && m.maxPerChunk > 0 && len(m.byChunk[newChunk]) >= m.maxPerChunk {
+		m.mu.Unlock()
+		return &ChunkFullError{Chunk: newChunk, Limit: m.maxPerChunk}
 	}
 
 	if chunkSet := m.byChunk[entity.Chunk.Chunk]; chunkSet != nil {
 		delete(chunkSet, id)
 		if len(chunkSet) == 0 {
 			delete(m.byChunk, entity.Chunk.Chunk)
+			m.wakeLocked(entity.Chunk.Chunk)
 		}
 	}
 
@@ -88,6 +237,12 @@ func (m *Manager) Transfer(id ID, newChunk world.ChunkCoord, serverID string) {
 		m.byChunk[newChunk] = set
 	}
 	set[id] = entity
+	m.wakeLocked(newChunk)
+	event := eventFor(EventTransferred, entity)
+	event.From = from
+	m.mu.Unlock()
+	m.emit(event)
+	return nil
 }
 
 func (m *Manager) ByChunk(coord world.ChunkCoord) []Entity {
@@ -146,15 +301,18 @@ func (m *Manager) Apply(fn func(*Entity)) []Entity {
 }
 
 // ApplyConcurrent executes fn for every entity, partitioning work across the requested number of workers.
+// Entities in sleeping chunks are skipped unless one of them has changed since it was last ticked.
 // It returns snapshots of entities that became dirty or dying during processing.
+// Dying entities stay registered until ReapDying removes them.
+//
+// The entities to visit are fixed when the call starts and no manager lock is
+// held while fn runs, so fn may add, remove, or transfer entities. Entities
+// added during the call are first visited by the next call; entities removed
+// before a worker reaches them are skipped.
 func (m *Manager) ApplyConcurrent(workers int, fn func(*Entity)) []Entity {
-	m.mu.RLock()
-	entities := make([]*Entity, 0, len(m.entities))
-	for _, ent := range m.entities {
-		entities = append(entities, ent)
-	}
-	m.mu.RUnlock()
+	m.wakeChanged()
 
+	entities := m.awakeSnapshot()
 	count := len(entities)
 	if count == 0 {
 		return nil
@@ -168,8 +326,8 @@ func (m *Manager) ApplyConcurrent(workers int, fn func(*Entity)) []Entity {
 	}
 
 	type workerResult struct {
-		dirty    []Entity
-		toRemove []ID
+		dirty  []Entity
+		active map[world.ChunkCoord]bool
 	}
 
 	results := make([]workerResult, workers)
@@ -188,19 +346,22 @@ func (m *Manager) ApplyConcurrent(workers int, fn func(*Entity)) []Entity {
 		go func(idx int, subset []*Entity) {
 			defer wg.Done()
 			res := workerResult{
-				dirty:    make([]Entity, 0, len(subset)),
-				toRemove: make([]ID, 0),
+				dirty:  make([]Entity, 0, len(subset)),
+				active: make(map[world.ChunkCoord]bool),
 			}
 			for _, ent := range subset {
+				if !m.tracks(ent) {
+					continue
+				}
+				before, _ := ent.motion()
 				fn(ent)
 				snapshot := ent.Snapshot()
+				coord := snapshot.Chunk.Chunk
+				res.active[coord] = res.active[coord] || isActive(before, snapshot)
 				if snapshot.Dirty || snapshot.Dying {
 					res.dirty = append(res.dirty, snapshot)
 					ent.MarkClean()
 				}
-				if snapshot.Dying {
-					res.toRemove = append(res.toRemove, ent.ID)
-				}
 			}
 			results[idx] = res
 		}(i, entities[start:end])
@@ -208,36 +369,41 @@ func (m *Manager) ApplyConcurrent(workers int, fn func(*Entity)) []Entity {
 	wg.Wait()
 
 	dirtySnapshots := make([]Entity, 0, count)
-	toRemove := make([]ID, 0)
+	ticked := make(map[world.ChunkCoord]bool)
 	for _, res := range results {
+		for coord, active := range res.active {
+			ticked[coord] = ticked[coord] || active
+		}
 		if len(res.dirty) > 0 {
 			dirtySnapshots = append(dirtySnapshots, res.dirty...)
 		}
-		if len(res.toRemove) > 0 {
-			toRemove = append(toRemove, res.toRemove...)
-		}
 	}
 
-	if len(toRemove) > 0 {
-		m.mu.Lock()
-		for _, id := range toRemove {
-			entity, ok := m.entities[id]
-			if !ok {
-				continue
-			}
-			delete(m.entities, id)
-			if chunkSet := m.byChunk[entity.Chunk.Chunk]; chunkSet != nil {
-				delete(chunkSet, id)
-				if len(chunkSet) == 0 {
-					delete(m.byChunk, entity.Chunk.Chunk)
-				}
-			}
-		}
-		m.mu.Unlock()
-	}
+	m.recordActivity(ticked)
 
 	if len(dirtySnapshots) == 0 {
 		return nil
 	}
 	return dirtySnapshots
 }
+
+// awakeSnapshot lists the entities outside sleeping chunks.
+func (m *Manager) awakeSnapshot() []*Entity {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	entities := make([]*Entity, 0, len(m.entities))
+	for _, ent := range m.entities {
+		if m.asleepLocked(ent.Chunk.Chunk) {
+			continue
+		}
+		entities = append(entities, ent)
+	}
+	return entities
+}
+
+// tracks reports whether ent is still the entity registered under its ID.
+func (m *Manager) tracks(ent *Entity) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.entities[ent.ID] == ent
+}
@@ -0,0 +1,263 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"chunkserver/internal/world"
+)
+
+func TestReapDyingRemovesEntitiesFromChunks(t *testing.T) {
+	mgr := NewManager("test")
+	coord := world.ChunkCoord{X: 1, Y: 2}
+	alive := &Entity{ID: "alive", Kind: KindUnit, Chunk: ChunkMembership{Chunk: coord}, Stats: Stats{MaxHP: 10, CurrentHP: 10}}
+	doomed := &Entity{ID: "doomed", Kind: KindUnit, Chunk: ChunkMembership{Chunk: coord}, Stats: Stats{MaxHP: 10, CurrentHP: 10}}
+	for _, ent := range []*Entity{alive, doomed} {
+		if err := mgr.Add(ent); err != nil {
+			t.Fatalf("add %s: %v", ent.ID, err)
+		}
+	}
+
+	// Dying outside a tick, the way blast damage kills a unit.
+	doomed.ApplyDamage(25)
+	reaped := mgr.ReapDying()
+	if len(reaped) != 1 || reaped[0].ID != "doomed" || !reaped[0].Removed || !reaped[0].Dying {
+		t.Fatalf("ReapDying() = %+v, want the doomed entity's removed snapshot", reaped)
+	}
+	if _, ok := mgr.Entity("doomed"); ok {
+		t.Fatalf("reaped entity is still registered")
+	}
+	in := mgr.ByChunk(coord)
+	if len(in) != 1 || in[0].ID != "alive" {
+		t.Fatalf("ByChunk() after reaping = %v, want only the living entity", in)
+	}
+	if again := mgr.ReapDying(); len(again) != 0 {
+		t.Fatalf("second ReapDying() = %v, want nothing", again)
+	}
+}
+
+func TestByTagTracksTaggedEntities(t *testing.T) {
+	mgr := NewManager("test")
+	north := &Entity{ID: "north", Kind: KindStructure, Tags: []string{"turret"}}
+	south := &Entity{ID: "south", Kind: KindStructure}
+	scout := &Entity{ID: "scout", Kind: KindUnit, Tags: []string{"recon"}}
+	for _, ent := range []*Entity{north, south, scout} {
+		if err := mgr.Add(ent); err != nil {
+			t.Fatalf("add %s: %v", ent.ID, err)
+		}
+	}
+	if !mgr.SetTags("south", "turret", "heavy") {
+		t.Fatalf("SetTags() on a tracked entity returned false")
+	}
+
+	if got := taggedIDs(mgr, "turret"); len(got) != 2 || !got["north"] || !got["south"] {
+		t.Fatalf("ByTag(turret) = %v, want north and south", got)
+	}
+	if got := taggedIDs(mgr, "heavy"); len(got) != 1 || !got["south"] {
+		t.Fatalf("ByTag(heavy) = %v, want south", got)
+	}
+
+	mgr.Remove("north")
+	if got := taggedIDs(mgr, "turret"); len(got) != 1 || !got["south"] {
+		t.Fatalf("ByTag(turret) after removal = %v, want south", got)
+	}
+
+	mgr.SetTags("south", "wreck")
+	if got := mgr.ByTag("turret"); got != nil {
+		t.Fatalf("ByTag(turret) after retagging = %v, want none", got)
+	}
+	if snapshot := south.Snapshot(); len(snapshot.Tags) != 1 || snapshot.Tags[0] != "wreck" || !snapshot.Dirty {
+		t.Fatalf("retagged entity snapshot = %+v", snapshot)
+	}
+	if mgr.SetTags("north", "turret") {
+		t.Fatalf("SetTags() on a removed entity returned true")
+	}
+}
+
+func taggedIDs(mgr *Manager, tag string) map[ID]bool {
+	ids := make(map[ID]bool)
+	for _, ent := range mgr.ByTag(tag) {
+		ids[ent.ID] = true
+	}
+	return ids
+}
+
+func TestApplyConcurrentToleratesMembershipChanges(t *testing.T) {
+	mgr := NewManager("test")
+	const initial = 400
+	for i := 0; i < initial; i++ {
+		ent := &Entity{
+			ID:       ID(fmt.Sprintf("unit-%d", i)),
+			Kind:     KindUnit,
+			Chunk:    ChunkMembership{Chunk: world.ChunkCoord{X: i % 7, Y: i % 3}},
+			Velocity: Vec3{X: 1},
+		}
+		if err := mgr.Add(ent); err != nil {
+			t.Fatalf("add %s: %v", ent.ID, err)
+		}
+	}
+
+	var spawned atomic.Int64
+	for round := 0; round < 10; round++ {
+		mgr.ApplyConcurrent(8, func(ent *Entity) {
+			ent.Advance(10 * time.Millisecond)
+			n := spawned.Add(1)
+			switch n % 4 {
+			case 0:
+				child := &Entity{
+					ID:    ID(fmt.Sprintf("spawn-%d", n)),
+					Kind:  KindProjectile,
+					Chunk: ent.Snapshot().Chunk,
+				}
+				if err := mgr.Add(child); err != nil {
+					t.Errorf("spawn %s: %v", child.ID, err)
+				}
+			case 1:
+				mgr.Remove(ID(fmt.Sprintf("unit-%d", n%initial)))
+			case 2:
+				mgr.Transfer(ent.ID, world.ChunkCoord{X: int(n % 5), Y: 1}, "test")
+			}
+		})
+	}
+
+	total := 0
+	for _, n := range mgr.CountByKind() {
+		total += n
+	}
+	mgr.mu.RLock()
+	indexed := 0
+	for _, set := range mgr.byChunk {
+		indexed += len(set)
+	}
+	mgr.mu.RUnlock()
+	if indexed != total {
+		t.Fatalf("chunk index holds %d entities, manager tracks %d", indexed, total)
+	}
+}
+
+func TestApplyConcurrentSkipsEntitiesRemovedMidPass(t *testing.T) {
+	mgr := NewManager("test")
+	for i := 0; i < 5; i++ {
+		if err := mgr.Add(&Entity{ID: ID(fmt.Sprintf("unit-%d", i)), Kind: KindUnit}); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+
+	var visited []ID
+	mgr.ApplyConcurrent(1, func(ent *Entity) {
+		visited = append(visited, ent.ID)
+		for i := 0; i < 5; i++ {
+			if id := ID(fmt.Sprintf("unit-%d", i)); id != ent.ID {
+				mgr.Remove(id)
+			}
+		}
+		if err := mgr.Add(&Entity{ID: "late", Kind: KindUnit}); err != nil {
+			t.Errorf("add during pass: %v", err)
+		}
+	})
+	if len(visited) != 1 {
+		t.Fatalf("visited %v, want only the entity that removed the others", visited)
+	}
+	if _, ok := mgr.Entity("late"); !ok {
+		t.Fatalf("entity added during the pass was not registered")
+	}
+}
+
+func TestOnEventReportsEachLifecycleChangeOnce(t *testing.T) {
+	mgr := NewManager("test")
+	var events []EntityEvent
+	mgr.OnEvent(func(event EntityEvent) {
+		// Observers run outside the lock, so calling back in must not block.
+		mgr.Entity(event.ID)
+		events = append(events, event)
+	})
+	coord := world.ChunkCoord{X: 3, Y: -1}
+	unit := &Entity{ID: "unit", Kind: KindUnit, Chunk: ChunkMembership{Chunk: coord}, Stats: Stats{MaxHP: 10, CurrentHP: 10}}
+
+	if err := mgr.Add(unit); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != EventSpawned || events[0].ID != "unit" || events[0].Chunk != coord {
+		t.Fatalf("events after Add = %+v, want one spawned event", events)
+	}
+
+	mgr.Damage(unit, 4)
+	if len(events) != 2 || events[1].Type != EventDamaged || events[1].Damage != 4 || events[1].CurrentHP != 6 {
+		t.Fatalf("events after Damage = %+v, want one damaged event leaving 6 hp", events)
+	}
+
+	mgr.Remove("unit")
+	mgr.Remove("unit")
+	if len(events) != 3 || events[2].Type != EventRemoved || events[2].ID != "unit" {
+		t.Fatalf("events after Remove = %+v, want one removed event", events)
+	}
+}
+
+func TestAddRejectsEntitiesPastTheChunkCap(t *testing.T) {
+	mgr := NewManager("test")
+	mgr.SetMaxPerChunk(2)
+	full := world.ChunkCoord{X: 1, Y: 1}
+	for _, id := range []ID{"a", "b"} {
+		if err := mgr.Add(&Entity{ID: id, Chunk: ChunkMembership{Chunk: full}}); err != nil {
+			t.Fatalf("add %s: %v", id, err)
+		}
+	}
+
+	err := mgr.Add(&Entity{ID: "c", Chunk: ChunkMembership{Chunk: full}})
+	var fullErr *ChunkFullError
+	if !errors.As(err, &fullErr) || fullErr.Chunk != full || fullErr.Limit != 2 {
+		t.Fatalf("Add past the cap returned %v, want a ChunkFullError for %v", err, full)
+	}
+	if _, ok := mgr.Entity("c"); ok {
+		t.Fatalf("rejected entity was registered")
+	}
+	if err := mgr.Add(&Entity{ID: "c", Chunk: ChunkMembership{Chunk: world.ChunkCoord{X: 2, Y: 1}}}); err != nil {
+		t.Fatalf("add to another chunk: %v", err)
+	}
+
+	mgr.Remove("a")
+	if err := mgr.Add(&Entity{ID: "d", Chunk: ChunkMembership{Chunk: full}}); err != nil {
+		t.Fatalf("add after making room: %v", err)
+	}
+}
+
+func TestTransferKeepsEntitiesOutOfFullChunks(t *testing.T) {
+	mgr := NewManager("test")
+	mgr.SetMaxPerChunk(2)
+	full := world.ChunkCoord{X: 1, Y: 1}
+	open := world.ChunkCoord{X: 2, Y: 1}
+	for _, id := range []ID{"a", "b"} {
+		if err := mgr.Add(&Entity{ID: id, Chunk: ChunkMembership{Chunk: full}}); err != nil {
+			t.Fatalf("add %s: %v", id, err)
+		}
+	}
+	if err := mgr.Add(&Entity{ID: "c", Chunk: ChunkMembership{Chunk: open}}); err != nil {
+		t.Fatalf("add c: %v", err)
+	}
+
+	err := mgr.Transfer("c", full, "test")
+	var fullErr *ChunkFullError
+	if !errors.As(err, &fullErr) || fullErr.Chunk != full || fullErr.Limit != 2 {
+		t.Fatalf("Transfer into a full chunk returned %v, want a ChunkFullError for %v", err, full)
+	}
+	if ent, _ := mgr.Entity("c"); ent.Chunk.Chunk != open {
+		t.Fatalf("refused entity moved to %v, want it kept in %v", ent.Chunk.Chunk, open)
+	}
+	if got := len(mgr.ByChunk(full)); got != 2 {
+		t.Fatalf("full chunk holds %d entities after a refused transfer, want 2", got)
+	}
+	if err := mgr.Transfer("a", full, "test"); err != nil {
+		t.Fatalf("Transfer within the same full chunk: %v", err)
+	}
+
+	mgr.Remove("a")
+	if err := mgr.Transfer("c", full, "test"); err != nil {
+		t.Fatalf("Transfer after making room: %v", err)
+	}
+	if got := len(mgr.ByChunk(full)); got != 2 {
+		t.Fatalf("chunk holds %d entities after the transfer, want 2", got)
+	}
+}
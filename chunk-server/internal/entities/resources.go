@@ -0,0 +1,128 @@
+package entities
+
+// consumerRoles lists the block roles that draw from an entity's resource
+// network, in the order they are supplied when the budget runs short.
+var consumerRoles = []EntityBlockRole{BlockRoleThruster, BlockRoleWeapon, BlockRoleFactory}
+
+// ResourceNetwork is the result of balancing an entity's power and gas blocks
+// against the blocks that consume them.
+type ResourceNetwork struct {
+	// Power and Gas are what remains after every online system has drawn
+	// its share.
+	Power float64
+	Gas   float64
+	// Offline holds the consumer roles the budget could not cover.
+	Offline map[EntityBlockRole]bool
+}
+
+// BlockSetting reads a numeric setting, such as "power_draw", from a block's
+// metadata.
+func BlockSetting(block EntityBlock, key string) (float64, bool) {
+	switch value := block.Block.Metadata[key].(type) {
+	case float64:
+		return value, true
+	case float32:
+		return float64(value), true
+	case int:
+		return float64(value), true
+	}
+	return 0, false
+}
+
+// BalanceResources totals the "power_output" of power blocks and the
+// "gas_output" of gas blocks, then hands the budget to thrusters, weapons and
+// factories in that order. Consumers draw what their "power_draw" and
+// "gas_draw" settings ask for; a role whose combined draw no longer fits is
+// taken offline as a whole. Blocks whose hit points in hp have run out neither
+// produce nor consume.
+func BalanceResources(blocks []EntityBlock, hp []float64) ResourceNetwork {
+	var network ResourceNetwork
+	powerDraw := make(map[EntityBlockRole]float64)
+	gasDraw := make(map[EntityBlockRole]float64)
+	for i, block := range blocks {
+		if BlockDestroyed(blocks, hp, i) {
+			continue
+		}
+		switch block.Role {
+		case BlockRolePower:
+			if output, ok := BlockSetting(block, "power_output"); ok && output > 0 {
+				network.Power += output
+			}
+		case BlockRoleGas:
+			if output, ok := BlockSetting(block, "gas_output"); ok && output > 0 {
+				network.Gas += output
+			}
+		default:
+			if draw, ok := BlockSetting(block, "power_draw"); ok && draw > 0 {
+				powerDraw[block.Role] += draw
+			}
+			if draw, ok := BlockSetting(block, "gas_draw"); ok && draw > 0 {
+				gasDraw[block.Role] += draw
+			}
+		}
+	}
+	for _, role := range consumerRoles {
+		power, gas := powerDraw[role], gasDraw[role]
+		if power > network.Power || gas > network.Gas {
+			if network.Offline == nil {
+				network.Offline = make(map[EntityBlockRole]bool)
+			}
+			network.Offline[role] = true
+			continue
+		}
+		network.Power -= power
+		network.Gas -= gas
+	}
+	return network
+}
+
+// onlineAttribute names the attribute recording whether a consumer role is
+// supplied.
+func onlineAttribute(role EntityBlockRole) string {
+	return string(role) + "_online"
+}
+
+// UpdateResources balances the entity's resource network and records the
+// outcome in its "power_available", "gas_available" and "<role>_online"
+// attributes. Entities without producing or consuming blocks are left alone.
+func (e *Entity) UpdateResources() ResourceNetwork {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	network := BalanceResources(e.Blocks, e.Stats.BlockHP)
+	if !hasResourceBlocks(e.Blocks) {
+		return network
+	}
+	e.setAttributeIfDifferentLocked("power_available", network.Power, 0)
+	e.setAttributeIfDifferentLocked("gas_available", network.Gas, 0)
+	for _, role := range consumerRoles {
+		online := 1.0
+		if network.Offline[role] {
+			online = 0
+		}
+		e.setAttributeIfDifferentLocked(onlineAttribute(role), online, 0)
+	}
+	return network
+}
+
+// SystemOnline reports whether the blocks with the given consumer role were
+// supplied on the last UpdateResources. Entities that never balanced their
+// resources count as online.
+func (e *Entity) SystemOnline(role EntityBlockRole) bool {
+	value, ok := e.Attribute(onlineAttribute(role))
+	return !ok || value > 0
+}
+
+func hasResourceBlocks(blocks []EntityBlock) bool {
+	for _, block := range blocks {
+		switch block.Role {
+		case BlockRolePower, BlockRoleGas:
+			return true
+		}
+		for _, key := range []string{"power_draw", "gas_draw"} {
+			if _, ok := BlockSetting(block, key); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package entities
+
+import (
+	"testing"
+
+	"chunkserver/internal/world"
+)
+
+func settingBlock(role EntityBlockRole, settings map[string]any) EntityBlock {
+	return EntityBlock{Role: role, Block: world.Block{MaxHitPoints: 1, Metadata: settings}}
+}
+
+func TestBalanceResourcesSuppliesRolesInPriorityOrder(t *testing.T) {
+	blocks := []EntityBlock{
+		settingBlock(BlockRolePower, map[string]any{"power_output": 10.0}),
+		settingBlock(BlockRoleGas, map[string]any{"gas_output": 2}),
+		settingBlock(BlockRoleFactory, map[string]any{"power_draw": 4.0}),
+		settingBlock(BlockRoleWeapon, map[string]any{"power_draw": 3.0}),
+		settingBlock(BlockRoleThruster, map[string]any{"power_draw": 5.0, "gas_draw": 1.0}),
+	}
+	network := BalanceResources(blocks, nil)
+	if network.Offline[BlockRoleThruster] || network.Offline[BlockRoleWeapon] {
+		t.Fatalf("expected thrusters and weapons online, got offline %v", network.Offline)
+	}
+	if !network.Offline[BlockRoleFactory] {
+		t.Fatalf("expected factories offline once the budget ran out")
+	}
+	if network.Power != 2 || network.Gas != 1 {
+		t.Fatalf("expected 2 power and 1 gas left, got %v and %v", network.Power, network.Gas)
+	}
+
+	// A destroyed power block stops producing.
+	network = BalanceResources(blocks, []float64{0, 1, 1, 1, 1})
+	if !network.Offline[BlockRoleThruster] || !network.Offline[BlockRoleWeapon] {
+		t.Fatalf("expected consumers offline without power, got offline %v", network.Offline)
+	}
+}
+
+func TestUpdateResourcesRecordsAttributes(t *testing.T) {
+	ent := &Entity{Blocks: []EntityBlock{
+		settingBlock(BlockRolePower, map[string]any{"power_output": 6.0}),
+		settingBlock(BlockRoleWeapon, map[string]any{"power_draw": 4.0}),
+	}}
+	ent.UpdateResources()
+	if value, _ := ent.Attribute("power_available"); value != 2 {
+		t.Fatalf("expected 2 power available, got %v", value)
+	}
+	if !ent.SystemOnline(BlockRoleWeapon) {
+		t.Fatalf("expected weapons online")
+	}
+
+	plain := &Entity{Blocks: []EntityBlock{{Role: BlockRoleWeapon}}}
+	plain.UpdateResources()
+	if _, ok := plain.Attribute("power_available"); ok {
+		t.Fatalf("expected entities without a resource network to be left alone")
+	}
+	if !plain.SystemOnline(BlockRoleWeapon) {
+		t.Fatalf("expected systems without a draw to stay online")
+	}
+}
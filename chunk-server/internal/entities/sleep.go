@@ -0,0 +1,170 @@
+package entities
+
+import (
+	"math"
+	"strconv"
+
+	"chunkserver/internal/world"
+)
+
+const (
+	// restingSpeed is the speed in blocks per second below which an entity
+	// counts as stationary.
+	restingSpeed = 1e-3
+	// restingDisplacement is the per-tick movement in blocks below which an
+	// entity counts as stationary.
+	restingDisplacement = 1e-4
+)
+
+// chunkActivity tracks how long a chunk's entities have been idle.
+type chunkActivity struct {
+	idleTicks int
+	asleep    bool
+}
+
+// SetSleepAfter sets how many consecutive idle ticks a chunk may have before
+// its entities stop being ticked. Zero disables sleeping and wakes every chunk.
+func (m *Manager) SetSleepAfter(ticks int) {
+	if ticks < 0 {
+		ticks = 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sleepAfter = ticks
+	if ticks == 0 {
+		m.activity = make(map[world.ChunkCoord]*chunkActivity)
+	}
+}
+
+// WakeChunks resumes ticking entities in the given chunks and restarts their
+// idle countdown.
+func (m *Manager) WakeChunks(coords ...world.ChunkCoord) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, coord := range coords {
+		m.wakeLocked(coord)
+	}
+}
+
+// Asleep reports whether entities in coord are currently skipped by
+// ApplyConcurrent.
+func (m *Manager) Asleep(coord world.ChunkCoord) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.asleepLocked(coord)
+}
+
+func (m *Manager) wakeLocked(coord world.ChunkCoord) {
+	delete(m.activity, coord)
+}
+
+func (m *Manager) asleepLocked(coord world.ChunkCoord) bool {
+	activity, ok := m.activity[coord]
+	return ok && activity.asleep
+}
+
+// wakeChanged wakes sleeping chunks holding an entity that was modified since
+// it was last ticked, such as by damage, a new order, or a collapse.
+func (m *Manager) wakeChanged() {
+	m.mu.RLock()
+	var changed []world.ChunkCoord
+	for coord, set := range m.byChunk {
+		if !m.asleepLocked(coord) {
+			continue
+		}
+		for _, ent := range set {
+			if ent.IsDirty() {
+				changed = append(changed, coord)
+				break
+			}
+		}
+	}
+	m.mu.RUnlock()
+	if len(changed) > 0 {
+		m.WakeChunks(changed...)
+	}
+}
+
+// recordActivity advances the idle countdown for every chunk that was ticked,
+// putting a chunk to sleep once none of its entities has moved for sleepAfter
+// ticks in a row.
+func (m *Manager) recordActivity(ticked map[world.ChunkCoord]bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sleepAfter <= 0 {
+		return
+	}
+	if m.activity == nil {
+		m.activity = make(map[world.ChunkCoord]*chunkActivity)
+	}
+	for coord, active := range ticked {
+		if active {
+			m.wakeLocked(coord)
+			continue
+		}
+		activity := m.activity[coord]
+		if activity == nil {
+			activity = &chunkActivity{}
+			m.activity[coord] = activity
+		}
+		activity.idleTicks++
+		if activity.idleTicks >= m.sleepAfter {
+			activity.asleep = true
+		}
+	}
+}
+
+// motion returns the entity's position and velocity.
+func (e *Entity) motion() (Vec3, Vec3) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.Position, e.Velocity
+}
+
+// isActive reports whether an entity that started a tick at before ended it
+// moving, or is otherwise about to change the world around it.
+func isActive(before Vec3, after Entity) bool {
+	if after.Dying || engaging(after) {
+		return true
+	}
+	v := after.Velocity
+	if math.Sqrt(v.X*v.X+v.Y*v.Y+v.Z*v.Z) > restingSpeed {
+		return true
+	}
+	dx := after.Position.X - before.X
+	dy := after.Position.Y - before.Y
+	dz := after.Position.Z - before.Z
+	return math.Sqrt(dx*dx+dy*dy+dz*dz) > restingDisplacement
+}
+
+// WeaponCooldownAttribute names the attribute in which the weapon block at
+// index counts down the seconds until it may fire again.
+func WeaponCooldownAttribute(index int) string {
+	return "weapon_" + strconv.Itoa(index) + "_cooldown"
+}
+
+// engaging reports whether ent has a weapon still counting down its cooldown,
+// or an intact weapon and a target in its "target_x", "target_y" and
+// "target_z" attributes, so a stationary turret keeps being ticked until it
+// fires.
+func engaging(ent Entity) bool {
+	if ent.Capabilities.ProjectileVelocity <= 0 {
+		return false
+	}
+	_, okX := ent.Attributes["target_x"]
+	_, okY := ent.Attributes["target_y"]
+	_, okZ := ent.Attributes["target_z"]
+	targeted := okX && okY && okZ
+	for index, block := range ent.Blocks {
+		if block.Role != BlockRoleWeapon {
+			continue
+		}
+		if ent.Attributes[WeaponCooldownAttribute(index)] > 0 {
+			return true
+		}
+		if targeted && !BlockDestroyed(ent.Blocks, ent.Stats.BlockHP, index) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"chunkserver/internal/world"
+)
+
+func TestIdleChunkStopsTickingWhileMovingUnitContinues(t *testing.T) {
+	mgr := NewManager("test")
+	mgr.SetSleepAfter(3)
+
+	idleChunk := world.ChunkCoord{X: 0, Y: 0}
+	busyChunk := world.ChunkCoord{X: 1, Y: 0}
+	structure := &Entity{ID: "bunker", Kind: KindStructure, Chunk: ChunkMembership{Chunk: idleChunk}}
+	unit := &Entity{ID: "scout", Kind: KindUnit, Chunk: ChunkMembership{Chunk: busyChunk}, Velocity: Vec3{X: 2}}
+	for _, ent := range []*Entity{structure, unit} {
+		if err := mgr.Add(ent); err != nil {
+			t.Fatalf("add %s: %v", ent.ID, err)
+		}
+	}
+
+	ticks := make(map[ID]int)
+	tick := func() {
+		mgr.Apply(func(ent *Entity) {
+			ticks[ent.ID]++
+			ent.Advance(50 * time.Millisecond)
+		})
+	}
+
+	for i := 0; i < 10; i++ {
+		tick()
+	}
+	if ticks[structure.ID] != 3 {
+		t.Fatalf("idle structure ticked %d times, want 3 before sleeping", ticks[structure.ID])
+	}
+	if ticks[unit.ID] != 10 {
+		t.Fatalf("moving unit ticked %d times, want 10", ticks[unit.ID])
+	}
+	if !mgr.Asleep(idleChunk) {
+		t.Fatalf("expected idle chunk to be asleep")
+	}
+	if mgr.Asleep(busyChunk) {
+		t.Fatalf("expected chunk with a moving unit to stay awake")
+	}
+
+	structure.ApplyDamage(5)
+	tick()
+	if ticks[structure.ID] != 4 {
+		t.Fatalf("damaged structure ticked %d times, want an immediate wake", ticks[structure.ID])
+	}
+	if mgr.Asleep(idleChunk) {
+		t.Fatalf("expected damage to wake the chunk")
+	}
+}
+
+func TestSleepDisabledTicksEveryEntity(t *testing.T) {
+	mgr := NewManager("test")
+	structure := &Entity{ID: "bunker", Kind: KindStructure}
+	if err := mgr.Add(structure); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	count := 0
+	for i := 0; i < 5; i++ {
+		mgr.Apply(func(ent *Entity) { count++ })
+	}
+	if count != 5 {
+		t.Fatalf("ticked %d times with sleeping disabled, want 5", count)
+	}
+}
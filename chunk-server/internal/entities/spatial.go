@@ -0,0 +1,182 @@
+package entities
+
+import "math"
+
+// spatialCellSize is the edge, in blocks, of the cubic cells the spatial
+// index buckets entities into. Chunks span many cells, so a radius query in a
+// crowded chunk only looks at the entities near its origin.
+const spatialCellSize = 8.0
+
+type spatialCell struct{ x, y, z int }
+
+func cellOf(pos Vec3) spatialCell {
+	return spatialCell{
+		x: int(math.Floor(pos.X / spatialCellSize)),
+		y: int(math.Floor(pos.Y / spatialCellSize)),
+		z: int(math.Floor(pos.Z / spatialCellSize)),
+	}
+}
+
+// spatialIndex buckets entities by the cell they were in when last placed.
+// Queries test each candidate's current position, and search one cell beyond
+// the query's reach so entities that moved less than a cell since they were
+// placed are still found.
+type spatialIndex struct {
+	cells map[spatialCell]map[ID]*Entity
+	at    map[ID]spatialCell
+}
+
+func newSpatialIndex() spatialIndex {
+	return spatialIndex{
+		cells: make(map[spatialCell]map[ID]*Entity),
+		at:    make(map[ID]spatialCell),
+	}
+}
+
+// place files ent under the cell holding pos, moving it from its old cell.
+func (ix *spatialIndex) place(ent *Entity, pos Vec3) {
+	cell := cellOf(pos)
+	if old, ok := ix.at[ent.ID]; ok {
+		if old == cell {
+			return
+		}
+		ix.unlink(ent.ID, old)
+	}
+	set := ix.cells[cell]
+	if set == nil {
+		set = make(map[ID]*Entity)
+		ix.cells[cell] = set
+	}
+	set[ent.ID] = ent
+	ix.at[ent.ID] = cell
+}
+
+func (ix *spatialIndex) remove(id ID) {
+	if cell, ok := ix.at[id]; ok {
+		ix.unlink(id, cell)
+		delete(ix.at, id)
+	}
+}
+
+func (ix *spatialIndex) unlink(id ID, cell spatialCell) {
+	if set := ix.cells[cell]; set != nil {
+		delete(set, id)
+		if len(set) == 0 {
+			delete(ix.cells, cell)
+		}
+	}
+}
+
+// visit calls fn with every entity in cells within reach cells of center
+// along each axis. When that box holds more cells than are occupied, the
+// occupied cells are walked instead.
+func (ix *spatialIndex) visit(center spatialCell, reach int, fn func(*Entity)) {
+	side := 2*reach + 1
+	if side*side*side > len(ix.cells) {
+		for cell, set := range ix.cells {
+			if chebyshev(cell, center) > reach {
+				continue
+			}
+			for _, ent := range set {
+				fn(ent)
+			}
+		}
+		return
+	}
+	for x := center.x - reach; x <= center.x+reach; x++ {
+		for y := center.y - reach; y <= center.y+reach; y++ {
+			for z := center.z - reach; z <= center.z+reach; z++ {
+				for _, ent := range ix.cells[spatialCell{x, y, z}] {
+					fn(ent)
+				}
+			}
+		}
+	}
+}
+
+func chebyshev(a, b spatialCell) int {
+	return max(abs(a.x-b.x), max(abs(a.y-b.y), abs(a.z-b.z)))
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func distance(a, b Vec3) float64 {
+	dx, dy, dz := a.X-b.X, a.Y-b.Y, a.Z-b.Z
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
+
+// UpdatePositions refiles every entity in the spatial index under the cell
+// holding its current position. Call it once positions have settled, such as
+// at the end of a tick.
+func (m *Manager) UpdatePositions() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, ent := range m.entities {
+		m.spatial.place(ent, ent.PositionVec())
+	}
+}
+
+// Within returns the entities within radius blocks of origin that filter does
+// not skip, in no particular order. Entities that have moved more than a cell
+// since the last UpdatePositions may be missed.
+func (m *Manager) Within(origin Vec3, radius float64, filter CollisionFilter) []*Entity {
+	if radius < 0 {
+		return nil
+	}
+	// A point radius away can be filed radius/spatialCellSize+1 cells over,
+	// and one more allows for an entity having moved up to a cell since.
+	var found []*Entity
+	for _, ent := range m.nearby(origin, int(radius/spatialCellSize)+2) {
+		if distance(ent.PositionVec(), origin) > radius || filter.Skips(ent) {
+			continue
+		}
+		found = append(found, ent)
+	}
+	return found
+}
+
+// nearby returns the entities filed within reach cells of the one holding
+// origin.
+func (m *Manager) nearby(origin Vec3, reach int) []*Entity {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var candidates []*Entity
+	m.spatial.visit(cellOf(origin), reach, func(ent *Entity) {
+		candidates = append(candidates, ent)
+	})
+	return candidates
+}
+
+// Nearest returns the entity closest to origin, no more than maxRadius blocks
+// away, that filter does not skip. ok is false when there is none. Like
+// Within, it searches the cells around origin rather than every entity.
+func (m *Manager) Nearest(origin Vec3, maxRadius float64, filter CollisionFilter) (*Entity, bool) {
+	if maxRadius < 0 {
+		return nil, false
+	}
+	var best *Entity
+	bestDist := maxRadius
+	// Widen the search until it passes the best hit. An entity filed k cells
+	// over is at least k-2 cells from origin once a cell of movement is
+	// allowed for, so after searching reach cells nothing unseen is closer
+	// than reach-1. Ties go to the lowest ID.
+	limit := int(maxRadius/spatialCellSize) + 2
+	for reach := 1; ; reach = min(2*reach, limit) {
+		for _, ent := range m.nearby(origin, reach) {
+			d := distance(ent.PositionVec(), origin)
+			if d > bestDist || (best != nil && d == bestDist && ent.ID >= best.ID) || filter.Skips(ent) {
+				continue
+			}
+			best, bestDist = ent, d
+		}
+		if reach == limit || (best != nil && float64(reach-1)*spatialCellSize > bestDist) {
+			break
+		}
+	}
+	return best, best != nil
+}
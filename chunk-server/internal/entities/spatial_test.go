@@ -0,0 +1,112 @@
+package entities
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func bruteWithin(ents []*Entity, origin Vec3, radius float64) []ID {
+	var ids []ID
+	for _, ent := range ents {
+		if distance(ent.PositionVec(), origin) <= radius {
+			ids = append(ids, ent.ID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func bruteNearest(ents []*Entity, origin Vec3, maxRadius float64) (ID, bool) {
+	var best ID
+	bestDist, found := maxRadius, false
+	for _, ent := range ents {
+		d := distance(ent.PositionVec(), origin)
+		if d < bestDist || d == bestDist && (!found || ent.ID < best) {
+			best, bestDist, found = ent.ID, d, true
+		}
+	}
+	return best, found
+}
+
+func idsOf(ents []*Entity) []ID {
+	ids := make([]ID, 0, len(ents))
+	for _, ent := range ents {
+		ids = append(ids, ent.ID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func TestSpatialQueriesMatchBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	for _, density := range []int{0, 1, 10, 200, 2000} {
+		mgr := NewManager("test")
+		ents := make([]*Entity, 0, density)
+		for i := 0; i < density; i++ {
+			ent := &Entity{ID: ID(fmt.Sprintf("e%04d", i)), Kind: KindUnit, Position: Vec3{
+				X: rng.Float64()*256 - 64,
+				Y: rng.Float64() * 64,
+				Z: rng.Float64() * 48,
+			}}
+			if err := mgr.Add(ent); err != nil {
+				t.Fatalf("add: %v", err)
+			}
+			ents = append(ents, ent)
+		}
+
+		check := func(stage string) {
+			for trial := 0; trial < 20; trial++ {
+				origin := Vec3{X: rng.Float64()*256 - 64, Y: rng.Float64() * 64, Z: rng.Float64() * 48}
+				for _, radius := range []float64{0, 1.5, 8, 13, 40, 500} {
+					want := bruteWithin(ents, origin, radius)
+					if got := idsOf(mgr.Within(origin, radius, nil)); fmt.Sprint(got) != fmt.Sprint(want) {
+						t.Fatalf("%s, %d entities: Within(%+v, %v) = %v, want %v", stage, density, origin, radius, got, want)
+					}
+					wantID, wantOK := bruteNearest(ents, origin, radius)
+					got, ok := mgr.Nearest(origin, radius, nil)
+					if ok != wantOK || ok && got.ID != wantID {
+						t.Fatalf("%s, %d entities: Nearest(%+v, %v) = %v, %t, want %v, %t", stage, density, origin, radius, got, ok, wantID, wantOK)
+					}
+				}
+			}
+		}
+		check("fresh")
+
+		// Entities that moved less than a cell are found before the index
+		// catches up; after UpdatePositions any move is.
+		for _, ent := range ents {
+			pos := ent.PositionVec()
+			ent.SetPosition(Vec3{X: pos.X + rng.Float64()*7 - 3.5, Y: pos.Y + rng.Float64()*7 - 3.5, Z: pos.Z})
+		}
+		check("small moves")
+		for _, ent := range ents {
+			ent.SetPosition(Vec3{X: rng.Float64()*256 - 64, Y: rng.Float64() * 64, Z: rng.Float64() * 48})
+		}
+		mgr.UpdatePositions()
+		check("after UpdatePositions")
+	}
+}
+
+func TestSpatialQueriesSkipRemovedAndFilteredEntities(t *testing.T) {
+	mgr := NewManager("test")
+	near := &Entity{ID: "near", Kind: KindUnit, Position: Vec3{X: 1}}
+	far := &Entity{ID: "far", Kind: KindUnit, Position: Vec3{X: 5}}
+	for _, ent := range []*Entity{near, far} {
+		if err := mgr.Add(ent); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	skipNear := CollisionFilter(func(ent *Entity) bool { return ent.ID == "near" })
+	if got, ok := mgr.Nearest(Vec3{}, 10, skipNear); !ok || got.ID != "far" {
+		t.Fatalf("Nearest() skipping near = %v, %t, want far", got, ok)
+	}
+	mgr.Remove("far")
+	if got := idsOf(mgr.Within(Vec3{}, 10, nil)); len(got) != 1 || got[0] != "near" {
+		t.Fatalf("Within() after removing far = %v, want only near", got)
+	}
+	if got, ok := mgr.Nearest(Vec3{}, 10, skipNear); ok {
+		t.Fatalf("Nearest() with every entity skipped or removed = %v", got)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"math/rand"
 	"sync"
 	"time"
+
+	"chunkserver/internal/clock"
 )
 
 type WeatherKind string
@@ -32,7 +34,31 @@ type Config struct {
 	RainChance         float64       `json:"rainChance"`
 	WindBase           float64       `json:"windBase"`
 	WindVariance       float64       `json:"windVariance"`
-	Seed               int64         `json:"seed"`
+	// TransitionHours is the width, in in-game hours, of the window centred on
+	// each night boundary across which lighting blends between night and day.
+	// Zero means unset and selects one hour; the server config rejects it.
+	TransitionHours float64 `json:"transitionHours"`
+	Seed            int64   `json:"seed"`
+	// Overrides pin localized weather over chunk ranges on top of the global
+	// weather cycle.
+	Overrides []WeatherOverride `json:"overrides"`
+	// Clock seeds the weather when Seed is zero. The simulation itself only
+	// moves when stepped, so a manual clock and fixed steps replay exactly.
+	// Nil means the system clock.
+	Clock clock.Clock `json:"-"`
+}
+
+// WeatherOverride replaces the global weather over an inclusive range of global
+// chunk coordinates. Chunks within BlendChunks of the range edge mix the
+// override with the global weather so the zone has no hard border.
+type WeatherOverride struct {
+	ID          string       `json:"id"`
+	MinChunkX   int          `json:"minChunkX"`
+	MinChunkY   int          `json:"minChunkY"`
+	MaxChunkX   int          `json:"maxChunkX"`
+	MaxChunkY   int          `json:"maxChunkY"`
+	Weather     WeatherState `json:"weather"`
+	BlendChunks int          `json:"blendChunks"`
 }
 
 type State struct {
@@ -82,22 +108,46 @@ type Environment struct {
 
 func New(cfg Config) *Environment {
 	cfg = applyDefaults(cfg)
-	rng := rand.New(rand.NewSource(cfg.Seed))
-	env := &Environment{
-		cfg: cfg,
-		rng: rng,
-	}
-	env.state.TimeOfDay = 12.0
-	env.dayProgress = 0.5
-	env.state.Phase = PhaseDay
-	env.state.Weather = WeatherState{Kind: WeatherClear, Intensity: 0, WindSpeed: cfg.WindBase, WindDirection: 0, Precipitation: 0}
-	env.state.Lighting = computeLighting(env.dayProgress, env.state.Weather, env.state.Phase)
-	env.state.Physics = computePhysics(env.state.Weather)
-	env.state.Behavior = computeBehavior(env.dayProgress, env.state.Weather, env.state.Phase)
-	env.weatherTimer = env.randomWeatherDuration()
+	env := &Environment{cfg: cfg}
+	env.reset()
 	return env
 }
 
+// reset rewinds the simulation to its initial state: noon, clear skies, and a
+// random sequence freshly seeded from the configuration.
+func (e *Environment) reset() {
+	e.rng = rand.New(rand.NewSource(e.cfg.Seed))
+	e.dayProgress = 0.5
+	e.state = State{}
+	e.state.Weather = WeatherState{Kind: WeatherClear, Intensity: 0, WindSpeed: e.cfg.WindBase, WindDirection: 0, Precipitation: 0}
+	e.refresh()
+	e.weatherTimer = e.randomWeatherDuration()
+}
+
+// refresh recomputes the time-of-day and weather-derived parts of the state.
+func (e *Environment) refresh() {
+	hours := e.dayProgress * 24
+	phase := determinePhase(hours)
+	e.state.TimeOfDay = hours
+	e.state.Phase = phase
+	e.state.Lighting = computeLighting(e.dayProgress, e.state.Weather, e.cfg.TransitionHours)
+	e.state.Physics = computePhysics(e.state.Weather)
+	e.state.Behavior = computeBehavior(e.dayProgress, e.state.Weather, phase)
+}
+
+// Reconfigure swaps the weather and cycle parameters while preserving the current
+// time of day, active weather, and random sequence. The configured seed is ignored
+// so a reload never rewinds the weather stream.
+func (e *Environment) Reconfigure(cfg Config) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	cfg.Seed = e.cfg.Seed
+	e.cfg = applyDefaults(cfg)
+	if e.weatherTimer > e.cfg.WeatherMaxDuration {
+		e.weatherTimer = e.cfg.WeatherMaxDuration
+	}
+}
+
 func applyDefaults(cfg Config) Config {
 	if cfg.DayLength <= 0 {
 		cfg.DayLength = 20 * time.Minute
@@ -125,8 +175,17 @@ func applyDefaults(cfg Config) Config {
 	if cfg.WindVariance < 0 {
 		cfg.WindVariance = 0
 	}
+	if cfg.TransitionHours <= 0 {
+		cfg.TransitionHours = 1
+	}
+	if cfg.TransitionHours > 12 {
+		cfg.TransitionHours = 12
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = clock.Real()
+	}
 	if cfg.Seed == 0 {
-		cfg.Seed = time.Now().UnixNano()
+		cfg.Seed = cfg.Clock.Now().UnixNano()
 	}
 	return cfg
 }
@@ -143,29 +202,109 @@ func (e *Environment) Step(delta time.Duration) State {
 	for e.dayProgress >= 1 {
 		e.dayProgress -= 1
 	}
-	hours := e.dayProgress * 24
-	phase := determinePhase(hours)
+	e.advanceWeather(delta)
+	e.refresh()
+	return e.state
+}
 
-	e.weatherTimer -= delta
-	if e.weatherTimer <= 0 {
-		e.state.Weather = e.rollWeather()
-		e.weatherTimer = e.randomWeatherDuration()
+// Seek rebuilds the state the environment reaches totalElapsed after creation.
+// The random sequence is re-seeded from the configuration and weather is rolled
+// once per elapsed weather period, so the result matches stepping through the
+// same span in any increments without replaying each delta.
+func (e *Environment) Seek(totalElapsed time.Duration) State {
+	if totalElapsed < 0 {
+		totalElapsed = 0
 	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
 
-	e.state.TimeOfDay = hours
-	e.state.Phase = phase
-	e.state.Lighting = computeLighting(e.dayProgress, e.state.Weather, phase)
-	e.state.Physics = computePhysics(e.state.Weather)
-	e.state.Behavior = computeBehavior(e.dayProgress, e.state.Weather, phase)
+	e.reset()
+	e.dayProgress = math.Mod(e.dayProgress+float64(totalElapsed)/float64(e.cfg.DayLength), 1)
+	e.advanceWeather(totalElapsed)
+	e.refresh()
 	return e.state
 }
 
+// advanceWeather runs the weather timer forward by delta, rolling new weather at
+// every boundary crossed. Overshoot carries into the next period so boundaries
+// do not depend on how time is sliced into steps.
+func (e *Environment) advanceWeather(delta time.Duration) {
+	e.weatherTimer -= delta
+	for e.weatherTimer <= 0 {
+		e.state.Weather = e.rollWeather()
+		e.weatherTimer += e.randomWeatherDuration()
+	}
+}
+
 func (e *Environment) CurrentState() State {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	return e.state
 }
 
+// WeatherAt reports the weather over the given global chunk. The second result
+// is false when no override covers the chunk and the global weather applies.
+// Where overrides overlap, the one with the strongest blend weight wins.
+func (e *Environment) WeatherAt(chunkX, chunkY int) (WeatherState, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	global := e.state.Weather
+	best := -1
+	bestWeight := 0.0
+	for i, override := range e.cfg.Overrides {
+		if weight := override.weight(chunkX, chunkY); weight > bestWeight {
+			best = i
+			bestWeight = weight
+		}
+	}
+	if best < 0 {
+		return global, false
+	}
+	return blendWeather(global, e.cfg.Overrides[best].Weather, bestWeight), true
+}
+
+// weight returns how strongly the override applies to a chunk: 0 outside the
+// range, rising across the blend band to 1 in the interior.
+func (o WeatherOverride) weight(chunkX, chunkY int) float64 {
+	if chunkX < o.MinChunkX || chunkX > o.MaxChunkX || chunkY < o.MinChunkY || chunkY > o.MaxChunkY {
+		return 0
+	}
+	if o.BlendChunks <= 0 {
+		return 1
+	}
+	edge := chunkX - o.MinChunkX
+	for _, d := range []int{o.MaxChunkX - chunkX, chunkY - o.MinChunkY, o.MaxChunkY - chunkY} {
+		if d < edge {
+			edge = d
+		}
+	}
+	return clamp01(float64(edge+1) / float64(o.BlendChunks+1))
+}
+
+func blendWeather(global, override WeatherState, weight float64) WeatherState {
+	if weight >= 1 {
+		return override
+	}
+	lerp := func(a, b float64) float64 { return a + (b-a)*weight }
+	kind := global.Kind
+	if weight >= 0.5 {
+		kind = override.Kind
+	}
+	windX := lerp(math.Cos(global.WindDirection)*global.WindSpeed, math.Cos(override.WindDirection)*override.WindSpeed)
+	windY := lerp(math.Sin(global.WindDirection)*global.WindSpeed, math.Sin(override.WindDirection)*override.WindSpeed)
+	direction := math.Atan2(windY, windX)
+	if direction < 0 {
+		direction += 2 * math.Pi
+	}
+	return WeatherState{
+		Kind:          kind,
+		Intensity:     lerp(global.Intensity, override.Intensity),
+		WindSpeed:     math.Hypot(windX, windY),
+		WindDirection: direction,
+		Precipitation: lerp(global.Precipitation, override.Precipitation),
+	}
+}
+
 func (e *Environment) rollWeather() WeatherState {
 	roll := e.rng.Float64()
 	var kind WeatherKind
@@ -220,18 +359,26 @@ func determinePhase(hour float64) Phase {
 	}
 }
 
-func computeLighting(progress float64, weather WeatherState, phase Phase) LightingState {
+const (
+	nightEndHour   = 5.0
+	nightStartHour = 21.0
+)
+
+// computeLighting derives lighting from the continuous time of day rather than
+// the discrete phase, so ambient and fog ease between their night and day levels
+// across the transition window instead of stepping at the phase boundary.
+func computeLighting(progress float64, weather WeatherState, transitionHours float64) LightingState {
 	sunAngle := progress * 2 * math.Pi
 	sunHeight := math.Cos((progress - 0.5) * 2 * math.Pi)
 	if sunHeight < 0 {
 		sunHeight = 0
 	}
-	ambient := 0.12 + 0.88*sunHeight
-	if phase == PhaseNight {
-		ambient = 0.08 + 0.12*sunHeight
-	}
+	night := nightWeight(progress*24, transitionHours)
+	dayAmbient := 0.12 + 0.88*sunHeight
+	nightAmbient := 0.08 + 0.12*sunHeight
+	ambient := dayAmbient + (nightAmbient-dayAmbient)*night
 	ambient *= 1 - 0.35*weather.Intensity
-	fog := 0.02 + 0.25*weather.Intensity
+	fog := 0.02 + 0.04*night + 0.25*weather.Intensity
 	tint := 0.0
 	switch weather.Kind {
 	case WeatherRain:
@@ -247,6 +394,28 @@ func computeLighting(progress float64, weather WeatherState, phase Phase) Lighti
 	}
 }
 
+// nightWeight reports how far into night the given hour is: 0 in full day, 1 in
+// full night, easing smoothly across a window of the given width centred on
+// each night boundary.
+func nightWeight(hour, window float64) float64 {
+	half := window / 2
+	if hour < 12 {
+		return 1 - smoothstep(nightEndHour-half, nightEndHour+half, hour)
+	}
+	return smoothstep(nightStartHour-half, nightStartHour+half, hour)
+}
+
+func smoothstep(edge0, edge1, x float64) float64 {
+	if edge1 <= edge0 {
+		if x < edge0 {
+			return 0
+		}
+		return 1
+	}
+	t := clamp01((x - edge0) / (edge1 - edge0))
+	return t * t * (3 - 2*t)
+}
+
 func computePhysics(weather WeatherState) PhysicsModifiers {
 	gravity := 1.0 + 0.06*weather.Intensity
 	drag := 1.0 + 0.5*weather.Intensity
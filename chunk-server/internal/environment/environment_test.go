@@ -0,0 +1,124 @@
+package environment
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func testConfig() Config {
+	return Config{
+		DayLength:          10 * time.Minute,
+		WeatherMinDuration: 20 * time.Second,
+		WeatherMaxDuration: 90 * time.Second,
+		StormChance:        0.3,
+		RainChance:         0.3,
+		WindBase:           3,
+		WindVariance:       5,
+		Seed:               42,
+	}
+}
+
+func TestSeekMatchesIncrementalSteps(t *testing.T) {
+	const step = 100 * time.Millisecond
+	target := 37*time.Minute + 400*time.Millisecond
+
+	stepped := New(testConfig())
+	var want State
+	for elapsed := time.Duration(0); elapsed < target; elapsed += step {
+		want = stepped.Step(step)
+	}
+
+	got := New(testConfig()).Seek(target)
+	if got.Weather != want.Weather {
+		t.Fatalf("weather mismatch: seek %+v, stepped %+v", got.Weather, want.Weather)
+	}
+	if got.Phase != want.Phase {
+		t.Fatalf("phase mismatch: seek %s, stepped %s", got.Phase, want.Phase)
+	}
+	if math.Abs(got.TimeOfDay-want.TimeOfDay) > 1e-6 {
+		t.Fatalf("time of day mismatch: seek %.9f, stepped %.9f", got.TimeOfDay, want.TimeOfDay)
+	}
+	if math.Abs(got.Lighting.Ambient-want.Lighting.Ambient) > 1e-6 {
+		t.Fatalf("ambient mismatch: seek %.9f, stepped %.9f", got.Lighting.Ambient, want.Lighting.Ambient)
+	}
+}
+
+func TestSeekRewindsAfterStepping(t *testing.T) {
+	env := New(testConfig())
+	early := env.Seek(5 * time.Minute)
+	for i := 0; i < 600; i++ {
+		env.Step(time.Second)
+	}
+	if again := env.Seek(5 * time.Minute); again != early {
+		t.Fatalf("expected seeking back to reproduce the earlier state: %+v vs %+v", again, early)
+	}
+}
+
+func TestAmbientChangesContinuouslyAcrossDawn(t *testing.T) {
+	clear := WeatherState{Kind: WeatherClear}
+	const samplesPerHour = 60
+	prev := computeLighting(4.0/24, clear, 1).Ambient
+	for i := 1; i <= 2*samplesPerHour; i++ {
+		hour := 4.0 + float64(i)/samplesPerHour
+		ambient := computeLighting(hour/24, clear, 1).Ambient
+		if ambient < prev-1e-12 {
+			t.Fatalf("ambient dropped from %.4f to %.4f at hour %.3f", prev, ambient, hour)
+		}
+		if ambient-prev > 0.005 {
+			t.Fatalf("ambient jumped from %.4f to %.4f at hour %.3f", prev, ambient, hour)
+		}
+		prev = ambient
+	}
+}
+
+func TestLightingChangesContinuouslyAcrossDusk(t *testing.T) {
+	clear := WeatherState{Kind: WeatherClear}
+	const samplesPerHour = 60
+	prev := computeLighting(20.0/24, clear, 1)
+	for i := 1; i <= 2*samplesPerHour; i++ {
+		hour := 20.0 + float64(i)/samplesPerHour
+		lighting := computeLighting(hour/24, clear, 1)
+		if lighting.Ambient > prev.Ambient+1e-12 {
+			t.Fatalf("ambient rose from %.4f to %.4f at hour %.3f", prev.Ambient, lighting.Ambient, hour)
+		}
+		if math.Abs(lighting.Ambient-prev.Ambient) > 0.005 || math.Abs(lighting.FogDensity-prev.FogDensity) > 0.005 {
+			t.Fatalf("lighting stepped at hour %.3f: %+v -> %+v", hour, prev, lighting)
+		}
+		prev = lighting
+	}
+	if got := determinePhase(21.5); got != PhaseNight {
+		t.Fatalf("expected gameplay phase to stay discrete, got %s", got)
+	}
+}
+
+func TestWeatherOverrideAppliesInsideZone(t *testing.T) {
+	cfg := testConfig()
+	cfg.Overrides = []WeatherOverride{{
+		ID:        "squall",
+		MinChunkX: 10, MinChunkY: 10,
+		MaxChunkX: 20, MaxChunkY: 20,
+		Weather:     WeatherState{Kind: WeatherStorm, Intensity: 0.9, WindSpeed: 18, WindDirection: math.Pi, Precipitation: 0.9},
+		BlendChunks: 2,
+	}}
+	env := New(cfg)
+	global := env.CurrentState().Weather
+
+	inside, overridden := env.WeatherAt(15, 15)
+	if !overridden || inside != cfg.Overrides[0].Weather {
+		t.Fatalf("expected interior chunk to report override weather, got %+v (overridden=%v)", inside, overridden)
+	}
+
+	outside, overridden := env.WeatherAt(2, 2)
+	if overridden || outside != global {
+		t.Fatalf("expected outside chunk to report global weather, got %+v (overridden=%v)", outside, overridden)
+	}
+
+	edge, overridden := env.WeatherAt(10, 15)
+	if !overridden {
+		t.Fatalf("expected edge chunk to be covered by the override")
+	}
+	if edge.Intensity <= global.Intensity || edge.Intensity >= inside.Intensity {
+		t.Fatalf("expected edge intensity between %.3f and %.3f, got %.3f", global.Intensity, inside.Intensity, edge.Intensity)
+	}
+}
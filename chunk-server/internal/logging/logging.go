@@ -0,0 +1,98 @@
+// Package logging provides the leveled logger the chunk server writes
+// through. Lines are key=value records, so they can be filtered by level and
+// parsed by log tooling.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"strings"
+)
+
+// Logger is a structured logger whose level can change while it is in use.
+// Loggers derived with With share their parent's level.
+type Logger struct {
+	*slog.Logger
+	level *slog.LevelVar
+}
+
+// New returns a logger writing records at level and above to w.
+func New(w io.Writer, level slog.Level) *Logger {
+	lv := new(slog.LevelVar)
+	lv.Set(level)
+	handler := slog.NewTextHandler(w, &slog.HandlerOptions{Level: lv})
+	return &Logger{Logger: slog.New(handler), level: lv}
+}
+
+// Default returns an info-level logger writing to the standard log output.
+func Default() *Logger {
+	return New(log.Writer(), slog.LevelInfo)
+}
+
+// Discard returns a logger that drops every record.
+func Discard() *Logger {
+	return New(io.Discard, slog.LevelError)
+}
+
+// ParseLevel reads a level name: debug, info, warn, or error. The empty name
+// is info.
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("unknown log level %q (want debug, info, warn, or error)", name)
+	}
+}
+
+// SetLevel changes the minimum level of l and every logger sharing it.
+func (l *Logger) SetLevel(level slog.Level) {
+	l.level.Set(level)
+}
+
+// Level returns the minimum level l writes.
+func (l *Logger) Level() slog.Level {
+	return l.level.Level()
+}
+
+// With returns a logger that adds args to every record and shares l's level.
+func (l *Logger) With(args ...any) *Logger {
+	return &Logger{Logger: l.Logger.With(args...), level: l.level}
+}
+
+// Debugf formats a debug record.
+func (l *Logger) Debugf(format string, args ...any) {
+	l.logf(slog.LevelDebug, format, args...)
+}
+
+// Printf formats an info record, so l can stand in for a *log.Logger.
+func (l *Logger) Printf(format string, args ...any) {
+	l.logf(slog.LevelInfo, format, args...)
+}
+
+// Warnf formats a warning record.
+func (l *Logger) Warnf(format string, args ...any) {
+	l.logf(slog.LevelWarn, format, args...)
+}
+
+// Errorf formats an error record.
+func (l *Logger) Errorf(format string, args ...any) {
+	l.logf(slog.LevelError, format, args...)
+}
+
+func (l *Logger) logf(level slog.Level, format string, args ...any) {
+	ctx := context.Background()
+	if !l.Enabled(ctx, level) {
+		return
+	}
+	l.Log(ctx, level, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLevelFiltersRecords(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out, slog.LevelInfo)
+	derived := logger.With("component", "terrain")
+
+	derived.Debugf("chunk %d generation progress: %d%%", 3, 50)
+	logger.Warn("migration failed", "entity", "unit-1")
+	if strings.Contains(out.String(), "progress") {
+		t.Fatalf("debug record written at info level:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "level=WARN") || !strings.Contains(out.String(), "entity=unit-1") {
+		t.Fatalf("expected a structured warning, got:\n%s", out.String())
+	}
+
+	logger.SetLevel(slog.LevelDebug)
+	derived.Debugf("chunk %d generation progress: %d%%", 3, 50)
+	if !strings.Contains(out.String(), `msg="chunk 3 generation progress: 50%" component=terrain`) {
+		t.Fatalf("expected derived logger to follow the new level, got:\n%s", out.String())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for name, want := range map[string]slog.Level{
+		"":        slog.LevelInfo,
+		"debug":   slog.LevelDebug,
+		"INFO":    slog.LevelInfo,
+		"warning": slog.LevelWarn,
+		"error":   slog.LevelError,
+	} {
+		got, err := ParseLevel(name)
+		if err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Fatalf("expected an unknown level to fail")
+	}
+}
@@ -2,9 +2,17 @@ package migration
 
 import "sync"
 
+// deadLetterLimit is how many dead-lettered requests a queue keeps; older
+// ones are dropped but still counted.
+const deadLetterLimit = 32
+
 type Queue struct {
 	mu      sync.Mutex
 	pending []Request
+
+	maxAttempts int
+	deadLetters []Request
+	deadCount   int
 }
 
 func NewQueue() *Queue {
@@ -49,3 +57,44 @@ func (q *Queue) Len() int {
 	defer q.mu.Unlock()
 	return len(q.pending)
 }
+
+// SetMaxAttempts bounds how many attempts Retry allows a request. Zero, the
+// default, retries forever.
+func (q *Queue) SetMaxAttempts(n int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.maxAttempts = n
+}
+
+// Retry enqueues req again unless it has used up its attempts, in which case
+// it is moved to the dead letters and Retry returns false.
+func (q *Queue) Retry(req Request) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.maxAttempts <= 0 || req.Attempts < q.maxAttempts {
+		q.pending = append(q.pending, req)
+		return true
+	}
+	q.deadCount++
+	if len(q.deadLetters) == deadLetterLimit {
+		q.deadLetters = append(q.deadLetters[:0:0], q.deadLetters[1:]...)
+	}
+	q.deadLetters = append(q.deadLetters, req)
+	return false
+}
+
+// DeadLetters returns the most recent requests that ran out of attempts,
+// oldest first.
+func (q *Queue) DeadLetters() []Request {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]Request(nil), q.deadLetters...)
+}
+
+// DeadLetterCount reports how many requests have run out of attempts in
+// total.
+func (q *Queue) DeadLetterCount() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.deadCount
+}
@@ -51,3 +51,27 @@ func TestQueueDrainReleasesReferences(t *testing.T) {
 		t.Fatalf("expected remaining request to be 'third', got %s", q.pending[0].EntityID)
 	}
 }
+
+func TestQueueRetryDeadLettersRequestsOutOfAttempts(t *testing.T) {
+	q := NewQueue()
+	q.SetMaxAttempts(3)
+
+	req := sampleRequest("stuck")
+	for req.Attempts = 1; req.Attempts < 3; req.Attempts++ {
+		if !q.Retry(req) {
+			t.Fatalf("attempt %d was dead-lettered before the limit", req.Attempts)
+		}
+	}
+	if q.Len() != 2 {
+		t.Fatalf("expected both retries queued, got %d", q.Len())
+	}
+	if q.Retry(req) {
+		t.Fatalf("expected the third attempt to be dead-lettered")
+	}
+	if q.Len() != 2 || q.DeadLetterCount() != 1 {
+		t.Fatalf("queued %d, dead-lettered %d; want 2 and 1", q.Len(), q.DeadLetterCount())
+	}
+	if dead := q.DeadLetters(); len(dead) != 1 || dead[0].EntityID != req.EntityID {
+		t.Fatalf("unexpected dead letters %+v", dead)
+	}
+}
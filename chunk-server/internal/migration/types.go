@@ -26,6 +26,8 @@ type Request struct {
 	LastAttempt    time.Time
 	Reason         string
 	Nonce          uint64
+	// Attempts counts the transfer requests sent for this migration.
+	Attempts int
 }
 
 type Result struct {
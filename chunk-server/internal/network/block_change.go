@@ -0,0 +1,125 @@
+package network
+
+import "chunkserver/internal/world"
+
+// EncodeBlockType returns the compact code for t, or BlockTypeUnknown for
+// types without one.
+func EncodeBlockType(t world.BlockType) BlockTypeCode {
+	switch t {
+	case world.BlockAir:
+		return BlockTypeAir
+	case world.BlockSolid:
+		return BlockTypeSolid
+	case world.BlockUnstable:
+		return BlockTypeUnstable
+	case world.BlockMineral:
+		return BlockTypeMineral
+	case world.BlockExplosive:
+		return BlockTypeExplosive
+	default:
+		return BlockTypeUnknown
+	}
+}
+
+// BlockType returns the block type c encodes, or the empty type for
+// BlockTypeUnknown.
+func (c BlockTypeCode) BlockType() world.BlockType {
+	switch c {
+	case BlockTypeAir:
+		return world.BlockAir
+	case BlockTypeSolid:
+		return world.BlockSolid
+	case BlockTypeUnstable:
+		return world.BlockUnstable
+	case BlockTypeMineral:
+		return world.BlockMineral
+	case BlockTypeExplosive:
+		return world.BlockExplosive
+	default:
+		return ""
+	}
+}
+
+// EncodeChangeReason returns the compact code for reason.
+func EncodeChangeReason(reason world.ChangeReason) ChangeReasonCode {
+	switch reason {
+	case world.ReasonDamage:
+		return ChangeReasonDamage
+	case world.ReasonDestroy:
+		return ChangeReasonDestroy
+	case world.ReasonCollapse:
+		return ChangeReasonCollapse
+	case world.ReasonEdit:
+		return ChangeReasonEdit
+	case world.ReasonRepair:
+		return ChangeReasonRepair
+	default:
+		return ChangeReasonUnknown
+	}
+}
+
+// Reason returns the change reason c encodes, or the empty reason for
+// ChangeReasonUnknown.
+func (c ChangeReasonCode) Reason() world.ChangeReason {
+	switch c {
+	case ChangeReasonDamage:
+		return world.ReasonDamage
+	case ChangeReasonDestroy:
+		return world.ReasonDestroy
+	case ChangeReasonCollapse:
+		return world.ReasonCollapse
+	case ChangeReasonEdit:
+		return world.ReasonEdit
+	case ChangeReasonRepair:
+		return world.ReasonRepair
+	default:
+		return ""
+	}
+}
+
+// BlockChangeFromChange encodes the block a change leaves behind for a chunk
+// delta. Only the fields clients render are carried; resource yields,
+// metadata, and structural values stay on the server.
+func BlockChangeFromChange(change world.BlockChange) BlockChange {
+	after := change.After
+	encoded := BlockChange{
+		X:        change.Coord.X,
+		Y:        change.Coord.Y,
+		Z:        change.Coord.Z,
+		Type:     EncodeBlockType(after.Type),
+		Material: after.Material,
+		Color:    after.Color,
+		Texture:  after.Texture,
+		HP:       after.HitPoints,
+		MaxHP:    after.MaxHitPoints,
+		Reason:   EncodeChangeReason(change.Reason),
+		Light:    after.LightEmission,
+	}
+	if encoded.Type == BlockTypeUnknown {
+		encoded.TypeName = string(after.Type)
+	}
+	return encoded
+}
+
+// Coord returns the position of the changed block.
+func (c BlockChange) Coord() world.BlockCoord {
+	return world.BlockCoord{X: c.X, Y: c.Y, Z: c.Z}
+}
+
+// ToBlock rebuilds the block c carries, for receivers keeping a mirror of the
+// world. Fields a BlockChange does not carry are left zero.
+func (c BlockChange) ToBlock() world.Block {
+	blockType := c.Type.BlockType()
+	if c.Type == BlockTypeUnknown {
+		blockType = world.BlockType(c.TypeName)
+	}
+	return world.Block{
+		Type:          blockType,
+		Material:      c.Material,
+		Color:         c.Color,
+		Texture:       c.Texture,
+		HitPoints:     c.HP,
+		MaxHitPoints:  c.MaxHP,
+		LightEmission: c.Light,
+	}
+}
@@ -0,0 +1,62 @@
+package network
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"chunkserver/internal/world"
+)
+
+func TestBlockChangeRoundTripsEveryType(t *testing.T) {
+	types := []world.BlockType{
+		world.BlockAir,
+		world.BlockSolid,
+		world.BlockUnstable,
+		world.BlockMineral,
+		world.BlockExplosive,
+		world.BlockType("crystal"),
+	}
+	reasons := []world.ChangeReason{
+		world.ReasonDamage,
+		world.ReasonDestroy,
+		world.ReasonCollapse,
+		world.ReasonEdit,
+		world.ReasonRepair,
+	}
+	for _, blockType := range types {
+		for _, reason := range reasons {
+			after := world.Block{
+				Type:          blockType,
+				Material:      "granite",
+				Color:         "#808080",
+				Texture:       "rough",
+				HitPoints:     40,
+				MaxHitPoints:  100,
+				LightEmission: 0.5,
+			}
+			change := world.BlockChange{
+				Coord:  world.BlockCoord{X: 3, Y: -4, Z: 5},
+				After:  after,
+				Reason: reason,
+			}
+			payload, err := json.Marshal(BlockChangeFromChange(change))
+			if err != nil {
+				t.Fatalf("marshal %s/%s: %v", blockType, reason, err)
+			}
+			var decoded BlockChange
+			if err := json.Unmarshal(payload, &decoded); err != nil {
+				t.Fatalf("unmarshal %s/%s: %v", blockType, reason, err)
+			}
+			if got := decoded.ToBlock(); !reflect.DeepEqual(got, after) {
+				t.Fatalf("%s/%s: expected block %+v, got %+v", blockType, reason, after, got)
+			}
+			if got := decoded.Coord(); got != change.Coord {
+				t.Fatalf("%s/%s: expected coord %+v, got %+v", blockType, reason, change.Coord, got)
+			}
+			if got := decoded.Reason.Reason(); got != reason {
+				t.Fatalf("expected reason %s, got %s", reason, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package network
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"chunkserver/internal/world"
+)
+
+const (
+	// chunkTransferOverhead is the room left in each datagram for the
+	// envelope and the ChunkTransfer fields around the data.
+	chunkTransferOverhead = 1024
+	// chunkTransferTimeout is how long a partially received transfer is kept
+	// before its parts are dropped.
+	chunkTransferTimeout = 30 * time.Second
+	// maxPendingChunkTransfers bounds how many partially received transfers
+	// are buffered at once.
+	maxPendingChunkTransfers = 8
+)
+
+// chunkTransferPartSize returns how many bytes of export data fit in each part
+// sent in datagrams of maxDatagram bytes.
+func chunkTransferPartSize(maxDatagram int) int {
+	// Data travels base64 encoded, which grows it by a third.
+	partSize := (maxDatagram - chunkTransferOverhead) / 4 * 3
+	if partSize <= 0 {
+		partSize = 1
+	}
+	return partSize
+}
+
+// SplitChunkTransfer cuts data into ChunkTransfer parts that each fit in a
+// datagram of maxDatagram bytes once encoded. Every part copies the header
+// fields from base.
+func SplitChunkTransfer(base ChunkTransfer, data []byte, maxDatagram int) []ChunkTransfer {
+	partSize := chunkTransferPartSize(maxDatagram)
+	parts := (len(data) + partSize - 1) / partSize
+	if parts == 0 {
+		parts = 1
+	}
+	out := make([]ChunkTransfer, parts)
+	for i := range out {
+		start := i * partSize
+		end := start + partSize
+		if end > len(data) {
+			end = len(data)
+		}
+		part := base
+		part.Part = i
+		part.Parts = parts
+		part.Data = data[start:end]
+		out[i] = part
+	}
+	return out
+}
+
+// ChunkTransferAssembler collects ChunkTransfer parts until every part of a
+// transfer has arrived. It buffers at most maxPendingChunkTransfers transfers,
+// each no larger than world.MaxChunkExportBytes. The zero value is ready to
+// use and assumes senders split exports for 64 KiB datagrams.
+type ChunkTransferAssembler struct {
+	mu          sync.Mutex
+	pending     map[chunkTransferKey]*chunkTransferParts
+	maxDatagram int
+}
+
+type chunkTransferKey struct {
+	serverID string
+	id       uint64
+}
+
+type chunkTransferParts struct {
+	parts    [][]byte
+	received int
+	started  time.Time
+}
+
+// SetMaxDatagram sets the datagram size senders split exports for, which
+// bounds how many parts one transfer may claim.
+func (a *ChunkTransferAssembler) SetMaxDatagram(size int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.maxDatagram = size
+}
+
+// maxPartsLocked returns how many parts a transfer of the largest allowed
+// export needs.
+func (a *ChunkTransferAssembler) maxPartsLocked() int {
+	maxDatagram := a.maxDatagram
+	if maxDatagram <= 0 {
+		maxDatagram = defaultMaxDatagramSize
+	}
+	partSize := chunkTransferPartSize(maxDatagram)
+	return (world.MaxChunkExportBytes + partSize - 1) / partSize
+}
+
+// Add records part and returns the reassembled data once the transfer is
+// complete. Parts of transfers that have not completed within a timeout are
+// discarded.
+func (a *ChunkTransferAssembler) Add(part ChunkTransfer) ([]byte, bool, error) {
+	if part.Parts <= 0 || part.Part < 0 || part.Part >= part.Parts {
+		return nil, false, fmt.Errorf("chunk transfer %d: part %d of %d out of range", part.TransferID, part.Part, part.Parts)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	if a.pending == nil {
+		a.pending = make(map[chunkTransferKey]*chunkTransferParts)
+	}
+	for key, transfer := range a.pending {
+		if now.Sub(transfer.started) > chunkTransferTimeout {
+			delete(a.pending, key)
+		}
+	}
+	if maxParts := a.maxPartsLocked(); part.Parts > maxParts {
+		return nil, false, fmt.Errorf("chunk transfer %d: %d parts exceeds the limit of %d", part.TransferID, part.Parts, maxParts)
+	}
+
+	key := chunkTransferKey{serverID: part.ServerID, id: part.TransferID}
+	transfer, ok := a.pending[key]
+	if !ok {
+		if len(a.pending) >= maxPendingChunkTransfers {
+			return nil, false, fmt.Errorf("chunk transfer %d: %d transfers already pending", part.TransferID, len(a.pending))
+		}
+		transfer = &chunkTransferParts{parts: make([][]byte, part.Parts), started: now}
+		a.pending[key] = transfer
+	}
+	if len(transfer.parts) != part.Parts {
+		delete(a.pending, key)
+		return nil, false, fmt.Errorf("chunk transfer %d: part count changed from %d to %d", part.TransferID, len(transfer.parts), part.Parts)
+	}
+	if transfer.parts[part.Part] == nil {
+		// A nil entry marks a missing part, so empty data is stored as an
+		// empty, non-nil slice.
+		transfer.parts[part.Part] = append([]byte{}, part.Data...)
+		transfer.received++
+	}
+	if transfer.received < len(transfer.parts) {
+		return nil, false, nil
+	}
+
+	delete(a.pending, key)
+	var size int
+	for _, data := range transfer.parts {
+		size += len(data)
+	}
+	data := make([]byte, 0, size)
+	for _, piece := range transfer.parts {
+		data = append(data, piece...)
+	}
+	return data, true, nil
+}
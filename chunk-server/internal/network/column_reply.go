@@ -0,0 +1,61 @@
+package network
+
+import (
+	"encoding/json"
+
+	"chunkserver/internal/world"
+)
+
+// columnReplyOverhead is the room left in each datagram for the envelope and
+// the ColumnReply fields around the runs.
+const columnReplyOverhead = 512
+
+// ColumnRunsFromWorld encodes a stored column's runs for a ColumnReply,
+// numbering them from z=0.
+func ColumnRunsFromWorld(runs []world.ColumnRun) []ColumnRun {
+	out := make([]ColumnRun, 0, len(runs))
+	z := 0
+	for _, run := range runs {
+		out = append(out, ColumnRun{
+			Z:            z,
+			Count:        run.Count,
+			Type:         string(run.Block.Type),
+			Material:     run.Block.Material,
+			HitPoints:    run.Block.HitPoints,
+			MaxHitPoints: run.Block.MaxHitPoints,
+		})
+		z += run.Count
+	}
+	return out
+}
+
+// SplitColumnReply spreads runs over as few ColumnReply parts as fit in
+// datagrams of maxDatagram bytes once encoded. Every part copies the header
+// fields from base. A run is never split, so one that alone exceeds the
+// budget still gets a part of its own.
+func SplitColumnReply(base ColumnReply, runs []ColumnRun, maxDatagram int) []ColumnReply {
+	budget := maxDatagram - columnReplyOverhead
+	var out []ColumnReply
+	part := base
+	part.Runs = nil
+	size := 0
+	for _, run := range runs {
+		encoded, _ := json.Marshal(run)
+		// Each run also costs a separating comma.
+		n := len(encoded) + 1
+		if len(part.Runs) > 0 && size+n > budget {
+			out = append(out, part)
+			part = base
+			part.Runs = nil
+			size = 0
+		}
+		part.Runs = append(part.Runs, run)
+		size += n
+	}
+	out = append(out, part)
+	for i := range out {
+		out[i].Part = i
+		out[i].Parts = len(out)
+	}
+	return out
+}
@@ -17,11 +17,18 @@ const (
 	MessageEntityReply     MessageType = "entityReply"
 	MessagePathRequest     MessageType = "pathRequest"
 	MessagePathResponse    MessageType = "pathResponse"
+	MessagePathCancel      MessageType = "pathCancel"
 	MessageTransferClaim   MessageType = "transferClaim"
 	MessageNeighborHello   MessageType = "neighborHello"
 	MessageNeighborAck     MessageType = "neighborAck"
 	MessageTransferRequest MessageType = "transferRequest"
 	MessageTransferAck     MessageType = "transferAck"
+	MessageEnvironment     MessageType = "environment"
+	MessageChunkTransfer   MessageType = "chunkTransfer"
+	MessageBlockQuery      MessageType = "blockQuery"
+	MessageBlockReply      MessageType = "blockReply"
+	MessageColumnQuery     MessageType = "columnQuery"
+	MessageColumnReply     MessageType = "columnReply"
 )
 
 type Envelope struct {
@@ -37,6 +44,7 @@ type Hello struct {
 		OriginX int `json:"originX"`
 		OriginY int `json:"originY"`
 		Size    int `json:"size"`
+		SizeY   int `json:"sizeY,omitempty"` // omitted for square regions
 	} `json:"region"`
 }
 
@@ -50,6 +58,11 @@ type ChunkSummary struct {
 	ChunkY     int    `json:"chunkY"`
 	Version    uint64 `json:"version"`
 	BlockCount int    `json:"blockCount"`
+	// Fingerprint is the chunk's block hash in hex. A mirror whose copy of
+	// the chunk hashes differently should fetch it again.
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// Weather is set only when a weather override covers the chunk.
+	Weather *WeatherUpdate `json:"weather,omitempty"`
 }
 
 type ChunkDelta struct {
@@ -82,6 +95,8 @@ const (
 	ChangeReasonDamage
 	ChangeReasonDestroy
 	ChangeReasonCollapse
+	ChangeReasonEdit
+	ChangeReasonRepair
 )
 
 type BlockChange struct {
@@ -89,6 +104,7 @@ type BlockChange struct {
 	Y        int              `json:"y"`
 	Z        int              `json:"z"`
 	Type     BlockTypeCode    `json:"type"`
+	TypeName string           `json:"typeName,omitempty"` // set when Type is BlockTypeUnknown
 	Material string           `json:"material,omitempty"`
 	Color    string           `json:"color,omitempty"`
 	Texture  string           `json:"texture,omitempty"`
@@ -98,12 +114,39 @@ type BlockChange struct {
 	Light    float64          `json:"lightEmission,omitempty"`
 }
 
+// EnvironmentUpdate publishes the server's day/night and weather state so
+// clients can render sky, fog, and particles consistently with the simulation.
+type EnvironmentUpdate struct {
+	ServerID  string         `json:"serverId"`
+	Timestamp time.Time      `json:"timestamp"`
+	TimeOfDay float64        `json:"timeOfDay"`
+	Phase     string         `json:"phase"`
+	Weather   WeatherUpdate  `json:"weather"`
+	Lighting  LightingUpdate `json:"lighting"`
+}
+
+type WeatherUpdate struct {
+	Kind          string  `json:"kind"`
+	Intensity     float64 `json:"intensity"`
+	WindSpeed     float64 `json:"windSpeed"`
+	WindDirection float64 `json:"windDirection"`
+	Precipitation float64 `json:"precipitation"`
+}
+
+type LightingUpdate struct {
+	Ambient     float64 `json:"ambient"`
+	SunAngle    float64 `json:"sunAngle"`
+	FogDensity  float64 `json:"fogDensity"`
+	WeatherTint float64 `json:"weatherTint"`
+}
+
 type NeighborHello struct {
 	ServerID      string    `json:"serverId"`
 	Listen        string    `json:"listen"`
 	RegionOriginX int       `json:"regionOriginX"`
 	RegionOriginY int       `json:"regionOriginY"`
 	RegionSize    int       `json:"regionSize"`
+	RegionSizeY   int       `json:"regionSizeY,omitempty"` // omitted for square regions
 	DeltaX        int       `json:"deltaX"`
 	DeltaY        int       `json:"deltaY"`
 	Timestamp     time.Time `json:"timestamp"`
@@ -116,6 +159,7 @@ type NeighborAck struct {
 	RegionOriginX int       `json:"regionOriginX"`
 	RegionOriginY int       `json:"regionOriginY"`
 	RegionSize    int       `json:"regionSize"`
+	RegionSizeY   int       `json:"regionSizeY,omitempty"` // omitted for square regions
 	DeltaX        int       `json:"deltaX"`
 	DeltaY        int       `json:"deltaY"`
 	Timestamp     time.Time `json:"timestamp"`
@@ -142,6 +186,9 @@ type EntityReply struct {
 	Entities []EntityState `json:"entities"`
 }
 
+// EntityState describes an entity in updates and transfers. Updates carry
+// BlockHP, the hit points each of the entity's blocks has left; only
+// transfers carry its whole body in Blocks, in the same order.
 type EntityState struct {
 	ID         string             `json:"id"`
 	Kind       string             `json:"kind"`
@@ -154,9 +201,40 @@ type EntityState struct {
 	CanFly     bool               `json:"canFly"`
 	CanDig     bool               `json:"canDig"`
 	Voxels     int                `json:"voxels"`
+	Blocks     []EntityBlockState `json:"blocks,omitempty"`
+	BlockHP    []float64          `json:"blockHp,omitempty"`
 	Attributes map[string]float64 `json:"attributes,omitempty"`
+	Inventory  map[string]float64 `json:"inventory,omitempty"`
+	Tags       []string           `json:"tags,omitempty"`
 	Dirty      bool               `json:"dirty"`
 	Dying      bool               `json:"dying"`
+	// Owner and CollisionGroup decide which entities this one does not
+	// collide with.
+	Owner          string `json:"owner,omitempty"`
+	CollisionGroup string `json:"collisionGroup,omitempty"`
+	// InventoryCapacity caps the total of Inventory; zero is unlimited.
+	InventoryCapacity float64 `json:"inventoryCapacity,omitempty"`
+	// Removed marks the last update for an entity the server has dropped.
+	Removed bool `json:"removed,omitempty"`
+}
+
+// EntityBlockState is one block of an entity's body. Offset is in entity
+// voxels from the entity's position, and HP is what the block has left of
+// MaxHP.
+type EntityBlockState struct {
+	Offset        []float64          `json:"offset"`
+	VoxelSize     float64            `json:"voxelSize,omitempty"`
+	Role          string             `json:"role,omitempty"`
+	Type          string             `json:"type"`
+	Material      string             `json:"material,omitempty"`
+	Color         string             `json:"color,omitempty"`
+	Texture       string             `json:"texture,omitempty"`
+	HP            float64            `json:"hp"`
+	MaxHP         float64            `json:"maxHp"`
+	Weight        float64            `json:"weight,omitempty"`
+	Light         float64            `json:"lightEmission,omitempty"`
+	ResourceYield map[string]float64 `json:"resourceYield,omitempty"`
+	Metadata      map[string]any     `json:"metadata,omitempty"`
 }
 
 type EntityBatch struct {
@@ -167,7 +245,10 @@ type EntityBatch struct {
 }
 
 type PathRequest struct {
-	EntityID  string `json:"entityId"`
+	EntityID string `json:"entityId"`
+	// RequestID, when set, is echoed in the response and lets a PathCancel
+	// call the request off.
+	RequestID string `json:"requestId,omitempty"`
 	FromX     int    `json:"fromX"`
 	FromY     int    `json:"fromY"`
 	FromZ     int    `json:"fromZ"`
@@ -178,6 +259,27 @@ type PathRequest struct {
 	Clearance int    `json:"clearance,omitempty"`
 	MaxClimb  int    `json:"maxClimb,omitempty"`
 	MaxDrop   int    `json:"maxDrop,omitempty"`
+	// TunnelWidth is how many blocks wide a digging unit's passage must be.
+	TunnelWidth int `json:"tunnelWidth,omitempty"`
+	// Waypoints, when set, are visited in order between the start and the
+	// destination. Requests with more than 32 are refused.
+	Waypoints []BlockStep `json:"waypoints,omitempty"`
+	// KeepOut lists boxes the route should avoid even where they are
+	// passable.
+	KeepOut []KeepOutBox `json:"keepOut,omitempty"`
+	// RegionRelative makes every block coordinate in the request, and in its
+	// response, relative to the first block of the server's region instead
+	// of global.
+	RegionRelative bool `json:"regionRelative,omitempty"`
+}
+
+// KeepOutBox is an inclusive box of blocks a path request avoids. A zero
+// Penalty forbids the box; a positive one is the extra cost per block
+// entered.
+type KeepOutBox struct {
+	Min     BlockStep `json:"min"`
+	Max     BlockStep `json:"max"`
+	Penalty float64   `json:"penalty,omitempty"`
 }
 
 type BlockStep struct {
@@ -187,8 +289,112 @@ type BlockStep struct {
 }
 
 type PathResponse struct {
-	EntityID string      `json:"entityId"`
-	Route    []BlockStep `json:"route"`
+	EntityID  string      `json:"entityId"`
+	RequestID string      `json:"requestId,omitempty"`
+	Route     []BlockStep `json:"route"`
+	// Dig lists the blocks a digging unit clears to follow Route.
+	Dig []DigStep `json:"dig,omitempty"`
+	// Status is "ok" when Route is set, otherwise why the search failed:
+	// "out_of_region", "blocked_endpoint", "node_limit", "timeout",
+	// "no_path", "unavailable" or "too_many_waypoints".
+	Status string     `json:"status"`
+	Stats  *PathStats `json:"stats,omitempty"`
+	// Error explains an empty route, such as which waypoint segment could
+	// not be routed.
+	Error string `json:"error,omitempty"`
+	// RegionRelative echoes the request: Route and Dig are relative to the
+	// server's region origin.
+	RegionRelative bool `json:"regionRelative,omitempty"`
+}
+
+// DigStep is a block a digging route clears and what mining it yields.
+type DigStep struct {
+	X     int                `json:"x"`
+	Y     int                `json:"y"`
+	Z     int                `json:"z"`
+	Block string             `json:"block"`
+	Yield map[string]float64 `json:"yield,omitempty"`
+}
+
+// PathStats reports the work behind a PathResponse.
+type PathStats struct {
+	Expanded  int     `json:"expanded"`
+	ElapsedMs float64 `json:"elapsedMs"`
+}
+
+// PathCancel calls off the path request with RequestID. No response is sent
+// for a cancelled request.
+type PathCancel struct {
+	RequestID string `json:"requestId"`
+}
+
+// BlockQuery asks for the block at (X, Y, Z) in global block coordinates.
+// When Max is set the query covers the inclusive box from (X, Y, Z) to Max
+// instead.
+type BlockQuery struct {
+	// RequestID is echoed in the reply so callers can match them up.
+	RequestID string     `json:"requestId,omitempty"`
+	X         int        `json:"x"`
+	Y         int        `json:"y"`
+	Z         int        `json:"z"`
+	Max       *BlockStep `json:"max,omitempty"`
+}
+
+// BlockReply answers a BlockQuery. Status is "ok" when Blocks is set,
+// "not_found" when no queried block lies in the server's region, or
+// "too_large" when the box holds more blocks than one reply carries.
+type BlockReply struct {
+	ServerID  string      `json:"serverId"`
+	RequestID string      `json:"requestId,omitempty"`
+	Status    string      `json:"status"`
+	Blocks    []BlockInfo `json:"blocks,omitempty"`
+}
+
+// BlockInfo is one block in a BlockReply. Blocks outside the region are left
+// out.
+type BlockInfo struct {
+	X            int     `json:"x"`
+	Y            int     `json:"y"`
+	Z            int     `json:"z"`
+	Type         string  `json:"type"`
+	Material     string  `json:"material,omitempty"`
+	HitPoints    float64 `json:"hp"`
+	MaxHitPoints float64 `json:"maxHp"`
+}
+
+// ColumnQuery asks for every block in the column at (X, Y) in global block
+// coordinates.
+type ColumnQuery struct {
+	// RequestID is echoed in each reply part.
+	RequestID string `json:"requestId,omitempty"`
+	X         int    `json:"x"`
+	Y         int    `json:"y"`
+}
+
+// ColumnReply answers a ColumnQuery with the column run-length encoded,
+// bottom first. Columns whose runs do not fit in one datagram are split
+// across Parts replies; each run carries its own Z so parts can be applied
+// in any order. Status is "ok" or "not_found" when the column is outside the
+// server's region.
+type ColumnReply struct {
+	ServerID  string      `json:"serverId"`
+	RequestID string      `json:"requestId,omitempty"`
+	X         int         `json:"x"`
+	Y         int         `json:"y"`
+	Status    string      `json:"status"`
+	Part      int         `json:"part"`
+	Parts     int         `json:"parts"`
+	Runs      []ColumnRun `json:"runs,omitempty"`
+}
+
+// ColumnRun is Count identical blocks stacked from Z upwards.
+type ColumnRun struct {
+	Z            int     `json:"z"`
+	Count        int     `json:"count"`
+	Type         string  `json:"type"`
+	Material     string  `json:"material,omitempty"`
+	HitPoints    float64 `json:"hp"`
+	MaxHitPoints float64 `json:"maxHp"`
 }
 
 type TransferClaim struct {
@@ -219,6 +425,19 @@ type TransferAck struct {
 	Timestamp  time.Time `json:"timestamp"`
 }
 
+// ChunkTransfer carries one part of a chunk export handed to another server.
+// Exports larger than a datagram are split into Parts pieces that share a
+// TransferID.
+type ChunkTransfer struct {
+	ServerID   string `json:"serverId"`
+	ChunkX     int    `json:"chunkX"`
+	ChunkY     int    `json:"chunkY"`
+	TransferID uint64 `json:"transferId"`
+	Part       int    `json:"part"`
+	Parts      int    `json:"parts"`
+	Data       []byte `json:"data"`
+}
+
 func Encode(msg Envelope) ([]byte, error) {
 	return json.Marshal(msg)
 }
@@ -0,0 +1,98 @@
+package network
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Direction marks whether a recorded envelope was sent or received.
+type Direction string
+
+const (
+	DirectionSent     Direction = "sent"
+	DirectionReceived Direction = "received"
+)
+
+// Record is one envelope captured by a Recorder. Peer is the destination of a
+// sent envelope or the source of a received one.
+type Record struct {
+	Time      time.Time `json:"time"`
+	Direction Direction `json:"direction"`
+	Peer      string    `json:"peer"`
+	Envelope  Envelope  `json:"envelope"`
+}
+
+// Recorder writes every envelope passing through a Server to a capture, one
+// JSON record per line, so cross-server exchanges can be inspected or replayed
+// later with cmd/netreplay.
+type Recorder struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	closer io.Closer
+}
+
+// NewRecorder returns a recorder writing to w. Closing it closes w when w is an
+// io.Closer.
+func NewRecorder(w io.Writer) *Recorder {
+	rec := &Recorder{enc: json.NewEncoder(w)}
+	if closer, ok := w.(io.Closer); ok {
+		rec.closer = closer
+	}
+	return rec
+}
+
+// CreateRecorder opens path for appending and returns a recorder writing to it.
+func CreateRecorder(path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open capture: %w", err)
+	}
+	return NewRecorder(file), nil
+}
+
+// Record appends env to the capture.
+func (r *Recorder) Record(direction Direction, peer string, env Envelope) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enc.Encode(Record{
+		Time:      time.Now().UTC(),
+		Direction: direction,
+		Peer:      peer,
+		Envelope:  env,
+	})
+}
+
+// Close closes the underlying writer.
+func (r *Recorder) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// ReadCapture decodes every record in a capture written by a Recorder, in the
+// order they were recorded.
+func ReadCapture(r io.Reader) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("capture line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read capture: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,202 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"chunkserver/internal/logging"
+)
+
+func TestRecorderCapturesSendsInOrder(t *testing.T) {
+	logger := logging.Discard()
+	sender, err := Listen("127.0.0.1:0", logger, 0)
+	if err != nil {
+		t.Fatalf("listen sender: %v", err)
+	}
+	defer sender.Close()
+	peer, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen peer: %v", err)
+	}
+	defer peer.Close()
+
+	path := filepath.Join(t.TempDir(), "session.capture")
+	rec, err := CreateRecorder(path)
+	if err != nil {
+		t.Fatalf("create recorder: %v", err)
+	}
+	sender.SetRecorder(rec)
+
+	messages := []struct {
+		msgType MessageType
+		payload any
+	}{
+		{MessageNeighborHello, Hello{ServerID: "west"}},
+		{MessageTransferAck, TransferAck{EntityID: "unit-1", Accepted: true}},
+		{MessageChunkDelta, nil},
+	}
+	var wire []Envelope
+	buffer := make([]byte, 65536)
+	for _, msg := range messages {
+		if err := sender.Send(peer.LocalAddr().String(), msg.msgType, msg.payload); err != nil {
+			t.Fatalf("send %s: %v", msg.msgType, err)
+		}
+		peer.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := peer.ReadFrom(buffer)
+		if err != nil {
+			t.Fatalf("read %s: %v", msg.msgType, err)
+		}
+		env, err := Decode(buffer[:n])
+		if err != nil {
+			t.Fatalf("decode %s: %v", msg.msgType, err)
+		}
+		wire = append(wire, env)
+	}
+	if previous := sender.SetRecorder(nil); previous != rec {
+		t.Fatalf("expected SetRecorder to hand back the active recorder")
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("close recorder: %v", err)
+	}
+	// Sends after recording stops are not captured.
+	if err := sender.Send(peer.LocalAddr().String(), MessageNeighborAck, nil); err != nil {
+		t.Fatalf("send after stop: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open capture: %v", err)
+	}
+	defer file.Close()
+	records, err := ReadCapture(file)
+	if err != nil {
+		t.Fatalf("read capture: %v", err)
+	}
+	if len(records) != len(wire) {
+		t.Fatalf("captured %d records, want %d", len(records), len(wire))
+	}
+	for i, record := range records {
+		if record.Direction != DirectionSent || record.Peer != peer.LocalAddr().String() {
+			t.Fatalf("record %d: got %s to %s", i, record.Direction, record.Peer)
+		}
+		got, want := record.Envelope, wire[i]
+		if got.Type != want.Type || got.Seq != want.Seq || !got.Timestamp.Equal(want.Timestamp) || !bytes.Equal(got.Payload, want.Payload) {
+			t.Fatalf("record %d = %+v, want %+v", i, got, want)
+		}
+	}
+	var ack TransferAck
+	if err := json.Unmarshal(records[1].Envelope.Payload, &ack); err != nil || ack.EntityID != "unit-1" || !ack.Accepted {
+		t.Fatalf("expected the transfer ack payload back, got %+v (err %v)", ack, err)
+	}
+}
+
+func TestServeRecordsReceivedEnvelopes(t *testing.T) {
+	logger := logging.Discard()
+	receiver, err := Listen("127.0.0.1:0", logger, 0)
+	if err != nil {
+		t.Fatalf("listen receiver: %v", err)
+	}
+	defer receiver.Close()
+	var capture bytes.Buffer
+	receiver.SetRecorder(NewRecorder(&capture))
+	handled := make(chan struct{}, 1)
+	receiver.Register(MessageNeighborHello, func(context.Context, *net.UDPAddr, Envelope) {
+		handled <- struct{}{}
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go receiver.Serve(ctx)
+
+	sender, err := Listen("127.0.0.1:0", logger, 0)
+	if err != nil {
+		t.Fatalf("listen sender: %v", err)
+	}
+	defer sender.Close()
+	if err := sender.Send(receiver.LocalAddr().String(), MessageNeighborHello, Hello{ServerID: "east"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	select {
+	case <-handled:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("hello was not handled")
+	}
+	receiver.SetRecorder(nil)
+
+	records, err := ReadCapture(&capture)
+	if err != nil {
+		t.Fatalf("read capture: %v", err)
+	}
+	if len(records) != 1 || records[0].Direction != DirectionReceived || records[0].Envelope.Type != MessageNeighborHello {
+		t.Fatalf("expected one received hello, got %+v", records)
+	}
+}
+
+// closeTrackingWriter counts writes that finish after it was closed.
+type closeTrackingWriter struct {
+	closed     atomic.Bool
+	lateWrites atomic.Int64
+}
+
+func (w *closeTrackingWriter) Write(p []byte) (int, error) {
+	// Writing takes a moment, leaving a swap room to close the recorder
+	// mid-write.
+	time.Sleep(10 * time.Microsecond)
+	if w.closed.Load() {
+		w.lateWrites.Add(1)
+	}
+	return len(p), nil
+}
+
+func (w *closeTrackingWriter) Close() error {
+	w.closed.Store(true)
+	return nil
+}
+
+func TestSetRecorderWaitsForInFlightRecords(t *testing.T) {
+	srv, err := Listen("127.0.0.1:0", logging.Discard(), 0)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer srv.Close()
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					srv.record(DirectionSent, "peer", Envelope{Type: MessageNeighborHello})
+				}
+			}
+		}()
+	}
+	var writers []*closeTrackingWriter
+	for i := 0; i < 200; i++ {
+		w := &closeTrackingWriter{}
+		writers = append(writers, w)
+		if previous := srv.SetRecorder(NewRecorder(w)); previous != nil {
+			previous.Close()
+		}
+		time.Sleep(50 * time.Microsecond)
+	}
+	close(stop)
+	wg.Wait()
+	for i, w := range writers {
+		if late := w.lateWrites.Load(); late != 0 {
+			t.Fatalf("recorder %d was written %d times after it was closed", i, late)
+		}
+	}
+}
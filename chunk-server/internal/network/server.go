@@ -4,28 +4,54 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"chunkserver/internal/logging"
 )
 
 type Handler func(ctx context.Context, addr *net.UDPAddr, env Envelope)
 
 type Server struct {
 	conn    *net.UDPConn
-	logger  *log.Logger
+	logger  *logging.Logger
 	maxSize int
 	seq     atomic.Uint64
 
 	mu       sync.RWMutex
 	handlers map[MessageType][]Handler
+
+	// recordMu is held for reading while an envelope is recorded, so
+	// SetRecorder can wait out in-flight records before handing back the
+	// recorder it replaced.
+	recordMu sync.RWMutex
+	recorder *Recorder
+
+	received     atomic.Uint64
+	sent         atomic.Uint64
+	decodeErrors atomic.Uint64
+	unhandled    atomic.Uint64
+	sendErrors   atomic.Uint64
 }
 
-func Listen(listenAddr string, logger *log.Logger, maxSize int) (*Server, error) {
+// Stats counts datagrams handled by the server. DecodeErrors and Unhandled are
+// inbound datagrams that were dropped; SendErrors are outbound sends that failed.
+type Stats struct {
+	Received     uint64 `json:"received"`
+	Sent         uint64 `json:"sent"`
+	DecodeErrors uint64 `json:"decodeErrors"`
+	Unhandled    uint64 `json:"unhandled"`
+	SendErrors   uint64 `json:"sendErrors"`
+}
+
+// defaultMaxDatagramSize is the datagram limit used when none is configured.
+const defaultMaxDatagramSize = 64 * 1024
+
+func Listen(listenAddr string, logger *logging.Logger, maxSize int) (*Server, error) {
 	if maxSize <= 0 {
-		maxSize = 64 * 1024
+		maxSize = defaultMaxDatagramSize
 	}
 	addr, err := net.ResolveUDPAddr("udp", listenAddr)
 	if err != nil {
@@ -36,7 +62,7 @@ func Listen(listenAddr string, logger *log.Logger, maxSize int) (*Server, error)
 		return nil, fmt.Errorf("listen udp: %w", err)
 	}
 	if logger == nil {
-		logger = log.New(log.Writer(), "network", log.LstdFlags|log.Lmicroseconds)
+		logger = logging.Default().With("component", "network")
 	}
 	return &Server{
 		conn:     conn,
@@ -46,10 +72,49 @@ func Listen(listenAddr string, logger *log.Logger, maxSize int) (*Server, error)
 	}, nil
 }
 
+// Stats returns a snapshot of the datagram counters.
+func (s *Server) Stats() Stats {
+	return Stats{
+		Received:     s.received.Load(),
+		Sent:         s.sent.Load(),
+		DecodeErrors: s.decodeErrors.Load(),
+		Unhandled:    s.unhandled.Load(),
+		SendErrors:   s.sendErrors.Load(),
+	}
+}
+
+// LocalAddr reports the address the UDP socket is bound to.
+func (s *Server) LocalAddr() net.Addr {
+	return s.conn.LocalAddr()
+}
+
 func (s *Server) Close() error {
 	return s.conn.Close()
 }
 
+// SetRecorder starts capturing every envelope sent and received to rec, or
+// stops capturing when rec is nil. It returns the previous recorder, which the
+// caller is responsible for closing; no envelope is recorded to it once
+// SetRecorder returns.
+func (s *Server) SetRecorder(rec *Recorder) *Recorder {
+	s.recordMu.Lock()
+	defer s.recordMu.Unlock()
+	previous := s.recorder
+	s.recorder = rec
+	return previous
+}
+
+func (s *Server) record(direction Direction, peer string, env Envelope) {
+	s.recordMu.RLock()
+	defer s.recordMu.RUnlock()
+	if s.recorder == nil {
+		return
+	}
+	if err := s.recorder.Record(direction, peer, env); err != nil {
+		s.logger.Warnf("record %s message: %v", env.Type, err)
+	}
+}
+
 func (s *Server) Register(msgType MessageType, handler Handler) {
 	s.mu.Lock()
 	s.handlers[msgType] = append(s.handlers[msgType], handler)
@@ -75,17 +140,21 @@ func (s *Server) Serve(ctx context.Context) error {
 			return err
 		}
 
+		s.received.Add(1)
 		payload := make([]byte, n)
 		copy(payload, buffer[:n])
 
 		env, err := Decode(payload)
 		if err != nil {
-			s.logger.Printf("decode message from %s: %v", addr, err)
+			s.decodeErrors.Add(1)
+			s.logger.Warnf("decode message from %s: %v", addr, err)
 			continue
 		}
+		s.record(DirectionReceived, addr.String(), env)
 
 		handlers := s.handlersFor(env.Type)
 		if len(handlers) == 0 {
+			s.unhandled.Add(1)
 			continue
 		}
 
@@ -105,20 +174,27 @@ func (s *Server) handlersFor(msgType MessageType) []Handler {
 func (s *Server) Send(addr string, msg MessageType, payload any) error {
 	target, err := net.ResolveUDPAddr("udp", addr)
 	if err != nil {
+		s.sendErrors.Add(1)
 		return err
 	}
-	data, err := s.prepare(msg, payload)
+	env, data, err := s.prepare(msg, payload)
 	if err != nil {
+		s.sendErrors.Add(1)
+		return err
+	}
+	if _, err := s.conn.WriteToUDP(data, target); err != nil {
+		s.sendErrors.Add(1)
 		return err
 	}
-	_, err = s.conn.WriteToUDP(data, target)
-	return err
+	s.sent.Add(1)
+	s.record(DirectionSent, target.String(), env)
+	return nil
 }
 
-func (s *Server) prepare(msgType MessageType, payload any) ([]byte, error) {
+func (s *Server) prepare(msgType MessageType, payload any) (Envelope, []byte, error) {
 	raw, err := encodePayload(payload)
 	if err != nil {
-		return nil, err
+		return Envelope{}, nil, err
 	}
 	env := Envelope{
 		Type:      msgType,
@@ -126,7 +202,8 @@ func (s *Server) prepare(msgType MessageType, payload any) ([]byte, error) {
 		Seq:       s.seq.Add(1),
 		Payload:   raw,
 	}
-	return Encode(env)
+	data, err := Encode(env)
+	return env, data, err
 }
 
 func encodePayload(payload any) ([]byte, error) {
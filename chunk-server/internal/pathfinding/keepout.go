@@ -0,0 +1,98 @@
+package pathfinding
+
+import "chunkserver/internal/world"
+
+// KeepOutZone is an inclusive box of blocks a unit should stay out of even
+// though it could physically pass through them.
+type KeepOutZone struct {
+	Min world.BlockCoord
+	Max world.BlockCoord
+	// Penalty is the extra cost, in blocks, of entering a block in the zone.
+	// Zero or less makes the zone impassable.
+	Penalty float64
+}
+
+// Contains reports whether coord lies inside the zone.
+func (z KeepOutZone) Contains(coord world.BlockCoord) bool {
+	return coord.X >= z.Min.X && coord.X <= z.Max.X &&
+		coord.Y >= z.Min.Y && coord.Y <= z.Max.Y &&
+		coord.Z >= z.Min.Z && coord.Z <= z.Max.Z
+}
+
+// KeepOut is a set of zones consulted during a route search. A nil *KeepOut
+// avoids nothing.
+type KeepOut struct {
+	zones []KeepOutZone
+}
+
+// NewKeepOut returns a keep-out set holding zones, with each zone's corners
+// normalised so Min is the lower corner.
+func NewKeepOut(zones ...KeepOutZone) *KeepOut {
+	k := &KeepOut{zones: make([]KeepOutZone, 0, len(zones))}
+	for _, zone := range zones {
+		zone.Min, zone.Max = world.BlockCoord{
+			X: min(zone.Min.X, zone.Max.X),
+			Y: min(zone.Min.Y, zone.Max.Y),
+			Z: min(zone.Min.Z, zone.Max.Z),
+		}, world.BlockCoord{
+			X: max(zone.Min.X, zone.Max.X),
+			Y: max(zone.Min.Y, zone.Max.Y),
+			Z: max(zone.Min.Z, zone.Max.Z),
+		}
+		k.zones = append(k.zones, zone)
+	}
+	return k
+}
+
+// Zones returns a copy of the zones in the set.
+func (k *KeepOut) Zones() []KeepOutZone {
+	if k == nil {
+		return nil
+	}
+	return append([]KeepOutZone(nil), k.zones...)
+}
+
+// Blocks reports whether coord lies in an impassable zone.
+func (k *KeepOut) Blocks(coord world.BlockCoord) bool {
+	if k == nil {
+		return false
+	}
+	for _, zone := range k.zones {
+		if zone.Penalty <= 0 && zone.Contains(coord) {
+			return true
+		}
+	}
+	return false
+}
+
+// Penalty returns the extra cost of entering coord: the sum of the penalties
+// of every soft zone containing it.
+func (k *KeepOut) Penalty(coord world.BlockCoord) float64 {
+	if k == nil {
+		return 0
+	}
+	total := 0.0
+	for _, zone := range k.zones {
+		if zone.Penalty > 0 && zone.Contains(coord) {
+			total += zone.Penalty
+		}
+	}
+	return total
+}
+
+// excluding returns the set without the zones that contain coord.
+func (k *KeepOut) excluding(coord world.BlockCoord) *KeepOut {
+	if k == nil {
+		return nil
+	}
+	kept := k.zones[:0:0]
+	for _, zone := range k.zones {
+		if !zone.Contains(coord) {
+			kept = append(kept, zone)
+		}
+	}
+	if len(kept) == len(k.zones) {
+		return k
+	}
+	return &KeepOut{zones: kept}
+}
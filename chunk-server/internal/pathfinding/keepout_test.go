@@ -0,0 +1,95 @@
+package pathfinding
+
+import (
+	"context"
+	"testing"
+
+	"chunkserver/internal/world"
+)
+
+func routeEnters(route []world.BlockCoord, zone KeepOutZone) bool {
+	for _, step := range route {
+		if zone.Contains(step) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestBlockNavigatorKeepOutBoxForcesDetour(t *testing.T) {
+	dims := world.Dimensions{Width: 10, Depth: 10, Height: 4}
+	navigator, chunk := newTestNavigator(t, dims)
+	addFloor(chunk, 0)
+
+	start := world.BlockCoord{X: 1, Y: 5, Z: 1}
+	goal := world.BlockCoord{X: 8, Y: 5, Z: 1}
+	direct := navigator.FindRoute(context.Background(), start, goal, DefaultProfile(ModeGround))
+
+	zone := KeepOutZone{Min: world.BlockCoord{X: 6, Y: 7, Z: 3}, Max: world.BlockCoord{X: 3, Y: 3, Z: 0}}
+	profile := DefaultProfile(ModeGround)
+	profile.Avoid = NewKeepOut(zone)
+	normalised := profile.Avoid.Zones()[0]
+	route := navigator.FindRoute(context.Background(), start, goal, profile)
+	if len(route) == 0 {
+		t.Fatalf("expected a route around the keep-out box")
+	}
+	if routeEnters(route, normalised) {
+		t.Fatalf("route %v enters keep-out box %+v", route, normalised)
+	}
+	if len(route) <= len(direct) {
+		t.Fatalf("expected a detour longer than the direct %d blocks, got %d", len(direct), len(route))
+	}
+
+	// A unit already inside the box may leave it, but may not be sent into it.
+	inside := world.BlockCoord{X: 4, Y: 5, Z: 1}
+	if route := navigator.FindRoute(context.Background(), inside, goal, profile); len(route) == 0 {
+		t.Fatalf("expected a unit inside the box to route out of it")
+	}
+	if _, stats := navigator.FindRouteWithStats(context.Background(), start, inside, profile); stats.Status != RouteBlockedEndpoint {
+		t.Fatalf("routing into the box reported %q, want %q", stats.Status, RouteBlockedEndpoint)
+	}
+}
+
+func TestBlockNavigatorSoftKeepOutOnlyCrossedWhenCheaper(t *testing.T) {
+	// A three-block-wide strip: the straight route runs along y=0 and the
+	// clear detour along y=2 costs four extra steps.
+	dims := world.Dimensions{Width: 10, Depth: 3, Height: 4}
+	navigator, chunk := newTestNavigator(t, dims)
+	addFloor(chunk, 0)
+
+	start := world.BlockCoord{X: 1, Y: 0, Z: 1}
+	goal := world.BlockCoord{X: 8, Y: 0, Z: 1}
+	partial := KeepOutZone{Min: world.BlockCoord{X: 4, Y: 0, Z: 0}, Max: world.BlockCoord{X: 5, Y: 1, Z: 3}}
+	full := KeepOutZone{Min: world.BlockCoord{X: 4, Y: 0, Z: 0}, Max: world.BlockCoord{X: 5, Y: 2, Z: 3}}
+
+	cases := []struct {
+		name    string
+		zone    KeepOutZone
+		penalty float64
+		enters  bool
+	}{
+		{name: "penalty above the detour cost", zone: partial, penalty: 5, enters: false},
+		{name: "penalty below the detour cost", zone: partial, penalty: 0.5, enters: true},
+		{name: "no clear route", zone: full, penalty: 5, enters: true},
+	}
+	for _, tc := range cases {
+		zone := tc.zone
+		zone.Penalty = tc.penalty
+		profile := DefaultProfile(ModeGround)
+		profile.Avoid = NewKeepOut(zone)
+		route := navigator.FindRoute(context.Background(), start, goal, profile)
+		if len(route) == 0 {
+			t.Fatalf("%s: expected a route", tc.name)
+		}
+		if got := routeEnters(route, zone); got != tc.enters {
+			t.Fatalf("%s: route enters zone = %t, want %t: %v", tc.name, got, tc.enters, route)
+		}
+	}
+
+	hard := full
+	profile := DefaultProfile(ModeGround)
+	profile.Avoid = NewKeepOut(hard)
+	if _, stats := navigator.FindRouteWithStats(context.Background(), start, goal, profile); stats.Status != RouteNoPath {
+		t.Fatalf("hard zone across the strip reported %q, want %q", stats.Status, RouteNoPath)
+	}
+}
@@ -0,0 +1,184 @@
+package pathfinding
+
+import (
+	"math"
+	"math/bits"
+	"sync"
+	"time"
+)
+
+// histogramSubBuckets is how many buckets split each power of two. Values
+// below it are counted exactly; larger ones land in buckets about 1/16 of
+// their magnitude wide.
+const histogramSubBuckets = 16
+
+// histogramBuckets covers every non-negative int64.
+const histogramBuckets = histogramSubBuckets + (64-5)*histogramSubBuckets
+
+// Histogram counts non-negative samples in buckets whose width grows with the
+// value, so it answers percentile queries to within about 6% over any range in
+// fixed memory. The zero value is empty and ready to use. It is not safe for
+// concurrent use.
+type Histogram struct {
+	counts [histogramBuckets]uint64
+	total  uint64
+	sum    float64
+	min    int64
+	max    int64
+}
+
+// Record adds one sample. Negative samples count as zero.
+func (h *Histogram) Record(value int64) {
+	if value < 0 {
+		value = 0
+	}
+	h.counts[histogramIndex(value)]++
+	if h.total == 0 || value < h.min {
+		h.min = value
+	}
+	if value > h.max {
+		h.max = value
+	}
+	h.total++
+	h.sum += float64(value)
+}
+
+// Count returns how many samples were recorded.
+func (h *Histogram) Count() uint64 {
+	return h.total
+}
+
+// Mean returns the exact average of the samples, or zero when empty.
+func (h *Histogram) Mean() int64 {
+	if h.total == 0 {
+		return 0
+	}
+	return int64(h.sum / float64(h.total))
+}
+
+// Max returns the largest sample, or zero when empty.
+func (h *Histogram) Max() int64 {
+	return h.max
+}
+
+// Percentile returns the smallest bucket bound that at least p percent of the
+// samples fall at or below, clamped to the recorded minimum and maximum. It
+// returns zero when the histogram is empty.
+func (h *Histogram) Percentile(p float64) int64 {
+	if h.total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(p / 100 * float64(h.total)))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > h.total {
+		rank = h.total
+	}
+	var seen uint64
+	for i, count := range h.counts {
+		seen += count
+		if seen >= rank {
+			return min(max(histogramUpperBound(i), h.min), h.max)
+		}
+	}
+	return h.max
+}
+
+func histogramIndex(value int64) int {
+	v := uint64(value)
+	if v < histogramSubBuckets {
+		return int(v)
+	}
+	shift := bits.Len64(v) - 5
+	return histogramSubBuckets + shift*histogramSubBuckets + int(v>>shift) - histogramSubBuckets
+}
+
+func histogramUpperBound(index int) int64 {
+	if index < histogramSubBuckets {
+		return int64(index)
+	}
+	shift := (index - histogramSubBuckets) / histogramSubBuckets
+	sub := uint64((index-histogramSubBuckets)%histogramSubBuckets + histogramSubBuckets)
+	upper := (sub+1)<<shift - 1
+	if upper > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(upper)
+}
+
+// Percentiles summarises a distribution of counts.
+type Percentiles struct {
+	Mean int64
+	P50  int64
+	P95  int64
+	P99  int64
+	Max  int64
+}
+
+// DurationPercentiles summarises a distribution of durations.
+type DurationPercentiles struct {
+	Mean time.Duration
+	P50  time.Duration
+	P95  time.Duration
+	P99  time.Duration
+	Max  time.Duration
+}
+
+// RequestSnapshot is the per-request distribution of search work.
+type RequestSnapshot struct {
+	Requests int
+	ByStatus map[RouteStatus]int
+	Expanded Percentiles
+	Latency  DurationPercentiles
+}
+
+// RequestStats aggregates the stats of many route searches into node count
+// and latency distributions. It is safe for concurrent use; the zero value is
+// ready to use.
+type RequestStats struct {
+	mu       sync.Mutex
+	expanded Histogram
+	latency  Histogram
+	byStatus map[RouteStatus]int
+}
+
+// Record adds the outcome of one search.
+func (r *RequestStats) Record(stats SearchStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expanded.Record(int64(stats.Expanded))
+	r.latency.Record(int64(stats.Elapsed))
+	if r.byStatus == nil {
+		r.byStatus = make(map[RouteStatus]int)
+	}
+	r.byStatus[stats.Status]++
+}
+
+// Snapshot returns the distributions recorded so far.
+func (r *RequestStats) Snapshot() RequestSnapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snapshot := RequestSnapshot{
+		Requests: int(r.expanded.Count()),
+		ByStatus: make(map[RouteStatus]int, len(r.byStatus)),
+		Expanded: Percentiles{
+			Mean: r.expanded.Mean(),
+			P50:  r.expanded.Percentile(50),
+			P95:  r.expanded.Percentile(95),
+			P99:  r.expanded.Percentile(99),
+			Max:  r.expanded.Max(),
+		},
+		Latency: DurationPercentiles{
+			Mean: time.Duration(r.latency.Mean()),
+			P50:  time.Duration(r.latency.Percentile(50)),
+			P95:  time.Duration(r.latency.Percentile(95)),
+			P99:  time.Duration(r.latency.Percentile(99)),
+			Max:  time.Duration(r.latency.Max()),
+		},
+	}
+	for status, n := range r.byStatus {
+		snapshot.ByStatus[status] = n
+	}
+	return snapshot
+}
@@ -0,0 +1,109 @@
+package pathfinding
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistogramPercentilesOfSmallValuesAreExact(t *testing.T) {
+	var h Histogram
+	for _, v := range []int64{13, 1, 8, 2, 5, 1, 3} {
+		h.Record(v)
+	}
+	cases := []struct {
+		p    float64
+		want int64
+	}{
+		{p: 0, want: 1},
+		{p: 25, want: 1},
+		{p: 50, want: 3},
+		{p: 75, want: 8},
+		{p: 99, want: 13},
+		{p: 100, want: 13},
+	}
+	for _, tc := range cases {
+		if got := h.Percentile(tc.p); got != tc.want {
+			t.Errorf("Percentile(%v) = %d, want %d", tc.p, got, tc.want)
+		}
+	}
+	if h.Count() != 7 || h.Max() != 13 {
+		t.Fatalf("Count() = %d, Max() = %d, want 7 and 13", h.Count(), h.Max())
+	}
+
+	var empty Histogram
+	if got := empty.Percentile(50); got != 0 {
+		t.Fatalf("empty Percentile(50) = %d, want 0", got)
+	}
+}
+
+func TestHistogramBucketsEveryValueItsOwnWidth(t *testing.T) {
+	for _, v := range []int64{0, 15, 16, 17, 31, 32, 33, 1000, 123456789, 1<<62 + 12345} {
+		index := histogramIndex(v)
+		upper := histogramUpperBound(index)
+		if upper < v || float64(upper-v) > float64(v)/histogramSubBuckets {
+			t.Errorf("value %d lands in bucket %d with upper bound %d", v, index, upper)
+		}
+		if index > 0 && histogramUpperBound(index-1) >= v {
+			t.Errorf("value %d also fits the previous bucket bound %d", v, histogramUpperBound(index-1))
+		}
+	}
+}
+
+func TestRequestStatsReportsLatencyAndNodePercentiles(t *testing.T) {
+	var stats RequestStats
+	for i := 1; i <= 100; i++ {
+		status := RouteFound
+		if i%10 == 0 {
+			status = RouteNoPath
+		}
+		stats.Record(SearchStats{Status: status, Expanded: i * 10, Elapsed: time.Duration(i) * time.Millisecond})
+	}
+
+	snapshot := stats.Snapshot()
+	if snapshot.Requests != 100 {
+		t.Fatalf("Requests = %d, want 100", snapshot.Requests)
+	}
+	if snapshot.ByStatus[RouteFound] != 90 || snapshot.ByStatus[RouteNoPath] != 10 {
+		t.Fatalf("ByStatus = %v, want 90 found and 10 no_path", snapshot.ByStatus)
+	}
+
+	within := func(got, want int64) bool {
+		return got >= want && float64(got-want) <= float64(want)/histogramSubBuckets
+	}
+	latency := []struct {
+		name      string
+		got, want time.Duration
+	}{
+		{"p50", snapshot.Latency.P50, 50 * time.Millisecond},
+		{"p95", snapshot.Latency.P95, 95 * time.Millisecond},
+		{"p99", snapshot.Latency.P99, 99 * time.Millisecond},
+	}
+	for _, tc := range latency {
+		if !within(int64(tc.got), int64(tc.want)) {
+			t.Errorf("latency %s = %s, want %s within bucket precision", tc.name, tc.got, tc.want)
+		}
+	}
+	if snapshot.Latency.Mean != 50500*time.Microsecond || snapshot.Expanded.Mean != 505 {
+		t.Errorf("means = %s and %d, want 50.5ms and 505", snapshot.Latency.Mean, snapshot.Expanded.Mean)
+	}
+	if snapshot.Latency.Max != 100*time.Millisecond {
+		t.Errorf("latency max = %s, want 100ms", snapshot.Latency.Max)
+	}
+
+	nodes := []struct {
+		name      string
+		got, want int64
+	}{
+		{"p50", snapshot.Expanded.P50, 500},
+		{"p95", snapshot.Expanded.P95, 950},
+		{"p99", snapshot.Expanded.P99, 990},
+	}
+	for _, tc := range nodes {
+		if !within(tc.got, tc.want) {
+			t.Errorf("expanded %s = %d, want %d within bucket precision", tc.name, tc.got, tc.want)
+		}
+	}
+	if snapshot.Expanded.Max != 1000 {
+		t.Errorf("expanded max = %d, want 1000", snapshot.Expanded.Max)
+	}
+}
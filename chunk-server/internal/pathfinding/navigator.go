@@ -1,9 +1,10 @@
 package pathfinding
 
 import (
-	"container/heap"
 	"context"
+	"math"
 	"strings"
+	"sync"
 	"time"
 
 	"chunkserver/internal/world"
@@ -24,18 +25,98 @@ type UnitProfile struct {
 	MaxClimb  int
 	MaxDrop   int
 	CanDig    bool
+	// TunnelWidth is how many blocks wide, along X and Y, the passage
+	// around each step of the route must be. Every block of that cross-section
+	// up to Clearance must be open, or diggable for units that dig. Zero and
+	// one mean a single column.
+	TunnelWidth int
+	// AllowDiagonal lets the unit step diagonally across the X/Y plane at a
+	// cost of √2 blocks, provided both blocks it cuts between are open.
+	AllowDiagonal bool
+	// Heuristic picks the distance estimate; HeuristicAuto suits the profile's
+	// movement.
+	Heuristic Heuristic
+	// Avoid lists the zones the unit keeps out of or pays extra to cross.
+	// Zones holding the start are ignored so a unit inside one can leave it.
+	Avoid *KeepOut
+	// WallPenalty is the extra cost of stepping into a cell for each of the
+	// four sides of the unit's footprint, along X and Y, with a column beside
+	// it that is blocked anywhere within the unit's clearance. It steers
+	// routes away from walls and through open space. Zero lets units hug
+	// walls.
+	WallPenalty float64
+}
+
+// Heuristic selects the distance estimate that guides a route search.
+type Heuristic int
+
+const (
+	// HeuristicAuto uses octile distance for diagonal movement and Manhattan
+	// distance otherwise.
+	HeuristicAuto Heuristic = iota
+	// HeuristicManhattan sums the axis distances. It overestimates once
+	// diagonal steps are allowed.
+	HeuristicManhattan
+	// HeuristicOctile takes √2 per diagonal step across X/Y plus the
+	// remaining straight distance.
+	HeuristicOctile
+	// HeuristicEuclidean is the straight-line distance, a looser estimate that
+	// never overestimates, for units that move freely in all three axes.
+	HeuristicEuclidean
+)
+
+// HeuristicFromString parses a heuristic name. Empty or unknown names select
+// HeuristicAuto.
+func HeuristicFromString(value string) Heuristic {
+	switch strings.ToLower(value) {
+	case "manhattan":
+		return HeuristicManhattan
+	case "octile":
+		return HeuristicOctile
+	case "euclidean":
+		return HeuristicEuclidean
+	default:
+		return HeuristicAuto
+	}
+}
+
+// SearchOptions bounds the work a single FindRoute call may perform.
+type SearchOptions struct {
+	// MaxNodes caps the number of node expansions per search. Zero disables the cap.
+	MaxNodes int
+	// HeuristicScale weights the distance estimate in the A* priority. A value of
+	// 1 (or zero) keeps the search optimal; values above 1 make the search greedier,
+	// expanding fewer nodes at the cost of possibly longer routes.
+	HeuristicScale float64
 }
 
 // BlockNavigator performs A* search over individual world blocks.
 type BlockNavigator struct {
 	region world.ServerRegion
 	world  *world.Manager
+
+	optsMu sync.RWMutex
+	opts   SearchOptions
 }
 
 func NewBlockNavigator(region world.ServerRegion, world *world.Manager) *BlockNavigator {
 	return &BlockNavigator{region: region, world: world}
 }
 
+// SetOptions replaces the search limits applied to subsequent FindRoute calls.
+func (n *BlockNavigator) SetOptions(opts SearchOptions) {
+	n.optsMu.Lock()
+	n.opts = opts
+	n.optsMu.Unlock()
+}
+
+// Options returns the search limits currently applied to FindRoute.
+func (n *BlockNavigator) Options() SearchOptions {
+	n.optsMu.RLock()
+	defer n.optsMu.RUnlock()
+	return n.opts
+}
+
 // DefaultProfile returns traversal defaults for the given unit mode.
 func DefaultProfile(mode Mode) UnitProfile {
 	switch mode {
@@ -62,72 +143,47 @@ func ModeFromString(value string) Mode {
 	}
 }
 
-// FindRoute locates a block-level path subject to unit traversal constraints.
-func (n *BlockNavigator) FindRoute(ctx context.Context, start, goal world.BlockCoord, profile UnitProfile) []world.BlockCoord {
-	profiler := profilerFromContext(ctx)
-	if start == goal {
-		return []world.BlockCoord{start}
-	}
-	if n.world == nil {
-		return nil
-	}
-	if _, ok := n.region.LocateBlock(start); !ok {
-		return nil
-	}
-	if _, ok := n.region.LocateBlock(goal); !ok {
-		return nil
-	}
-
-	chunkCache := make(map[world.ChunkCoord]*world.Chunk)
-	if !n.passable(ctx, chunkCache, start, profile) {
-		return nil
-	}
-	if !n.passable(ctx, chunkCache, goal, profile) {
-		return nil
-	}
-
-	open := &blockQueue{}
-	heap.Init(open)
-	heap.Push(open, &blockPath{coord: start, priority: 0})
+// RouteStatus classifies the outcome of a route search.
+type RouteStatus string
 
-	cameFrom := map[world.BlockCoord]world.BlockCoord{}
-	gScore := map[world.BlockCoord]int{start: 0}
-
-	for open.Len() > 0 {
-		select {
-		case <-ctx.Done():
-			return nil
-		default:
-		}
+const (
+	// RouteFound means a route was returned.
+	RouteFound RouteStatus = "ok"
+	// RouteOutOfRegion means the start or goal lies outside this server's region.
+	RouteOutOfRegion RouteStatus = "out_of_region"
+	// RouteBlockedEndpoint means the unit cannot stand at the start or goal.
+	RouteBlockedEndpoint RouteStatus = "blocked_endpoint"
+	// RouteNodeLimit means the search gave up after SearchOptions.MaxNodes expansions.
+	RouteNodeLimit RouteStatus = "node_limit"
+	// RouteTimeout means the caller's context expired or was cancelled mid-search.
+	RouteTimeout RouteStatus = "timeout"
+	// RouteNoPath means the search exhausted every reachable block.
+	RouteNoPath RouteStatus = "no_path"
+	// RouteUnavailable means the navigator has no world to search.
+	RouteUnavailable RouteStatus = "unavailable"
+)
 
-		current := heap.Pop(open).(*blockPath)
-		if profiler != nil {
-			profiler.RecordNodeExpanded()
-		}
-		if current.coord == goal {
-			return reconstructBlocks(cameFrom, current.coord)
-		}
+// SearchStats describes the work done by one route search.
+type SearchStats struct {
+	Status   RouteStatus
+	Expanded int
+	Elapsed  time.Duration
+	// Dig is the dig manifest of a found route: the blocks a digging unit
+	// clears on the way, as DigManifest lists them.
+	Dig []DigTarget
+}
 
-		neighbors := n.neighbors(ctx, chunkCache, current.coord, profile)
-		if profiler != nil {
-			profiler.RecordNeighborGeneration(len(neighbors))
-		}
-		for _, neighbor := range neighbors {
-			tentative := gScore[current.coord] + 1
-			if score, ok := gScore[neighbor]; ok && tentative >= score {
-				continue
-			}
-			cameFrom[neighbor] = current.coord
-			gScore[neighbor] = tentative
-			if profiler != nil {
-				profiler.RecordHeuristicEvaluation()
-			}
-			priority := tentative + heuristicBlocks(neighbor, goal)
-			heap.Push(open, &blockPath{coord: neighbor, priority: priority})
-		}
-	}
+// FindRoute locates a block-level path subject to unit traversal constraints.
+func (n *BlockNavigator) FindRoute(ctx context.Context, start, goal world.BlockCoord, profile UnitProfile) []world.BlockCoord {
+	route, _ := n.FindRouteWithStats(ctx, start, goal, profile)
+	return route
+}
 
-	return nil
+// FindRouteWithStats is FindRoute that also reports why a search failed and
+// how much work it did.
+func (n *BlockNavigator) FindRouteWithStats(ctx context.Context, start, goal world.BlockCoord, profile UnitProfile) ([]world.BlockCoord, SearchStats) {
+	route, stats, _ := n.NewSearchSession(ctx, start, goal, profile).Step(0)
+	return route, stats
 }
 
 func (n *BlockNavigator) neighbors(ctx context.Context, cache map[world.ChunkCoord]*world.Chunk, coord world.BlockCoord, profile UnitProfile) []world.BlockCoord {
@@ -142,12 +198,20 @@ func (n *BlockNavigator) neighbors(ctx context.Context, cache map[world.ChunkCoo
 }
 
 func (n *BlockNavigator) groundNeighbors(ctx context.Context, cache map[world.ChunkCoord]*world.Chunk, coord world.BlockCoord, profile UnitProfile) []world.BlockCoord {
-	offsets := [...]struct{ dx, dy int }{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+	offsets := []struct{ dx, dy int }{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+	if profile.AllowDiagonal {
+		for _, diagonal := range diagonalOffsets {
+			offsets = append(offsets, struct{ dx, dy int }{diagonal.dx, diagonal.dy})
+		}
+	}
 	maxDelta := profile.MaxClimb
 	if profile.MaxDrop > maxDelta {
 		maxDelta = profile.MaxDrop
 	}
+	// Neighbors are listed in offset order, not map order, so equal-cost
+	// routes break ties the same way on every search.
 	seen := make(map[world.BlockCoord]struct{})
+	var neighbors []world.BlockCoord
 	for _, offset := range offsets {
 		targetX := coord.X + offset.dx
 		targetY := coord.Y + offset.dy
@@ -175,21 +239,24 @@ func (n *BlockNavigator) groundNeighbors(ctx context.Context, cache map[world.Ch
 				if dz > profile.MaxClimb || dz < -profile.MaxDrop {
 					continue
 				}
+				if !n.cornerOpen(ctx, cache, coord, candidate, profile) {
+					continue
+				}
 				seen[candidate] = struct{}{}
+				neighbors = append(neighbors, candidate)
 			}
 		}
 	}
-	neighbors := make([]world.BlockCoord, 0, len(seen))
-	for candidate := range seen {
-		neighbors = append(neighbors, candidate)
-	}
 	return neighbors
 }
 
 func (n *BlockNavigator) flyingNeighbors(ctx context.Context, cache map[world.ChunkCoord]*world.Chunk, coord world.BlockCoord, profile UnitProfile) []world.BlockCoord {
-	offsets := [...]struct{ dx, dy, dz int }{
+	offsets := []struct{ dx, dy, dz int }{
 		{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {0, 0, 1}, {0, 0, -1},
 	}
+	if profile.AllowDiagonal {
+		offsets = append(offsets, diagonalOffsets...)
+	}
 	var neighbors []world.BlockCoord
 	for _, offset := range offsets {
 		candidate := world.BlockCoord{X: coord.X + offset.dx, Y: coord.Y + offset.dy, Z: coord.Z + offset.dz}
@@ -200,6 +267,9 @@ func (n *BlockNavigator) flyingNeighbors(ctx context.Context, cache map[world.Ch
 		if !n.passable(ctx, cache, candidate, profile) {
 			continue
 		}
+		if !n.cornerOpen(ctx, cache, coord, candidate, profile) {
+			continue
+		}
 		neighbors = append(neighbors, candidate)
 	}
 	return neighbors
@@ -211,48 +281,175 @@ func (n *BlockNavigator) undergroundNeighbors(ctx context.Context, cache map[wor
 }
 
 func (n *BlockNavigator) passable(ctx context.Context, cache map[world.ChunkCoord]*world.Chunk, coord world.BlockCoord, profile UnitProfile) bool {
-	dims := n.region.ChunkDimension
-	if coord.Z < 0 || coord.Z >= dims.Height {
-		return false
-	}
-	if _, ok := n.region.LocateBlock(coord); !ok {
+	if !n.open(ctx, cache, coord, profile) {
 		return false
 	}
 
-	for i := 0; i < profile.Clearance; i++ {
-		test := world.BlockCoord{X: coord.X, Y: coord.Y, Z: coord.Z + i}
-		if test.Z >= dims.Height {
+	switch profile.Mode {
+	case ModeGround:
+		if coord.Z == 0 {
 			return false
 		}
-		block, ok := n.blockAt(ctx, cache, test)
+		below := world.BlockCoord{X: coord.X, Y: coord.Y, Z: coord.Z - 1}
+		block, ok := n.blockAt(ctx, cache, below)
 		if !ok {
 			return false
 		}
-		if block.Type != world.BlockAir {
-			if profile.CanDig && block.Type != world.BlockSolid {
-				continue
+		return block.Type.Properties().SupportsLoad
+	default:
+		return true
+	}
+}
+
+// open reports whether the unit fits at coord: every block of its clearance
+// across the tunnel width is passable, or diggable for units that can dig.
+// Support is not checked.
+func (n *BlockNavigator) open(ctx context.Context, cache map[world.ChunkCoord]*world.Chunk, coord world.BlockCoord, profile UnitProfile) bool {
+	dims := n.region.ChunkDimension
+	if coord.Z < 0 || coord.Z >= dims.Height {
+		return false
+	}
+	if _, ok := n.region.LocateBlock(coord); !ok {
+		return false
+	}
+
+	lo, hi := profile.footprint()
+	for dy := lo; dy <= hi; dy++ {
+		for dx := lo; dx <= hi; dx++ {
+			for i := 0; i < profile.Clearance; i++ {
+				test := world.BlockCoord{X: coord.X + dx, Y: coord.Y + dy, Z: coord.Z + i}
+				if test.Z >= dims.Height {
+					return false
+				}
+				block, ok := n.blockAt(ctx, cache, test)
+				if !ok {
+					return false
+				}
+				props := block.Type.Properties()
+				if !props.Passable {
+					if profile.CanDig && props.Diggable {
+						continue
+					}
+					return false
+				}
 			}
-			return false
 		}
 	}
+	return true
+}
 
-	switch profile.Mode {
-	case ModeGround:
-		if coord.Z == 0 {
-			return false
+// wallPenalty returns the extra cost of occupying coord: the profile's
+// WallPenalty for each side of the unit's footprint, along X and Y, where a
+// column just outside it holds a block the unit cannot pass through within
+// its clearance. Every column across the tunnel width is checked on each side.
+// Blocks that fail to load do not count as walls.
+func (n *BlockNavigator) wallPenalty(ctx context.Context, cache map[world.ChunkCoord]*world.Chunk, coord world.BlockCoord, profile UnitProfile) float64 {
+	if profile.WallPenalty <= 0 {
+		return 0
+	}
+	lo, hi := profile.footprint()
+	walls := 0
+	for _, side := range [4]struct {
+		offset int
+		alongY bool
+	}{{lo - 1, false}, {hi + 1, false}, {lo - 1, true}, {hi + 1, true}} {
+		if n.sideBlocked(ctx, cache, coord, profile, side.offset, side.alongY, lo, hi) {
+			walls++
 		}
-		below := world.BlockCoord{X: coord.X, Y: coord.Y, Z: coord.Z - 1}
-		block, ok := n.blockAt(ctx, cache, below)
-		if !ok {
-			return false
+	}
+	return float64(walls) * profile.WallPenalty
+}
+
+// sideBlocked reports whether any column beside the footprint, offset from
+// coord along X (or along Y when alongY is set) and spanning lo..hi across
+// it, holds an impassable block within the unit's clearance.
+func (n *BlockNavigator) sideBlocked(ctx context.Context, cache map[world.ChunkCoord]*world.Chunk, coord world.BlockCoord, profile UnitProfile, offset int, alongY bool, lo, hi int) bool {
+	for across := lo; across <= hi; across++ {
+		column := world.BlockCoord{X: coord.X + offset, Y: coord.Y + across, Z: coord.Z}
+		if alongY {
+			column = world.BlockCoord{X: coord.X + across, Y: coord.Y + offset, Z: coord.Z}
 		}
-		if block.Type == world.BlockAir {
-			return false
+		for i := 0; i < max(profile.Clearance, 1); i++ {
+			column.Z = coord.Z + i
+			block, ok := n.blockAt(ctx, cache, column)
+			if ok && !block.Type.Properties().Passable {
+				return true
+			}
 		}
-		return true
-	default:
+	}
+	return false
+}
+
+// footprint returns the offsets, from the route cell, of the first and last
+// column of the tunnel cross-section along each of X and Y.
+func (p UnitProfile) footprint() (lo, hi int) {
+	width := max(p.TunnelWidth, 1)
+	lo = -(width - 1) / 2
+	return lo, lo + width - 1
+}
+
+// DigTarget is a block a digging unit must clear to follow its route.
+type DigTarget struct {
+	Coord world.BlockCoord
+	Type  world.BlockType
+	// Yield is what mining the block produces, keyed by resource.
+	Yield map[string]float64
+}
+
+// DigManifest lists the blocks a digging unit would clear to follow route:
+// every block in the tunnel cross-section of each step, up to the unit's
+// clearance, that is not already passable. Blocks appear once, in the order
+// the route first reaches them. Units that cannot dig clear nothing.
+func (n *BlockNavigator) DigManifest(ctx context.Context, route []world.BlockCoord, profile UnitProfile) []DigTarget {
+	return n.digTargets(ctx, make(map[world.ChunkCoord]*world.Chunk), route, profile)
+}
+
+func (n *BlockNavigator) digTargets(ctx context.Context, cache map[world.ChunkCoord]*world.Chunk, route []world.BlockCoord, profile UnitProfile) []DigTarget {
+	if !profile.CanDig || n.world == nil {
+		return nil
+	}
+	seen := make(map[world.BlockCoord]struct{})
+	var dig []DigTarget
+	lo, hi := profile.footprint()
+	for _, step := range route {
+		for dy := lo; dy <= hi; dy++ {
+			for dx := lo; dx <= hi; dx++ {
+				for i := 0; i < profile.Clearance; i++ {
+					coord := world.BlockCoord{X: step.X + dx, Y: step.Y + dy, Z: step.Z + i}
+					if _, ok := seen[coord]; ok {
+						continue
+					}
+					seen[coord] = struct{}{}
+					block, ok := n.blockAt(ctx, cache, coord)
+					if !ok || block.Type.Properties().Passable {
+						continue
+					}
+					target := DigTarget{Coord: coord, Type: block.Type}
+					if len(block.ResourceYield) > 0 {
+						target.Yield = make(map[string]float64, len(block.ResourceYield))
+						for resource, amount := range block.ResourceYield {
+							target.Yield[resource] = amount
+						}
+					}
+					dig = append(dig, target)
+				}
+			}
+		}
+	}
+	return dig
+}
+
+// cornerOpen reports whether a step from one block to another may be taken
+// without cutting a corner. Straight steps always may; diagonal steps need the
+// unit to fit in both blocks beside the diagonal, at the higher of the two
+// heights.
+func (n *BlockNavigator) cornerOpen(ctx context.Context, cache map[world.ChunkCoord]*world.Chunk, from, to world.BlockCoord, profile UnitProfile) bool {
+	if from.X == to.X || from.Y == to.Y {
 		return true
 	}
+	z := max(from.Z, to.Z)
+	return n.open(ctx, cache, world.BlockCoord{X: to.X, Y: from.Y, Z: z}, profile) &&
+		n.open(ctx, cache, world.BlockCoord{X: from.X, Y: to.Y, Z: z}, profile)
 }
 
 func (n *BlockNavigator) blockAt(ctx context.Context, cache map[world.ChunkCoord]*world.Chunk, coord world.BlockCoord) (world.Block, bool) {
@@ -290,11 +487,53 @@ func (n *BlockNavigator) blockAt(ctx context.Context, cache map[world.ChunkCoord
 	return block, true
 }
 
-func heuristicBlocks(a, b world.BlockCoord) int {
-	dx := abs(a.X - b.X)
-	dy := abs(a.Y - b.Y)
-	dz := abs(a.Z - b.Z)
-	return dx + dy + dz
+// diagonalOffsets are the four diagonal steps across the X/Y plane.
+var diagonalOffsets = []struct{ dx, dy, dz int }{
+	{1, 1, 0}, {1, -1, 0}, {-1, 1, 0}, {-1, -1, 0},
+}
+
+// stepCost is the distance travelled between adjacent blocks: √2 for a
+// diagonal step across X/Y and one block otherwise, however far a ground unit
+// climbs or drops on the way.
+func stepCost(from, to world.BlockCoord) float64 {
+	if from.X != to.X && from.Y != to.Y {
+		return math.Sqrt2
+	}
+	return 1
+}
+
+// estimate returns the heuristic distance from a to b for the profile.
+func (p UnitProfile) estimate(a, b world.BlockCoord) float64 {
+	heuristic := p.Heuristic
+	if heuristic == HeuristicAuto {
+		heuristic = HeuristicManhattan
+		if p.AllowDiagonal {
+			heuristic = HeuristicOctile
+		}
+	}
+	switch heuristic {
+	case HeuristicOctile:
+		return octileDistance(a, b)
+	case HeuristicEuclidean:
+		dx, dy, dz := float64(a.X-b.X), float64(a.Y-b.Y), float64(a.Z-b.Z)
+		return math.Sqrt(dx*dx + dy*dy + dz*dz)
+	default:
+		return float64(a.Manhattan(b))
+	}
+}
+
+// octileDistance is the cost of the shortest route from a to b on an open map
+// where diagonal steps across X/Y cost √2 and every other step costs one.
+func octileDistance(a, b world.BlockCoord) float64 {
+	dx, dy, dz := absInt(a.X-b.X), absInt(a.Y-b.Y), absInt(a.Z-b.Z)
+	return float64(max(dx, dy)-min(dx, dy)) + math.Sqrt2*float64(min(dx, dy)) + float64(dz)
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
 }
 
 func reconstructBlocks(cameFrom map[world.BlockCoord]world.BlockCoord, current world.BlockCoord) []world.BlockCoord {
@@ -312,7 +551,7 @@ func reconstructBlocks(cameFrom map[world.BlockCoord]world.BlockCoord, current w
 
 type blockPath struct {
 	coord    world.BlockCoord
-	priority int
+	priority float64
 	index    int
 }
 
@@ -341,10 +580,3 @@ func (q *blockQueue) Pop() any {
 	*q = old[:n-1]
 	return item
 }
-
-func abs(v int) int {
-	if v < 0 {
-		return -v
-	}
-	return v
-}
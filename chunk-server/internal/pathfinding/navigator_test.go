@@ -2,6 +2,8 @@ package pathfinding
 
 import (
 	"context"
+	"math"
+	"reflect"
 	"testing"
 
 	"chunkserver/internal/world"
@@ -33,7 +35,8 @@ func newTestNavigator(t *testing.T, dims world.Dimensions) (*BlockNavigator, *wo
 
 	region := world.ServerRegion{
 		Origin:         world.ChunkCoord{X: 0, Y: 0},
-		ChunksPerAxis:  1,
+		ChunksX:        1,
+		ChunksY:        1,
 		ChunkDimension: dims,
 	}
 
@@ -290,6 +293,110 @@ func TestBlockNavigatorUndergroundRouteCanTunnelThroughMineral(t *testing.T) {
 	}
 }
 
+// newVeinNavigator returns a navigator over solid rock with a floor at z=0
+// and a mineral vein at z=1 running along X across rows minY..maxY.
+func newVeinNavigator(t *testing.T, minY, maxY int) *BlockNavigator {
+	t.Helper()
+	dims := world.Dimensions{Width: 9, Depth: 7, Height: 4}
+	navigator, chunk := newTestNavigator(t, dims)
+	for x := 0; x < dims.Width; x++ {
+		for y := 0; y < dims.Depth; y++ {
+			for z := 0; z < dims.Height; z++ {
+				block := world.BlockSolid
+				if z == 1 && y >= minY && y <= maxY {
+					block = world.BlockMineral
+				}
+				chunk.SetLocalBlock(x, y, z, world.Block{Type: block})
+			}
+		}
+	}
+	return navigator
+}
+
+func TestBlockNavigatorTunnelWidthLimitsDiggers(t *testing.T) {
+	start := world.BlockCoord{X: 1, Y: 3, Z: 1}
+	goal := world.BlockCoord{X: 7, Y: 3, Z: 1}
+	narrow := DefaultProfile(ModeUnderground)
+	wide := narrow
+	wide.TunnelWidth = 3
+
+	navigator := newVeinNavigator(t, 3, 3)
+	path := navigator.FindRoute(context.Background(), start, goal, narrow)
+	if len(path) != 7 {
+		t.Fatalf("width-1 digger route = %v, want the 7 blocks along the vein", path)
+	}
+	dug := navigator.DigManifest(context.Background(), path, narrow)
+	if len(dug) != 7 {
+		t.Fatalf("width-1 digger clears %v, want the 7 vein blocks", dug)
+	}
+	if path := navigator.FindRoute(context.Background(), start, goal, wide); path != nil {
+		t.Fatalf("width-3 digger routed through a one-block vein in hard rock: %v", path)
+	}
+	_, stats := navigator.FindRouteWithStats(context.Background(), start, goal, wide)
+	if stats.Status != RouteBlockedEndpoint {
+		t.Fatalf("width-3 status = %s, want %s", stats.Status, RouteBlockedEndpoint)
+	}
+
+	navigator = newVeinNavigator(t, 2, 4)
+	path = navigator.FindRoute(context.Background(), start, goal, wide)
+	if len(path) != 7 {
+		t.Fatalf("width-3 digger route = %v, want the 7 blocks along the vein", path)
+	}
+	dug = navigator.DigManifest(context.Background(), path, wide)
+	if len(dug) != 27 {
+		t.Fatalf("width-3 digger clears %d blocks, want 27", len(dug))
+	}
+	for _, target := range dug {
+		coord := target.Coord
+		if coord.X < 0 || coord.X > 8 || coord.Y < 2 || coord.Y > 4 || coord.Z != 1 {
+			t.Fatalf("width-3 digger clears %v outside the vein", coord)
+		}
+	}
+	if dug := navigator.DigManifest(context.Background(), path, DefaultProfile(ModeGround)); dug != nil {
+		t.Fatalf("non-digging unit clears %v, want nothing", dug)
+	}
+}
+
+func TestFindRouteWithStatsReportsDigManifest(t *testing.T) {
+	dims := world.Dimensions{Width: 5, Depth: 1, Height: 3}
+	navigator, chunk := newTestNavigator(t, dims)
+	addFloor(chunk, 0)
+	for x := 0; x < dims.Width; x++ {
+		chunk.SetLocalBlock(x, 0, 2, world.Block{Type: world.BlockSolid})
+	}
+	yields := []map[string]float64{{"iron": 1}, {"iron": 2}, {"gold": 0.5}}
+	for i, yield := range yields {
+		chunk.SetLocalBlock(i+1, 0, 1, world.Block{Type: world.BlockMineral, ResourceYield: yield})
+	}
+
+	start := world.BlockCoord{X: 0, Y: 0, Z: 1}
+	goal := world.BlockCoord{X: 4, Y: 0, Z: 1}
+	route, stats := navigator.FindRouteWithStats(context.Background(), start, goal, DefaultProfile(ModeUnderground))
+	if len(route) != 5 {
+		t.Fatalf("route = %v, want straight through the vein", route)
+	}
+	if len(stats.Dig) != len(yields) {
+		t.Fatalf("dig manifest = %+v, want the %d vein blocks", stats.Dig, len(yields))
+	}
+	for i, target := range stats.Dig {
+		want := world.BlockCoord{X: i + 1, Y: 0, Z: 1}
+		if target.Coord != want || target.Type != world.BlockMineral {
+			t.Fatalf("dig[%d] = %+v, want mineral at %v", i, target, want)
+		}
+		if !reflect.DeepEqual(target.Yield, yields[i]) {
+			t.Fatalf("dig[%d] yield = %v, want %v", i, target.Yield, yields[i])
+		}
+	}
+
+	_, stats = navigator.FindRouteWithStats(context.Background(), start, start, DefaultProfile(ModeUnderground))
+	if len(stats.Dig) != 0 {
+		t.Fatalf("standing still digs %+v, want nothing", stats.Dig)
+	}
+	if _, stats = navigator.FindRouteWithStats(context.Background(), start, goal, DefaultProfile(ModeGround)); stats.Dig != nil {
+		t.Fatalf("failed ground search reports dig manifest %+v", stats.Dig)
+	}
+}
+
 func TestBlockNavigatorGroundRouteRejectsBlockedEndpoints(t *testing.T) {
 	dims := world.Dimensions{Width: 5, Depth: 3, Height: 4}
 	navigator, chunk := newTestNavigator(t, dims)
@@ -427,7 +534,8 @@ func TestBlockNavigatorGroundRouteStopsAtCanyon(t *testing.T) {
 func TestBlockNavigatorGroundRouteCrossChunk(t *testing.T) {
 	region := world.ServerRegion{
 		Origin:         world.ChunkCoord{X: 0, Y: 0},
-		ChunksPerAxis:  2,
+		ChunksX:        2,
+		ChunksY:        2,
 		ChunkDimension: world.Dimensions{Width: 4, Depth: 3, Height: 4},
 	}
 	navigator, _, generator := newNavigatorWithRegion(t, region)
@@ -699,7 +807,8 @@ func TestBlockNavigatorFlyingRouteHonorsMaxClimb(t *testing.T) {
 func TestBlockNavigatorFlyingRouteCrossChunk(t *testing.T) {
 	region := world.ServerRegion{
 		Origin:         world.ChunkCoord{X: 0, Y: 0},
-		ChunksPerAxis:  2,
+		ChunksX:        2,
+		ChunksY:        2,
 		ChunkDimension: world.Dimensions{Width: 4, Depth: 1, Height: 5},
 	}
 	navigator, _, generator := newNavigatorWithRegion(t, region)
@@ -753,7 +862,8 @@ func TestBlockNavigatorUndergroundRouteBlockedBySolid(t *testing.T) {
 func TestBlockNavigatorUndergroundRouteCrossChunkThroughMineral(t *testing.T) {
 	region := world.ServerRegion{
 		Origin:         world.ChunkCoord{X: 0, Y: 0},
-		ChunksPerAxis:  2,
+		ChunksX:        2,
+		ChunksY:        2,
 		ChunkDimension: world.Dimensions{Width: 3, Depth: 1, Height: 4},
 	}
 	navigator, _, generator := newNavigatorWithRegion(t, region)
@@ -787,7 +897,8 @@ func TestBlockNavigatorUndergroundRouteCrossChunkThroughMineral(t *testing.T) {
 func TestBlockNavigatorGroundRouteNeedsSupportAcrossBoundary(t *testing.T) {
 	region := world.ServerRegion{
 		Origin:         world.ChunkCoord{X: 0, Y: 0},
-		ChunksPerAxis:  2,
+		ChunksX:        2,
+		ChunksY:        2,
 		ChunkDimension: world.Dimensions{Width: 3, Depth: 1, Height: 4},
 	}
 	navigator, _, generator := newNavigatorWithRegion(t, region)
@@ -827,7 +938,8 @@ func TestBlockNavigatorFindRouteCancelledContext(t *testing.T) {
 func TestBlockNavigatorGroundRouteFailsWithNilWorld(t *testing.T) {
 	region := world.ServerRegion{
 		Origin:         world.ChunkCoord{X: 0, Y: 0},
-		ChunksPerAxis:  1,
+		ChunksX:        1,
+		ChunksY:        1,
 		ChunkDimension: world.Dimensions{Width: 4, Depth: 4, Height: 4},
 	}
 
@@ -876,3 +988,365 @@ func TestBlockNavigatorGroundRouteCannotStartOnWorldFloor(t *testing.T) {
 		t.Fatalf("expected start on world floor to be invalid, got %v", path)
 	}
 }
+
+func TestBlockNavigatorHeuristicScaleOneKeepsOptimalRoute(t *testing.T) {
+	dims := world.Dimensions{Width: 16, Depth: 16, Height: 4}
+	navigator, chunk := newTestNavigator(t, dims)
+	addFloor(chunk, 0)
+
+	start := world.BlockCoord{X: 1, Y: 1, Z: 1}
+	goal := world.BlockCoord{X: 13, Y: 10, Z: 1}
+
+	baseline := navigator.FindRoute(context.Background(), start, goal, DefaultProfile(ModeGround))
+	navigator.SetOptions(SearchOptions{HeuristicScale: 1.0})
+	scaled := navigator.FindRoute(context.Background(), start, goal, DefaultProfile(ModeGround))
+
+	optimal := start.Manhattan(goal) + 1
+	if len(baseline) != optimal {
+		t.Fatalf("expected unscaled route of %d steps, got %d", optimal, len(baseline))
+	}
+	if len(scaled) != optimal {
+		t.Fatalf("expected scale 1.0 route of %d steps, got %d", optimal, len(scaled))
+	}
+}
+
+func TestBlockNavigatorHeuristicScaleReducesExpansions(t *testing.T) {
+	dims := world.Dimensions{Width: 24, Depth: 24, Height: 4}
+	navigator, chunk := newTestNavigator(t, dims)
+	addFloor(chunk, 0)
+
+	start := world.BlockCoord{X: 1, Y: 1, Z: 1}
+	goal := world.BlockCoord{X: 21, Y: 19, Z: 1}
+
+	run := func(scale float64) ([]world.BlockCoord, int64) {
+		navigator.SetOptions(SearchOptions{HeuristicScale: scale})
+		metrics := &NavigatorMetrics{}
+		ctx := ContextWithProfiler(context.Background(), metrics.Profiler())
+		path := navigator.FindRoute(ctx, start, goal, DefaultProfile(ModeGround))
+		return path, metrics.Snapshot().NodesExpanded
+	}
+
+	optimalPath, optimalExpanded := run(1.0)
+	greedyPath, greedyExpanded := run(3.0)
+
+	if len(optimalPath) == 0 || len(greedyPath) == 0 {
+		t.Fatalf("expected both searches to find a route")
+	}
+	if greedyExpanded >= optimalExpanded {
+		t.Fatalf("expected weighted search to expand fewer nodes: scale 1 expanded %d, scale 3 expanded %d", optimalExpanded, greedyExpanded)
+	}
+	if len(greedyPath) < len(optimalPath) {
+		t.Fatalf("weighted route (%d) cannot be shorter than optimal route (%d)", len(greedyPath), len(optimalPath))
+	}
+}
+
+func TestBlockNavigatorRoutesAtNegativeCoordinates(t *testing.T) {
+	region := world.ServerRegion{
+		Origin:         world.ChunkCoord{X: -1, Y: 0},
+		ChunksX:        1,
+		ChunksY:        1,
+		ChunkDimension: world.Dimensions{Width: 4, Depth: 4, Height: 4},
+	}
+	navigator, _, generator := newNavigatorWithRegion(t, region)
+	chunkCoord := world.ChunkCoord{X: -1, Y: 0}
+	chunk := newChunkForRegion(t, region, chunkCoord)
+	addFloor(chunk, 0)
+	generator.setChunk(chunkCoord, chunk)
+
+	profile := UnitProfile{Mode: ModeGround, Clearance: 1, MaxClimb: 1, MaxDrop: 1}
+	// x=-1 and x=-width are the east and west edges of chunk -1.
+	start := world.BlockCoord{X: -1, Y: 1, Z: 1}
+	goal := world.BlockCoord{X: -4, Y: 2, Z: 1}
+	route := navigator.FindRoute(context.Background(), start, goal, profile)
+	if len(route) == 0 {
+		t.Fatalf("expected a route across chunk %v", chunkCoord)
+	}
+	for _, step := range route {
+		if located, ok := region.LocateBlock(step); !ok || located != chunkCoord {
+			t.Fatalf("route step %v located in %v (owned %t), want %v", step, located, ok, chunkCoord)
+		}
+	}
+
+	if route := navigator.FindRoute(context.Background(), start, world.BlockCoord{X: 0, Y: 1, Z: 1}, profile); route != nil {
+		t.Fatalf("expected no route to x=0 outside the region, got %v", route)
+	}
+}
+
+func TestFindRouteWithStatsClassifiesFailures(t *testing.T) {
+	start := world.BlockCoord{X: 1, Y: 1, Z: 1}
+	goal := world.BlockCoord{X: 5, Y: 1, Z: 1}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cases := []struct {
+		name   string
+		setup  func(*BlockNavigator, *world.Chunk)
+		ctx    context.Context
+		goal   world.BlockCoord
+		status RouteStatus
+	}{
+		{name: "found", goal: goal, status: RouteFound},
+		{name: "out of region", goal: world.BlockCoord{X: 40, Y: 1, Z: 1}, status: RouteOutOfRegion},
+		{
+			name: "blocked endpoint",
+			setup: func(_ *BlockNavigator, chunk *world.Chunk) {
+				chunk.SetLocalBlock(goal.X, goal.Y, goal.Z, world.Block{Type: world.BlockSolid})
+			},
+			goal:   goal,
+			status: RouteBlockedEndpoint,
+		},
+		{
+			name: "node limit",
+			setup: func(navigator *BlockNavigator, _ *world.Chunk) {
+				navigator.SetOptions(SearchOptions{MaxNodes: 2})
+			},
+			goal:   goal,
+			status: RouteNodeLimit,
+		},
+		{name: "timeout", ctx: cancelled, goal: goal, status: RouteTimeout},
+		{
+			name: "no path",
+			setup: func(_ *BlockNavigator, chunk *world.Chunk) {
+				for y := 0; y < 3; y++ {
+					for z := 0; z < 3; z++ {
+						chunk.ClearLocalBlock(3, y, z)
+					}
+				}
+			},
+			goal:   goal,
+			status: RouteNoPath,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			navigator, chunk := newTestNavigator(t, world.Dimensions{Width: 6, Depth: 3, Height: 4})
+			addFloor(chunk, 0)
+			if tc.setup != nil {
+				tc.setup(navigator, chunk)
+			}
+			ctx := tc.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			route, stats := navigator.FindRouteWithStats(ctx, start, tc.goal, DefaultProfile(ModeGround))
+			if stats.Status != tc.status {
+				t.Fatalf("status = %q, want %q", stats.Status, tc.status)
+			}
+			if (tc.status == RouteFound) != (len(route) > 0) {
+				t.Fatalf("status %q returned route %v", stats.Status, route)
+			}
+		})
+	}
+}
+
+func TestBlockNavigatorHonorsRegisteredBlockTypes(t *testing.T) {
+	registry := world.NewBlockTypeRegistry()
+	registry.Register("water", world.BlockTypeProperties{Passable: true, Transmissive: true})
+	registry.Register("mud", world.BlockTypeProperties{Diggable: true, SupportsLoad: true})
+	previous := world.CurrentBlockTypes()
+	world.SetBlockTypes(registry)
+	t.Cleanup(func() { world.SetBlockTypes(previous) })
+
+	dims := world.Dimensions{Width: 5, Depth: 1, Height: 3}
+	start := world.BlockCoord{X: 0, Y: 0, Z: 1}
+	goal := world.BlockCoord{X: 4, Y: 0, Z: 1}
+	wall := func(chunk *world.Chunk, blockType world.BlockType) {
+		for z := 1; z < dims.Height; z++ {
+			chunk.SetLocalBlock(2, 0, z, world.Block{Type: blockType})
+		}
+	}
+
+	// A flying unit passes through a wall of water but not one of mud.
+	navigator, chunk := newTestNavigator(t, dims)
+	addFloor(chunk, 0)
+	wall(chunk, "water")
+	if path := navigator.FindRoute(context.Background(), start, goal, DefaultProfile(ModeFlying)); len(path) == 0 {
+		t.Fatalf("expected a flying route through water")
+	}
+	wall(chunk, "mud")
+	if path := navigator.FindRoute(context.Background(), start, goal, DefaultProfile(ModeFlying)); path != nil {
+		t.Fatalf("expected mud to block a unit that cannot dig, got %v", path)
+	}
+
+	// A digging unit tunnels through mud.
+	digger := DefaultProfile(ModeUnderground)
+	digger.CanDig = true
+	if path := navigator.FindRoute(context.Background(), start, goal, digger); len(path) == 0 {
+		t.Fatalf("expected a digging unit to tunnel through mud")
+	}
+
+	// A ground unit cannot stand on water.
+	navigator, chunk = newTestNavigator(t, dims)
+	addFloor(chunk, 0)
+	chunk.SetLocalBlock(2, 0, 0, world.Block{Type: "water"})
+	if path := navigator.FindRoute(context.Background(), start, goal, DefaultProfile(ModeGround)); path != nil {
+		t.Fatalf("expected water to give no footing, got %v", path)
+	}
+}
+
+func routeCost(route []world.BlockCoord) float64 {
+	cost := 0.0
+	for i := 1; i < len(route); i++ {
+		cost += stepCost(route[i-1], route[i])
+	}
+	return cost
+}
+
+func TestBlockNavigatorOctileHeuristicNeverOverestimatesOnOpenMap(t *testing.T) {
+	dims := world.Dimensions{Width: 10, Depth: 10, Height: 4}
+	navigator, chunk := newTestNavigator(t, dims)
+	addFloor(chunk, 0)
+
+	profile := DefaultProfile(ModeGround)
+	profile.AllowDiagonal = true
+	start := world.BlockCoord{X: 2, Y: 1, Z: 1}
+	const epsilon = 1e-9
+	for x := 0; x < dims.Width; x++ {
+		for y := 0; y < dims.Depth; y++ {
+			goal := world.BlockCoord{X: x, Y: y, Z: 1}
+			route := navigator.FindRoute(context.Background(), start, goal, profile)
+			if len(route) == 0 {
+				t.Fatalf("no route from %v to %v on an open map", start, goal)
+			}
+			// On an open map the octile distance is the true optimum, so the
+			// route must match it and no node along it may be overestimated.
+			cost := routeCost(route)
+			if optimal := octileDistance(start, goal); math.Abs(cost-optimal) > epsilon {
+				t.Fatalf("route %v -> %v costs %.3f, want optimal %.3f", start, goal, cost, optimal)
+			}
+			for i, step := range route {
+				remaining := routeCost(route[i:])
+				if estimate := profile.estimate(step, goal); estimate > remaining+epsilon {
+					t.Fatalf("estimate %.3f from %v to %v exceeds true cost %.3f", estimate, step, goal, remaining)
+				}
+			}
+		}
+	}
+
+	// Manhattan distance is what made diagonal search inadmissible.
+	diagonal := world.BlockCoord{X: 8, Y: 7, Z: 1}
+	manhattan := profile
+	manhattan.Heuristic = HeuristicManhattan
+	if manhattan.estimate(start, diagonal) <= octileDistance(start, diagonal) {
+		t.Fatalf("expected Manhattan distance to overestimate a diagonal route")
+	}
+}
+
+func TestBlockNavigatorDiagonalStepsDoNotCutCorners(t *testing.T) {
+	dims := world.Dimensions{Width: 4, Depth: 4, Height: 4}
+	navigator, chunk := newTestNavigator(t, dims)
+	addFloor(chunk, 0)
+	for z := 1; z < 4; z++ {
+		chunk.SetLocalBlock(2, 1, z, world.Block{Type: world.BlockSolid})
+	}
+
+	profile := DefaultProfile(ModeGround)
+	profile.AllowDiagonal = true
+	start := world.BlockCoord{X: 1, Y: 1, Z: 1}
+	goal := world.BlockCoord{X: 2, Y: 2, Z: 1}
+	route := navigator.FindRoute(context.Background(), start, goal, profile)
+	if len(route) != 3 {
+		t.Fatalf("expected a two-step route around the wall corner, got %v", route)
+	}
+
+	open := world.BlockCoord{X: 1, Y: 2, Z: 1}
+	if route := navigator.FindRoute(context.Background(), world.BlockCoord{X: 0, Y: 1, Z: 1}, open, profile); len(route) != 2 {
+		t.Fatalf("expected a single diagonal step across open ground, got %v", route)
+	}
+}
+
+func TestBlockNavigatorFlyingEuclideanHeuristicKeepsOptimalRoute(t *testing.T) {
+	dims := world.Dimensions{Width: 8, Depth: 8, Height: 8}
+	navigator, _ := newTestNavigator(t, dims)
+
+	profile := DefaultProfile(ModeFlying)
+	profile.Heuristic = HeuristicEuclidean
+	start := world.BlockCoord{X: 0, Y: 0, Z: 1}
+	goal := world.BlockCoord{X: 5, Y: 3, Z: 4}
+	route := navigator.FindRoute(context.Background(), start, goal, profile)
+	if want := start.Manhattan(goal) + 1; len(route) != want {
+		t.Fatalf("expected an optimal route of %d blocks, got %d: %v", want, len(route), route)
+	}
+
+	profile.AllowDiagonal = true
+	route = navigator.FindRoute(context.Background(), start, goal, profile)
+	if cost, want := routeCost(route), octileDistance(start, goal); math.Abs(cost-want) > 1e-9 {
+		t.Fatalf("diagonal flying route costs %.3f, want %.3f", cost, want)
+	}
+}
+
+func TestBlockNavigatorReportsDegenerateRegionUnavailable(t *testing.T) {
+	region := world.ServerRegion{
+		ChunksX:        1,
+		ChunksY:        1,
+		ChunkDimension: world.Dimensions{Width: 4, Depth: 4, Height: 0},
+	}
+	navigator, _, _ := newNavigatorWithRegion(t, region)
+	_, stats := navigator.FindRouteWithStats(context.Background(), world.BlockCoord{X: 0, Y: 0}, world.BlockCoord{X: 3, Y: 3}, DefaultProfile(ModeFlying))
+	if stats.Status != RouteUnavailable {
+		t.Fatalf("status = %q, want %q", stats.Status, RouteUnavailable)
+	}
+}
+
+func TestBlockNavigatorWallPenaltyKeepsRoutesOffWalls(t *testing.T) {
+	// A walled room seven blocks deep; start and goal both sit against the
+	// y=0 wall.
+	dims := world.Dimensions{Width: 14, Depth: 9, Height: 4}
+	navigator, chunk := newTestNavigator(t, dims)
+	addFloor(chunk, 0)
+	surroundWithWalls(chunk, 3)
+	start := world.BlockCoord{X: 1, Y: 1, Z: 1}
+	goal := world.BlockCoord{X: 12, Y: 1, Z: 1}
+	middle := func(route []world.BlockCoord) []world.BlockCoord {
+		var steps []world.BlockCoord
+		for _, step := range route {
+			if step.X == dims.Width/2 {
+				steps = append(steps, step)
+			}
+		}
+		return steps
+	}
+
+	baseline := navigator.FindRoute(context.Background(), start, goal, DefaultProfile(ModeGround))
+	if len(baseline) != 12 {
+		t.Fatalf("baseline route = %v, want the straight 12 steps along the wall", baseline)
+	}
+	for _, step := range middle(baseline) {
+		if step.Y != 1 {
+			t.Fatalf("baseline route %v leaves the wall, want it to hug y=1", baseline)
+		}
+	}
+
+	profile := DefaultProfile(ModeGround)
+	profile.WallPenalty = 1
+	route := navigator.FindRoute(context.Background(), start, goal, profile)
+	if len(route) == 0 || route[len(route)-1] != goal {
+		t.Fatalf("expected a route with the wall penalty, got %v", route)
+	}
+	for _, step := range middle(route) {
+		if step.Y <= 1 || step.Y >= dims.Depth-2 {
+			t.Fatalf("route %v crosses the middle of the room at %+v, want it clear of the walls", route, step)
+		}
+	}
+}
+
+func TestWallPenaltyChecksEverySideColumnOfWideFootprints(t *testing.T) {
+	navigator, chunk := newTestNavigator(t, world.Dimensions{Width: 9, Depth: 9, Height: 4})
+	addFloor(chunk, 0)
+	coord := world.BlockCoord{X: 4, Y: 4, Z: 1}
+	// The pillar sits beside the corner of a three-wide footprint, off the
+	// line through its center.
+	chunk.SetLocalBlock(coord.X+2, coord.Y+1, coord.Z, world.Block{Type: world.BlockSolid})
+
+	profile := DefaultProfile(ModeGround)
+	profile.WallPenalty = 1
+	profile.TunnelWidth = 3
+	if got := navigator.wallPenalty(context.Background(), make(map[world.ChunkCoord]*world.Chunk), coord, profile); got != 1 {
+		t.Fatalf("wallPenalty() = %v, want 1 for the pillar beside the footprint", got)
+	}
+
+	profile.TunnelWidth = 1
+	if got := navigator.wallPenalty(context.Background(), make(map[world.ChunkCoord]*world.Chunk), coord, profile); got != 0 {
+		t.Fatalf("wallPenalty() = %v, want 0 for a single column clear of the pillar", got)
+	}
+}
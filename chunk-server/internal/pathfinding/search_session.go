@@ -0,0 +1,205 @@
+package pathfinding
+
+import (
+	"container/heap"
+	"context"
+	"time"
+
+	"chunkserver/internal/world"
+)
+
+// RouteInProgress is the status of a search session that has not finished.
+const RouteInProgress RouteStatus = "in_progress"
+
+// SearchSession is a route search that runs a bounded number of node
+// expansions per Step, so a long search can be spread over several server
+// ticks. The open set, scores, and loaded chunks carry over between steps.
+// A session is not safe for concurrent use.
+type SearchSession struct {
+	navigator *BlockNavigator
+	ctx       context.Context
+	start     world.BlockCoord
+	goal      world.BlockCoord
+	profile   UnitProfile
+	profiler  NavigatorProfiler
+
+	opts     SearchOptions
+	scale    float64
+	avoid    *KeepOut
+	cache    map[world.ChunkCoord]*world.Chunk
+	open     *blockQueue
+	cameFrom map[world.BlockCoord]world.BlockCoord
+	gScore   map[world.BlockCoord]float64
+
+	started bool
+	done    bool
+	route   []world.BlockCoord
+	stats   SearchStats
+}
+
+// NewSearchSession prepares a search from start to goal. No work is done until
+// the first Step; the navigator's search options are read then and stay fixed
+// for the rest of the session. ctx bounds the whole search, across steps.
+func (n *BlockNavigator) NewSearchSession(ctx context.Context, start, goal world.BlockCoord, profile UnitProfile) *SearchSession {
+	return &SearchSession{
+		navigator: n,
+		ctx:       ctx,
+		start:     start,
+		goal:      goal,
+		profile:   profile,
+		profiler:  profilerFromContext(ctx),
+		stats:     SearchStats{Status: RouteInProgress},
+	}
+}
+
+// Step expands up to budget nodes, or runs the search to the end when budget
+// is zero or less, and reports whether the search is done. Until it is, the
+// route is nil and the status RouteInProgress. Elapsed counts only the time
+// spent inside Step. Calls after the search is done return its result again.
+func (s *SearchSession) Step(budget int) ([]world.BlockCoord, SearchStats, bool) {
+	if !s.done {
+		began := time.Now()
+		s.advance(budget)
+		s.stats.Elapsed += time.Since(began)
+	}
+	if !s.done {
+		return nil, s.stats, false
+	}
+	return s.route, s.stats, true
+}
+
+// advance runs up to budget expansions, finishing the session if the search
+// ends on the way.
+func (s *SearchSession) advance(budget int) {
+	if !s.started {
+		s.started = true
+		if status, ok := s.begin(); !ok {
+			s.finish(nil, status)
+			return
+		}
+		if s.done {
+			return
+		}
+	}
+
+	n := s.navigator
+	for expanded := 0; s.open.Len() > 0; expanded++ {
+		if budget > 0 && expanded >= budget {
+			return
+		}
+		select {
+		case <-s.ctx.Done():
+			s.finish(nil, RouteTimeout)
+			return
+		default:
+		}
+
+		current := heap.Pop(s.open).(*blockPath)
+		if s.profiler != nil {
+			s.profiler.RecordNodeExpanded()
+		}
+		if current.coord == s.goal {
+			s.finish(reconstructBlocks(s.cameFrom, current.coord), RouteFound)
+			return
+		}
+		if s.opts.MaxNodes > 0 && s.stats.Expanded >= s.opts.MaxNodes {
+			s.finish(nil, RouteNodeLimit)
+			return
+		}
+		s.stats.Expanded++
+
+		neighbors := n.neighbors(s.ctx, s.cache, current.coord, s.profile)
+		if s.profiler != nil {
+			s.profiler.RecordNeighborGeneration(len(neighbors))
+		}
+		for _, neighbor := range neighbors {
+			if s.avoid.Blocks(neighbor) {
+				continue
+			}
+			tentative := s.gScore[current.coord] + stepCost(current.coord, neighbor) + s.avoid.Penalty(neighbor) +
+				n.wallPenalty(s.ctx, s.cache, neighbor, s.profile)
+			if score, ok := s.gScore[neighbor]; ok && tentative >= score {
+				continue
+			}
+			s.cameFrom[neighbor] = current.coord
+			s.gScore[neighbor] = tentative
+			if s.profiler != nil {
+				s.profiler.RecordHeuristicEvaluation()
+			}
+			priority := tentative + s.scale*s.profile.estimate(neighbor, s.goal)
+			heap.Push(s.open, &blockPath{coord: neighbor, priority: priority})
+		}
+	}
+
+	if s.ctx.Err() != nil {
+		s.finish(nil, RouteTimeout)
+		return
+	}
+	s.finish(nil, RouteNoPath)
+}
+
+// Done reports whether the search has finished.
+func (s *SearchSession) Done() bool {
+	return s.done
+}
+
+// begin checks the endpoints and seeds the open set. It reports false with the
+// failure status when the search cannot start, and finishes the session
+// outright when start is the goal.
+func (s *SearchSession) begin() (RouteStatus, bool) {
+	n := s.navigator
+	if s.start == s.goal {
+		s.finish([]world.BlockCoord{s.start}, RouteFound)
+		return RouteFound, true
+	}
+	if n.world == nil || n.region.Validate() != nil {
+		return RouteUnavailable, false
+	}
+	if _, ok := n.region.LocateBlock(s.start); !ok {
+		return RouteOutOfRegion, false
+	}
+	if _, ok := n.region.LocateBlock(s.goal); !ok {
+		return RouteOutOfRegion, false
+	}
+
+	s.cache = make(map[world.ChunkCoord]*world.Chunk)
+	if !n.passable(s.ctx, s.cache, s.start, s.profile) || !n.passable(s.ctx, s.cache, s.goal, s.profile) {
+		// An endpoint whose chunk could not load because the caller gave up
+		// is a timeout, not an obstacle.
+		if s.ctx.Err() != nil {
+			return RouteTimeout, false
+		}
+		return RouteBlockedEndpoint, false
+	}
+	s.avoid = s.profile.Avoid.excluding(s.start)
+	if s.avoid.Blocks(s.goal) {
+		return RouteBlockedEndpoint, false
+	}
+
+	s.opts = n.Options()
+	s.scale = s.opts.HeuristicScale
+	if s.scale <= 0 {
+		s.scale = 1
+	}
+	s.open = &blockQueue{}
+	heap.Init(s.open)
+	heap.Push(s.open, &blockPath{coord: s.start, priority: 0})
+	s.cameFrom = map[world.BlockCoord]world.BlockCoord{}
+	s.gScore = map[world.BlockCoord]float64{s.start: 0}
+	return "", true
+}
+
+func (s *SearchSession) finish(route []world.BlockCoord, status RouteStatus) {
+	s.done = true
+	s.route = route
+	s.stats.Status = status
+	if status == RouteFound {
+		if s.cache == nil {
+			s.cache = make(map[world.ChunkCoord]*world.Chunk)
+		}
+		s.stats.Dig = s.navigator.digTargets(s.ctx, s.cache, route, s.profile)
+	}
+	// The search state is no longer needed; drop it so a finished session
+	// kept around for its result does not pin the chunks and scores.
+	s.cache, s.open, s.cameFrom, s.gScore = nil, nil, nil, nil
+}
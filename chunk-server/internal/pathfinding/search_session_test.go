@@ -0,0 +1,126 @@
+package pathfinding
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"chunkserver/internal/world"
+)
+
+func newMazeNavigator(t *testing.T) *BlockNavigator {
+	t.Helper()
+	dims := world.Dimensions{Width: 12, Depth: 12, Height: 4}
+	navigator, chunk := newTestNavigator(t, dims)
+	addFloor(chunk, 0)
+	// Two staggered walls force the route to snake through the map.
+	for y := 0; y < 9; y++ {
+		for z := 1; z < 4; z++ {
+			chunk.SetLocalBlock(4, y, z, world.Block{Type: world.BlockSolid})
+		}
+	}
+	for y := 3; y < 12; y++ {
+		for z := 1; z < 4; z++ {
+			chunk.SetLocalBlock(8, y, z, world.Block{Type: world.BlockSolid})
+		}
+	}
+	return navigator
+}
+
+func TestSearchSessionInStepsMatchesOneShotRoute(t *testing.T) {
+	navigator := newMazeNavigator(t)
+	start := world.BlockCoord{X: 1, Y: 1, Z: 1}
+	goal := world.BlockCoord{X: 10, Y: 10, Z: 1}
+	profiles := map[string]UnitProfile{
+		"ground":   DefaultProfile(ModeGround),
+		"diagonal": {Mode: ModeGround, Clearance: 2, MaxClimb: 1, MaxDrop: 2, AllowDiagonal: true},
+	}
+
+	for name, profile := range profiles {
+		want, wantStats := navigator.FindRouteWithStats(context.Background(), start, goal, profile)
+		if len(want) == 0 {
+			t.Fatalf("%s: expected a one-shot route through the maze", name)
+		}
+		for _, budget := range []int{1, 3, 17} {
+			session := navigator.NewSearchSession(context.Background(), start, goal, profile)
+			var (
+				route []world.BlockCoord
+				stats SearchStats
+				done  bool
+				steps int
+			)
+			for !done {
+				route, stats, done = session.Step(budget)
+				steps++
+				if !done && (route != nil || stats.Status != RouteInProgress) {
+					t.Fatalf("%s budget %d: unfinished step returned route %v status %q", name, budget, route, stats.Status)
+				}
+				if steps > wantStats.Expanded+2 {
+					t.Fatalf("%s budget %d: search did not finish after %d steps", name, budget, steps)
+				}
+			}
+			if budget < wantStats.Expanded && steps < 2 {
+				t.Fatalf("%s budget %d: expected the search to yield before finishing", name, budget)
+			}
+			if !reflect.DeepEqual(route, want) {
+				t.Fatalf("%s budget %d: stepped route %v differs from one-shot %v", name, budget, route, want)
+			}
+			if stats.Status != RouteFound || stats.Expanded != wantStats.Expanded {
+				t.Fatalf("%s budget %d: stats %+v, want status %q with %d expansions", name, budget, stats, RouteFound, wantStats.Expanded)
+			}
+			if again, _, done := session.Step(budget); !done || !reflect.DeepEqual(again, want) {
+				t.Fatalf("%s budget %d: finished session did not repeat its result", name, budget)
+			}
+		}
+	}
+}
+
+func TestSearchSessionNodeLimitSpansSteps(t *testing.T) {
+	navigator := newMazeNavigator(t)
+	navigator.SetOptions(SearchOptions{MaxNodes: 10})
+	session := navigator.NewSearchSession(context.Background(), world.BlockCoord{X: 1, Y: 1, Z: 1}, world.BlockCoord{X: 10, Y: 10, Z: 1}, DefaultProfile(ModeGround))
+
+	for i := 0; i < 3; i++ {
+		if _, _, done := session.Step(3); done {
+			t.Fatalf("session finished after %d expansions, before the node limit", (i+1)*3)
+		}
+	}
+	route, stats, done := session.Step(3)
+	if !done || route != nil || stats.Status != RouteNodeLimit || stats.Expanded != 10 {
+		t.Fatalf("expected the node limit after 10 expansions, got done=%t status %q expanded %d", done, stats.Status, stats.Expanded)
+	}
+}
+
+func TestNodeLimitAllowsExactlyMaxNodesExpansions(t *testing.T) {
+	navigator := newMazeNavigator(t)
+	start, goal := world.BlockCoord{X: 1, Y: 1, Z: 1}, world.BlockCoord{X: 10, Y: 10, Z: 1}
+	profile := DefaultProfile(ModeGround)
+	want, stats := navigator.FindRouteWithStats(context.Background(), start, goal, profile)
+	if len(want) == 0 {
+		t.Fatalf("expected a route through the maze")
+	}
+
+	// A limit of exactly the expansions the search needs still finds the
+	// route; one fewer stops it.
+	navigator.SetOptions(SearchOptions{MaxNodes: stats.Expanded})
+	if route, got := navigator.FindRouteWithStats(context.Background(), start, goal, profile); !reflect.DeepEqual(route, want) || got.Status != RouteFound {
+		t.Fatalf("expected the route within %d expansions, got status %q", stats.Expanded, got.Status)
+	}
+	navigator.SetOptions(SearchOptions{MaxNodes: stats.Expanded - 1})
+	if route, got := navigator.FindRouteWithStats(context.Background(), start, goal, profile); route != nil || got.Status != RouteNodeLimit || got.Expanded != stats.Expanded-1 {
+		t.Fatalf("expected the node limit after %d expansions, got status %q after %d", stats.Expanded-1, got.Status, got.Expanded)
+	}
+}
+
+func TestSearchSessionStopsWhenContextCancelled(t *testing.T) {
+	navigator := newMazeNavigator(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	session := navigator.NewSearchSession(ctx, world.BlockCoord{X: 1, Y: 1, Z: 1}, world.BlockCoord{X: 10, Y: 10, Z: 1}, DefaultProfile(ModeGround))
+	if _, _, done := session.Step(2); done {
+		t.Fatalf("expected the first step to yield")
+	}
+	cancel()
+	if _, stats, done := session.Step(2); !done || stats.Status != RouteTimeout {
+		t.Fatalf("expected a cancelled session to time out, got done=%t status %q", done, stats.Status)
+	}
+}
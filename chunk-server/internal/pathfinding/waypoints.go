@@ -0,0 +1,68 @@
+package pathfinding
+
+import (
+	"context"
+	"fmt"
+
+	"chunkserver/internal/world"
+)
+
+// SegmentError reports the leg of a multi-point route that could not be
+// routed. Index counts legs from zero: leg i runs from point i to point i+1.
+type SegmentError struct {
+	Index  int
+	From   world.BlockCoord
+	To     world.BlockCoord
+	Status RouteStatus
+}
+
+func (e *SegmentError) Error() string {
+	return fmt.Sprintf("no route for segment %d from %v to %v: %s", e.Index, e.From, e.To, e.Status)
+}
+
+// FindRouteThrough chains FindRoute between each consecutive pair of points
+// and joins the legs into one route that visits every point in order. The
+// point shared by two legs appears once. If any leg is unroutable the whole
+// route fails with a *SegmentError naming it. The returned stats add up the
+// work of every leg searched and carry the status of the last one; their dig
+// manifest covers the whole route, each block once.
+func (n *BlockNavigator) FindRouteThrough(ctx context.Context, points []world.BlockCoord, profile UnitProfile) ([]world.BlockCoord, SearchStats, error) {
+	if len(points) == 0 {
+		return nil, SearchStats{Status: RouteNoPath}, nil
+	}
+	if len(points) == 1 {
+		route, stats := n.FindRouteWithStats(ctx, points[0], points[0], profile)
+		return route, stats, nil
+	}
+	var route []world.BlockCoord
+	var total SearchStats
+	for i := 0; i+1 < len(points); i++ {
+		leg, stats := n.FindRouteWithStats(ctx, points[i], points[i+1], profile)
+		total.Status = stats.Status
+		total.Expanded += stats.Expanded
+		total.Elapsed += stats.Elapsed
+		if len(leg) == 0 {
+			total.Dig = nil
+			return nil, total, &SegmentError{Index: i, From: points[i], To: points[i+1], Status: stats.Status}
+		}
+		for _, target := range stats.Dig {
+			if !containsDigTarget(total.Dig, target.Coord) {
+				total.Dig = append(total.Dig, target)
+			}
+		}
+		if len(route) > 0 && route[len(route)-1] == leg[0] {
+			leg = leg[1:]
+		}
+		route = append(route, leg...)
+	}
+	return route, total, nil
+}
+
+func containsDigTarget(dig []DigTarget, coord world.BlockCoord) bool {
+	for _, target := range dig {
+		if target.Coord == coord {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package pathfinding
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"chunkserver/internal/world"
+)
+
+func TestFindRouteThroughVisitsWaypointsInOrder(t *testing.T) {
+	dims := world.Dimensions{Width: 8, Depth: 8, Height: 4}
+	navigator, chunk := newTestNavigator(t, dims)
+	addFloor(chunk, 0)
+
+	points := []world.BlockCoord{
+		{X: 0, Y: 0, Z: 1},
+		{X: 6, Y: 1, Z: 1},
+		{X: 6, Y: 6, Z: 1},
+		{X: 1, Y: 5, Z: 1},
+	}
+	route, _, err := navigator.FindRouteThrough(context.Background(), points, DefaultProfile(ModeGround))
+	if err != nil {
+		t.Fatalf("FindRouteThrough() error = %v", err)
+	}
+	if route[0] != points[0] || route[len(route)-1] != points[len(points)-1] {
+		t.Fatalf("route runs %v to %v, want %v to %v", route[0], route[len(route)-1], points[0], points[len(points)-1])
+	}
+	next := 0
+	for i, step := range route {
+		if i > 0 {
+			prev := route[i-1]
+			if step == prev {
+				t.Fatalf("route repeats %v at step %d", step, i)
+			}
+			if absInt(step.X-prev.X) > 1 || absInt(step.Y-prev.Y) > 1 || absInt(step.Z-prev.Z) > 1 {
+				t.Fatalf("route jumps from %v to %v", prev, step)
+			}
+		}
+		if next < len(points) && step == points[next] {
+			next++
+		}
+	}
+	if next != len(points) {
+		t.Fatalf("route visited %d of %d points in order: %v", next, len(points), route)
+	}
+}
+
+func TestFindRouteThroughReportsUnroutableSegment(t *testing.T) {
+	dims := world.Dimensions{Width: 8, Depth: 8, Height: 4}
+	navigator, chunk := newTestNavigator(t, dims)
+	addFloor(chunk, 0)
+	blocked := world.BlockCoord{X: 6, Y: 6, Z: 1}
+	chunk.SetLocalBlock(blocked.X, blocked.Y, blocked.Z, world.Block{Type: world.BlockSolid})
+
+	points := []world.BlockCoord{{X: 0, Y: 0, Z: 1}, {X: 6, Y: 1, Z: 1}, blocked, {X: 1, Y: 5, Z: 1}}
+	route, _, err := navigator.FindRouteThrough(context.Background(), points, DefaultProfile(ModeGround))
+	if route != nil {
+		t.Fatalf("expected no route, got %v", route)
+	}
+	var segErr *SegmentError
+	if !errors.As(err, &segErr) {
+		t.Fatalf("expected a SegmentError, got %v", err)
+	}
+	if segErr.Index != 1 || segErr.To != blocked || segErr.Status != RouteBlockedEndpoint {
+		t.Fatalf("expected segment 1 into %v to fail, got %+v", blocked, segErr)
+	}
+}
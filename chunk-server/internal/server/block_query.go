@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+
+	"chunkserver/internal/network"
+	"chunkserver/internal/world"
+)
+
+// maxBlockQueryBlocks bounds how many blocks one BlockQuery may cover so the
+// reply fits in a datagram.
+const maxBlockQueryBlocks = 256
+
+const (
+	blockQueryOK       = "ok"
+	blockQueryNotFound = "not_found"
+	blockQueryTooLarge = "too_large"
+)
+
+func (s *Server) onBlockQuery(ctx context.Context, addr *net.UDPAddr, env network.Envelope) {
+	var query network.BlockQuery
+	if err := json.Unmarshal(env.Payload, &query); err != nil {
+		s.logger.Warnf("block query decode: %v", err)
+		return
+	}
+
+	reply := s.queryBlocks(ctx, query)
+	if err := s.net.Send(addr.String(), network.MessageBlockReply, reply); err != nil {
+		s.logger.Warnf("block reply send: %v", err)
+	}
+}
+
+// queryBlocks reads the blocks a query covers, loading their chunks as
+// needed.
+func (s *Server) queryBlocks(ctx context.Context, query network.BlockQuery) network.BlockReply {
+	reply := network.BlockReply{
+		ServerID:  s.currentConfig().Server.ID,
+		RequestID: query.RequestID,
+	}
+	from := world.BlockCoord{X: query.X, Y: query.Y, Z: query.Z}
+	to := from
+	if query.Max != nil {
+		to = world.BlockCoord{X: query.Max.X, Y: query.Max.Y, Z: query.Max.Z}
+		from, to = world.BlockCoord{X: min(from.X, to.X), Y: min(from.Y, to.Y), Z: min(from.Z, to.Z)},
+			world.BlockCoord{X: max(from.X, to.X), Y: max(from.Y, to.Y), Z: max(from.Z, to.Z)}
+	}
+	spanX, okX := blockSpan(from.X, to.X)
+	spanY, okY := blockSpan(from.Y, to.Y)
+	spanZ, okZ := blockSpan(from.Z, to.Z)
+	if !okX || !okY || !okZ || spanX*spanY*spanZ > maxBlockQueryBlocks {
+		reply.Status = blockQueryTooLarge
+		return reply
+	}
+
+	chunks := make(map[world.ChunkCoord]*world.Chunk)
+	for z := from.Z; z <= to.Z; z++ {
+		for y := from.Y; y <= to.Y; y++ {
+			for x := from.X; x <= to.X; x++ {
+				coord := world.BlockCoord{X: x, Y: y, Z: z}
+				block, ok := s.blockAt(ctx, chunks, coord)
+				if !ok {
+					continue
+				}
+				reply.Blocks = append(reply.Blocks, network.BlockInfo{
+					X:            x,
+					Y:            y,
+					Z:            z,
+					Type:         string(block.Type),
+					Material:     block.Material,
+					HitPoints:    block.HitPoints,
+					MaxHitPoints: block.MaxHitPoints,
+				})
+			}
+		}
+	}
+	if len(reply.Blocks) == 0 {
+		reply.Status = blockQueryNotFound
+	} else {
+		reply.Status = blockQueryOK
+	}
+	return reply
+}
+
+// blockSpan returns how many blocks lie in from..to. ok is false when the span
+// alone exceeds maxBlockQueryBlocks, which also keeps the product of three
+// spans from overflowing.
+func blockSpan(from, to int) (int, bool) {
+	diff := to - from
+	if diff < 0 || diff >= maxBlockQueryBlocks {
+		return 0, false
+	}
+	return diff + 1, true
+}
+
+// blockAt reads the block at coord, remembering loaded chunks in chunks. ok
+// is false when coord is outside the region or its chunk fails to load.
+func (s *Server) blockAt(ctx context.Context, chunks map[world.ChunkCoord]*world.Chunk, coord world.BlockCoord) (world.Block, bool) {
+	chunkCoord, ok := s.world.Region().LocateBlock(coord)
+	if !ok {
+		return world.Block{}, false
+	}
+	chunk, ok := chunks[chunkCoord]
+	if !ok {
+		var err error
+		chunk, err = s.world.ChunkForBlock(ctx, coord)
+		if err != nil {
+			s.logger.Warnf("block query load chunk %v: %v", chunkCoord, err)
+			chunk = nil
+		}
+		chunks[chunkCoord] = chunk
+	}
+	if chunk == nil {
+		return world.Block{}, false
+	}
+	x, y, z, ok := chunk.GlobalToLocal(coord)
+	if !ok {
+		return world.Block{}, false
+	}
+	return chunk.LocalBlock(x, y, z)
+}
+
+func (s *Server) onColumnQuery(ctx context.Context, addr *net.UDPAddr, env network.Envelope) {
+	var query network.ColumnQuery
+	if err := json.Unmarshal(env.Payload, &query); err != nil {
+		s.logger.Warnf("column query decode: %v", err)
+		return
+	}
+
+	for _, part := range s.queryColumn(ctx, query) {
+		if err := s.net.Send(addr.String(), network.MessageColumnReply, part); err != nil {
+			s.logger.Warnf("column reply send part %d/%d: %v", part.Part+1, part.Parts, err)
+			return
+		}
+	}
+}
+
+// queryColumn reads the column a query names and splits it into replies that
+// each fit in a datagram.
+func (s *Server) queryColumn(ctx context.Context, query network.ColumnQuery) []network.ColumnReply {
+	cfg := s.currentConfig()
+	reply := network.ColumnReply{
+		ServerID:  cfg.Server.ID,
+		RequestID: query.RequestID,
+		X:         query.X,
+		Y:         query.Y,
+		Status:    blockQueryNotFound,
+		Parts:     1,
+	}
+	base := world.BlockCoord{X: query.X, Y: query.Y, Z: 0}
+	if _, ok := s.world.Region().LocateBlock(base); !ok {
+		return []network.ColumnReply{reply}
+	}
+	chunk, err := s.world.ChunkForBlock(ctx, base)
+	if err != nil {
+		s.logger.Warnf("column query load chunk for %v: %v", base, err)
+		return []network.ColumnReply{reply}
+	}
+	x, y, _, ok := chunk.GlobalToLocal(base)
+	if !ok {
+		return []network.ColumnReply{reply}
+	}
+	runs, ok := chunk.ColumnRuns(x, y)
+	if !ok {
+		return []network.ColumnReply{reply}
+	}
+	reply.Status = blockQueryOK
+	return network.SplitColumnReply(reply, network.ColumnRunsFromWorld(runs), cfg.Network.MaxDatagramSizeBytes)
+}
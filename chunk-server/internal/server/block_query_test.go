@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"chunkserver/internal/config"
+	"chunkserver/internal/network"
+	"chunkserver/internal/world"
+)
+
+// quarryGenerator lays a floor of granite at z=0 with mineral ore at the
+// chunk's local origin.
+type quarryGenerator struct{}
+
+func (quarryGenerator) Generate(ctx context.Context, coord world.ChunkCoord, bounds world.Bounds, dim world.Dimensions) (*world.Chunk, error) {
+	chunk := world.NewChunk(coord, bounds, dim)
+	for x := 0; x < dim.Width; x++ {
+		for y := 0; y < dim.Depth; y++ {
+			chunk.SetLocalBlock(x, y, 0, world.Block{Type: world.BlockSolid, Material: "granite", HitPoints: 40, MaxHitPoints: 40})
+		}
+	}
+	chunk.SetLocalBlock(0, 0, 0, world.Block{Type: world.BlockMineral, Material: "iron", HitPoints: 25, MaxHitPoints: 30})
+	return chunk, nil
+}
+
+func newBlockQueryServer(t *testing.T) (*Server, net.PacketConn) {
+	t.Helper()
+	region := world.ServerRegion{
+		ChunksX:        2,
+		ChunksY:        1,
+		ChunkDimension: world.Dimensions{Width: 4, Depth: 4, Height: 4},
+	}
+	netSrv, err := network.Listen("127.0.0.1:0", noopLogger(), 0)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { netSrv.Close() })
+	client, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return &Server{
+		cfg:    config.Default(),
+		net:    netSrv,
+		logger: noopLogger(),
+		world:  world.NewManager(region, quarryGenerator{}),
+	}, client
+}
+
+// readReply reads one datagram from client and decodes its payload into out.
+func readReply(t *testing.T, client net.PacketConn, msg network.MessageType, out any) {
+	t.Helper()
+	buffer := make([]byte, 65536)
+	client.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := client.ReadFrom(buffer)
+	if err != nil {
+		t.Fatalf("read reply: %v", err)
+	}
+	env, err := network.Decode(buffer[:n])
+	if err != nil {
+		t.Fatalf("decode reply: %v", err)
+	}
+	if env.Type != msg {
+		t.Fatalf("expected %q, got %q", msg, env.Type)
+	}
+	if err := json.Unmarshal(env.Payload, out); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+}
+
+func queryBlocks(t *testing.T, query network.BlockQuery) network.BlockReply {
+	t.Helper()
+	srv, client := newBlockQueryServer(t)
+	payload, err := json.Marshal(query)
+	if err != nil {
+		t.Fatalf("marshal query: %v", err)
+	}
+	srv.onBlockQuery(context.Background(), client.LocalAddr().(*net.UDPAddr), network.Envelope{
+		Type:    network.MessageBlockQuery,
+		Payload: payload,
+	})
+	var reply network.BlockReply
+	readReply(t, client, network.MessageBlockReply, &reply)
+	if reply.RequestID != query.RequestID {
+		t.Fatalf("reply echoes request %q, want %q", reply.RequestID, query.RequestID)
+	}
+	return reply
+}
+
+func TestBlockQueryReturnsGeneratedBlock(t *testing.T) {
+	reply := queryBlocks(t, network.BlockQuery{RequestID: "ore", X: 4, Y: 0, Z: 0})
+	want := network.BlockInfo{X: 4, Y: 0, Z: 0, Type: string(world.BlockMineral), Material: "iron", HitPoints: 25, MaxHitPoints: 30}
+	if reply.Status != "ok" || len(reply.Blocks) != 1 || reply.Blocks[0] != want {
+		t.Fatalf("expected %+v, got status %q blocks %+v", want, reply.Status, reply.Blocks)
+	}
+}
+
+func TestBlockQueryBoxSkipsBlocksOutsideRegion(t *testing.T) {
+	reply := queryBlocks(t, network.BlockQuery{X: 7, Y: 0, Z: 1, Max: &network.BlockStep{X: 9, Y: 0, Z: 0}})
+	if reply.Status != "ok" {
+		t.Fatalf("expected ok, got %q", reply.Status)
+	}
+	want := []network.BlockInfo{
+		{X: 7, Y: 0, Z: 0, Type: string(world.BlockSolid), Material: "granite", HitPoints: 40, MaxHitPoints: 40},
+		{X: 7, Y: 0, Z: 1, Type: string(world.BlockAir)},
+	}
+	if len(reply.Blocks) != len(want) {
+		t.Fatalf("expected %d blocks, got %+v", len(want), reply.Blocks)
+	}
+	for i := range want {
+		if reply.Blocks[i] != want[i] {
+			t.Fatalf("block %d = %+v, want %+v", i, reply.Blocks[i], want[i])
+		}
+	}
+}
+
+func TestBlockQueryOutsideRegionIsNotFound(t *testing.T) {
+	reply := queryBlocks(t, network.BlockQuery{RequestID: "far", X: 40, Y: 2, Z: 0})
+	if reply.Status != "not_found" || len(reply.Blocks) != 0 {
+		t.Fatalf("expected not_found with no blocks, got %q and %+v", reply.Status, reply.Blocks)
+	}
+}
+
+func TestBlockQueryRejectsOversizedBox(t *testing.T) {
+	reply := queryBlocks(t, network.BlockQuery{X: 0, Y: 0, Z: 0, Max: &network.BlockStep{X: 7, Y: 7, Z: 7}})
+	if reply.Status != "too_large" {
+		t.Fatalf("expected too_large for a %d-block box, got %q", 8*8*8, reply.Status)
+	}
+}
+
+func TestBlockQueryRejectsBoxWhoseVolumeOverflows(t *testing.T) {
+	// 3 * 6148914691236517206 wraps to 2 in a 64-bit int.
+	reply := queryBlocks(t, network.BlockQuery{X: 0, Y: 0, Z: 0, Max: &network.BlockStep{X: 2, Y: 6148914691236517205, Z: 0}})
+	if reply.Status != "too_large" {
+		t.Fatalf("expected too_large for an overflowing box, got %q", reply.Status)
+	}
+}
+
+func TestColumnQueryMatchesLocalBlocks(t *testing.T) {
+	srv, client := newBlockQueryServer(t)
+	chunk, err := srv.world.Chunk(context.Background(), world.ChunkCoord{X: 1, Y: 0})
+	if err != nil {
+		t.Fatalf("load chunk: %v", err)
+	}
+	chunk.SetLocalBlock(1, 2, 2, world.Block{Type: world.BlockUnstable, Material: "sand", HitPoints: 5, MaxHitPoints: 5})
+	// Small datagrams force the column across several replies.
+	srv.cfg.Network.MaxDatagramSizeBytes = 600
+
+	payload, err := json.Marshal(network.ColumnQuery{RequestID: "col", X: 5, Y: 2})
+	if err != nil {
+		t.Fatalf("marshal query: %v", err)
+	}
+	srv.onColumnQuery(context.Background(), client.LocalAddr().(*net.UDPAddr), network.Envelope{
+		Type:    network.MessageColumnQuery,
+		Payload: payload,
+	})
+
+	var got []network.BlockInfo
+	parts := 1
+	for i := 0; i < parts; i++ {
+		var reply network.ColumnReply
+		readReply(t, client, network.MessageColumnReply, &reply)
+		if reply.Status != "ok" || reply.RequestID != "col" || reply.X != 5 || reply.Y != 2 || reply.Part != i {
+			t.Fatalf("unexpected reply header %+v", reply)
+		}
+		parts = reply.Parts
+		for _, run := range reply.Runs {
+			for z := run.Z; z < run.Z+run.Count; z++ {
+				got = append(got, network.BlockInfo{X: 5, Y: 2, Z: z, Type: run.Type, Material: run.Material, HitPoints: run.HitPoints, MaxHitPoints: run.MaxHitPoints})
+			}
+		}
+	}
+	if parts < 2 {
+		t.Fatalf("expected the column split across replies, got %d part", parts)
+	}
+
+	var want []network.BlockInfo
+	for z := 0; z < 4; z++ {
+		block, ok := chunk.LocalBlock(1, 2, z)
+		if !ok {
+			t.Fatalf("local block at z=%d missing", z)
+		}
+		want = append(want, network.BlockInfo{X: 5, Y: 2, Z: z, Type: string(block.Type), Material: block.Material, HitPoints: block.HitPoints, MaxHitPoints: block.MaxHitPoints})
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d blocks, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("block %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestColumnQueryOutsideRegionIsNotFound(t *testing.T) {
+	srv, client := newBlockQueryServer(t)
+	payload, err := json.Marshal(network.ColumnQuery{X: -1, Y: 0})
+	if err != nil {
+		t.Fatalf("marshal query: %v", err)
+	}
+	srv.onColumnQuery(context.Background(), client.LocalAddr().(*net.UDPAddr), network.Envelope{
+		Type:    network.MessageColumnQuery,
+		Payload: payload,
+	})
+	var reply network.ColumnReply
+	readReply(t, client, network.MessageColumnReply, &reply)
+	if reply.Status != "not_found" || len(reply.Runs) != 0 || reply.Parts != 1 {
+		t.Fatalf("expected a single not_found reply, got %+v", reply)
+	}
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"testing"
+
+	"chunkserver/internal/config"
+	"chunkserver/internal/entities"
+	"chunkserver/internal/world"
+)
+
+func TestUpdateEntityChunkFloorsNegativeCoordinates(t *testing.T) {
+	region := world.ServerRegion{
+		Origin:         world.ChunkCoord{X: -2, Y: 0},
+		ChunksX:        2,
+		ChunksY:        2,
+		ChunkDimension: world.Dimensions{Width: 4, Depth: 4, Height: 4},
+	}
+	cfg := config.Default()
+	srv := &Server{
+		cfg:           cfg,
+		logger:        noopLogger(),
+		world:         world.NewManager(region, stubGenerator{}),
+		entities:      entities.NewManager(cfg.Server.ID),
+		dirtyEntities: make(map[entities.ID]entities.Entity),
+		dirtyChunks:   make(map[world.ChunkCoord]struct{}),
+	}
+	ent := &entities.Entity{
+		ID:       "unit-1",
+		Kind:     entities.KindUnit,
+		Position: entities.Vec3{X: -2.5, Y: 1.5, Z: 1},
+		Chunk:    entities.ChunkMembership{Chunk: world.ChunkCoord{X: -1, Y: 0}},
+	}
+	if err := srv.entities.Add(ent); err != nil {
+		t.Fatalf("add entity: %v", err)
+	}
+
+	cases := []struct {
+		x    float64
+		want world.ChunkCoord
+	}{
+		// Block -1 sits in chunk -1; truncating division would put it in chunk 0.
+		{x: -0.5, want: world.ChunkCoord{X: -1, Y: 0}},
+		// Block -4 (x = -width) is the first block of chunk -1.
+		{x: -4, want: world.ChunkCoord{X: -1, Y: 0}},
+		{x: -4.5, want: world.ChunkCoord{X: -2, Y: 0}},
+		{x: -8, want: world.ChunkCoord{X: -2, Y: 0}},
+	}
+	for _, tc := range cases {
+		ent.SetPosition(entities.Vec3{X: tc.x, Y: 1.5, Z: 1})
+		srv.updateEntityChunk(ent)
+		if ent.Chunk.Chunk != tc.want {
+			t.Fatalf("entity at x=%v assigned to chunk %v, want %v", tc.x, ent.Chunk.Chunk, tc.want)
+		}
+		located, ok := region.LocateBlock(world.BlockFromVec(ent.PositionVec()))
+		if !ok || located != tc.want {
+			t.Fatalf("LocateBlock at x=%v = %v, %t, disagrees with entity chunk %v", tc.x, located, ok, tc.want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+
+	"chunkserver/internal/network"
+	"chunkserver/internal/world"
+)
+
+func (s *Server) onChunkTransfer(ctx context.Context, addr *net.UDPAddr, env network.Envelope) {
+	if s.neighbors == nil || !s.neighbors.isConfiguredPeer(addr) {
+		s.logger.Warn("chunk transfer ignored: not a configured neighbor", "addr", addr.String())
+		return
+	}
+	var part network.ChunkTransfer
+	if err := json.Unmarshal(env.Payload, &part); err != nil {
+		s.logger.Warn("chunk transfer decode failed", "err", err)
+		return
+	}
+	if err := s.receiveChunkTransfer(ctx, part); err != nil {
+		s.logger.Warn("chunk transfer failed", "neighbor", part.ServerID, "err", err)
+	}
+}
+
+// receiveChunkTransfer buffers part and imports the chunk once every part of
+// its transfer has arrived.
+func (s *Server) receiveChunkTransfer(ctx context.Context, part network.ChunkTransfer) error {
+	data, complete, err := s.chunkTransfers.Add(part)
+	if err != nil || !complete {
+		return err
+	}
+	coord := world.ChunkCoord{X: part.ChunkX, Y: part.ChunkY}
+	if _, err := s.world.ImportChunk(coord, bytes.NewReader(data)); err != nil {
+		return err
+	}
+	s.logger.Info("imported chunk", "chunk", coord, "neighbor", part.ServerID)
+	return s.sendChunkSummary(ctx, coord)
+}
@@ -0,0 +1,196 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"chunkserver/internal/config"
+	"chunkserver/internal/network"
+	"chunkserver/internal/world"
+)
+
+// chunkTransferPeers starts a sender network and a receiver server that keeps
+// chunks in its own storage and reports imports to mainServer. The receiver
+// trusts the sender when trustSender is set.
+func chunkTransferPeers(t *testing.T, ctx context.Context, region world.ServerRegion, trustSender bool) (*network.Server, *Server, net.PacketConn) {
+	t.Helper()
+	senderNet, err := network.Listen("127.0.0.1:0", noopLogger(), 0)
+	if err != nil {
+		t.Fatalf("listen sender: %v", err)
+	}
+	t.Cleanup(func() { senderNet.Close() })
+	mainServer, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen main server: %v", err)
+	}
+	t.Cleanup(func() { mainServer.Close() })
+	receiverNet, err := network.Listen("127.0.0.1:0", noopLogger(), 0)
+	if err != nil {
+		t.Fatalf("listen receiver: %v", err)
+	}
+	t.Cleanup(func() { receiverNet.Close() })
+
+	var refs []config.NeighborRef
+	if trustSender {
+		refs = append(refs, config.NeighborRef{ChunkDelta: config.ChunkIndex{X: 1}, Endpoint: senderNet.LocalAddr().String()})
+	}
+	receiverCfg := config.Default()
+	receiverCfg.Network.MainServerEndpoints = []string{mainServer.LocalAddr().String()}
+	receiver := &Server{
+		cfg:       receiverCfg,
+		net:       receiverNet,
+		logger:    noopLogger(),
+		world:     world.NewManager(region, stubGenerator{}),
+		neighbors: newNeighborManager(region, refs),
+	}
+	// The receiver stores chunks elsewhere, so it can only see the blocks
+	// that came over the wire.
+	receiver.world.SetStorageProvider(world.NewDiskStorageProvider(t.TempDir(), region))
+	receiver.net.Register(network.MessageChunkTransfer, receiver.onChunkTransfer)
+	go func() { _ = receiver.net.Serve(ctx) }()
+	return senderNet, receiver, mainServer
+}
+
+// sendChunkExport exports chunk and sends it to endpoint split for datagrams
+// of maxDatagram bytes.
+func sendChunkExport(t *testing.T, from *network.Server, chunk *world.Chunk, endpoint string, maxDatagram int) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := chunk.ExportColumns(&buf); err != nil {
+		t.Fatalf("ExportColumns() error = %v", err)
+	}
+	base := network.ChunkTransfer{ServerID: "west", ChunkX: chunk.Key.X, ChunkY: chunk.Key.Y, TransferID: 1}
+	for _, part := range network.SplitChunkTransfer(base, buf.Bytes(), maxDatagram) {
+		if err := from.Send(endpoint, network.MessageChunkTransfer, part); err != nil {
+			t.Fatalf("send part %d/%d: %v", part.Part+1, part.Parts, err)
+		}
+	}
+}
+
+func TestChunkTransferHandsChunkToAnotherServer(t *testing.T) {
+	region := world.ServerRegion{
+		ChunksX:        1,
+		ChunksY:        1,
+		ChunkDimension: world.Dimensions{Width: 8, Depth: 8, Height: 8},
+	}
+	source := world.NewManager(region, stubGenerator{})
+	source.SetStorageProvider(world.NewDiskStorageProvider(t.TempDir(), region))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	coord := world.ChunkCoord{}
+	chunk, err := source.Chunk(ctx, coord)
+	if err != nil {
+		t.Fatalf("load chunk: %v", err)
+	}
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			chunk.SetLocalBlock(x, y, (x+y)%8, world.Block{Type: world.BlockSolid, Material: "stone", HitPoints: float64(x*8 + y), MaxHitPoints: 64})
+		}
+	}
+
+	senderNet, receiver, mainServer := chunkTransferPeers(t, ctx, region, true)
+	// A tiny datagram limit forces the export to be split into several parts.
+	sendChunkExport(t, senderNet, chunk, receiver.net.LocalAddr().String(), 1200)
+	if received := readMessageTypes(t, mainServer, 1); !received[network.MessageChunkSummary] {
+		t.Fatalf("expected a chunk summary once the import finished, got %v", received)
+	}
+
+	imported, ready, err := receiver.world.ChunkIfReady(coord)
+	if err != nil || !ready {
+		t.Fatalf("expected the transferred chunk to be resident (ready %t, err %v)", ready, err)
+	}
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			for z := 0; z < 8; z++ {
+				want, _ := chunk.LocalBlock(x, y, z)
+				got, _ := imported.LocalBlock(x, y, z)
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("block (%d,%d,%d) = %+v, want %+v", x, y, z, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestChunkTransferIgnoresUnconfiguredPeers(t *testing.T) {
+	region := world.ServerRegion{
+		ChunksX:        1,
+		ChunksY:        1,
+		ChunkDimension: world.Dimensions{Width: 4, Depth: 4, Height: 4},
+	}
+	source := world.NewManager(region, stubGenerator{})
+	source.SetStorageProvider(world.NewDiskStorageProvider(t.TempDir(), region))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	chunk, err := source.Chunk(ctx, world.ChunkCoord{})
+	if err != nil {
+		t.Fatalf("load chunk: %v", err)
+	}
+
+	senderNet, receiver, mainServer := chunkTransferPeers(t, ctx, region, false)
+	sendChunkExport(t, senderNet, chunk, receiver.net.LocalAddr().String(), 1200)
+	mainServer.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if _, _, err := mainServer.ReadFrom(make([]byte, 65536)); err == nil {
+		t.Fatalf("expected no chunk summary for a transfer from an unconfigured peer")
+	}
+	if resident, _ := receiver.world.ChunkCounts(); resident != 0 {
+		t.Fatalf("expected no chunk imported, got %d resident", resident)
+	}
+}
+
+func TestChunkTransferAssemblerWaitsForEveryPart(t *testing.T) {
+	data := make([]byte, 5000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	parts := network.SplitChunkTransfer(network.ChunkTransfer{ServerID: "west", TransferID: 3}, data, 1200)
+	if len(parts) < 3 {
+		t.Fatalf("expected the data to be split into several parts, got %d", len(parts))
+	}
+
+	var assembler network.ChunkTransferAssembler
+	for i := len(parts) - 1; i >= 0; i-- {
+		got, complete, err := assembler.Add(parts[i])
+		if err != nil {
+			t.Fatalf("Add(part %d) error = %v", i, err)
+		}
+		if complete != (i == 0) {
+			t.Fatalf("Add(part %d) complete = %t", i, complete)
+		}
+		if complete && !reflect.DeepEqual(got, data) {
+			t.Fatalf("reassembled data does not match the original")
+		}
+	}
+}
+
+func TestChunkTransferAssemblerRejectsOversizedTransfers(t *testing.T) {
+	var assembler network.ChunkTransferAssembler
+	assembler.SetMaxDatagram(1200)
+	_, _, err := assembler.Add(network.ChunkTransfer{ServerID: "west", TransferID: 1, Part: 0, Parts: 1 << 40})
+	if err == nil || !strings.Contains(err.Error(), "exceeds the limit") {
+		t.Fatalf("Add() error = %v, want part count rejected", err)
+	}
+
+	for id := uint64(1); ; id++ {
+		_, _, err := assembler.Add(network.ChunkTransfer{ServerID: "west", TransferID: id, Part: 0, Parts: 2})
+		if err != nil {
+			if !strings.Contains(err.Error(), "already pending") {
+				t.Fatalf("Add(transfer %d) error = %v, want pending limit", id, err)
+			}
+			if id < 2 {
+				t.Fatalf("expected some transfers to be buffered before the limit")
+			}
+			break
+		}
+		if id > 1000 {
+			t.Fatalf("expected pending transfers to be bounded")
+		}
+	}
+}
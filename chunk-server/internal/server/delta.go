@@ -13,7 +13,9 @@ type deltaAccumulator struct {
 
 var deltaPriority = map[world.ChangeReason]int{
 	world.ReasonDamage:   1,
+	world.ReasonRepair:   1,
 	world.ReasonDestroy:  2,
+	world.ReasonEdit:     2,
 	world.ReasonCollapse: 3,
 }
 
@@ -46,12 +48,12 @@ func (d *deltaAccumulator) add(chunk world.ChunkCoord, change world.BlockChange)
 	byBlock[change.Coord] = change
 }
 
-func (d *deltaAccumulator) flush(serverID string, seq *uint64) []network.ChunkDelta {
+func (d *deltaAccumulator) flush(serverID string, seq *uint64, at time.Time) []network.ChunkDelta {
 	if len(d.data) == 0 {
 		return nil
 	}
 
-	now := time.Now().UTC()
+	now := at.UTC()
 	deltas := make([]network.ChunkDelta, 0, len(d.data))
 
 	for chunk, blocks := range d.data {
@@ -67,20 +69,8 @@ func (d *deltaAccumulator) flush(serverID string, seq *uint64) []network.ChunkDe
 			Blocks:    make([]network.BlockChange, 0, len(blocks)),
 		}
 		*seq++
-		for coord, change := range blocks {
-			delta.Blocks = append(delta.Blocks, network.BlockChange{
-				X:        coord.X,
-				Y:        coord.Y,
-				Z:        coord.Z,
-				Type:     encodeBlockType(change.After.Type),
-				Material: change.After.Material,
-				Color:    change.After.Color,
-				Texture:  change.After.Texture,
-				HP:       change.After.HitPoints,
-				MaxHP:    change.After.MaxHitPoints,
-				Reason:   encodeChangeReason(change.Reason),
-				Light:    change.After.LightEmission,
-			})
+		for _, change := range blocks {
+			delta.Blocks = append(delta.Blocks, network.BlockChangeFromChange(change))
 		}
 		deltas = append(deltas, delta)
 	}
@@ -95,33 +85,3 @@ func priority(reason world.ChangeReason) int {
 	}
 	return 0
 }
-
-func encodeBlockType(t world.BlockType) network.BlockTypeCode {
-	switch t {
-	case world.BlockAir:
-		return network.BlockTypeAir
-	case world.BlockSolid:
-		return network.BlockTypeSolid
-	case world.BlockUnstable:
-		return network.BlockTypeUnstable
-	case world.BlockMineral:
-		return network.BlockTypeMineral
-	case world.BlockExplosive:
-		return network.BlockTypeExplosive
-	default:
-		return network.BlockTypeUnknown
-	}
-}
-
-func encodeChangeReason(reason world.ChangeReason) network.ChangeReasonCode {
-	switch reason {
-	case world.ReasonDamage:
-		return network.ChangeReasonDamage
-	case world.ReasonDestroy:
-		return network.ChangeReasonDestroy
-	case world.ReasonCollapse:
-		return network.ChangeReasonCollapse
-	default:
-		return network.ChangeReasonUnknown
-	}
-}
@@ -77,7 +77,7 @@ func TestDeltaAccumulatorFlushProducesNetworkDeltas(t *testing.T) {
 	accumulator.add(chunkB, changeB)
 
 	seq := uint64(100)
-	deltas := accumulator.flush("server-123", &seq)
+	deltas := accumulator.flush("server-123", &seq, time.Unix(1000, 0))
 
 	if len(deltas) != 2 {
 		t.Fatalf("expected 2 deltas, got %d", len(deltas))
@@ -102,8 +102,8 @@ func TestDeltaAccumulatorFlushProducesNetworkDeltas(t *testing.T) {
 		if delta.ServerID != "server-123" {
 			t.Errorf("unexpected server id %q", delta.ServerID)
 		}
-		if delta.Timestamp.IsZero() {
-			t.Errorf("expected timestamp to be set")
+		if !delta.Timestamp.Equal(time.Unix(1000, 0)) || delta.Timestamp.Location() != time.UTC {
+			t.Errorf("expected the flush time in UTC as the timestamp, got %v", delta.Timestamp)
 		}
 		seenSeq[delta.Seq] = true
 
@@ -119,14 +119,14 @@ func TestDeltaAccumulatorFlushProducesNetworkDeltas(t *testing.T) {
 		if block.X != change.Coord.X || block.Y != change.Coord.Y || block.Z != change.Coord.Z {
 			t.Errorf("block coordinates mismatch: got (%d,%d,%d) want (%d,%d,%d)", block.X, block.Y, block.Z, change.Coord.X, change.Coord.Y, change.Coord.Z)
 		}
-		if block.Type != encodeBlockType(change.After.Type) {
-			t.Errorf("block type mismatch: got %v want %v", block.Type, encodeBlockType(change.After.Type))
+		if block.Type != network.EncodeBlockType(change.After.Type) {
+			t.Errorf("block type mismatch: got %v want %v", block.Type, network.EncodeBlockType(change.After.Type))
 		}
 		if block.HP != change.After.HitPoints || block.MaxHP != change.After.MaxHitPoints {
 			t.Errorf("block hp mismatch: got (%f,%f) want (%f,%f)", block.HP, block.MaxHP, change.After.HitPoints, change.After.MaxHitPoints)
 		}
-		if block.Reason != encodeChangeReason(change.Reason) {
-			t.Errorf("reason mismatch: got %v want %v", block.Reason, encodeChangeReason(change.Reason))
+		if block.Reason != network.EncodeChangeReason(change.Reason) {
+			t.Errorf("reason mismatch: got %v want %v", block.Reason, network.EncodeChangeReason(change.Reason))
 		}
 		delete(expectedByChunk, coord)
 	}
@@ -143,7 +143,7 @@ func TestDeltaAccumulatorFlushProducesNetworkDeltas(t *testing.T) {
 func TestDeltaAccumulatorFlushEmptyReturnsNil(t *testing.T) {
 	accumulator := newDeltaAccumulator()
 	seq := uint64(5)
-	if deltas := accumulator.flush("server-abc", &seq); deltas != nil {
+	if deltas := accumulator.flush("server-abc", &seq, time.Unix(1000, 0)); deltas != nil {
 		t.Fatalf("expected nil deltas for empty accumulator, got %#v", deltas)
 	}
 	if seq != 5 {
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"chunkserver/internal/entities"
+)
+
+const drainPollInterval = 20 * time.Millisecond
+
+// drainOnShutdown runs the drain phase under server.drainTimeout once the run
+// loop has stopped. A zero timeout skips it.
+func (s *Server) drainOnShutdown() {
+	timeout := s.currentConfig().Server.DrainTimeout.Duration()
+	if timeout <= 0 {
+		return
+	}
+	started := s.now()
+	ctx, cancel := s.withClockDeadline(context.Background(), started.Add(timeout))
+	defer cancel()
+
+	if err := s.drain(ctx); err != nil {
+		s.logger.Warn("drain incomplete", "elapsed", s.now().Sub(started).Round(time.Millisecond), "err", err)
+		return
+	}
+	s.logger.Info("drained", "elapsed", s.now().Sub(started).Round(time.Millisecond))
+}
+
+// withClockDeadline returns a context that is cancelled, with cause
+// context.DeadlineExceeded, once the server clock reaches deadline. The clock
+// is checked every drainPollInterval of its own time, so a manual clock
+// decides exactly when the deadline passes.
+func (s *Server) withClockDeadline(parent context.Context, deadline time.Time) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	ticker := s.serverClock().NewTicker(drainPollInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+				if !s.now().Before(deadline) {
+					cancel(context.DeadlineExceeded)
+					return
+				}
+			}
+		}
+	}()
+	return ctx, func() { cancel(context.Canceled) }
+}
+
+// drain stops the server from taking on new path and transfer work, gives
+// queued and in-flight migrations until ctx is done to be acknowledged, flushes
+// dirty entities and voxel deltas to the main servers, and snapshots resident
+// chunks. Flushing and snapshotting happen even when migrations run out of
+// time, so the returned error only reports what could not be finished.
+//
+// It must be called from the goroutine that runs the server loop.
+func (s *Server) drain(ctx context.Context) error {
+	s.draining.Store(true)
+
+	var errs []error
+	if err := s.drainMigrations(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	s.flushDirtyEntities()
+	s.flushVoxelDeltas()
+	if s.world != nil {
+		if err := s.world.Snapshot(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// drainMigrations keeps sending queued migrations until every transfer has
+// been acknowledged. Entities whose transfer is still open when ctx is done
+// stay with this server and are marked dirty so their final state is flushed.
+func (s *Server) drainMigrations(ctx context.Context) error {
+	if s.migrationQueue == nil {
+		return nil
+	}
+	ticker := s.serverClock().NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		s.processMigrationQueue()
+		if s.migrationQueue.Len() == 0 && s.inFlightCount() == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d migrations unfinished: %w", s.abandonMigrations(), context.Cause(ctx))
+		case <-ticker.C():
+		}
+	}
+}
+
+// abandonMigrations clears every queued and in-flight migration, returning the
+// number of entities left with this server.
+func (s *Server) abandonMigrations() int {
+	abandoned := make(map[entities.ID]struct{})
+	for _, req := range s.migrationQueue.Drain(0) {
+		abandoned[req.EntityID] = struct{}{}
+	}
+	s.transfersMu.Lock()
+	for id := range s.inFlightTransfers {
+		abandoned[id] = struct{}{}
+		delete(s.inFlightTransfers, id)
+	}
+	s.transfersMu.Unlock()
+	for id := range abandoned {
+		if ent, ok := s.entities.Entity(id); ok {
+			ent.SetAttribute("migration_pending", 0)
+			s.recordDirtyEntity(ent)
+		}
+	}
+	return len(abandoned)
+}
+
+// inFlightCount returns how many transfers await their ack.
+func (s *Server) inFlightCount() int {
+	s.transfersMu.Lock()
+	defer s.transfersMu.Unlock()
+	return len(s.inFlightTransfers)
+}
+
+// isDraining reports whether the server has begun shutting down and is
+// refusing new work.
+func (s *Server) isDraining() bool {
+	return s.draining.Load()
+}
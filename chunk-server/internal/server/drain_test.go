@@ -0,0 +1,220 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"chunkserver/internal/clock"
+	"chunkserver/internal/config"
+	"chunkserver/internal/entities"
+	"chunkserver/internal/migration"
+	"chunkserver/internal/network"
+	"chunkserver/internal/world"
+)
+
+func TestDrainFlushesDeltasAndSnapshotsChunks(t *testing.T) {
+	region := world.ServerRegion{
+		ChunksX:        1,
+		ChunksY:        1,
+		ChunkDimension: world.Dimensions{Width: 4, Depth: 4, Height: 4},
+	}
+	dir := t.TempDir()
+
+	mainServer, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen main server: %v", err)
+	}
+	defer mainServer.Close()
+
+	netSrv, err := network.Listen("127.0.0.1:0", noopLogger(), 0)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer netSrv.Close()
+
+	cfg := config.Default()
+	cfg.Network.MainServerEndpoints = []string{mainServer.LocalAddr().String()}
+	srv := &Server{
+		cfg:               cfg,
+		net:               netSrv,
+		logger:            noopLogger(),
+		world:             world.NewManager(region, stubGenerator{}),
+		entities:          entities.NewManager(cfg.Server.ID),
+		dirtyEntities:     make(map[entities.ID]entities.Entity),
+		deltaBuffer:       newDeltaAccumulator(),
+		migrationQueue:    migration.NewQueue(),
+		inFlightTransfers: make(map[entities.ID]migration.Request),
+	}
+
+	srv.world.SetStorageProvider(world.NewDiskStorageProvider(dir, region))
+
+	coord := world.ChunkCoord{X: 0, Y: 0}
+	chunk, err := srv.world.Chunk(context.Background(), coord)
+	if err != nil {
+		t.Fatalf("load chunk: %v", err)
+	}
+	solid := world.Block{Type: world.BlockSolid, HitPoints: 10, MaxHitPoints: 10}
+	if !chunk.SetLocalBlock(1, 1, 0, solid) {
+		t.Fatalf("set block failed")
+	}
+	srv.deltaBuffer.add(coord, world.BlockChange{
+		Coord:  world.BlockCoord{X: 1, Y: 1, Z: 0},
+		After:  solid,
+		Reason: world.ReasonDamage,
+	})
+	srv.recordDirtyEntity(&entities.Entity{ID: "unit-1", Kind: entities.KindUnit})
+
+	indexes, err := filepath.Glob(filepath.Join(dir, "*", "*", "*.idx"))
+	if err != nil || len(indexes) != 1 {
+		t.Fatalf("expected one chunk index, got %v (err %v)", indexes, err)
+	}
+	index := indexes[0]
+	if err := os.Remove(index); err != nil {
+		t.Fatalf("remove chunk index: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := srv.drain(ctx); err != nil {
+		t.Fatalf("drain() error = %v", err)
+	}
+
+	if !srv.isDraining() {
+		t.Fatalf("expected server to report draining")
+	}
+	if _, err := os.Stat(index); err != nil {
+		t.Fatalf("expected drain to rewrite the chunk index: %v", err)
+	}
+
+	received := readMessageTypes(t, mainServer, 2)
+	if !received[network.MessageChunkDelta] {
+		t.Fatalf("expected pending voxel deltas to be flushed, got %v", received)
+	}
+	if !received[network.MessageEntityUpdate] {
+		t.Fatalf("expected dirty entities to be flushed, got %v", received)
+	}
+}
+
+func TestDrainAbandonsUnacknowledgedMigrations(t *testing.T) {
+	cfg := config.Default()
+	cfg.Network.MainServerEndpoints = nil
+	srv := &Server{
+		cfg:               cfg,
+		logger:            noopLogger(),
+		entities:          entities.NewManager(cfg.Server.ID),
+		dirtyEntities:     make(map[entities.ID]entities.Entity),
+		migrationQueue:    migration.NewQueue(),
+		inFlightTransfers: make(map[entities.ID]migration.Request),
+	}
+	ent := &entities.Entity{ID: "unit-1", Kind: entities.KindUnit}
+	if err := srv.entities.Add(ent); err != nil {
+		t.Fatalf("add entity: %v", err)
+	}
+	ent.SetAttribute("migration_pending", 1)
+	clk := clock.NewManual(time.Unix(0, 0))
+	srv.clock = clk
+	srv.inFlightTransfers[ent.ID] = migration.Request{EntityID: ent.ID, LastAttempt: clk.Now()}
+
+	deadline := clk.Now().Add(5 * drainPollInterval)
+	ctx, cancel := srv.withClockDeadline(context.Background(), deadline)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- srv.drain(ctx) }()
+
+	var err error
+	for drained := false; !drained; {
+		select {
+		case err = <-done:
+			drained = true
+		default:
+			if clk.Now().After(deadline) {
+				t.Fatalf("drain still running at %v, past its deadline %v", clk.Now(), deadline)
+			}
+			clk.Advance(drainPollInterval)
+		}
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("drain() error = %v, want deadline exceeded", err)
+	}
+	if now := clk.Now(); now.Before(deadline) {
+		t.Fatalf("drain gave up at %v, before its deadline %v", now, deadline)
+	}
+	if len(srv.inFlightTransfers) != 0 {
+		t.Fatalf("expected in-flight transfers to be cleared, got %d", len(srv.inFlightTransfers))
+	}
+	if value, _ := ent.Attribute("migration_pending"); value != 0 {
+		t.Fatalf("expected migration_pending to be reset, got %v", value)
+	}
+
+	ack := srv.handleTransferRequest(context.Background(), network.TransferRequest{EntityID: "incoming", State: network.EntityState{ID: "incoming"}})
+	if ack.Accepted {
+		t.Fatalf("expected transfers to be refused while draining")
+	}
+}
+
+func TestDrainWaitsForAcksFromNetworkGoroutines(t *testing.T) {
+	cfg := config.Default()
+	cfg.Network.MainServerEndpoints = nil
+	srv := &Server{
+		cfg:               cfg,
+		logger:            noopLogger(),
+		entities:          entities.NewManager(cfg.Server.ID),
+		dirtyEntities:     make(map[entities.ID]entities.Entity),
+		migrationQueue:    migration.NewQueue(),
+		inFlightTransfers: make(map[entities.ID]migration.Request),
+	}
+	const units = 20
+	for i := 0; i < units; i++ {
+		ent := &entities.Entity{ID: entities.ID(fmt.Sprintf("unit-%d", i)), Kind: entities.KindUnit}
+		if err := srv.entities.Add(ent); err != nil {
+			t.Fatalf("add entity: %v", err)
+		}
+		srv.inFlightTransfers[ent.ID] = migration.Request{EntityID: ent.ID}
+	}
+
+	// Acks arrive on their own goroutines while drain polls the transfers.
+	for i := 0; i < units; i++ {
+		payload, err := json.Marshal(network.TransferAck{EntityID: fmt.Sprintf("unit-%d", i), Accepted: true})
+		if err != nil {
+			t.Fatalf("encode ack: %v", err)
+		}
+		go srv.onTransferAck(context.Background(), nil, network.Envelope{Type: network.MessageTransferAck, Payload: payload})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.drainMigrations(ctx); err != nil {
+		t.Fatalf("drainMigrations() error = %v", err)
+	}
+	if n := srv.entities.CountByKind()[entities.KindUnit]; n != 0 {
+		t.Fatalf("expected every acknowledged unit to leave, %d remain", n)
+	}
+}
+
+func readMessageTypes(t *testing.T, conn net.PacketConn, count int) map[network.MessageType]bool {
+	t.Helper()
+	seen := make(map[network.MessageType]bool)
+	buffer := make([]byte, 65536)
+	for i := 0; i < count; i++ {
+		if err := conn.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+			t.Fatalf("set deadline: %v", err)
+		}
+		n, _, err := conn.ReadFrom(buffer)
+		if err != nil {
+			t.Fatalf("read datagram: %v", err)
+		}
+		env, err := network.Decode(buffer[:n])
+		if err != nil {
+			t.Fatalf("decode datagram: %v", err)
+		}
+		seen[env.Type] = true
+	}
+	return seen
+}
@@ -0,0 +1,348 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"chunkserver/internal/clock"
+	"chunkserver/internal/config"
+	"chunkserver/internal/entities"
+	"chunkserver/internal/environment"
+	"chunkserver/internal/network"
+	"chunkserver/internal/world"
+)
+
+func TestStormIncreasesEntityGravityAndDrag(t *testing.T) {
+	env := environment.New(environment.Config{
+		DayLength:          time.Hour,
+		WeatherMinDuration: time.Millisecond,
+		WeatherMaxDuration: time.Millisecond,
+		StormChance:        1,
+		Seed:               7,
+	})
+	region := world.ServerRegion{ChunksX: 1, ChunksY: 1, ChunkDimension: world.Dimensions{Width: 4, Depth: 4, Height: 4}}
+	srv := &Server{
+		env:   env,
+		world: world.NewManager(region, nil),
+	}
+
+	clear := env.CurrentState()
+	if clear.Weather.Kind != environment.WeatherClear {
+		t.Fatalf("expected environment to start clear, got %s", clear.Weather.Kind)
+	}
+
+	storm := srv.stepEnvironment(10 * time.Millisecond)
+	if storm.Weather.Kind != environment.WeatherStorm {
+		t.Fatalf("expected storm after weather roll, got %s", storm.Weather.Kind)
+	}
+
+	base := entityPhysics(config.Default().Physics)
+	clearPhysics := environmentPhysics(base, clear)
+	stormPhysics := environmentPhysics(base, storm)
+	if stormPhysics.Gravity <= clearPhysics.Gravity {
+		t.Fatalf("expected storm gravity %.3f to exceed clear gravity %.3f", stormPhysics.Gravity, clearPhysics.Gravity)
+	}
+	if stormPhysics.AirDrag <= clearPhysics.AirDrag {
+		t.Fatalf("expected storm drag %.3f to exceed clear drag %.3f", stormPhysics.AirDrag, clearPhysics.AirDrag)
+	}
+
+	if got := srv.EnvironmentState(); got.Weather.Kind != environment.WeatherStorm {
+		t.Fatalf("expected server to record storm state, got %s", got.Weather.Kind)
+	}
+	if got := srv.world.Lighting(); got.FogDensity != storm.Lighting.FogDensity {
+		t.Fatalf("expected world lighting fog %.3f, got %.3f", storm.Lighting.FogDensity, got.FogDensity)
+	}
+}
+
+func TestEnvironmentUpdateRoundTripsState(t *testing.T) {
+	env := environment.New(environment.Config{
+		WeatherMinDuration: time.Millisecond,
+		WeatherMaxDuration: time.Millisecond,
+		StormChance:        1,
+		WindBase:           4,
+		WindVariance:       2,
+		Seed:               11,
+	})
+	state := env.Step(50 * time.Millisecond)
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	payload, err := json.Marshal(environmentUpdate("srv-1", state, now))
+	if err != nil {
+		t.Fatalf("marshal environment update: %v", err)
+	}
+	var decoded network.EnvironmentUpdate
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		t.Fatalf("unmarshal environment update: %v", err)
+	}
+
+	if decoded.ServerID != "srv-1" || !decoded.Timestamp.Equal(now) {
+		t.Fatalf("unexpected envelope fields: %+v", decoded)
+	}
+	if decoded.TimeOfDay != state.TimeOfDay || decoded.Phase != string(state.Phase) {
+		t.Fatalf("time of day mismatch: got %.3f/%s want %.3f/%s", decoded.TimeOfDay, decoded.Phase, state.TimeOfDay, state.Phase)
+	}
+	wantWeather := network.WeatherUpdate{
+		Kind:          string(state.Weather.Kind),
+		Intensity:     state.Weather.Intensity,
+		WindSpeed:     state.Weather.WindSpeed,
+		WindDirection: state.Weather.WindDirection,
+		Precipitation: state.Weather.Precipitation,
+	}
+	if decoded.Weather != wantWeather {
+		t.Fatalf("weather mismatch: got %+v want %+v", decoded.Weather, wantWeather)
+	}
+	if decoded.Weather.WindSpeed <= 0 {
+		t.Fatalf("expected wind speed to be broadcast, got %.3f", decoded.Weather.WindSpeed)
+	}
+	wantLighting := network.LightingUpdate{
+		Ambient:     state.Lighting.Ambient,
+		SunAngle:    state.Lighting.SunAngle,
+		FogDensity:  state.Lighting.FogDensity,
+		WeatherTint: state.Lighting.WeatherTint,
+	}
+	if decoded.Lighting != wantLighting {
+		t.Fatalf("lighting mismatch: got %+v want %+v", decoded.Lighting, wantLighting)
+	}
+}
+
+func TestEnvironmentBroadcastFollowsStateStreamRate(t *testing.T) {
+	receiver, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("listen receiver: %v", err)
+	}
+	defer receiver.Close()
+
+	netSrv, err := network.Listen("127.0.0.1:0", noopLogger(), 0)
+	if err != nil {
+		t.Fatalf("listen server: %v", err)
+	}
+
+	const rate = 20 * time.Millisecond
+	clk := clock.NewManual(time.Unix(0, 0))
+	cfg := config.Default()
+	cfg.Server.StateStreamRate = config.Duration(rate)
+	cfg.Server.TickRate = config.Duration(time.Hour)
+	cfg.Entities.EntityTickRate = config.Duration(time.Hour)
+	cfg.Network.DiscoveryInterval = 0
+	cfg.Network.MainServerEndpoints = []string{receiver.LocalAddr().String()}
+
+	srv := &Server{
+		cfg:      cfg,
+		net:      netSrv,
+		logger:   noopLogger(),
+		clock:    clk,
+		env:      environment.New(convertEnvironmentConfig(cfg.Environment)),
+		entities: entities.NewManager(cfg.Server.ID),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = srv.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// The movement engine, state and entity tickers; the idle chunk sweep
+	// stays off.
+	deadline := time.Now().Add(5 * time.Second)
+	for clk.Tickers() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("run loop started %d tickers, want 3", clk.Tickers())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// broadcasts counts the environment updates that arrive before the
+	// receiver has been quiet for a while.
+	buf := make([]byte, 64*1024)
+	broadcasts := func() int {
+		count := 0
+		for {
+			receiver.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+			n, _, err := receiver.ReadFromUDP(buf)
+			if err != nil {
+				return count
+			}
+			env, err := network.Decode(buf[:n])
+			if err != nil {
+				t.Fatalf("decode envelope: %v", err)
+			}
+			if env.Type == network.MessageEnvironment {
+				count++
+			}
+		}
+	}
+	broadcasts()
+
+	for step := 1; step <= 3; step++ {
+		clk.Advance(rate / 2)
+		if got := broadcasts(); got != 0 {
+			t.Fatalf("step %d: %d environment broadcasts half way to the next state tick, want 0", step, got)
+		}
+		clk.Advance(rate / 2)
+		if got := broadcasts(); got != 1 {
+			t.Fatalf("step %d: %d environment broadcasts for one state tick, want 1", step, got)
+		}
+	}
+}
+
+func TestProjectileDriftsWithCrosswind(t *testing.T) {
+	fly := func(weather environment.WeatherState) entities.Vec3 {
+		ent := &entities.Entity{
+			ID:       "shell",
+			Kind:     entities.KindProjectile,
+			Position: entities.Vec3{Z: 100},
+			Velocity: entities.Vec3{X: 30},
+		}
+		state := environment.State{Weather: weather}
+		physics := environmentPhysics(entityPhysics(config.Default().Physics), state)
+		for i := 0; i < 40; i++ {
+			stepProjectile(ent, 50*time.Millisecond, physics, state)
+		}
+		return ent.PositionVec()
+	}
+
+	calm := fly(environment.WeatherState{Kind: environment.WeatherClear})
+	gusty := fly(environment.WeatherState{
+		Kind:          environment.WeatherStorm,
+		Intensity:     1,
+		WindSpeed:     20,
+		WindDirection: math.Pi / 2,
+	})
+
+	breezy := fly(environment.WeatherState{
+		Kind:          environment.WeatherStorm,
+		Intensity:     0.5,
+		WindSpeed:     20,
+		WindDirection: math.Pi / 2,
+	})
+	still := fly(environment.WeatherState{
+		Kind:          environment.WeatherClear,
+		WindSpeed:     20,
+		WindDirection: math.Pi / 2,
+	})
+
+	if math.Abs(calm.Y) > 1e-9 || math.Abs(still.Y) > 1e-9 {
+		t.Fatalf("expected no lateral drift without wind or weather, got %.3f and %.3f", calm.Y, still.Y)
+	}
+	if math.Abs(breezy.Y-gusty.Y/2) > 1e-6 {
+		t.Fatalf("expected half the intensity to drift half as far, got %.3f vs %.3f", breezy.Y, gusty.Y)
+	}
+	if gusty.Y < 5 {
+		t.Fatalf("expected crosswind to push projectile along +Y, got %.3f", gusty.Y)
+	}
+	if gusty.Z != calm.Z {
+		t.Fatalf("expected wind to leave vertical flight untouched, got z %.3f vs %.3f", gusty.Z, calm.Z)
+	}
+}
+
+func TestChunkWeatherReportsOverrideOnlyInsideZone(t *testing.T) {
+	cfg := config.Default()
+	cfg.Environment.WeatherOverrides = []config.WeatherOverrideConfig{{
+		ID:        "squall",
+		MinChunk:  config.ChunkIndex{X: 0, Y: 0},
+		MaxChunk:  config.ChunkIndex{X: 3, Y: 3},
+		Kind:      "storm",
+		Intensity: 0.8,
+		WindSpeed: 12,
+	}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	srv := &Server{env: environment.New(convertEnvironmentConfig(cfg.Environment))}
+
+	inside := srv.chunkWeather(world.ChunkCoord{X: 1, Y: 2})
+	if inside == nil || inside.Kind != "storm" || inside.Intensity != 0.8 {
+		t.Fatalf("expected storm override inside the zone, got %+v", inside)
+	}
+	if outside := srv.chunkWeather(world.ChunkCoord{X: 8, Y: 8}); outside != nil {
+		t.Fatalf("expected outside chunk to follow global weather, got %+v", outside)
+	}
+}
+
+func TestManualClockDrivesWeatherThroughTickLoop(t *testing.T) {
+	clk := clock.NewManual(time.Unix(0, 0))
+	srv := newExplosionTestServer(t)
+	srv.clock = clk
+	srv.env = environment.New(environment.Config{
+		DayLength:          time.Hour,
+		WeatherMinDuration: time.Second,
+		WeatherMaxDuration: time.Second,
+		WindBase:           1,
+		Seed:               3,
+		Clock:              clk,
+	})
+	unit := addUnit(t, srv, "scout", entities.Vec3{X: 2, Y: 2, Z: 3})
+	initial := srv.env.CurrentState().Weather
+
+	ctx, cancel := context.WithCancel(context.Background())
+	engine := newMovementEngine(srv, 100*time.Millisecond, 1, clk)
+	engine.Start(ctx)
+	defer func() {
+		cancel()
+		engine.Wait()
+	}()
+
+	clk.Advance(900 * time.Millisecond)
+	if weather := srv.env.CurrentState().Weather; weather != initial {
+		t.Fatalf("weather changed before its period ended: %+v", weather)
+	}
+	clk.Advance(100 * time.Millisecond)
+	// Stopping the engine waits for the last tick to finish.
+	cancel()
+	engine.Wait()
+
+	if weather := srv.EnvironmentState().Weather; weather == initial {
+		t.Fatalf("expected new weather after one period, still %+v", weather)
+	}
+	if tick := unit.Snapshot().LastTick; !tick.Equal(time.Unix(1, 0)) {
+		t.Fatalf("unit last ticked at %v, want the clock's %v", tick, time.Unix(1, 0))
+	}
+}
+
+func TestConfiguredGravitySetsFallSpeed(t *testing.T) {
+	fall := func(cfg *config.Config) float64 {
+		srv := newExplosionTestServer(t)
+		srv.cfg = cfg
+		srv.physics = entityPhysics(cfg.Physics)
+		ent := addUnit(t, srv, "faller", entities.Vec3{X: 4, Y: 4, Z: 6})
+		srv.tickEntities(100*time.Millisecond, 1)
+		return ent.Snapshot().Velocity.Z
+	}
+
+	normal := fall(config.Default())
+	heavy := config.Default()
+	heavy.Physics.Gravity *= 2
+	doubled := fall(heavy)
+	if normal >= 0 {
+		t.Fatalf("expected the unit to start falling, got vertical velocity %.3f", normal)
+	}
+	if ratio := doubled / normal; math.Abs(ratio-2) > 0.01 {
+		t.Fatalf("fall speed with doubled gravity = %.3f, want about twice the default %.3f", doubled, normal)
+	}
+}
+
+func TestEntityPhysicsDefaultsWhenUnconfigured(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"server": {"id": "test"}, "physics": {"supportFactor": 2}}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	want := entities.PhysicsParams{Gravity: 9.8, AirDrag: 0.4, GroundFriction: 4, MaxFallSpeed: 150, SupportsGravity: true}
+	if got := entityPhysics(cfg.Physics); got != want {
+		t.Fatalf("entity physics = %+v, want the built-in %+v", got, want)
+	}
+}
@@ -0,0 +1,286 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"chunkserver/internal/config"
+	"chunkserver/internal/entities"
+	"chunkserver/internal/migration"
+	"chunkserver/internal/network"
+	"chunkserver/internal/world"
+)
+
+func newExplosionTestServer(t *testing.T) *Server {
+	t.Helper()
+	region := world.ServerRegion{
+		ChunksX:        2,
+		ChunksY:        2,
+		ChunkDimension: world.Dimensions{Width: 8, Depth: 8, Height: 8},
+	}
+	cfg := config.Default()
+	return &Server{
+		cfg:           cfg,
+		logger:        noopLogger(),
+		world:         world.NewManager(region, stubGenerator{}),
+		entities:      entities.NewManager(cfg.Server.ID),
+		dirtyEntities: make(map[entities.ID]entities.Entity),
+		deltaBuffer:   newDeltaAccumulator(),
+		dirtyChunks:   make(map[world.ChunkCoord]struct{}),
+		physics:       entityPhysics(cfg.Physics),
+	}
+}
+
+func addUnit(t *testing.T, srv *Server, id entities.ID, pos entities.Vec3) *entities.Entity {
+	t.Helper()
+	ent := &entities.Entity{
+		ID:       id,
+		Kind:     entities.KindUnit,
+		Position: pos,
+		Stats:    entities.Stats{MaxHP: 500, CurrentHP: 500},
+	}
+	if err := srv.entities.Add(ent); err != nil {
+		t.Fatalf("add %s: %v", id, err)
+	}
+	return ent
+}
+
+func TestProjectileImpactDamagesEntitiesInOpenAir(t *testing.T) {
+	srv := newExplosionTestServer(t)
+	near := addUnit(t, srv, "near", entities.Vec3{X: 3, Y: 4, Z: 5})
+	mid := addUnit(t, srv, "mid", entities.Vec3{X: 2, Y: 4, Z: 5})
+	outside := addUnit(t, srv, "outside", entities.Vec3{X: 4, Y: 4, Z: 1})
+
+	shell := &entities.Entity{
+		ID:       "shell",
+		Kind:     entities.KindProjectile,
+		Position: entities.Vec3{X: 4, Y: 4, Z: 5},
+	}
+	shell.SetAttribute("explosion_radius", 4)
+	shell.SetAttribute("explosion_damage", 100)
+	if err := srv.entities.Add(shell); err != nil {
+		t.Fatalf("add shell: %v", err)
+	}
+	srv.handleProjectileImpact(shell)
+
+	if got := near.Stats.CurrentHP; got != 425 {
+		t.Fatalf("unit one block from the blast has %v hp, want 425", got)
+	}
+	if got := mid.Stats.CurrentHP; got != 450 {
+		t.Fatalf("unit at half radius has %v hp, want 450", got)
+	}
+	if got := outside.Stats.CurrentHP; got != 500 {
+		t.Fatalf("unit outside the radius took damage, hp %v", got)
+	}
+	if shell.Stats.CurrentHP != 0 || shell.Dying {
+		t.Fatalf("detonating projectile damaged itself")
+	}
+	if _, ok := srv.dirtyEntities["near"]; !ok {
+		t.Fatalf("expected damaged unit to be recorded dirty")
+	}
+}
+
+func TestExplosionDamageIsNotDoubleCounted(t *testing.T) {
+	srv := newExplosionTestServer(t)
+	unit := addUnit(t, srv, "unit", entities.Vec3{X: 2, Y: 2, Z: 1})
+
+	summary := world.NewDamageSummary()
+	summary.AddChange(world.BlockChange{
+		Coord:  world.BlockCoord{X: 2, Y: 2, Z: 2},
+		Before: world.Block{Type: world.BlockSolid},
+		After:  world.Block{Type: world.BlockAir},
+		Reason: world.ReasonCollapse,
+	})
+	// Blast: 100 * (1 - 2/4) = 50. Collapse one block away: 45 * (1 - 1/3.5).
+	srv.damageEntitiesFromExplosion(&entities.Entity{ID: "shell"}, entities.Vec3{X: 4, Y: 2, Z: 1}, 4, 100, world.FalloffLinear, summary)
+
+	if got := unit.Stats.CurrentHP; got != 450 {
+		t.Fatalf("unit hit by blast and collapse has %v hp, want 450", got)
+	}
+}
+
+func TestProjectileSparesFirerAndSquad(t *testing.T) {
+	srv := newExplosionTestServer(t)
+	firer := addUnit(t, srv, "firer", entities.Vec3{X: 3, Y: 4, Z: 5})
+	squadmate := addUnit(t, srv, "squadmate", entities.Vec3{X: 5, Y: 4, Z: 5})
+	enemy := addUnit(t, srv, "enemy", entities.Vec3{X: 4, Y: 3, Z: 5})
+	firer.CollisionGroup = "alpha"
+	squadmate.CollisionGroup = "alpha"
+	enemy.CollisionGroup = "bravo"
+
+	shell := &entities.Entity{
+		ID:       "shell",
+		Kind:     entities.KindProjectile,
+		Position: entities.Vec3{X: 4, Y: 4, Z: 5},
+		Owner:    firer.ID,
+		// fireWeapons puts shots in their firer's group.
+		CollisionGroup: firer.CollisionGroup,
+	}
+	shell.SetAttribute("explosion_radius", 4)
+	shell.SetAttribute("explosion_damage", 100)
+	if err := srv.entities.Add(shell); err != nil {
+		t.Fatalf("add shell: %v", err)
+	}
+	srv.handleProjectileImpact(shell)
+
+	if got := firer.Stats.CurrentHP; got != 500 {
+		t.Fatalf("firer took damage from its own shot, hp %v", got)
+	}
+	if got := enemy.Stats.CurrentHP; got != 425 {
+		t.Fatalf("enemy one block from the blast has %v hp, want 425", got)
+	}
+	if got := squadmate.Stats.CurrentHP; got != 500 {
+		t.Fatalf("squadmate took damage from a friendly shot, hp %v", got)
+	}
+}
+
+func TestExplosionSparesEntitiesBehindCover(t *testing.T) {
+	srv := newExplosionTestServer(t)
+	exposed := addUnit(t, srv, "exposed", entities.Vec3{X: 4.5, Y: 6.5, Z: 5.5})
+	covered := addUnit(t, srv, "covered", entities.Vec3{X: 4.5, Y: 2.5, Z: 5.5})
+
+	chunk, err := srv.world.Chunk(context.Background(), world.ChunkCoord{})
+	if err != nil {
+		t.Fatalf("load chunk: %v", err)
+	}
+	// A wall at y=3 stands between the blast and the covered unit. The
+	// blast is too weak to break it.
+	for x := 0; x < 8; x++ {
+		for z := 0; z < 8; z++ {
+			chunk.SetLocalBlock(x, 3, z, world.Block{Type: world.BlockSolid, HitPoints: 1000, MaxHitPoints: 1000})
+		}
+	}
+
+	srv.damageEntitiesFromExplosion(&entities.Entity{ID: "shell"}, entities.Vec3{X: 4.5, Y: 4.5, Z: 5.5}, 4, 100, world.FalloffLinear, world.NewDamageSummary())
+
+	if got := exposed.Stats.CurrentHP; got != 450 {
+		t.Fatalf("exposed unit at half radius has %v hp, want 450", got)
+	}
+	if got := covered.Stats.CurrentHP; got != 500 {
+		t.Fatalf("unit behind the wall took blast damage, hp %v", got)
+	}
+}
+
+func TestExplosionDestroysBlocksFacingTheBlast(t *testing.T) {
+	srv := newExplosionTestServer(t)
+	unit := addUnit(t, srv, "unit", entities.Vec3{X: 4, Y: 4, Z: 5})
+	unit.Capabilities = entities.Capabilities{CanFly: true, ProjectileVelocity: 20}
+	unit.Blocks = []entities.EntityBlock{
+		{Role: entities.BlockRoleWeapon, Offset: entities.Vec3{X: -20}, Block: world.Block{MaxHitPoints: 30}},
+		{Role: entities.BlockRoleStructure, Block: world.Block{MaxHitPoints: 30}},
+		{Role: entities.BlockRoleThruster, Offset: entities.Vec3{X: 20}, Block: world.Block{MaxHitPoints: 30}},
+	}
+	unit.Stats.BlockHP = []float64{30, 30, 30}
+	unit.SetAttribute("target_x", 12)
+	unit.SetAttribute("target_y", 4)
+	unit.SetAttribute("target_z", 5)
+
+	// 100 * (1 - 2/4) = 50: the weapon facing the blast absorbs 30 and the
+	// structure behind it the other 20.
+	srv.damageEntitiesFromExplosion(&entities.Entity{ID: "shell"}, entities.Vec3{X: 2, Y: 4, Z: 5}, 4, 100, world.FalloffLinear, world.NewDamageSummary())
+
+	if got := unit.Snapshot().Stats.BlockHP; got[0] != 0 || got[1] != 10 || got[2] != 30 {
+		t.Fatalf("block hit points after the blast = %v, want [0 10 30]", got)
+	}
+	if got := unit.Stats.CurrentHP; got != 450 {
+		t.Fatalf("unit has %v hp, want 450", got)
+	}
+	if !unit.RoleDestroyed(entities.BlockRoleWeapon) || unit.RoleDestroyed(entities.BlockRoleThruster) {
+		t.Fatalf("expected only the facing weapon to be destroyed")
+	}
+
+	srv.dirtyEntities = make(map[entities.ID]entities.Entity)
+	srv.tickEntities(100*time.Millisecond, 1)
+	if shots := firedProjectiles(srv); len(shots) != 0 {
+		t.Fatalf("destroyed weapon fired %d projectiles", len(shots))
+	}
+	if vel := unit.Snapshot().Velocity; vel.Z != 0 {
+		t.Fatalf("expected the surviving thruster to hold altitude, got vertical velocity %v", vel.Z)
+	}
+}
+
+func TestBlocksWithoutHitPointsKeepWorking(t *testing.T) {
+	srv := newExplosionTestServer(t)
+	unit := &entities.Entity{
+		ID:           "unit",
+		Kind:         entities.KindUnit,
+		Position:     entities.Vec3{X: 4, Y: 4, Z: 5},
+		Stats:        entities.Stats{MaxHP: 500, CurrentHP: 500},
+		Capabilities: entities.Capabilities{CanFly: true, ProjectileVelocity: 20},
+		Blocks: []entities.EntityBlock{
+			{Role: entities.BlockRoleWeapon, Offset: entities.Vec3{X: -20}},
+			{Role: entities.BlockRoleThruster, Offset: entities.Vec3{X: 20}},
+		},
+	}
+	if err := srv.entities.Add(unit); err != nil {
+		t.Fatalf("add unit: %v", err)
+	}
+	unit.SetAttribute("target_x", 12)
+	unit.SetAttribute("target_y", 4)
+	unit.SetAttribute("target_z", 5)
+
+	srv.tickEntities(100*time.Millisecond, 1)
+	if shots := firedProjectiles(srv); len(shots) != 1 {
+		t.Fatalf("expected the weapon to fire once, got %d projectiles", len(shots))
+	}
+	if vel := unit.Snapshot().Velocity; vel.Z != 0 {
+		t.Fatalf("expected the thruster to hold altitude, got vertical velocity %v", vel.Z)
+	}
+}
+
+func TestBlockDamageSurvivesMigration(t *testing.T) {
+	srv := newExplosionTestServer(t)
+	// Entities arrive with only their blocks; adding them fills in each
+	// block's hit points.
+	unit := &entities.Entity{
+		ID:       "unit",
+		Kind:     entities.KindUnit,
+		Position: entities.Vec3{X: 4, Y: 4, Z: 5},
+		Stats:    entities.Stats{MaxHP: 500, CurrentHP: 500},
+		Blocks: []entities.EntityBlock{
+			{Role: entities.BlockRoleWeapon, Offset: entities.Vec3{X: -20}, Block: world.Block{Type: world.BlockSolid, Material: "steel", MaxHitPoints: 30}},
+			{Role: entities.BlockRoleStructure, Block: world.Block{Type: world.BlockSolid, Material: "steel", HitPoints: 25, MaxHitPoints: 30}},
+		},
+	}
+	if err := srv.entities.Add(unit); err != nil {
+		t.Fatalf("add unit: %v", err)
+	}
+	if got := unit.Snapshot().Stats.BlockHP; len(got) != 2 || got[0] != 30 || got[1] != 25 {
+		t.Fatalf("block hit points after adding = %v, want [30 25]", got)
+	}
+
+	// 80 * (1 - 2/4) = 40: the facing weapon absorbs 30 and the structure 10.
+	srv.damageEntitiesFromExplosion(&entities.Entity{ID: "shell"}, entities.Vec3{X: 2, Y: 4, Z: 5}, 4, 80, world.FalloffLinear, world.NewDamageSummary())
+	if !unit.RoleDestroyed(entities.BlockRoleWeapon) {
+		t.Fatalf("expected the weapon facing the blast to be destroyed")
+	}
+
+	if update := serializeEntity(unit.Snapshot()); update.Blocks != nil || len(update.BlockHP) != 2 || update.BlockHP[1] != 15 {
+		t.Fatalf("expected updates to carry only block hit points, got blocks %v and hit points %v", update.Blocks, update.BlockHP)
+	}
+
+	payload, err := json.Marshal(transferEntityState(&migration.Request{EntitySnapshot: unit.Snapshot()}))
+	if err != nil {
+		t.Fatalf("marshal state: %v", err)
+	}
+	var state network.EntityState
+	if err := json.Unmarshal(payload, &state); err != nil {
+		t.Fatalf("decode state: %v", err)
+	}
+	arrived, err := srv.buildEntityFromState(state, world.ChunkCoord{X: 1, Y: 0})
+	if err != nil {
+		t.Fatalf("build entity: %v", err)
+	}
+	if got := arrived.Stats.BlockHP; len(got) != 2 || got[0] != 0 || got[1] != 15 {
+		t.Fatalf("block hit points after migration = %v, want [0 15]", got)
+	}
+	if len(arrived.Blocks) != 2 || arrived.Blocks[0].Role != entities.BlockRoleWeapon || arrived.Blocks[0].Offset.X != -20 ||
+		arrived.Blocks[1].Block.Material != "steel" || arrived.Blocks[1].Block.MaxHitPoints != 30 {
+		t.Fatalf("blocks after migration = %+v", arrived.Blocks)
+	}
+	if !arrived.RoleDestroyed(entities.BlockRoleWeapon) || arrived.RoleDestroyed(entities.BlockRoleStructure) {
+		t.Fatalf("expected only the weapon to stay destroyed after migration")
+	}
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"time"
+
+	"chunkserver/internal/world"
+)
+
+// idleChunkSweepInterval is how often the run loop looks for idle chunks, so
+// a chunk is unloaded between one and one and a half TTLs after its last use.
+func idleChunkSweepInterval(ttl time.Duration) time.Duration {
+	return ttl / 2
+}
+
+// unloadIdleChunks snapshots and unloads the resident chunks that no entity
+// occupies and that have not been read for server.chunkIdleTTL. A zero TTL
+// keeps every chunk resident.
+func (s *Server) unloadIdleChunks() {
+	ttl := s.currentConfig().Server.ChunkIdleTTL.Duration()
+	if ttl <= 0 || s.world == nil {
+		return
+	}
+	occupied := make(map[world.ChunkCoord]struct{})
+	for _, coord := range s.entities.ActiveChunks() {
+		occupied[coord] = struct{}{}
+	}
+	unloaded, err := s.world.UnloadIdle(ttl, func(coord world.ChunkCoord) bool {
+		_, ok := occupied[coord]
+		return ok
+	})
+	if err != nil {
+		s.logger.Warnf("unload idle chunks: %v", err)
+	}
+	if unloaded > 0 {
+		s.logger.Debugf("unloaded %d idle chunks", unloaded)
+	}
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chunkserver/internal/clock"
+	"chunkserver/internal/config"
+	"chunkserver/internal/entities"
+	"chunkserver/internal/world"
+)
+
+func TestUnloadIdleChunksKeepsChunksWithEntities(t *testing.T) {
+	srv := newExplosionTestServer(t)
+	srv.cfg.Server.ChunkIdleTTL = config.Duration(time.Minute)
+	srv.world.SetStorageProvider(world.NewDiskStorageProvider(t.TempDir(), srv.world.Region()))
+	clk := clock.NewManual(time.Unix(1000, 0))
+	srv.world.SetClock(clk)
+
+	occupied := world.ChunkCoord{X: 0, Y: 0}
+	empty := world.ChunkCoord{X: 1, Y: 0}
+	for _, coord := range []world.ChunkCoord{occupied, empty} {
+		if _, err := srv.world.Chunk(context.Background(), coord); err != nil {
+			t.Fatalf("load chunk %v: %v", coord, err)
+		}
+	}
+	addUnit(t, srv, "resident", entities.Vec3{X: 3, Y: 4, Z: 5})
+
+	clk.Advance(2 * time.Minute)
+	srv.unloadIdleChunks()
+
+	if resident, _ := srv.world.ChunkCounts(); resident != 1 {
+		t.Fatalf("%d chunks resident after the sweep, want only the occupied one", resident)
+	}
+	if _, ready, err := srv.world.ChunkIfReady(occupied); err != nil || !ready {
+		t.Fatalf("occupied chunk was unloaded (ready=%v, err=%v)", ready, err)
+	}
+}
@@ -0,0 +1,25 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// TestMain runs the package's tests from a temporary directory so chunk
+// previews written by the manager, including ones from generations that
+// outlive their test, never land in the source tree.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "server-test-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "create test directory: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.Chdir(dir); err != nil {
+		fmt.Fprintf(os.Stderr, "chdir to test directory: %v\n", err)
+		os.Exit(1)
+	}
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
@@ -1,13 +1,22 @@
 package server
 
 import (
-	"log"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"strings"
 	"testing"
 	"time"
 
+	"chunkserver/internal/clock"
 	"chunkserver/internal/config"
 	"chunkserver/internal/entities"
+	"chunkserver/internal/environment"
+	"chunkserver/internal/logging"
 	"chunkserver/internal/migration"
+	"chunkserver/internal/network"
+	"chunkserver/internal/world"
 )
 
 func TestRetryStaleTransfers(t *testing.T) {
@@ -68,10 +77,244 @@ func TestRetryStaleTransfers(t *testing.T) {
 	}
 }
 
-type noWriter struct{}
+func TestMigrationRetryLogsStructuredWarning(t *testing.T) {
+	var out bytes.Buffer
+	logger := logging.New(&out, slog.LevelInfo)
+	srv := &Server{
+		cfg: &config.Config{
+			Network: config.NetworkConfig{
+				TransferRetry: config.Duration(2 * time.Second),
+			},
+		},
+		migrationQueue:    migration.NewQueue(),
+		inFlightTransfers: make(map[entities.ID]migration.Request),
+		logger:            logger,
+	}
+	now := time.Now()
+	stale := func(id entities.ID) {
+		srv.inFlightTransfers[id] = migration.Request{
+			EntityID:     id,
+			TargetServer: "east",
+			LastAttempt:  now.Add(-5 * time.Second),
+		}
+	}
+
+	stale("unit-1")
+	srv.retryStaleTransfers(now)
+	line := out.String()
+	for _, want := range []string{"level=WARN", `msg="migration timed out, retrying"`, "entity=unit-1", "neighbor=east"} {
+		if !strings.Contains(line, want) {
+			t.Fatalf("expected %q in log output, got:\n%s", want, line)
+		}
+	}
+
+	out.Reset()
+	logger.SetLevel(slog.LevelError)
+	stale("unit-2")
+	srv.retryStaleTransfers(now)
+	if out.Len() != 0 {
+		t.Fatalf("expected warnings to be dropped at error level, got:\n%s", out.String())
+	}
+}
+
+func TestManualClockTimesOutMigration(t *testing.T) {
+	clk := clock.NewManual(time.Unix(0, 0))
+	srv := newExplosionTestServer(t)
+	srv.clock = clk
+	srv.cfg.Network.TransferRetry = config.Duration(2 * time.Second)
+	srv.migrationQueue = migration.NewQueue()
+	srv.inFlightTransfers = make(map[entities.ID]migration.Request)
+	netSrv, err := network.Listen("127.0.0.1:0", noopLogger(), 0)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer netSrv.Close()
+	srv.net = netSrv
+	neighbor, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen neighbor: %v", err)
+	}
+	defer neighbor.Close()
+
+	unit := addUnit(t, srv, "unit-1", entities.Vec3{X: 1, Y: 1, Z: 1})
+	srv.migrationQueue.Enqueue(migration.Request{
+		EntityID:       unit.ID,
+		TargetServer:   "east",
+		TargetEndpoint: neighbor.LocalAddr().String(),
+		QueuedAt:       clk.Now(),
+	})
+	readRequest := func() network.TransferRequest {
+		t.Helper()
+		buffer := make([]byte, 65536)
+		neighbor.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := neighbor.ReadFrom(buffer)
+		if err != nil {
+			t.Fatalf("read transfer request: %v", err)
+		}
+		env, err := network.Decode(buffer[:n])
+		if err != nil {
+			t.Fatalf("decode envelope: %v", err)
+		}
+		var req network.TransferRequest
+		if err := json.Unmarshal(env.Payload, &req); err != nil {
+			t.Fatalf("decode transfer request: %v", err)
+		}
+		return req
+	}
+
+	srv.processMigrationQueue()
+	first := readRequest()
+	if !first.Timestamp.Equal(time.Unix(0, 0)) {
+		t.Fatalf("request stamped %v, want the clock's %v", first.Timestamp, time.Unix(0, 0))
+	}
+
+	clk.Advance(1500 * time.Millisecond)
+	srv.processMigrationQueue()
+	if inFlight, ok := srv.inFlightTransfers[unit.ID]; !ok || inFlight.Nonce != first.Nonce {
+		t.Fatalf("expected the first attempt still in flight before the retry interval, got %+v", inFlight)
+	}
+
+	clk.Advance(500 * time.Millisecond)
+	srv.processMigrationQueue()
+	retry := readRequest()
+	if retry.Nonce == first.Nonce {
+		t.Fatalf("expected the retry to use a new nonce, both were %d", retry.Nonce)
+	}
+	if !retry.Timestamp.Equal(time.Unix(2, 0)) {
+		t.Fatalf("retry stamped %v, want %v", retry.Timestamp, time.Unix(2, 0))
+	}
+}
+
+func TestFailingMigrationIsDeadLetteredAndEntityResumes(t *testing.T) {
+	clk := clock.NewManual(time.Unix(0, 0))
+	srv := newExplosionTestServer(t)
+	srv.clock = clk
+	srv.cfg.Network.TransferRetry = config.Duration(2 * time.Second)
+	srv.migrationQueue = migration.NewQueue()
+	srv.migrationQueue.SetMaxAttempts(2)
+	srv.inFlightTransfers = make(map[entities.ID]migration.Request)
+	netSrv, err := network.Listen("127.0.0.1:0", noopLogger(), 0)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer netSrv.Close()
+	srv.net = netSrv
+	// Nothing answers on the neighbor's endpoint, so every attempt times out.
+	neighbor, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen neighbor: %v", err)
+	}
+	defer neighbor.Close()
+
+	unit := addUnit(t, srv, "unit-1", entities.Vec3{X: 1, Y: 1, Z: 1})
+	unit.SetAttribute("migration_pending", 1)
+	srv.migrationQueue.Enqueue(migration.Request{
+		EntityID:       unit.ID,
+		TargetServer:   "east",
+		TargetEndpoint: neighbor.LocalAddr().String(),
+		QueuedAt:       clk.Now(),
+	})
+
+	srv.processMigrationQueue()
+	clk.Advance(2 * time.Second)
+	srv.processMigrationQueue()
+	if inFlight, ok := srv.inFlightTransfers[unit.ID]; !ok || inFlight.Attempts != 2 {
+		t.Fatalf("expected the second attempt in flight, got %+v", inFlight)
+	}
+	if srv.migrationQueue.DeadLetterCount() != 0 {
+		t.Fatalf("dead-lettered before the attempts ran out")
+	}
+
+	clk.Advance(2 * time.Second)
+	srv.processMigrationQueue()
+	if srv.migrationQueue.DeadLetterCount() != 1 {
+		t.Fatalf("expected the migration dead-lettered after 2 attempts, got %d", srv.migrationQueue.DeadLetterCount())
+	}
+	if _, ok := srv.inFlightTransfers[unit.ID]; ok || srv.migrationQueue.Len() != 0 {
+		t.Fatalf("expected no further attempts, %d queued", srv.migrationQueue.Len())
+	}
+	if value, _ := unit.Attribute("migration_pending"); value != 0 {
+		t.Fatalf("expected the entity unfrozen, migration_pending is %v", value)
+	}
+	if stats := srv.Stats(); stats.MigrationDeadLetters != 1 {
+		t.Fatalf("stats report %d dead letters, want 1", stats.MigrationDeadLetters)
+	}
+}
+
+func TestDeadLetteredMigrationIsNotQueuedAgain(t *testing.T) {
+	clk := clock.NewManual(time.Unix(0, 0))
+	srv := newExplosionTestServer(t)
+	srv.clock = clk
+	srv.cfg.Network.TransferRetry = config.Duration(2 * time.Second)
+	srv.migrationQueue = migration.NewQueue()
+	srv.migrationQueue.SetMaxAttempts(1)
+	srv.inFlightTransfers = make(map[entities.ID]migration.Request)
+	netSrv, err := network.Listen("127.0.0.1:0", noopLogger(), 0)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer netSrv.Close()
+	srv.net = netSrv
+	neighbor, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen neighbor: %v", err)
+	}
+	defer neighbor.Close()
+	srv.neighbors = newNeighborManager(srv.world.Region(), nil)
+	srv.neighbors.updateFromHello(neighbor.LocalAddr().String(), "", "east", world.ChunkCoord{X: 2, Y: 0}, 2, 2, clk.Now())
+
+	// The region spans blocks 0-15 along X; the unit has wandered into the
+	// east neighbor's first chunk.
+	unit := addUnit(t, srv, "unit-1", entities.Vec3{X: 15, Y: 4, Z: 1})
+	unit.SetPosition(entities.Vec3{X: 17, Y: 4, Z: 1})
+	unit.SetVelocity(entities.Vec3{X: 5})
+	srv.updateEntityChunk(unit)
+	if srv.migrationQueue.Len() != 1 {
+		t.Fatalf("expected the boundary exit to queue a migration, %d queued", srv.migrationQueue.Len())
+	}
+
+	srv.processMigrationQueue()
+	clk.Advance(2 * time.Second)
+	srv.processMigrationQueue()
+	if srv.migrationQueue.DeadLetterCount() != 1 {
+		t.Fatalf("expected the migration dead-lettered, got %d", srv.migrationQueue.DeadLetterCount())
+	}
+	if pos := unit.PositionVec(); pos.X > 15 {
+		t.Fatalf("expected the unit parked inside the region, at x=%v", pos.X)
+	}
+
+	for i := 0; i < 5; i++ {
+		srv.tickUnit(unit, 100*time.Millisecond, srv.physics, environment.State{})
+	}
+	if srv.migrationQueue.Len() != 0 {
+		t.Fatalf("expected no migration queued after the dead letter, %d queued", srv.migrationQueue.Len())
+	}
+	if value, _ := unit.Attribute("migration_pending"); value != 0 {
+		t.Fatalf("expected the unit to keep ticking here, migration_pending is %v", value)
+	}
+	if srv.migrationQueue.DeadLetterCount() != 1 {
+		t.Fatalf("expected a single dead letter, got %d", srv.migrationQueue.DeadLetterCount())
+	}
+}
+
+func TestReloadUpdatesTransferMaxAttempts(t *testing.T) {
+	srv := &Server{
+		cfg:            config.Default(),
+		logger:         noopLogger(),
+		migrationQueue: migration.NewQueue(),
+	}
+	next := config.Default()
+	next.Network.TransferMaxAttempts = 1
+	srv.applyReload(next, nil)
 
-func (noWriter) Write(p []byte) (int, error) { return len(p), nil }
+	if got := srv.currentConfig().Network.TransferMaxAttempts; got != 1 {
+		t.Fatalf("expected active transfer max attempts 1, got %d", got)
+	}
+	if srv.migrationQueue.Retry(migration.Request{EntityID: "unit-1", Attempts: 1}) {
+		t.Fatalf("expected the reloaded limit to dead-letter after one attempt")
+	}
+}
 
-func noopLogger() *log.Logger {
-	return log.New(noWriter{}, "", 0)
+func noopLogger() *logging.Logger {
+	return logging.Discard()
 }
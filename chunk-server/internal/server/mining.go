@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"chunkserver/internal/config"
+	"chunkserver/internal/entities"
+	"chunkserver/internal/world"
+)
+
+// miningReach is how far, in blocks from the unit's position to the centre of
+// the block, a miner can work.
+const miningReach = 3.0
+
+// mineBlock works the diggable block held in the unit's "mine_x", "mine_y" and
+// "mine_z" attributes when the unit has a "miner_level". The block loses
+// miningRate hit points per second; when it breaks, its resource yield is
+// deposited in the unit's inventory, and whatever does not fit is lost. A
+// unit with a full inventory stops mining.
+func (s *Server) mineBlock(ent *entities.Entity, delta time.Duration) {
+	level, ok := ent.Attribute("miner_level")
+	if !ok {
+		return
+	}
+	snapshot := ent.Snapshot()
+	if snapshot.Dying || ent.Free() <= 0 {
+		return
+	}
+	target, ok := miningTarget(ent)
+	if !ok {
+		return
+	}
+	dx := float64(target.X) + 0.5 - snapshot.Position.X
+	dy := float64(target.Y) + 0.5 - snapshot.Position.Y
+	dz := float64(target.Z) + 0.5 - snapshot.Position.Z
+	if math.Sqrt(dx*dx+dy*dy+dz*dz) > miningReach {
+		return
+	}
+	damage := miningRate(s.currentConfig().Economy, level) * delta.Seconds()
+	if damage <= 0 {
+		return
+	}
+
+	ctx := context.Background()
+	block, ok := s.blockAt(ctx, make(map[world.ChunkCoord]*world.Chunk, 1), target)
+	if !ok || !block.Type.Properties().Diggable {
+		return
+	}
+	summary, err := s.world.ApplyBlockDamage(ctx, target, damage)
+	if err != nil {
+		s.logger.Warn("mining failed", "entity", snapshot.ID, "block", target, "err", err)
+		return
+	}
+	s.entities.WakeChunks(summary.DirtyChunks()...)
+	s.queueVoxelDeltas(summary)
+	s.markChunksDirty(summary.DirtyChunks())
+
+	for _, change := range summary.Changes() {
+		if change.Coord != target || change.Reason != world.ReasonDestroy {
+			continue
+		}
+		for resource, amount := range change.Before.ResourceYield {
+			ent.Deposit(resource, amount)
+		}
+		s.recordDirtyEntity(ent)
+	}
+}
+
+// miningTarget reads the block a unit is mining.
+func miningTarget(ent *entities.Entity) (world.BlockCoord, bool) {
+	x, okX := ent.Attribute("mine_x")
+	y, okY := ent.Attribute("mine_y")
+	z, okZ := ent.Attribute("mine_z")
+	if !okX || !okY || !okZ {
+		return world.BlockCoord{}, false
+	}
+	return world.BlockCoord{X: int(math.Floor(x)), Y: int(math.Floor(y)), Z: int(math.Floor(z))}, true
+}
+
+// miningRate is the hit points per second a miner of level removes:
+// BaseMiningRate grown by MiningLevelGrowth for every level. A growth of zero
+// or less leaves the rate flat.
+func miningRate(cfg config.EconomyConfig, level float64) float64 {
+	growth := cfg.MiningLevelGrowth
+	if growth <= 0 {
+		growth = 1
+	}
+	return cfg.BaseMiningRate * math.Pow(growth, level)
+}
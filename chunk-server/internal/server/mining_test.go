@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"chunkserver/internal/entities"
+	"chunkserver/internal/migration"
+	"chunkserver/internal/network"
+	"chunkserver/internal/world"
+)
+
+// addMiner places a miner of level beside an iron seam at (3, 3, 0) holding
+// hp hit points.
+func addMiner(t *testing.T, srv *Server, id entities.ID, level, hp float64) *entities.Entity {
+	t.Helper()
+	chunk, err := srv.world.Chunk(context.Background(), world.ChunkCoord{X: 0, Y: 0})
+	if err != nil {
+		t.Fatalf("load chunk: %v", err)
+	}
+	chunk.SetLocalBlock(3, 3, 0, world.Block{
+		Type:          world.BlockMineral,
+		Material:      "iron",
+		HitPoints:     hp,
+		MaxHitPoints:  hp,
+		ResourceYield: map[string]float64{"iron": 4, "stone": 1.5},
+	})
+	miner := addUnit(t, srv, id, entities.Vec3{X: 3.5, Y: 2.5, Z: 1})
+	miner.SetAttribute("miner_level", level)
+	miner.SetAttribute("mine_x", 3)
+	miner.SetAttribute("mine_y", 3)
+	miner.SetAttribute("mine_z", 0)
+	return miner
+}
+
+// secondsToMine ticks the miner one second at a time until its block breaks.
+func secondsToMine(t *testing.T, srv *Server, miner *entities.Entity) int {
+	t.Helper()
+	for seconds := 1; seconds <= 60; seconds++ {
+		srv.mineBlock(miner, time.Second)
+		block, ok := srv.blockAt(context.Background(), map[world.ChunkCoord]*world.Chunk{}, world.BlockCoord{X: 3, Y: 3, Z: 0})
+		if ok && block.Type == world.BlockAir {
+			return seconds
+		}
+	}
+	t.Fatalf("miner %s never broke its block", miner.ID)
+	return 0
+}
+
+func TestHigherLevelMinerBreaksBlocksFaster(t *testing.T) {
+	novice := newExplosionTestServer(t)
+	expert := newExplosionTestServer(t)
+	novice.cfg.Economy.BaseMiningRate = 3
+	novice.cfg.Economy.MiningLevelGrowth = 1.5
+	expert.cfg.Economy = novice.cfg.Economy
+
+	// 30 hit points at 3/s for level 0, and 3*1.5^4 ≈ 15.2/s for level 4.
+	slow := secondsToMine(t, novice, addMiner(t, novice, "novice", 0, 30))
+	fast := secondsToMine(t, expert, addMiner(t, expert, "expert", 4, 30))
+	if slow != 10 || fast != 2 {
+		t.Fatalf("expected 10s at level 0 and 2s at level 4, got %ds and %ds", slow, fast)
+	}
+}
+
+func TestMinerCollectsYieldWhenBlockBreaks(t *testing.T) {
+	srv := newExplosionTestServer(t)
+	srv.cfg.Economy.BaseMiningRate = 10
+	miner := addMiner(t, srv, "miner", 0, 15)
+
+	srv.mineBlock(miner, time.Second)
+	if iron := miner.Held("iron"); iron != 0 {
+		t.Fatalf("expected nothing credited before the block breaks, got %v iron", iron)
+	}
+	srv.mineBlock(miner, time.Second)
+	if iron := miner.Held("iron"); iron != 4 {
+		t.Fatalf("expected 4 iron, got %v", iron)
+	}
+	if stone := miner.Held("stone"); stone != 1.5 {
+		t.Fatalf("expected 1.5 stone, got %v", stone)
+	}
+	if _, ok := srv.dirtyEntities[miner.ID]; !ok {
+		t.Fatal("expected the miner to be sent with its new inventory")
+	}
+
+	// The seam is gone, so further ticks credit nothing.
+	srv.mineBlock(miner, time.Second)
+	if iron := miner.Held("iron"); iron != 4 {
+		t.Fatalf("expected the inventory to stay at 4 iron, got %v", iron)
+	}
+}
+
+func TestMinerOutOfReachLeavesBlock(t *testing.T) {
+	srv := newExplosionTestServer(t)
+	miner := addMiner(t, srv, "miner", 0, 15)
+	miner.Position = entities.Vec3{X: 12, Y: 3, Z: 1}
+
+	srv.mineBlock(miner, time.Second)
+	block, ok := srv.blockAt(context.Background(), map[world.ChunkCoord]*world.Chunk{}, world.BlockCoord{X: 3, Y: 3, Z: 0})
+	if !ok || block.HitPoints != 15 {
+		t.Fatalf("expected the block untouched, got %+v", block)
+	}
+}
+
+func TestInventorySurvivesMigration(t *testing.T) {
+	srv := newExplosionTestServer(t)
+	miner := addUnit(t, srv, "miner", entities.Vec3{X: 1, Y: 1, Z: 1})
+	miner.InventoryCapacity = 20
+	miner.Deposit("iron", 4)
+	miner.Deposit("stone", 1.5)
+
+	payload, err := json.Marshal(transferEntityState(&migration.Request{EntitySnapshot: miner.Snapshot()}))
+	if err != nil {
+		t.Fatalf("marshal state: %v", err)
+	}
+	var state network.EntityState
+	if err := json.Unmarshal(payload, &state); err != nil {
+		t.Fatalf("decode state: %v", err)
+	}
+	arrived, err := srv.buildEntityFromState(state, world.ChunkCoord{X: 1, Y: 0})
+	if err != nil {
+		t.Fatalf("build entity: %v", err)
+	}
+	if arrived.Held("iron") != 4 || arrived.Held("stone") != 1.5 || arrived.InventoryCapacity != 20 {
+		t.Fatalf("inventory after migration = %v with capacity %v", arrived.Inventory, arrived.InventoryCapacity)
+	}
+	if free := arrived.Free(); free != 14.5 {
+		t.Fatalf("expected 14.5 free after migration, got %v", free)
+	}
+}
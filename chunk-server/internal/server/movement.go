@@ -4,6 +4,8 @@ import (
 	"context"
 	"sync"
 	"time"
+
+	"chunkserver/internal/clock"
 )
 
 type entityTicker interface {
@@ -24,49 +26,59 @@ type movementEngine struct {
 }
 
 func defaultTickerFactory() tickerFactory {
+	return clockTickerFactory(clock.Real())
+}
+
+// clockTickerFactory makes the engine's tickers from clk.
+func clockTickerFactory(clk clock.Clock) tickerFactory {
 	return func(d time.Duration) (<-chan time.Time, func()) {
-		ticker := time.NewTicker(d)
-		return ticker.C, ticker.Stop
+		ticker := clk.NewTicker(d)
+		return ticker.C(), ticker.Stop
 	}
 }
 
-func newMovementEngine(target entityTicker, tick time.Duration, workers int) *movementEngine {
+// newMovementEngine ticks target every tick as measured by clk, or by the
+// system clock when clk is nil.
+func newMovementEngine(target entityTicker, tick time.Duration, workers int, clk clock.Clock) *movementEngine {
 	if workers <= 0 {
 		workers = 1
 	}
 	if tick <= 0 {
 		tick = 33 * time.Millisecond
 	}
+	if clk == nil {
+		clk = clock.Real()
+	}
 	return &movementEngine{
 		target:    target,
 		tick:      tick,
 		workers:   workers,
-		newTicker: defaultTickerFactory(),
-		now:       time.Now,
+		newTicker: clockTickerFactory(clk),
+		now:       clk.Now,
 	}
 }
 
+// Start begins ticking. The ticker is running by the time Start returns, so
+// a manual clock advanced afterwards drives the engine.
 func (m *movementEngine) Start(ctx context.Context) {
 	if m == nil || m.target == nil {
 		return
 	}
-	m.wg.Add(1)
-	go m.run(ctx)
-}
-
-func (m *movementEngine) run(ctx context.Context) {
-	defer m.wg.Done()
 	if m.newTicker == nil {
 		m.newTicker = defaultTickerFactory()
 	}
 	if m.now == nil {
 		m.now = time.Now
 	}
-
 	tickerC, stop := m.newTicker(m.tick)
+	m.wg.Add(1)
+	go m.run(ctx, tickerC, stop, m.now())
+}
+
+func (m *movementEngine) run(ctx context.Context, tickerC <-chan time.Time, stop func(), last time.Time) {
+	defer m.wg.Done()
 	defer stop()
 
-	last := m.now()
 	for {
 		select {
 		case <-ctx.Done():
@@ -58,7 +58,7 @@ func (s *stubEntityTicker) snapshot() (deltas []time.Duration, workers []int) {
 func TestMovementEngineClampsDeltaAndUsesWorkers(t *testing.T) {
 	stub := newStubEntityTicker()
 	tick := 10 * time.Millisecond
-	engine := newMovementEngine(stub, tick, 4)
+	engine := newMovementEngine(stub, tick, 4, nil)
 
 	base := time.Unix(0, 0)
 	engine.now = func() time.Time { return base }
@@ -106,7 +106,7 @@ func TestMovementEngineClampsDeltaAndUsesWorkers(t *testing.T) {
 
 func TestMovementEngineDefaults(t *testing.T) {
 	stub := newStubEntityTicker()
-	engine := newMovementEngine(stub, 0, 0)
+	engine := newMovementEngine(stub, 0, 0, nil)
 
 	if engine.tick != 33*time.Millisecond {
 		t.Fatalf("default tick duration = %v, want 33ms", engine.tick)
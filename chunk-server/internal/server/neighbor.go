@@ -1,6 +1,8 @@
 package server
 
 import (
+	"net"
+	"sort"
 	"sync"
 	"time"
 
@@ -22,8 +24,11 @@ type neighborInfo struct {
 	serverID           string
 	listen             string
 	regionOrigin       world.ChunkCoord
-	regionSize         int
+	regionSizeX        int
+	regionSizeY        int
 	lastHello          time.Time
+	lastHelloReceived  time.Time
+	lastAck            time.Time
 	lastHeard          time.Time
 	connected          bool
 	pendingNonce       uint64
@@ -38,7 +43,8 @@ type neighborOwnership struct {
 	serverID string
 	endpoint string
 	origin   world.ChunkCoord
-	size     int
+	sizeX    int
+	sizeY    int
 }
 
 func newNeighborManager(region world.ServerRegion, refs []config.NeighborRef) *neighborManager {
@@ -92,13 +98,12 @@ func (m *neighborManager) markHelloSent(delta world.ChunkCoord, endpoint string,
 	})
 }
 
-func (m *neighborManager) updateFromHello(addr string, listen string, serverID string, origin world.ChunkCoord, size int) world.ChunkCoord {
+func (m *neighborManager) updateFromHello(addr string, listen string, serverID string, origin world.ChunkCoord, sizeX, sizeY int, now time.Time) world.ChunkCoord {
 	delta := world.ChunkCoord{
 		X: origin.X - m.region.Origin.X,
 		Y: origin.Y - m.region.Origin.Y,
 	}
 	m.withNeighbor(delta, func(info *neighborInfo) {
-		now := time.Now()
 		info.remoteAddr = addr
 		if listen != "" {
 			info.contact = listen
@@ -108,23 +113,19 @@ func (m *neighborManager) updateFromHello(addr string, listen string, serverID s
 		info.serverID = serverID
 		info.listen = listen
 		info.regionOrigin = origin
-		if size > 0 {
-			info.regionSize = size
-		} else {
-			info.regionSize = m.region.ChunksPerAxis
-		}
+		info.regionSizeX, info.regionSizeY = m.advertisedSpan(sizeX, sizeY)
 		info.connected = true
+		info.lastHelloReceived = now
 		info.lastHeard = now
 		info.pendingNonce = 0
 	})
 	return delta
 }
 
-func (m *neighborManager) updateFromAck(addr string, listen string, serverID string, origin world.ChunkCoord, size int, nonce uint64) {
+func (m *neighborManager) updateFromAck(addr string, listen string, serverID string, origin world.ChunkCoord, sizeX, sizeY int, nonce uint64, now time.Time) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	var info *neighborInfo
-	now := time.Now()
 	if nonce != 0 {
 		for _, candidate := range m.neighbors {
 			if candidate.pendingNonce == nonce {
@@ -149,12 +150,11 @@ func (m *neighborManager) updateFromAck(addr string, listen string, serverID str
 	info.serverID = serverID
 	info.listen = listen
 	info.regionOrigin = origin
-	if size > 0 {
-		info.regionSize = size
-	} else if info.regionSize == 0 {
-		info.regionSize = m.region.ChunksPerAxis
+	if sizeX > 0 || info.regionSizeX == 0 {
+		info.regionSizeX, info.regionSizeY = m.advertisedSpan(sizeX, sizeY)
 	}
 	info.connected = true
+	info.lastAck = now
 	info.lastHeard = now
 	info.pendingNonce = 0
 }
@@ -184,19 +184,71 @@ func (m *neighborManager) neighborForChunk(chunk world.ChunkCoord) (*neighborInf
 		if !info.connected {
 			continue
 		}
-		size := info.regionSize
-		if size == 0 {
-			size = m.region.ChunksPerAxis
-		}
+		sizeX, sizeY := m.spanOf(info)
 		origin := info.regionOrigin
-		if chunk.X >= origin.X && chunk.X < origin.X+size &&
-			chunk.Y >= origin.Y && chunk.Y < origin.Y+size {
+		if chunk.X >= origin.X && chunk.X < origin.X+sizeX &&
+			chunk.Y >= origin.Y && chunk.Y < origin.Y+sizeY {
 			return info, true
 		}
 	}
 	return nil, false
 }
 
+// advertisedSpan resolves the region size a neighbor advertised. Peers
+// without a Y span own square regions, and peers advertising nothing are
+// assumed to match this server's region.
+func (m *neighborManager) advertisedSpan(sizeX, sizeY int) (int, int) {
+	if sizeX <= 0 {
+		return m.region.ChunksX, m.region.ChunksY
+	}
+	if sizeY <= 0 {
+		sizeY = sizeX
+	}
+	return sizeX, sizeY
+}
+
+// spanOf returns the region size of info, falling back to this server's own
+// until the neighbor has advertised one.
+func (m *neighborManager) spanOf(info *neighborInfo) (int, int) {
+	if info.regionSizeX == 0 {
+		return m.region.ChunksX, m.region.ChunksY
+	}
+	return info.regionSizeX, info.regionSizeY
+}
+
+// isConfiguredPeer reports whether addr belongs to a neighbor listed in
+// network.neighborEndpoints, either at its configured endpoint or at the
+// address its hellos arrived from. Neighbors that only announced themselves
+// are not trusted.
+func (m *neighborManager) isConfiguredPeer(addr *net.UDPAddr) bool {
+	if addr == nil {
+		return false
+	}
+	var endpoints []string
+	m.mu.RLock()
+	for _, info := range m.neighbors {
+		if info.configuredEndpoint == "" {
+			continue
+		}
+		if info.remoteAddr == addr.String() {
+			m.mu.RUnlock()
+			return true
+		}
+		endpoints = append(endpoints, info.configuredEndpoint)
+	}
+	m.mu.RUnlock()
+	for _, endpoint := range endpoints {
+		resolved, err := net.ResolveUDPAddr("udp", endpoint)
+		if err != nil {
+			continue
+		}
+		if resolved.Port == addr.Port && resolved.IP.Equal(addr.IP) {
+			return true
+		}
+	}
+	return false
+}
+
 func (info *neighborInfo) endpoint() string {
 	if info.contact != "" {
 		return info.contact
@@ -214,20 +266,89 @@ func (m *neighborManager) ownership(chunk world.ChunkCoord) (neighborOwnership,
 		if !info.connected {
 			continue
 		}
-		size := info.regionSize
-		if size == 0 {
-			size = m.region.ChunksPerAxis
-		}
+		sizeX, sizeY := m.spanOf(info)
 		origin := info.regionOrigin
-		if chunk.X >= origin.X && chunk.X < origin.X+size &&
-			chunk.Y >= origin.Y && chunk.Y < origin.Y+size {
+		if chunk.X >= origin.X && chunk.X < origin.X+sizeX &&
+			chunk.Y >= origin.Y && chunk.Y < origin.Y+sizeY {
 			return neighborOwnership{
 				serverID: info.serverID,
 				endpoint: info.endpoint(),
 				origin:   origin,
-				size:     size,
+				sizeX:    sizeX,
+				sizeY:    sizeY,
 			}, true
 		}
 	}
 	return neighborOwnership{}, false
 }
+
+// neighborStaleIntervals is how many discovery intervals may pass without
+// hearing from a connected neighbor before its status reports it stale.
+const neighborStaleIntervals = 3
+
+// Neighbor health values reported by NeighborStatus.
+const (
+	neighborHealthUnreached = "unreached"
+	neighborHealthOK        = "ok"
+	neighborHealthStale     = "stale"
+)
+
+// NeighborStatus is a read-only view of one neighbor slot: who owns it, how
+// it is reached, and when the discovery handshake last ran in each direction.
+type NeighborStatus struct {
+	ServerID          string    `json:"serverId,omitempty"`
+	Endpoint          string    `json:"endpoint,omitempty"`
+	DeltaX            int       `json:"deltaX"`
+	DeltaY            int       `json:"deltaY"`
+	RegionOriginX     int       `json:"regionOriginX"`
+	RegionOriginY     int       `json:"regionOriginY"`
+	RegionSizeX       int       `json:"regionSizeX"`
+	RegionSizeY       int       `json:"regionSizeY"`
+	LastHelloSent     time.Time `json:"lastHelloSent"`
+	LastHelloReceived time.Time `json:"lastHelloReceived"`
+	LastAckReceived   time.Time `json:"lastAckReceived"`
+	Health            string    `json:"health"`
+}
+
+// statuses reports every known neighbor ordered by delta. A neighbor is
+// unreached until it has answered or greeted us, and stale once nothing has
+// been heard from it for neighborStaleIntervals discovery intervals.
+func (m *neighborManager) statuses(now time.Time, interval time.Duration) []NeighborStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]NeighborStatus, 0, len(m.neighbors))
+	for _, info := range m.neighbors {
+		sizeX, sizeY := m.spanOf(info)
+		status := NeighborStatus{
+			ServerID:          info.serverID,
+			Endpoint:          info.endpoint(),
+			DeltaX:            info.delta.X,
+			DeltaY:            info.delta.Y,
+			RegionOriginX:     info.regionOrigin.X,
+			RegionOriginY:     info.regionOrigin.Y,
+			RegionSizeX:       sizeX,
+			RegionSizeY:       sizeY,
+			LastHelloSent:     info.lastHello,
+			LastHelloReceived: info.lastHelloReceived,
+			LastAckReceived:   info.lastAck,
+			Health:            neighborHealthOK,
+		}
+		if status.Endpoint == "" {
+			status.Endpoint = info.configuredEndpoint
+		}
+		switch {
+		case !info.connected:
+			status.Health = neighborHealthUnreached
+		case interval > 0 && now.Sub(info.lastHeard) > neighborStaleIntervals*interval:
+			status.Health = neighborHealthStale
+		}
+		out = append(out, status)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].DeltaX != out[j].DeltaX {
+			return out[i].DeltaX < out[j].DeltaX
+		}
+		return out[i].DeltaY < out[j].DeltaY
+	})
+	return out
+}
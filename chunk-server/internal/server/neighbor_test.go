@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"chunkserver/internal/clock"
+	"chunkserver/internal/config"
+	"chunkserver/internal/network"
+)
+
+func neighborEnvelope(t *testing.T, kind network.MessageType, payload any) network.Envelope {
+	t.Helper()
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("marshal %s: %v", kind, err)
+	}
+	return network.Envelope{Type: kind, Payload: data}
+}
+
+func TestNeighborStatusTracksHandshake(t *testing.T) {
+	clk := clock.NewManual(time.Unix(100, 0))
+	srv := newExplosionTestServer(t)
+	srv.clock = clk
+	srv.cfg.Network.DiscoveryInterval = config.Duration(time.Second)
+	netSrv, err := network.Listen("127.0.0.1:0", noopLogger(), 0)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer netSrv.Close()
+	srv.net = netSrv
+	peer, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen peer: %v", err)
+	}
+	defer peer.Close()
+	peerAddr := peer.LocalAddr().(*net.UDPAddr)
+	srv.neighbors = newNeighborManager(srv.world.Region(), []config.NeighborRef{
+		{ChunkDelta: config.ChunkIndex{X: 2, Y: 0}, Endpoint: peerAddr.String()},
+	})
+
+	status := srv.NeighborStatus()
+	if len(status) != 1 || status[0].Health != neighborHealthUnreached || status[0].Endpoint != peerAddr.String() {
+		t.Fatalf("expected one unreached configured neighbor, got %+v", status)
+	}
+
+	sent := clk.Now()
+	srv.discoverNeighbors(sent)
+	clk.Advance(200 * time.Millisecond)
+	acked := clk.Now()
+	srv.onNeighborAck(context.Background(), peerAddr, neighborEnvelope(t, network.MessageNeighborAck, network.NeighborAck{
+		ServerID:      "east",
+		Listen:        peerAddr.String(),
+		RegionOriginX: 2,
+		RegionSize:    2,
+		Nonce:         srv.neighborSeq,
+		Status:        "ok",
+	}))
+
+	status = srv.NeighborStatus()
+	if len(status) != 1 {
+		t.Fatalf("expected the ack to update the configured neighbor, got %+v", status)
+	}
+	east := status[0]
+	if east.ServerID != "east" || east.DeltaX != 2 || east.DeltaY != 0 || east.Health != neighborHealthOK {
+		t.Fatalf("unexpected neighbor after ack: %+v", east)
+	}
+	if !east.LastHelloSent.Equal(sent) || !east.LastAckReceived.Equal(acked) || !east.LastHelloReceived.IsZero() {
+		t.Fatalf("unexpected handshake times after ack: %+v", east)
+	}
+
+	clk.Advance(time.Second)
+	greeted := clk.Now()
+	srv.onNeighborHello(context.Background(), peerAddr, neighborEnvelope(t, network.MessageNeighborHello, network.NeighborHello{
+		ServerID:      "south",
+		RegionOriginX: 0,
+		RegionOriginY: -2,
+		RegionSize:    2,
+	}))
+
+	status = srv.NeighborStatus()
+	if len(status) != 2 {
+		t.Fatalf("expected the hello to add a second neighbor, got %+v", status)
+	}
+	south := status[0]
+	if south.ServerID != "south" || south.DeltaX != 0 || south.DeltaY != -2 || south.Health != neighborHealthOK {
+		t.Fatalf("unexpected neighbor after hello: %+v", south)
+	}
+	if !south.LastHelloReceived.Equal(greeted) || !south.LastHelloSent.IsZero() {
+		t.Fatalf("unexpected handshake times after hello: %+v", south)
+	}
+
+	clk.Advance(3 * time.Second)
+	for _, s := range srv.NeighborStatus() {
+		want := neighborHealthOK
+		if s.ServerID == "east" {
+			want = neighborHealthStale
+		}
+		if s.Health != want {
+			t.Fatalf("neighbor %s health = %q, want %q", s.ServerID, s.Health, want)
+		}
+	}
+	if stats := srv.Stats(); len(stats.Neighbors) != 2 {
+		t.Fatalf("expected stats to carry the neighbor status, got %+v", stats.Neighbors)
+	}
+}
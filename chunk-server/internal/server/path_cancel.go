@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"chunkserver/internal/network"
+)
+
+// pathCancelMemory is how long a cancel for a request that has not arrived
+// yet is kept, in case the datagrams were reordered on the way.
+const pathCancelMemory = time.Minute
+
+// pathCancelLimit bounds how many early cancels are remembered; the oldest is
+// forgotten to make room for a new one.
+const pathCancelLimit = 1024
+
+var errPathCancelled = errors.New("path request cancelled")
+
+// pathSearches tracks path requests while they are being searched, so a
+// client can call them off. Requests are keyed by the sending address and the
+// client-chosen ID, so clients that pick the same IDs do not interfere.
+type pathSearches struct {
+	mu        sync.Mutex
+	running   map[string]*runningSearch
+	cancelled map[string]time.Time
+}
+
+type runningSearch struct {
+	cancel context.CancelCauseFunc
+}
+
+// pathSearchKey identifies request id from client.
+func pathSearchKey(client *net.UDPAddr, id string) string {
+	return client.String() + "/" + id
+}
+
+// start registers a search for id from client and returns the context it
+// should run under and a func to call once it is done. ok is false when the
+// request was cancelled before it arrived; it should then be dropped.
+// Requests without an ID cannot be cancelled and always start.
+func (p *pathSearches) start(ctx context.Context, client *net.UDPAddr, id string) (context.Context, func(), bool) {
+	if id == "" {
+		return ctx, func() {}, true
+	}
+	key := pathSearchKey(client, id)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.cancelled[key]; ok {
+		delete(p.cancelled, key)
+		return nil, nil, false
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	if p.running == nil {
+		p.running = make(map[string]*runningSearch)
+	}
+	search := &runningSearch{cancel: cancel}
+	p.running[key] = search
+	return ctx, func() {
+		p.mu.Lock()
+		// A later request reusing the ID may have taken the slot.
+		if p.running[key] == search {
+			delete(p.running, key)
+		}
+		p.mu.Unlock()
+		cancel(nil)
+	}, true
+}
+
+// cancel stops the search for id from client and reports whether one was
+// running. A cancel for an unknown request is remembered so the request is
+// dropped if it shows up later.
+func (p *pathSearches) cancel(client *net.UDPAddr, id string, now time.Time) bool {
+	if id == "" {
+		return false
+	}
+	key := pathSearchKey(client, id)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if search, ok := p.running[key]; ok {
+		delete(p.running, key)
+		search.cancel(errPathCancelled)
+		return true
+	}
+	if p.cancelled == nil {
+		p.cancelled = make(map[string]time.Time)
+	}
+	var oldest string
+	var oldestAt time.Time
+	for other, at := range p.cancelled {
+		if now.Sub(at) > pathCancelMemory {
+			delete(p.cancelled, other)
+			continue
+		}
+		if oldest == "" || at.Before(oldestAt) {
+			oldest, oldestAt = other, at
+		}
+	}
+	if _, ok := p.cancelled[key]; !ok && len(p.cancelled) >= pathCancelLimit {
+		delete(p.cancelled, oldest)
+	}
+	p.cancelled[key] = now
+	return false
+}
+
+// CancelPath calls off the path request client sent with the given ID. A
+// search in progress stops at its next node expansion, and a request that has
+// not arrived yet is dropped when it does; neither sends a response. It
+// reports whether a search was running.
+func (s *Server) CancelPath(client *net.UDPAddr, id string) bool {
+	return s.pathSearches.cancel(client, id, s.now())
+}
+
+func (s *Server) onPathCancel(ctx context.Context, addr *net.UDPAddr, env network.Envelope) {
+	var req network.PathCancel
+	if err := json.Unmarshal(env.Payload, &req); err != nil {
+		s.logger.Warnf("path cancel decode: %v", err)
+		return
+	}
+	s.CancelPath(addr, req.RequestID)
+}
@@ -0,0 +1,462 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"chunkserver/internal/config"
+	"chunkserver/internal/network"
+	"chunkserver/internal/pathfinding"
+	"chunkserver/internal/world"
+)
+
+func newPathTestServer(t *testing.T) (*Server, net.PacketConn) {
+	t.Helper()
+	region := world.ServerRegion{
+		ChunksX:        1,
+		ChunksY:        1,
+		ChunkDimension: world.Dimensions{Width: 8, Depth: 8, Height: 6},
+	}
+	netSrv, err := network.Listen("127.0.0.1:0", noopLogger(), 0)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { netSrv.Close() })
+	client, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	manager := world.NewManager(region, stubGenerator{})
+	return &Server{
+		cfg:       config.Default(),
+		net:       netSrv,
+		logger:    noopLogger(),
+		world:     manager,
+		navigator: pathfinding.NewBlockNavigator(region, manager),
+	}, client
+}
+
+func requestPath(t *testing.T, srv *Server, client net.PacketConn, req network.PathRequest) network.PathResponse {
+	t.Helper()
+	payload, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("marshal request: %v", err)
+	}
+	srv.onPathRequest(context.Background(), client.LocalAddr().(*net.UDPAddr), network.Envelope{
+		Type:    network.MessagePathRequest,
+		Payload: payload,
+	})
+	buffer := make([]byte, 65536)
+	client.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := client.ReadFrom(buffer)
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	env, err := network.Decode(buffer[:n])
+	if err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	var resp network.PathResponse
+	if err := json.Unmarshal(env.Payload, &resp); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	return resp
+}
+
+func TestPathRequestChainsWaypoints(t *testing.T) {
+	srv, client := newPathTestServer(t)
+	waypoints := []network.BlockStep{{X: 6, Y: 1, Z: 2}, {X: 6, Y: 6, Z: 3}, {X: 2, Y: 6, Z: 2}}
+	resp := requestPath(t, srv, client, network.PathRequest{
+		EntityID:  "patrol",
+		FromX:     1,
+		FromY:     1,
+		FromZ:     2,
+		ToX:       1,
+		ToY:       3,
+		ToZ:       2,
+		Mode:      "flying",
+		Waypoints: waypoints,
+	})
+	if resp.Error != "" || len(resp.Route) == 0 || resp.Status != string(pathfinding.RouteFound) {
+		t.Fatalf("expected a route, got status %q error %q", resp.Status, resp.Error)
+	}
+	if resp.Stats == nil || resp.Stats.Expanded == 0 {
+		t.Fatalf("expected search stats, got %+v", resp.Stats)
+	}
+
+	visit := append([]network.BlockStep{{X: 1, Y: 1, Z: 2}}, waypoints...)
+	visit = append(visit, network.BlockStep{X: 1, Y: 3, Z: 2})
+	next := 0
+	for i, step := range resp.Route {
+		if i > 0 {
+			prev := resp.Route[i-1]
+			if step == prev {
+				t.Fatalf("route repeats %v at step %d", step, i)
+			}
+			dx, dy, dz := step.X-prev.X, step.Y-prev.Y, step.Z-prev.Z
+			if dx*dx > 1 || dy*dy > 1 || dz*dz > 1 {
+				t.Fatalf("route jumps from %v to %v", prev, step)
+			}
+		}
+		if next < len(visit) && step == visit[next] {
+			next++
+		}
+	}
+	if next != len(visit) {
+		t.Fatalf("route visited %d of %d points in order: %v", next, len(visit), resp.Route)
+	}
+	if last := resp.Route[len(resp.Route)-1]; last != visit[len(visit)-1] {
+		t.Fatalf("route ends at %v, want %v", last, visit[len(visit)-1])
+	}
+}
+
+func TestPathRequestFailsWhenAnyWaypointIsUnroutable(t *testing.T) {
+	srv, client := newPathTestServer(t)
+	resp := requestPath(t, srv, client, network.PathRequest{
+		EntityID:  "patrol",
+		FromX:     1,
+		FromY:     1,
+		FromZ:     2,
+		ToX:       1,
+		ToY:       3,
+		ToZ:       2,
+		Mode:      "flying",
+		Waypoints: []network.BlockStep{{X: 6, Y: 1, Z: 2}, {X: 40, Y: 1, Z: 2}},
+	})
+	if len(resp.Route) != 0 {
+		t.Fatalf("expected no route, got %v", resp.Route)
+	}
+	if resp.Status != string(pathfinding.RouteOutOfRegion) {
+		t.Fatalf("expected status %q, got %q", pathfinding.RouteOutOfRegion, resp.Status)
+	}
+	if resp.Error != "no route for segment 1 from {6 1 2} to {40 1 2}: out_of_region" {
+		t.Fatalf("unexpected error %q", resp.Error)
+	}
+}
+
+func TestPathRequestRejectsTooManyWaypoints(t *testing.T) {
+	srv, client := newPathTestServer(t)
+	waypoints := make([]network.BlockStep, maxPathWaypoints+1)
+	for i := range waypoints {
+		waypoints[i] = network.BlockStep{X: 1 + i%6, Y: 1, Z: 2}
+	}
+	resp := requestPath(t, srv, client, network.PathRequest{
+		EntityID:  "patrol",
+		RequestID: "long-patrol",
+		FromX:     1,
+		FromY:     1,
+		FromZ:     2,
+		ToX:       1,
+		ToY:       3,
+		ToZ:       2,
+		Mode:      "flying",
+		Waypoints: waypoints,
+	})
+	if resp.Status != pathTooManyWaypoints || resp.RequestID != "long-patrol" || len(resp.Route) != 0 {
+		t.Fatalf("expected the request refused, got status %q route %v", resp.Status, resp.Route)
+	}
+	if resp.Error != "33 waypoints exceed the limit of 32" {
+		t.Fatalf("unexpected error %q", resp.Error)
+	}
+
+	resp = requestPath(t, srv, client, network.PathRequest{
+		EntityID:  "patrol",
+		FromX:     1,
+		FromY:     1,
+		FromZ:     2,
+		ToX:       1,
+		ToY:       3,
+		ToZ:       2,
+		Mode:      "flying",
+		Waypoints: waypoints[:maxPathWaypoints],
+	})
+	if resp.Status != string(pathfinding.RouteFound) {
+		t.Fatalf("expected %d waypoints to be routed, got status %q (%s)", maxPathWaypoints, resp.Status, resp.Error)
+	}
+}
+
+func TestPathRequestReportsNodeLimit(t *testing.T) {
+	srv, client := newPathTestServer(t)
+	srv.navigator.SetOptions(pathfinding.SearchOptions{MaxNodes: 3})
+	resp := requestPath(t, srv, client, network.PathRequest{
+		EntityID: "scout",
+		FromX:    1,
+		FromY:    1,
+		FromZ:    2,
+		ToX:      6,
+		ToY:      6,
+		ToZ:      2,
+		Mode:     "flying",
+	})
+	if resp.Status != string(pathfinding.RouteNodeLimit) || len(resp.Route) != 0 {
+		t.Fatalf("expected %q with no route, got %q and %v", pathfinding.RouteNodeLimit, resp.Status, resp.Route)
+	}
+	if resp.Stats == nil || resp.Stats.Expanded != 3 {
+		t.Fatalf("expected 3 expansions in the stats, got %+v", resp.Stats)
+	}
+}
+
+func TestUnitProfileAppliesConfiguredOverrides(t *testing.T) {
+	var cfg config.PathfindingConfig
+	if err := json.Unmarshal([]byte(`{"profiles": {"flying": {"maxClimb": 12}}}`), &cfg); err != nil {
+		t.Fatalf("decode pathfinding config: %v", err)
+	}
+
+	flying := unitProfile(cfg, pathfinding.ModeFlying)
+	want := pathfinding.DefaultProfile(pathfinding.ModeFlying)
+	want.MaxClimb = 12
+	if flying != want {
+		t.Fatalf("flying profile = %+v, want %+v", flying, want)
+	}
+	if ground := unitProfile(cfg, pathfinding.ModeGround); ground != pathfinding.DefaultProfile(pathfinding.ModeGround) {
+		t.Fatalf("ground profile = %+v, want the built-in default", ground)
+	}
+
+	if err := json.Unmarshal([]byte(`{"profiles": {"ground": {"allowDiagonal": true}, "flying": {"heuristic": "euclidean"}}}`), &cfg); err != nil {
+		t.Fatalf("decode pathfinding config: %v", err)
+	}
+	if ground := unitProfile(cfg, pathfinding.ModeGround); !ground.AllowDiagonal || ground.Heuristic != pathfinding.HeuristicAuto {
+		t.Fatalf("ground profile = %+v, want diagonal movement with the automatic heuristic", ground)
+	}
+	if flying := unitProfile(cfg, pathfinding.ModeFlying); flying.Heuristic != pathfinding.HeuristicEuclidean {
+		t.Fatalf("flying heuristic = %v, want euclidean", flying.Heuristic)
+	}
+}
+
+func TestPathRequestAvoidsKeepOutBoxes(t *testing.T) {
+	srv, client := newPathTestServer(t)
+	box := network.KeepOutBox{Min: network.BlockStep{X: 3, Y: 0, Z: 0}, Max: network.BlockStep{X: 4, Y: 5, Z: 5}}
+	resp := requestPath(t, srv, client, network.PathRequest{
+		EntityID: "sapper",
+		FromX:    1,
+		FromY:    1,
+		FromZ:    2,
+		ToX:      6,
+		ToY:      1,
+		ToZ:      2,
+		Mode:     "flying",
+		KeepOut:  []network.KeepOutBox{box},
+	})
+	if resp.Status != string(pathfinding.RouteFound) {
+		t.Fatalf("expected a route around the keep-out box, got status %q error %q", resp.Status, resp.Error)
+	}
+	for _, step := range resp.Route {
+		if step.X >= box.Min.X && step.X <= box.Max.X && step.Y <= box.Max.Y && step.Z <= box.Max.Z {
+			t.Fatalf("route %v enters keep-out box %+v", resp.Route, box)
+		}
+	}
+}
+
+func TestPathRequestReportsDigManifest(t *testing.T) {
+	srv, client := newPathTestServer(t)
+	chunk, err := srv.world.Chunk(context.Background(), world.ChunkCoord{})
+	if err != nil {
+		t.Fatalf("load chunk: %v", err)
+	}
+	dims := chunk.Dimensions()
+	for x := 0; x < dims.Width; x++ {
+		for y := 0; y < dims.Depth; y++ {
+			for z := 0; z < dims.Height; z++ {
+				if y != 1 || z != 1 {
+					chunk.SetLocalBlock(x, y, z, world.Block{Type: world.BlockSolid})
+				}
+			}
+		}
+	}
+	for x := 2; x <= 4; x++ {
+		chunk.SetLocalBlock(x, 1, 1, world.Block{Type: world.BlockMineral, ResourceYield: map[string]float64{"copper": float64(x)}})
+	}
+
+	resp := requestPath(t, srv, client, network.PathRequest{
+		EntityID: "miner",
+		FromX:    1,
+		FromY:    1,
+		FromZ:    1,
+		ToX:      5,
+		ToY:      1,
+		ToZ:      1,
+		Mode:     "underground",
+	})
+	if resp.Status != string(pathfinding.RouteFound) {
+		t.Fatalf("expected a route, got status %q error %q", resp.Status, resp.Error)
+	}
+	want := []network.DigStep{
+		{X: 2, Y: 1, Z: 1, Block: string(world.BlockMineral), Yield: map[string]float64{"copper": 2}},
+		{X: 3, Y: 1, Z: 1, Block: string(world.BlockMineral), Yield: map[string]float64{"copper": 3}},
+		{X: 4, Y: 1, Z: 1, Block: string(world.BlockMineral), Yield: map[string]float64{"copper": 4}},
+	}
+	if !reflect.DeepEqual(resp.Dig, want) {
+		t.Fatalf("dig = %+v, want %+v", resp.Dig, want)
+	}
+}
+
+func sendPathRequest(t *testing.T, srv *Server, ctx context.Context, client net.PacketConn, req network.PathRequest) {
+	t.Helper()
+	payload, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("marshal request: %v", err)
+	}
+	srv.onPathRequest(ctx, client.LocalAddr().(*net.UDPAddr), network.Envelope{
+		Type:    network.MessagePathRequest,
+		Payload: payload,
+	})
+}
+
+func expectNoPathResponse(t *testing.T, client net.PacketConn) {
+	t.Helper()
+	buffer := make([]byte, 65536)
+	client.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if n, _, err := client.ReadFrom(buffer); err == nil {
+		t.Fatalf("expected no response, got %s", buffer[:n])
+	}
+}
+
+func TestPathRequestCancelledBeforeItArrivesIsDropped(t *testing.T) {
+	srv, client := newPathTestServer(t)
+	payload, _ := json.Marshal(network.PathCancel{RequestID: "order-7"})
+	srv.onPathCancel(context.Background(), client.LocalAddr().(*net.UDPAddr), network.Envelope{
+		Type:    network.MessagePathCancel,
+		Payload: payload,
+	})
+
+	req := network.PathRequest{EntityID: "scout", RequestID: "order-7", FromX: 1, FromY: 1, FromZ: 2, ToX: 6, ToY: 6, ToZ: 2, Mode: "flying"}
+	sendPathRequest(t, srv, context.Background(), client, req)
+	expectNoPathResponse(t, client)
+
+	// The cancel is spent on the request it matched; a retry is answered.
+	resp := requestPath(t, srv, client, req)
+	if resp.RequestID != "order-7" || resp.Status != string(pathfinding.RouteFound) {
+		t.Fatalf("retried request = %+v, want a found route echoing the request id", resp)
+	}
+}
+
+func TestPathCancelOnlyAffectsTheSendingClient(t *testing.T) {
+	srv, client := newPathTestServer(t)
+	other := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1}
+	payload, _ := json.Marshal(network.PathCancel{RequestID: "order-7"})
+	srv.onPathCancel(context.Background(), other, network.Envelope{
+		Type:    network.MessagePathCancel,
+		Payload: payload,
+	})
+
+	req := network.PathRequest{EntityID: "scout", RequestID: "order-7", FromX: 1, FromY: 1, FromZ: 2, ToX: 6, ToY: 6, ToZ: 2, Mode: "flying"}
+	resp := requestPath(t, srv, client, req)
+	if resp.RequestID != "order-7" || resp.Status != string(pathfinding.RouteFound) {
+		t.Fatalf("request = %+v, want it answered despite another client's cancel", resp)
+	}
+}
+
+func TestPathCancelMemoryIsBounded(t *testing.T) {
+	var searches pathSearches
+	client := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1}
+	now := time.Unix(0, 0)
+	for i := 0; i <= pathCancelLimit; i++ {
+		searches.cancel(client, fmt.Sprintf("order-%d", i), now.Add(time.Duration(i)*time.Millisecond))
+	}
+	if len(searches.cancelled) != pathCancelLimit {
+		t.Fatalf("remembered %d cancels, want the limit of %d", len(searches.cancelled), pathCancelLimit)
+	}
+	if _, ok := searches.cancelled[pathSearchKey(client, "order-0")]; ok {
+		t.Fatalf("expected the oldest cancel to be forgotten")
+	}
+	if _, _, ok := searches.start(context.Background(), client, fmt.Sprintf("order-%d", pathCancelLimit)); ok {
+		t.Fatalf("expected the newest cancel to still drop its request")
+	}
+}
+
+// cancellingProfiler cancels a path request once the search has expanded
+// after nodes.
+type cancellingProfiler struct {
+	srv      *Server
+	client   *net.UDPAddr
+	id       string
+	after    int
+	expanded int
+}
+
+func (p *cancellingProfiler) RecordNodeExpanded() {
+	p.expanded++
+	if p.expanded == p.after {
+		p.srv.CancelPath(p.client, p.id)
+	}
+}
+
+func (p *cancellingProfiler) RecordNeighborGeneration(int)  {}
+func (p *cancellingProfiler) RecordHeuristicEvaluation()    {}
+func (p *cancellingProfiler) RecordCacheHit()               {}
+func (p *cancellingProfiler) RecordCacheMiss()              {}
+func (p *cancellingProfiler) RecordChunkLoad(time.Duration) {}
+
+func TestCancelPathStopsSearchInProgress(t *testing.T) {
+	srv, client := newPathTestServer(t)
+	clientAddr := client.LocalAddr().(*net.UDPAddr)
+	profiler := &cancellingProfiler{srv: srv, client: clientAddr, id: "order-9", after: 2}
+	ctx := pathfinding.ContextWithProfiler(context.Background(), profiler)
+
+	sendPathRequest(t, srv, ctx, client, network.PathRequest{EntityID: "scout", RequestID: "order-9", FromX: 1, FromY: 1, FromZ: 2, ToX: 6, ToY: 6, ToZ: 2, Mode: "flying"})
+	expectNoPathResponse(t, client)
+	if profiler.expanded != 2 {
+		t.Fatalf("search expanded %d nodes, want it to stop after the 2 before the cancel", profiler.expanded)
+	}
+	if srv.CancelPath(clientAddr, "order-9") {
+		t.Fatalf("CancelPath() after the search ended reported it still running")
+	}
+}
+
+func TestPathRequestRegionRelativeMatchesAbsolute(t *testing.T) {
+	srv, client := newPathTestServer(t)
+	region := srv.world.Region()
+	region.Origin = world.ChunkCoord{X: 3, Y: -2}
+	srv.world = world.NewManager(region, stubGenerator{})
+	srv.navigator = pathfinding.NewBlockNavigator(region, srv.world)
+	origin := region.BlockOrigin()
+	shift := func(step network.BlockStep) network.BlockStep {
+		return network.BlockStep{X: step.X + origin.X, Y: step.Y + origin.Y, Z: step.Z}
+	}
+
+	relative := network.PathRequest{
+		EntityID:       "scout",
+		FromX:          1,
+		FromY:          1,
+		FromZ:          2,
+		ToX:            6,
+		ToY:            1,
+		ToZ:            2,
+		Mode:           "flying",
+		Waypoints:      []network.BlockStep{{X: 2, Y: 6, Z: 2}},
+		KeepOut:        []network.KeepOutBox{{Min: network.BlockStep{X: 3, Y: 0, Z: 0}, Max: network.BlockStep{X: 4, Y: 5, Z: 5}}},
+		RegionRelative: true,
+	}
+	absolute := relative
+	absolute.RegionRelative = false
+	absolute.FromX, absolute.FromY = relative.FromX+origin.X, relative.FromY+origin.Y
+	absolute.ToX, absolute.ToY = relative.ToX+origin.X, relative.ToY+origin.Y
+	absolute.Waypoints = []network.BlockStep{shift(relative.Waypoints[0])}
+	absolute.KeepOut = []network.KeepOutBox{{Min: shift(relative.KeepOut[0].Min), Max: shift(relative.KeepOut[0].Max)}}
+
+	want := requestPath(t, srv, client, absolute)
+	if want.Status != string(pathfinding.RouteFound) || want.RegionRelative {
+		t.Fatalf("absolute request = %+v, want a found route in global coordinates", want)
+	}
+	got := requestPath(t, srv, client, relative)
+	if got.Status != string(pathfinding.RouteFound) || !got.RegionRelative {
+		t.Fatalf("relative request = %+v, want a found route flagged region-relative", got)
+	}
+	if len(got.Route) != len(want.Route) {
+		t.Fatalf("relative route has %d steps, absolute %d", len(got.Route), len(want.Route))
+	}
+	for i, step := range got.Route {
+		if shift(step) != want.Route[i] {
+			t.Fatalf("relative step %d = %+v, want %+v less the region origin %+v", i, step, want.Route[i], origin)
+		}
+	}
+	if first := got.Route[0]; first != (network.BlockStep{X: 1, Y: 1, Z: 2}) {
+		t.Fatalf("relative route starts at %+v, want the requested start", first)
+	}
+}
@@ -2,8 +2,6 @@ package server
 
 import (
 	"context"
-	"io"
-	"log"
 	"testing"
 
 	"chunkserver/internal/world"
@@ -12,13 +10,14 @@ import (
 type stubGenerator struct{}
 
 func (stubGenerator) Generate(ctx context.Context, coord world.ChunkCoord, bounds world.Bounds, dim world.Dimensions) (*world.Chunk, error) {
-	return world.NewChunk(coord, bounds, dim), nil
+	return world.NewChunkWithStorage(coord, bounds, dim, world.StorageProviderFromContext(ctx)), nil
 }
 
 func TestQueueVoxelDeltasFiltersInteriorBlocks(t *testing.T) {
 	region := world.ServerRegion{
-		Origin:        world.ChunkCoord{X: 0, Y: 0},
-		ChunksPerAxis: 1,
+		Origin:  world.ChunkCoord{X: 0, Y: 0},
+		ChunksX: 1,
+		ChunksY: 1,
 		ChunkDimension: world.Dimensions{
 			Width:  4,
 			Depth:  4,
@@ -28,7 +27,7 @@ func TestQueueVoxelDeltasFiltersInteriorBlocks(t *testing.T) {
 
 	srv := &Server{
 		world:  world.NewManager(region, stubGenerator{}),
-		logger: log.New(io.Discard, "", 0),
+		logger: noopLogger(),
 	}
 
 	chunk, err := srv.world.Chunk(context.Background(), world.ChunkCoord{X: 0, Y: 0})
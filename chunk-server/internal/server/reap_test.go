@@ -0,0 +1,44 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"chunkserver/internal/entities"
+	"chunkserver/internal/world"
+)
+
+func TestDetonatedProjectileIsReapedAfterImpactTick(t *testing.T) {
+	srv := newExplosionTestServer(t)
+	victim := addUnit(t, srv, "victim", entities.Vec3{X: 3, Y: 4, Z: 5})
+	victim.Stats = entities.Stats{MaxHP: 10, CurrentHP: 10}
+	shell := &entities.Entity{
+		ID:       "shell",
+		Kind:     entities.KindProjectile,
+		Position: entities.Vec3{X: 4, Y: 4, Z: 5},
+	}
+	shell.SetAttribute("projectile_life", 0.01)
+	shell.SetAttribute("explosion_radius", 4)
+	shell.SetAttribute("explosion_damage", 100)
+	if err := srv.entities.Add(shell); err != nil {
+		t.Fatalf("add shell: %v", err)
+	}
+
+	srv.tickEntities(50*time.Millisecond, 1)
+
+	for _, id := range []entities.ID{"shell", "victim"} {
+		if _, ok := srv.entities.Entity(id); ok {
+			t.Fatalf("%s is still registered after the impact tick", id)
+		}
+		final, ok := srv.dirtyEntities[id]
+		if !ok || !final.Removed {
+			t.Fatalf("expected a final removed state for %s, got %+v (recorded %t)", id, final, ok)
+		}
+		if state := serializeEntity(final); !state.Removed || !state.Dying {
+			t.Fatalf("streamed state for %s = %+v, want dying and removed", id, state)
+		}
+	}
+	if left := srv.entities.ByChunk(world.ChunkCoord{}); len(left) != 0 {
+		t.Fatalf("ByChunk() still lists %v", left)
+	}
+}
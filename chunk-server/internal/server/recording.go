@@ -0,0 +1,25 @@
+package server
+
+import "chunkserver/internal/network"
+
+// setRecordPath captures every envelope the server sends and receives to path,
+// closing any capture already in progress. An empty path stops recording.
+func (s *Server) setRecordPath(path string) error {
+	var rec *network.Recorder
+	if path != "" {
+		var err error
+		rec, err = network.CreateRecorder(path)
+		if err != nil {
+			return err
+		}
+	}
+	if previous := s.net.SetRecorder(rec); previous != nil {
+		if err := previous.Close(); err != nil {
+			s.logger.Warn("close network capture failed", "err", err)
+		}
+	}
+	if path != "" {
+		s.logger.Info("recording network messages", "path", path)
+	}
+	return nil
+}
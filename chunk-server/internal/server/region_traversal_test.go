@@ -0,0 +1,74 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"chunkserver/internal/world"
+)
+
+func TestChunkTraversalCoversAsymmetricRegion(t *testing.T) {
+	traversal := buildCircularChunkTraversal(3, 2)
+	if len(traversal) != 6 {
+		t.Fatalf("traversal has %d entries, want 6", len(traversal))
+	}
+	seen := make(map[world.LocalChunkIndex]bool)
+	for _, idx := range traversal {
+		if idx.X < 0 || idx.X >= 3 || idx.Y < 0 || idx.Y >= 2 {
+			t.Fatalf("traversal visits %v outside a 3x2 region", idx)
+		}
+		if seen[idx] {
+			t.Fatalf("traversal visits %v twice", idx)
+		}
+		seen[idx] = true
+	}
+
+	srv := &Server{chunkTraversal: traversal}
+	for i := 0; i < len(traversal)-1; i++ {
+		srv.advanceChunkCursor()
+	}
+	if srv.chunkCursor != len(traversal)-1 {
+		t.Fatalf("cursor = %d after %d advances, want %d", srv.chunkCursor, len(traversal)-1, len(traversal)-1)
+	}
+	srv.advanceChunkCursor()
+	if srv.chunkCursor != 0 {
+		t.Fatalf("cursor = %d after a full lap, want 0", srv.chunkCursor)
+	}
+}
+
+func TestNeighborOwnsChunksAcrossAdvertisedSpan(t *testing.T) {
+	region := world.ServerRegion{
+		ChunksX:        3,
+		ChunksY:        2,
+		ChunkDimension: world.Dimensions{Width: 4, Depth: 4, Height: 4},
+	}
+	neighbors := newNeighborManager(region, nil)
+	neighbors.updateFromHello("127.0.0.1:9001", "", "east", world.ChunkCoord{X: 3, Y: 0}, 3, 2, time.Now())
+	neighbors.updateFromHello("127.0.0.1:9002", "", "south", world.ChunkCoord{X: 0, Y: 2}, 0, 0, time.Now())
+
+	for _, tc := range []struct {
+		chunk world.ChunkCoord
+		owner string
+	}{
+		{chunk: world.ChunkCoord{X: 5, Y: 1}, owner: "east"},
+		{chunk: world.ChunkCoord{X: 5, Y: 2}, owner: ""},
+		{chunk: world.ChunkCoord{X: 6, Y: 0}, owner: ""},
+		{chunk: world.ChunkCoord{X: 2, Y: 3}, owner: "south"},
+		{chunk: world.ChunkCoord{X: 2, Y: 4}, owner: ""},
+	} {
+		info, ok := neighbors.neighborForChunk(tc.chunk)
+		got := ""
+		if ok {
+			got = info.serverID
+		}
+		if got != tc.owner {
+			t.Errorf("neighborForChunk(%v) = %q, want %q", tc.chunk, got, tc.owner)
+		}
+	}
+
+	// An ack without a Y span describes a square region.
+	neighbors.updateFromAck("127.0.0.1:9001", "", "east", world.ChunkCoord{X: 3, Y: 0}, 4, 0, 0, time.Now())
+	if info, ok := neighbors.neighborForChunk(world.ChunkCoord{X: 6, Y: 3}); !ok || info.serverID != "east" {
+		t.Fatalf("expected square span from ack to cover (6,3)")
+	}
+}
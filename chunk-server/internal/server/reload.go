@@ -0,0 +1,190 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"chunkserver/internal/clock"
+	"chunkserver/internal/config"
+	"chunkserver/internal/logging"
+)
+
+// loopTickers tracks the run loop tickers whose cadence may change on reload.
+type loopTickers struct {
+	state          clock.Ticker
+	stateInterval  time.Duration
+	entity         clock.Ticker
+	entityInterval time.Duration
+	// idleChunk sweeps for idle chunks; it is stopped, and idleChunkInterval
+	// zero, while server.chunkIdleTTL is unset.
+	idleChunk         clock.Ticker
+	idleChunkInterval time.Duration
+}
+
+// newLoopTickers starts the run loop tickers on clk, or on the system clock
+// when clk is nil.
+func newLoopTickers(cfg *config.Config, clk clock.Clock) *loopTickers {
+	if clk == nil {
+		clk = clock.Real()
+	}
+	stateInterval := cfg.Server.StateStreamRate.Duration()
+	entityInterval := cfg.Entities.EntityTickRate.Duration()
+	t := &loopTickers{
+		state:          clk.NewTicker(stateInterval),
+		stateInterval:  stateInterval,
+		entity:         clk.NewTicker(entityInterval),
+		entityInterval: entityInterval,
+		idleChunk:      clk.NewTicker(time.Hour),
+	}
+	t.idleChunk.Stop()
+	t.resetIdleChunk(cfg.Server.ChunkIdleTTL.Duration())
+	return t
+}
+
+func (t *loopTickers) reset(cfg *config.Config) {
+	if interval := cfg.Server.StateStreamRate.Duration(); interval != t.stateInterval {
+		t.state.Reset(interval)
+		t.stateInterval = interval
+	}
+	if interval := cfg.Entities.EntityTickRate.Duration(); interval != t.entityInterval {
+		t.entity.Reset(interval)
+		t.entityInterval = interval
+	}
+	t.resetIdleChunk(cfg.Server.ChunkIdleTTL.Duration())
+}
+
+// resetIdleChunk starts, retimes, or stops the idle chunk sweep for ttl.
+func (t *loopTickers) resetIdleChunk(ttl time.Duration) {
+	interval := time.Duration(0)
+	if ttl > 0 {
+		interval = idleChunkSweepInterval(ttl)
+	}
+	if interval == t.idleChunkInterval {
+		return
+	}
+	if interval > 0 {
+		t.idleChunk.Reset(interval)
+	} else {
+		t.idleChunk.Stop()
+	}
+	t.idleChunkInterval = interval
+}
+
+func (t *loopTickers) stop() {
+	t.state.Stop()
+	t.entity.Stop()
+	t.idleChunk.Stop()
+}
+
+// Reload validates cfg and schedules the runtime-safe subset of it to be
+// applied by the run loop: stream and tick rates, the chunk generation limit,
+// the log level, the idle chunk TTL, the summary resync interval, the entity
+// sleep threshold, the migration attempt limit, pathfinding limits, weather,
+// physics parameters, and the network capture path. Settings that shape
+// resident state (server identity, chunk geometry, listen address) must match
+// the running configuration; reloads that change them are rejected and the
+// current configuration stays in effect.
+func (s *Server) Reload(next *config.Config) error {
+	if next == nil {
+		return errors.New("config is nil")
+	}
+	if err := next.Validate(); err != nil {
+		return fmt.Errorf("validate config: %w", err)
+	}
+	if err := checkReloadable(s.currentConfig(), next); err != nil {
+		return err
+	}
+	select {
+	case s.reloads <- next:
+		return nil
+	default:
+		return errors.New("a config reload is already pending")
+	}
+}
+
+func checkReloadable(current, next *config.Config) error {
+	if next.Server.ID != current.Server.ID {
+		return errors.New("server.id cannot change at runtime")
+	}
+	if next.Server.GlobalChunkOrigin != current.Server.GlobalChunkOrigin {
+		return errors.New("server.globalChunkOrigin cannot change at runtime")
+	}
+	if next.Chunk != current.Chunk {
+		return errors.New("chunk dimensions cannot change at runtime")
+	}
+	if next.Server.Storage != current.Server.Storage || next.Server.StorageDir != current.Server.StorageDir ||
+		next.Server.StorageMaxFileBytes != current.Server.StorageMaxFileBytes {
+		return errors.New("server.storage cannot change at runtime")
+	}
+	if next.Network.ListenUDP != current.Network.ListenUDP {
+		return errors.New("network.listenUdp cannot change at runtime")
+	}
+	if next.Network.ListenHTTP != current.Network.ListenHTTP {
+		return errors.New("network.listenHttp cannot change at runtime")
+	}
+	if next.Server.StateStreamRate <= 0 {
+		return errors.New("server.stateStreamRate must be positive")
+	}
+	if next.Entities.EntityTickRate <= 0 {
+		return errors.New("entities.entityTickRate must be positive")
+	}
+	return nil
+}
+
+// applyReload merges the reloadable settings from next into the active
+// configuration and retunes the components that consume them.
+func (s *Server) applyReload(next *config.Config, tickers *loopTickers) {
+	current := s.currentConfig()
+	merged := *current
+	merged.Server.StateStreamRate = next.Server.StateStreamRate
+	merged.Server.EntityStreamRate = next.Server.EntityStreamRate
+	merged.Server.MaxConcurrentLoads = next.Server.MaxConcurrentLoads
+	merged.Server.LogLevel = next.Server.LogLevel
+	merged.Entities.EntityTickRate = next.Entities.EntityTickRate
+	merged.Entities.SleepAfterTicks = next.Entities.SleepAfterTicks
+	merged.Server.ChunkIdleTTL = next.Server.ChunkIdleTTL
+	merged.Server.SummaryResync = next.Server.SummaryResync
+	merged.Pathfinding = next.Pathfinding
+	merged.Environment = next.Environment
+	merged.Environment.Seed = current.Environment.Seed
+	merged.Physics = next.Physics
+	merged.Network.TransferMaxAttempts = next.Network.TransferMaxAttempts
+	if next.Network.RecordPath != current.Network.RecordPath && s.net != nil {
+		if err := s.setRecordPath(next.Network.RecordPath); err != nil {
+			s.logger.Warnf("network capture unchanged: %v", err)
+		} else {
+			merged.Network.RecordPath = next.Network.RecordPath
+		}
+	}
+	s.cfgMu.Lock()
+	s.cfg = &merged
+	s.physics = entityPhysics(merged.Physics)
+	s.cfgMu.Unlock()
+	if level, err := logging.ParseLevel(merged.Server.LogLevel); err == nil {
+		s.logger.SetLevel(level)
+	}
+	if s.navigator != nil {
+		s.navigator.SetOptions(searchOptions(merged.Pathfinding))
+	}
+	if s.world != nil {
+		s.world.SetMaxConcurrentLoads(merged.Server.MaxConcurrentLoads)
+		s.world.SetStabilityParams(stabilityParams(merged.Physics))
+	}
+	if s.migrationQueue != nil {
+		s.migrationQueue.SetMaxAttempts(merged.Network.TransferMaxAttempts)
+	}
+	if s.entities != nil {
+		s.entities.SetSleepAfter(merged.Entities.SleepAfterTicks)
+	}
+	if s.env != nil {
+		s.env.Reconfigure(convertEnvironmentConfig(merged.Environment))
+	}
+	if tickers != nil {
+		tickers.reset(&merged)
+	}
+	s.logger.Info("config reloaded",
+		"stateStream", merged.Server.StateStreamRate.Duration(),
+		"entityTick", merged.Entities.EntityTickRate.Duration(),
+		"maxSearchNodes", merged.Pathfinding.MaxSearchNodes)
+}
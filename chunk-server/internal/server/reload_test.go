@@ -0,0 +1,250 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"chunkserver/internal/clock"
+	"chunkserver/internal/config"
+	"chunkserver/internal/network"
+	"chunkserver/internal/world"
+)
+
+func TestReloadUpdatesStateStreamTicker(t *testing.T) {
+	cfg := config.Default()
+	srv := &Server{
+		cfg:     cfg,
+		logger:  noopLogger(),
+		reloads: make(chan *config.Config, 1),
+	}
+	tickers := newLoopTickers(cfg, nil)
+	defer tickers.stop()
+
+	next := config.Default()
+	next.Server.StateStreamRate = config.Duration(750 * time.Millisecond)
+	next.Pathfinding.MaxSearchNodes = 1234
+
+	if err := srv.Reload(next); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	select {
+	case pending := <-srv.reloads:
+		srv.applyReload(pending, tickers)
+	default:
+		t.Fatalf("expected reload to be queued for the run loop")
+	}
+
+	if tickers.stateInterval != 750*time.Millisecond {
+		t.Fatalf("expected state ticker interval 750ms, got %s", tickers.stateInterval)
+	}
+	if tickers.entityInterval != cfg.Entities.EntityTickRate.Duration() {
+		t.Fatalf("expected entity ticker interval to be unchanged, got %s", tickers.entityInterval)
+	}
+	if got := srv.cfg.Server.StateStreamRate.Duration(); got != 750*time.Millisecond {
+		t.Fatalf("expected active config stream rate 750ms, got %s", got)
+	}
+	if got := srv.cfg.Pathfinding.MaxSearchNodes; got != 1234 {
+		t.Fatalf("expected active config max search nodes 1234, got %d", got)
+	}
+}
+
+func TestReloadStartsAndRetimesIdleChunkSweep(t *testing.T) {
+	cfg := config.Default()
+	srv := &Server{cfg: cfg, logger: noopLogger()}
+	tickers := newLoopTickers(cfg, nil)
+	defer tickers.stop()
+	if tickers.idleChunkInterval != 0 {
+		t.Fatalf("expected no idle chunk sweep without a TTL, got %s", tickers.idleChunkInterval)
+	}
+
+	next := config.Default()
+	next.Server.ChunkIdleTTL = config.Duration(10 * time.Millisecond)
+	srv.applyReload(next, tickers)
+	if got := srv.currentConfig().Server.ChunkIdleTTL.Duration(); got != 10*time.Millisecond {
+		t.Fatalf("expected active chunk idle TTL 10ms, got %s", got)
+	}
+	if tickers.idleChunkInterval != 5*time.Millisecond {
+		t.Fatalf("expected a 5ms sweep after enabling the TTL, got %s", tickers.idleChunkInterval)
+	}
+	select {
+	case <-tickers.idleChunk.C():
+	case <-time.After(time.Second):
+		t.Fatalf("expected the idle chunk sweep to fire")
+	}
+
+	srv.applyReload(config.Default(), tickers)
+	if tickers.idleChunkInterval != 0 {
+		t.Fatalf("expected the sweep stopped after clearing the TTL, got %s", tickers.idleChunkInterval)
+	}
+}
+
+func TestLoopTickersRunOnTheServerClock(t *testing.T) {
+	start := time.Unix(0, 0)
+	clk := clock.NewManual(start)
+	cfg := config.Default()
+	cfg.Server.StateStreamRate = config.Duration(time.Hour)
+	cfg.Entities.EntityTickRate = config.Duration(time.Hour)
+	cfg.Server.ChunkIdleTTL = config.Duration(10 * time.Second)
+	srv := &Server{cfg: cfg, logger: noopLogger(), clock: clk}
+	tickers := newLoopTickers(cfg, srv.clock)
+	defer tickers.stop()
+
+	sweeps := make(chan time.Time)
+	go func() {
+		for tick := range tickers.idleChunk.C() {
+			sweeps <- tick
+		}
+	}()
+	sweep := func() time.Time {
+		select {
+		case tick := <-sweeps:
+			return tick
+		case <-time.After(time.Second):
+			t.Fatal("expected the idle chunk sweep to fire")
+			return time.Time{}
+		}
+	}
+
+	go clk.Advance(5 * time.Second)
+	if tick := sweep(); !tick.Equal(start.Add(5 * time.Second)) {
+		t.Fatalf("sweep at %v, want %v", tick, start.Add(5*time.Second))
+	}
+
+	next := config.Default()
+	next.Server.StateStreamRate = config.Duration(time.Hour)
+	next.Entities.EntityTickRate = config.Duration(time.Hour)
+	next.Server.ChunkIdleTTL = config.Duration(2 * time.Second)
+	srv.applyReload(next, tickers)
+	go clk.Advance(time.Second)
+	if tick := sweep(); !tick.Equal(start.Add(6 * time.Second)) {
+		t.Fatalf("retimed sweep at %v, want %v", tick, start.Add(6*time.Second))
+	}
+}
+
+func TestReloadRetunesStabilityModel(t *testing.T) {
+	cfg := config.Default()
+	srv := &Server{
+		cfg:    cfg,
+		logger: noopLogger(),
+		world:  world.NewManager(world.ServerRegion{ChunksX: 1, ChunksY: 1, ChunkDimension: world.Dimensions{Width: 4, Depth: 4, Height: 4}}, stubGenerator{}),
+	}
+
+	next := config.Default()
+	next.Physics.SupportFactor = 1.5
+	next.Physics.CollapseImpactRadius = 6
+	srv.applyReload(next, nil)
+
+	if got := srv.world.StabilityParams().SupportFactor; got != 1.5 {
+		t.Fatalf("expected world support factor 1.5, got %v", got)
+	}
+	if got := srv.cfg.Physics.CollapseImpactRadius; got != 6 {
+		t.Fatalf("expected collapse impact radius 6, got %v", got)
+	}
+}
+
+func TestReloadSwapsConfigWhileHandlersRead(t *testing.T) {
+	cfg := config.Default()
+	srv := &Server{
+		cfg:     cfg,
+		logger:  noopLogger(),
+		physics: entityPhysics(cfg.Physics),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			if srv.currentConfig().Server.ID == "" || srv.basePhysics().Gravity == 0 {
+				t.Error("expected a complete config snapshot")
+				return
+			}
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		next := config.Default()
+		next.Pathfinding.MaxSearchNodes = i + 1
+		srv.applyReload(next, nil)
+	}
+	wg.Wait()
+
+	if got := srv.currentConfig().Pathfinding.MaxSearchNodes; got != 200 {
+		t.Fatalf("expected the last reload to stay active, got max search nodes %d", got)
+	}
+}
+
+func TestReloadRejectsImmutableChanges(t *testing.T) {
+	cfg := config.Default()
+	srv := &Server{
+		cfg:     cfg,
+		logger:  noopLogger(),
+		reloads: make(chan *config.Config, 1),
+	}
+
+	next := config.Default()
+	next.Network.ListenUDP = ":29000"
+	if err := srv.Reload(next); err == nil {
+		t.Fatalf("expected listen address change to be rejected")
+	}
+
+	next = config.Default()
+	next.Chunk.Height = cfg.Chunk.Height * 2
+	if err := srv.Reload(next); err == nil {
+		t.Fatalf("expected chunk dimension change to be rejected")
+	}
+
+	next = config.Default()
+	next.Server.ID = ""
+	if err := srv.Reload(next); err == nil {
+		t.Fatalf("expected invalid config to be rejected")
+	}
+
+	if len(srv.reloads) != 0 {
+		t.Fatalf("expected rejected reloads to leave nothing queued")
+	}
+	if srv.cfg != cfg {
+		t.Fatalf("expected active config to be untouched")
+	}
+}
+
+func TestReloadTogglesNetworkCapture(t *testing.T) {
+	netSrv, err := network.Listen("127.0.0.1:0", noopLogger(), 0)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer netSrv.Close()
+	cfg := config.Default()
+	srv := &Server{cfg: cfg, net: netSrv, logger: noopLogger()}
+
+	path := filepath.Join(t.TempDir(), "session.capture")
+	next := config.Default()
+	next.Network.RecordPath = path
+	srv.applyReload(next, nil)
+	if err := netSrv.Send(netSrv.LocalAddr().String(), network.MessageNeighborHello, nil); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	srv.applyReload(config.Default(), nil)
+	if srv.cfg.Network.RecordPath != "" {
+		t.Fatalf("expected recording to be switched off, got %q", srv.cfg.Network.RecordPath)
+	}
+	if err := netSrv.Send(netSrv.LocalAddr().String(), network.MessageNeighborAck, nil); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open capture: %v", err)
+	}
+	defer file.Close()
+	records, err := network.ReadCapture(file)
+	if err != nil {
+		t.Fatalf("read capture: %v", err)
+	}
+	if len(records) != 1 || records[0].Envelope.Type != network.MessageNeighborHello {
+		t.Fatalf("expected only the hello sent while recording, got %+v", records)
+	}
+}
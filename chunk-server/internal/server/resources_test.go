@@ -0,0 +1,74 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"chunkserver/internal/entities"
+	"chunkserver/internal/world"
+)
+
+func powerBlock(output float64) entities.EntityBlock {
+	return entities.EntityBlock{
+		Role:  entities.BlockRolePower,
+		Block: world.Block{Metadata: map[string]any{"power_output": output}},
+	}
+}
+
+func TestPoweredWeaponFiresUntilPowerBlockRemoved(t *testing.T) {
+	srv := newExplosionTestServer(t)
+	gunner := addGunner(t, srv, false, entities.Vec3{X: 14, Y: 2, Z: 1})
+	gunner.Blocks[1].Block.Metadata["power_draw"] = 5.0
+	gunner.Blocks = append(gunner.Blocks, powerBlock(10))
+
+	srv.tickEntities(250*time.Millisecond, 1)
+	shots := firedProjectiles(srv)
+	if len(shots) != 1 {
+		t.Fatalf("expected a powered weapon to fire, got %d projectiles", len(shots))
+	}
+	first := shots[0]
+	if value, _ := gunner.Attribute("power_available"); value != 5 {
+		t.Fatalf("expected 5 power left after the weapon's draw, got %v", value)
+	}
+
+	gunner.Blocks = gunner.Blocks[:2]
+	srv.dirtyEntities = make(map[entities.ID]entities.Entity)
+	for i := 0; i < 8; i++ {
+		srv.tickEntities(250*time.Millisecond, 1)
+	}
+	for _, id := range firedProjectiles(srv) {
+		if id != first {
+			t.Fatalf("expected an unpowered weapon to hold fire, got projectile %s", id)
+		}
+	}
+	if gunner.SystemOnline(entities.BlockRoleWeapon) {
+		t.Fatalf("expected weapons offline without a power block")
+	}
+}
+
+func TestPoweredThrustersKeepUnitAloft(t *testing.T) {
+	srv := newExplosionTestServer(t)
+	flyer := addUnit(t, srv, "flyer", entities.Vec3{X: 4, Y: 4, Z: 6})
+	flyer.Capabilities.CanFly = true
+	flyer.Blocks = []entities.EntityBlock{
+		{
+			Role:  entities.BlockRoleThruster,
+			Block: world.Block{Metadata: map[string]any{"power_draw": 3.0}},
+		},
+		powerBlock(5),
+	}
+
+	srv.tickEntities(100*time.Millisecond, 1)
+	if vel := flyer.Snapshot().Velocity; vel.Z != 0 {
+		t.Fatalf("expected powered thrusters to hold altitude, got vertical velocity %v", vel.Z)
+	}
+
+	flyer.Blocks = flyer.Blocks[:1]
+	srv.tickEntities(100*time.Millisecond, 1)
+	if flyer.SystemOnline(entities.BlockRoleThruster) {
+		t.Fatalf("expected thrusters offline without a power block")
+	}
+	if vel := flyer.Snapshot().Velocity; vel.Z >= 0 {
+		t.Fatalf("expected the unit to start falling, got vertical velocity %v", vel.Z)
+	}
+}
@@ -3,19 +3,22 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math"
 	"net"
-	"path/filepath"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"chunkserver/internal/ai"
+	"chunkserver/internal/clock"
 	"chunkserver/internal/config"
 	"chunkserver/internal/entities"
 	"chunkserver/internal/environment"
+	"chunkserver/internal/logging"
 	"chunkserver/internal/migration"
 	"chunkserver/internal/network"
 	"chunkserver/internal/pathfinding"
@@ -24,13 +27,19 @@ import (
 )
 
 type Server struct {
+	// cfg is replaced, never mutated, by the run loop on reload; read it
+	// through currentConfig.
 	cfg       *config.Config
+	cfgMu     sync.RWMutex
 	world     *world.Manager
 	entities  *entities.Manager
 	navigator *pathfinding.BlockNavigator
 	net       *network.Server
-	logger    *log.Logger
+	logger    *logging.Logger
 	env       *environment.Environment
+	// clock times the tick loop, migrations, and outgoing timestamps. Nil
+	// means the system clock.
+	clock clock.Clock
 
 	movementWorkers int
 
@@ -38,6 +47,8 @@ type Server struct {
 
 	chunkTraversal    []world.LocalChunkIndex
 	chunkCursor       int
+	baselineSent      bool
+	summaryVersions   map[world.ChunkCoord]uint64
 	streamSeq         uint64
 	dirtyEntities     map[entities.ID]entities.Entity
 	dirtyChunks       map[world.ChunkCoord]struct{}
@@ -47,45 +58,80 @@ type Server struct {
 	neighbors         *neighborManager
 	neighborSeq       uint64
 	migrationQueue    *migration.Queue
+	transfersMu       sync.Mutex // guards inFlightTransfers, which acks reach from network goroutines
 	inFlightTransfers map[entities.ID]migration.Request
 	transferSeq       uint64
 
+	// baselineEnd is the traversal cursor at which the current full pass of
+	// chunk summaries is complete, and baselineDoneAt when the last pass
+	// finished. resyncRequested asks the run loop for another pass.
+	baselineEnd     int
+	baselineDoneAt  time.Time
+	resyncRequested atomic.Bool
+
+	chunkTransfers network.ChunkTransferAssembler
+	projectileSeq  atomic.Uint64
+
 	envState environment.State
 	envMu    sync.RWMutex
+	// physics is the clear-weather entity physics read from cfg.Physics. It is
+	// guarded by cfgMu.
+	physics entities.PhysicsParams
+
+	reloads chan *config.Config
+
+	pathRequests pathRequestCounter
+	pathSearches pathSearches
+	draining     atomic.Bool
 
 	dirtyMu sync.Mutex
 }
 
-const (
-	collapseImpactRadius = 3.5
-	collapseImpactDamage = 45.0
-)
-
 func New(cfg *config.Config) (*Server, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("config is nil")
 	}
+	region, err := world.NewServerRegion(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	logger := log.New(log.Writer(), "chunk-server ", log.LstdFlags|log.Lmicroseconds)
-	netSrv, err := network.Listen(cfg.Network.ListenUDP, logger, cfg.Network.MaxDatagramSizeBytes)
+	level, err := logging.ParseLevel(cfg.Server.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+	base := logging.New(log.Writer(), level).With("server", cfg.Server.ID)
+	logger := base.With("component", "server")
+	netSrv, err := network.Listen(cfg.Network.ListenUDP, base.With("component", "network"), cfg.Network.MaxDatagramSizeBytes)
 	if err != nil {
 		return nil, err
 	}
 
-	region := world.NewServerRegion(cfg)
-	world.SetStorageProvider(world.NewDiskStorageProvider(filepath.Join("chunks"), region))
 	terrainGen := terrain.NewNoiseGenerator(cfg.Terrain, cfg.Economy)
+	terrainGen.SetBlockDefinitions(cfg.Blocks)
+	terrainGen.SetLogger(base.With("component", "terrain"))
 	worldManager := world.NewManager(region, terrainGen)
+	worldManager.SetLogger(base.With("component", "world"))
+	worldManager.SetStorageProvider(storageProvider(cfg.Server, region))
+	worldManager.SetMaxConcurrentLoads(cfg.Server.MaxConcurrentLoads)
+	worldManager.SetStabilityParams(stabilityParams(cfg.Physics))
 
 	entityManager := entities.NewManager(cfg.Server.ID)
+	entityManager.SetSleepAfter(cfg.Entities.SleepAfterTicks)
+	entityManager.SetMaxPerChunk(cfg.Entities.MaxEntitiesPerChunk)
 	navigator := pathfinding.NewBlockNavigator(region, worldManager)
+	navigator.SetOptions(searchOptions(cfg.Pathfinding))
 
 	workers := cfg.Entities.MovementWorkers
 	if workers <= 0 {
 		workers = 1
 	}
 
-	env := environment.New(convertEnvironmentConfig(cfg.Environment))
+	clk := clock.Real()
+	worldManager.SetClock(clk)
+	envCfg := convertEnvironmentConfig(cfg.Environment)
+	envCfg.Clock = clk
+	env := environment.New(envCfg)
 
 	initialEnv := env.CurrentState()
 
@@ -97,15 +143,21 @@ func New(cfg *config.Config) (*Server, error) {
 		net:               netSrv,
 		logger:            logger,
 		env:               env,
+		clock:             clk,
 		movementWorkers:   workers,
 		dirtyEntities:     make(map[entities.ID]entities.Entity),
 		dirtyChunks:       make(map[world.ChunkCoord]struct{}),
+		summaryVersions:   make(map[world.ChunkCoord]uint64),
 		deltaBuffer:       newDeltaAccumulator(),
 		neighbors:         newNeighborManager(region, cfg.Network.NeighborEndpoints),
 		migrationQueue:    migration.NewQueue(),
 		inFlightTransfers: make(map[entities.ID]migration.Request),
 		envState:          initialEnv,
+		physics:           entityPhysics(cfg.Physics),
+		reloads:           make(chan *config.Config, 1),
 	}
+	srv.migrationQueue.SetMaxAttempts(cfg.Network.TransferMaxAttempts)
+	srv.chunkTransfers.SetMaxDatagram(cfg.Network.MaxDatagramSizeBytes)
 	var lookup ai.NeighborLookup
 	if srv.neighbors != nil {
 		lookup = func(chunk world.ChunkCoord) (ai.NeighborOwnership, bool) {
@@ -117,12 +169,13 @@ func New(cfg *config.Config) (*Server, error) {
 				ServerID:     info.serverID,
 				Endpoint:     info.endpoint,
 				RegionOrigin: info.origin,
-				RegionSize:   info.size,
+				RegionSize:   info.sizeX,
 			}, true
 		}
 	}
 	srv.ai = ai.NewCoordinator(region, entityManager, navigator, lookup)
-	srv.chunkTraversal = buildCircularChunkTraversal(region.ChunksPerAxis)
+	srv.ai.SetSeed(cfg.Entities.AISeed)
+	srv.chunkTraversal = buildCircularChunkTraversal(region.ChunksX, region.ChunksY)
 	srv.world.SetLighting(world.LightingState{
 		Ambient:     initialEnv.Lighting.Ambient,
 		SunAngle:    initialEnv.Lighting.SunAngle,
@@ -130,109 +183,157 @@ func New(cfg *config.Config) (*Server, error) {
 		WeatherTint: initialEnv.Lighting.WeatherTint,
 	})
 	srv.registerHandlers()
+	if err := srv.setRecordPath(cfg.Network.RecordPath); err != nil {
+		netSrv.Close()
+		return nil, err
+	}
 	return srv, nil
 }
 
+// storageProvider builds the chunk storage selected by server.storage: files
+// under server.storageDir split at server.storageMaxFileBytes, or memory that
+// is lost on shutdown.
+func storageProvider(cfg config.ServerConfig, region world.ServerRegion) world.StorageProvider {
+	if cfg.Storage == config.StorageMemory {
+		return world.NewMemoryStorageProvider()
+	}
+	dir := cfg.StorageDir
+	if dir == "" {
+		dir = "chunks"
+	}
+	provider := world.NewDiskStorageProvider(dir, region)
+	provider.SetMaxFileSize(cfg.StorageMaxFileBytes)
+	return provider
+}
+
 func (s *Server) registerHandlers() {
+	s.net.Register(network.MessageHello, s.onMainServerHello)
 	s.net.Register(network.MessageNeighborHello, s.onNeighborHello)
 	s.net.Register(network.MessageNeighborAck, s.onNeighborAck)
 	s.net.Register(network.MessageEntityQuery, s.onEntityQuery)
 	s.net.Register(network.MessagePathRequest, s.onPathRequest)
+	s.net.Register(network.MessagePathCancel, s.onPathCancel)
+	s.net.Register(network.MessageBlockQuery, s.onBlockQuery)
+	s.net.Register(network.MessageColumnQuery, s.onColumnQuery)
 	s.net.Register(network.MessageTransferClaim, s.onTransferClaim)
 	s.net.Register(network.MessageTransferRequest, s.onTransferRequest)
 	s.net.Register(network.MessageTransferAck, s.onTransferAck)
+	s.net.Register(network.MessageChunkTransfer, s.onChunkTransfer)
 }
 
 func (s *Server) Run(ctx context.Context) error {
+	cfg := s.currentConfig()
 	defer s.net.Close()
+	defer s.setRecordPath("")
+
+	// The network outlives ctx so the drain phase can still send the final
+	// flush and receive migration acks.
+	netCtx, stopNet := context.WithCancel(context.WithoutCancel(ctx))
+	defer stopNet()
 
 	ctx, cancel := context.WithCancel(ctx)
 
 	go func() {
-		if err := s.net.Serve(ctx); err != nil && ctx.Err() == nil {
-			s.logger.Printf("network server stopped: %v", err)
+		if err := s.net.Serve(netCtx); err != nil && netCtx.Err() == nil {
+			s.logger.Warnf("network server stopped: %v", err)
 			cancel()
 		}
 	}()
 
+	if err := s.serveHTTP(netCtx); err != nil {
+		cancel()
+		return fmt.Errorf("start stats endpoint: %w", err)
+	}
+
 	s.announceToMainServers()
 
-	movement := newMovementEngine(s, s.cfg.Server.TickRate.Duration(), s.movementWorkers)
+	movement := newMovementEngine(s, cfg.Server.TickRate.Duration(), s.movementWorkers, s.clock)
 	movement.Start(ctx)
 	defer func() {
 		cancel()
 		movement.Wait()
 	}()
 
-	stateTicker := time.NewTicker(s.cfg.Server.StateStreamRate.Duration())
-	defer stateTicker.Stop()
-
-	entityTicker := time.NewTicker(s.cfg.Entities.EntityTickRate.Duration())
-	defer entityTicker.Stop()
+	tickers := newLoopTickers(cfg, s.clock)
+	defer tickers.stop()
 
-	var discoveryTicker *time.Ticker
 	var discoveryC <-chan time.Time
-	if interval := s.cfg.Network.DiscoveryInterval.Duration(); interval > 0 {
-		discoveryTicker = time.NewTicker(interval)
-		discoveryC = discoveryTicker.C
+	if interval := cfg.Network.DiscoveryInterval.Duration(); interval > 0 {
+		discoveryTicker := s.serverClock().NewTicker(interval)
+		discoveryC = discoveryTicker.C()
 		defer discoveryTicker.Stop()
 	}
 
 	if discoveryC != nil {
-		s.discoverNeighbors(time.Now())
+		s.discoverNeighbors(s.now())
 	}
 
 	for {
 		select {
 		case <-ctx.Done():
+			movement.Wait()
+			s.drainOnShutdown()
 			return ctx.Err()
-		case <-entityTicker.C:
+		case <-tickers.entity.C():
 			s.flushDirtyEntities()
 			s.flushVoxelDeltas()
 			s.processMigrationQueue()
-		case <-stateTicker.C:
+		case <-tickers.state.C():
 			s.broadcastChunkSummaries(ctx)
+			s.broadcastEnvironment()
 		case <-discoveryC:
-			s.discoverNeighbors(time.Now())
+			s.discoverNeighbors(s.now())
+		case <-tickers.idleChunk.C():
+			s.unloadIdleChunks()
+		case next := <-s.reloads:
+			s.applyReload(next, tickers)
 		}
 	}
 }
 
+// currentConfig returns the active configuration. Callers should load it once
+// per operation so a concurrent reload cannot mix settings from two configs.
+func (s *Server) currentConfig() *config.Config {
+	s.cfgMu.RLock()
+	defer s.cfgMu.RUnlock()
+	return s.cfg
+}
+
+// basePhysics returns the clear-weather entity physics of the active
+// configuration.
+func (s *Server) basePhysics() entities.PhysicsParams {
+	s.cfgMu.RLock()
+	defer s.cfgMu.RUnlock()
+	return s.physics
+}
+
+// serverClock returns the server's clock, or the system clock when none is
+// set.
+func (s *Server) serverClock() clock.Clock {
+	if s.clock == nil {
+		return clock.Real()
+	}
+	return s.clock
+}
+
+// now reads the server's clock.
+func (s *Server) now() time.Time {
+	if s.clock == nil {
+		return time.Now()
+	}
+	return s.clock.Now()
+}
+
 func (s *Server) tickEntities(delta time.Duration, workers int) {
+	envState := s.stepEnvironment(delta)
 	if s.ai != nil {
 		s.ai.Tick(delta)
 	}
-	var envState environment.State
-	if s.env != nil {
-		envState = s.env.Step(delta)
-		s.world.SetLighting(world.LightingState{
-			Ambient:     envState.Lighting.Ambient,
-			SunAngle:    envState.Lighting.SunAngle,
-			FogDensity:  envState.Lighting.FogDensity,
-			WeatherTint: envState.Lighting.WeatherTint,
-		})
-		s.envMu.Lock()
-		s.envState = envState
-		s.envMu.Unlock()
-	}
-	physics := entities.PhysicsParams{
-		Gravity:         9.8,
-		AirDrag:         0.4,
-		GroundFriction:  4,
-		MaxFallSpeed:    150,
-		SupportsGravity: true,
-	}
-	if envState.Physics.GravityScale != 0 {
-		physics.Gravity *= envState.Physics.GravityScale
-	}
-	if envState.Physics.DragScale != 0 {
-		physics.AirDrag *= envState.Physics.DragScale
-	}
-	if envState.Physics.GroundFrictionScale != 0 {
-		physics.GroundFriction *= envState.Physics.GroundFrictionScale
-	}
+	physics := environmentPhysics(s.basePhysics(), envState)
+	now := s.now()
 
 	dirty := s.entities.ApplyConcurrent(workers, func(ent *entities.Entity) {
+		ent.SetLastTick(now)
 		switch ent.Kind {
 		case entities.KindProjectile:
 			s.tickProjectile(ent, delta, physics, envState)
@@ -241,21 +342,72 @@ func (s *Server) tickEntities(delta time.Duration, workers int) {
 		}
 	})
 
+	s.entities.UpdatePositions()
 	s.recordDirtyEntities(dirty)
+	// Reap after recording so a dying entity's final, removed state is the
+	// one streamed.
+	s.recordDirtyEntities(s.entities.ReapDying())
 }
 
-func (s *Server) tickProjectile(ent *entities.Entity, delta time.Duration, physics entities.PhysicsParams, envState environment.State) {
-	ent.ApplyGravity(physics, delta)
-	ent.ApplyDrag(physics, delta)
-	if envState.Weather.WindSpeed > 0 && envState.Weather.Intensity > 0 {
-		magnitude := envState.Weather.WindSpeed * envState.Weather.Intensity * delta.Seconds()
-		ent.AddVelocity(entities.Vec3{
-			X: math.Cos(envState.Weather.WindDirection) * magnitude,
-			Y: math.Sin(envState.Weather.WindDirection) * magnitude,
-			Z: 0,
-		})
+// entityPhysics builds the clear-weather physics coefficients applied to
+// entity ticks, before environment modifiers are layered on top, from cfg.
+func entityPhysics(cfg config.PhysicsConfig) entities.PhysicsParams {
+	return entities.PhysicsParams{
+		Gravity:         cfg.Gravity,
+		AirDrag:         cfg.AirDrag,
+		GroundFriction:  cfg.GroundFriction,
+		MaxFallSpeed:    cfg.MaxFallSpeed,
+		SupportsGravity: true,
 	}
-	ent.Advance(delta)
+}
+
+// stabilityParams builds the structural support coefficients collapse checks
+// use from cfg.
+func stabilityParams(cfg config.PhysicsConfig) world.StabilityParams {
+	return world.StabilityParams{
+		GroundSupport:  cfg.GroundSupportForce,
+		HangingPenalty: cfg.HangingPenalty,
+		SupportFactor:  cfg.SupportFactor,
+	}
+}
+
+// stepEnvironment advances the environment simulation, publishes its lighting to
+// the world manager, and records the resulting state for readers.
+func (s *Server) stepEnvironment(delta time.Duration) environment.State {
+	if s.env == nil {
+		return environment.State{}
+	}
+	envState := s.env.Step(delta)
+	s.world.SetLighting(world.LightingState{
+		Ambient:     envState.Lighting.Ambient,
+		SunAngle:    envState.Lighting.SunAngle,
+		FogDensity:  envState.Lighting.FogDensity,
+		WeatherTint: envState.Lighting.WeatherTint,
+	})
+	s.envMu.Lock()
+	s.envState = envState
+	s.envMu.Unlock()
+	return envState
+}
+
+// environmentPhysics scales the baseline entity physics by the weather-driven
+// modifiers in envState. Zero modifiers leave the baseline untouched.
+func environmentPhysics(base entities.PhysicsParams, envState environment.State) entities.PhysicsParams {
+	physics := base
+	if envState.Physics.GravityScale != 0 {
+		physics.Gravity *= envState.Physics.GravityScale
+	}
+	if envState.Physics.DragScale != 0 {
+		physics.AirDrag *= envState.Physics.DragScale
+	}
+	if envState.Physics.GroundFrictionScale != 0 {
+		physics.GroundFriction *= envState.Physics.GroundFrictionScale
+	}
+	return physics
+}
+
+func (s *Server) tickProjectile(ent *entities.Entity, delta time.Duration, physics entities.PhysicsParams, envState environment.State) {
+	stepProjectile(ent, delta, physics, envState)
 	if life, ok := ent.ReduceAttribute("projectile_life", delta.Seconds()); ok && life <= 0 {
 		s.handleProjectileImpact(ent)
 		ent.FlagCollapse()
@@ -269,11 +421,46 @@ func (s *Server) tickProjectile(ent *entities.Entity, delta time.Duration, physi
 	}
 }
 
+// stepProjectile integrates a projectile's flight for one tick: gravity, air
+// drag, and the crosswind push from the current weather.
+func stepProjectile(ent *entities.Entity, delta time.Duration, physics entities.PhysicsParams, envState environment.State) {
+	ent.ApplyGravity(physics, delta)
+	ent.ApplyDrag(physics, delta)
+	ent.ApplyWind(windVector(envState.Weather), projectileWindCoupling(ent, physics), delta)
+	ent.Advance(delta)
+}
+
+// windVector converts the weather's wind speed and heading into a horizontal
+// velocity in blocks per second, scaled by the weather's intensity so calm
+// skies leave projectiles alone.
+func windVector(weather environment.WeatherState) entities.Vec3 {
+	speed := weather.WindSpeed * weather.Intensity
+	if speed <= 0 {
+		return entities.Vec3{}
+	}
+	return entities.Vec3{
+		X: math.Cos(weather.WindDirection) * speed,
+		Y: math.Sin(weather.WindDirection) * speed,
+	}
+}
+
+// projectileWindCoupling scales the air drag by the projectile's
+// "projectile_cross_section" attribute (1 when unset), so broad or light
+// rounds are pushed around more than slim ones.
+func projectileWindCoupling(ent *entities.Entity, physics entities.PhysicsParams) float64 {
+	crossSection := 1.0
+	if value, ok := ent.Attribute("projectile_cross_section"); ok {
+		crossSection = value
+	}
+	return physics.AirDrag * crossSection
+}
+
 func (s *Server) tickUnit(ent *entities.Entity, delta time.Duration, physics entities.PhysicsParams, envState environment.State) {
 	if value, ok := ent.Attribute("migration_pending"); ok && value > 0 {
 		return
 	}
-	if !ent.Capabilities.CanFly {
+	ent.UpdateResources()
+	if !ent.Capabilities.CanFly || !ent.SystemOnline(entities.BlockRoleThruster) || ent.RoleDestroyed(entities.BlockRoleThruster) {
 		ent.ApplyGravity(physics, delta)
 		ent.ApplyGroundFriction(physics.GroundFriction, delta)
 	} else {
@@ -290,6 +477,8 @@ func (s *Server) tickUnit(ent *entities.Entity, delta time.Duration, physics ent
 	ent.SetAttributeIfDifferent("environment_morale", envState.Behavior.MoraleShift, 1e-3)
 	ent.SetAttributeIfDifferent("environment_phase", float64(envPhaseToInt(envState.Phase)), 0)
 	s.updateEntityChunk(ent)
+	s.fireWeapons(ent, delta, physics)
+	s.mineBlock(ent, delta)
 }
 
 func envPhaseToInt(p environment.Phase) int {
@@ -322,10 +511,37 @@ func convertEnvironmentConfig(cfg config.EnvironmentConfig) environment.Config {
 		RainChance:         cfg.RainChance,
 		WindBase:           cfg.WindBase,
 		WindVariance:       cfg.WindVariance,
+		TransitionHours:    cfg.TransitionHours,
 		Seed:               cfg.Seed,
+		Overrides:          convertWeatherOverrides(cfg.WeatherOverrides),
 	}
 }
 
+func convertWeatherOverrides(cfgs []config.WeatherOverrideConfig) []environment.WeatherOverride {
+	if len(cfgs) == 0 {
+		return nil
+	}
+	overrides := make([]environment.WeatherOverride, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		overrides = append(overrides, environment.WeatherOverride{
+			ID:        cfg.ID,
+			MinChunkX: cfg.MinChunk.X,
+			MinChunkY: cfg.MinChunk.Y,
+			MaxChunkX: cfg.MaxChunk.X,
+			MaxChunkY: cfg.MaxChunk.Y,
+			Weather: environment.WeatherState{
+				Kind:          environment.WeatherKind(cfg.Kind),
+				Intensity:     cfg.Intensity,
+				WindSpeed:     cfg.WindSpeed,
+				WindDirection: cfg.WindDirection,
+				Precipitation: cfg.Precipitation,
+			},
+			BlendChunks: cfg.BlendChunks,
+		})
+	}
+	return overrides
+}
+
 func (s *Server) handleProjectileImpact(ent *entities.Entity) {
 	if flagged, ok := ent.Attribute("_detonated"); ok && flagged > 0 {
 		return
@@ -333,11 +549,7 @@ func (s *Server) handleProjectileImpact(ent *entities.Entity) {
 	ent.SetAttribute("_detonated", 1)
 
 	pos := ent.PositionVec()
-	center := world.BlockCoord{
-		X: int(math.Floor(pos.X)),
-		Y: int(math.Floor(pos.Y)),
-		Z: int(math.Floor(pos.Z)),
-	}
+	center := world.BlockFromVec(pos)
 	if center.Z < 0 {
 		center.Z = 0
 	}
@@ -350,33 +562,62 @@ func (s *Server) handleProjectileImpact(ent *entities.Entity) {
 	if d, ok := ent.Attribute("explosion_damage"); ok && d > 0 {
 		damage = d
 	}
+	falloff := world.FalloffLinear
+	if f, ok := ent.Attribute("explosion_falloff"); ok {
+		falloff = world.Falloff(f)
+	}
+
+	s.wakeAround(center, radius)
 
-	summary, err := s.world.ApplyExplosion(context.Background(), center, radius, damage)
+	summary, err := s.world.ApplyExplosion(context.Background(), center, radius, damage, falloff)
 	if err != nil {
-		s.logger.Printf("apply explosion at %v: %v", center, err)
+		s.logger.Warn("explosion failed", "entity", ent.ID, "center", center, "err", err)
 		return
 	}
+	s.entities.WakeChunks(summary.DirtyChunks()...)
 	s.queueVoxelDeltas(summary)
-	s.damageEntitiesFromCollapses(summary)
+	s.damageEntitiesFromExplosion(ent, pos, radius, damage, falloff, summary)
 	s.markChunksDirty(summary.DirtyChunks())
 
 	if changes := summary.Changes(); len(changes) > 0 {
-		s.logger.Printf("projectile %s detonated at %v affecting %d blocks", ent.ID, center, len(changes))
+		s.logger.Info("explosion", "entity", ent.ID, "center", center, "blocks", len(changes), "collapsed", len(summary.CollapsedBlocks()))
 	}
 }
 
-func (s *Server) updateEntityChunk(ent *entities.Entity) {
+// wakeAround wakes sleeping entities in every chunk within radius blocks of
+// center.
+func (s *Server) wakeAround(center world.BlockCoord, radius float64) {
+	s.entities.WakeChunks(s.chunksAround(center, radius)...)
+}
+
+// chunksAround lists every chunk within radius blocks of center.
+func (s *Server) chunksAround(center world.BlockCoord, radius float64) []world.ChunkCoord {
 	region := s.world.Region()
-	pos := ent.PositionVec()
-	blockX := int(math.Floor(pos.X))
-	blockY := int(math.Floor(pos.Y))
-	chunkCoord := world.ChunkCoord{
-		X: floorDiv(blockX, region.ChunkDimension.Width),
-		Y: floorDiv(blockY, region.ChunkDimension.Depth),
+	reach := int(math.Ceil(radius))
+	minX := world.FloorDiv(center.X-reach, region.ChunkDimension.Width)
+	maxX := world.FloorDiv(center.X+reach, region.ChunkDimension.Width)
+	minY := world.FloorDiv(center.Y-reach, region.ChunkDimension.Depth)
+	maxY := world.FloorDiv(center.Y+reach, region.ChunkDimension.Depth)
+	coords := make([]world.ChunkCoord, 0, (maxX-minX+1)*(maxY-minY+1))
+	for x := minX; x <= maxX; x++ {
+		for y := minY; y <= maxY; y++ {
+			coords = append(coords, world.ChunkCoord{X: x, Y: y})
+		}
 	}
+	return coords
+}
+
+func (s *Server) updateEntityChunk(ent *entities.Entity) {
+	region := s.world.Region()
+	chunkCoord := region.ChunkOfBlock(world.BlockFromVec(ent.PositionVec()))
 	if region.ContainsGlobalChunk(chunkCoord) {
 		if chunkCoord != ent.Chunk.Chunk {
-			s.entities.Transfer(ent.ID, chunkCoord, s.cfg.Server.ID)
+			// A full chunk keeps the entity listed where it was; the move is
+			// retried on later ticks until the chunk has room.
+			if err := s.entities.Transfer(ent.ID, chunkCoord, s.currentConfig().Server.ID); err != nil {
+				s.logger.Debug("chunk move deferred", "entity", ent.ID, "chunk", chunkCoord, "err", err)
+				return
+			}
 			s.recordDirtyEntity(ent)
 			s.prefetchChunkNeighborhood(chunkCoord)
 		}
@@ -399,12 +640,12 @@ func (s *Server) queueMigration(ent *entities.Entity, targetChunk world.ChunkCoo
 	}
 	info, ok := s.neighbors.neighborForChunk(targetChunk)
 	if !ok {
-		s.logger.Printf("migration: no neighbor found for chunk %v (entity %s)", targetChunk, ent.ID)
+		s.logger.Warn("migration skipped: no neighbor owns chunk", "entity", ent.ID, "chunk", targetChunk)
 		return
 	}
 	endpoint := info.endpoint()
 	if endpoint == "" {
-		s.logger.Printf("migration: neighbor %s missing endpoint for entity %s", info.serverID, ent.ID)
+		s.logger.Warn("migration skipped: neighbor has no endpoint", "entity", ent.ID, "neighbor", info.serverID)
 		return
 	}
 
@@ -415,7 +656,7 @@ func (s *Server) queueMigration(ent *entities.Entity, targetChunk world.ChunkCoo
 		TargetChunk:    targetChunk,
 		TargetServer:   info.serverID,
 		TargetEndpoint: endpoint,
-		QueuedAt:       time.Now(),
+		QueuedAt:       s.now(),
 		Reason:         "boundary_exit",
 	}
 	s.migrationQueue.Enqueue(req)
@@ -426,10 +667,10 @@ func (s *Server) processMigrationQueue() {
 	if s.migrationQueue == nil {
 		return
 	}
-	s.retryStaleTransfers(time.Now())
+	s.retryStaleTransfers(s.now())
 	batch := s.migrationQueue.Drain(8)
 	for _, req := range batch {
-		if _, exists := s.inFlightTransfers[req.EntityID]; exists {
+		if s.transferInFlight(req.EntityID) {
 			continue
 		}
 		if ent, ok := s.entities.Entity(req.EntityID); ok {
@@ -437,27 +678,63 @@ func (s *Server) processMigrationQueue() {
 		} else {
 			continue
 		}
+		req.Attempts++
 		if err := s.sendMigrationRequest(req); err != nil {
-			s.logger.Printf("migration: send request for entity %s failed: %v", req.EntityID, err)
-			s.migrationQueue.Enqueue(req)
+			s.logger.Warn("migration request failed", "entity", req.EntityID, "neighbor", req.TargetServer, "err", err)
+			s.retryMigration(req)
 		}
 	}
 }
 
+// retryMigration queues req for another attempt. Once it has used up
+// network.transferMaxAttempts it is dead-lettered instead, and its entity
+// stops waiting on the transfer and is parked back inside the region so the
+// next tick does not queue the same migration again.
+func (s *Server) retryMigration(req migration.Request) bool {
+	if s.migrationQueue.Retry(req) {
+		return true
+	}
+	s.logger.Warn("migration dead-lettered", "entity", req.EntityID, "neighbor", req.TargetServer, "attempts", req.Attempts)
+	if ent, ok := s.entities.Entity(req.EntityID); ok {
+		s.parkInRegion(ent)
+		ent.SetAttribute("migration_pending", 0)
+		s.recordDirtyEntity(ent)
+	}
+	return false
+}
+
+// parkInRegion clamps ent's horizontal position to the region's edge and
+// stops its horizontal motion, leaving it on the last block it may occupy
+// here.
+func (s *Server) parkInRegion(ent *entities.Entity) {
+	bounds := s.world.Region().GlobalBlockBounds()
+	pos := ent.PositionVec()
+	parked := pos
+	parked.X = math.Max(float64(bounds.Min.X), math.Min(pos.X, float64(bounds.Max.X)))
+	parked.Y = math.Max(float64(bounds.Min.Y), math.Min(pos.Y, float64(bounds.Max.Y)))
+	if parked == pos {
+		return
+	}
+	ent.SetPosition(parked)
+	vel := ent.VelocityVec()
+	ent.SetVelocity(entities.Vec3{Z: vel.Z})
+	s.updateEntityChunk(ent)
+}
+
 func (s *Server) sendMigrationRequest(req migration.Request) error {
 	if req.TargetEndpoint == "" {
 		return fmt.Errorf("missing target endpoint")
 	}
-	state := serializeEntity(req.EntitySnapshot)
+	state := transferEntityState(&req)
 	if state.Attributes == nil {
 		state.Attributes = make(map[string]float64)
 	}
 	state.Attributes["migration_pending"] = 1
-	attempt := time.Now()
+	attempt := s.now()
 	nonce := s.nextTransferNonce()
 	msg := network.TransferRequest{
 		EntityID:     string(req.EntityID),
-		FromServer:   s.cfg.Server.ID,
+		FromServer:   s.currentConfig().Server.ID,
 		ToServer:     req.TargetServer,
 		GlobalChunkX: req.TargetChunk.X,
 		GlobalChunkY: req.TargetChunk.Y,
@@ -471,18 +748,34 @@ func (s *Server) sendMigrationRequest(req migration.Request) error {
 	}
 	req.Nonce = nonce
 	req.LastAttempt = attempt
+	s.transfersMu.Lock()
 	s.inFlightTransfers[req.EntityID] = req
+	s.transfersMu.Unlock()
 	return nil
 }
 
+// transferInFlight reports whether a transfer of id awaits its ack.
+func (s *Server) transferInFlight(id entities.ID) bool {
+	s.transfersMu.Lock()
+	defer s.transfersMu.Unlock()
+	_, ok := s.inFlightTransfers[id]
+	return ok
+}
+
 func (s *Server) retryStaleTransfers(now time.Time) {
-	if s == nil || s.cfg == nil || s.migrationQueue == nil {
+	if s == nil || s.migrationQueue == nil {
+		return
+	}
+	cfg := s.currentConfig()
+	if cfg == nil {
 		return
 	}
-	retry := s.cfg.Network.TransferRetry.Duration()
+	retry := cfg.Network.TransferRetry.Duration()
 	if retry <= 0 {
 		return
 	}
+	s.transfersMu.Lock()
+	defer s.transfersMu.Unlock()
 	for id, req := range s.inFlightTransfers {
 		if req.LastAttempt.IsZero() {
 			continue
@@ -494,16 +787,18 @@ func (s *Server) retryStaleTransfers(now time.Time) {
 		req.Nonce = 0
 		req.LastAttempt = time.Time{}
 		req.QueuedAt = now
-		s.migrationQueue.Enqueue(req)
-		s.logger.Printf("migration: retrying transfer for entity %s after timeout", req.EntityID)
+		if s.retryMigration(req) {
+			s.logger.Warn("migration timed out, retrying", "entity", req.EntityID, "neighbor", req.TargetServer)
+		}
 	}
 }
 
 func (s *Server) discoverNeighbors(now time.Time) {
+	cfg := s.currentConfig()
 	if s.neighbors == nil {
 		return
 	}
-	targets := s.neighbors.discoveryTargets(now, s.cfg.Network.DiscoveryInterval.Duration())
+	targets := s.neighbors.discoveryTargets(now, cfg.Network.DiscoveryInterval.Duration())
 	if len(targets) == 0 {
 		return
 	}
@@ -516,22 +811,23 @@ func (s *Server) discoverNeighbors(now time.Time) {
 		}
 		nonce := s.nextNeighborNonce()
 		hello := network.NeighborHello{
-			ServerID:      s.cfg.Server.ID,
-			Listen:        s.cfg.Network.ListenUDP,
+			ServerID:      cfg.Server.ID,
+			Listen:        cfg.Network.ListenUDP,
 			RegionOriginX: region.Origin.X,
 			RegionOriginY: region.Origin.Y,
-			RegionSize:    region.ChunksPerAxis,
+			RegionSize:    region.ChunksX,
+			RegionSizeY:   advertisedSizeY(region),
 			DeltaX:        target.Delta.X,
 			DeltaY:        target.Delta.Y,
 			Timestamp:     nowUTC,
 			Nonce:         nonce,
 		}
 		if err := s.net.Send(target.Endpoint, network.MessageNeighborHello, hello); err != nil {
-			s.logger.Printf("neighbor hello to %s failed: %v", target.Endpoint, err)
+			s.logger.Warn("neighbor hello failed", "endpoint", target.Endpoint, "err", err)
 			continue
 		}
 		s.neighbors.markHelloSent(target.Delta, target.Endpoint, nonce, now)
-		s.logger.Printf("neighbor hello sent to %s (delta %d,%d)", target.Endpoint, target.Delta.X, target.Delta.Y)
+		s.logger.Debug("neighbor hello sent", "endpoint", target.Endpoint, "deltaX", target.Delta.X, "deltaY", target.Delta.Y)
 	}
 }
 
@@ -565,9 +861,75 @@ func (s *Server) recordDirtyEntity(ent *entities.Entity) {
 	s.dirtyMu.Unlock()
 }
 
-func (s *Server) damageEntitiesFromCollapses(summary *world.DamageSummary) {
+// explosionHits collects the damage each entity takes from one detonation. An
+// entity reached several ways keeps only the largest amount, along with the
+// point it came from.
+type explosionHits map[entities.ID]explosionHit
+
+type explosionHit struct {
+	ent    *entities.Entity
+	from   entities.Vec3
+	damage float64
+}
+
+func (h explosionHits) add(ent *entities.Entity, from entities.Vec3, damage float64) {
+	if damage <= 0 {
+		return
+	}
+	if existing, ok := h[ent.ID]; ok && existing.damage >= damage {
+		return
+	}
+	h[ent.ID] = explosionHit{ent: ent, from: from, damage: damage}
+}
+
+// damageEntitiesFromExplosion hurts the entities caught by a detonation at
+// origin in one pass: entities inside the blast radius with no surviving block
+// between them and origin take falloff-scaled blast damage, and entities near
+// a block the blast brought down take collapse damage. An entity hit by both
+// takes whichever is greater, once. Multi-block entities lose the blocks
+// facing the blast or the fallen block first. The detonating entity and
+// everything it does not collide with, such as the unit that fired it, are
+// spared.
+func (s *Server) damageEntitiesFromExplosion(source *entities.Entity, origin entities.Vec3, radius, damage float64, falloff world.Falloff, summary *world.DamageSummary) {
+	hits := make(explosionHits)
+	skip := entities.IgnoreFriendly(source)
+	for _, ent := range s.entities.Within(origin, radius, skip) {
+		pos := ent.PositionVec()
+		if s.blastShielded(origin, pos) {
+			continue
+		}
+		hits.add(ent, origin, damage*falloff.Scale(vecDistance(pos, origin), radius))
+	}
+	s.collectCollapseHits(summary, hits, skip)
+
+	for _, hit := range hits {
+		s.entities.DamageFrom(hit.ent, hit.from, hit.damage)
+		s.recordDirtyEntity(hit.ent)
+	}
+}
+
+// blastShielded reports whether a solid block lies on the line from a blast at
+// origin to pos. The blocks holding origin and pos themselves do not count.
+func (s *Server) blastShielded(origin, pos entities.Vec3) bool {
+	distance := vecDistance(origin, pos)
+	if distance == 0 {
+		return false
+	}
+	dir := entities.Vec3{X: pos.X - origin.X, Y: pos.Y - origin.Y, Z: pos.Z - origin.Z}
+	// Stop just short of pos so a block whose face pos lies on is not
+	// mistaken for cover.
+	hit, _, ok := s.world.RaycastBlock(context.Background(), origin, dir, distance-1e-6)
+	return ok && hit != world.BlockFromVec(origin) && hit != world.BlockFromVec(pos)
+}
+
+// collectCollapseHits adds collapse damage for entities within
+// physics.collapseImpactRadius of a block that collapsed in summary, other
+// than those filter skips.
+func (s *Server) collectCollapseHits(summary *world.DamageSummary, hits explosionHits, filter entities.CollisionFilter) {
+	cfg := s.currentConfig()
 	collapsed := summary.CollapsedBlocks()
-	if len(collapsed) == 0 {
+	radius := cfg.Physics.CollapseImpactRadius
+	if len(collapsed) == 0 || radius <= 0 {
 		return
 	}
 
@@ -582,32 +944,30 @@ func (s *Server) damageEntitiesFromCollapses(summary *world.DamageSummary) {
 	}
 
 	for chunkCoord, coords := range perChunk {
-		entities := s.entities.MutableByChunk(chunkCoord)
-		if len(entities) == 0 {
-			continue
-		}
-		for _, ent := range entities {
+		for _, ent := range s.entities.MutableByChunk(chunkCoord) {
+			if filter.Skips(ent) {
+				continue
+			}
 			pos := ent.PositionVec()
 			for _, block := range coords {
-				dx := pos.X - float64(block.X)
-				dy := pos.Y - float64(block.Y)
-				dz := pos.Z - float64(block.Z)
-				distance := math.Sqrt(dx*dx + dy*dy + dz*dz)
-				if distance > collapseImpactRadius {
+				distance := vecDistance(pos, block.ToVec())
+				if distance > radius {
 					continue
 				}
-				damage := collapseImpactDamage * (1 - distance/collapseImpactRadius)
-				if damage <= 0 {
-					continue
-				}
-				ent.ApplyDamage(damage)
-				s.recordDirtyEntity(ent)
+				hits.add(ent, block.ToVec(), cfg.Physics.CollapseImpactDamage*(1-distance/radius))
 				break
 			}
 		}
 	}
 }
 
+func vecDistance(a, b entities.Vec3) float64 {
+	dx := a.X - b.X
+	dy := a.Y - b.Y
+	dz := a.Z - b.Z
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
+
 func (s *Server) markChunksDirty(chunks []world.ChunkCoord) {
 	if len(chunks) == 0 {
 		return
@@ -656,8 +1016,8 @@ func (s *Server) prefetchChunkNeighborhood(center world.ChunkCoord) {
 	}
 
 	for _, coord := range neighbors {
-		if err := s.world.EnsureChunk(coord); err != nil {
-			s.logger.Printf("ensure chunk %v: %v", coord, err)
+		if err := s.world.EnsureChunkWithPriority(coord, world.PriorityLow); err != nil {
+			s.logger.Warnf("ensure chunk %v: %v", coord, err)
 		}
 	}
 
@@ -694,10 +1054,10 @@ func (s *Server) queueVoxelDeltas(summary *world.DamageSummary) {
 }
 
 func (s *Server) shouldStreamChange(region world.ServerRegion, change world.BlockChange, cache map[world.ChunkCoord]*world.Chunk, failed map[world.ChunkCoord]struct{}) bool {
-	if change.After.Type == world.BlockAir {
+	if change.After.Type.Properties().Transmissive {
 		return true
 	}
-	return s.blockAdjacentToAir(region, change.Coord, cache, failed)
+	return s.blockExposed(region, change.Coord, cache, failed)
 }
 
 var blockNeighborOffsets = [...]struct{ dx, dy, dz int }{
@@ -709,7 +1069,9 @@ var blockNeighborOffsets = [...]struct{ dx, dy, dz int }{
 	{0, 0, -1},
 }
 
-func (s *Server) blockAdjacentToAir(region world.ServerRegion, coord world.BlockCoord, cache map[world.ChunkCoord]*world.Chunk, failed map[world.ChunkCoord]struct{}) bool {
+// blockExposed reports whether a neighbor of coord lets light through, so
+// clients can see the block.
+func (s *Server) blockExposed(region world.ServerRegion, coord world.BlockCoord, cache map[world.ChunkCoord]*world.Chunk, failed map[world.ChunkCoord]struct{}) bool {
 	for _, offset := range blockNeighborOffsets {
 		neighbor := world.BlockCoord{
 			X: coord.X + offset.dx,
@@ -723,7 +1085,7 @@ func (s *Server) blockAdjacentToAir(region world.ServerRegion, coord world.Block
 		if !ok {
 			return true
 		}
-		if block.Type == "" || block.Type == world.BlockAir {
+		if block.Type.Properties().Transmissive {
 			return true
 		}
 	}
@@ -743,7 +1105,7 @@ func (s *Server) lookupBlock(region world.ServerRegion, coord world.BlockCoord,
 		var err error
 		chunk, err = s.world.Chunk(context.Background(), chunkCoord)
 		if err != nil {
-			s.logger.Printf("adjacency chunk load %v: %v", chunkCoord, err)
+			s.logger.Warnf("adjacency chunk load %v: %v", chunkCoord, err)
 			failed[chunkCoord] = struct{}{}
 			return world.Block{}, false
 		}
@@ -761,18 +1123,19 @@ func (s *Server) lookupBlock(region world.ServerRegion, coord world.BlockCoord,
 }
 
 func (s *Server) flushVoxelDeltas() {
+	cfg := s.currentConfig()
 	if s.deltaBuffer == nil {
 		return
 	}
-	deltas := s.deltaBuffer.flush(s.cfg.Server.ID, &s.deltaSeq)
+	deltas := s.deltaBuffer.flush(cfg.Server.ID, &s.deltaSeq, s.now())
 	if len(deltas) == 0 {
 		return
 	}
 
 	for _, delta := range deltas {
-		for _, endpoint := range s.cfg.Network.MainServerEndpoints {
+		for _, endpoint := range cfg.Network.MainServerEndpoints {
 			if err := s.net.Send(endpoint, network.MessageChunkDelta, delta); err != nil {
-				s.logger.Printf("chunk delta send to %s: %v", endpoint, err)
+				s.logger.Warnf("chunk delta send to %s: %v", endpoint, err)
 			}
 		}
 	}
@@ -797,13 +1160,14 @@ func (s *Server) flushDirtyEntities() {
 }
 
 func (s *Server) streamEntities(list []entities.Entity) {
+	cfg := s.currentConfig()
 	if len(list) == 0 {
 		return
 	}
 	batch := network.EntityBatch{
-		ServerID:  s.cfg.Server.ID,
+		ServerID:  cfg.Server.ID,
 		Seq:       s.streamSeq,
-		Timestamp: time.Now().UTC(),
+		Timestamp: s.now().UTC(),
 		Entities:  make([]network.EntityState, 0, len(list)),
 	}
 	s.streamSeq++
@@ -812,22 +1176,42 @@ func (s *Server) streamEntities(list []entities.Entity) {
 		batch.Entities = append(batch.Entities, serializeEntity(ent))
 	}
 
-	for _, endpoint := range s.cfg.Network.MainServerEndpoints {
+	for _, endpoint := range cfg.Network.MainServerEndpoints {
 		if err := s.net.Send(endpoint, network.MessageEntityUpdate, batch); err != nil {
-			s.logger.Printf("entity batch send to %s: %v", endpoint, err)
+			s.logger.Warnf("entity batch send to %s: %v", endpoint, err)
 		}
 	}
 }
 
+// broadcastChunkSummaries sends at most one chunk summary per state tick.
+// Dirty chunks go first. Until every chunk in the region has been summarized
+// once the tick otherwise walks the traversal; after that baseline clean
+// chunks are skipped, and a dirty chunk is only sent when its version moved
+// past the last summary, so repeated edits coalesce into one summary and a
+// quiet region sends nothing. A main server hello, or server.summaryResync
+// passing since the last full pass, starts another full pass so late or
+// reconnecting main servers, lost datagrams, and weather changes on clean
+// chunks catch up.
 func (s *Server) broadcastChunkSummaries(ctx context.Context) {
-	if coord, ok := s.popDirtyChunk(); ok {
+	s.maybeRestartBaseline()
+
+	// Chunks that are not ready yet go back on the queue, so only look at
+	// the entries queued when the tick started.
+	for pending := len(s.dirtyChunkQueue); pending > 0; pending-- {
+		coord, ok := s.popDirtyChunk()
+		if !ok {
+			break
+		}
+		if !s.summaryStale(coord) {
+			continue
+		}
 		if err := s.sendChunkSummary(ctx, coord); err != nil {
-			s.logger.Printf("load dirty chunk %v: %v", coord, err)
+			s.logger.Warnf("load dirty chunk %v: %v", coord, err)
 		}
 		return
 	}
 
-	if len(s.chunkTraversal) == 0 {
+	if s.baselineSent || len(s.chunkTraversal) == 0 {
 		return
 	}
 
@@ -840,12 +1224,110 @@ func (s *Server) broadcastChunkSummaries(ctx context.Context) {
 	}
 
 	if err := s.sendChunkSummary(ctx, global); err != nil {
-		s.logger.Printf("load chunk %v: %v", global, err)
-		s.advanceChunkCursor()
-		return
+		s.logger.Warnf("load chunk %v: %v", global, err)
 	}
 
 	s.advanceChunkCursor()
+	if s.chunkCursor == s.baselineEnd {
+		s.baselineSent = true
+		s.baselineDoneAt = s.now()
+	}
+}
+
+// maybeRestartBaseline starts a new full pass of summaries when one was
+// requested or the resync interval has passed. The pass begins at the current
+// cursor and ends once the traversal wraps back to it; a request arriving
+// mid-pass extends the pass so every chunk is sent after the request.
+func (s *Server) maybeRestartBaseline() {
+	requested := s.resyncRequested.Swap(false)
+	if !requested && s.baselineSent {
+		resync := s.currentConfig().Server.SummaryResync.Duration()
+		requested = resync > 0 && s.now().Sub(s.baselineDoneAt) >= resync
+	}
+	if !requested {
+		return
+	}
+	s.baselineSent = false
+	s.baselineEnd = s.chunkCursor
+}
+
+// onMainServerHello answers a main server that has just connected or
+// reconnected with a fresh full pass of chunk summaries.
+func (s *Server) onMainServerHello(ctx context.Context, addr *net.UDPAddr, env network.Envelope) {
+	var hello network.Hello
+	if err := json.Unmarshal(env.Payload, &hello); err != nil {
+		s.logger.Warnf("main server hello decode: %v", err)
+		return
+	}
+	s.logger.Info("main server hello, resending chunk summaries", "server", hello.ServerID, "addr", addr.String())
+	s.resyncRequested.Store(true)
+}
+
+// summaryStale reports whether coord changed since its last summary. Chunks
+// never summarized, or not loaded yet, count as stale.
+func (s *Server) summaryStale(coord world.ChunkCoord) bool {
+	sent, ok := s.summaryVersions[coord]
+	if !ok {
+		return true
+	}
+	chunk, ready, err := s.world.ChunkIfReady(coord)
+	if err != nil || !ready {
+		return true
+	}
+	return chunk.Version() != sent
+}
+
+func (s *Server) broadcastEnvironment() {
+	cfg := s.currentConfig()
+	if s.env == nil {
+		return
+	}
+	update := environmentUpdate(cfg.Server.ID, s.EnvironmentState(), s.now().UTC())
+	for _, endpoint := range cfg.Network.MainServerEndpoints {
+		if err := s.net.Send(endpoint, network.MessageEnvironment, update); err != nil {
+			s.logger.Warnf("environment send to %s: %v", endpoint, err)
+		}
+	}
+}
+
+func environmentUpdate(serverID string, state environment.State, now time.Time) network.EnvironmentUpdate {
+	return network.EnvironmentUpdate{
+		ServerID:  serverID,
+		Timestamp: now,
+		TimeOfDay: state.TimeOfDay,
+		Phase:     string(state.Phase),
+		Weather:   weatherUpdate(state.Weather),
+		Lighting: network.LightingUpdate{
+			Ambient:     state.Lighting.Ambient,
+			SunAngle:    state.Lighting.SunAngle,
+			FogDensity:  state.Lighting.FogDensity,
+			WeatherTint: state.Lighting.WeatherTint,
+		},
+	}
+}
+
+func weatherUpdate(weather environment.WeatherState) network.WeatherUpdate {
+	return network.WeatherUpdate{
+		Kind:          string(weather.Kind),
+		Intensity:     weather.Intensity,
+		WindSpeed:     weather.WindSpeed,
+		WindDirection: weather.WindDirection,
+		Precipitation: weather.Precipitation,
+	}
+}
+
+// chunkWeather returns the local weather for a chunk covered by a weather
+// override, or nil when the chunk follows the global weather.
+func (s *Server) chunkWeather(coord world.ChunkCoord) *network.WeatherUpdate {
+	if s.env == nil {
+		return nil
+	}
+	weather, overridden := s.env.WeatherAt(coord.X, coord.Y)
+	if !overridden {
+		return nil
+	}
+	update := weatherUpdate(weather)
+	return &update
 }
 
 func (s *Server) sendChunkSummary(ctx context.Context, coord world.ChunkCoord) error {
@@ -859,17 +1341,23 @@ func (s *Server) sendChunkSummary(ctx context.Context, coord world.ChunkCoord) e
 	}
 
 	summary := network.ChunkSummary{
-		ChunkX:     coord.X,
-		ChunkY:     coord.Y,
-		Version:    1,
-		BlockCount: chunkBlockCount(chunk),
+		ChunkX:      coord.X,
+		ChunkY:      coord.Y,
+		Version:     chunk.Version(),
+		BlockCount:  chunkBlockCount(chunk),
+		Fingerprint: fmt.Sprintf("%016x", chunk.Fingerprint()),
+		Weather:     s.chunkWeather(coord),
 	}
 
-	for _, endpoint := range s.cfg.Network.MainServerEndpoints {
+	for _, endpoint := range s.currentConfig().Network.MainServerEndpoints {
 		if err := s.net.Send(endpoint, network.MessageChunkSummary, summary); err != nil {
-			s.logger.Printf("send chunk summary to %s: %v", endpoint, err)
+			s.logger.Warnf("send chunk summary to %s: %v", endpoint, err)
 		}
 	}
+	if s.summaryVersions == nil {
+		s.summaryVersions = make(map[world.ChunkCoord]uint64)
+	}
+	s.summaryVersions[coord] = summary.Version
 	return nil
 }
 
@@ -880,8 +1368,18 @@ func (s *Server) advanceChunkCursor() {
 	s.chunkCursor = (s.chunkCursor + 1) % len(s.chunkTraversal)
 }
 
-func buildCircularChunkTraversal(size int) []world.LocalChunkIndex {
-	if size <= 0 {
+// advertisedSizeY is the Y span sent alongside a region's X span. Square
+// regions leave it out so peers that only know square regions read them
+// unchanged.
+func advertisedSizeY(region world.ServerRegion) int {
+	if region.ChunksY == region.ChunksX {
+		return 0
+	}
+	return region.ChunksY
+}
+
+func buildCircularChunkTraversal(sizeX, sizeY int) []world.LocalChunkIndex {
+	if sizeX <= 0 || sizeY <= 0 {
 		return nil
 	}
 
@@ -891,9 +1389,9 @@ func buildCircularChunkTraversal(size int) []world.LocalChunkIndex {
 		angle    float64
 	}
 
-	entries := make([]entry, 0, size*size)
-	for y := 0; y < size; y++ {
-		for x := 0; x < size; x++ {
+	entries := make([]entry, 0, sizeX*sizeY)
+	for y := 0; y < sizeY; y++ {
+		for x := 0; x < sizeX; x++ {
 			dx := x
 			dy := y
 			entries = append(entries, entry{
@@ -925,78 +1423,84 @@ func buildCircularChunkTraversal(size int) []world.LocalChunkIndex {
 }
 
 func (s *Server) onNeighborHello(ctx context.Context, addr *net.UDPAddr, env network.Envelope) {
+	cfg := s.currentConfig()
 	var msg network.NeighborHello
 	if err := json.Unmarshal(env.Payload, &msg); err != nil {
-		s.logger.Printf("neighbor hello decode: %v", err)
+		s.logger.Warnf("neighbor hello decode: %v", err)
 		return
 	}
 	origin := world.ChunkCoord{X: msg.RegionOriginX, Y: msg.RegionOriginY}
 	var delta world.ChunkCoord
 	if s.neighbors != nil {
-		delta = s.neighbors.updateFromHello(addr.String(), msg.Listen, msg.ServerID, origin, msg.RegionSize)
+		delta = s.neighbors.updateFromHello(addr.String(), msg.Listen, msg.ServerID, origin, msg.RegionSize, msg.RegionSizeY, s.now())
 	}
 	region := s.world.Region()
 	ack := network.NeighborAck{
-		ServerID:      s.cfg.Server.ID,
-		Listen:        s.cfg.Network.ListenUDP,
+		ServerID:      cfg.Server.ID,
+		Listen:        cfg.Network.ListenUDP,
 		RegionOriginX: region.Origin.X,
 		RegionOriginY: region.Origin.Y,
-		RegionSize:    region.ChunksPerAxis,
+		RegionSize:    region.ChunksX,
+		RegionSizeY:   advertisedSizeY(region),
 		DeltaX:        region.Origin.X - msg.RegionOriginX,
 		DeltaY:        region.Origin.Y - msg.RegionOriginY,
-		Timestamp:     time.Now().UTC(),
+		Timestamp:     s.now().UTC(),
 		Nonce:         msg.Nonce,
 		Status:        "ok",
 	}
 	if err := s.net.Send(addr.String(), network.MessageNeighborAck, ack); err != nil {
-		s.logger.Printf("neighbor ack send: %v", err)
+		s.logger.Warn("neighbor ack failed", "neighbor", msg.ServerID, "endpoint", addr.String(), "err", err)
 	}
-	s.logger.Printf("neighbor hello from %s via %s delta(%d,%d)", msg.ServerID, addr.String(), delta.X, delta.Y)
+	s.logger.Info("neighbor hello", "neighbor", msg.ServerID, "endpoint", addr.String(), "deltaX", delta.X, "deltaY", delta.Y)
 }
 
 func (s *Server) onNeighborAck(ctx context.Context, addr *net.UDPAddr, env network.Envelope) {
 	var ack network.NeighborAck
 	if err := json.Unmarshal(env.Payload, &ack); err != nil {
-		s.logger.Printf("neighbor ack decode: %v", err)
+		s.logger.Warnf("neighbor ack decode: %v", err)
 		return
 	}
 	origin := world.ChunkCoord{X: ack.RegionOriginX, Y: ack.RegionOriginY}
 	if s.neighbors != nil {
-		s.neighbors.updateFromAck(addr.String(), ack.Listen, ack.ServerID, origin, ack.RegionSize, ack.Nonce)
+		s.neighbors.updateFromAck(addr.String(), ack.Listen, ack.ServerID, origin, ack.RegionSize, ack.RegionSizeY, ack.Nonce, s.now())
 	}
-	s.logger.Printf("neighbor ack from %s accepted=%s", ack.ServerID, ack.Status)
+	s.logger.Info("neighbor ack", "neighbor", ack.ServerID, "endpoint", addr.String(), "status", ack.Status)
 }
 
 func (s *Server) onTransferRequest(ctx context.Context, addr *net.UDPAddr, env network.Envelope) {
 	var req network.TransferRequest
 	if err := json.Unmarshal(env.Payload, &req); err != nil {
-		s.logger.Printf("transfer request decode: %v", err)
+		s.logger.Warnf("transfer request decode: %v", err)
 		return
 	}
-	ack := s.handleTransferRequest(req)
+	ack := s.handleTransferRequest(ctx, req)
 	if err := s.net.Send(addr.String(), network.MessageTransferAck, ack); err != nil {
-		s.logger.Printf("transfer ack send: %v", err)
+		s.logger.Warnf("transfer ack send: %v", err)
 	}
 }
 
 func (s *Server) onTransferAck(ctx context.Context, addr *net.UDPAddr, env network.Envelope) {
 	var ack network.TransferAck
 	if err := json.Unmarshal(env.Payload, &ack); err != nil {
-		s.logger.Printf("transfer ack decode: %v", err)
+		s.logger.Warnf("transfer ack decode: %v", err)
 		return
 	}
-	s.logger.Printf("transfer ack entity %s accepted=%t from %s msg=%s", ack.EntityID, ack.Accepted, ack.FromServer, ack.Message)
+	s.logger.Info("migration ack", "entity", ack.EntityID, "neighbor", ack.FromServer, "accepted", ack.Accepted, "message", ack.Message)
 	id := entities.ID(ack.EntityID)
+	s.transfersMu.Lock()
 	req, ok := s.inFlightTransfers[id]
+	delete(s.inFlightTransfers, id)
+	s.transfersMu.Unlock()
 	if !ok {
 		return
 	}
-	delete(s.inFlightTransfers, id)
 
 	if ack.Accepted {
 		s.entities.Remove(id)
+		s.dirtyMu.Lock()
 		delete(s.dirtyEntities, id)
-		s.logger.Printf("migration: entity %s transferred to %s", ack.EntityID, ack.FromServer)
+		s.dirtyMu.Unlock()
+		s.logger.Info("migration complete", "entity", ack.EntityID, "neighbor", ack.FromServer)
 		return
 	}
 
@@ -1004,19 +1508,24 @@ func (s *Server) onTransferAck(ctx context.Context, addr *net.UDPAddr, env netwo
 		ent.SetAttribute("migration_pending", 0)
 		s.recordDirtyEntity(ent)
 		req.EntitySnapshot = ent.Snapshot()
-		req.QueuedAt = time.Now()
+		req.QueuedAt = s.now()
 		req.Nonce = 0
-		s.migrationQueue.Enqueue(req)
+		s.retryMigration(req)
 	}
 }
 
-func (s *Server) handleTransferRequest(req network.TransferRequest) network.TransferAck {
+func (s *Server) handleTransferRequest(ctx context.Context, req network.TransferRequest) network.TransferAck {
 	ack := network.TransferAck{
 		EntityID:   req.EntityID,
-		FromServer: s.cfg.Server.ID,
+		FromServer: s.currentConfig().Server.ID,
 		ToServer:   req.FromServer,
 		Nonce:      req.Nonce,
-		Timestamp:  time.Now().UTC(),
+		Timestamp:  s.now().UTC(),
+	}
+	if s.isDraining() {
+		ack.Accepted = false
+		ack.Message = "server draining"
+		return ack
 	}
 	targetChunk := world.ChunkCoord{X: req.GlobalChunkX, Y: req.GlobalChunkY}
 	region := s.world.Region()
@@ -1036,6 +1545,9 @@ func (s *Server) handleTransferRequest(req network.TransferRequest) network.Tran
 		ack.Message = err.Error()
 		return ack
 	}
+	if s.settleSpawn(ctx, ent) {
+		s.logger.Info("migrated entity moved out of terrain", "entity", req.EntityID, "block", world.BlockFromVec(ent.Position))
+	}
 	if err := s.entities.Add(ent); err != nil {
 		ack.Accepted = false
 		ack.Message = err.Error()
@@ -1045,18 +1557,19 @@ func (s *Server) handleTransferRequest(req network.TransferRequest) network.Tran
 	s.recordDirtyEntity(ent)
 	ack.Accepted = true
 	ack.Message = "accepted"
-	s.logger.Printf("migration: entity %s received from %s", req.EntityID, req.FromServer)
+	s.logger.Info("migration received", "entity", req.EntityID, "neighbor", req.FromServer)
 	return ack
 }
 
 func (s *Server) buildEntityFromState(state network.EntityState, targetChunk world.ChunkCoord) (*entities.Entity, error) {
+	cfg := s.currentConfig()
 	pos := vec3FromSlice(state.Position)
 	vel := vec3FromSlice(state.Velocity)
 	ent := &entities.Entity{
 		ID:   entities.ID(state.ID),
 		Kind: entities.Kind(state.Kind),
 		Chunk: entities.ChunkMembership{
-			ServerID: s.cfg.Server.ID,
+			ServerID: cfg.Server.ID,
 			Chunk:    targetChunk,
 		},
 		Position: pos,
@@ -1069,13 +1582,33 @@ func (s *Server) buildEntityFromState(state network.EntityState, targetChunk wor
 			CanFly: state.CanFly,
 			CanDig: state.CanDig,
 		},
-		Attributes: make(map[string]float64),
-		LastTick:   time.Now(),
+		Attributes:        make(map[string]float64),
+		InventoryCapacity: state.InventoryCapacity,
+		Owner:             entities.ID(state.Owner),
+		CollisionGroup:    state.CollisionGroup,
+		LastTick:          s.now(),
+	}
+	if len(state.Tags) > 0 {
+		ent.Tags = append([]string(nil), state.Tags...)
+	}
+	if len(state.Blocks) > 0 {
+		ent.Blocks = make([]entities.EntityBlock, len(state.Blocks))
+		ent.Stats.BlockHP = make([]float64, len(state.Blocks))
+		for i, block := range state.Blocks {
+			ent.Blocks[i] = entityBlockFromState(block)
+			ent.Stats.BlockHP[i] = block.HP
+		}
 	}
 	for k, v := range state.Attributes {
 		ent.Attributes[k] = v
 	}
-	ent.UpdateChunk(s.cfg.Server.ID, targetChunk)
+	if len(state.Inventory) > 0 {
+		ent.Inventory = make(map[string]float64, len(state.Inventory))
+		for k, v := range state.Inventory {
+			ent.Inventory[k] = v
+		}
+	}
+	ent.UpdateChunk(cfg.Server.ID, targetChunk)
 	return ent, nil
 }
 
@@ -1096,13 +1629,13 @@ func vec3FromSlice(values []float64) entities.Vec3 {
 func (s *Server) onEntityQuery(ctx context.Context, addr *net.UDPAddr, env network.Envelope) {
 	var query network.EntityQuery
 	if err := json.Unmarshal(env.Payload, &query); err != nil {
-		s.logger.Printf("entity query decode: %v", err)
+		s.logger.Warnf("entity query decode: %v", err)
 		return
 	}
 
 	entities := s.entities.ByChunk(world.ChunkCoord{X: query.ChunkX, Y: query.ChunkY})
 	result := network.EntityReply{
-		ServerID: s.cfg.Server.ID,
+		ServerID: s.currentConfig().Server.ID,
 		Entities: make([]network.EntityState, 0, len(entities)),
 	}
 	for _, ent := range entities {
@@ -1110,19 +1643,54 @@ func (s *Server) onEntityQuery(ctx context.Context, addr *net.UDPAddr, env netwo
 	}
 
 	if err := s.net.Send(addr.String(), network.MessageEntityReply, result); err != nil {
-		s.logger.Printf("entity reply send: %v", err)
+		s.logger.Warn("entity reply send failed", "err", err)
 	}
 }
 
+// maxPathWaypoints bounds how many waypoints one PathRequest may chain, since
+// each one adds a full route search.
+const maxPathWaypoints = 32
+
+// pathTooManyWaypoints is the status of a path request with more than
+// maxPathWaypoints waypoints.
+const pathTooManyWaypoints = "too_many_waypoints"
+
 func (s *Server) onPathRequest(ctx context.Context, addr *net.UDPAddr, env network.Envelope) {
 	var req network.PathRequest
 	if err := json.Unmarshal(env.Payload, &req); err != nil {
-		s.logger.Printf("path request decode: %v", err)
+		s.logger.Warn("path request decode failed", "err", err)
+		return
+	}
+
+	if s.isDraining() {
+		s.logger.Info("path request dropped: server draining", "entity", req.EntityID)
+		return
+	}
+
+	if len(req.Waypoints) > maxPathWaypoints {
+		resp := network.PathResponse{
+			EntityID:       req.EntityID,
+			RequestID:      req.RequestID,
+			Status:         pathTooManyWaypoints,
+			Error:          fmt.Sprintf("%d waypoints exceed the limit of %d", len(req.Waypoints), maxPathWaypoints),
+			RegionRelative: req.RegionRelative,
+		}
+		if err := s.net.Send(addr.String(), network.MessagePathResponse, resp); err != nil {
+			s.logger.Warnf("path response send: %v", err)
+		}
 		return
 	}
 
+	ctx, done, ok := s.pathSearches.start(ctx, addr, req.RequestID)
+	if !ok {
+		s.logger.Info("path request dropped: cancelled", "request", req.RequestID, "entity", req.EntityID)
+		return
+	}
+	defer done()
+
 	mode := pathfinding.ModeFromString(req.Mode)
-	profile := pathfinding.DefaultProfile(mode)
+	s.pathRequests.record(mode)
+	profile := unitProfile(s.currentConfig().Pathfinding, mode)
 	if req.Clearance > 0 {
 		profile.Clearance = req.Clearance
 	}
@@ -1132,44 +1700,150 @@ func (s *Server) onPathRequest(ctx context.Context, addr *net.UDPAddr, env netwo
 	if req.MaxDrop > 0 {
 		profile.MaxDrop = req.MaxDrop
 	}
+	if req.TunnelWidth > 0 {
+		profile.TunnelWidth = req.TunnelWidth
+	}
+	// Region-relative requests are routed in global coordinates and their
+	// results converted back.
+	region := s.world.Region()
+	toGlobal := func(x, y, z int) world.BlockCoord {
+		coord := world.BlockCoord{X: x, Y: y, Z: z}
+		if req.RegionRelative {
+			return region.RegionToGlobalBlock(coord)
+		}
+		return coord
+	}
+	fromGlobal := func(coord world.BlockCoord) world.BlockCoord {
+		if req.RegionRelative {
+			return region.GlobalToRegionBlock(coord)
+		}
+		return coord
+	}
+
+	if len(req.KeepOut) > 0 {
+		zones := make([]pathfinding.KeepOutZone, 0, len(req.KeepOut))
+		for _, box := range req.KeepOut {
+			zones = append(zones, pathfinding.KeepOutZone{
+				Min:     toGlobal(box.Min.X, box.Min.Y, box.Min.Z),
+				Max:     toGlobal(box.Max.X, box.Max.Y, box.Max.Z),
+				Penalty: box.Penalty,
+			})
+		}
+		profile.Avoid = pathfinding.NewKeepOut(zones...)
+	}
 
-	start := world.BlockCoord{X: req.FromX, Y: req.FromY, Z: req.FromZ}
-	goal := world.BlockCoord{X: req.ToX, Y: req.ToY, Z: req.ToZ}
+	points := make([]world.BlockCoord, 0, len(req.Waypoints)+2)
+	points = append(points, toGlobal(req.FromX, req.FromY, req.FromZ))
+	for _, step := range req.Waypoints {
+		points = append(points, toGlobal(step.X, step.Y, step.Z))
+	}
+	points = append(points, toGlobal(req.ToX, req.ToY, req.ToZ))
 
-	route := s.navigator.FindRoute(ctx, start, goal, profile)
+	route, stats, err := s.navigator.FindRouteThrough(ctx, points, profile)
+	if errors.Is(context.Cause(ctx), errPathCancelled) {
+		return
+	}
 
 	resp := network.PathResponse{
-		EntityID: req.EntityID,
+		EntityID:  req.EntityID,
+		RequestID: req.RequestID,
+		Status:    string(stats.Status),
+		Stats: &network.PathStats{
+			Expanded:  stats.Expanded,
+			ElapsedMs: float64(stats.Elapsed) / float64(time.Millisecond),
+		},
+		RegionRelative: req.RegionRelative,
+	}
+	if err != nil {
+		resp.Error = err.Error()
 	}
 	for _, coord := range route {
+		coord = fromGlobal(coord)
 		resp.Route = append(resp.Route, network.BlockStep{X: coord.X, Y: coord.Y, Z: coord.Z})
 	}
+	for _, target := range stats.Dig {
+		coord := fromGlobal(target.Coord)
+		resp.Dig = append(resp.Dig, network.DigStep{
+			X:     coord.X,
+			Y:     coord.Y,
+			Z:     coord.Z,
+			Block: string(target.Type),
+			Yield: target.Yield,
+		})
+	}
 
 	if err := s.net.Send(addr.String(), network.MessagePathResponse, resp); err != nil {
-		s.logger.Printf("path response send: %v", err)
+		s.logger.Warnf("path response send: %v", err)
+	}
+}
+
+// searchOptions builds the route search limits of the navigator from cfg.
+func searchOptions(cfg config.PathfindingConfig) pathfinding.SearchOptions {
+	return pathfinding.SearchOptions{
+		MaxNodes:       cfg.MaxSearchNodes,
+		HeuristicScale: cfg.HeuristicScale,
 	}
 }
 
+// unitProfile returns the default profile for mode with any configured
+// override applied on top.
+func unitProfile(cfg config.PathfindingConfig, mode pathfinding.Mode) pathfinding.UnitProfile {
+	profile := pathfinding.DefaultProfile(mode)
+	override, ok := cfg.Profiles[modeLabel(mode)]
+	if !ok {
+		return profile
+	}
+	if override.Clearance != nil {
+		profile.Clearance = *override.Clearance
+	}
+	if override.MaxClimb != nil {
+		profile.MaxClimb = *override.MaxClimb
+	}
+	if override.MaxDrop != nil {
+		profile.MaxDrop = *override.MaxDrop
+	}
+	if override.CanDig != nil {
+		profile.CanDig = *override.CanDig
+	}
+	if override.TunnelWidth != nil {
+		profile.TunnelWidth = *override.TunnelWidth
+	}
+	if override.AllowDiagonal != nil {
+		profile.AllowDiagonal = *override.AllowDiagonal
+	}
+	if override.Heuristic != "" {
+		profile.Heuristic = pathfinding.HeuristicFromString(override.Heuristic)
+	}
+	if override.WallPenalty != nil {
+		profile.WallPenalty = *override.WallPenalty
+	}
+	return profile
+}
+
 func (s *Server) onTransferClaim(ctx context.Context, addr *net.UDPAddr, env network.Envelope) {
 	var claim network.TransferClaim
 	if err := json.Unmarshal(env.Payload, &claim); err != nil {
-		s.logger.Printf("transfer claim decode: %v", err)
+		s.logger.Warnf("transfer claim decode: %v", err)
 		return
 	}
-	s.logger.Printf("transfer claim for entity %s from %s to %s", claim.EntityID, claim.From, claim.To)
+	s.logger.Info("migration claim", "entity", claim.EntityID, "from", claim.From, "to", claim.To)
 }
 
 func (s *Server) announceToMainServers() {
+	cfg := s.currentConfig()
 	payload := network.Hello{
-		ServerID: s.cfg.Server.ID,
+		ServerID: cfg.Server.ID,
+	}
+	payload.Region.OriginX = cfg.Server.GlobalChunkOrigin.X
+	payload.Region.OriginY = cfg.Server.GlobalChunkOrigin.Y
+	payload.Region.Size, payload.Region.SizeY = cfg.Chunk.RegionSize()
+	if payload.Region.SizeY == payload.Region.Size {
+		payload.Region.SizeY = 0
 	}
-	payload.Region.OriginX = s.cfg.Server.GlobalChunkOrigin.X
-	payload.Region.OriginY = s.cfg.Server.GlobalChunkOrigin.Y
-	payload.Region.Size = s.cfg.Chunk.ChunksPerAxis
 
-	for _, endpoint := range s.cfg.Network.MainServerEndpoints {
+	for _, endpoint := range cfg.Network.MainServerEndpoints {
 		if err := s.net.Send(endpoint, network.MessageHello, payload); err != nil {
-			s.logger.Printf("hello send to %s: %v", endpoint, err)
+			s.logger.Warnf("hello send to %s: %v", endpoint, err)
 		}
 	}
 }
@@ -1187,6 +1861,11 @@ func serializeEntity(ent entities.Entity) network.EntityState {
 		CanFly:   ent.Capabilities.CanFly,
 		CanDig:   ent.Capabilities.CanDig,
 		Voxels:   len(ent.Blocks),
+		Owner:    string(ent.Owner),
+		// The group travels with the entity so squads stay friendly across
+		// servers.
+		CollisionGroup:    ent.CollisionGroup,
+		InventoryCapacity: ent.InventoryCapacity,
 	}
 	if len(ent.Attributes) > 0 {
 		state.Attributes = make(map[string]float64, len(ent.Attributes))
@@ -1194,33 +1873,98 @@ func serializeEntity(ent entities.Entity) network.EntityState {
 			state.Attributes[k] = v
 		}
 	}
+	if len(ent.Inventory) > 0 {
+		state.Inventory = make(map[string]float64, len(ent.Inventory))
+		for k, v := range ent.Inventory {
+			state.Inventory[k] = v
+		}
+	}
+	if len(ent.Tags) > 0 {
+		state.Tags = append([]string(nil), ent.Tags...)
+	}
+	state.BlockHP = entityBlockHP(ent.Blocks, ent.Stats.BlockHP)
 	state.Dirty = ent.Dirty
 	state.Dying = ent.Dying
+	state.Removed = ent.Removed
 	return state
 }
 
-func chunkBlockCount(chunk *world.Chunk) int {
-	count := 0
-	chunk.ForEachBlock(func(_ world.BlockCoord, block world.Block) bool {
-		if block.Type != world.BlockAir {
-			count++
+// entityBlockHP returns the hit points each of blocks has left, falling back
+// to a block's starting hit points where hp has no entry.
+func entityBlockHP(blocks []entities.EntityBlock, hp []float64) []float64 {
+	if len(blocks) == 0 {
+		return nil
+	}
+	out := make([]float64, len(blocks))
+	for i, block := range blocks {
+		out[i] = entities.BlockHitPoints(block)
+		if i < len(hp) {
+			out[i] = hp[i]
 		}
-		return true
-	})
-	return count
+	}
+	return out
 }
 
-func (s *Server) nextTransferNonce() uint64 {
-	s.transferSeq++
-	return s.transferSeq
+// transferEntityState serializes the entity of a migration request. Unlike
+// the updates built by serializeEntity it carries the entity's whole body,
+// which the receiving server needs to rebuild it.
+func transferEntityState(req *migration.Request) network.EntityState {
+	state := serializeEntity(req.EntitySnapshot)
+	if len(state.BlockHP) > 0 {
+		state.Blocks = make([]network.EntityBlockState, len(state.BlockHP))
+		for i, block := range req.EntitySnapshot.Blocks {
+			state.Blocks[i] = entityBlockState(block, state.BlockHP[i])
+		}
+	}
+	return state
 }
 
-func floorDiv(value, size int) int {
-	if size <= 0 {
-		return 0
+// entityBlockState serializes block of an entity body with hp hit points left.
+func entityBlockState(block entities.EntityBlock, hp float64) network.EntityBlockState {
+	return network.EntityBlockState{
+		Offset:        []float64{block.Offset.X, block.Offset.Y, block.Offset.Z},
+		VoxelSize:     block.VoxelSize,
+		Role:          string(block.Role),
+		Type:          string(block.Block.Type),
+		Material:      block.Block.Material,
+		Color:         block.Block.Color,
+		Texture:       block.Block.Texture,
+		HP:            hp,
+		MaxHP:         block.Block.MaxHitPoints,
+		Weight:        block.Block.Weight,
+		Light:         block.Block.LightEmission,
+		ResourceYield: block.Block.ResourceYield,
+		Metadata:      block.Block.Metadata,
 	}
-	if value >= 0 {
-		return value / size
+}
+
+// entityBlockFromState rebuilds an entity body block serialized by
+// entityBlockState. The block's hit points are the ones it has left.
+func entityBlockFromState(state network.EntityBlockState) entities.EntityBlock {
+	return entities.EntityBlock{
+		Offset:    vec3FromSlice(state.Offset),
+		VoxelSize: state.VoxelSize,
+		Role:      entities.EntityBlockRole(state.Role),
+		Block: world.Block{
+			Type:          world.BlockType(state.Type),
+			Material:      state.Material,
+			Color:         state.Color,
+			Texture:       state.Texture,
+			HitPoints:     state.HP,
+			MaxHitPoints:  state.MaxHP,
+			Weight:        state.Weight,
+			LightEmission: state.Light,
+			ResourceYield: state.ResourceYield,
+			Metadata:      state.Metadata,
+		},
 	}
-	return -((-value - 1) / size) - 1
+}
+
+func chunkBlockCount(chunk *world.Chunk) int {
+	return chunk.Census().Total
+}
+
+func (s *Server) nextTransferNonce() uint64 {
+	s.transferSeq++
+	return s.transferSeq
 }
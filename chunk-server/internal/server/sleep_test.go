@@ -0,0 +1,57 @@
+package server
+
+import (
+	"testing"
+
+	"chunkserver/internal/config"
+	"chunkserver/internal/entities"
+	"chunkserver/internal/world"
+)
+
+func TestProjectileImpactWakesSleepingChunks(t *testing.T) {
+	region := world.ServerRegion{
+		ChunksX:        4,
+		ChunksY:        4,
+		ChunkDimension: world.Dimensions{Width: 8, Depth: 8, Height: 8},
+	}
+	cfg := config.Default()
+	srv := &Server{
+		cfg:         cfg,
+		logger:      noopLogger(),
+		world:       world.NewManager(region, stubGenerator{}),
+		entities:    entities.NewManager(cfg.Server.ID),
+		deltaBuffer: newDeltaAccumulator(),
+		dirtyChunks: make(map[world.ChunkCoord]struct{}),
+	}
+	srv.entities.SetSleepAfter(1)
+
+	near := world.ChunkCoord{X: 1, Y: 0}
+	far := world.ChunkCoord{X: 3, Y: 3}
+	for _, ent := range []*entities.Entity{
+		{ID: "near", Kind: entities.KindStructure, Chunk: entities.ChunkMembership{Chunk: near}},
+		{ID: "far", Kind: entities.KindStructure, Chunk: entities.ChunkMembership{Chunk: far}},
+	} {
+		if err := srv.entities.Add(ent); err != nil {
+			t.Fatalf("add %s: %v", ent.ID, err)
+		}
+	}
+	srv.entities.Apply(func(*entities.Entity) {})
+	if !srv.entities.Asleep(near) || !srv.entities.Asleep(far) {
+		t.Fatalf("expected idle chunks to fall asleep")
+	}
+
+	shell := &entities.Entity{
+		ID:       "shell",
+		Kind:     entities.KindProjectile,
+		Position: entities.Vec3{X: 7, Y: 3, Z: 1},
+	}
+	shell.SetAttribute("explosion_radius", 2)
+	srv.handleProjectileImpact(shell)
+
+	if srv.entities.Asleep(near) {
+		t.Fatalf("expected explosion within reach to wake the neighboring chunk")
+	}
+	if !srv.entities.Asleep(far) {
+		t.Fatalf("expected distant chunk to keep sleeping")
+	}
+}
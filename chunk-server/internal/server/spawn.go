@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"math"
+
+	"chunkserver/internal/entities"
+	"chunkserver/internal/pathfinding"
+	"chunkserver/internal/world"
+)
+
+// spawnProfile returns the room ent needs, sized by the pathfinding profile of
+// its movement mode.
+func (s *Server) spawnProfile(ent *entities.Entity) world.SpawnProfile {
+	mode := pathfinding.ModeGround
+	switch {
+	case ent.Capabilities.CanFly:
+		mode = pathfinding.ModeFlying
+	case ent.Capabilities.CanDig:
+		mode = pathfinding.ModeUnderground
+	}
+	return world.SpawnProfile{
+		Clearance: unitProfile(s.currentConfig().Pathfinding, mode).Clearance,
+		Flying:    ent.Capabilities.CanFly,
+	}
+}
+
+// settleSpawn moves ent out of terrain it was placed inside, to the nearest
+// cell it fits in, keeping its offset within the block. Entities with room
+// around them are left where they are, even in mid-air. It reports whether
+// ent moved.
+func (s *Server) settleSpawn(ctx context.Context, ent *entities.Entity) bool {
+	if s.world == nil {
+		return false
+	}
+	near := world.BlockFromVec(ent.Position)
+	profile := s.spawnProfile(ent)
+	room := profile
+	room.Flying = true
+	if s.world.SpawnFits(ctx, near, room) {
+		return false
+	}
+	spawn, ok := s.world.FindSpawn(ctx, near, profile)
+	if !ok {
+		return false
+	}
+	pos := ent.Position
+	ent.SetPosition(world.Vec3{
+		X: float64(spawn.X) + pos.X - math.Floor(pos.X),
+		Y: float64(spawn.Y) + pos.Y - math.Floor(pos.Y),
+		Z: float64(spawn.Z),
+	})
+	ent.UpdateChunk(s.currentConfig().Server.ID, s.world.Region().ChunkOfBlock(spawn))
+	return true
+}
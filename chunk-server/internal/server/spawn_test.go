@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"chunkserver/internal/config"
+	"chunkserver/internal/entities"
+	"chunkserver/internal/network"
+	"chunkserver/internal/world"
+)
+
+// bedrockGenerator fills every column with stone up to z=3.
+type bedrockGenerator struct{}
+
+func (bedrockGenerator) Generate(ctx context.Context, coord world.ChunkCoord, bounds world.Bounds, dim world.Dimensions) (*world.Chunk, error) {
+	chunk := world.NewChunk(coord, bounds, dim)
+	for x := 0; x < dim.Width; x++ {
+		for y := 0; y < dim.Depth; y++ {
+			for z := 0; z <= 3; z++ {
+				chunk.SetLocalBlock(x, y, z, world.Block{Type: world.BlockSolid})
+			}
+		}
+	}
+	return chunk, nil
+}
+
+func TestTransferRequestMovesEntityOutOfTerrain(t *testing.T) {
+	cfg := config.Default()
+	region := world.ServerRegion{
+		ChunksX:        1,
+		ChunksY:        1,
+		ChunkDimension: world.Dimensions{Width: 8, Depth: 8, Height: 8},
+	}
+	srv := &Server{
+		cfg:           cfg,
+		logger:        noopLogger(),
+		world:         world.NewManager(region, bedrockGenerator{}),
+		entities:      entities.NewManager(cfg.Server.ID),
+		dirtyEntities: make(map[entities.ID]entities.Entity),
+	}
+
+	for _, tc := range []struct {
+		id   string
+		from []float64
+		want world.Vec3
+	}{
+		{"buried", []float64{2.5, 3.25, 1.5}, world.Vec3{X: 2.5, Y: 3.25, Z: 4}},
+		{"standing", []float64{5.5, 5.5, 4}, world.Vec3{X: 5.5, Y: 5.5, Z: 4}},
+		{"falling", []float64{1.5, 1.5, 6.5}, world.Vec3{X: 1.5, Y: 1.5, Z: 6.5}},
+	} {
+		ack := srv.handleTransferRequest(context.Background(), network.TransferRequest{
+			EntityID: tc.id,
+			State:    network.EntityState{ID: tc.id, Kind: string(entities.KindUnit), Position: tc.from},
+		})
+		if !ack.Accepted {
+			t.Fatalf("%s: transfer refused: %s", tc.id, ack.Message)
+		}
+		ent, ok := srv.entities.Entity(entities.ID(tc.id))
+		if !ok {
+			t.Fatalf("%s: entity not added", tc.id)
+		}
+		if got := ent.PositionVec(); got != tc.want {
+			t.Fatalf("%s: position = %+v, want %+v", tc.id, got, tc.want)
+		}
+	}
+}
+
+func TestTransferRequestIntoFullChunkIsRejected(t *testing.T) {
+	srv := newExplosionTestServer(t)
+	srv.entities.SetMaxPerChunk(1)
+	addUnit(t, srv, "resident", entities.Vec3{X: 2, Y: 2, Z: 1})
+
+	ack := srv.handleTransferRequest(context.Background(), network.TransferRequest{
+		EntityID: "incoming",
+		State:    network.EntityState{ID: "incoming", Kind: string(entities.KindUnit), Position: []float64{3, 3, 1}},
+	})
+	if ack.Accepted {
+		t.Fatalf("expected a transfer into a full chunk to be refused")
+	}
+	if !strings.Contains(ack.Message, "is full") {
+		t.Fatalf("ack message %q does not say the chunk is full", ack.Message)
+	}
+	if _, ok := srv.entities.Entity("incoming"); ok {
+		t.Fatalf("refused entity was added")
+	}
+}
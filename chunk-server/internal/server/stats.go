@@ -0,0 +1,161 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"chunkserver/internal/network"
+	"chunkserver/internal/pathfinding"
+)
+
+// Stats is the JSON document served at /stats.
+type Stats struct {
+	ServerID       string            `json:"serverId"`
+	Timestamp      time.Time         `json:"timestamp"`
+	Chunks         ChunkStats        `json:"chunks"`
+	Entities       EntityStats       `json:"entities"`
+	PathRequests   map[string]uint64 `json:"pathRequests"`
+	MigrationQueue int               `json:"migrationQueue"`
+	// MigrationDeadLetters counts migrations abandoned after
+	// network.transferMaxAttempts failed attempts.
+	MigrationDeadLetters int              `json:"migrationDeadLetters"`
+	Network              network.Stats    `json:"network"`
+	Neighbors            []NeighborStatus `json:"neighbors"`
+}
+
+type ChunkStats struct {
+	Resident int `json:"resident"`
+	Pending  int `json:"pending"`
+}
+
+type EntityStats struct {
+	Total  int            `json:"total"`
+	ByKind map[string]int `json:"byKind"`
+}
+
+// pathRequestCounter counts path requests by traversal mode.
+type pathRequestCounter struct {
+	mu     sync.Mutex
+	counts map[string]uint64
+}
+
+func (c *pathRequestCounter) record(mode pathfinding.Mode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]uint64)
+	}
+	c.counts[modeLabel(mode)]++
+}
+
+func (c *pathRequestCounter) snapshot() map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]uint64, len(c.counts))
+	for mode, n := range c.counts {
+		out[mode] = n
+	}
+	return out
+}
+
+func modeLabel(mode pathfinding.Mode) string {
+	switch mode {
+	case pathfinding.ModeFlying:
+		return "flying"
+	case pathfinding.ModeUnderground:
+		return "underground"
+	default:
+		return "ground"
+	}
+}
+
+// Stats gathers a point-in-time view of the server's load.
+func (s *Server) Stats() Stats {
+	stats := Stats{
+		ServerID:     s.currentConfig().Server.ID,
+		Timestamp:    s.now().UTC(),
+		PathRequests: s.pathRequests.snapshot(),
+		Entities:     EntityStats{ByKind: make(map[string]int)},
+	}
+	if s.world != nil {
+		stats.Chunks.Resident, stats.Chunks.Pending = s.world.ChunkCounts()
+	}
+	if s.entities != nil {
+		for kind, n := range s.entities.CountByKind() {
+			stats.Entities.ByKind[string(kind)] = n
+			stats.Entities.Total += n
+		}
+	}
+	if s.migrationQueue != nil {
+		stats.MigrationQueue = s.migrationQueue.Len()
+		stats.MigrationDeadLetters = s.migrationQueue.DeadLetterCount()
+	}
+	if s.net != nil {
+		stats.Network = s.net.Stats()
+	}
+	stats.Neighbors = s.NeighborStatus()
+	return stats
+}
+
+// NeighborStatus reports the neighbor topology this server has learned from
+// configuration and discovery, for spotting mis-wired or split clusters.
+func (s *Server) NeighborStatus() []NeighborStatus {
+	if s.neighbors == nil {
+		return nil
+	}
+	return s.neighbors.statuses(s.now(), s.currentConfig().Network.DiscoveryInterval.Duration())
+}
+
+// httpHandler serves /stats and the /healthz probe used by central. The probe
+// fails once the server starts draining.
+func (s *Server) httpHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if s.isDraining() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"status":"draining"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	})
+	mux.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(s.Stats()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	return mux
+}
+
+// serveHTTP runs the stats endpoint on network.listenHttp until ctx is done.
+// An empty listen address disables it.
+func (s *Server) serveHTTP(ctx context.Context) error {
+	addr := s.currentConfig().Network.ListenHTTP
+	if addr == "" {
+		return nil
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	httpSrv := &http.Server{Handler: s.httpHandler(), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = httpSrv.Shutdown(shutdownCtx)
+	}()
+	s.logger.Info("stats endpoint listening", "addr", listener.Addr().String())
+	go func() {
+		if err := httpSrv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Warn("stats endpoint stopped", "err", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"chunkserver/internal/config"
+	"chunkserver/internal/entities"
+	"chunkserver/internal/migration"
+	"chunkserver/internal/network"
+	"chunkserver/internal/pathfinding"
+	"chunkserver/internal/world"
+)
+
+func TestStatsEndpointReportsActivity(t *testing.T) {
+	netSrv, err := network.Listen("127.0.0.1:0", noopLogger(), 0)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer netSrv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = netSrv.Serve(ctx) }()
+
+	cfg := config.Default()
+	region := world.ServerRegion{ChunksX: 1, ChunksY: 1, ChunkDimension: world.Dimensions{Width: 4, Depth: 4, Height: 4}}
+	srv := &Server{
+		cfg:            cfg,
+		net:            netSrv,
+		logger:         noopLogger(),
+		world:          world.NewManager(region, nil),
+		entities:       entities.NewManager(cfg.Server.ID),
+		migrationQueue: migration.NewQueue(),
+	}
+
+	for _, ent := range []*entities.Entity{
+		{ID: "unit-1", Kind: entities.KindUnit},
+		{ID: "unit-2", Kind: entities.KindUnit},
+		{ID: "shell-1", Kind: entities.KindProjectile},
+	} {
+		if err := srv.entities.Add(ent); err != nil {
+			t.Fatalf("add entity: %v", err)
+		}
+	}
+	srv.pathRequests.record(pathfinding.ModeGround)
+	srv.pathRequests.record(pathfinding.ModeGround)
+	srv.pathRequests.record(pathfinding.ModeFlying)
+	srv.migrationQueue.Enqueue(migration.Request{EntityID: "unit-2"})
+
+	conn, err := net.Dial("udp", netSrv.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("not an envelope")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for netSrv.Stats().DecodeErrors == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("malformed datagram was not counted")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	httpSrv := httptest.NewServer(srv.httpHandler())
+	defer httpSrv.Close()
+
+	resp, err := http.Get(httpSrv.URL + "/stats")
+	if err != nil {
+		t.Fatalf("get stats: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("stats status = %s", resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("stats content type = %q", ct)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		t.Fatalf("decode stats: %v", err)
+	}
+	for _, field := range []string{"serverId", "timestamp", "chunks", "entities", "pathRequests", "migrationQueue", "network", "neighbors"} {
+		if _, ok := raw[field]; !ok {
+			t.Fatalf("stats missing field %q: %s", field, raw)
+		}
+	}
+
+	var stats Stats
+	data, _ := json.Marshal(raw)
+	if err := json.Unmarshal(data, &stats); err != nil {
+		t.Fatalf("decode stats document: %v", err)
+	}
+	if stats.Entities.Total != 3 || stats.Entities.ByKind["unit"] != 2 || stats.Entities.ByKind["projectile"] != 1 {
+		t.Fatalf("unexpected entity stats: %+v", stats.Entities)
+	}
+	if stats.PathRequests["ground"] != 2 || stats.PathRequests["flying"] != 1 {
+		t.Fatalf("unexpected path request counts: %+v", stats.PathRequests)
+	}
+	if stats.MigrationQueue != 1 {
+		t.Fatalf("migration queue = %d, want 1", stats.MigrationQueue)
+	}
+	if stats.Network.Received == 0 || stats.Network.DecodeErrors == 0 {
+		t.Fatalf("expected network counters to record the malformed datagram: %+v", stats.Network)
+	}
+
+	health, err := http.Get(httpSrv.URL + "/healthz")
+	if err != nil {
+		t.Fatalf("get healthz: %v", err)
+	}
+	health.Body.Close()
+	if health.StatusCode != http.StatusOK {
+		t.Fatalf("healthz status = %s", health.Status)
+	}
+}
@@ -0,0 +1,265 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"chunkserver/internal/clock"
+	"chunkserver/internal/config"
+	"chunkserver/internal/network"
+	"chunkserver/internal/world"
+)
+
+func TestChunkSummaryVersionTracksBlockChanges(t *testing.T) {
+	mainServer, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen main server: %v", err)
+	}
+	defer mainServer.Close()
+
+	netSrv, err := network.Listen("127.0.0.1:0", noopLogger(), 0)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer netSrv.Close()
+
+	cfg := config.Default()
+	cfg.Network.MainServerEndpoints = []string{mainServer.LocalAddr().String()}
+	region := world.ServerRegion{
+		ChunksX:        1,
+		ChunksY:        1,
+		ChunkDimension: world.Dimensions{Width: 4, Depth: 4, Height: 4},
+	}
+	srv := &Server{
+		cfg:    cfg,
+		net:    netSrv,
+		logger: noopLogger(),
+		world:  world.NewManager(region, stubGenerator{}),
+	}
+
+	ctx := context.Background()
+	coord := world.ChunkCoord{}
+	chunk, err := srv.world.Chunk(ctx, coord)
+	if err != nil {
+		t.Fatalf("load chunk: %v", err)
+	}
+
+	send := func() network.ChunkSummary {
+		t.Helper()
+		if err := srv.sendChunkSummary(ctx, coord); err != nil {
+			t.Fatalf("sendChunkSummary() error = %v", err)
+		}
+		return readChunkSummary(t, mainServer)
+	}
+
+	first := send()
+	if again := send(); again.Version != first.Version {
+		t.Fatalf("unchanged chunk reported version %d then %d", first.Version, again.Version)
+	}
+
+	if !chunk.SetLocalBlock(1, 1, 3, world.Block{Type: world.BlockSolid}) {
+		t.Fatalf("set block failed")
+	}
+	changed := send()
+	if changed.Version <= first.Version {
+		t.Fatalf("expected version to advance past %d after a block change, got %d", first.Version, changed.Version)
+	}
+	if changed.Fingerprint == first.Fingerprint || changed.Fingerprint != fmt.Sprintf("%016x", chunk.Fingerprint()) {
+		t.Fatalf("summary fingerprint %q after a block change, was %q; want the chunk's new fingerprint", changed.Fingerprint, first.Fingerprint)
+	}
+}
+
+func TestChunkSummariesOnlyFollowEditsAfterBaseline(t *testing.T) {
+	mainServer, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen main server: %v", err)
+	}
+	defer mainServer.Close()
+
+	netSrv, err := network.Listen("127.0.0.1:0", noopLogger(), 0)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer netSrv.Close()
+
+	cfg := config.Default()
+	cfg.Network.MainServerEndpoints = []string{mainServer.LocalAddr().String()}
+	region := world.ServerRegion{
+		ChunksX:        2,
+		ChunksY:        2,
+		ChunkDimension: world.Dimensions{Width: 4, Depth: 4, Height: 4},
+	}
+	srv := &Server{
+		cfg:             cfg,
+		net:             netSrv,
+		logger:          noopLogger(),
+		world:           world.NewManager(region, stubGenerator{}),
+		dirtyChunks:     make(map[world.ChunkCoord]struct{}),
+		summaryVersions: make(map[world.ChunkCoord]uint64),
+		chunkTraversal:  buildCircularChunkTraversal(region.ChunksX, region.ChunksY),
+	}
+
+	ctx := context.Background()
+	chunks := make(map[world.ChunkCoord]*world.Chunk)
+	for x := 0; x < region.ChunksX; x++ {
+		for y := 0; y < region.ChunksY; y++ {
+			coord := world.ChunkCoord{X: x, Y: y}
+			chunk, err := srv.world.Chunk(ctx, coord)
+			if err != nil {
+				t.Fatalf("load chunk %v: %v", coord, err)
+			}
+			chunks[coord] = chunk
+		}
+	}
+
+	baseline := make(map[world.ChunkCoord]bool)
+	for range chunks {
+		srv.broadcastChunkSummaries(ctx)
+		summary := readChunkSummary(t, mainServer)
+		baseline[world.ChunkCoord{X: summary.ChunkX, Y: summary.ChunkY}] = true
+	}
+	if len(baseline) != len(chunks) {
+		t.Fatalf("baseline covered %d chunks, want %d", len(baseline), len(chunks))
+	}
+
+	// A quiet region sends nothing once the baseline is out.
+	for i := 0; i < 3; i++ {
+		srv.broadcastChunkSummaries(ctx)
+	}
+	expectNoDatagram(t, mainServer)
+
+	// Repeated edits to one chunk coalesce into a single summary, and a chunk
+	// marked dirty without changing is skipped.
+	edited := world.ChunkCoord{X: 1, Y: 0}
+	for z := 1; z <= 2; z++ {
+		if !chunks[edited].SetLocalBlock(1, 1, z, world.Block{Type: world.BlockSolid}) {
+			t.Fatalf("set block failed")
+		}
+		srv.markChunksDirty([]world.ChunkCoord{edited})
+	}
+	srv.markChunksDirty([]world.ChunkCoord{{X: 0, Y: 1}})
+	for i := 0; i < 3; i++ {
+		srv.broadcastChunkSummaries(ctx)
+	}
+	summary := readChunkSummary(t, mainServer)
+	if got := (world.ChunkCoord{X: summary.ChunkX, Y: summary.ChunkY}); got != edited {
+		t.Fatalf("expected a summary for edited chunk %v, got %v", edited, got)
+	}
+	if summary.Version != chunks[edited].Version() {
+		t.Fatalf("expected version %d, got %d", chunks[edited].Version(), summary.Version)
+	}
+	expectNoDatagram(t, mainServer)
+}
+
+func expectNoDatagram(t *testing.T, conn net.PacketConn) {
+	t.Helper()
+	buffer := make([]byte, 65536)
+	if err := conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond)); err != nil {
+		t.Fatalf("set deadline: %v", err)
+	}
+	if n, _, err := conn.ReadFrom(buffer); err == nil {
+		env, _ := network.Decode(buffer[:n])
+		t.Fatalf("expected no datagram, got %s", env.Type)
+	}
+}
+
+func readChunkSummary(t *testing.T, conn net.PacketConn) network.ChunkSummary {
+	t.Helper()
+	buffer := make([]byte, 65536)
+	if err := conn.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+		t.Fatalf("set deadline: %v", err)
+	}
+	n, _, err := conn.ReadFrom(buffer)
+	if err != nil {
+		t.Fatalf("read datagram: %v", err)
+	}
+	env, err := network.Decode(buffer[:n])
+	if err != nil {
+		t.Fatalf("decode datagram: %v", err)
+	}
+	if env.Type != network.MessageChunkSummary {
+		t.Fatalf("expected chunk summary, got %s", env.Type)
+	}
+	var summary network.ChunkSummary
+	if err := json.Unmarshal(env.Payload, &summary); err != nil {
+		t.Fatalf("decode summary: %v", err)
+	}
+	return summary
+}
+
+func TestChunkSummariesResendBaselineOnHelloAndResync(t *testing.T) {
+	mainServer, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen main server: %v", err)
+	}
+	defer mainServer.Close()
+
+	netSrv, err := network.Listen("127.0.0.1:0", noopLogger(), 0)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer netSrv.Close()
+
+	clk := clock.NewManual(time.Unix(0, 0))
+	cfg := config.Default()
+	cfg.Network.MainServerEndpoints = []string{mainServer.LocalAddr().String()}
+	cfg.Server.SummaryResync = config.Duration(time.Minute)
+	region := world.ServerRegion{
+		ChunksX:        2,
+		ChunksY:        2,
+		ChunkDimension: world.Dimensions{Width: 4, Depth: 4, Height: 4},
+	}
+	srv := &Server{
+		cfg:             cfg,
+		net:             netSrv,
+		logger:          noopLogger(),
+		clock:           clk,
+		world:           world.NewManager(region, stubGenerator{}),
+		dirtyChunks:     make(map[world.ChunkCoord]struct{}),
+		summaryVersions: make(map[world.ChunkCoord]uint64),
+		chunkTraversal:  buildCircularChunkTraversal(region.ChunksX, region.ChunksY),
+	}
+	ctx := context.Background()
+	for x := 0; x < region.ChunksX; x++ {
+		for y := 0; y < region.ChunksY; y++ {
+			if _, err := srv.world.Chunk(ctx, world.ChunkCoord{X: x, Y: y}); err != nil {
+				t.Fatalf("load chunk: %v", err)
+			}
+		}
+	}
+	chunkCount := region.ChunksX * region.ChunksY
+	expectFullPass := func(what string) {
+		t.Helper()
+		seen := make(map[world.ChunkCoord]bool)
+		for i := 0; i < chunkCount; i++ {
+			srv.broadcastChunkSummaries(ctx)
+			summary := readChunkSummary(t, mainServer)
+			seen[world.ChunkCoord{X: summary.ChunkX, Y: summary.ChunkY}] = true
+		}
+		if len(seen) != chunkCount {
+			t.Fatalf("%s: resent %d chunks, want all %d", what, len(seen), chunkCount)
+		}
+		srv.broadcastChunkSummaries(ctx)
+		expectNoDatagram(t, mainServer)
+	}
+
+	expectFullPass("initial baseline")
+
+	// Part way through a pass, a reconnecting main server still gets every
+	// chunk after its hello.
+	srv.onMainServerHello(ctx, nil, neighborEnvelope(t, network.MessageHello, network.Hello{ServerID: "main"}))
+	srv.broadcastChunkSummaries(ctx)
+	readChunkSummary(t, mainServer)
+	srv.onMainServerHello(ctx, nil, neighborEnvelope(t, network.MessageHello, network.Hello{ServerID: "main"}))
+	expectFullPass("after hello")
+
+	clk.Advance(59 * time.Second)
+	srv.broadcastChunkSummaries(ctx)
+	expectNoDatagram(t, mainServer)
+	clk.Advance(time.Second)
+	expectFullPass("after the resync interval")
+}
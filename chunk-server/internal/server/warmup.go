@@ -0,0 +1,28 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"chunkserver/internal/world"
+)
+
+// Warmup pre-generates and persists the region's chunks inside the inclusive
+// box from from to to, so a fresh server does not generate terrain lazily
+// during play. Generation is bounded by server.maxConcurrentLoads. Progress is
+// logged about every tenth of the way.
+func (s *Server) Warmup(ctx context.Context, from, to world.ChunkCoord) error {
+	started := time.Now()
+	logged := 0
+	warmed, err := s.world.Warmup(ctx, from, to, func(done, total int) {
+		if done == total || (done-logged)*10 >= total {
+			s.logger.Info("warmup progress", "ready", done, "total", total)
+			logged = done
+		}
+	})
+	if err != nil {
+		return err
+	}
+	s.logger.Info("warmup complete", "chunks", warmed, "elapsed", time.Since(started).Round(time.Millisecond))
+	return nil
+}
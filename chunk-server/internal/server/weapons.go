@@ -0,0 +1,164 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"chunkserver/internal/entities"
+)
+
+// defaultWeaponCooldown is the seconds between shots for a weapon block with
+// no "cooldown" setting.
+const defaultWeaponCooldown = 1.0
+
+// weaponExplosionKeys are the settings copied from a weapon onto the
+// projectiles it fires; handleProjectileImpact reads them on detonation.
+var weaponExplosionKeys = []string{"explosion_radius", "explosion_damage", "explosion_falloff"}
+
+// fireWeapons fires every weapon-role block on ent that is off cooldown at the
+// point held in the unit's "target_x", "target_y" and "target_z" attributes.
+// Each weapon counts down its own "weapon_<index>_cooldown" attribute, where
+// index is the block's position in ent.Blocks. A weapon that cannot reach the
+// target holds its fire and shoots as soon as a solution exists; one whose
+// block hit points have run out no longer fires. Armed entities with a target
+// or a pending cooldown keep their chunk awake so the countdown continues.
+func (s *Server) fireWeapons(ent *entities.Entity, delta time.Duration, physics entities.PhysicsParams) {
+	snapshot := ent.Snapshot()
+	if snapshot.Dying || snapshot.Capabilities.ProjectileVelocity <= 0 || !ent.SystemOnline(entities.BlockRoleWeapon) {
+		return
+	}
+	target, ok := weaponTarget(&snapshot)
+	if !ok {
+		return
+	}
+	for index, block := range snapshot.Blocks {
+		if block.Role != entities.BlockRoleWeapon {
+			continue
+		}
+		if entities.BlockDestroyed(snapshot.Blocks, snapshot.Stats.BlockHP, index) {
+			continue
+		}
+		key := entities.WeaponCooldownAttribute(index)
+		if remaining, ok := ent.ReduceAttribute(key, delta.Seconds()); ok && remaining > 0 {
+			continue
+		}
+		origin := entities.BlockPosition(snapshot.Position, block)
+		offset := entities.Vec3{X: target.X - origin.X, Y: target.Y - origin.Y, Z: target.Z - origin.Z}
+		velocity, flight, ok := aimProjectile(offset, snapshot.Capabilities.ProjectileVelocity, physics, delta, snapshot.Capabilities.ProjectileArc)
+		if !ok {
+			continue
+		}
+		projectile := &entities.Entity{
+			ID:       entities.ID(fmt.Sprintf("%s-shot-%d", snapshot.ID, s.projectileSeq.Add(1))),
+			Kind:     entities.KindProjectile,
+			Chunk:    entities.ChunkMembership{Chunk: snapshot.Chunk.Chunk},
+			Position: origin,
+			Velocity: velocity,
+			Owner:    snapshot.ID,
+			// The shot sides with its firer's squad.
+			CollisionGroup: snapshot.CollisionGroup,
+		}
+		projectile.SetAttribute("projectile_life", flight)
+		for _, setting := range weaponExplosionKeys {
+			if value, ok := weaponSetting(&snapshot, block, setting); ok {
+				projectile.SetAttribute(setting, value)
+			}
+		}
+		if err := s.entities.Add(projectile); err != nil {
+			s.logger.Warnf("fire weapon on %s: %v", snapshot.ID, err)
+			continue
+		}
+		s.recordDirtyEntity(projectile)
+		cooldown, ok := weaponSetting(&snapshot, block, "cooldown")
+		if !ok || cooldown <= 0 {
+			cooldown = defaultWeaponCooldown
+		}
+		ent.SetAttribute(key, cooldown)
+	}
+}
+
+// weaponTarget reads the point a unit's weapons are aimed at.
+func weaponTarget(ent *entities.Entity) (entities.Vec3, bool) {
+	x, okX := ent.Attributes["target_x"]
+	y, okY := ent.Attributes["target_y"]
+	z, okZ := ent.Attributes["target_z"]
+	if !okX || !okY || !okZ {
+		return entities.Vec3{}, false
+	}
+	return entities.Vec3{X: x, Y: y, Z: z}, true
+}
+
+// weaponSetting looks up a weapon setting on the block's metadata first, then
+// on the owning entity's "weapon_<key>" attribute.
+func weaponSetting(ent *entities.Entity, block entities.EntityBlock, key string) (float64, bool) {
+	if value, ok := entities.BlockSetting(block, key); ok {
+		return value, true
+	}
+	value, ok := ent.Attributes["weapon_"+key]
+	return value, ok
+}
+
+// maxAimTicks bounds how many ticks of flight aimProjectile considers.
+const maxAimTicks = 2048
+
+// aimProjectile returns the launch velocity and the "projectile_life" in
+// seconds for a projectile that should detonate at offset from its muzzle. It
+// solves against the same per-tick integration stepProjectile applies, gravity
+// then air drag then movement, for projectiles ticked every tick seconds, and
+// picks a launch speed no faster than speed. Direct weapons take the shortest
+// flight that reaches the target; arc weapons the longest, lobbing the shot
+// over obstacles. Wind and the fall speed cap are not predicted.
+func aimProjectile(offset entities.Vec3, speed float64, physics entities.PhysicsParams, tick time.Duration, arc bool) (entities.Vec3, float64, bool) {
+	dt := tick.Seconds()
+	if dt <= 0 || speed <= 0 || offset == (entities.Vec3{}) {
+		return entities.Vec3{}, 0, false
+	}
+	gravity := 0.0
+	if physics.SupportsGravity {
+		gravity = physics.Gravity
+	}
+	decay := 1.0
+	if physics.AirDrag > 0 {
+		decay = math.Exp(-physics.AirDrag * dt)
+	}
+	// After n ticks a launch velocity v has moved the projectile
+	// dt*v*sum(decay^i) horizontally and dt*(v.Z*sum(decay^i) - g*dt*sum of
+	// those partial sums) vertically, so the velocity needed to be at offset
+	// after n ticks follows directly.
+	var (
+		best     entities.Vec3
+		bestN    int
+		reached  bool
+		power    = 1.0
+		partial  float64
+		gravSums float64
+	)
+	for n := 1; n <= maxAimTicks; n++ {
+		power *= decay
+		partial += power
+		gravSums += partial
+		velocity := entities.Vec3{
+			X: offset.X / (dt * partial),
+			Y: offset.Y / (dt * partial),
+			Z: (offset.Z/dt + gravity*dt*gravSums) / partial,
+		}
+		needed := math.Sqrt(velocity.X*velocity.X + velocity.Y*velocity.Y + velocity.Z*velocity.Z)
+		if needed > speed {
+			if reached {
+				break
+			}
+			continue
+		}
+		best, bestN, reached = velocity, n, true
+		if !arc || gravity <= 0 {
+			break
+		}
+	}
+	if !reached {
+		return entities.Vec3{}, 0, false
+	}
+	// tickProjectile detonates on the first tick that takes the life to zero
+	// or below; half a tick of slack keeps rounding from adding or losing one.
+	return best, (float64(bestN) - 0.5) * dt, true
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"chunkserver/internal/entities"
+	"chunkserver/internal/environment"
+	"chunkserver/internal/world"
+)
+
+func addGunner(t *testing.T, srv *Server, arc bool, target entities.Vec3) *entities.Entity {
+	t.Helper()
+	gunner := addUnit(t, srv, "gunner", entities.Vec3{X: 2, Y: 2, Z: 0})
+	gunner.Capabilities = entities.Capabilities{ProjectileVelocity: 20, ProjectileArc: arc}
+	gunner.Blocks = []entities.EntityBlock{
+		{Role: entities.BlockRoleStructure},
+		{
+			Role:   entities.BlockRoleWeapon,
+			Offset: entities.Vec3{Z: 20},
+			Block:  world.Block{Metadata: map[string]any{"cooldown": 1.0, "explosion_radius": 2.0}},
+		},
+	}
+	gunner.SetAttribute("target_x", target.X)
+	gunner.SetAttribute("target_y", target.Y)
+	gunner.SetAttribute("target_z", target.Z)
+	gunner.SetAttribute("weapon_explosion_damage", 40)
+	return gunner
+}
+
+// firedProjectiles lists the IDs of the projectiles among the dirty entities.
+func firedProjectiles(srv *Server) []entities.ID {
+	var shots []entities.ID
+	for id := range srv.dirtyEntities {
+		if srv.dirtyEntities[id].Kind == entities.KindProjectile {
+			shots = append(shots, id)
+		}
+	}
+	return shots
+}
+
+func TestWeaponFiresOncePerCooldownInterval(t *testing.T) {
+	srv := newExplosionTestServer(t)
+	target := entities.Vec3{X: 14, Y: 2, Z: 1}
+	addGunner(t, srv, false, target)
+
+	// Ticks of 250ms against a 1s cooldown: shots at 0s, 1s and 2s.
+	for i := 0; i < 12; i++ {
+		srv.tickEntities(250*time.Millisecond, 1)
+	}
+	shots := firedProjectiles(srv)
+	if len(shots) != 3 {
+		t.Fatalf("expected 3 projectiles over 3s with a 1s cooldown, got %d", len(shots))
+	}
+
+	// Earlier shots may still be in flight and dirty; only count new ones.
+	fired := make(map[entities.ID]bool, len(shots))
+	for _, id := range shots {
+		fired[id] = true
+	}
+	srv.dirtyEntities = make(map[entities.ID]entities.Entity)
+	srv.tickEntities(250*time.Millisecond, 1)
+	var fresh []entities.ID
+	for _, id := range firedProjectiles(srv) {
+		if !fired[id] {
+			fresh = append(fresh, id)
+		}
+	}
+	if len(fresh) != 1 {
+		t.Fatalf("expected one more projectile once the cooldown elapsed, got %d", len(fresh))
+	}
+	shot := srv.dirtyEntities[fresh[0]].Attributes
+	if shot["explosion_radius"] != 2 || shot["explosion_damage"] != 40 {
+		t.Fatalf("expected explosion settings from the weapon, got %v", shot)
+	}
+	if owner := srv.dirtyEntities[fresh[0]].Owner; owner != "gunner" {
+		t.Fatalf("projectile owner = %q, want the gunner", owner)
+	}
+}
+
+// flyShot steps a projectile launched from origin the way tickProjectile
+// does, in still air, and returns where it detonates.
+func flyShot(t *testing.T, origin, velocity entities.Vec3, life float64, physics entities.PhysicsParams, tick time.Duration) entities.Vec3 {
+	t.Helper()
+	shot := &entities.Entity{Kind: entities.KindProjectile, Position: origin, Velocity: velocity}
+	shot.SetAttribute("projectile_life", life)
+	for i := 0; i < maxAimTicks+1; i++ {
+		stepProjectile(shot, tick, physics, environment.State{})
+		if remaining, _ := shot.ReduceAttribute("projectile_life", tick.Seconds()); remaining <= 0 {
+			return shot.PositionVec()
+		}
+	}
+	t.Fatalf("projectile never detonated")
+	return entities.Vec3{}
+}
+
+func assertNear(t *testing.T, got, want entities.Vec3, tolerance float64) {
+	t.Helper()
+	if math.Abs(got.X-want.X) > tolerance || math.Abs(got.Y-want.Y) > tolerance || math.Abs(got.Z-want.Z) > tolerance {
+		t.Fatalf("projectile detonates at %v, want %v", got, want)
+	}
+}
+
+func TestWeaponAimsDirectFireAtTarget(t *testing.T) {
+	srv := newExplosionTestServer(t)
+	target := entities.Vec3{X: 14, Y: 10, Z: 1}
+	addGunner(t, srv, false, target)
+	tick := 50 * time.Millisecond
+	srv.tickEntities(tick, 1)
+
+	shots := firedProjectiles(srv)
+	if len(shots) != 1 {
+		t.Fatalf("expected one projectile, got %d", len(shots))
+	}
+	position, velocity := srv.dirtyEntities[shots[0]].Position, srv.dirtyEntities[shots[0]].Velocity
+	origin := entities.Vec3{X: 2, Y: 2, Z: 1}
+	if position != origin {
+		t.Fatalf("expected the projectile at the weapon's muzzle %v, got %v", origin, position)
+	}
+	if speed := math.Sqrt(velocity.X*velocity.X + velocity.Y*velocity.Y + velocity.Z*velocity.Z); speed > 20 {
+		t.Fatalf("launched at %v blocks/s, faster than the weapon's 20", speed)
+	}
+	life := srv.dirtyEntities[shots[0]].Attributes["projectile_life"]
+	landed := flyShot(t, origin, velocity, life, srv.basePhysics(), tick)
+	assertNear(t, landed, target, 1e-6)
+}
+
+func TestAimProjectileLandsOnTargetUnderGravityAndDrag(t *testing.T) {
+	physics := entities.PhysicsParams{Gravity: 9.8, AirDrag: 0.4, SupportsGravity: true}
+	offset := entities.Vec3{X: 12, Y: 9, Z: -1}
+	for _, tick := range []time.Duration{50 * time.Millisecond, 250 * time.Millisecond} {
+		direct, directLife, ok := aimProjectile(offset, 20, physics, tick, false)
+		if !ok {
+			t.Fatalf("tick %s: expected a direct firing solution", tick)
+		}
+		assertNear(t, flyShot(t, entities.Vec3{}, direct, directLife, physics, tick), offset, 1e-6)
+
+		lob, lobLife, ok := aimProjectile(offset, 20, physics, tick, true)
+		if !ok {
+			t.Fatalf("tick %s: expected an arc firing solution", tick)
+		}
+		assertNear(t, flyShot(t, entities.Vec3{}, lob, lobLife, physics, tick), offset, 1e-6)
+		if lobLife <= directLife || lob.Z <= math.Hypot(lob.X, lob.Y) {
+			t.Fatalf("tick %s: expected the arc %v to fly higher and longer than the direct shot %v", tick, lob, direct)
+		}
+	}
+	if _, _, ok := aimProjectile(entities.Vec3{X: 500}, 20, physics, 50*time.Millisecond, true); ok {
+		t.Fatalf("expected no solution beyond maximum range")
+	}
+}
+
+func TestStationaryTurretKeepsFiringWhenItsChunkWouldSleep(t *testing.T) {
+	srv := newExplosionTestServer(t)
+	srv.entities.SetSleepAfter(2)
+	turret := addGunner(t, srv, false, entities.Vec3{X: 14, Y: 2, Z: 1})
+	// A 3s cooldown outlasts the two idle 250ms ticks after which a chunk of
+	// stationary entities falls asleep.
+	turret.Blocks[1].Block.Metadata["cooldown"] = 3.0
+
+	for i := 0; i < 40; i++ {
+		srv.tickEntities(250*time.Millisecond, 1)
+	}
+	if shots := firedProjectiles(srv); len(shots) != 4 {
+		t.Fatalf("expected shots at 0s, 3s, 6s and 9s, got %d", len(shots))
+	}
+	if vel := turret.Snapshot().Velocity; vel != (entities.Vec3{}) {
+		t.Fatalf("expected the turret to stay put, got velocity %+v", vel)
+	}
+}
@@ -356,14 +356,14 @@ func (g *NoiseGenerator) buildTree(buffer *chunkWriteBuffer, bounds world.Bounds
 				}
 
 				if dist <= float64(interior)-0.25 {
-					clearBlock(buffer, dim, targetX, targetY, localZ)
+					g.clearBlock(buffer, dim, targetX, targetY, localZ)
 					continue
 				}
 
 				block := g.blockForPart(variant.trunkBlock, variant.name, "trunk", map[string]any{
 					"level": level,
 				})
-				setBlock(buffer, dim, targetX, targetY, localZ, block)
+				g.setBlock(buffer, dim, targetX, targetY, localZ, block)
 			}
 		}
 	}
@@ -380,13 +380,13 @@ func (g *NoiseGenerator) buildTree(buffer *chunkWriteBuffer, bounds world.Bounds
 					}
 					if math.Abs(float64(dx))+math.Abs(float64(dy)) < 1 {
 						// leave core open for circulation
-						clearBlock(buffer, dim, placement.localX+dx, placement.localY+dy, localZ)
+						g.clearBlock(buffer, dim, placement.localX+dx, placement.localY+dy, localZ)
 						continue
 					}
 					block := g.blockForPart(variant.floorBlock, variant.name, "floor", map[string]any{
 						"level": level,
 					})
-					setBlock(buffer, dim, placement.localX+dx, placement.localY+dy, localZ, block)
+					g.setBlock(buffer, dim, placement.localX+dx, placement.localY+dy, localZ, block)
 				}
 			}
 		}
@@ -437,8 +437,8 @@ func (g *NoiseGenerator) buildSpiral(buffer *chunkWriteBuffer, dim world.Dimensi
 		block := g.blockForPart(variant.stairBlock, variant.name, "stair", map[string]any{
 			"level": level,
 		})
-		setBlock(buffer, dim, targetX, targetY, targetZ, block)
-		clearBlock(buffer, dim, targetX, targetY, targetZ+1)
+		g.setBlock(buffer, dim, targetX, targetY, targetZ, block)
+		g.clearBlock(buffer, dim, targetX, targetY, targetZ+1)
 	}
 }
 
@@ -464,14 +464,14 @@ func (g *NoiseGenerator) carveEntrance(buffer *chunkWriteBuffer, dim world.Dimen
 			}
 			tz := baseLocalZ + h
 			if inColumnBounds(dim, tx, ty) {
-				clearBlock(buffer, dim, tx, ty, tz)
+				g.clearBlock(buffer, dim, tx, ty, tz)
 			}
 			// carve tunnel inward
 			for offset := 1; offset <= radius; offset++ {
 				innerX := placement.localX + dir.dx*(levelRadius-offset)
 				innerY := placement.localY + dir.dy*(levelRadius-offset)
 				if inColumnBounds(dim, innerX, innerY) {
-					clearBlock(buffer, dim, innerX, innerY, tz)
+					g.clearBlock(buffer, dim, innerX, innerY, tz)
 				}
 			}
 		}
@@ -485,7 +485,7 @@ func (g *NoiseGenerator) carveEntrance(buffer *chunkWriteBuffer, dim world.Dimen
 				continue
 			}
 			block := g.blockForPart(variant.floorBlock, variant.name, "entry", nil)
-			setBlock(buffer, dim, placement.localX+dx, placement.localY+dy, entryZ-1, block)
+			g.setBlock(buffer, dim, placement.localX+dx, placement.localY+dy, entryZ-1, block)
 		}
 	}
 }
@@ -529,7 +529,7 @@ func (g *NoiseGenerator) buildRoots(buffer *chunkWriteBuffer, dim world.Dimensio
 					continue
 				}
 				block := g.blockForPart(variant.trunkBlock, variant.name, "stump", map[string]any{"level": level})
-				setBlock(buffer, dim, placement.localX+dx, placement.localY+dy, localZ, block)
+				g.setBlock(buffer, dim, placement.localX+dx, placement.localY+dy, localZ, block)
 			}
 		}
 	}
@@ -566,7 +566,7 @@ func (g *NoiseGenerator) buildRoots(buffer *chunkWriteBuffer, dim world.Dimensio
 			})
 
 			for fill := bottomZ; fill <= topZ; fill++ {
-				setBlock(buffer, dim, targetX, targetY, fill, block)
+				g.setBlock(buffer, dim, targetX, targetY, fill, block)
 			}
 		}
 	}
@@ -606,8 +606,8 @@ func (g *NoiseGenerator) buildBranches(buffer *chunkWriteBuffer, dim world.Dimen
 				block := g.blockForPart(variant.branchBlock, variant.name, "branch", map[string]any{
 					"span": step,
 				})
-				setBlock(buffer, dim, tx, ty, targetZ, block)
-				clearBlock(buffer, dim, tx, ty, targetZ+1)
+				g.setBlock(buffer, dim, tx, ty, targetZ, block)
+				g.clearBlock(buffer, dim, tx, ty, targetZ+1)
 				if variant.hasVeins && variant.veinBlock.Type != "" {
 					veinBlock := g.blockForPart(variant.veinBlock, variant.name, "vein", map[string]any{
 						"span": step,
@@ -615,13 +615,17 @@ func (g *NoiseGenerator) buildBranches(buffer *chunkWriteBuffer, dim world.Dimen
 					placeIfAir(buffer, dim, tx, ty, targetZ-1, veinBlock)
 				}
 			}
-			g.buildLeafCluster(buffer, dim, centerX, centerY, targetZ+1, variant)
+			g.buildLeafCluster(buffer, dim, placement, centerX, centerY, targetZ+1)
 		}
 	}
 }
 
-func (g *NoiseGenerator) buildLeafCluster(buffer *chunkWriteBuffer, dim world.Dimensions, centerX, centerY, centerZ int, variant *treeVariant) {
-	radius := 3
+func (g *NoiseGenerator) buildLeafCluster(buffer *chunkWriteBuffer, dim world.Dimensions, placement treePlacement, centerX, centerY, centerZ int) {
+	variant := placement.variant
+	jitter := g.treeJitter(placement)
+	// Clusters range from radius 2 to 3 so the leaves along a branch are
+	// uneven rather than a row of identical balls.
+	radius := 2 + int(hash3(centerX, centerY, centerZ^int(jitter))%2)
 	for dx := -radius; dx <= radius; dx++ {
 		for dy := -radius; dy <= radius; dy++ {
 			for dz := -1; dz <= 2; dz++ {
@@ -636,7 +640,7 @@ func (g *NoiseGenerator) buildLeafCluster(buffer *chunkWriteBuffer, dim world.Di
 				}
 				dist := math.Sqrt(float64(dx*dx + dy*dy))
 				heightBias := math.Abs(float64(dz)) * 0.8
-				if dist+heightBias > float64(radius)+0.2 {
+				if dist+heightBias > float64(radius)+0.2+jitterOffset(jitter, tx, ty, tz, 0.8) {
 					continue
 				}
 				leaf := g.blockForPart(variant.leavesBlock, variant.name, "leaf", nil)
@@ -648,8 +652,12 @@ func (g *NoiseGenerator) buildLeafCluster(buffer *chunkWriteBuffer, dim world.Di
 
 func (g *NoiseGenerator) buildCanopy(buffer *chunkWriteBuffer, dim world.Dimensions, placement treePlacement, canopyStart, canopyTop int) {
 	variant := placement.variant
+	jitter := g.treeJitter(placement)
+	// Each tree's canopy is up to a block wider or narrower than its
+	// variant's, and its edge is roughened per block.
+	radiusShift := int(jitter%3) - 1
 	for z := canopyStart; z <= canopyTop; z++ {
-		layerRadius := canopyRadiusForLevel(variant, z-canopyStart)
+		layerRadius := canopyRadiusForLevel(variant, z-canopyStart) + radiusShift
 		for dx := -layerRadius; dx <= layerRadius; dx++ {
 			for dy := -layerRadius; dy <= layerRadius; dy++ {
 				tx := placement.localX + dx
@@ -658,7 +666,7 @@ func (g *NoiseGenerator) buildCanopy(buffer *chunkWriteBuffer, dim world.Dimensi
 					continue
 				}
 				dist := math.Sqrt(float64(dx*dx + dy*dy))
-				if dist > float64(layerRadius)+0.45 {
+				if dist > float64(layerRadius)+0.45+jitterOffset(jitter, dx, dy, z-canopyStart, 0.9) {
 					continue
 				}
 				fade := float64(z-canopyStart) / float64(variant.canopyHeight)
@@ -690,7 +698,7 @@ func (g *NoiseGenerator) buildCanopy(buffer *chunkWriteBuffer, dim world.Dimensi
 				if dist > float64(accentRadius)+0.25 {
 					continue
 				}
-				if ((dx+dy)+(z-canopyStart))%3 != 0 {
+				if hash3(dx, dy, (z-canopyStart)^int(jitter))%3 != 0 {
 					continue
 				}
 				accent := g.blockForPart(variant.accentBlock, variant.name, "canopyVeil", map[string]any{
@@ -702,6 +710,20 @@ func (g *NoiseGenerator) buildCanopy(buffer *chunkWriteBuffer, dim world.Dimensi
 	}
 }
 
+// treeJitter returns the seed-derived value that varies a tree's shape from
+// others of its variant. It depends only on the tree's global position, so a
+// tree is the same every time its chunk is generated.
+func (g *NoiseGenerator) treeJitter(placement treePlacement) uint32 {
+	return hash3(placement.globalX, placement.globalY, int(g.seed^0x3c6ef3))
+}
+
+// jitterOffset returns a deterministic offset in [-spread/2, spread/2) for the
+// block at (x, y, z) of the tree with the given jitter.
+func jitterOffset(jitter uint32, x, y, z int, spread float64) float64 {
+	unit := float64(hash3(x, y, z^int(jitter>>3))&0xFFFF) / 0x10000
+	return (unit - 0.5) * spread
+}
+
 func (g *NoiseGenerator) decorateVeins(buffer *chunkWriteBuffer, dim world.Dimensions, placement treePlacement, baseLocalZ, trunkTop, canopyTop int) {
 	variant := placement.variant
 	if variant.veinBlock.Type == "" {
@@ -719,7 +741,7 @@ func (g *NoiseGenerator) decorateVeins(buffer *chunkWriteBuffer, dim world.Dimen
 			block := g.blockForPart(variant.veinBlock, variant.name, "trunkVein", map[string]any{
 				"level": level,
 			})
-			setBlock(buffer, dim, tx, ty, tz, block)
+			g.setBlock(buffer, dim, tx, ty, tz, block)
 		}
 	}
 
@@ -728,7 +750,7 @@ func (g *NoiseGenerator) decorateVeins(buffer *chunkWriteBuffer, dim world.Dimen
 		block := g.blockForPart(variant.veinBlock, variant.name, "canopyVein", map[string]any{
 			"layer": z - trunkTop,
 		})
-		setBlock(buffer, dim, placement.localX, placement.localY, z, block)
+		g.setBlock(buffer, dim, placement.localX, placement.localY, z, block)
 	}
 }
 
@@ -897,7 +919,22 @@ func withAppearance(block world.Block, material, color, texture string) world.Bl
 	return clone
 }
 
-func setBlock(buffer *chunkWriteBuffer, dim world.Dimensions, localX, localY, localZ int, block world.Block) {
+// setBlock writes a tree block, or clears a cell when block is empty. Mineral
+// blocks are left in place so ore under a tree survives; with
+// terrain.treesReplaceOre set the tree block takes the cell but keeps the
+// ore's resource yield.
+func (g *NoiseGenerator) setBlock(buffer *chunkWriteBuffer, dim world.Dimensions, localX, localY, localZ int, block world.Block) {
+	column, ok := buffer.column(localX, localY)
+	if ok && localZ >= 0 && localZ < len(column) && column[localZ].Type == world.BlockMineral {
+		if !g.cfg.TreesReplaceOre || blockIsEmpty(block) {
+			return
+		}
+		block.ResourceYield = column[localZ].ResourceYield
+	}
+	writeBlock(buffer, dim, localX, localY, localZ, block)
+}
+
+func writeBlock(buffer *chunkWriteBuffer, dim world.Dimensions, localX, localY, localZ int, block world.Block) {
 	if !inColumnBounds(dim, localX, localY) || localZ < 0 {
 		return
 	}
@@ -927,14 +964,14 @@ func placeIfAir(buffer *chunkWriteBuffer, dim world.Dimensions, localX, localY,
 	if ok && localZ < len(column) && !blockIsEmpty(column[localZ]) {
 		return
 	}
-	setBlock(buffer, dim, localX, localY, localZ, block)
+	writeBlock(buffer, dim, localX, localY, localZ, block)
 }
 
-func clearBlock(buffer *chunkWriteBuffer, dim world.Dimensions, localX, localY, localZ int) {
+func (g *NoiseGenerator) clearBlock(buffer *chunkWriteBuffer, dim world.Dimensions, localX, localY, localZ int) {
 	if !inColumnBounds(dim, localX, localY) || localZ < 0 {
 		return
 	}
-	setBlock(buffer, dim, localX, localY, localZ, world.Block{})
+	g.setBlock(buffer, dim, localX, localY, localZ, world.Block{})
 }
 
 func inColumnBounds(dim world.Dimensions, localX, localY int) bool {
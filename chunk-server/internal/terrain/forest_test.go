@@ -1,6 +1,7 @@
 package terrain
 
 import (
+	"reflect"
 	"testing"
 
 	"chunkserver/internal/config"
@@ -22,7 +23,7 @@ func TestBuildRootsStayBelowSurfaceOnSlopes(t *testing.T) {
 	}
 
 	chunk := world.NewChunk(world.ChunkCoord{X: 0, Y: 0}, bounds, dim)
-	buffer := newChunkWriteBuffer(chunk, dim, 1<<20)
+	buffer := newChunkWriteBuffer(chunk, dim, 1<<20, 0)
 
 	centerX, centerY := 20, 20
 	baseSurface := 30
@@ -92,3 +93,126 @@ func TestBuildRootsStayBelowSurfaceOnSlopes(t *testing.T) {
 		}
 	}
 }
+
+// grownCanopy builds the canopy and one branch tier of a tree centred in an
+// empty chunk and returns the leaf blocks placed, keyed by offset from the
+// trunk.
+func grownCanopy(t *testing.T, gen *NoiseGenerator, globalX, globalY int) map[[3]int]string {
+	t.Helper()
+	dim := world.Dimensions{Width: 48, Depth: 48, Height: 64}
+	bounds := world.Bounds{
+		Min: world.BlockCoord{X: 0, Y: 0, Z: 0},
+		Max: world.BlockCoord{X: dim.Width - 1, Y: dim.Depth - 1, Z: dim.Height - 1},
+	}
+	chunk := world.NewChunk(world.ChunkCoord{X: 0, Y: 0}, bounds, dim)
+	buffer := newChunkWriteBuffer(chunk, dim, 1<<20, 0)
+
+	variant := &gen.treeVariants[0]
+	placement := treePlacement{
+		localX:  24,
+		localY:  24,
+		globalX: globalX,
+		globalY: globalY,
+		variant: variant,
+	}
+	gen.buildBranches(buffer, dim, placement, 20)
+	gen.buildCanopy(buffer, dim, placement, 30, 30+variant.canopyHeight)
+
+	leaves := make(map[[3]int]string)
+	for x := 0; x < dim.Width; x++ {
+		for y := 0; y < dim.Depth; y++ {
+			column, ok := buffer.column(x, y)
+			if !ok {
+				continue
+			}
+			for z, block := range column {
+				part, _ := block.Metadata["part"].(string)
+				switch part {
+				case "leaf", "canopy", "canopyVeil":
+					leaves[[3]int{x - placement.localX, y - placement.localY, z}] = part
+				}
+			}
+		}
+	}
+	if len(leaves) == 0 {
+		t.Fatalf("tree at (%d,%d) grew no leaves", globalX, globalY)
+	}
+	return leaves
+}
+
+func TestCanopyVariesBetweenTreesButNotBetweenRuns(t *testing.T) {
+	gen := NewNoiseGenerator(config.TerrainConfig{Seed: 7}, config.EconomyConfig{})
+
+	first := grownCanopy(t, gen, 100, 200)
+	again := grownCanopy(t, gen, 100, 200)
+	if !reflect.DeepEqual(first, again) {
+		t.Fatalf("the same tree grew differently: %d and %d leaf blocks", len(first), len(again))
+	}
+
+	for _, at := range [][2]int{{140, 200}, {100, 260}, {-75, 31}} {
+		if other := grownCanopy(t, gen, at[0], at[1]); reflect.DeepEqual(first, other) {
+			t.Fatalf("trees at (100,200) and %v grew identical canopies", at)
+		}
+	}
+}
+
+func TestTreesLeaveOreInPlace(t *testing.T) {
+	ore := []world.BlockCoord{{X: 24, Y: 24, Z: 30}, {X: 25, Y: 25, Z: 30}, {X: 28, Y: 24, Z: 29}, {X: 24, Y: 26, Z: 29}}
+	growOver := func(cfg config.TerrainConfig) *chunkWriteBuffer {
+		gen := NewNoiseGenerator(cfg, config.EconomyConfig{})
+		dim := world.Dimensions{Width: 48, Depth: 48, Height: 80}
+		bounds := world.Bounds{
+			Min: world.BlockCoord{X: 0, Y: 0, Z: 0},
+			Max: world.BlockCoord{X: dim.Width - 1, Y: dim.Depth - 1, Z: dim.Height - 1},
+		}
+		chunk := world.NewChunk(world.ChunkCoord{X: 0, Y: 0}, bounds, dim)
+		buffer := newChunkWriteBuffer(chunk, dim, 1<<20, 0)
+		for x := 0; x < dim.Width; x++ {
+			for y := 0; y < dim.Depth; y++ {
+				column := make([]world.Block, 31)
+				for z := range column {
+					column[z] = world.Block{Type: world.BlockSolid}
+				}
+				buffer.setColumn(x, y, column)
+			}
+		}
+		for _, cell := range ore {
+			column, _ := buffer.column(cell.X, cell.Y)
+			column[cell.Z] = world.Block{Type: world.BlockMineral, ResourceYield: map[string]float64{"iron": 2}}
+			buffer.setColumn(cell.X, cell.Y, column)
+		}
+		gen.buildTree(buffer, bounds, dim, treePlacement{
+			localX:        24,
+			localY:        24,
+			surfaceLocalZ: 30,
+			globalX:       24,
+			globalY:       24,
+			variant:       &gen.treeVariants[0],
+		})
+		return buffer
+	}
+
+	buffer := growOver(config.TerrainConfig{})
+	for _, cell := range ore {
+		column, _ := buffer.column(cell.X, cell.Y)
+		if block := column[cell.Z]; block.Type != world.BlockMineral || block.ResourceYield["iron"] != 2 {
+			t.Fatalf("ore at %v replaced by %+v", cell, block)
+		}
+	}
+
+	buffer = growOver(config.TerrainConfig{TreesReplaceOre: true})
+	replaced := 0
+	for _, cell := range ore {
+		column, _ := buffer.column(cell.X, cell.Y)
+		block := column[cell.Z]
+		if block.ResourceYield["iron"] != 2 {
+			t.Fatalf("block at %v lost the ore's yield: %+v", cell, block)
+		}
+		if part, _ := block.Metadata["part"].(string); part != "" {
+			replaced++
+		}
+	}
+	if replaced == 0 {
+		t.Fatal("treesReplaceOre set but no tree block took an ore cell")
+	}
+}
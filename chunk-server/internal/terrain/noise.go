@@ -3,15 +3,16 @@ package terrain
 import (
 	"context"
 	"fmt"
-	"log"
 	"math"
 	"math/rand"
 	"runtime"
+	"sort"
 	"sync"
 	"time"
 	"unsafe"
 
 	"chunkserver/internal/config"
+	"chunkserver/internal/logging"
 	"chunkserver/internal/world"
 )
 
@@ -26,7 +27,16 @@ type NoiseGenerator struct {
 	subsoilPrototype        world.Block
 	stonePrototype          world.Block
 	deepstonePrototype      world.Block
+	blockDefs               map[string]config.BlockDefinition
+	prototypes              map[string]world.Block
 	treeVariants            []treeVariant
+	logger                  *logging.Logger
+	verboseProgress         bool
+
+	// Soil blocks share their layer's metadata map; passes that add to a
+	// block's metadata copy it first.
+	subsoilMetadata map[string]any
+	topsoilMetadata map[string]any
 }
 
 func NewNoiseGenerator(cfg config.TerrainConfig, economy config.EconomyConfig) *NoiseGenerator {
@@ -34,6 +44,7 @@ func NewNoiseGenerator(cfg config.TerrainConfig, economy config.EconomyConfig) *
 		cfg:     cfg,
 		economy: economy,
 		seed:    cfg.Seed,
+		logger:  logging.Default(),
 		randPool: sync.Pool{
 			New: func() any {
 				// Seed with time for uniqueness but override deterministically per use.
@@ -46,6 +57,53 @@ func NewNoiseGenerator(cfg config.TerrainConfig, economy config.EconomyConfig) *
 	return generator
 }
 
+// Block definition IDs that style the terrain layers. Definitions that are not
+// configured leave the built-in appearance of their layer untouched.
+const (
+	surfaceBlockID   = "grass"
+	soilBlockID      = "dirt"
+	stoneBlockID     = "stone"
+	deepstoneBlockID = "deepstone"
+)
+
+// SetBlockDefinitions applies the configured block definitions to the terrain
+// layers and mineral veins and makes every definition available through
+// BlockPrototype. Call it before the generator is used.
+func (g *NoiseGenerator) SetBlockDefinitions(defs []config.BlockDefinition) {
+	g.blockDefs = make(map[string]config.BlockDefinition, len(defs))
+	for _, def := range defs {
+		g.blockDefs[def.ID] = def
+	}
+	g.initPrototypes()
+}
+
+// SetLogger sets where the generator reports progress. Per-chunk progress is
+// logged at debug level, and only for chunks slower than
+// terrain.progressLogThreshold.
+func (g *NoiseGenerator) SetLogger(logger *logging.Logger) {
+	g.logger = logger
+}
+
+// SetVerboseProgress logs every chunk's progress at each 10% step, however
+// fast the chunk generates.
+func (g *NoiseGenerator) SetVerboseProgress(verbose bool) {
+	g.verboseProgress = verbose
+}
+
+func (g *NoiseGenerator) progressThreshold() time.Duration {
+	if threshold := g.cfg.ProgressLogThreshold.Duration(); threshold > 0 {
+		return threshold
+	}
+	return defaultProgressLogThreshold
+}
+
+// BlockPrototype returns the block the generator builds for the block
+// definition id, and whether such a definition is configured.
+func (g *NoiseGenerator) BlockPrototype(id string) (world.Block, bool) {
+	block, ok := g.prototypes[id]
+	return block, ok
+}
+
 func (g *NoiseGenerator) initPrototypes() {
 	topsoilSurface := world.Block{
 		Type:            world.BlockSolid,
@@ -55,7 +113,7 @@ func (g *NoiseGenerator) initPrototypes() {
 		Weight:          6,
 	}
 	world.ApplyAppearance(&topsoilSurface, world.MaterialGrass)
-	g.topsoilSurfacePrototype = topsoilSurface
+	g.topsoilSurfacePrototype = g.withDefinition(topsoilSurface, surfaceBlockID)
 
 	topsoil := world.Block{
 		Type:            world.BlockSolid,
@@ -65,7 +123,7 @@ func (g *NoiseGenerator) initPrototypes() {
 		Weight:          6,
 	}
 	world.ApplyAppearance(&topsoil, world.MaterialDirt)
-	g.topsoilPrototype = topsoil
+	g.topsoilPrototype = g.withDefinition(topsoil, soilBlockID)
 
 	subsoil := world.Block{
 		Type:            world.BlockSolid,
@@ -75,25 +133,61 @@ func (g *NoiseGenerator) initPrototypes() {
 		Weight:          9,
 	}
 	world.ApplyAppearance(&subsoil, world.MaterialDirt)
-	g.subsoilPrototype = subsoil
+	g.subsoilPrototype = g.withDefinition(subsoil, soilBlockID)
+	g.subsoilMetadata = map[string]any{"layer": "subsoil"}
+	g.topsoilMetadata = map[string]any{"layer": "topsoil"}
 
-	g.stonePrototype = world.Block{
+	g.stonePrototype = g.withDefinition(world.Block{
 		Type:            world.BlockSolid,
 		HitPoints:       190,
 		MaxHitPoints:    190,
 		ConnectingForce: 150,
 		Weight:          14,
-	}
+	}, stoneBlockID)
 
-	g.deepstonePrototype = world.Block{
+	g.deepstonePrototype = g.withDefinition(world.Block{
 		Type:            world.BlockSolid,
 		HitPoints:       240,
 		MaxHitPoints:    240,
 		ConnectingForce: 210,
 		Weight:          18,
+	}, deepstoneBlockID)
+
+	// Definitions without a layer of their own take stone's stats.
+	g.prototypes = make(map[string]world.Block, len(g.blockDefs))
+	for id := range g.blockDefs {
+		switch id {
+		case surfaceBlockID:
+			g.prototypes[id] = g.topsoilSurfacePrototype
+		case soilBlockID:
+			g.prototypes[id] = g.topsoilPrototype
+		case deepstoneBlockID:
+			g.prototypes[id] = g.deepstonePrototype
+		default:
+			base := g.stonePrototype
+			world.ApplyAppearance(&base, id)
+			g.prototypes[id] = g.withDefinition(base, id)
+		}
 	}
 }
 
+// withDefinition styles block with the configured definition id, if any. Only
+// the fields the definition sets are applied.
+func (g *NoiseGenerator) withDefinition(block world.Block, id string) world.Block {
+	def, ok := g.blockDefs[id]
+	if !ok {
+		return block
+	}
+	block.Material = def.ID
+	if def.Color != "" {
+		block.Color = def.Color
+	}
+	if def.LightEmission > 0 {
+		block.LightEmission = def.LightEmission
+	}
+	return block
+}
+
 func (g *NoiseGenerator) surfaceLevel(bounds world.Bounds, dim world.Dimensions) int {
 	ratio := g.cfg.SurfaceRatio
 	if ratio <= 0 || ratio >= 1 {
@@ -133,25 +227,31 @@ func (g *NoiseGenerator) undergroundLimit(bounds world.Bounds, dim world.Dimensi
 }
 
 func (g *NoiseGenerator) Generate(ctx context.Context, coord world.ChunkCoord, bounds world.Bounds, dim world.Dimensions) (*world.Chunk, error) {
-	chunk := world.NewChunk(coord, bounds, dim)
+	if dim.Width <= 0 || dim.Depth <= 0 || dim.Height <= 0 {
+		return nil, fmt.Errorf("generate chunk %v: dimensions must be positive, got width %d depth %d height %d", coord, dim.Width, dim.Depth, dim.Height)
+	}
+	profiler := profilerFromContext(ctx)
+	chunk := world.NewChunkWithStorage(coord, bounds, dim, world.StorageProviderFromContext(ctx))
 
 	if chunk.HasStoredBlocks() {
-		log.Printf("chunk %v generation progress: 100%% (cached)", coord)
+		if g.verboseProgress {
+			g.logger.Debugf("chunk %v generation progress: 100%% (cached)", coord)
+		}
 		return chunk, nil
 	}
 
-	totalColumns := dim.Width * dim.Depth
-	if totalColumns <= 0 {
-		log.Printf("chunk %v generation progress: 100%%", coord)
-		return chunk, nil
+	if err := chunk.BeginGeneration(); err != nil {
+		return nil, fmt.Errorf("generate chunk %v: %w", coord, err)
 	}
 
-	log.Printf("chunk %v generation progress: 0%%", coord)
+	totalColumns := dim.Width * dim.Depth
+
+	progress := newProgressLog(g.logger, coord, g.progressThreshold(), g.verboseProgress)
 
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	buffer := newChunkWriteBuffer(chunk, dim, 1<<28)
+	buffer := newChunkWriteBuffer(chunk, dim, g.cfg.WriteBufferBytes, g.cfg.WriteBufferColumns)
 
 	surfaceBase := g.surfaceLevel(bounds, dim)
 	amplitude := g.surfaceAmplitude(dim)
@@ -176,6 +276,7 @@ func (g *NoiseGenerator) Generate(ctx context.Context, coord world.ChunkCoord, b
 
 	tasks := make(chan columnTask, workers)
 	results := make(chan columnResult, workers)
+	started := time.Now()
 
 	var wg sync.WaitGroup
 	for i := 0; i < workers; i++ {
@@ -234,55 +335,80 @@ func (g *NoiseGenerator) Generate(ctx context.Context, coord world.ChunkCoord, b
 	}()
 
 	generatedColumns := 0
-	nextLogPercent := 10
-	loggedComplete := false
 
+	// Workers finish out of order, so results are held back and stored in
+	// task order: the buffer then flushes at the same columns on every run and
+	// the later passes see the same columns.
+	waiting := make(map[int]columnResult, workers)
 	for result := range results {
 		if result.err != nil {
 			cancel()
 			return nil, result.err
 		}
+		waiting[result.localX*dim.Depth+result.localY] = result
 
-		if err := buffer.Store(result.localX, result.localY, result.column); err != nil {
-			cancel()
-			return nil, err
-		}
-
-		generatedColumns++
-		progress := generatedColumns * 100 / totalColumns
-		if progress >= nextLogPercent {
-			if progress > 100 {
-				progress = 100
+		for {
+			ready, ok := waiting[generatedColumns]
+			if !ok {
+				break
 			}
-			log.Printf("chunk %v generation progress: %d%%", coord, progress)
-			if progress >= 100 {
-				loggedComplete = true
-				nextLogPercent = 110
-			} else {
-				nextLogPercent = ((progress / 10) + 1) * 10
+			delete(waiting, generatedColumns)
+
+			if err := buffer.Store(ready.localX, ready.localY, ready.column); err != nil {
+				cancel()
+				return nil, err
 			}
+
+			generatedColumns++
+			progress.report(generatedColumns * 100 / totalColumns)
 		}
 	}
 
+	// A worker's cancellation error can be dropped when the results channel
+	// is full, so the pass may end early without reporting it.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	recordPass(profiler, PassColumns, started)
+
+	started = time.Now()
 	if err := g.growForests(buffer, bounds, dim); err != nil {
 		return nil, err
 	}
+	recordPass(profiler, PassForests, started)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
+	started = time.Now()
 	if err := g.seedMineralVeins(buffer, bounds, dim); err != nil {
 		return nil, err
 	}
+	recordPass(profiler, PassMinerals, started)
 
+	started = time.Now()
 	if err := buffer.Flush(); err != nil {
 		return nil, err
 	}
-
-	if !loggedComplete {
-		log.Printf("chunk %v generation progress: 100%%", coord)
+	if err := chunk.FinishGeneration(); err != nil {
+		return nil, fmt.Errorf("generate chunk %v: %w", coord, err)
+	}
+	recordPass(profiler, PassFlush, started)
+	if profiler != nil {
+		profiler.RecordChunk()
 	}
 
+	progress.finish()
+
 	return chunk, nil
 }
 
+func recordPass(profiler GenerationProfiler, pass GenerationPass, started time.Time) {
+	if profiler != nil {
+		profiler.RecordPass(pass, time.Since(started))
+	}
+}
+
 func (g *NoiseGenerator) populateColumn(bounds world.Bounds, dim world.Dimensions, localX, localY int, surfaceHeight int, noise float64, undergroundCap int) []world.Block {
 	maxLocalZ := surfaceHeight - bounds.Min.Z
 	if maxLocalZ >= dim.Height {
@@ -327,7 +453,7 @@ func (g *NoiseGenerator) populateColumn(bounds world.Bounds, dim world.Dimension
 		}
 		for idx := subsoilStart; idx <= subsoilEnd; idx++ {
 			block := g.subsoilPrototype
-			block.Metadata = map[string]any{"layer": "subsoil"}
+			block.Metadata = g.subsoilMetadata
 			column[idx] = block
 		}
 	}
@@ -340,7 +466,7 @@ func (g *NoiseGenerator) populateColumn(bounds world.Bounds, dim world.Dimension
 		} else {
 			block = g.topsoilPrototype
 		}
-		block.Metadata = map[string]any{"layer": "topsoil"}
+		block.Metadata = g.topsoilMetadata
 		column[idx] = block
 	}
 
@@ -367,7 +493,14 @@ func (g *NoiseGenerator) seedMineralVeins(buffer *chunkWriteBuffer, bounds world
 		return nil
 	}
 
-	for mineral, density := range g.economy.ResourceSpawnDensity {
+	minerals := make([]string, 0, len(g.economy.ResourceSpawnDensity))
+	for mineral := range g.economy.ResourceSpawnDensity {
+		minerals = append(minerals, mineral)
+	}
+	sort.Strings(minerals)
+
+	for _, mineral := range minerals {
+		density := g.economy.ResourceSpawnDensity[mineral]
 		if density <= 0 {
 			continue
 		}
@@ -388,11 +521,8 @@ func (g *NoiseGenerator) seedMineralVeins(buffer *chunkWriteBuffer, bounds world
 				}
 
 				rng := g.random(hashVal)
-				placements := veinSizeForDensity(density, rng)
-				if placements > len(column) {
-					placements = len(column)
-				}
-				g.scatterMinerals(buffer, column, localX, localY, mineral, placements, rng)
+				placements := g.veinSize(mineral, density, rng)
+				g.growVein(buffer, dim, localX, localY, mineral, placements, rng)
 				g.releaseRandom(rng)
 			}
 		}
@@ -402,30 +532,75 @@ func (g *NoiseGenerator) seedMineralVeins(buffer *chunkWriteBuffer, bounds world
 	return nil
 }
 
-func (g *NoiseGenerator) scatterMinerals(buffer *chunkWriteBuffer, column []world.Block, localX, localY int, mineral string, placements int, rng *rand.Rand) {
+// veinSteps are the face-adjacent moves a vein grows along.
+var veinSteps = [...]world.BlockCoord{
+	{X: 1}, {X: -1}, {Y: 1}, {Y: -1}, {Z: 1}, {Z: -1},
+}
+
+// growVein places a connected ore body of up to placements cells seeded in the
+// column at localX, localY. Each new cell is grown from a random cell already
+// in the vein onto a face-adjacent block that can hold minerals, so the vein
+// spreads across neighboring columns and depths while staying one connected
+// cluster. Growth is clipped to the columns held by the buffer.
+func (g *NoiseGenerator) growVein(buffer *chunkWriteBuffer, dim world.Dimensions, localX, localY int, mineral string, placements int, rng *rand.Rand) []world.BlockCoord {
 	if placements <= 0 {
-		return
+		return nil
+	}
+	column, ok := buffer.column(localX, localY)
+	if !ok || len(column) == 0 {
+		return nil
 	}
-	used := make(map[int]struct{}, placements)
-	placed := 0
-	attempts := 0
-	maxAttempts := placements * 6
 
-	for placed < placements && attempts < maxAttempts {
-		attempts++
-		target := rng.Intn(len(column))
-		if _, ok := used[target]; ok {
-			continue
+	maxAttempts := placements * 6
+	vein := make([]world.BlockCoord, 0, placements)
+	used := make(map[world.BlockCoord]struct{}, placements)
+	place := func(cell world.BlockCoord) bool {
+		if _, ok := used[cell]; ok {
+			return false
 		}
-		if !g.applyMineralToBlock(column, target, mineral) {
-			continue
+		if !inColumnBounds(dim, cell.X, cell.Y) {
+			return false
+		}
+		column, ok := buffer.column(cell.X, cell.Y)
+		if !ok || !g.applyMineralToBlock(column, cell.Z, mineral) {
+			return false
 		}
-		used[target] = struct{}{}
-		placed++
+		buffer.setColumn(cell.X, cell.Y, column)
+		used[cell] = struct{}{}
+		vein = append(vein, cell)
+		return true
 	}
 
-	if placed > 0 {
-		buffer.setColumn(localX, localY, column)
+	for attempts := 0; len(vein) == 0 && attempts < maxAttempts; attempts++ {
+		place(world.BlockCoord{X: localX, Y: localY, Z: rng.Intn(len(column))})
+	}
+	for attempts := 0; len(vein) > 0 && len(vein) < placements && attempts < maxAttempts; attempts++ {
+		from := vein[rng.Intn(len(vein))]
+		step := veinSteps[rng.Intn(len(veinSteps))]
+		place(world.BlockCoord{X: from.X + step.X, Y: from.Y + step.Y, Z: from.Z + step.Z})
+	}
+	return vein
+}
+
+// veinSize picks how many blocks of mineral one deposit places. A block
+// definition's spawn config takes precedence: solo minerals place a single
+// block and veins draw their size from the configured range. Minerals without
+// a definition fall back to a size derived from their spawn density.
+func (g *NoiseGenerator) veinSize(mineral string, density float64, rng *rand.Rand) int {
+	def, ok := g.blockDefs[mineral]
+	if !ok {
+		return veinSizeForDensity(density, rng)
+	}
+	switch def.Spawn.Type {
+	case "solo":
+		return 1
+	case "vein":
+		if def.Spawn.VeinSizeMax <= def.Spawn.VeinSizeMin {
+			return def.Spawn.VeinSizeMin
+		}
+		return def.Spawn.VeinSizeMin + rng.Intn(def.Spawn.VeinSizeMax-def.Spawn.VeinSizeMin+1)
+	default:
+		return veinSizeForDensity(density, rng)
 	}
 }
 
@@ -449,7 +624,7 @@ func (g *NoiseGenerator) applyMineralToBlock(column []world.Block, localZ int, m
 		return false
 	}
 	block := column[localZ]
-	if block.Type == world.BlockAir {
+	if block.Type.Properties().Passable {
 		return false
 	}
 	if layer, ok := block.Metadata["layer"].(string); ok && layer == "topsoil" {
@@ -470,14 +645,22 @@ func (g *NoiseGenerator) applyMineralToBlock(column []world.Block, localZ int, m
 		block.HitPoints = block.MaxHitPoints
 	}
 	block.Weight += 3
-	if block.Metadata == nil {
-		block.Metadata = make(map[string]any)
-	}
+	block.Metadata = extendMetadata(block.Metadata, 1)
 	block.Metadata["veinResource"] = mineral
-	column[localZ] = block
+	column[localZ] = g.withDefinition(block, mineral)
 	return true
 }
 
+// extendMetadata returns a copy of metadata with room for extra more keys, so
+// a block can add to metadata it may share with other blocks.
+func extendMetadata(metadata map[string]any, extra int) map[string]any {
+	extended := make(map[string]any, len(metadata)+extra)
+	for key, value := range metadata {
+		extended[key] = value
+	}
+	return extended
+}
+
 func (g *NoiseGenerator) random(seed uint32) *rand.Rand {
 	r := g.randPool.Get().(*rand.Rand)
 	r.Seed(int64(seed)<<1 | 1)
@@ -491,23 +674,39 @@ func (g *NoiseGenerator) releaseRandom(r *rand.Rand) {
 	g.randPool.Put(r)
 }
 
+// defaultWriteBufferBytes is the generated terrain held before a flush when
+// the terrain config does not set writeBufferBytes.
+const defaultWriteBufferBytes = 1 << 28
+
 type chunkWriteBuffer struct {
 	chunk      *world.Chunk
 	dim        world.Dimensions
 	threshold  int64
+	maxColumns int
 	columns    map[int][]world.Block
 	usageBytes int64
+	// usage holds each buffered column's share of usageBytes; stale marks
+	// the columns changed through setColumn since usage was last brought up
+	// to date.
+	usage map[int]int64
+	stale map[int]struct{}
 }
 
-func newChunkWriteBuffer(chunk *world.Chunk, dim world.Dimensions, threshold int64) *chunkWriteBuffer {
+// newChunkWriteBuffer returns a buffer that flushes to chunk once it holds
+// threshold bytes or maxColumns columns. A zero maxColumns leaves the column
+// count unbounded.
+func newChunkWriteBuffer(chunk *world.Chunk, dim world.Dimensions, threshold int64, maxColumns int) *chunkWriteBuffer {
 	if threshold <= 0 {
-		threshold = 1 << 30
+		threshold = defaultWriteBufferBytes
 	}
 	return &chunkWriteBuffer{
-		chunk:     chunk,
-		dim:       dim,
-		threshold: threshold,
-		columns:   make(map[int][]world.Block),
+		chunk:      chunk,
+		dim:        dim,
+		threshold:  threshold,
+		maxColumns: maxColumns,
+		columns:    make(map[int][]world.Block),
+		usage:      make(map[int]int64),
+		stale:      make(map[int]struct{}),
 	}
 }
 
@@ -517,8 +716,8 @@ func (b *chunkWriteBuffer) Store(localX, localY int, column []world.Block) error
 	}
 	idx := b.index(localX, localY)
 	b.columns[idx] = column
-	b.usageBytes += columnMemory(column)
-	if b.usageBytes >= b.threshold {
+	b.account(idx)
+	if b.usageBytes >= b.threshold || (b.maxColumns > 0 && len(b.columns) >= b.maxColumns) {
 		return b.Flush()
 	}
 	return nil
@@ -529,7 +728,15 @@ func (b *chunkWriteBuffer) Flush() error {
 		b.usageBytes = 0
 		return nil
 	}
-	for idx, column := range b.columns {
+	// Columns are written in index order so persisted chunk files come out the
+	// same on every run.
+	indices := make([]int, 0, len(b.columns))
+	for idx := range b.columns {
+		indices = append(indices, idx)
+	}
+	sort.Ints(indices)
+	for _, idx := range indices {
+		column := b.columns[idx]
 		localX := idx % b.dim.Width
 		localY := idx / b.dim.Width
 		if ok := b.chunk.SetColumnBlocks(localX, localY, column); !ok {
@@ -537,6 +744,8 @@ func (b *chunkWriteBuffer) Flush() error {
 		}
 	}
 	b.columns = make(map[int][]world.Block)
+	b.usage = make(map[int]int64)
+	b.stale = make(map[int]struct{})
 	b.usageBytes = 0
 	return nil
 }
@@ -557,26 +766,99 @@ func (b *chunkWriteBuffer) setColumn(localX, localY int, column []world.Block) {
 	if b == nil {
 		return
 	}
-	b.columns[b.index(localX, localY)] = column
+	idx := b.index(localX, localY)
+	b.columns[idx] = column
+	b.stale[idx] = struct{}{}
 }
 
+// recalculateUsage brings usageBytes up to date with the columns changed
+// through setColumn, leaving the rest as they were last counted.
 func (b *chunkWriteBuffer) recalculateUsage() {
 	if b == nil {
 		return
 	}
-	var total int64
-	for _, column := range b.columns {
-		total += columnMemory(column)
+	for idx := range b.stale {
+		b.account(idx)
 	}
-	b.usageBytes = total
 }
 
+// account recounts the memory held by the column at idx.
+func (b *chunkWriteBuffer) account(idx int) {
+	memory := columnMemory(b.columns[idx])
+	b.usageBytes += memory - b.usage[idx]
+	b.usage[idx] = memory
+	delete(b.stale, idx)
+}
+
+// Rough heap cost of a map entry beyond its key and value, covering bucket
+// and hash overhead.
+const mapEntryOverhead = 48
+
+// columnMemory estimates the heap held by column: the blocks themselves plus
+// the keys and values of their metadata and resource yield maps. Runs of
+// blocks built from one prototype share its maps, so a map is counted again
+// only when it differs from the previous block's.
 func columnMemory(column []world.Block) int64 {
 	if len(column) == 0 {
 		return 0
 	}
 	blockSize := int64(unsafe.Sizeof(world.Block{}))
-	return int64(len(column)) * blockSize
+	total := int64(len(column)) * blockSize
+	var lastMetadata, lastYield unsafe.Pointer
+	for i := range column {
+		block := &column[i]
+		if metadata := mapPointer(block.Metadata); metadata != lastMetadata {
+			lastMetadata = metadata
+			for key, value := range block.Metadata {
+				total += mapEntryOverhead + int64(len(key)) + metadataValueMemory(value)
+			}
+		}
+		if yield := mapPointer(block.ResourceYield); yield != lastYield {
+			lastYield = yield
+			for key := range block.ResourceYield {
+				total += mapEntryOverhead + int64(len(key)) + 8
+			}
+		}
+	}
+	return total
+}
+
+// mapPointer identifies the map m refers to, so blocks sharing a map can be
+// told apart from blocks holding equal copies.
+func mapPointer[M ~map[K]V, K comparable, V any](m M) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&m))
+}
+
+// metadataValueMemory estimates the heap held by a metadata value, including
+// the interface that boxes it.
+func metadataValueMemory(value any) int64 {
+	const interfaceSize = 16
+	switch v := value.(type) {
+	case string:
+		return interfaceSize + int64(len(v))
+	case []byte:
+		return interfaceSize + int64(len(v))
+	case map[string]any:
+		total := int64(interfaceSize)
+		for key, inner := range v {
+			total += mapEntryOverhead + int64(len(key)) + metadataValueMemory(inner)
+		}
+		return total
+	case map[string]float64:
+		total := int64(interfaceSize)
+		for key := range v {
+			total += mapEntryOverhead + int64(len(key)) + 8
+		}
+		return total
+	case []any:
+		total := int64(interfaceSize)
+		for _, inner := range v {
+			total += metadataValueMemory(inner)
+		}
+		return total
+	default:
+		return interfaceSize + 8
+	}
 }
 
 func fillBlockRange(column []world.Block, start, end int, value world.Block) {
@@ -638,7 +920,7 @@ func (g *NoiseGenerator) applyColumnInstability(column []world.Block, maxLocalZ
 			continue
 		}
 		block := &column[idx]
-		if block.Type == world.BlockAir {
+		if block.Type.Properties().Passable {
 			continue
 		}
 		selected[offset] = struct{}{}
@@ -648,9 +930,7 @@ func (g *NoiseGenerator) applyColumnInstability(column []world.Block, maxLocalZ
 		block.HitPoints *= 0.8
 		block.MaxHitPoints = block.HitPoints
 		block.Weight *= 0.92
-		if block.Metadata == nil {
-			block.Metadata = make(map[string]any, 2)
-		}
+		block.Metadata = extendMetadata(block.Metadata, 2)
 		penalty := threshold * (0.5 + 0.5*(float64(rng.next()&0xFFFF)/0xFFFF))
 		block.Metadata["unstable"] = true
 		block.Metadata["stabilityPenalty"] = penalty
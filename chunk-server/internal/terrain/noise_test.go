@@ -3,62 +3,72 @@ package terrain
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"log"
+	"log/slog"
 	"math/rand"
 	"reflect"
 	"strings"
 	"sync"
 	"testing"
 	"time"
+	"unsafe"
 
 	"chunkserver/internal/config"
+	"chunkserver/internal/logging"
 	"chunkserver/internal/world"
 )
 
 func TestNoiseGeneratorGenerateLogsProgress(t *testing.T) {
-	var buf bytes.Buffer
-	originalFlags := log.Flags()
-	originalPrefix := log.Prefix()
-	originalWriter := log.Writer()
-	log.SetFlags(0)
-	log.SetPrefix("")
-	log.SetOutput(&buf)
-	defer func() {
-		log.SetOutput(originalWriter)
-		log.SetPrefix(originalPrefix)
-		log.SetFlags(originalFlags)
-	}()
-
-	gen := NewNoiseGenerator(config.TerrainConfig{
-		Seed:        1,
-		Frequency:   0.5,
-		Amplitude:   1,
-		Octaves:     1,
-		Persistence: 0.5,
-		Lacunarity:  2,
-	}, config.EconomyConfig{ResourceSpawnDensity: map[string]float64{}})
-
-	dim := world.Dimensions{Width: 2, Depth: 2, Height: 4}
-	bounds := world.Bounds{
-		Min: world.BlockCoord{X: 0, Y: 0, Z: 0},
-		Max: world.BlockCoord{X: dim.Width - 1, Y: dim.Depth - 1, Z: dim.Height - 1},
-	}
+	generate := func(level slog.Level, verbose bool) string {
+		t.Helper()
+		var buf bytes.Buffer
+		gen := NewNoiseGenerator(config.TerrainConfig{
+			Seed:        1,
+			Frequency:   0.5,
+			Amplitude:   1,
+			Octaves:     1,
+			Persistence: 0.5,
+			Lacunarity:  2,
+		}, config.EconomyConfig{ResourceSpawnDensity: map[string]float64{}})
+		gen.SetLogger(logging.New(&buf, level))
+		gen.SetVerboseProgress(verbose)
+
+		dim := world.Dimensions{Width: 2, Depth: 2, Height: 4}
+		bounds := world.Bounds{
+			Min: world.BlockCoord{X: 0, Y: 0, Z: 0},
+			Max: world.BlockCoord{X: dim.Width - 1, Y: dim.Depth - 1, Z: dim.Height - 1},
+		}
 
-	chunk, err := gen.Generate(context.Background(), world.ChunkCoord{X: 0, Y: 0}, bounds, dim)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-	if chunk == nil {
-		t.Fatal("expected chunk to be generated")
+		chunk, err := gen.Generate(context.Background(), world.ChunkCoord{X: 0, Y: 0}, bounds, dim)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if chunk == nil {
+			t.Fatal("expected chunk to be generated")
+		}
+		return buf.String()
 	}
 
-	logs := buf.String()
+	logs := generate(slog.LevelDebug, true)
 	expected := []string{"0%", "25%", "50%", "75%", "100%"}
 	for _, marker := range expected {
 		if !strings.Contains(logs, marker) {
-			t.Fatalf("expected logs to contain progress %s, got: %s", marker, logs)
+			t.Fatalf("expected debug logs to contain progress %s, got: %s", marker, logs)
 		}
 	}
+
+	// Progress is debug output; the default info level keeps it quiet.
+	if logs := generate(slog.LevelInfo, true); strings.Contains(logs, "generation progress") {
+		t.Fatalf("expected no progress lines at info level, got: %s", logs)
+	}
+
+	// A small chunk finishes well within the default threshold, so even debug
+	// output stays quiet unless progress is forced.
+	if logs := generate(slog.LevelDebug, false); strings.Contains(logs, "generation progress") {
+		t.Fatalf("expected no progress lines for a fast chunk, got: %s", logs)
+	}
 }
 
 func TestNoiseGeneratorWorkerCountRespectsConfig(t *testing.T) {
@@ -251,12 +261,8 @@ func TestNoiseGeneratorMineralVeinsDistributeAcrossColumns(t *testing.T) {
 }
 
 func TestNoiseGeneratorReusesPersistedChunk(t *testing.T) {
-	original := world.CurrentStorageProvider()
 	storage := newStubBlockStorage()
-	world.SetStorageProvider(&stubStorageProvider{storage: storage})
-	t.Cleanup(func() {
-		world.SetStorageProvider(original)
-	})
+	ctx := world.ContextWithStorageProvider(context.Background(), &stubStorageProvider{storage: storage})
 
 	gen := NewNoiseGenerator(config.TerrainConfig{}, config.EconomyConfig{})
 	dim := world.Dimensions{Width: 1, Depth: 1, Height: 2}
@@ -265,7 +271,7 @@ func TestNoiseGeneratorReusesPersistedChunk(t *testing.T) {
 		Max: world.BlockCoord{X: dim.Width - 1, Y: dim.Depth - 1, Z: dim.Height - 1},
 	}
 
-	chunk, err := gen.Generate(context.Background(), world.ChunkCoord{X: 9, Y: 4}, bounds, dim)
+	chunk, err := gen.Generate(ctx, world.ChunkCoord{X: 9, Y: 4}, bounds, dim)
 	if err != nil {
 		t.Fatalf("generate chunk: %v", err)
 	}
@@ -369,15 +375,6 @@ func TestNoiseGeneratorDeterministicAcrossRandomLocations(t *testing.T) {
 	dim := world.Dimensions{Width: 2, Depth: 2, Height: 16}
 	ctx := context.Background()
 
-	snapshot := func(chunk *world.Chunk) map[world.BlockCoord]world.Block {
-		blocks := make(map[world.BlockCoord]world.Block)
-		chunk.ForEachBlock(func(coord world.BlockCoord, block world.Block) bool {
-			blocks[coord] = block
-			return true
-		})
-		return blocks
-	}
-
 	r := rand.New(rand.NewSource(1337))
 	const locations = 1000
 	for i := 0; i < locations; i++ {
@@ -407,20 +404,515 @@ func TestNoiseGeneratorDeterministicAcrossRandomLocations(t *testing.T) {
 			t.Fatalf("iteration %d: generator B error: %v", i, err)
 		}
 
-		blocksA := snapshot(chunkA)
-		blocksB := snapshot(chunkB)
+		if diff := world.ChunkDiff(chunkA, chunkB); len(diff) != 0 {
+			t.Fatalf("iteration %d: chunk %v differs at %v between generations", i, chunkCoord, diff[0].Coord)
+		}
+	}
+}
+
+func TestGenerateReportsPassTimingsToProfiler(t *testing.T) {
+	originalWriter := log.Writer()
+	log.SetOutput(&bytes.Buffer{})
+	defer log.SetOutput(originalWriter)
+
+	cfg := config.Default()
+	generator := NewNoiseGenerator(cfg.Terrain, cfg.Economy)
+	dim := world.Dimensions{Width: 8, Depth: 8, Height: 32}
+	bounds := world.Bounds{
+		Min: world.BlockCoord{X: 0, Y: 0, Z: 0},
+		Max: world.BlockCoord{X: dim.Width - 1, Y: dim.Depth - 1, Z: dim.Height - 1},
+	}
+	metrics := &GenerationMetrics{}
+	ctx := ContextWithProfiler(context.Background(), metrics.Profiler())
+
+	if _, err := generator.Generate(ctx, world.ChunkCoord{X: 91, Y: 17}, bounds, dim); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	snapshot := metrics.Snapshot()
+	if snapshot.Chunks != 1 {
+		t.Fatalf("expected one generated chunk, got %d", snapshot.Chunks)
+	}
+	if snapshot.Columns <= 0 {
+		t.Fatalf("expected column pass duration to be recorded")
+	}
+	if snapshot.Minerals <= 0 {
+		t.Fatalf("expected mineral pass duration to be recorded")
+	}
+
+	metrics.Reset()
+	if snapshot := metrics.Snapshot(); snapshot.Chunks != 0 || snapshot.Total() != 0 {
+		t.Fatalf("expected reset metrics to be zero, got %+v", snapshot)
+	}
+}
+
+func TestNoiseGeneratorAppliesConfiguredBlockColors(t *testing.T) {
+	gen := NewNoiseGenerator(config.TerrainConfig{Seed: 5}, config.EconomyConfig{})
+	gen.SetBlockDefinitions([]config.BlockDefinition{
+		{ID: "dirt", Color: "#123456", LightEmission: 0.25},
+	})
 
-		if len(blocksA) != len(blocksB) {
-			t.Fatalf("iteration %d: block count mismatch for chunk %v: %d vs %d", i, chunkCoord, len(blocksA), len(blocksB))
+	dim := world.Dimensions{Width: 4, Depth: 4, Height: 16}
+	bounds := world.Bounds{
+		Min: world.BlockCoord{X: 0, Y: 0, Z: 0},
+		Max: world.BlockCoord{X: dim.Width - 1, Y: dim.Depth - 1, Z: dim.Height - 1},
+	}
+	chunk, err := gen.Generate(context.Background(), world.ChunkCoord{X: 1, Y: 1}, bounds, dim)
+	if err != nil {
+		t.Fatalf("generate chunk: %v", err)
+	}
+
+	dirt := 0
+	chunk.ForEachBlock(func(coord world.BlockCoord, block world.Block) bool {
+		if block.Material != "dirt" {
+			return true
 		}
-		for coord, blockA := range blocksA {
-			blockB, ok := blocksB[coord]
-			if !ok {
-				t.Fatalf("iteration %d: chunk %v missing block at %v in second generation", i, chunkCoord, coord)
+		dirt++
+		if block.Color != "#123456" {
+			t.Fatalf("expected configured dirt color at %v, got %q", coord, block.Color)
+		}
+		if block.LightEmission != 0.25 {
+			t.Fatalf("expected configured dirt light at %v, got %v", coord, block.LightEmission)
+		}
+		return true
+	})
+	if dirt == 0 {
+		t.Fatal("expected generated chunk to contain dirt")
+	}
+	if gen.topsoilSurfacePrototype.Color != world.DefaultAppearances[world.MaterialGrass].Color {
+		t.Fatalf("expected unconfigured grass to keep its default color, got %q", gen.topsoilSurfacePrototype.Color)
+	}
+}
+
+func TestNoiseGeneratorExposesAddedBlockDefinitions(t *testing.T) {
+	gen := NewNoiseGenerator(config.TerrainConfig{}, config.EconomyConfig{})
+	if _, ok := gen.BlockPrototype("glowstone"); ok {
+		t.Fatal("expected no prototype before the block is defined")
+	}
+
+	gen.SetBlockDefinitions([]config.BlockDefinition{
+		{ID: "glowstone", Color: "#FFD700", LightEmission: 0.8},
+	})
+	block, ok := gen.BlockPrototype("glowstone")
+	if !ok {
+		t.Fatal("expected added block definition to be available")
+	}
+	if block.Material != "glowstone" || block.Color != "#FFD700" || block.LightEmission != 0.8 {
+		t.Fatalf("expected prototype styled by its definition, got %+v", block)
+	}
+	if block.Type != world.BlockSolid || block.MaxHitPoints != gen.stonePrototype.MaxHitPoints {
+		t.Fatalf("expected unspecified stats to default to stone, got %+v", block)
+	}
+}
+
+func TestGrowVeinPlacesConnectedCluster(t *testing.T) {
+	gen := NewNoiseGenerator(config.TerrainConfig{}, config.EconomyConfig{})
+	dim := world.Dimensions{Width: 5, Depth: 5, Height: 8}
+	buffer := newChunkWriteBuffer(nil, dim, 0, 0)
+	for x := 0; x < dim.Width; x++ {
+		for y := 0; y < dim.Depth; y++ {
+			column := make([]world.Block, dim.Height)
+			fillBlockRange(column, 0, dim.Height-1, gen.stonePrototype)
+			buffer.setColumn(x, y, column)
+		}
+	}
+
+	vein := gen.growVein(buffer, dim, 2, 2, "iron", 12, rand.New(rand.NewSource(3)))
+	if len(vein) != 12 {
+		t.Fatalf("expected 12 vein cells, got %d", len(vein))
+	}
+
+	cells := make(map[world.BlockCoord]struct{}, len(vein))
+	columns := make(map[[2]int]struct{})
+	for _, cell := range vein {
+		cells[cell] = struct{}{}
+		columns[[2]int{cell.X, cell.Y}] = struct{}{}
+		column, _ := buffer.column(cell.X, cell.Y)
+		if block := column[cell.Z]; block.Type != world.BlockMineral || block.ResourceYield["iron"] <= 0 {
+			t.Fatalf("expected vein cell %v to be written through the buffer, got %+v", cell, block)
+		}
+	}
+	if len(columns) < 2 {
+		t.Fatalf("expected vein to spread across columns, got %v", vein)
+	}
+	if reached := connectedCells(cells, vein[0]); reached != len(cells) {
+		t.Fatalf("expected one connected vein, reached %d of %d cells: %v", reached, len(cells), vein)
+	}
+}
+
+func TestNoiseGeneratorMineralVeinsFormConnectedClusters(t *testing.T) {
+	gen := NewNoiseGenerator(config.TerrainConfig{
+		Seed:        21,
+		Frequency:   0.02,
+		Amplitude:   4,
+		Octaves:     2,
+		Persistence: 0.5,
+		Lacunarity:  2,
+	}, config.EconomyConfig{ResourceSpawnDensity: map[string]float64{"copper": 0.1}})
+
+	dim := world.Dimensions{Width: 16, Depth: 16, Height: 32}
+	bounds := world.Bounds{
+		Min: world.BlockCoord{X: 0, Y: 0, Z: 0},
+		Max: world.BlockCoord{X: dim.Width - 1, Y: dim.Depth - 1, Z: dim.Height - 1},
+	}
+	chunk, err := gen.Generate(context.Background(), world.ChunkCoord{X: 4, Y: 2}, bounds, dim)
+	if err != nil {
+		t.Fatalf("generate chunk: %v", err)
+	}
+
+	cells := make(map[world.BlockCoord]struct{})
+	chunk.ForEachBlock(func(coord world.BlockCoord, block world.Block) bool {
+		if block.ResourceYield["copper"] > 0 {
+			cells[coord] = struct{}{}
+		}
+		return true
+	})
+	if len(cells) == 0 {
+		t.Fatal("expected copper to be placed")
+	}
+	for cell := range cells {
+		if connectedCells(cells, cell) < 2 {
+			t.Fatalf("expected copper at %v to belong to a vein, found an isolated block", cell)
+		}
+	}
+}
+
+func TestNoiseGeneratorMineralSpawnFollowsBlockDefinition(t *testing.T) {
+	terrain := config.TerrainConfig{
+		Seed:        21,
+		Frequency:   0.02,
+		Amplitude:   4,
+		Octaves:     2,
+		Persistence: 0.5,
+		Lacunarity:  2,
+	}
+	economy := config.EconomyConfig{ResourceSpawnDensity: map[string]float64{"copper": 0.1}}
+	dim := world.Dimensions{Width: 16, Depth: 16, Height: 32}
+	bounds := world.Bounds{
+		Min: world.BlockCoord{X: 0, Y: 0, Z: 0},
+		Max: world.BlockCoord{X: dim.Width - 1, Y: dim.Depth - 1, Z: dim.Height - 1},
+	}
+	copperPerColumn := func(spawn config.BlockSpawnConfig) map[[2]int]int {
+		gen := NewNoiseGenerator(terrain, economy)
+		gen.SetBlockDefinitions([]config.BlockDefinition{{ID: "copper", Color: "#B87333", Spawn: spawn}})
+		chunk, err := gen.Generate(context.Background(), world.ChunkCoord{X: 4, Y: 2}, bounds, dim)
+		if err != nil {
+			t.Fatalf("generate chunk: %v", err)
+		}
+		counts := make(map[[2]int]int)
+		chunk.ForEachBlock(func(coord world.BlockCoord, block world.Block) bool {
+			if block.ResourceYield["copper"] > 0 {
+				counts[[2]int{coord.X, coord.Y}]++
+			}
+			return true
+		})
+		return counts
+	}
+
+	solo := copperPerColumn(config.BlockSpawnConfig{Type: "solo"})
+	if len(solo) == 0 {
+		t.Fatal("expected solo copper to be placed")
+	}
+	for column, count := range solo {
+		if count != 1 {
+			t.Fatalf("expected solo copper to place one block per deposit, column %v holds %d", column, count)
+		}
+	}
+
+	veins := copperPerColumn(config.BlockSpawnConfig{Type: "vein", VeinSizeMin: 20, VeinSizeMax: 24})
+	total, soloTotal := 0, 0
+	for _, count := range veins {
+		total += count
+	}
+	for _, count := range solo {
+		soloTotal += count
+	}
+	if total < 10*soloTotal {
+		t.Fatalf("expected configured vein sizes to grow large deposits, got %d copper blocks for %d deposits", total, soloTotal)
+	}
+}
+
+func TestVeinSizeUsesConfiguredRange(t *testing.T) {
+	gen := NewNoiseGenerator(config.TerrainConfig{}, config.EconomyConfig{})
+	gen.SetBlockDefinitions([]config.BlockDefinition{
+		{ID: "gold", Color: "#FFD700", Spawn: config.BlockSpawnConfig{Type: "vein", VeinSizeMin: 3, VeinSizeMax: 8}},
+		{ID: "gem", Color: "#00FFFF", Spawn: config.BlockSpawnConfig{Type: "solo"}},
+	})
+	rng := rand.New(rand.NewSource(7))
+	for i := 0; i < 100; i++ {
+		if size := gen.veinSize("gold", 0.1, rng); size < 3 || size > 8 {
+			t.Fatalf("expected gold vein size within 3..8, got %d", size)
+		}
+		if size := gen.veinSize("gem", 0.9, rng); size != 1 {
+			t.Fatalf("expected solo gem to place one block, got %d", size)
+		}
+	}
+}
+
+// connectedCells counts the cells reachable from start through face-adjacent
+// members of cells.
+func connectedCells(cells map[world.BlockCoord]struct{}, start world.BlockCoord) int {
+	seen := map[world.BlockCoord]struct{}{start: {}}
+	queue := []world.BlockCoord{start}
+	for len(queue) > 0 {
+		cell := queue[0]
+		queue = queue[1:]
+		for _, step := range veinSteps {
+			next := world.BlockCoord{X: cell.X + step.X, Y: cell.Y + step.Y, Z: cell.Z + step.Z}
+			if _, ok := cells[next]; !ok {
+				continue
 			}
-			if !reflect.DeepEqual(blockA, blockB) {
-				t.Fatalf("iteration %d: chunk %v block mismatch at %v", i, chunkCoord, coord)
+			if _, ok := seen[next]; ok {
+				continue
+			}
+			seen[next] = struct{}{}
+			queue = append(queue, next)
+		}
+	}
+	return len(seen)
+}
+
+func TestColumnMemoryCountsBlockMaps(t *testing.T) {
+	plain := make([]world.Block, 16)
+	heavy := make([]world.Block, 16)
+	for i := range heavy {
+		heavy[i] = world.Block{
+			Metadata:      map[string]any{"layer": "deepstone", "variant": strings.Repeat("v", 64)},
+			ResourceYield: map[string]float64{"iron": 1},
+		}
+	}
+	if columnMemory(heavy) <= columnMemory(plain)+int64(len(heavy))*(2*mapEntryOverhead+64) {
+		t.Fatalf("expected metadata and yields to be counted, plain %d heavy %d", columnMemory(plain), columnMemory(heavy))
+	}
+}
+
+func TestChunkWriteBufferFlushesTallMetadataColumnsBeforeCap(t *testing.T) {
+	dim := world.Dimensions{Width: 8, Depth: 8, Height: 2048}
+	bounds := world.Bounds{
+		Min: world.BlockCoord{X: 0, Y: 0, Z: 0},
+		Max: world.BlockCoord{X: dim.Width - 1, Y: dim.Depth - 1, Z: dim.Height - 1},
+	}
+	chunk := world.NewChunk(world.ChunkCoord{X: 0, Y: 0}, bounds, dim)
+
+	tallColumn := func() []world.Block {
+		column := make([]world.Block, dim.Height)
+		for z := range column {
+			column[z] = world.Block{
+				Type:          world.BlockMineral,
+				Metadata:      map[string]any{"layer": "deepstone", "treeVariant": "spiral", "treePart": "trunk"},
+				ResourceYield: map[string]float64{"iron": 1, "coal": 2},
+			}
+		}
+		return column
+	}
+	sample := tallColumn()
+	blocksOnly := int64(len(sample)) * int64(unsafe.Sizeof(world.Block{}))
+	if columnMemory(sample) < 2*blocksOnly {
+		t.Fatalf("expected map contents to dominate a metadata-heavy column, got %d for %d bytes of blocks", columnMemory(sample), blocksOnly)
+	}
+
+	// Counting blocks alone, two columns fit under the byte cap; with their
+	// maps counted, the second must flush.
+	byteCap := 3 * blocksOnly
+	buffer := newChunkWriteBuffer(chunk, dim, byteCap, 0)
+	for x := 0; x < 4; x++ {
+		if err := buffer.Store(x, 0, tallColumn()); err != nil {
+			t.Fatalf("store column %d: %v", x, err)
+		}
+		if buffer.usageBytes >= byteCap {
+			t.Fatalf("expected buffer to flush before holding %d bytes, holds %d", byteCap, buffer.usageBytes)
+		}
+		if len(buffer.columns) > 1 {
+			t.Fatalf("expected each metadata-heavy column to trigger a flush, buffer holds %d", len(buffer.columns))
+		}
+	}
+
+	columnCap := 5
+	buffer = newChunkWriteBuffer(chunk, dim, 1<<40, columnCap)
+	for i := 0; i < dim.Width*dim.Depth; i++ {
+		if err := buffer.Store(i%dim.Width, i/dim.Width, tallColumn()); err != nil {
+			t.Fatalf("store column %d: %v", i, err)
+		}
+		if len(buffer.columns) >= columnCap {
+			t.Fatalf("expected buffer to flush at %d columns, holds %d", columnCap, len(buffer.columns))
+		}
+	}
+	if err := buffer.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	for x := 0; x < dim.Width; x++ {
+		for y := 0; y < dim.Depth; y++ {
+			if block, ok := chunk.LocalBlock(x, y, dim.Height-1); !ok || block.Type != world.BlockMineral {
+				t.Fatalf("expected flushed column (%d,%d) in the chunk, got %+v ok=%v", x, y, block, ok)
 			}
 		}
 	}
 }
+
+func TestNoiseGeneratorStopsWhenContextCancelled(t *testing.T) {
+	gen := NewNoiseGenerator(config.TerrainConfig{Seed: 3, Workers: 2}, config.EconomyConfig{})
+	dim := world.Dimensions{Width: 64, Depth: 64, Height: 256}
+	bounds := world.Bounds{
+		Min: world.BlockCoord{X: 0, Y: 0, Z: 0},
+		Max: world.BlockCoord{X: dim.Width - 1, Y: dim.Depth - 1, Z: dim.Height - 1},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	chunk, err := gen.Generate(ctx, world.ChunkCoord{X: 6, Y: 6}, bounds, dim)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected generation to stop with the context deadline, got chunk=%v err=%v", chunk != nil, err)
+	}
+	if chunk != nil {
+		t.Fatal("expected no chunk from a cancelled generation")
+	}
+}
+
+func TestNoiseGeneratorConcurrentGenerationsAreByteIdentical(t *testing.T) {
+	originalWriter := log.Writer()
+	log.SetOutput(&bytes.Buffer{})
+	defer log.SetOutput(originalWriter)
+
+	cfg := config.Default()
+	cfg.Terrain.Workers = 8
+	// Flush mid-generation so the later passes only see part of the chunk,
+	// which exposes any dependence on the order columns arrive in.
+	cfg.Terrain.WriteBufferColumns = 37
+	gen := NewNoiseGenerator(cfg.Terrain, config.EconomyConfig{ResourceSpawnDensity: map[string]float64{
+		"iron": 0.3, "coal": 0.5, "gold": 0.1,
+	}})
+
+	dim := world.Dimensions{Width: 24, Depth: 24, Height: 48}
+	bounds := world.Bounds{
+		Min: world.BlockCoord{X: 240, Y: -96, Z: 0},
+		Max: world.BlockCoord{X: 240 + dim.Width - 1, Y: -96 + dim.Depth - 1, Z: dim.Height - 1},
+	}
+	encode := func(chunk *world.Chunk) []byte {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		for x := 0; x < dim.Width; x++ {
+			for y := 0; y < dim.Depth; y++ {
+				for z := 0; z < dim.Height; z++ {
+					block, _ := chunk.LocalBlock(x, y, z)
+					if err := enc.Encode(block); err != nil {
+						t.Errorf("encode block: %v", err)
+					}
+				}
+			}
+		}
+		return buf.Bytes()
+	}
+
+	const runs = 8
+	outputs := make([][]byte, runs)
+	var wg sync.WaitGroup
+	for i := 0; i < runs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			chunk, err := gen.Generate(context.Background(), world.ChunkCoord{X: 10, Y: -4}, bounds, dim)
+			if err != nil {
+				t.Errorf("run %d: %v", i, err)
+				return
+			}
+			outputs[i] = encode(chunk)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 1; i < runs; i++ {
+		if !bytes.Equal(outputs[0], outputs[i]) {
+			t.Fatalf("run %d produced a different chunk than run 0", i)
+		}
+	}
+}
+
+func TestNoiseGeneratorRejectsNonPositiveDimensions(t *testing.T) {
+	gen := NewNoiseGenerator(config.Default().Terrain, config.Default().Economy)
+	for _, dim := range []world.Dimensions{
+		{Width: 0, Depth: 4, Height: 8},
+		{Width: 4, Depth: 0, Height: 8},
+		{Width: 4, Depth: 4, Height: 0},
+	} {
+		bounds := world.Bounds{Max: world.BlockCoord{X: dim.Width - 1, Y: dim.Depth - 1, Z: dim.Height - 1}}
+		chunk, err := gen.Generate(context.Background(), world.ChunkCoord{}, bounds, dim)
+		if err == nil || chunk != nil {
+			t.Fatalf("Generate(%+v) = %v, %v, want an error", dim, chunk, err)
+		}
+		if !strings.Contains(err.Error(), "dimensions must be positive") {
+			t.Fatalf("Generate(%+v) error %q does not explain the dimensions", dim, err)
+		}
+	}
+}
+
+// cancelOnWrite cancels a generation once its progress log reaches marker.
+type cancelOnWrite struct {
+	marker string
+	cancel context.CancelFunc
+}
+
+func (w *cancelOnWrite) Write(p []byte) (int, error) {
+	if strings.Contains(string(p), w.marker) {
+		w.cancel()
+	}
+	return len(p), nil
+}
+
+func TestNoiseGeneratorRegeneratesChunkCancelledMidGeneration(t *testing.T) {
+	cfg := config.TerrainConfig{Seed: 11, Workers: 1, WriteBufferColumns: 1}
+	dim := world.Dimensions{Width: 4, Depth: 4, Height: 16}
+	region := world.ServerRegion{ChunksX: 1, ChunksY: 1, ChunkDimension: dim}
+	bounds := world.Bounds{
+		Min: world.BlockCoord{X: 0, Y: 0, Z: 0},
+		Max: world.BlockCoord{X: dim.Width - 1, Y: dim.Depth - 1, Z: dim.Height - 1},
+	}
+	coord := world.ChunkCoord{X: 0, Y: 0}
+
+	blocksOf := func(chunk *world.Chunk) map[world.BlockCoord]world.Block {
+		blocks := make(map[world.BlockCoord]world.Block)
+		chunk.ForEachBlock(func(coord world.BlockCoord, block world.Block) bool {
+			blocks[coord] = block
+			return true
+		})
+		return blocks
+	}
+
+	referenceCtx := world.ContextWithStorageProvider(context.Background(), world.NewDiskStorageProvider(t.TempDir(), region))
+	reference, err := NewNoiseGenerator(cfg, config.EconomyConfig{}).Generate(referenceCtx, coord, bounds, dim)
+	if err != nil {
+		t.Fatalf("generate reference chunk: %v", err)
+	}
+	want := blocksOf(reference)
+	reference.Close()
+
+	provider := world.NewDiskStorageProvider(t.TempDir(), region)
+	ctx, cancel := context.WithCancel(world.ContextWithStorageProvider(context.Background(), provider))
+	defer cancel()
+	gen := NewNoiseGenerator(cfg, config.EconomyConfig{})
+	gen.SetLogger(logging.New(&cancelOnWrite{marker: "50%", cancel: cancel}, slog.LevelDebug))
+	gen.SetVerboseProgress(true)
+	if _, err := gen.Generate(ctx, coord, bounds, dim); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected generation to be cancelled, got %v", err)
+	}
+
+	partial := world.NewChunkWithStorage(coord, bounds, dim, provider)
+	if partial.HasStoredBlocks() {
+		t.Fatal("expected the half generated columns to be discarded on load")
+	}
+	partial.Close()
+
+	regenerated, err := NewNoiseGenerator(cfg, config.EconomyConfig{}).Generate(world.ContextWithStorageProvider(context.Background(), provider), coord, bounds, dim)
+	if err != nil {
+		t.Fatalf("regenerate chunk: %v", err)
+	}
+	if got := blocksOf(regenerated); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected regenerated chunk to match a fresh generation")
+	}
+	regenerated.Close()
+
+	reopened := world.NewChunkWithStorage(coord, bounds, dim, provider)
+	defer reopened.Close()
+	if !reopened.HasStoredBlocks() {
+		t.Fatal("expected a finished generation to be kept on load")
+	}
+}
@@ -0,0 +1,132 @@
+package terrain
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// GenerationPass names a phase of NoiseGenerator.Generate.
+type GenerationPass int
+
+const (
+	// PassColumns populates the base terrain columns across the worker pool.
+	PassColumns GenerationPass = iota
+	// PassForests grows trees on suitable surface columns.
+	PassForests
+	// PassMinerals seeds mineral veins into the stone layers.
+	PassMinerals
+	// PassFlush writes the buffered columns to block storage.
+	PassFlush
+	passCount
+)
+
+func (p GenerationPass) String() string {
+	switch p {
+	case PassColumns:
+		return "columns"
+	case PassForests:
+		return "forests"
+	case PassMinerals:
+		return "minerals"
+	case PassFlush:
+		return "flush"
+	default:
+		return "unknown"
+	}
+}
+
+// GenerationProfiler captures instrumentation hooks for terrain generation.
+type GenerationProfiler interface {
+	RecordPass(pass GenerationPass, duration time.Duration)
+	RecordChunk()
+}
+
+// GenerationMetrics accumulates per-pass timings across generated chunks.
+// Chunks served from stored blocks are not counted.
+type GenerationMetrics struct {
+	chunks   atomic.Int64
+	passTime [passCount]atomic.Int64
+}
+
+// GenerationSnapshot captures a point-in-time copy of generation metrics.
+type GenerationSnapshot struct {
+	Chunks   int64
+	Columns  time.Duration
+	Forests  time.Duration
+	Minerals time.Duration
+	Flush    time.Duration
+}
+
+// Total returns the time spent across every pass.
+func (s GenerationSnapshot) Total() time.Duration {
+	return s.Columns + s.Forests + s.Minerals + s.Flush
+}
+
+// Profiler returns a GenerationProfiler implementation backed by this metric set.
+func (m *GenerationMetrics) Profiler() GenerationProfiler {
+	if m == nil {
+		return nil
+	}
+	return (*generationProfiler)(m)
+}
+
+// Reset zeroes all counters in the metrics set.
+func (m *GenerationMetrics) Reset() {
+	if m == nil {
+		return
+	}
+	m.chunks.Store(0)
+	for i := range m.passTime {
+		m.passTime[i].Store(0)
+	}
+}
+
+// Snapshot captures the current counter values.
+func (m *GenerationMetrics) Snapshot() GenerationSnapshot {
+	if m == nil {
+		return GenerationSnapshot{}
+	}
+	return GenerationSnapshot{
+		Chunks:   m.chunks.Load(),
+		Columns:  time.Duration(m.passTime[PassColumns].Load()),
+		Forests:  time.Duration(m.passTime[PassForests].Load()),
+		Minerals: time.Duration(m.passTime[PassMinerals].Load()),
+		Flush:    time.Duration(m.passTime[PassFlush].Load()),
+	}
+}
+
+// generationProfiler implements GenerationProfiler by mutating the backing metrics set.
+type generationProfiler GenerationMetrics
+
+func (m *generationProfiler) RecordPass(pass GenerationPass, duration time.Duration) {
+	if pass < 0 || pass >= passCount {
+		return
+	}
+	(*GenerationMetrics)(m).passTime[pass].Add(duration.Nanoseconds())
+}
+
+func (m *generationProfiler) RecordChunk() {
+	(*GenerationMetrics)(m).chunks.Add(1)
+}
+
+type profilerContextKey struct{}
+
+// ContextWithProfiler returns a context that will report the provided profiler
+// during chunk generation.
+func ContextWithProfiler(ctx context.Context, profiler GenerationProfiler) context.Context {
+	if profiler == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, profilerContextKey{}, profiler)
+}
+
+func profilerFromContext(ctx context.Context) GenerationProfiler {
+	if ctx == nil {
+		return nil
+	}
+	if profiler, ok := ctx.Value(profilerContextKey{}).(GenerationProfiler); ok {
+		return profiler
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package terrain
+
+import (
+	"time"
+
+	"chunkserver/internal/logging"
+	"chunkserver/internal/world"
+)
+
+const (
+	// defaultProgressLogThreshold is how long a chunk generates before its
+	// progress is logged when the configuration leaves it unset.
+	defaultProgressLogThreshold = 2 * time.Second
+	// progressLogInterval is the least time between progress lines for one
+	// chunk.
+	progressLogInterval = time.Second
+)
+
+// progressLog reports one chunk's generation progress. Chunks that finish
+// within the threshold log nothing; slower chunks log at 10% steps, no more
+// than once per progressLogInterval, and always log completion. Verbose logs
+// every step from the start.
+type progressLog struct {
+	logger    *logging.Logger
+	coord     world.ChunkCoord
+	started   time.Time
+	threshold time.Duration
+	verbose   bool
+
+	nextPercent int
+	lastLogged  time.Time
+	logged      bool
+}
+
+func newProgressLog(logger *logging.Logger, coord world.ChunkCoord, threshold time.Duration, verbose bool) *progressLog {
+	p := &progressLog{
+		logger:      logger,
+		coord:       coord,
+		started:     time.Now(),
+		threshold:   threshold,
+		verbose:     verbose,
+		nextPercent: 10,
+	}
+	if verbose {
+		p.write(0)
+	}
+	return p
+}
+
+// report records that percent of the chunk's columns are done.
+func (p *progressLog) report(percent int) {
+	if percent > 100 {
+		percent = 100
+	}
+	if percent < p.nextPercent {
+		return
+	}
+	p.nextPercent = (percent/10 + 1) * 10
+	if !p.verbose {
+		now := time.Now()
+		if now.Sub(p.started) < p.threshold || (p.logged && now.Sub(p.lastLogged) < progressLogInterval) {
+			return
+		}
+	}
+	p.write(percent)
+}
+
+// finish logs completion for chunks that logged any progress.
+func (p *progressLog) finish() {
+	if p.logged && p.nextPercent <= 100 {
+		p.write(100)
+	}
+}
+
+func (p *progressLog) write(percent int) {
+	p.logger.Debugf("chunk %v generation progress: %d%%", p.coord, percent)
+	p.logged = true
+	p.lastLogged = time.Now()
+}
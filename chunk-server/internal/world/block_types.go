@@ -0,0 +1,100 @@
+package world
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// BlockTypeProperties describes how the simulation treats every block of a
+// type, so new types can be added as data rather than as new branches.
+type BlockTypeProperties struct {
+	// Passable blocks can be occupied by units, like air.
+	Passable bool
+	// Diggable blocks can be tunnelled through by units that dig.
+	Diggable bool
+	// SupportsLoad blocks can be stood on and take part in column stability,
+	// carrying the weight of the blocks above them.
+	SupportsLoad bool
+	// Transmissive blocks let light and sight through, so the blocks behind
+	// them stay visible to clients.
+	Transmissive bool
+}
+
+// unknownBlockType is what unregistered types behave as: an ordinary
+// load-bearing block.
+var unknownBlockType = BlockTypeProperties{SupportsLoad: true}
+
+// BlockTypeRegistry maps block types to their properties. Lookups read an
+// immutable snapshot of the types without locking, since they sit on the
+// hottest paths of the simulation; Register publishes a new snapshot.
+type BlockTypeRegistry struct {
+	mu    sync.Mutex
+	types atomic.Pointer[map[BlockType]BlockTypeProperties]
+}
+
+// NewBlockTypeRegistry returns a registry seeded with the built-in block
+// types.
+func NewBlockTypeRegistry() *BlockTypeRegistry {
+	r := &BlockTypeRegistry{}
+	r.types.Store(&map[BlockType]BlockTypeProperties{
+		BlockAir:       {Passable: true, Transmissive: true},
+		BlockSolid:     {SupportsLoad: true},
+		BlockUnstable:  {Diggable: true, SupportsLoad: true},
+		BlockMineral:   {Diggable: true, SupportsLoad: true},
+		BlockExplosive: {Diggable: true, SupportsLoad: true},
+	})
+	return r
+}
+
+// Register sets the properties of t, replacing any earlier registration.
+func (r *BlockTypeRegistry) Register(t BlockType, props BlockTypeProperties) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current := *r.types.Load()
+	next := make(map[BlockType]BlockTypeProperties, len(current)+1)
+	for k, v := range current {
+		next[k] = v
+	}
+	next[t] = props
+	r.types.Store(&next)
+}
+
+// Lookup returns the properties registered for t.
+func (r *BlockTypeRegistry) Lookup(t BlockType) (BlockTypeProperties, bool) {
+	if t == "" {
+		t = BlockAir
+	}
+	props, ok := (*r.types.Load())[t]
+	return props, ok
+}
+
+// Properties returns the properties registered for t. The empty type is air;
+// unregistered types behave as plain load-bearing blocks.
+func (r *BlockTypeRegistry) Properties(t BlockType) BlockTypeProperties {
+	if props, ok := r.Lookup(t); ok {
+		return props
+	}
+	return unknownBlockType
+}
+
+var blockTypes atomic.Pointer[BlockTypeRegistry]
+
+func init() {
+	blockTypes.Store(NewBlockTypeRegistry())
+}
+
+// SetBlockTypes overrides the global block type registry consulted by the
+// simulation.
+func SetBlockTypes(registry *BlockTypeRegistry) {
+	blockTypes.Store(registry)
+}
+
+// CurrentBlockTypes returns the block type registry currently in use.
+func CurrentBlockTypes() *BlockTypeRegistry {
+	return blockTypes.Load()
+}
+
+// Properties returns the properties of t from the current registry.
+func (t BlockType) Properties() BlockTypeProperties {
+	return CurrentBlockTypes().Properties(t)
+}
@@ -0,0 +1,83 @@
+package world
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func useBlockTypes(t *testing.T, registry *BlockTypeRegistry) {
+	t.Helper()
+	previous := CurrentBlockTypes()
+	SetBlockTypes(registry)
+	t.Cleanup(func() { SetBlockTypes(previous) })
+}
+
+func TestBlockTypeRegistrySeedsBuiltInTypes(t *testing.T) {
+	registry := NewBlockTypeRegistry()
+	if props := registry.Properties(""); !props.Passable || props.SupportsLoad {
+		t.Fatalf("expected the empty type to behave as air, got %+v", props)
+	}
+	if props := registry.Properties(BlockSolid); props.Passable || props.Diggable || !props.SupportsLoad {
+		t.Fatalf("unexpected solid properties %+v", props)
+	}
+	if props := registry.Properties(BlockMineral); !props.Diggable {
+		t.Fatalf("expected minerals to be diggable, got %+v", props)
+	}
+	if _, ok := registry.Lookup("glass"); ok {
+		t.Fatalf("expected glass to be unregistered")
+	}
+	if props := registry.Properties("glass"); props != unknownBlockType {
+		t.Fatalf("expected unregistered types to use the default properties, got %+v", props)
+	}
+}
+
+func TestStabilityHonorsRegisteredBlockTypes(t *testing.T) {
+	registry := NewBlockTypeRegistry()
+	registry.Register("water", BlockTypeProperties{Passable: true, Transmissive: true})
+	registry.Register("glass", BlockTypeProperties{SupportsLoad: true, Transmissive: true})
+	useBlockTypes(t, registry)
+
+	params := DefaultStabilityParams()
+	stone := Block{Type: BlockSolid, ConnectingForce: 100, Weight: 1}
+	column := func(middle BlockType) []StabilityReport {
+		middleBlock := stone
+		middleBlock.Type = middle
+		return evaluateColumn([]Block{stone, middleBlock, stone}, BlockCoord{}, params)
+	}
+
+	// Glass carries the block above it like stone does.
+	reports := column("glass")
+	if len(reports) != 3 || reports[2].Hanging {
+		t.Fatalf("expected the top block to rest on glass, got %+v", reports)
+	}
+	// Water does not, so the top block hangs over it.
+	reports = column("water")
+	if len(reports) != 2 || !reports[1].Hanging || reports[1].Global.Z != 2 {
+		t.Fatalf("expected the top block to hang over water, got %+v", reports)
+	}
+}
+
+func TestBlockTypeRegisterWhileLookingUp(t *testing.T) {
+	registry := NewBlockTypeRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				if props := registry.Properties(BlockSolid); !props.SupportsLoad {
+					t.Errorf("solid lost its properties: %+v", props)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		registry.Register(BlockType(fmt.Sprintf("custom-%d", i)), BlockTypeProperties{Passable: true})
+	}
+	wg.Wait()
+	if props, ok := registry.Lookup("custom-99"); !ok || !props.Passable {
+		t.Fatalf("expected every registration to be kept, got %+v (%t)", props, ok)
+	}
+}
@@ -1,8 +1,11 @@
 package world
 
 import (
-	"log"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"chunkserver/internal/logging"
 )
 
 // BlockType enumerates known world block categories.
@@ -37,20 +40,138 @@ type Chunk struct {
 	mu        sync.RWMutex
 	store     BlockStorage
 	dimension Dimensions
+	version   atomic.Uint64
+	// logger is where storage problems are reported: the logger of the
+	// manager the chunk was created through.
+	logger *logging.Logger
+	// lastAccess is when the manager last handed the chunk out, in Unix
+	// nanoseconds.
+	lastAccess atomic.Int64
+
+	censusMu      sync.Mutex
+	census        BlockCensus
+	censusVersion uint64
+
+	surfaceMu      sync.Mutex
+	surface        map[int]int
+	surfaceVersion uint64
+
+	fingerprintMu      sync.Mutex
+	fingerprint        uint64
+	fingerprintVersion uint64
+	fingerprinted      bool
+}
+
+// BlockCensus counts the non-air blocks in a chunk.
+type BlockCensus struct {
+	ByType map[BlockType]int
+	Total  int
 }
 
 func NewChunk(key ChunkCoord, bounds Bounds, dim Dimensions) *Chunk {
-	store, err := getStorageProvider().NewStorage(key, bounds, dim)
+	return NewChunkWithStorage(key, bounds, dim, getStorageProvider())
+}
+
+// NewChunkWithStorage creates a chunk backed by storage from provider rather
+// than the global one. A nil provider means the global provider. Storage the
+// provider cannot open falls back to memory.
+func NewChunkWithStorage(key ChunkCoord, bounds Bounds, dim Dimensions, provider StorageProvider) *Chunk {
+	if provider == nil {
+		provider = getStorageProvider()
+	}
+	logger := providerLogger(provider)
+	store, err := provider.NewStorage(key, bounds, dim)
 	if err != nil {
-		log.Printf("chunk storage unavailable for %v: %v", key, err)
+		logger.Warnf("chunk storage unavailable for %v: %v", key, err)
 		store, _ = newMemoryStorageProvider().NewStorage(key, bounds, dim)
 	}
-	return &Chunk{
+	if marker, ok := store.(generationMarker); ok && marker.Generating() {
+		discardPartialGeneration(key, store, marker, logger)
+	}
+	chunk := &Chunk{
 		Key:       key,
 		Bounds:    bounds,
 		store:     store,
 		dimension: dim,
+		logger:    logger,
+	}
+	chunk.version.Store(nextChunkVersionBase())
+	return chunk
+}
+
+// lastChunkVersionBase is the most recent version a chunk load started from.
+var lastChunkVersionBase atomic.Uint64
+
+// nextChunkVersionBase returns the version a freshly loaded chunk starts at:
+// the wall clock in nanoseconds, kept strictly increasing within the process.
+// Edits advance a version by one, far slower than the clock, so a chunk that
+// is unloaded and loaded again, here or after a restart, starts above every
+// version it was summarized at before.
+func nextChunkVersionBase() uint64 {
+	for {
+		last := lastChunkVersionBase.Load()
+		next := uint64(time.Now().UnixNano())
+		if next <= last {
+			next = last + 1
+		}
+		if lastChunkVersionBase.CompareAndSwap(last, next) {
+			return next
+		}
+	}
+}
+
+// discardPartialGeneration deletes every column of a chunk whose generation
+// never finished, so it is generated afresh instead of loaded half filled.
+// The mark stays set if any column could not be deleted.
+func discardPartialGeneration(key ChunkCoord, store BlockStorage, marker generationMarker, logger *logging.Logger) {
+	var indices []int
+	if err := store.ForEach(func(index int, _ []Block) bool {
+		indices = append(indices, index)
+		return true
+	}); err != nil {
+		logger.Warnf("chunk %v list partial generation: %v", key, err)
+		return
+	}
+	for _, index := range indices {
+		if err := store.Delete(index); err != nil {
+			logger.Warnf("chunk %v discard partial column %d: %v", key, index, err)
+			return
+		}
 	}
+	logger.Warnf("chunk %v generation was left unfinished; discarded %d columns to regenerate it", key, len(indices))
+	if err := marker.SetGenerating(false); err != nil {
+		logger.Warnf("chunk %v: %v", key, err)
+	}
+}
+
+// BeginGeneration marks the chunk as being generated. Until FinishGeneration
+// clears the mark, loading the chunk discards whatever columns it holds.
+func (c *Chunk) BeginGeneration() error {
+	return c.setGenerating(true)
+}
+
+// FinishGeneration clears the mark set by BeginGeneration once every
+// generated column is stored.
+func (c *Chunk) FinishGeneration() error {
+	return c.setGenerating(false)
+}
+
+func (c *Chunk) setGenerating(generating bool) error {
+	c.mu.RLock()
+	store := c.store
+	c.mu.RUnlock()
+	if marker, ok := store.(generationMarker); ok {
+		return marker.SetGenerating(generating)
+	}
+	return nil
+}
+
+// Version increases every time a block in the chunk changes, so receivers of
+// chunk summaries can skip chunks they are already up to date on. Each load
+// starts from a fresh base above any version the chunk had before, so a
+// version is never reused for different contents.
+func (c *Chunk) Version() uint64 {
+	return c.version.Load()
 }
 
 func (c *Chunk) columnIndex(localX, localY int) int {
@@ -94,7 +215,7 @@ func (c *Chunk) LocalBlock(localX, localY, localZ int) (Block, bool) {
 	}
 	column, ok, err := store.LoadColumn(idx)
 	if err != nil {
-		log.Printf("chunk %v load column %d: %v", c.Key, idx, err)
+		c.logger.Warnf("chunk %v load column %d: %v", c.Key, idx, err)
 		return Block{}, false
 	}
 	if !ok || localZ >= len(column) || blockIsAir(column[localZ]) {
@@ -103,45 +224,119 @@ func (c *Chunk) LocalBlock(localX, localY, localZ int) (Block, bool) {
 	return column[localZ], true
 }
 
-func (c *Chunk) SetLocalBlock(localX, localY, localZ int, block Block) bool {
-	if localX < 0 || localY < 0 || localZ < 0 ||
-		localX >= c.dimension.Width || localY >= c.dimension.Depth || localZ >= c.dimension.Height {
-		return false
+// ColumnRuns returns the column at (localX, localY) from z=0 to the chunk's
+// height, run-length encoded the way columns are stored. Air reads the same
+// as LocalBlock returns it. ok is false when the column is outside the chunk
+// or cannot be loaded.
+func (c *Chunk) ColumnRuns(localX, localY int) ([]ColumnRun, bool) {
+	if localX < 0 || localY < 0 || localX >= c.dimension.Width || localY >= c.dimension.Depth {
+		return nil, false
 	}
-	idx := c.columnIndex(localX, localY)
-	c.mu.Lock()
+	c.mu.RLock()
 	store := c.store
-	c.mu.Unlock()
+	c.mu.RUnlock()
 	if store == nil {
-		return false
+		return nil, false
 	}
-	column, ok, err := store.LoadColumn(idx)
+	column, _, err := store.LoadColumn(c.columnIndex(localX, localY))
 	if err != nil {
-		log.Printf("chunk %v load column %d: %v", c.Key, idx, err)
-		return false
+		c.logger.Warnf("chunk %v load column %d: %v", c.Key, c.columnIndex(localX, localY), err)
+		return nil, false
 	}
-	if !ok {
-		column = make([]Block, localZ+1)
-	} else if localZ >= len(column) {
-		expanded := make([]Block, localZ+1)
-		copy(expanded, column)
-		column = expanded
+	blocks := make([]Block, c.dimension.Height)
+	for z := range blocks {
+		if z < len(column) && !blockIsAir(column[z]) {
+			blocks[z] = column[z]
+		} else {
+			blocks[z] = Block{Type: BlockAir}
+		}
 	}
-	if blockIsAir(block) {
-		column[localZ] = Block{}
-	} else {
-		column[localZ] = block
+	return compressColumn(blocks), true
+}
+
+func (c *Chunk) SetLocalBlock(localX, localY, localZ int, block Block) bool {
+	return c.SetLocalBlocks([]LocalBlockEdit{{X: localX, Y: localY, Z: localZ, Block: block}})
+}
+
+// LocalBlockEdit places Block at a chunk-local coordinate. An air or zero
+// Block clears the cell.
+type LocalBlockEdit struct {
+	X, Y, Z int
+	Block   Block
+}
+
+// SetLocalBlocks applies edits grouped by column: each touched column is
+// loaded once, takes all of its edits in order, and is saved once. Nothing is
+// written when any edit lies outside the chunk. It reports false if a column
+// fails to load or persist; columns written before the failure keep their
+// edits.
+func (c *Chunk) SetLocalBlocks(edits []LocalBlockEdit) bool {
+	if len(edits) == 0 {
+		return true
 	}
-	column = trimColumn(column)
-	if len(column) == 0 {
-		err = store.Delete(idx)
-	} else {
-		err = store.SaveColumn(idx, column)
+	for _, edit := range edits {
+		if edit.X < 0 || edit.Y < 0 || edit.Z < 0 ||
+			edit.X >= c.dimension.Width || edit.Y >= c.dimension.Depth || edit.Z >= c.dimension.Height {
+			return false
+		}
 	}
-	if err != nil {
-		log.Printf("chunk %v persist column %d: %v", c.Key, idx, err)
+	// The read lock is held across the writes so detach waits for them.
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	store := c.store
+	if store == nil {
 		return false
 	}
+
+	order := make([]int, 0, 1)
+	byColumn := make(map[int][]LocalBlockEdit)
+	for _, edit := range edits {
+		idx := c.columnIndex(edit.X, edit.Y)
+		if _, ok := byColumn[idx]; !ok {
+			order = append(order, idx)
+		}
+		byColumn[idx] = append(byColumn[idx], edit)
+	}
+
+	written := 0
+	defer func() {
+		if written > 0 {
+			c.version.Add(1)
+		}
+	}()
+	for _, idx := range order {
+		column, ok, err := store.LoadColumn(idx)
+		if err != nil {
+			c.logger.Warnf("chunk %v load column %d: %v", c.Key, idx, err)
+			return false
+		}
+		if !ok {
+			column = nil
+		}
+		for _, edit := range byColumn[idx] {
+			if edit.Z >= len(column) {
+				expanded := make([]Block, edit.Z+1)
+				copy(expanded, column)
+				column = expanded
+			}
+			if blockIsAir(edit.Block) {
+				column[edit.Z] = Block{}
+			} else {
+				column[edit.Z] = edit.Block
+			}
+		}
+		column = trimColumn(column)
+		if len(column) == 0 {
+			err = store.Delete(idx)
+		} else {
+			err = store.SaveColumn(idx, column)
+		}
+		if err != nil {
+			c.logger.Warnf("chunk %v persist column %d: %v", c.Key, idx, err)
+			return false
+		}
+		written++
+	}
 	return true
 }
 
@@ -179,8 +374,135 @@ func (c *Chunk) ForEachBlock(fn func(global BlockCoord, block Block) bool) {
 		}
 		return true
 	}); err != nil {
-		log.Printf("chunk %v iterate blocks: %v", c.Key, err)
+		c.logger.Warnf("chunk %v iterate blocks: %v", c.Key, err)
+	}
+}
+
+// ForEachBlockInBounds iterates over the non-air blocks inside the inclusive
+// global box from min to max, clamped to the chunk, invoking fn with global
+// coordinates until it returns false. Only columns overlapping the box are
+// loaded from storage.
+func (c *Chunk) ForEachBlockInBounds(min, max BlockCoord, fn func(global BlockCoord, block Block) bool) {
+	c.mu.RLock()
+	store := c.store
+	bounds := c.Bounds
+	dim := c.dimension
+	c.mu.RUnlock()
+
+	if store == nil {
+		return
+	}
+
+	minX, maxX := min.X-bounds.Min.X, max.X-bounds.Min.X
+	minY, maxY := min.Y-bounds.Min.Y, max.Y-bounds.Min.Y
+	minZ, maxZ := min.Z-bounds.Min.Z, max.Z-bounds.Min.Z
+	if maxX < 0 || maxY < 0 || maxZ < 0 ||
+		minX >= dim.Width || minY >= dim.Depth || minZ >= dim.Height {
+		return
+	}
+	minX, maxX = clampLocal(minX, dim.Width), clampLocal(maxX, dim.Width)
+	minY, maxY = clampLocal(minY, dim.Depth), clampLocal(maxY, dim.Depth)
+	minZ, maxZ = clampLocal(minZ, dim.Height), clampLocal(maxZ, dim.Height)
+
+	for localY := minY; localY <= maxY; localY++ {
+		for localX := minX; localX <= maxX; localX++ {
+			idx := c.columnIndex(localX, localY)
+			column, ok, err := store.LoadColumn(idx)
+			if err != nil {
+				c.logger.Warnf("chunk %v load column %d: %v", c.Key, idx, err)
+				continue
+			}
+			if !ok {
+				continue
+			}
+			for localZ := minZ; localZ <= maxZ && localZ < len(column); localZ++ {
+				block := column[localZ]
+				if blockIsAir(block) {
+					continue
+				}
+				global := BlockCoord{
+					X: bounds.Min.X + localX,
+					Y: bounds.Min.Y + localY,
+					Z: bounds.Min.Z + localZ,
+				}
+				if !fn(global, block) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// clampLocal clamps a local offset into [0, size).
+func clampLocal(offset, size int) int {
+	if offset < 0 {
+		return 0
+	}
+	if offset >= size {
+		return size - 1
+	}
+	return offset
+}
+
+// Census counts the chunk's non-air blocks by type. The result is cached until
+// the chunk's version changes, and callers may modify the returned map.
+func (c *Chunk) Census() BlockCensus {
+	c.censusMu.Lock()
+	defer c.censusMu.Unlock()
+	version := c.Version()
+	if c.censusVersion != version || c.census.ByType == nil {
+		census := BlockCensus{ByType: make(map[BlockType]int)}
+		c.ForEachBlock(func(_ BlockCoord, block Block) bool {
+			census.ByType[block.Type]++
+			census.Total++
+			return true
+		})
+		c.census = census
+		c.censusVersion = version
+	}
+	out := BlockCensus{ByType: make(map[BlockType]int, len(c.census.ByType)), Total: c.census.Total}
+	for blockType, n := range c.census.ByType {
+		out.ByType[blockType] = n
+	}
+	return out
+}
+
+// surfaceLocalZ returns the local Z of the highest non-air block in a column.
+// ok is false for an empty or unreadable column. Results are cached until the
+// chunk's version changes.
+func (c *Chunk) surfaceLocalZ(localX, localY int) (int, bool) {
+	if localX < 0 || localY < 0 || localX >= c.dimension.Width || localY >= c.dimension.Depth {
+		return 0, false
+	}
+	idx := c.columnIndex(localX, localY)
+	c.surfaceMu.Lock()
+	defer c.surfaceMu.Unlock()
+	version := c.Version()
+	if c.surfaceVersion != version || c.surface == nil {
+		c.surface = make(map[int]int)
+		c.surfaceVersion = version
 	}
+	if z, ok := c.surface[idx]; ok {
+		return z, z >= 0
+	}
+
+	c.mu.RLock()
+	store := c.store
+	c.mu.RUnlock()
+	if store == nil {
+		return 0, false
+	}
+	column, _, err := store.LoadColumn(idx)
+	if err != nil {
+		c.logger.Warnf("chunk %v load column %d: %v", c.Key, idx, err)
+		return 0, false
+	}
+	z := len(column) - 1
+	for z >= 0 && blockIsAir(column[z]) {
+		z--
+	}
+	c.surface[idx] = z
+	return z, z >= 0
 }
 
 func (c *Chunk) Dimensions() Dimensions {
@@ -206,13 +528,15 @@ func (c *Chunk) HasStoredBlocks() bool {
 		}
 		return true
 	}); err != nil {
-		log.Printf("chunk %v check stored blocks: %v", c.Key, err)
+		c.logger.Warnf("chunk %v check stored blocks: %v", c.Key, err)
 	}
 	return hasBlocks
 }
 
+// EvaluateColumnStability evaluates a column with DefaultStabilityParams. Use
+// Manager.EvaluateColumnStability to honour a manager's configured parameters.
 func (c *Chunk) EvaluateColumnStability(localX, localY int) ([]StabilityReport, error) {
-	return evaluateColumnStability(c, localX, localY)
+	return evaluateColumnStability(c, localX, localY, DefaultStabilityParams())
 }
 
 func (c *Chunk) DamageLocalBlock(localX, localY, localZ int, amount float64) (Block, bool) {
@@ -231,7 +555,7 @@ func (c *Chunk) DamageLocalBlock(localX, localY, localZ int, amount float64) (Bl
 	}
 	column, ok, err := c.store.LoadColumn(idx)
 	if err != nil {
-		log.Printf("chunk %v load column %d: %v", c.Key, idx, err)
+		c.logger.Warnf("chunk %v load column %d: %v", c.Key, idx, err)
 		return Block{}, false
 	}
 	if !ok || localZ >= len(column) || blockIsAir(column[localZ]) {
@@ -249,9 +573,10 @@ func (c *Chunk) DamageLocalBlock(localX, localY, localZ int, amount float64) (Bl
 			saveErr = c.store.SaveColumn(idx, column)
 		}
 		if saveErr != nil {
-			log.Printf("chunk %v persist column %d: %v", c.Key, idx, saveErr)
+			c.logger.Warnf("chunk %v persist column %d: %v", c.Key, idx, saveErr)
 			return Block{}, false
 		}
+		c.version.Add(1)
 		return Block{Type: BlockAir}, true
 	}
 	if block.MaxHitPoints > 0 && block.HitPoints > block.MaxHitPoints {
@@ -259,9 +584,52 @@ func (c *Chunk) DamageLocalBlock(localX, localY, localZ int, amount float64) (Bl
 	}
 	column[localZ] = block
 	if err := c.store.SaveColumn(idx, trimColumn(column)); err != nil {
-		log.Printf("chunk %v save column %d: %v", c.Key, idx, err)
+		c.logger.Warnf("chunk %v save column %d: %v", c.Key, idx, err)
+		return Block{}, false
+	}
+	c.version.Add(1)
+	return block, true
+}
+
+// HealLocalBlock restores up to amount hit points to the block at the given
+// local coordinates, never past its MaxHitPoints. Air, blocks without a
+// maximum, and blocks already at full health are left alone and report false.
+func (c *Chunk) HealLocalBlock(localX, localY, localZ int, amount float64) (Block, bool) {
+	if amount <= 0 {
+		return Block{}, false
+	}
+	if localX < 0 || localY < 0 || localZ < 0 ||
+		localX >= c.dimension.Width || localY >= c.dimension.Depth || localZ >= c.dimension.Height {
+		return Block{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	idx := c.columnIndex(localX, localY)
+	if c.store == nil {
+		return Block{}, false
+	}
+	column, ok, err := c.store.LoadColumn(idx)
+	if err != nil {
+		c.logger.Warnf("chunk %v load column %d: %v", c.Key, idx, err)
+		return Block{}, false
+	}
+	if !ok || localZ >= len(column) || blockIsAir(column[localZ]) {
+		return Block{}, false
+	}
+	block := column[localZ]
+	if block.MaxHitPoints <= 0 || block.HitPoints >= block.MaxHitPoints {
+		return Block{}, false
+	}
+	block.HitPoints += amount
+	if block.HitPoints > block.MaxHitPoints {
+		block.HitPoints = block.MaxHitPoints
+	}
+	column[localZ] = block
+	if err := c.store.SaveColumn(idx, trimColumn(column)); err != nil {
+		c.logger.Warnf("chunk %v save column %d: %v", c.Key, idx, err)
 		return Block{}, false
 	}
+	c.version.Add(1)
 	return block, true
 }
 
@@ -274,9 +642,9 @@ func (c *Chunk) SetColumnBlocks(localX, localY int, blocks []Block) bool {
 	column := make([]Block, len(blocks))
 	copy(column, blocks)
 	column = trimColumn(column)
-	c.mu.Lock()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	store := c.store
-	c.mu.Unlock()
 	if store == nil {
 		return false
 	}
@@ -287,12 +655,52 @@ func (c *Chunk) SetColumnBlocks(localX, localY int, blocks []Block) bool {
 		err = store.SaveColumn(idx, column)
 	}
 	if err != nil {
-		log.Printf("chunk %v persist column %d: %v", c.Key, idx, err)
+		c.logger.Warnf("chunk %v persist column %d: %v", c.Key, idx, err)
 		return false
 	}
+	c.version.Add(1)
 	return true
 }
 
+// Snapshot commits the chunk's storage to durable media. Storage that has
+// nothing to commit is a no-op.
+func (c *Chunk) Snapshot() error {
+	c.mu.RLock()
+	store := c.store
+	c.mu.RUnlock()
+	if snap, ok := store.(snapshotter); ok {
+		return snap.Snapshot()
+	}
+	return nil
+}
+
+// persistent reports whether the chunk's storage outlives it, so the chunk
+// can be dropped and loaded again without losing its blocks.
+func (c *Chunk) persistent() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.store.(snapshotter)
+	return ok
+}
+
+// detach takes the chunk's storage away once writes in progress have
+// finished; later reads and writes fail instead of reaching storage that is
+// about to be closed.
+func (c *Chunk) detach() BlockStorage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	store := c.store
+	c.store = nil
+	return store
+}
+
+// attach gives the chunk back storage taken by detach.
+func (c *Chunk) attach(store BlockStorage) {
+	c.mu.Lock()
+	c.store = store
+	c.mu.Unlock()
+}
+
 // Close releases any resources held by the chunk's underlying storage.
 func (c *Chunk) Close() error {
 	c.mu.Lock()
@@ -0,0 +1,103 @@
+package world
+
+// ChunksEqual reports whether a and b hold the same blocks at the same local
+// positions. Air and absent blocks compare equal, so a column that merely
+// stores trailing air does not make two chunks differ. Chunks of different
+// dimensions are never equal.
+func ChunksEqual(a, b *Chunk) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Dimensions() != b.Dimensions() {
+		return false
+	}
+	equal := true
+	compareChunkColumns(a, b, func(localX, localY, localZ int, before, after Block) bool {
+		equal = false
+		return false
+	})
+	return equal
+}
+
+// ChunkDiff lists the blocks that differ between a and b as changes from a to
+// b, ordered by column and then height. Coordinates are global to a, so the
+// diff reads as the edits that would turn a into b; Reason is left empty.
+// Positions outside the smaller of two differently sized chunks compare
+// against air.
+func ChunkDiff(a, b *Chunk) []BlockChange {
+	if a == nil || b == nil {
+		return nil
+	}
+	var changes []BlockChange
+	compareChunkColumns(a, b, func(localX, localY, localZ int, before, after Block) bool {
+		changes = append(changes, BlockChange{
+			Coord: BlockCoord{
+				X: a.Bounds.Min.X + localX,
+				Y: a.Bounds.Min.Y + localY,
+				Z: a.Bounds.Min.Z + localZ,
+			},
+			Before: before,
+			After:  after,
+		})
+		return true
+	})
+	return changes
+}
+
+// compareChunkColumns loads each column of a and b once and calls fn for every
+// height where they differ, until fn returns false.
+func compareChunkColumns(a, b *Chunk, fn func(localX, localY, localZ int, before, after Block) bool) {
+	dimA, dimB := a.Dimensions(), b.Dimensions()
+	width := max(dimA.Width, dimB.Width)
+	depth := max(dimA.Depth, dimB.Depth)
+	for localY := 0; localY < depth; localY++ {
+		for localX := 0; localX < width; localX++ {
+			colA := a.columnBlocks(localX, localY)
+			colB := b.columnBlocks(localX, localY)
+			for localZ := 0; localZ < max(len(colA), len(colB)); localZ++ {
+				before := columnBlockAt(colA, localZ)
+				after := columnBlockAt(colB, localZ)
+				if blockIsAir(before) && blockIsAir(after) {
+					continue
+				}
+				if blocksEqual(before, after) {
+					continue
+				}
+				if !fn(localX, localY, localZ, before, after) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// columnBlocks returns the stored column at the local coordinates, or nil when
+// it is empty or outside the chunk.
+func (c *Chunk) columnBlocks(localX, localY int) []Block {
+	if localX < 0 || localY < 0 || localX >= c.dimension.Width || localY >= c.dimension.Depth {
+		return nil
+	}
+	c.mu.RLock()
+	store := c.store
+	c.mu.RUnlock()
+	if store == nil {
+		return nil
+	}
+	idx := c.columnIndex(localX, localY)
+	column, ok, err := store.LoadColumn(idx)
+	if err != nil {
+		c.logger.Warnf("chunk %v load column %d: %v", c.Key, idx, err)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+	return column
+}
+
+func columnBlockAt(column []Block, localZ int) Block {
+	if localZ >= len(column) || blockIsAir(column[localZ]) {
+		return Block{Type: BlockAir}
+	}
+	return column[localZ]
+}
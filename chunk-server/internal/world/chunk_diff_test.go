@@ -0,0 +1,64 @@
+package world
+
+import "testing"
+
+func newDiffChunk(t *testing.T) *Chunk {
+	t.Helper()
+	dim := Dimensions{Width: 3, Depth: 3, Height: 4}
+	bounds := Bounds{Min: BlockCoord{X: 30, Y: 60, Z: 0}, Max: BlockCoord{X: 32, Y: 62, Z: 3}}
+	chunk := NewChunk(ChunkCoord{X: 10, Y: 20}, bounds, dim)
+	for x := 0; x < dim.Width; x++ {
+		for y := 0; y < dim.Depth; y++ {
+			chunk.SetColumnBlocks(x, y, []Block{
+				{Type: BlockSolid, HitPoints: 100, Metadata: map[string]any{"layer": "stone"}},
+				{Type: BlockMineral, ResourceYield: map[string]float64{"iron": 1}},
+			})
+		}
+	}
+	return chunk
+}
+
+func TestChunksEqualForIdenticalChunks(t *testing.T) {
+	a, b := newDiffChunk(t), newDiffChunk(t)
+	// Trailing air stored in one chunk only does not count as a difference.
+	b.SetColumnBlocks(0, 0, []Block{
+		{Type: BlockSolid, HitPoints: 100, Metadata: map[string]any{"layer": "stone"}},
+		{Type: BlockMineral, ResourceYield: map[string]float64{"iron": 1}},
+		{Type: BlockAir},
+	})
+
+	if !ChunksEqual(a, b) {
+		t.Fatal("expected identical chunks to be equal")
+	}
+	if diff := ChunkDiff(a, b); len(diff) != 0 {
+		t.Fatalf("expected empty diff, got %+v", diff)
+	}
+}
+
+func TestChunkDiffReportsSingleChangedBlock(t *testing.T) {
+	a, b := newDiffChunk(t), newDiffChunk(t)
+	changed := Block{Type: BlockMineral, ResourceYield: map[string]float64{"iron": 2}}
+	b.SetLocalBlock(2, 1, 1, changed)
+
+	if ChunksEqual(a, b) {
+		t.Fatal("expected chunks with a differing block not to be equal")
+	}
+	diff := ChunkDiff(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected one differing block, got %+v", diff)
+	}
+	if want := (BlockCoord{X: 32, Y: 61, Z: 1}); diff[0].Coord != want {
+		t.Fatalf("expected change at %v, got %v", want, diff[0].Coord)
+	}
+	if diff[0].Before.ResourceYield["iron"] != 1 || diff[0].After.ResourceYield["iron"] != 2 {
+		t.Fatalf("expected change from a's block to b's, got %+v", diff[0])
+	}
+}
+
+func TestChunksEqualRejectsDifferentDimensions(t *testing.T) {
+	a := newDiffChunk(t)
+	b := NewChunk(a.Key, a.Bounds, Dimensions{Width: 2, Depth: 3, Height: 4})
+	if ChunksEqual(a, b) {
+		t.Fatal("expected chunks of different dimensions not to be equal")
+	}
+}
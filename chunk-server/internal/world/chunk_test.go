@@ -1,21 +1,18 @@
 package world
 
-import "testing"
+import (
+	"context"
+	"testing"
+)
 
 func TestChunkHasStoredBlocks(t *testing.T) {
-	original := getStorageProvider()
-	SetStorageProvider(newMemoryStorageProvider())
-	t.Cleanup(func() {
-		SetStorageProvider(original)
-	})
-
 	dim := Dimensions{Width: 2, Depth: 2, Height: 4}
 	bounds := Bounds{
 		Min: BlockCoord{X: 0, Y: 0, Z: 0},
 		Max: BlockCoord{X: 1, Y: 1, Z: 3},
 	}
 
-	chunk := NewChunk(ChunkCoord{X: 0, Y: 0}, bounds, dim)
+	chunk := NewChunkWithStorage(ChunkCoord{X: 0, Y: 0}, bounds, dim, newMemoryStorageProvider())
 	if chunk.HasStoredBlocks() {
 		t.Fatalf("expected no stored blocks for fresh chunk")
 	}
@@ -28,3 +25,342 @@ func TestChunkHasStoredBlocks(t *testing.T) {
 		t.Fatalf("expected chunk to report stored blocks after persistence")
 	}
 }
+
+func TestChunkVersionAdvancesOnBlockChanges(t *testing.T) {
+	dim := Dimensions{Width: 2, Depth: 2, Height: 4}
+	bounds := Bounds{
+		Min: BlockCoord{X: 0, Y: 0, Z: 0},
+		Max: BlockCoord{X: 1, Y: 1, Z: 3},
+	}
+	chunk := NewChunkWithStorage(ChunkCoord{X: 0, Y: 0}, bounds, dim, newMemoryStorageProvider())
+
+	version := chunk.Version()
+	if version == 0 {
+		t.Fatalf("expected a fresh chunk to start at a non-zero version")
+	}
+	expectBump := func(what string, changed bool) {
+		t.Helper()
+		if !changed {
+			t.Fatalf("%s did not change the chunk", what)
+		}
+		next := chunk.Version()
+		if next <= version {
+			t.Fatalf("%s left version at %d, want > %d", what, next, version)
+		}
+		version = next
+	}
+
+	expectBump("SetColumnBlocks", chunk.SetColumnBlocks(0, 0, []Block{{Type: BlockSolid, HitPoints: 10}}))
+	expectBump("SetLocalBlock", chunk.SetLocalBlock(1, 1, 2, Block{Type: BlockSolid, HitPoints: 10}))
+	_, damaged := chunk.DamageLocalBlock(0, 0, 0, 4)
+	expectBump("DamageLocalBlock", damaged)
+	_, destroyed := chunk.DamageLocalBlock(0, 0, 0, 20)
+	expectBump("destroying DamageLocalBlock", destroyed)
+
+	if _, ok := chunk.LocalBlock(1, 1, 2); !ok {
+		t.Fatalf("read block failed")
+	}
+	chunk.ForEachBlock(func(BlockCoord, Block) bool { return true })
+	if _, changed := chunk.DamageLocalBlock(0, 0, 0, 5); changed {
+		t.Fatalf("damaging air should not change the chunk")
+	}
+	if got := chunk.Version(); got != version {
+		t.Fatalf("reads and no-op damage moved version from %d to %d", version, got)
+	}
+}
+
+// countingStorageProvider hands out memory storage that records which columns
+// are loaded.
+type countingStorageProvider struct {
+	storage *countingStorage
+}
+
+func (p *countingStorageProvider) NewStorage(key ChunkCoord, bounds Bounds, dim Dimensions) (BlockStorage, error) {
+	inner, err := newMemoryStorageProvider().NewStorage(key, bounds, dim)
+	if err != nil {
+		return nil, err
+	}
+	p.storage = &countingStorage{BlockStorage: inner, loads: make(map[int]int)}
+	return p.storage, nil
+}
+
+type countingStorage struct {
+	BlockStorage
+	loads    map[int]int
+	saves    map[int]int
+	forEachs int
+}
+
+func (s *countingStorage) SaveColumn(index int, blocks []Block) error {
+	if s.saves == nil {
+		s.saves = make(map[int]int)
+	}
+	s.saves[index]++
+	return s.BlockStorage.SaveColumn(index, blocks)
+}
+
+func (s *countingStorage) LoadColumn(index int) ([]Block, bool, error) {
+	s.loads[index]++
+	return s.BlockStorage.LoadColumn(index)
+}
+
+func (s *countingStorage) ForEach(fn func(index int, blocks []Block) bool) error {
+	s.forEachs++
+	return s.BlockStorage.ForEach(fn)
+}
+
+func TestChunkForEachBlockInBoundsVisitsOnlyTheBox(t *testing.T) {
+	provider := &countingStorageProvider{}
+
+	dim := Dimensions{Width: 4, Depth: 4, Height: 4}
+	bounds := Bounds{
+		Min: BlockCoord{X: 8, Y: 12, Z: 0},
+		Max: BlockCoord{X: 11, Y: 15, Z: 3},
+	}
+	chunk := NewChunkWithStorage(ChunkCoord{X: 2, Y: 3}, bounds, dim, provider)
+	for x := 0; x < dim.Width; x++ {
+		for y := 0; y < dim.Depth; y++ {
+			column := make([]Block, dim.Height)
+			for z := range column {
+				column[z] = Block{Type: BlockSolid}
+			}
+			if !chunk.SetColumnBlocks(x, y, column) {
+				t.Fatalf("set column %d,%d failed", x, y)
+			}
+		}
+	}
+	storage := provider.storage
+	storage.loads = make(map[int]int)
+	storage.forEachs = 0
+
+	// The box starts outside the chunk on X and covers local x 0..1, y 1..2, z 2..3.
+	visited := make(map[BlockCoord]bool)
+	chunk.ForEachBlockInBounds(BlockCoord{X: 5, Y: 13, Z: 2}, BlockCoord{X: 9, Y: 14, Z: 9}, func(global BlockCoord, block Block) bool {
+		if visited[global] {
+			t.Fatalf("visited %v twice", global)
+		}
+		visited[global] = true
+		return true
+	})
+
+	if len(visited) != 2*2*2 {
+		t.Fatalf("visited %d blocks, want 8: %v", len(visited), visited)
+	}
+	for coord := range visited {
+		if coord.X < 8 || coord.X > 9 || coord.Y < 13 || coord.Y > 14 || coord.Z < 2 || coord.Z > 3 {
+			t.Fatalf("visited %v outside the box", coord)
+		}
+	}
+	if storage.forEachs != 0 {
+		t.Fatalf("expected no full-chunk scans, got %d", storage.forEachs)
+	}
+	if len(storage.loads) != 4 {
+		t.Fatalf("loaded %d columns, want the 4 overlapping the box: %v", len(storage.loads), storage.loads)
+	}
+	for idx := range storage.loads {
+		localX, localY := idx%dim.Width, idx/dim.Width
+		if localX > 1 || localY < 1 || localY > 2 {
+			t.Fatalf("loaded column %d,%d outside the box", localX, localY)
+		}
+	}
+
+	storage.loads = make(map[int]int)
+	chunk.ForEachBlockInBounds(BlockCoord{X: 20, Y: 12, Z: 0}, BlockCoord{X: 30, Y: 15, Z: 3}, func(BlockCoord, Block) bool {
+		t.Fatalf("visited a block for a box outside the chunk")
+		return false
+	})
+	if len(storage.loads) != 0 {
+		t.Fatalf("loaded columns for a box outside the chunk: %v", storage.loads)
+	}
+}
+
+func TestChunkCensusMatchesBlocksAndTracksChanges(t *testing.T) {
+	chdirTemp(t)
+	ore := ChunkMutatorFunc(func(_ context.Context, chunk *Chunk, _ Bounds, _ Dimensions) error {
+		chunk.SetLocalBlock(1, 1, 1, Block{Type: BlockMineral})
+		chunk.SetLocalBlock(2, 2, 1, Block{Type: BlockMineral})
+		chunk.SetLocalBlock(3, 3, 2, Block{Type: BlockExplosive})
+		return nil
+	})
+	manager := NewManager(ServerRegion{
+		ChunksX:        1,
+		ChunksY:        1,
+		ChunkDimension: Dimensions{Width: 4, Depth: 4, Height: 4},
+	}, NewGeneratorChain(floorGenerator{}, ore))
+	chunk, err := manager.Chunk(context.Background(), ChunkCoord{})
+	if err != nil {
+		t.Fatalf("load chunk: %v", err)
+	}
+
+	manual := make(map[BlockType]int)
+	total := 0
+	chunk.ForEachBlock(func(_ BlockCoord, block Block) bool {
+		manual[block.Type]++
+		total++
+		return true
+	})
+
+	census := chunk.Census()
+	if census.Total != total || census.Total != 16+3 {
+		t.Fatalf("census total %d, manual count %d, want 19", census.Total, total)
+	}
+	for blockType, n := range manual {
+		if census.ByType[blockType] != n {
+			t.Fatalf("census has %d %q blocks, manual count %d", census.ByType[blockType], blockType, n)
+		}
+	}
+	if len(census.ByType) != len(manual) {
+		t.Fatalf("census types %v, manual %v", census.ByType, manual)
+	}
+
+	census.ByType[BlockSolid] = 0
+	if chunk.Census().ByType[BlockSolid] != 16 {
+		t.Fatalf("modifying a returned census changed the cached one")
+	}
+
+	if !chunk.ClearLocalBlock(1, 1, 1) {
+		t.Fatalf("clear block failed")
+	}
+	after := chunk.Census()
+	if after.Total != 18 || after.ByType[BlockMineral] != 1 {
+		t.Fatalf("expected census to drop the cleared mineral, got %+v", after)
+	}
+}
+
+func TestChunkSetLocalBlocksSavesEachColumnOnce(t *testing.T) {
+	provider := &countingStorageProvider{}
+
+	dim := Dimensions{Width: 4, Depth: 4, Height: 8}
+	bounds := Bounds{Min: BlockCoord{}, Max: BlockCoord{X: 3, Y: 3, Z: 7}}
+	chunk := NewChunkWithStorage(ChunkCoord{}, bounds, dim, provider)
+	if !chunk.SetColumnBlocks(1, 2, []Block{{Type: BlockSolid}, {Type: BlockSolid}, {Type: BlockSolid}}) {
+		t.Fatal("seed column failed")
+	}
+	storage := provider.storage
+	storage.loads = make(map[int]int)
+	storage.saves = make(map[int]int)
+	version := chunk.Version()
+
+	edits := []LocalBlockEdit{
+		{X: 1, Y: 2, Z: 5, Block: Block{Type: BlockSolid, Material: "wood"}},
+		{X: 3, Y: 0, Z: 0, Block: Block{Type: BlockSolid, Material: "stone"}},
+		{X: 1, Y: 2, Z: 1},
+		{X: 3, Y: 0, Z: 2, Block: Block{Type: BlockSolid, Material: "stone"}},
+		{X: 3, Y: 0, Z: 2, Block: Block{Type: BlockSolid, Material: "ore"}},
+	}
+	if !chunk.SetLocalBlocks(edits) {
+		t.Fatal("SetLocalBlocks failed")
+	}
+
+	if len(storage.saves) != 2 {
+		t.Fatalf("saved %d columns, want 2: %v", len(storage.saves), storage.saves)
+	}
+	for idx, n := range storage.saves {
+		if n != 1 || storage.loads[idx] != 1 {
+			t.Fatalf("column %d loaded %d and saved %d times, want once each", idx, storage.loads[idx], n)
+		}
+	}
+	if chunk.Version() != version+1 {
+		t.Fatalf("version = %d, want one bump from %d", chunk.Version(), version)
+	}
+
+	for _, tc := range []struct {
+		x, y, z  int
+		typ      BlockType
+		material string
+	}{
+		{1, 2, 0, BlockSolid, ""},
+		{1, 2, 1, BlockAir, ""},
+		{1, 2, 5, BlockSolid, "wood"},
+		{3, 0, 0, BlockSolid, "stone"},
+		{3, 0, 1, BlockAir, ""},
+		{3, 0, 2, BlockSolid, "ore"},
+	} {
+		block, ok := chunk.LocalBlock(tc.x, tc.y, tc.z)
+		if !ok || block.Type != tc.typ || block.Material != tc.material {
+			t.Fatalf("block (%d,%d,%d) = %+v, want %s %q", tc.x, tc.y, tc.z, block, tc.typ, tc.material)
+		}
+	}
+
+	storage.saves = make(map[int]int)
+	if chunk.SetLocalBlocks([]LocalBlockEdit{{X: 0, Y: 0, Z: 0, Block: Block{Type: BlockSolid}}, {X: 4, Y: 0, Z: 0}}) {
+		t.Fatal("expected an out-of-bounds edit to reject the batch")
+	}
+	if len(storage.saves) != 0 {
+		t.Fatalf("expected a rejected batch to write nothing, saved %v", storage.saves)
+	}
+}
+
+func TestChunkFingerprintIgnoresStorageAndMatchesContent(t *testing.T) {
+	dim := Dimensions{Width: 4, Depth: 4, Height: 6}
+	bounds := Bounds{Max: BlockCoord{X: 3, Y: 3, Z: 5}}
+	blockAt := func(x, y, z int) Block {
+		switch {
+		case z == 0:
+			return Block{Type: BlockSolid, HitPoints: 40, MaxHitPoints: 40}
+		case z < 3 && (x+y)%2 == 0:
+			return Block{Type: BlockMineral, Material: "iron", ResourceYield: map[string]float64{"iron": 2, "steel": 1}, Metadata: map[string]any{"vein": 3}}
+		default:
+			return Block{}
+		}
+	}
+	fill := func(chunk *Chunk, reverse bool) {
+		for i := 0; i < dim.Width*dim.Depth; i++ {
+			idx := i
+			if reverse {
+				idx = dim.Width*dim.Depth - 1 - i
+			}
+			x, y := idx%dim.Width, idx/dim.Width
+			column := make([]Block, dim.Height)
+			for z := range column {
+				column[z] = blockAt(x, y, z)
+			}
+			if !chunk.SetColumnBlocks(x, y, column) {
+				t.Fatalf("set column %d,%d failed", x, y)
+			}
+		}
+	}
+
+	memory := NewChunkWithStorage(ChunkCoord{}, bounds, dim, newMemoryStorageProvider())
+	fill(memory, false)
+	disk := NewChunkWithStorage(ChunkCoord{}, bounds, dim, NewDiskStorageProvider(t.TempDir(), ServerRegion{ChunksX: 1, ChunksY: 1, ChunkDimension: dim}))
+	fill(disk, true)
+	// Air written over an empty cell is not content.
+	disk.SetLocalBlock(1, 1, 5, Block{Type: BlockAir, Material: "vacuum"})
+
+	want := memory.Fingerprint()
+	if got := disk.Fingerprint(); got != want {
+		t.Fatalf("disk chunk filled in reverse has fingerprint %x, memory chunk %x", got, want)
+	}
+	if empty := NewChunkWithStorage(ChunkCoord{}, bounds, dim, newMemoryStorageProvider()); empty.Fingerprint() == want {
+		t.Fatalf("empty chunk shares the filled chunk's fingerprint %x", want)
+	}
+
+	original := blockAt(1, 3, 1)
+	changed := original
+	changed.HitPoints = 1
+	disk.SetLocalBlock(1, 3, 1, changed)
+	if got := disk.Fingerprint(); got == want {
+		t.Fatalf("fingerprint %x unchanged after a block's hit points changed", got)
+	}
+	disk.SetLocalBlock(1, 3, 1, original)
+	if got := disk.Fingerprint(); got != want {
+		t.Fatalf("fingerprint %x after restoring the block, want %x", got, want)
+	}
+}
+
+func TestChunkFingerprintIsCachedUntilTheVersionChanges(t *testing.T) {
+	provider := &countingStorageProvider{}
+	dim := Dimensions{Width: 2, Depth: 2, Height: 2}
+	chunk := NewChunkWithStorage(ChunkCoord{}, Bounds{Max: BlockCoord{X: 1, Y: 1, Z: 1}}, dim, provider)
+	chunk.SetLocalBlock(0, 0, 0, Block{Type: BlockSolid})
+
+	first := chunk.Fingerprint()
+	if again := chunk.Fingerprint(); again != first || provider.storage.forEachs != 1 {
+		t.Fatalf("repeat fingerprint %x read storage %d times, want %x from one read", again, provider.storage.forEachs, first)
+	}
+	chunk.SetLocalBlock(1, 1, 0, Block{Type: BlockSolid})
+	if changed := chunk.Fingerprint(); changed == first || provider.storage.forEachs != 2 {
+		t.Fatalf("fingerprint %x after an edit read storage %d times, want a fresh hash from a second read", changed, provider.storage.forEachs)
+	}
+}
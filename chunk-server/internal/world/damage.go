@@ -6,11 +6,15 @@ const (
 	ReasonDamage   ChangeReason = "damage"
 	ReasonDestroy  ChangeReason = "destroy"
 	ReasonCollapse ChangeReason = "collapse"
+	ReasonEdit     ChangeReason = "edit"
+	ReasonRepair   ChangeReason = "repair"
 )
 
 var reasonPriority = map[ChangeReason]int{
 	ReasonDamage:   1,
+	ReasonRepair:   1,
 	ReasonDestroy:  2,
+	ReasonEdit:     2,
 	ReasonCollapse: 3,
 }
 
@@ -24,8 +28,9 @@ type BlockChange struct {
 
 // DamageSummary accumulates block mutations resulting from damage application.
 type DamageSummary struct {
-	changes map[BlockCoord]BlockChange
-	chunks  map[ChunkCoord]struct{}
+	changes  map[BlockCoord]BlockChange
+	chunks   map[ChunkCoord]struct{}
+	unstable map[BlockCoord]struct{}
 }
 
 func NewDamageSummary() *DamageSummary {
@@ -97,6 +102,25 @@ func (s *DamageSummary) CollapsedBlocks() []BlockCoord {
 	return out
 }
 
+// MarkUnstable flags a block that was placed without the support to hold it.
+func (s *DamageSummary) MarkUnstable(coord BlockCoord) {
+	if s.unstable == nil {
+		s.unstable = make(map[BlockCoord]struct{})
+	}
+	s.unstable[coord] = struct{}{}
+}
+
+func (s *DamageSummary) UnstableBlocks() []BlockCoord {
+	if len(s.unstable) == 0 {
+		return nil
+	}
+	out := make([]BlockCoord, 0, len(s.unstable))
+	for coord := range s.unstable {
+		out = append(out, coord)
+	}
+	return out
+}
+
 func (s *DamageSummary) Merge(other *DamageSummary) {
 	if other == nil {
 		return
@@ -107,6 +131,9 @@ func (s *DamageSummary) Merge(other *DamageSummary) {
 	for coord := range other.chunks {
 		s.AddChunk(coord)
 	}
+	for coord := range other.unstable {
+		s.MarkUnstable(coord)
+	}
 }
 
 func cloneBlock(block Block) Block {
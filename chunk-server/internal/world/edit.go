@@ -0,0 +1,74 @@
+package world
+
+import (
+	"context"
+	"fmt"
+)
+
+// BlockEdit places Block at Coord. An air or zero Block removes whatever is
+// there.
+type BlockEdit struct {
+	Coord BlockCoord
+	Block Block
+}
+
+// ApplyBlockEdits applies a batch of placements and removals and then runs one
+// stability cascade over every column the batch touched, so intermediate states
+// of a build are never evaluated. Edits outside the region, and removals of
+// blocks that are already air, are skipped. The summary records each edit with
+// ReasonEdit alongside any collapses the batch caused, and the batch is
+// journaled for Undo as one mutation.
+func (m *Manager) ApplyBlockEdits(ctx context.Context, edits []BlockEdit) (*DamageSummary, error) {
+	summary := NewDamageSummary()
+	columns := make([]columnRef, 0, len(edits))
+	seen := make(map[columnRef]struct{}, len(edits))
+
+	for _, edit := range edits {
+		chunkCoord, ok := m.region.LocateBlock(edit.Coord)
+		if !ok {
+			continue
+		}
+		chunk, err := m.Chunk(ctx, chunkCoord)
+		if err != nil {
+			return nil, err
+		}
+		localX, localY, localZ, ok := chunk.GlobalToLocal(edit.Coord)
+		if !ok {
+			continue
+		}
+		before, ok := chunk.LocalBlock(localX, localY, localZ)
+		if !ok {
+			continue
+		}
+		after := cloneBlock(edit.Block)
+		if blockIsAir(after) {
+			if blockIsAir(before) {
+				continue
+			}
+			after = Block{Type: BlockAir}
+		}
+		if !chunk.SetLocalBlock(localX, localY, localZ, after) {
+			return nil, fmt.Errorf("write block %v", edit.Coord)
+		}
+
+		summary.AddChange(BlockChange{
+			Coord:  edit.Coord,
+			Before: cloneBlock(before),
+			After:  after,
+			Reason: ReasonEdit,
+		})
+		summary.AddChunk(chunkCoord)
+
+		ref := columnRef{Chunk: chunkCoord, LocalX: localX, LocalY: localY}
+		if _, dup := seen[ref]; !dup {
+			seen[ref] = struct{}{}
+			columns = append(columns, ref)
+		}
+	}
+
+	if err := m.cascadeColumns(ctx, columns, summary); err != nil {
+		return nil, err
+	}
+	m.recordEdit(summary)
+	return summary, nil
+}
@@ -0,0 +1,107 @@
+package world
+
+import (
+	"context"
+	"testing"
+)
+
+type emptyGenerator struct{}
+
+func (emptyGenerator) Generate(ctx context.Context, coord ChunkCoord, bounds Bounds, dim Dimensions) (*Chunk, error) {
+	return NewChunk(coord, bounds, dim), nil
+}
+
+// towerEdits builds a three block tower whose upper blocks only stand while the
+// foundation supports them.
+func towerEdits() []BlockEdit {
+	return []BlockEdit{
+		{Coord: BlockCoord{X: 1, Y: 1, Z: 0}, Block: Block{Type: BlockSolid, Weight: 10, ConnectingForce: 50}},
+		{Coord: BlockCoord{X: 1, Y: 1, Z: 1}, Block: Block{Type: BlockSolid, Weight: 10, ConnectingForce: 22}},
+		{Coord: BlockCoord{X: 1, Y: 1, Z: 2}, Block: Block{Type: BlockSolid, Weight: 10, ConnectingForce: 12}},
+	}
+}
+
+func TestApplyBlockEditsBuildsSupportedStructure(t *testing.T) {
+	chdirTemp(t)
+	manager := NewManager(ServerRegion{
+		ChunksX:        1,
+		ChunksY:        1,
+		ChunkDimension: Dimensions{Width: 4, Depth: 4, Height: 4},
+	}, emptyGenerator{})
+	ctx := context.Background()
+
+	summary, err := manager.ApplyBlockEdits(ctx, towerEdits())
+	if err != nil {
+		t.Fatalf("ApplyBlockEdits() error = %v", err)
+	}
+	if collapsed := summary.CollapsedBlocks(); len(collapsed) != 0 {
+		t.Fatalf("supported tower collapsed: %v", collapsed)
+	}
+	changes := summary.Changes()
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 edits in summary, got %d", len(changes))
+	}
+	for _, change := range changes {
+		if change.Reason != ReasonEdit || change.Before.Type != BlockAir || change.After.Type != BlockSolid {
+			t.Fatalf("unexpected change %+v", change)
+		}
+	}
+
+	chunk, err := manager.Chunk(ctx, ChunkCoord{})
+	if err != nil {
+		t.Fatalf("load chunk: %v", err)
+	}
+	for z := 0; z < 3; z++ {
+		if block, _ := chunk.LocalBlock(1, 1, z); block.Type != BlockSolid {
+			t.Fatalf("block at z=%d = %q, want solid", z, block.Type)
+		}
+	}
+}
+
+func TestApplyBlockEditsRemovingBaseCollapsesStructure(t *testing.T) {
+	chdirTemp(t)
+	manager := NewManager(ServerRegion{
+		ChunksX:        1,
+		ChunksY:        1,
+		ChunkDimension: Dimensions{Width: 4, Depth: 4, Height: 4},
+	}, emptyGenerator{})
+	ctx := context.Background()
+
+	if _, err := manager.ApplyBlockEdits(ctx, towerEdits()); err != nil {
+		t.Fatalf("build tower: %v", err)
+	}
+
+	base := BlockCoord{X: 1, Y: 1, Z: 0}
+	summary, err := manager.ApplyBlockEdits(ctx, []BlockEdit{{Coord: base}})
+	if err != nil {
+		t.Fatalf("ApplyBlockEdits() error = %v", err)
+	}
+
+	collapsed := make(map[BlockCoord]bool)
+	for _, coord := range summary.CollapsedBlocks() {
+		collapsed[coord] = true
+	}
+	if len(collapsed) != 2 || !collapsed[BlockCoord{X: 1, Y: 1, Z: 1}] || !collapsed[BlockCoord{X: 1, Y: 1, Z: 2}] {
+		t.Fatalf("expected both upper blocks to collapse, got %v", summary.CollapsedBlocks())
+	}
+	var removal *BlockChange
+	for _, change := range summary.Changes() {
+		if change.Coord == base {
+			change := change
+			removal = &change
+		}
+	}
+	if removal == nil || removal.Reason != ReasonEdit || removal.After.Type != BlockAir {
+		t.Fatalf("expected base removal recorded as an edit, got %+v", removal)
+	}
+
+	chunk, err := manager.Chunk(ctx, ChunkCoord{})
+	if err != nil {
+		t.Fatalf("load chunk: %v", err)
+	}
+	for z := 0; z < 3; z++ {
+		if block, _ := chunk.LocalBlock(1, 1, z); block.Type != BlockAir {
+			t.Fatalf("block at z=%d = %q, want air after collapse", z, block.Type)
+		}
+	}
+}
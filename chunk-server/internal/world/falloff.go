@@ -0,0 +1,33 @@
+package world
+
+// Falloff shapes how explosion damage drops off between the blast center and
+// its radius.
+type Falloff int
+
+const (
+	// FalloffLinear scales damage by 1 - distance/radius.
+	FalloffLinear Falloff = iota
+	// FalloffQuadratic squares the linear factor, concentrating damage near
+	// the center.
+	FalloffQuadratic
+	// FalloffConstant deals full damage everywhere inside the radius.
+	FalloffConstant
+)
+
+// Scale returns the fraction of full damage dealt at distance from the center
+// of a blast with the given radius. Points outside the radius get zero, and
+// unknown falloffs behave like FalloffLinear.
+func (f Falloff) Scale(distance, radius float64) float64 {
+	if radius <= 0 || distance > radius {
+		return 0
+	}
+	linear := 1 - distance/radius
+	switch f {
+	case FalloffQuadratic:
+		return linear * linear
+	case FalloffConstant:
+		return 1
+	default:
+		return linear
+	}
+}
@@ -0,0 +1,58 @@
+package world
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFalloffOrderingAtHalfRadius(t *testing.T) {
+	const radius = 4.0
+	constant := FalloffConstant.Scale(radius/2, radius)
+	linear := FalloffLinear.Scale(radius/2, radius)
+	quadratic := FalloffQuadratic.Scale(radius/2, radius)
+
+	if constant != 1 || linear != 0.5 || quadratic != 0.25 {
+		t.Fatalf("unexpected half-radius scales: constant=%v linear=%v quadratic=%v", constant, linear, quadratic)
+	}
+	if !(constant > linear && linear > quadratic) {
+		t.Fatalf("expected constant > linear > quadratic, got %v, %v, %v", constant, linear, quadratic)
+	}
+	for _, falloff := range []Falloff{FalloffLinear, FalloffQuadratic, FalloffConstant} {
+		if got := falloff.Scale(radius+0.1, radius); got != 0 {
+			t.Fatalf("falloff %d outside the radius = %v, want 0", falloff, got)
+		}
+	}
+}
+
+func TestApplyExplosionUsesFalloff(t *testing.T) {
+	target := BlockCoord{X: 2, Y: 1, Z: 0}
+	hitPoints := func(falloff Falloff) float64 {
+		t.Helper()
+		manager := newPlacementManager(t)
+		ctx := context.Background()
+		block := Block{Type: BlockSolid, HitPoints: 100, MaxHitPoints: 100, Weight: 1, ConnectingForce: 50}
+		if _, err := manager.ApplyBlockEdits(ctx, []BlockEdit{{Coord: target, Block: block}}); err != nil {
+			t.Fatalf("place block: %v", err)
+		}
+		// The target sits two blocks from the center, half the radius.
+		if _, err := manager.ApplyExplosion(ctx, BlockCoord{X: 0, Y: 1, Z: 0}, 4, 80, falloff); err != nil {
+			t.Fatalf("ApplyExplosion() error = %v", err)
+		}
+		chunk, err := manager.Chunk(ctx, ChunkCoord{})
+		if err != nil {
+			t.Fatalf("load chunk: %v", err)
+		}
+		after, _ := chunk.LocalBlock(target.X, target.Y, target.Z)
+		return after.HitPoints
+	}
+
+	if got := hitPoints(FalloffConstant); got != 20 {
+		t.Fatalf("constant falloff left %v hit points, want 20", got)
+	}
+	if got := hitPoints(FalloffLinear); got != 60 {
+		t.Fatalf("linear falloff left %v hit points, want 60", got)
+	}
+	if got := hitPoints(FalloffQuadratic); got != 80 {
+		t.Fatalf("quadratic falloff left %v hit points, want 80", got)
+	}
+}
@@ -0,0 +1,133 @@
+package world
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"math"
+	"sort"
+)
+
+// Fingerprint hashes the chunk's blocks into a value two chunks share when
+// they hold the same blocks, so a mirror can check its copy cheaply. Each
+// column is hashed as its run-length encoding and the columns are combined in
+// index order, so neither the storage backend nor the order it keeps columns
+// in affects the result. All air hashes alike, and air above a column's top
+// block is ignored. The result is cached until the chunk's version changes.
+func (c *Chunk) Fingerprint() uint64 {
+	c.fingerprintMu.Lock()
+	defer c.fingerprintMu.Unlock()
+	version := c.Version()
+	if !c.fingerprinted || c.fingerprintVersion != version {
+		sum, ok := c.computeFingerprint()
+		if !ok {
+			return sum
+		}
+		c.fingerprint = sum
+		c.fingerprintVersion = version
+		c.fingerprinted = true
+	}
+	return c.fingerprint
+}
+
+// computeFingerprint hashes the chunk's stored columns. ok is false when the
+// storage could not be read in full, so the result should not be cached.
+func (c *Chunk) computeFingerprint() (uint64, bool) {
+	c.mu.RLock()
+	store := c.store
+	c.mu.RUnlock()
+
+	type columnSum struct {
+		idx int
+		sum uint64
+	}
+	var columns []columnSum
+	complete := store != nil
+	if store != nil {
+		if err := store.ForEach(func(idx int, column []Block) bool {
+			if sum, ok := columnFingerprint(column); ok {
+				columns = append(columns, columnSum{idx: idx, sum: sum})
+			}
+			return true
+		}); err != nil {
+			c.logger.Warnf("chunk %v fingerprint: %v", c.Key, err)
+			complete = false
+		}
+	}
+	sort.Slice(columns, func(i, j int) bool { return columns[i].idx < columns[j].idx })
+
+	h := fnv.New64a()
+	for _, column := range columns {
+		writeUint64(h, uint64(column.idx))
+		writeUint64(h, column.sum)
+	}
+	return h.Sum64(), complete
+}
+
+// columnFingerprint hashes a column's runs. ok is false for a column of
+// nothing but air, which hashes as if it were not stored.
+func columnFingerprint(column []Block) (uint64, bool) {
+	normalized := make([]Block, len(column))
+	for i, block := range column {
+		if !blockIsAir(block) {
+			normalized[i] = block
+		}
+	}
+	normalized = trimColumn(normalized)
+	if len(normalized) == 0 {
+		return 0, false
+	}
+	h := fnv.New64a()
+	for _, run := range compressColumn(normalized) {
+		writeUint64(h, uint64(run.Count))
+		writeBlock(h, run.Block)
+	}
+	return h.Sum64(), true
+}
+
+func writeBlock(h hash.Hash64, block Block) {
+	writeString(h, string(block.Type))
+	writeString(h, block.Material)
+	writeString(h, block.Color)
+	writeString(h, block.Texture)
+	for _, value := range []float64{block.HitPoints, block.MaxHitPoints, block.ConnectingForce, block.Weight, block.LightEmission} {
+		writeUint64(h, math.Float64bits(value))
+	}
+
+	keys := make([]string, 0, len(block.ResourceYield))
+	for key := range block.ResourceYield {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	writeUint64(h, uint64(len(keys)))
+	for _, key := range keys {
+		writeString(h, key)
+		writeUint64(h, math.Float64bits(block.ResourceYield[key]))
+	}
+
+	// Metadata values are compared by their printed form, as blocksEqual
+	// does for types it does not know, so a value's Go type after a round
+	// trip through storage does not matter.
+	keys = keys[:0]
+	for key := range block.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	writeUint64(h, uint64(len(keys)))
+	for _, key := range keys {
+		writeString(h, key)
+		writeString(h, fmt.Sprint(block.Metadata[key]))
+	}
+}
+
+func writeUint64(h hash.Hash64, value uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], value)
+	h.Write(buf[:])
+}
+
+func writeString(h hash.Hash64, value string) {
+	writeUint64(h, uint64(len(value)))
+	h.Write([]byte(value))
+}
@@ -0,0 +1,51 @@
+package world
+
+import (
+	"context"
+	"fmt"
+)
+
+// ChunkMutator is a generation pass that edits a chunk after its base terrain
+// exists, such as placing structures or a custom overlay.
+type ChunkMutator interface {
+	Mutate(ctx context.Context, chunk *Chunk, bounds Bounds, dim Dimensions) error
+}
+
+// ChunkMutatorFunc adapts a function to ChunkMutator.
+type ChunkMutatorFunc func(ctx context.Context, chunk *Chunk, bounds Bounds, dim Dimensions) error
+
+func (f ChunkMutatorFunc) Mutate(ctx context.Context, chunk *Chunk, bounds Bounds, dim Dimensions) error {
+	return f(ctx, chunk, bounds, dim)
+}
+
+// GeneratorChain generates a chunk with Base and then runs each mutator over
+// it in order, the same way the terrain generator layers forests and mineral
+// veins over its base columns. Mutators also run over chunks Base restored
+// from storage, so they should leave an already mutated chunk unchanged.
+type GeneratorChain struct {
+	Base     Generator
+	Mutators []ChunkMutator
+}
+
+func NewGeneratorChain(base Generator, mutators ...ChunkMutator) *GeneratorChain {
+	return &GeneratorChain{Base: base, Mutators: mutators}
+}
+
+func (g *GeneratorChain) Generate(ctx context.Context, coord ChunkCoord, bounds Bounds, dim Dimensions) (*Chunk, error) {
+	if g.Base == nil {
+		return nil, fmt.Errorf("generator chain for chunk %v has no base generator", coord)
+	}
+	chunk, err := g.Base.Generate(ctx, coord, bounds, dim)
+	if err != nil {
+		return nil, err
+	}
+	for i, mutator := range g.Mutators {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := mutator.Mutate(ctx, chunk, bounds, dim); err != nil {
+			return nil, fmt.Errorf("chunk %v mutator %d: %w", coord, i, err)
+		}
+	}
+	return chunk, nil
+}
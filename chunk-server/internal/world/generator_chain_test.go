@@ -0,0 +1,63 @@
+package world
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestGeneratorChainRunsMutatorsOverBaseTerrain(t *testing.T) {
+	chdirTemp(t)
+	marker := ChunkMutatorFunc(func(ctx context.Context, chunk *Chunk, bounds Bounds, dim Dimensions) error {
+		chunk.SetLocalBlock(1, 2, 3, Block{Type: BlockSolid, Material: "marker"})
+		return nil
+	})
+	var order []string
+	trace := func(name string) ChunkMutator {
+		return ChunkMutatorFunc(func(context.Context, *Chunk, Bounds, Dimensions) error {
+			order = append(order, name)
+			return nil
+		})
+	}
+	manager := NewManager(ServerRegion{
+		ChunksX:        1,
+		ChunksY:        1,
+		ChunkDimension: Dimensions{Width: 4, Depth: 4, Height: 4},
+	}, NewGeneratorChain(floorGenerator{}, trace("first"), marker, trace("last")))
+
+	chunk, err := manager.Chunk(context.Background(), ChunkCoord{})
+	if err != nil {
+		t.Fatalf("load chunk: %v", err)
+	}
+	if block, _ := chunk.LocalBlock(0, 0, 0); block.Material != "stone" {
+		t.Fatalf("expected base terrain at the floor, got %+v", block)
+	}
+	if block, _ := chunk.LocalBlock(1, 2, 3); block.Material != "marker" {
+		t.Fatalf("expected mutator marker block, got %+v", block)
+	}
+	if len(order) != 2 || order[0] != "first" || order[1] != "last" {
+		t.Fatalf("mutators ran in order %v", order)
+	}
+}
+
+func TestGeneratorChainStopsOnMutatorError(t *testing.T) {
+	chdirTemp(t)
+	boom := errors.New("boom")
+	ran := false
+	chain := NewGeneratorChain(floorGenerator{},
+		ChunkMutatorFunc(func(context.Context, *Chunk, Bounds, Dimensions) error { return boom }),
+		ChunkMutatorFunc(func(context.Context, *Chunk, Bounds, Dimensions) error {
+			ran = true
+			return nil
+		}),
+	)
+	dim := Dimensions{Width: 2, Depth: 2, Height: 2}
+	bounds := Bounds{Max: BlockCoord{X: 1, Y: 1, Z: 1}}
+
+	if _, err := chain.Generate(context.Background(), ChunkCoord{X: 7}, bounds, dim); !errors.Is(err, boom) {
+		t.Fatalf("Generate() error = %v, want %v", err, boom)
+	}
+	if ran {
+		t.Fatalf("expected later mutators to be skipped after an error")
+	}
+}
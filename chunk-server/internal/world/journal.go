@@ -0,0 +1,141 @@
+package world
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DefaultEditJournalSize is how many block mutations a manager remembers for
+// Undo unless SetEditJournalSize says otherwise.
+const DefaultEditJournalSize = 64
+
+// ErrNothingToUndo is returned by Undo when the journal is empty.
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// ErrNothingToRedo is returned by Redo when no undone mutation is waiting.
+var ErrNothingToRedo = errors.New("nothing to redo")
+
+// editJournal keeps the changes of recent mutations so they can be reverted
+// and replayed. Entries beyond the size limit are dropped oldest first.
+type editJournal struct {
+	mu   sync.Mutex
+	size int
+	undo [][]BlockChange
+	redo [][]BlockChange
+}
+
+// SetEditJournalSize bounds how many mutations Undo can step back through.
+// Zero or less disables the journal and forgets what it held.
+func (m *Manager) SetEditJournalSize(n int) {
+	if n < 0 {
+		n = 0
+	}
+	j := &m.journal
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.size = n
+	if excess := len(j.undo) - n; excess > 0 {
+		j.undo = append([][]BlockChange(nil), j.undo[excess:]...)
+	}
+	if n == 0 {
+		j.redo = nil
+	}
+}
+
+// recordEdit journals the changes in summary. Recording a new mutation
+// discards anything waiting to be redone.
+func (m *Manager) recordEdit(summary *DamageSummary) {
+	changes := summary.Changes()
+	if len(changes) == 0 {
+		return
+	}
+	for i := range changes {
+		changes[i].Before = cloneBlock(changes[i].Before)
+		changes[i].After = cloneBlock(changes[i].After)
+	}
+	j := &m.journal
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.size == 0 {
+		return
+	}
+	j.undo = append(j.undo, changes)
+	if len(j.undo) > j.size {
+		j.undo = j.undo[1:]
+	}
+	j.redo = nil
+}
+
+// Undo reverts the most recent journaled mutation, including any collapses
+// it caused, by writing back every block's prior state. Blocks are restored
+// exactly as they were, without running a stability cascade.
+func (m *Manager) Undo(ctx context.Context) error {
+	j := &m.journal
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if len(j.undo) == 0 {
+		return ErrNothingToUndo
+	}
+	changes := j.undo[len(j.undo)-1]
+	if err := m.restoreBlocks(ctx, changes, false); err != nil {
+		return fmt.Errorf("undo: %w", err)
+	}
+	j.undo = j.undo[:len(j.undo)-1]
+	j.redo = append(j.redo, changes)
+	return nil
+}
+
+// Redo reapplies the mutation most recently reverted by Undo.
+func (m *Manager) Redo(ctx context.Context) error {
+	j := &m.journal
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if len(j.redo) == 0 {
+		return ErrNothingToRedo
+	}
+	changes := j.redo[len(j.redo)-1]
+	if err := m.restoreBlocks(ctx, changes, true); err != nil {
+		return fmt.Errorf("redo: %w", err)
+	}
+	j.redo = j.redo[:len(j.redo)-1]
+	j.undo = append(j.undo, changes)
+	return nil
+}
+
+// restoreBlocks writes the After state of each change when forward is set and
+// the Before state otherwise. Changes are batched per chunk so each touched
+// column is rewritten once.
+func (m *Manager) restoreBlocks(ctx context.Context, changes []BlockChange, forward bool) error {
+	var order []*Chunk
+	batches := make(map[*Chunk][]LocalBlockEdit)
+	for _, change := range changes {
+		block := change.Before
+		if forward {
+			block = change.After
+		}
+		chunkCoord, ok := m.region.LocateBlock(change.Coord)
+		if !ok {
+			return fmt.Errorf("block %v outside region", change.Coord)
+		}
+		chunk, err := m.Chunk(ctx, chunkCoord)
+		if err != nil {
+			return err
+		}
+		localX, localY, localZ, ok := chunk.GlobalToLocal(change.Coord)
+		if !ok {
+			return fmt.Errorf("block %v outside chunk %v", change.Coord, chunkCoord)
+		}
+		if _, seen := batches[chunk]; !seen {
+			order = append(order, chunk)
+		}
+		batches[chunk] = append(batches[chunk], LocalBlockEdit{X: localX, Y: localY, Z: localZ, Block: cloneBlock(block)})
+	}
+	for _, chunk := range order {
+		if !chunk.SetLocalBlocks(batches[chunk]) {
+			return fmt.Errorf("write blocks in chunk %v", chunk.Key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package world
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUndoRestoresDestroyedBlockAndRedoRemovesIt(t *testing.T) {
+	manager := newPlacementManager(t)
+	ctx := context.Background()
+	coord := BlockCoord{X: 2, Y: 1, Z: 0}
+	original := Block{
+		Type:            BlockMineral,
+		Material:        "iron",
+		HitPoints:       17,
+		MaxHitPoints:    40,
+		ConnectingForce: 80,
+		Weight:          6,
+		ResourceYield:   map[string]float64{"iron": 3},
+		Metadata:        map[string]any{"owner": "guild"},
+	}
+	if _, err := manager.ApplyBlockEdits(ctx, []BlockEdit{{Coord: coord, Block: original}}); err != nil {
+		t.Fatalf("place block: %v", err)
+	}
+
+	summary, err := manager.ApplyBlockDamage(ctx, coord, 100)
+	if err != nil {
+		t.Fatalf("ApplyBlockDamage() error = %v", err)
+	}
+	if changes := summary.Changes(); len(changes) != 1 || changes[0].Reason != ReasonDestroy {
+		t.Fatalf("expected the block to be destroyed, got %+v", changes)
+	}
+
+	if err := manager.Undo(ctx); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if got := blockAt(t, manager, coord); !reflect.DeepEqual(got, original) {
+		t.Fatalf("after Undo block = %+v, want %+v", got, original)
+	}
+
+	if err := manager.Redo(ctx); err != nil {
+		t.Fatalf("Redo() error = %v", err)
+	}
+	if got := blockAt(t, manager, coord); !blockIsAir(got) {
+		t.Fatalf("after Redo block = %+v, want air", got)
+	}
+	if err := manager.Redo(ctx); !errors.Is(err, ErrNothingToRedo) {
+		t.Fatalf("second Redo() error = %v, want ErrNothingToRedo", err)
+	}
+}
+
+func TestUndoRestoresCollapsedBlocks(t *testing.T) {
+	manager := newPlacementManager(t)
+	ctx := context.Background()
+	base := BlockCoord{X: 1, Y: 1, Z: 0}
+	top := BlockCoord{X: 1, Y: 1, Z: 1}
+	baseBlock := Block{Type: BlockSolid, HitPoints: 5, MaxHitPoints: 5, ConnectingForce: 500, Weight: 5}
+	topBlock := Block{Type: BlockSolid, HitPoints: 9, MaxHitPoints: 9, ConnectingForce: 150, Weight: 120}
+	if _, err := manager.ApplyBlockEdits(ctx, []BlockEdit{
+		{Coord: base, Block: baseBlock},
+		{Coord: top, Block: topBlock},
+	}); err != nil {
+		t.Fatalf("build column: %v", err)
+	}
+
+	summary, err := manager.ApplyBlockDamage(ctx, base, 10)
+	if err != nil {
+		t.Fatalf("ApplyBlockDamage() error = %v", err)
+	}
+	if collapsed := summary.CollapsedBlocks(); len(collapsed) != 1 || collapsed[0] != top {
+		t.Fatalf("expected the top block to collapse, got %v", collapsed)
+	}
+
+	if err := manager.Undo(ctx); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if got := blockAt(t, manager, base); !reflect.DeepEqual(got, baseBlock) {
+		t.Fatalf("base after Undo = %+v, want %+v", got, baseBlock)
+	}
+	if got := blockAt(t, manager, top); !reflect.DeepEqual(got, topBlock) {
+		t.Fatalf("collapsed block after Undo = %+v, want %+v", got, topBlock)
+	}
+}
+
+func TestEditJournalDropsOldestBeyondSize(t *testing.T) {
+	manager := newPlacementManager(t)
+	ctx := context.Background()
+	manager.SetEditJournalSize(2)
+	for x := 0; x < 3; x++ {
+		coord := BlockCoord{X: x, Y: 0, Z: 0}
+		if _, err := manager.ApplyBlockEdits(ctx, []BlockEdit{{Coord: coord, Block: Block{Type: BlockSolid, ConnectingForce: 50}}}); err != nil {
+			t.Fatalf("edit %v: %v", coord, err)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := manager.Undo(ctx); err != nil {
+			t.Fatalf("Undo() %d error = %v", i, err)
+		}
+	}
+	if err := manager.Undo(ctx); !errors.Is(err, ErrNothingToUndo) {
+		t.Fatalf("Undo() past the journal error = %v, want ErrNothingToUndo", err)
+	}
+	if got := blockAt(t, manager, BlockCoord{X: 0, Y: 0, Z: 0}); blockIsAir(got) {
+		t.Fatalf("edit that fell out of the journal was undone")
+	}
+}
+
+func blockAt(t *testing.T, manager *Manager, coord BlockCoord) Block {
+	t.Helper()
+	chunk, err := manager.ChunkForBlock(context.Background(), coord)
+	if err != nil {
+		t.Fatalf("load chunk for %v: %v", coord, err)
+	}
+	x, y, z, ok := chunk.GlobalToLocal(coord)
+	if !ok {
+		t.Fatalf("block %v outside chunk", coord)
+	}
+	block, _ := chunk.LocalBlock(x, y, z)
+	return block
+}
+
+func TestApplyBlockHealRestoresHitPointsUpToMax(t *testing.T) {
+	manager := newPlacementManager(t)
+	ctx := context.Background()
+	coord := BlockCoord{X: 1, Y: 2, Z: 0}
+	original := Block{Type: BlockSolid, HitPoints: 40, MaxHitPoints: 40, ConnectingForce: 500, Weight: 5}
+	if _, err := manager.ApplyBlockEdits(ctx, []BlockEdit{{Coord: coord, Block: original}}); err != nil {
+		t.Fatalf("place block: %v", err)
+	}
+	if _, err := manager.ApplyBlockDamage(ctx, coord, 25); err != nil {
+		t.Fatalf("ApplyBlockDamage() error = %v", err)
+	}
+
+	summary, err := manager.ApplyBlockHeal(ctx, coord, 10)
+	if err != nil {
+		t.Fatalf("ApplyBlockHeal() error = %v", err)
+	}
+	changes := summary.Changes()
+	if len(changes) != 1 || changes[0].Reason != ReasonRepair || changes[0].Before.HitPoints != 15 || changes[0].After.HitPoints != 25 {
+		t.Fatalf("heal changes = %+v, want one repair from 15 to 25 hit points", changes)
+	}
+	if got := blockAt(t, manager, coord).HitPoints; got != 25 {
+		t.Fatalf("hit points after healing = %v, want 25", got)
+	}
+
+	if _, err := manager.ApplyBlockHeal(ctx, coord, 100); err != nil {
+		t.Fatalf("ApplyBlockHeal() error = %v", err)
+	}
+	if got := blockAt(t, manager, coord).HitPoints; got != 40 {
+		t.Fatalf("hit points after overhealing = %v, want the 40 maximum", got)
+	}
+	if summary, _ := manager.ApplyBlockHeal(ctx, coord, 5); len(summary.Changes()) != 0 {
+		t.Fatalf("healing a block at full health changed %+v", summary.Changes())
+	}
+
+	if err := manager.Undo(ctx); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if got := blockAt(t, manager, coord).HitPoints; got != 25 {
+		t.Fatalf("hit points after undoing the overheal = %v, want 25", got)
+	}
+}
+
+func TestApplyBlockHealIgnoresAir(t *testing.T) {
+	manager := newPlacementManager(t)
+	ctx := context.Background()
+	coord := BlockCoord{X: 1, Y: 1, Z: 1}
+	summary, err := manager.ApplyBlockHeal(ctx, coord, 10)
+	if err != nil {
+		t.Fatalf("ApplyBlockHeal() error = %v", err)
+	}
+	if len(summary.Changes()) != 0 || len(summary.DirtyChunks()) != 0 {
+		t.Fatalf("healing air produced changes %+v", summary.Changes())
+	}
+	if got := blockAt(t, manager, coord); !blockIsAir(got) {
+		t.Fatalf("block after healing air = %+v, want air", got)
+	}
+}
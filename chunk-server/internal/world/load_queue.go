@@ -0,0 +1,159 @@
+package world
+
+import (
+	"container/heap"
+	"context"
+)
+
+// LoadPriority orders pending chunk generations. Higher values run first.
+type LoadPriority int
+
+const (
+	// PriorityLow is for speculative loads such as neighborhood prefetches.
+	PriorityLow LoadPriority = iota
+	// PriorityNormal is for loads nobody is waiting on yet.
+	PriorityNormal
+	// PriorityHigh is for loads a caller is blocked on.
+	PriorityHigh
+)
+
+// loadJob is a chunk generation waiting for a worker.
+type loadJob struct {
+	ctx      context.Context
+	coord    ChunkCoord
+	bounds   Bounds
+	future   *chunkFuture
+	priority LoadPriority
+	seq      uint64
+	index    int
+}
+
+// loadHeap orders jobs by priority, then by submission order.
+type loadHeap []*loadJob
+
+func (h loadHeap) Len() int { return len(h) }
+
+func (h loadHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h loadHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *loadHeap) Push(x any) {
+	job := x.(*loadJob)
+	job.index = len(*h)
+	*h = append(*h, job)
+}
+
+func (h *loadHeap) Pop() any {
+	old := *h
+	n := len(old)
+	job := old[n-1]
+	old[n-1] = nil
+	job.index = -1
+	*h = old[:n-1]
+	return job
+}
+
+// SetMaxConcurrentLoads bounds how many chunk generations run at once. Loads
+// beyond the limit wait in a queue ordered by priority. Zero or less removes
+// the bound and starts every generation immediately.
+func (m *Manager) SetMaxConcurrentLoads(n int) {
+	if n < 0 {
+		n = 0
+	}
+	m.loadMu.Lock()
+	m.maxLoads = n
+	m.loadMu.Unlock()
+	m.startLoadWorkers()
+}
+
+// submitLoad queues a generation job and makes sure a worker will pick it up.
+// The caller must have registered future in m.pending.
+func (m *Manager) submitLoad(ctx context.Context, coord ChunkCoord, bounds Bounds, future *chunkFuture, priority LoadPriority) {
+	m.loadMu.Lock()
+	m.loadSeq++
+	job := &loadJob{
+		ctx:      ctx,
+		coord:    coord,
+		bounds:   bounds,
+		future:   future,
+		priority: priority,
+		seq:      m.loadSeq,
+	}
+	heap.Push(&m.loadQueue, job)
+	m.queuedLoads[coord] = job
+	m.loadMu.Unlock()
+	m.startLoadWorkers()
+}
+
+// raiseLoadPriority moves a still-queued generation for coord up to priority.
+// Loads that are already running, or already at a higher priority, are left
+// alone.
+func (m *Manager) raiseLoadPriority(coord ChunkCoord, priority LoadPriority) {
+	m.loadMu.Lock()
+	defer m.loadMu.Unlock()
+	job, ok := m.queuedLoads[coord]
+	if !ok || job.priority >= priority {
+		return
+	}
+	job.priority = priority
+	heap.Fix(&m.loadQueue, job.index)
+}
+
+// startLoadWorkers hands queued jobs to new workers until the concurrency
+// limit is reached or the queue is empty.
+func (m *Manager) startLoadWorkers() {
+	m.loadMu.Lock()
+	defer m.loadMu.Unlock()
+	for m.loadQueue.Len() > 0 && (m.maxLoads <= 0 || m.activeLoads < m.maxLoads) {
+		m.activeLoads++
+		go m.runLoads(m.popLoadLocked())
+	}
+}
+
+// runLoads generates job and then keeps taking the highest priority queued job
+// until the queue drains or the concurrency limit has been lowered.
+func (m *Manager) runLoads(job *loadJob) {
+	for {
+		m.generateChunk(job.ctx, job.coord, job.bounds, job.future)
+
+		m.loadMu.Lock()
+		if m.loadQueue.Len() == 0 || (m.maxLoads > 0 && m.activeLoads > m.maxLoads) {
+			m.activeLoads--
+			m.loadMu.Unlock()
+			return
+		}
+		job = m.popLoadLocked()
+		m.loadMu.Unlock()
+	}
+}
+
+func (m *Manager) popLoadLocked() *loadJob {
+	job := heap.Pop(&m.loadQueue).(*loadJob)
+	if m.queuedLoads[job.coord] == job {
+		delete(m.queuedLoads, job.coord)
+	}
+	return job
+}
+
+// dropQueuedLoad removes the still-queued generation of future for coord,
+// reporting whether one was removed before a worker picked it up.
+func (m *Manager) dropQueuedLoad(coord ChunkCoord, future *chunkFuture) bool {
+	m.loadMu.Lock()
+	defer m.loadMu.Unlock()
+	job, ok := m.queuedLoads[coord]
+	if !ok || job.future != future {
+		return false
+	}
+	heap.Remove(&m.loadQueue, job.index)
+	delete(m.queuedLoads, coord)
+	return true
+}
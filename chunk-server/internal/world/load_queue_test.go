@@ -0,0 +1,211 @@
+package world
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedGenerator blocks generation of the first chunk until release is closed
+// and records the order in which chunks are generated.
+type gatedGenerator struct {
+	first   ChunkCoord
+	started chan struct{}
+	release chan struct{}
+
+	mu    sync.Mutex
+	order []ChunkCoord
+}
+
+func (g *gatedGenerator) Generate(ctx context.Context, coord ChunkCoord, bounds Bounds, dim Dimensions) (*Chunk, error) {
+	if coord == g.first {
+		close(g.started)
+		<-g.release
+	}
+	g.mu.Lock()
+	g.order = append(g.order, coord)
+	g.mu.Unlock()
+	return NewChunk(coord, bounds, dim), nil
+}
+
+func (g *gatedGenerator) generated() []ChunkCoord {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]ChunkCoord(nil), g.order...)
+}
+
+func TestHighPriorityLoadJumpsQueuedPrefetches(t *testing.T) {
+	chdirTemp(t)
+
+	region := ServerRegion{
+		ChunksX:        4,
+		ChunksY:        4,
+		ChunkDimension: Dimensions{Width: 2, Depth: 2, Height: 2},
+	}
+	gen := &gatedGenerator{
+		first:   ChunkCoord{X: 0, Y: 0},
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	manager := NewManager(region, gen)
+	manager.SetMaxConcurrentLoads(1)
+
+	if err := manager.EnsureChunkWithPriority(gen.first, PriorityLow); err != nil {
+		t.Fatalf("ensure first chunk: %v", err)
+	}
+	<-gen.started
+
+	prefetches := []ChunkCoord{{X: 1, Y: 0}, {X: 2, Y: 0}, {X: 3, Y: 0}}
+	for _, coord := range prefetches {
+		if err := manager.EnsureChunkWithPriority(coord, PriorityLow); err != nil {
+			t.Fatalf("ensure %v: %v", coord, err)
+		}
+	}
+
+	urgent := ChunkCoord{X: 0, Y: 3}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := manager.Chunk(ctx, urgent)
+		done <- err
+	}()
+
+	// Wait until the blocking request is queued before freeing the worker.
+	deadline := time.Now().Add(time.Second)
+	for {
+		manager.loadMu.Lock()
+		_, queued := manager.queuedLoads[urgent]
+		manager.loadMu.Unlock()
+		if queued {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("urgent chunk was never queued")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(gen.release)
+
+	if err := <-done; err != nil {
+		t.Fatalf("load urgent chunk: %v", err)
+	}
+	for _, coord := range prefetches {
+		if _, err := manager.Chunk(ctx, coord); err != nil {
+			t.Fatalf("load %v: %v", coord, err)
+		}
+	}
+
+	order := gen.generated()
+	want := []ChunkCoord{gen.first, urgent, prefetches[0], prefetches[1], prefetches[2]}
+	if len(order) != len(want) {
+		t.Fatalf("generated %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("generated %v, want %v", order, want)
+		}
+	}
+}
+
+func TestRequeuedLoadOnlyRaisesPriority(t *testing.T) {
+	manager := NewManager(ServerRegion{ChunksX: 1, ChunksY: 1}, nil)
+	manager.SetMaxConcurrentLoads(1)
+	manager.activeLoads = 1 // hold the only worker so jobs stay queued
+
+	coord := ChunkCoord{X: 0, Y: 0}
+	future := newChunkFuture()
+	manager.submitLoad(context.Background(), coord, Bounds{}, future, PriorityNormal)
+
+	manager.raiseLoadPriority(coord, PriorityLow)
+	if got := manager.queuedLoads[coord].priority; got != PriorityNormal {
+		t.Fatalf("priority after lower request = %d, want %d", got, PriorityNormal)
+	}
+	manager.raiseLoadPriority(coord, PriorityHigh)
+	if got := manager.queuedLoads[coord].priority; got != PriorityHigh {
+		t.Fatalf("priority after higher request = %d, want %d", got, PriorityHigh)
+	}
+}
+
+func chdirTemp(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("get working directory: %v", err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("chdir to temp dir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+// countingGenerator tracks how many generations are running at once.
+type countingGenerator struct {
+	mu      sync.Mutex
+	running int
+	peak    int
+	done    int
+}
+
+func (g *countingGenerator) Generate(ctx context.Context, coord ChunkCoord, bounds Bounds, dim Dimensions) (*Chunk, error) {
+	g.mu.Lock()
+	g.running++
+	if g.running > g.peak {
+		g.peak = g.running
+	}
+	g.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	g.mu.Lock()
+	g.running--
+	g.done++
+	g.mu.Unlock()
+	return NewChunk(coord, bounds, dim), nil
+}
+
+func TestMaxConcurrentLoadsBoundsGenerations(t *testing.T) {
+	chdirTemp(t)
+
+	region := ServerRegion{
+		ChunksX:        4,
+		ChunksY:        4,
+		ChunkDimension: Dimensions{Width: 2, Depth: 2, Height: 2},
+	}
+	gen := &countingGenerator{}
+	manager := NewManager(region, gen)
+	manager.SetMaxConcurrentLoads(2)
+
+	var coords []ChunkCoord
+	for x := 0; x < region.ChunksX; x++ {
+		for y := 0; y < region.ChunksY; y++ {
+			coord := ChunkCoord{X: x, Y: y}
+			coords = append(coords, coord)
+			if err := manager.EnsureChunk(coord); err != nil {
+				t.Fatalf("ensure %v: %v", coord, err)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, coord := range coords {
+		if _, err := manager.Chunk(ctx, coord); err != nil {
+			t.Fatalf("load %v: %v", coord, err)
+		}
+	}
+
+	gen.mu.Lock()
+	defer gen.mu.Unlock()
+	if gen.done != len(coords) {
+		t.Fatalf("generated %d chunks, want %d", gen.done, len(coords))
+	}
+	if gen.peak > 2 {
+		t.Fatalf("peak concurrent generations = %d, want at most 2", gen.peak)
+	}
+	if gen.peak < 2 {
+		t.Fatalf("peak concurrent generations = %d, expected the limit to be used", gen.peak)
+	}
+}
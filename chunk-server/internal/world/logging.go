@@ -0,0 +1,60 @@
+package world
+
+import "chunkserver/internal/logging"
+
+// defaultLogger is what chunks and storage report to when they were not
+// created through a manager, such as chunks made with NewChunk.
+var defaultLogger = logging.Default()
+
+// SetLogger sets the logger the manager, the chunks it loads and their
+// storage report problems to. Nil restores the default. Chunks already loaded
+// keep the logger they were created with.
+func (m *Manager) SetLogger(logger *logging.Logger) {
+	m.loggerMu.Lock()
+	m.logger = logger
+	m.loggerMu.Unlock()
+}
+
+// Logger returns the logger set with SetLogger, or the default logger.
+func (m *Manager) Logger() *logging.Logger {
+	m.loggerMu.RLock()
+	logger := m.logger
+	m.loggerMu.RUnlock()
+	if logger == nil {
+		return defaultLogger
+	}
+	return logger
+}
+
+// chunkStorage returns the manager's storage provider tagged with its logger,
+// so the chunks and storage created through it report to that logger.
+func (m *Manager) chunkStorage() StorageProvider {
+	return loggedStorageProvider{StorageProvider: m.StorageProvider(), logger: m.Logger()}
+}
+
+// loggedStorageProvider hands logger to the chunks and storage it creates.
+type loggedStorageProvider struct {
+	StorageProvider
+	logger *logging.Logger
+}
+
+func (p loggedStorageProvider) NewStorage(key ChunkCoord, bounds Bounds, dim Dimensions) (BlockStorage, error) {
+	if provider, ok := p.StorageProvider.(loggingStorageProvider); ok {
+		return provider.newLoggedStorage(key, bounds, dim, p.logger)
+	}
+	return p.StorageProvider.NewStorage(key, bounds, dim)
+}
+
+// loggingStorageProvider is implemented by providers whose storage reports
+// problems of its own.
+type loggingStorageProvider interface {
+	newLoggedStorage(key ChunkCoord, bounds Bounds, dim Dimensions, logger *logging.Logger) (BlockStorage, error)
+}
+
+// providerLogger returns the logger chunks created with provider report to.
+func providerLogger(provider StorageProvider) *logging.Logger {
+	if p, ok := provider.(loggedStorageProvider); ok && p.logger != nil {
+		return p.logger
+	}
+	return defaultLogger
+}
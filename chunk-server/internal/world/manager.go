@@ -2,11 +2,14 @@ package world
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"math"
 	"path/filepath"
 	"sync"
+
+	"chunkserver/internal/clock"
+	"chunkserver/internal/logging"
 )
 
 // Generator describes terrain population for chunks.
@@ -18,6 +21,9 @@ type Generator interface {
 type Manager struct {
 	region    ServerRegion
 	generator Generator
+	// regionErr is why region cannot hold any blocks. Chunks are never
+	// generated for such a region.
+	regionErr error
 
 	mu     sync.RWMutex
 	chunks map[ChunkCoord]*Chunk
@@ -26,15 +32,43 @@ type Manager struct {
 
 	lighting   LightingState
 	lightingMu sync.RWMutex
+
+	stability   StabilityParams
+	stabilityMu sync.RWMutex
+
+	journal editJournal
+
+	storage   StorageProvider
+	storageMu sync.RWMutex
+
+	logger   *logging.Logger
+	loggerMu sync.RWMutex
+
+	// clock stamps chunk accesses for UnloadIdle. Nil means the system
+	// clock.
+	clock   clock.Clock
+	clockMu sync.RWMutex
+
+	loadMu      sync.Mutex
+	loadQueue   loadHeap
+	queuedLoads map[ChunkCoord]*loadJob
+	loadSeq     uint64
+	activeLoads int
+	maxLoads    int
 }
 
 func NewManager(region ServerRegion, generator Generator) *Manager {
 	return &Manager{
 		region:    region,
 		generator: generator,
+		regionErr: region.Validate(),
 		chunks:    make(map[ChunkCoord]*Chunk),
 		pending:   make(map[ChunkCoord]*chunkFuture),
 		lighting:  DefaultLighting(),
+		stability: DefaultStabilityParams(),
+		journal:   editJournal{size: DefaultEditJournalSize},
+
+		queuedLoads: make(map[ChunkCoord]*loadJob),
 	}
 }
 
@@ -42,6 +76,52 @@ func (m *Manager) Region() ServerRegion {
 	return m.region
 }
 
+// ChunkCounts reports how many chunks are resident in memory and how many are
+// still being loaded or generated.
+func (m *Manager) ChunkCounts() (resident, pending int) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.chunks), len(m.pending)
+}
+
+// Snapshot waits for in-progress chunk loads to finish and then commits every
+// resident chunk to storage. It stops waiting when ctx is done but still
+// snapshots the chunks that are resident by then.
+func (m *Manager) Snapshot(ctx context.Context) error {
+	m.mu.RLock()
+	pending := make([]*chunkFuture, 0, len(m.pending))
+	for _, future := range m.pending {
+		pending = append(pending, future)
+	}
+	m.mu.RUnlock()
+
+	var errs []error
+	for _, future := range pending {
+		select {
+		case <-future.ready:
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("wait for pending chunks: %w", ctx.Err()))
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	m.mu.RLock()
+	chunks := make([]*Chunk, 0, len(m.chunks))
+	for _, chunk := range m.chunks {
+		chunks = append(chunks, chunk)
+	}
+	m.mu.RUnlock()
+
+	for _, chunk := range chunks {
+		if err := chunk.Snapshot(); err != nil {
+			errs = append(errs, fmt.Errorf("snapshot chunk %v: %w", chunk.Key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 type LightingState struct {
 	Ambient     float64
 	SunAngle    float64
@@ -58,6 +138,27 @@ func DefaultLighting() LightingState {
 	}
 }
 
+// SetStorageProvider makes the manager keep its chunks in provider instead of
+// the global storage provider. It applies to chunks generated or imported
+// afterwards; resident chunks keep the storage they were created with.
+func (m *Manager) SetStorageProvider(provider StorageProvider) {
+	m.storageMu.Lock()
+	m.storage = provider
+	m.storageMu.Unlock()
+}
+
+// StorageProvider returns the provider new chunks are stored with: the one
+// set with SetStorageProvider, or the global provider.
+func (m *Manager) StorageProvider() StorageProvider {
+	m.storageMu.RLock()
+	provider := m.storage
+	m.storageMu.RUnlock()
+	if provider == nil {
+		return getStorageProvider()
+	}
+	return provider
+}
+
 func (m *Manager) SetLighting(state LightingState) {
 	m.lightingMu.Lock()
 	m.lighting = state
@@ -79,13 +180,14 @@ func (m *Manager) Chunk(ctx context.Context, coord ChunkCoord) (*Chunk, error) {
 		return ch, nil
 	}
 
-	future, err := m.ensureChunkFuture(ctx, coord)
+	future, err := m.ensureChunkFuture(ctx, coord, PriorityHigh, true)
 	if err != nil {
 		return nil, err
 	}
 
 	select {
 	case <-ctx.Done():
+		m.abandonChunkFuture(coord, future)
 		return nil, ctx.Err()
 	case <-future.ready:
 		if future.err != nil {
@@ -104,7 +206,7 @@ func (m *Manager) ChunkIfReady(coord ChunkCoord) (*Chunk, bool, error) {
 		return ch, true, nil
 	}
 
-	future, err := m.ensureChunkFuture(context.Background(), coord)
+	future, err := m.ensureChunkFuture(context.Background(), coord, PriorityNormal, false)
 	if err != nil {
 		return nil, false, err
 	}
@@ -121,10 +223,18 @@ func (m *Manager) ChunkIfReady(coord ChunkCoord) (*Chunk, bool, error) {
 }
 
 func (m *Manager) EnsureChunk(coord ChunkCoord) error {
+	return m.EnsureChunkWithPriority(coord, PriorityNormal)
+}
+
+// EnsureChunkWithPriority starts loading coord without waiting for it. When
+// generation is bounded by SetMaxConcurrentLoads, higher priority loads are
+// generated before lower ones; requesting a chunk that is already queued can
+// only raise its priority.
+func (m *Manager) EnsureChunkWithPriority(coord ChunkCoord, priority LoadPriority) error {
 	if !m.region.ContainsGlobalChunk(coord) {
 		return fmt.Errorf("chunk %v outside server region", coord)
 	}
-	_, err := m.ensureChunkFuture(context.Background(), coord)
+	_, err := m.ensureChunkFuture(context.Background(), coord, priority, false)
 	return err
 }
 
@@ -132,64 +242,142 @@ func (m *Manager) cachedChunk(coord ChunkCoord) (*Chunk, bool) {
 	m.mu.RLock()
 	ch, ok := m.chunks[coord]
 	m.mu.RUnlock()
+	if ok {
+		m.touch(ch)
+	}
 	return ch, ok
 }
 
-func (m *Manager) ensureChunkFuture(ctx context.Context, coord ChunkCoord) (*chunkFuture, error) {
+// ensureChunkFuture returns the future for coord, starting its generation if
+// none is pending. A waiting caller counts as an awaiter and must call
+// abandonChunkFuture if it stops waiting before the future completes; other
+// callers pin the generation so it always runs to completion.
+func (m *Manager) ensureChunkFuture(ctx context.Context, coord ChunkCoord, priority LoadPriority, waiting bool) (*chunkFuture, error) {
 	m.mu.Lock()
 	if ch, ok := m.chunks[coord]; ok {
 		m.mu.Unlock()
+		m.touch(ch)
 		future := readyChunkFuture(ch)
 		return future, nil
 	}
-	if future, ok := m.pending[coord]; ok {
+	prev, running := m.pending[coord]
+	if running && !prev.abandoned {
+		prev.join(waiting)
 		m.mu.Unlock()
-		return future, nil
+		m.raiseLoadPriority(coord, priority)
+		return prev, nil
+	}
+	if m.regionErr != nil {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("load chunk %v: %w", coord, m.regionErr)
 	}
 	future := newChunkFuture()
+	future.join(waiting)
+	if running {
+		// An abandoned generation is still writing coord's storage; this one
+		// waits for it to return before starting.
+		future.after = prev
+	}
+	// Generation outlives any single caller but is cancelled once every
+	// awaiter has given up on it.
+	genCtx, cancel := context.WithCancel(contextWithoutCancel(ctx))
+	future.cancel = cancel
 	m.pending[coord] = future
 	m.mu.Unlock()
 
 	bounds, err := m.region.ChunkBounds(coord)
 	if err != nil {
-		m.finishChunkFuture(coord, nil, err)
+		m.finishChunkFuture(coord, future, nil, err)
 		return future, err
 	}
 
-	go m.generateChunk(contextWithoutCancel(ctx), coord, bounds, future)
+	m.submitLoad(genCtx, coord, bounds, future, priority)
 	return future, nil
 }
 
+// abandonChunkFuture records that a caller waiting on future has stopped.
+// When it was the last awaiter and nothing pinned the generation, the
+// generation's context is cancelled and later requests for coord start
+// afresh. A generation that is already running stays pending until it
+// returns, so the next one never writes the same storage concurrently.
+func (m *Manager) abandonChunkFuture(coord ChunkCoord, future *chunkFuture) {
+	m.mu.Lock()
+	future.waiters--
+	abandoned := future.waiters <= 0 && !future.pinned && m.pending[coord] == future
+	if abandoned {
+		future.abandoned = true
+	}
+	m.mu.Unlock()
+	if !abandoned {
+		return
+	}
+	future.cancel()
+	if m.dropQueuedLoad(coord, future) {
+		m.mu.Lock()
+		if m.pending[coord] == future {
+			delete(m.pending, coord)
+		}
+		m.mu.Unlock()
+		future.complete(nil, context.Canceled)
+	}
+}
+
 func (m *Manager) generateChunk(ctx context.Context, coord ChunkCoord, bounds Bounds, future *chunkFuture) {
+	if future.after != nil {
+		select {
+		case <-future.after.ready:
+		case <-ctx.Done():
+			m.finishChunkFuture(coord, future, nil, ctx.Err())
+			return
+		}
+		// The abandoned generation may have finished the chunk after all.
+		if chunk, ok := m.cachedChunk(coord); ok {
+			m.finishChunkFuture(coord, future, chunk, nil)
+			return
+		}
+	}
+	ctx = ContextWithStorageProvider(ctx, m.chunkStorage())
 	chunk, err := m.generator.Generate(ctx, coord, bounds, m.region.ChunkDimension)
 	if err != nil {
-		m.finishChunkFuture(coord, nil, err)
+		m.finishChunkFuture(coord, future, nil, err)
 		return
 	}
-	m.finishChunkFuture(coord, chunk, nil)
+	m.finishChunkFuture(coord, future, chunk, nil)
 }
 
-func (m *Manager) finishChunkFuture(coord ChunkCoord, chunk *Chunk, genErr error) {
-	var newlyGenerated *Chunk
+func (m *Manager) finishChunkFuture(coord ChunkCoord, future *chunkFuture, chunk *Chunk, genErr error) {
+	var newlyGenerated, discarded *Chunk
 
 	m.mu.Lock()
 	if chunk != nil {
 		if existing, ok := m.chunks[coord]; ok {
+			if existing != chunk {
+				discarded = chunk
+			}
 			chunk = existing
 		} else {
 			m.chunks[coord] = chunk
 			newlyGenerated = chunk
 		}
+		m.touch(chunk)
 	}
-	if future, ok := m.pending[coord]; ok {
+	if m.pending[coord] == future {
 		delete(m.pending, coord)
-		future.complete(chunk, genErr)
 	}
+	future.complete(chunk, genErr)
 	m.mu.Unlock()
+	if future.cancel != nil {
+		future.cancel()
+	}
+	if discarded != nil {
+		if err := discarded.Close(); err != nil {
+			m.Logger().Warnf("chunk %v close discarded generation: %v", coord, err)
+		}
+	}
 
 	if newlyGenerated != nil {
 		if err := SaveChunkPreview(newlyGenerated, filepath.Join("chunk-preview")); err != nil {
-			log.Printf("chunk %v preview: %v", coord, err)
+			m.Logger().Warnf("chunk %v preview: %v", coord, err)
 		}
 	}
 }
@@ -206,6 +394,18 @@ type chunkFuture struct {
 	chunk *Chunk
 	err   error
 	once  sync.Once
+
+	// cancel stops the generation. waiters, pinned, and abandoned are
+	// guarded by the manager's mu while the future is pending.
+	cancel  context.CancelFunc
+	waiters int
+	pinned  bool
+	// abandoned marks a cancelled generation that stays pending until its
+	// generator returns.
+	abandoned bool
+	// after is an abandoned generation of the same chunk that must return
+	// before this one starts.
+	after *chunkFuture
 }
 
 func newChunkFuture() *chunkFuture {
@@ -218,6 +418,15 @@ func readyChunkFuture(chunk *Chunk) *chunkFuture {
 	return future
 }
 
+// join registers a caller: an awaiter when waiting, otherwise a pin.
+func (f *chunkFuture) join(waiting bool) {
+	if waiting {
+		f.waiters++
+	} else {
+		f.pinned = true
+	}
+}
+
 func (f *chunkFuture) complete(chunk *Chunk, err error) {
 	f.once.Do(func() {
 		f.chunk = chunk
@@ -234,15 +443,104 @@ func (m *Manager) ChunkForBlock(ctx context.Context, block BlockCoord) (*Chunk,
 	return m.Chunk(ctx, chunkCoord)
 }
 
+// SurfaceHeight returns the global Z of the highest non-air block in the
+// column at (x, y), loading its chunk if needed. ok is false when the column
+// is outside the region, empty, or its chunk cannot be loaded.
+func (m *Manager) SurfaceHeight(ctx context.Context, x, y int) (int, bool) {
+	coord := BlockCoord{X: x, Y: y, Z: 0}
+	chunk, err := m.ChunkForBlock(ctx, coord)
+	if err != nil {
+		return 0, false
+	}
+	localX, localY, _, ok := chunk.GlobalToLocal(coord)
+	if !ok {
+		return 0, false
+	}
+	z, ok := chunk.surfaceLocalZ(localX, localY)
+	if !ok {
+		return 0, false
+	}
+	return chunk.Bounds.Min.Z + z, true
+}
+
+// cachedBlock returns the block at coord, loading its chunk through cache. A
+// chunk that fails to load is remembered as nil so it is not retried.
+func (m *Manager) cachedBlock(ctx context.Context, cache map[ChunkCoord]*Chunk, coord BlockCoord) (Block, bool) {
+	chunkCoord, ok := m.region.LocateBlock(coord)
+	if !ok {
+		return Block{}, false
+	}
+	chunk, ok := cache[chunkCoord]
+	if !ok {
+		chunk, _ = m.Chunk(ctx, chunkCoord)
+		cache[chunkCoord] = chunk
+	}
+	if chunk == nil {
+		return Block{}, false
+	}
+	localX, localY, localZ, ok := chunk.GlobalToLocal(coord)
+	if !ok {
+		return Block{}, false
+	}
+	return chunk.LocalBlock(localX, localY, localZ)
+}
+
 func (m *Manager) EvaluateColumnStability(ctx context.Context, coord ChunkCoord, localX, localY int) ([]StabilityReport, error) {
 	chunk, err := m.Chunk(ctx, coord)
 	if err != nil {
 		return nil, err
 	}
-	return chunk.EvaluateColumnStability(localX, localY)
+	return evaluateColumnStability(chunk, localX, localY, m.StabilityParams())
+}
+
+// SetStabilityParams changes the stability model used by later collapse
+// cascades, placements, and predictions. Blocks already standing are not
+// re-evaluated until something changes their column.
+func (m *Manager) SetStabilityParams(params StabilityParams) {
+	m.stabilityMu.Lock()
+	m.stability = params
+	m.stabilityMu.Unlock()
+}
+
+func (m *Manager) StabilityParams() StabilityParams {
+	m.stabilityMu.RLock()
+	defer m.stabilityMu.RUnlock()
+	return m.stability
+}
+
+// PredictStability evaluates the column holding coord as if that block had been
+// removed, without changing the world. The reports cover the blocks left in
+// the column, so callers can see what would collapse and how close the rest
+// would come to failing.
+func (m *Manager) PredictStability(ctx context.Context, coord BlockCoord) ([]StabilityReport, error) {
+	chunk, err := m.ChunkForBlock(ctx, coord)
+	if err != nil {
+		return nil, err
+	}
+	localX, localY, localZ, ok := chunk.GlobalToLocal(coord)
+	if !ok {
+		return nil, fmt.Errorf("block %v outside chunk %v", coord, chunk.Key)
+	}
+	column, err := columnBlocks(chunk, localX, localY)
+	if err != nil {
+		return nil, err
+	}
+	column[localZ] = Block{Type: BlockAir}
+	return evaluateColumn(column, BlockCoord{X: coord.X, Y: coord.Y, Z: chunk.Bounds.Min.Z}, m.StabilityParams()), nil
 }
 
+// ApplyBlockDamage damages the block at coord and runs the stability cascade
+// over its column. The resulting changes are journaled for Undo.
 func (m *Manager) ApplyBlockDamage(ctx context.Context, coord BlockCoord, amount float64) (*DamageSummary, error) {
+	summary, err := m.applyBlockDamage(ctx, coord, amount)
+	if err != nil {
+		return nil, err
+	}
+	m.recordEdit(summary)
+	return summary, nil
+}
+
+func (m *Manager) applyBlockDamage(ctx context.Context, coord BlockCoord, amount float64) (*DamageSummary, error) {
 	summary := NewDamageSummary()
 	if amount <= 0 {
 		return summary, nil
@@ -297,35 +595,75 @@ func (m *Manager) ApplyBlockDamage(ctx context.Context, coord BlockCoord, amount
 	return summary, nil
 }
 
-func (m *Manager) ApplyExplosion(ctx context.Context, center BlockCoord, radius float64, maxDamage float64) (*DamageSummary, error) {
+// ApplyBlockHeal restores up to amount hit points to the block at coord,
+// never past its MaxHitPoints, and reports the repair as a ReasonRepair
+// change. Air and blocks already at full health are left alone. The repair is
+// journaled for Undo.
+func (m *Manager) ApplyBlockHeal(ctx context.Context, coord BlockCoord, amount float64) (*DamageSummary, error) {
+	summary := NewDamageSummary()
+	if amount <= 0 {
+		return summary, nil
+	}
+
+	chunkCoord, ok := m.region.LocateBlock(coord)
+	if !ok {
+		return summary, nil
+	}
+
+	chunk, err := m.Chunk(ctx, chunkCoord)
+	if err != nil {
+		return nil, err
+	}
+
+	localX, localY, localZ, ok := chunk.GlobalToLocal(coord)
+	if !ok {
+		return summary, nil
+	}
+
+	before, ok := chunk.LocalBlock(localX, localY, localZ)
+	if !ok || before.Type == BlockAir {
+		return summary, nil
+	}
+	beforeCopy := cloneBlock(before)
+
+	after, changed := chunk.HealLocalBlock(localX, localY, localZ, amount)
+	if !changed {
+		return summary, nil
+	}
+	summary.AddChange(BlockChange{
+		Coord:  coord,
+		Before: beforeCopy,
+		After:  after,
+		Reason: ReasonRepair,
+	})
+	summary.AddChunk(chunkCoord)
+	m.recordEdit(summary)
+	return summary, nil
+}
+
+// ApplyExplosion damages every block within radius of center, scaling
+// maxDamage by falloff according to each block's distance. The whole blast is
+// journaled as a single mutation.
+func (m *Manager) ApplyExplosion(ctx context.Context, center BlockCoord, radius float64, maxDamage float64, falloff Falloff) (*DamageSummary, error) {
 	summary := NewDamageSummary()
 	if radius <= 0 || maxDamage <= 0 {
 		return summary, nil
 	}
 
+	// Only the part of the blast inside the region can damage anything.
 	radiusCeil := int(math.Ceil(radius))
-	minX := center.X - radiusCeil
-	maxX := center.X + radiusCeil
-	minY := center.Y - radiusCeil
-	maxY := center.Y + radiusCeil
-	minZ := center.Z - radiusCeil
-	maxZ := center.Z + radiusCeil
+	extent := m.region.GlobalBlockBounds()
+	minX := max(center.X-radiusCeil, extent.Min.X)
+	maxX := min(center.X+radiusCeil, extent.Max.X)
+	minY := max(center.Y-radiusCeil, extent.Min.Y)
+	maxY := min(center.Y+radiusCeil, extent.Max.Y)
+	minZ := max(center.Z-radiusCeil, extent.Min.Z)
+	maxZ := min(center.Z+radiusCeil, extent.Max.Z)
 
 	for x := minX; x <= maxX; x++ {
 		for y := minY; y <= maxY; y++ {
 			for z := minZ; z <= maxZ; z++ {
 				blockCoord := BlockCoord{X: x, Y: y, Z: z}
-				if blockCoord.Z < 0 {
-					continue
-				}
-				// Skip blocks outside region early.
-				if !m.region.ContainsGlobalChunk(ChunkCoord{
-					X: floorDiv(blockCoord.X, m.region.ChunkDimension.Width),
-					Y: floorDiv(blockCoord.Y, m.region.ChunkDimension.Depth),
-				}) {
-					continue
-				}
-
 				dx := float64(x - center.X)
 				dy := float64(y - center.Y)
 				dz := float64(z - center.Z)
@@ -333,11 +671,11 @@ func (m *Manager) ApplyExplosion(ctx context.Context, center BlockCoord, radius
 				if distance > radius {
 					continue
 				}
-				damage := maxDamage * (1 - distance/radius)
+				damage := maxDamage * falloff.Scale(distance, radius)
 				if damage <= 0 {
 					continue
 				}
-				partial, err := m.ApplyBlockDamage(ctx, blockCoord, damage)
+				partial, err := m.applyBlockDamage(ctx, blockCoord, damage)
 				if err != nil {
 					return nil, err
 				}
@@ -346,6 +684,7 @@ func (m *Manager) ApplyExplosion(ctx context.Context, center BlockCoord, radius
 		}
 	}
 
+	m.recordEdit(summary)
 	return summary, nil
 }
 
@@ -366,6 +705,7 @@ func (m *Manager) cascadeColumns(ctx context.Context, starts []columnRef, summar
 	if len(starts) == 0 {
 		return nil
 	}
+	params := m.StabilityParams()
 	visited := make(map[columnRef]struct{})
 	queue := append([]columnRef(nil), starts...)
 
@@ -389,7 +729,7 @@ func (m *Manager) cascadeColumns(ctx context.Context, starts []columnRef, summar
 			continue
 		}
 
-		reports, err := chunk.EvaluateColumnStability(current.LocalX, current.LocalY)
+		reports, err := evaluateColumnStability(chunk, current.LocalX, current.LocalY, params)
 		if err != nil {
 			return err
 		}
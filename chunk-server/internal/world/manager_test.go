@@ -4,14 +4,34 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"image"
 	"image/png"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"chunkserver/internal/logging"
 )
 
+// floorGenerator fills the bottom layer of every chunk with stone.
+type floorGenerator struct{}
+
+func (floorGenerator) Generate(ctx context.Context, coord ChunkCoord, bounds Bounds, dim Dimensions) (*Chunk, error) {
+	chunk := NewChunkWithStorage(coord, bounds, dim, StorageProviderFromContext(ctx))
+	for x := 0; x < dim.Width; x++ {
+		for y := 0; y < dim.Depth; y++ {
+			chunk.SetLocalBlock(x, y, 0, Block{Type: BlockSolid, Material: "stone"})
+		}
+	}
+	return chunk, nil
+}
+
 type stubPreviewGenerator struct {
 	block Block
 }
@@ -37,8 +57,9 @@ func TestManagerGeneratesChunkPreview(t *testing.T) {
 	})
 
 	region := ServerRegion{
-		Origin:        ChunkCoord{X: 0, Y: 0},
-		ChunksPerAxis: 1,
+		Origin:  ChunkCoord{X: 0, Y: 0},
+		ChunksX: 1,
+		ChunksY: 1,
 		ChunkDimension: Dimensions{
 			Width:  4,
 			Depth:  4,
@@ -132,3 +153,424 @@ func extractBounds(img image.Image) imageBounds {
 
 	return result
 }
+
+// stoppableGenerator works in small steps until its context is cancelled or
+// release is closed, reporting how each generation ended on done.
+type stoppableGenerator struct {
+	started chan struct{}
+	release chan struct{}
+	done    chan error
+}
+
+func newStoppableGenerator() *stoppableGenerator {
+	return &stoppableGenerator{
+		started: make(chan struct{}, 4),
+		release: make(chan struct{}),
+		done:    make(chan error, 4),
+	}
+}
+
+func (g *stoppableGenerator) Generate(ctx context.Context, coord ChunkCoord, bounds Bounds, dim Dimensions) (*Chunk, error) {
+	g.started <- struct{}{}
+	for {
+		select {
+		case <-ctx.Done():
+			g.done <- ctx.Err()
+			return nil, ctx.Err()
+		case <-g.release:
+			g.done <- nil
+			return NewChunk(coord, bounds, dim), nil
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func newStoppableManager(t *testing.T) (*Manager, *stoppableGenerator) {
+	t.Helper()
+	chdirTemp(t)
+	region := ServerRegion{ChunksX: 2, ChunksY: 2, ChunkDimension: Dimensions{Width: 2, Depth: 2, Height: 2}}
+	gen := newStoppableGenerator()
+	return NewManager(region, gen), gen
+}
+
+func awaitChunk(manager *Manager, ctx context.Context, coord ChunkCoord) chan error {
+	result := make(chan error, 1)
+	go func() {
+		_, err := manager.Chunk(ctx, coord)
+		result <- err
+	}()
+	return result
+}
+
+func TestChunkGenerationCancelledWhenAllAwaitersCancel(t *testing.T) {
+	manager, gen := newStoppableManager(t)
+	coord := ChunkCoord{X: 0, Y: 0}
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	defer cancelA()
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+	first := awaitChunk(manager, ctxA, coord)
+	<-gen.started
+	second := awaitChunk(manager, ctxB, coord)
+	waitFor(t, func() bool {
+		manager.mu.RLock()
+		defer manager.mu.RUnlock()
+		future := manager.pending[coord]
+		return future != nil && future.waiters == 2
+	})
+
+	cancelA()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected first caller to see its cancellation, got %v", err)
+	}
+	select {
+	case err := <-gen.done:
+		t.Fatalf("expected generation to continue while a caller still waits, ended with %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	cancelB()
+	if err := <-second; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected second caller to see its cancellation, got %v", err)
+	}
+	select {
+	case err := <-gen.done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected generation to stop on cancellation, ended with %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected generation to stop once every awaiter cancelled")
+	}
+
+	// A later request starts a fresh generation rather than inheriting the
+	// cancelled one.
+	result := awaitChunk(manager, context.Background(), coord)
+	<-gen.started
+	close(gen.release)
+	if err := <-result; err != nil {
+		t.Fatalf("expected fresh generation to succeed, got %v", err)
+	}
+}
+
+func TestChunkGenerationPinnedByEnsureSurvivesCancelledAwaiters(t *testing.T) {
+	manager, gen := newStoppableManager(t)
+	coord := ChunkCoord{X: 1, Y: 0}
+
+	if err := manager.EnsureChunk(coord); err != nil {
+		t.Fatalf("ensure chunk: %v", err)
+	}
+	<-gen.started
+	ctx, cancel := context.WithCancel(context.Background())
+	result := awaitChunk(manager, ctx, coord)
+	cancel()
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected caller to see its cancellation, got %v", err)
+	}
+	select {
+	case err := <-gen.done:
+		t.Fatalf("expected pinned generation to continue, ended with %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(gen.release)
+	if err := <-gen.done; err != nil {
+		t.Fatalf("expected pinned generation to complete, got %v", err)
+	}
+	waitFor(t, func() bool {
+		_, ok := manager.cachedChunk(coord)
+		return ok
+	})
+}
+
+// lingeringGenerator keeps working after cancellation until release is
+// closed, like a generator still flushing its buffered columns, and records
+// how many generations ran at once.
+type lingeringGenerator struct {
+	started   chan struct{}
+	release   chan struct{}
+	active    atomic.Int32
+	maxActive atomic.Int32
+}
+
+func (g *lingeringGenerator) Generate(ctx context.Context, coord ChunkCoord, bounds Bounds, dim Dimensions) (*Chunk, error) {
+	active := g.active.Add(1)
+	defer g.active.Add(-1)
+	for {
+		peak := g.maxActive.Load()
+		if active <= peak || g.maxActive.CompareAndSwap(peak, active) {
+			break
+		}
+	}
+	g.started <- struct{}{}
+	<-g.release
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return NewChunk(coord, bounds, dim), nil
+}
+
+func TestAbandonedGenerationFinishesBeforeTheNextStarts(t *testing.T) {
+	chdirTemp(t)
+	gen := &lingeringGenerator{started: make(chan struct{}, 4), release: make(chan struct{})}
+	manager := NewManager(ServerRegion{ChunksX: 1, ChunksY: 1, ChunkDimension: Dimensions{Width: 2, Depth: 2, Height: 2}}, gen)
+	coord := ChunkCoord{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := awaitChunk(manager, ctx, coord)
+	<-gen.started
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected caller to see its cancellation, got %v", err)
+	}
+
+	second := awaitChunk(manager, context.Background(), coord)
+	select {
+	case <-gen.started:
+		t.Fatal("expected the next generation to wait for the abandoned one")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(gen.release)
+	if err := <-second; err != nil {
+		t.Fatalf("expected the next generation to succeed, got %v", err)
+	}
+	if peak := gen.maxActive.Load(); peak != 1 {
+		t.Fatalf("expected generations of one chunk never to overlap, %d ran at once", peak)
+	}
+}
+
+func TestCancelledQueuedChunkLoadIsDropped(t *testing.T) {
+	manager, gen := newStoppableManager(t)
+	manager.SetMaxConcurrentLoads(1)
+
+	busy := ChunkCoord{X: 0, Y: 0}
+	if err := manager.EnsureChunk(busy); err != nil {
+		t.Fatalf("ensure busy chunk: %v", err)
+	}
+	<-gen.started
+
+	queued := ChunkCoord{X: 0, Y: 1}
+	ctx, cancel := context.WithCancel(context.Background())
+	result := awaitChunk(manager, ctx, queued)
+	waitFor(t, func() bool {
+		manager.loadMu.Lock()
+		defer manager.loadMu.Unlock()
+		_, ok := manager.queuedLoads[queued]
+		return ok
+	})
+	cancel()
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected caller to see its cancellation, got %v", err)
+	}
+
+	manager.loadMu.Lock()
+	remaining := manager.loadQueue.Len()
+	manager.loadMu.Unlock()
+	if remaining != 0 {
+		t.Fatalf("expected cancelled load to leave the queue, %d remain", remaining)
+	}
+	close(gen.release)
+	<-gen.done
+	select {
+	case <-gen.started:
+		t.Fatal("expected the dropped load never to start generating")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within a second")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// storageFiles lists every file beneath dir.
+func storageFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk %s: %v", dir, err)
+	}
+	return files
+}
+
+func TestManagerStorageProviderSelectsBackend(t *testing.T) {
+	chdirTemp(t)
+	region := ServerRegion{ChunksX: 2, ChunksY: 1, ChunkDimension: Dimensions{Width: 4, Depth: 4, Height: 4}}
+
+	// The global provider writes to disk, so a memory manager that leaked
+	// through to it would leave files behind.
+	globalDir := t.TempDir()
+	original := getStorageProvider()
+	SetStorageProvider(NewDiskStorageProvider(globalDir, region))
+	t.Cleanup(func() { SetStorageProvider(original) })
+
+	memory := NewManager(region, floorGenerator{})
+	memory.SetStorageProvider(NewMemoryStorageProvider())
+	chunk, err := memory.Chunk(context.Background(), ChunkCoord{X: 1, Y: 0})
+	if err != nil {
+		t.Fatalf("memory manager chunk: %v", err)
+	}
+	if block, ok := chunk.LocalBlock(2, 2, 0); !ok || block.Material != "stone" {
+		t.Fatalf("memory chunk floor = %+v, want stone", block)
+	}
+	if files := storageFiles(t, globalDir); len(files) != 0 {
+		t.Fatalf("expected a memory manager to write no chunk files, found %v", files)
+	}
+
+	diskDir := t.TempDir()
+	disk := NewManager(region, floorGenerator{})
+	disk.SetStorageProvider(NewDiskStorageProvider(diskDir, region))
+	if _, err := disk.Chunk(context.Background(), ChunkCoord{X: 1, Y: 0}); err != nil {
+		t.Fatalf("disk manager chunk: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(diskDir, "1", "0", "chunk02.bin")); err != nil {
+		t.Fatalf("expected the disk manager to write its chunk file: %v", err)
+	}
+	if files := storageFiles(t, globalDir); len(files) != 0 {
+		t.Fatalf("expected the disk manager to keep to its own directory, found %v in the global one", files)
+	}
+}
+
+func TestManagersReportToTheirOwnLoggers(t *testing.T) {
+	chdirTemp(t)
+	region := ServerRegion{ChunksX: 1, ChunksY: 1, ChunkDimension: Dimensions{Width: 4, Depth: 4, Height: 4}}
+
+	// Each manager finds its chunk half generated, which it reports as it
+	// discards the partial columns.
+	newManager := func(out *bytes.Buffer) *Manager {
+		dir := t.TempDir()
+		provider := NewDiskStorageProvider(dir, region)
+		store, err := provider.NewStorage(ChunkCoord{}, Bounds{}, region.ChunkDimension)
+		if err != nil {
+			t.Fatalf("open chunk storage: %v", err)
+		}
+		if err := store.(generationMarker).SetGenerating(true); err != nil {
+			t.Fatalf("mark chunk generating: %v", err)
+		}
+		store.Close()
+
+		manager := NewManager(region, floorGenerator{})
+		manager.SetStorageProvider(provider)
+		manager.SetLogger(logging.New(out, slog.LevelInfo))
+		return manager
+	}
+	var firstOut, secondOut bytes.Buffer
+	first := newManager(&firstOut)
+	second := newManager(&secondOut)
+
+	if _, err := first.Chunk(context.Background(), ChunkCoord{}); err != nil {
+		t.Fatalf("first manager chunk: %v", err)
+	}
+	if !strings.Contains(firstOut.String(), "left unfinished") {
+		t.Fatalf("expected the first manager's logger to report the discarded generation, got %q", firstOut.String())
+	}
+	if secondOut.Len() != 0 {
+		t.Fatalf("expected the second manager's logger to stay quiet, got %q", secondOut.String())
+	}
+
+	if _, err := second.Chunk(context.Background(), ChunkCoord{}); err != nil {
+		t.Fatalf("second manager chunk: %v", err)
+	}
+	if !strings.Contains(secondOut.String(), "left unfinished") {
+		t.Fatalf("expected the second manager's logger to report the discarded generation, got %q", secondOut.String())
+	}
+}
+
+// taggedStorageProvider hands out memory storage labelled with the provider
+// that created it.
+type taggedStorageProvider struct {
+	tag     string
+	mu      sync.Mutex
+	created int
+}
+
+type taggedStorage struct {
+	BlockStorage
+	tag string
+}
+
+func (p *taggedStorageProvider) NewStorage(key ChunkCoord, bounds Bounds, dim Dimensions) (BlockStorage, error) {
+	p.mu.Lock()
+	p.created++
+	p.mu.Unlock()
+	inner, err := newMemoryStorageProvider().NewStorage(key, bounds, dim)
+	if err != nil {
+		return nil, err
+	}
+	return &taggedStorage{BlockStorage: inner, tag: p.tag}, nil
+}
+
+// TestConcurrentManagersKeepTheirOwnStorage is meant to run under -race:
+// managers with different providers load chunks at the same time while the
+// global provider is being swapped.
+func TestConcurrentManagersKeepTheirOwnStorage(t *testing.T) {
+	chdirTemp(t)
+	region := ServerRegion{ChunksX: 3, ChunksY: 3, ChunkDimension: Dimensions{Width: 2, Depth: 2, Height: 2}}
+	original := getStorageProvider()
+	t.Cleanup(func() { SetStorageProvider(original) })
+
+	const managers = 4
+	providers := make([]*taggedStorageProvider, managers)
+	chunks := make([][]*Chunk, managers)
+	errs := make(chan error, managers)
+	var wg sync.WaitGroup
+	for i := range providers {
+		providers[i] = &taggedStorageProvider{tag: fmt.Sprintf("manager-%d", i)}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			manager := NewManager(region, floorGenerator{})
+			manager.SetStorageProvider(providers[i])
+			for y := 0; y < region.ChunksY; y++ {
+				for x := 0; x < region.ChunksX; x++ {
+					chunk, err := manager.Chunk(context.Background(), ChunkCoord{X: x, Y: y})
+					if err != nil {
+						errs <- err
+						return
+					}
+					chunks[i] = append(chunks[i], chunk)
+				}
+			}
+		}(i)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			SetStorageProvider(&taggedStorageProvider{tag: "global"})
+			_ = CurrentStorageProvider()
+		}
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("load chunk: %v", err)
+	}
+
+	for i, provider := range providers {
+		if len(chunks[i]) != region.ChunkCount() || provider.created != region.ChunkCount() {
+			t.Fatalf("manager %d loaded %d chunks from %d storages, want %d", i, len(chunks[i]), provider.created, region.ChunkCount())
+		}
+		for _, chunk := range chunks[i] {
+			store, ok := chunk.store.(*taggedStorage)
+			if !ok || store.tag != provider.tag {
+				t.Fatalf("chunk %v of manager %d is stored in %#v, want %s", chunk.Key, i, chunk.store, provider.tag)
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package world
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// PlaceBlock puts block at coord if the stability model says it can hold
+// there. A block is supported when it rests on the ground or on another block,
+// or hangs from the block above it, and adding its weight collapses nothing in
+// its column. Unsupported placements are refused and report placed as false.
+//
+// With force set the block is written regardless, without running a collapse
+// cascade, and the summary flags it through UnstableBlocks. Placing air is not
+// a placement; use ApplyBlockEdits to remove blocks. Placements are journaled
+// for Undo.
+func (m *Manager) PlaceBlock(ctx context.Context, coord BlockCoord, block Block, force bool) (bool, *DamageSummary, error) {
+	if blockIsAir(block) {
+		return false, nil, errors.New("cannot place an air block")
+	}
+	chunkCoord, ok := m.region.LocateBlock(coord)
+	if !ok {
+		return false, nil, fmt.Errorf("block %v outside region", coord)
+	}
+	chunk, err := m.Chunk(ctx, chunkCoord)
+	if err != nil {
+		return false, nil, err
+	}
+	localX, localY, localZ, ok := chunk.GlobalToLocal(coord)
+	if !ok {
+		return false, nil, fmt.Errorf("block %v outside chunk %v", coord, chunkCoord)
+	}
+	column, err := columnBlocks(chunk, localX, localY)
+	if err != nil {
+		return false, nil, err
+	}
+
+	before := column[localZ]
+	after := cloneBlock(block)
+	column[localZ] = after
+	base := BlockCoord{X: coord.X, Y: coord.Y, Z: chunk.Bounds.Min.Z}
+	supported := placementSupported(column, localZ, base, m.StabilityParams())
+	if !supported && !force {
+		return false, nil, nil
+	}
+
+	if !chunk.SetLocalBlock(localX, localY, localZ, after) {
+		return false, nil, fmt.Errorf("write block %v", coord)
+	}
+	summary := NewDamageSummary()
+	summary.AddChange(BlockChange{
+		Coord:  coord,
+		Before: cloneBlock(before),
+		After:  after,
+		Reason: ReasonEdit,
+	})
+	summary.AddChunk(chunkCoord)
+	if !supported {
+		summary.MarkUnstable(coord)
+	}
+	m.recordEdit(summary)
+	return true, summary, nil
+}
+
+// placementSupported reports whether the block at z in column is anchored and
+// the column stands with it in place.
+func placementSupported(column []Block, z int, base BlockCoord, params StabilityParams) bool {
+	anchored := z == 0 ||
+		!blockIsAir(column[z-1]) ||
+		(z+1 < len(column) && !blockIsAir(column[z+1]))
+	if !anchored {
+		return false
+	}
+	for _, report := range evaluateColumn(column, base, params) {
+		if report.Collapsed {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package world
+
+import (
+	"context"
+	"testing"
+)
+
+func newPlacementManager(t *testing.T) *Manager {
+	t.Helper()
+	chdirTemp(t)
+	return NewManager(ServerRegion{
+		ChunksX:        1,
+		ChunksY:        1,
+		ChunkDimension: Dimensions{Width: 4, Depth: 4, Height: 4},
+	}, emptyGenerator{})
+}
+
+func TestPlaceBlockOnGroundSucceeds(t *testing.T) {
+	manager := newPlacementManager(t)
+	ctx := context.Background()
+	block := Block{Type: BlockSolid, Weight: 10, ConnectingForce: 50}
+
+	placed, summary, err := manager.PlaceBlock(ctx, BlockCoord{X: 1, Y: 1, Z: 0}, block, false)
+	if err != nil {
+		t.Fatalf("PlaceBlock() error = %v", err)
+	}
+	if !placed {
+		t.Fatalf("expected block on the ground to be placed")
+	}
+	if unstable := summary.UnstableBlocks(); len(unstable) != 0 {
+		t.Fatalf("grounded block flagged unstable: %v", unstable)
+	}
+
+	placed, _, err = manager.PlaceBlock(ctx, BlockCoord{X: 1, Y: 1, Z: 1}, block, false)
+	if err != nil || !placed {
+		t.Fatalf("expected block on top of the first to be placed, placed=%v err=%v", placed, err)
+	}
+}
+
+func TestPlaceBlockInMidAirRequiresForce(t *testing.T) {
+	manager := newPlacementManager(t)
+	ctx := context.Background()
+	coord := BlockCoord{X: 2, Y: 2, Z: 2}
+	block := Block{Type: BlockSolid, Weight: 10, ConnectingForce: 50}
+
+	placed, summary, err := manager.PlaceBlock(ctx, coord, block, false)
+	if err != nil {
+		t.Fatalf("PlaceBlock() error = %v", err)
+	}
+	if placed || summary != nil {
+		t.Fatalf("expected floating placement to be refused, placed=%v summary=%v", placed, summary)
+	}
+	chunk, err := manager.Chunk(ctx, ChunkCoord{})
+	if err != nil {
+		t.Fatalf("load chunk: %v", err)
+	}
+	if existing, _ := chunk.LocalBlock(2, 2, 2); !blockIsAir(existing) {
+		t.Fatalf("refused placement wrote %q", existing.Type)
+	}
+
+	placed, summary, err = manager.PlaceBlock(ctx, coord, block, true)
+	if err != nil {
+		t.Fatalf("PlaceBlock(force) error = %v", err)
+	}
+	if !placed {
+		t.Fatalf("expected forced placement to succeed")
+	}
+	unstable := summary.UnstableBlocks()
+	if len(unstable) != 1 || unstable[0] != coord {
+		t.Fatalf("expected %v flagged unstable, got %v", coord, unstable)
+	}
+	if existing, _ := chunk.LocalBlock(2, 2, 2); existing.Type != BlockSolid {
+		t.Fatalf("forced placement left %q", existing.Type)
+	}
+}
+
+func TestPlaceBlockRefusesOverloadingColumn(t *testing.T) {
+	manager := newPlacementManager(t)
+	ctx := context.Background()
+	if _, err := manager.ApplyBlockEdits(ctx, towerEdits()); err != nil {
+		t.Fatalf("build tower: %v", err)
+	}
+
+	heavy := Block{Type: BlockSolid, Weight: 40, ConnectingForce: 50}
+	placed, _, err := manager.PlaceBlock(ctx, BlockCoord{X: 1, Y: 1, Z: 3}, heavy, false)
+	if err != nil {
+		t.Fatalf("PlaceBlock() error = %v", err)
+	}
+	if placed {
+		t.Fatalf("expected placement that would collapse the tower to be refused")
+	}
+}
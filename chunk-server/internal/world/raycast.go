@@ -0,0 +1,65 @@
+package world
+
+import (
+	"context"
+	"math"
+)
+
+// RaycastBlock walks the ray from origin along dir, block by block, and
+// returns the first block that is not passable within maxDist blocks of
+// origin. The block containing origin counts. Blocks outside the region, or in
+// chunks that fail to load, are treated as open. ok is false when the ray
+// reaches maxDist without hitting anything, when dir is zero, or when ctx is
+// done.
+func (m *Manager) RaycastBlock(ctx context.Context, origin, dir Vec3, maxDist float64) (BlockCoord, Block, bool) {
+	length := math.Sqrt(dir.X*dir.X + dir.Y*dir.Y + dir.Z*dir.Z)
+	if length == 0 || maxDist < 0 {
+		return BlockCoord{}, Block{}, false
+	}
+	d := [3]float64{dir.X / length, dir.Y / length, dir.Z / length}
+	o := [3]float64{origin.X, origin.Y, origin.Z}
+	start := BlockFromVec(origin)
+	cell := [3]int{start.X, start.Y, start.Z}
+
+	// tMax is the distance along the ray to the next boundary on each axis,
+	// and tDelta the distance between boundaries on it (Amanatides & Woo).
+	var step [3]int
+	var tMax, tDelta [3]float64
+	for axis := 0; axis < 3; axis++ {
+		switch {
+		case d[axis] > 0:
+			step[axis] = 1
+			tMax[axis] = (float64(cell[axis]+1) - o[axis]) / d[axis]
+			tDelta[axis] = 1 / d[axis]
+		case d[axis] < 0:
+			step[axis] = -1
+			tMax[axis] = (float64(cell[axis]) - o[axis]) / d[axis]
+			tDelta[axis] = -1 / d[axis]
+		default:
+			tMax[axis] = math.Inf(1)
+			tDelta[axis] = math.Inf(1)
+		}
+	}
+
+	cache := make(map[ChunkCoord]*Chunk)
+	for t := 0.0; t <= maxDist; {
+		if ctx.Err() != nil {
+			return BlockCoord{}, Block{}, false
+		}
+		coord := BlockCoord{X: cell[0], Y: cell[1], Z: cell[2]}
+		if block, ok := m.cachedBlock(ctx, cache, coord); ok && !block.Type.Properties().Passable {
+			return coord, block, true
+		}
+		axis := 0
+		if tMax[1] < tMax[axis] {
+			axis = 1
+		}
+		if tMax[2] < tMax[axis] {
+			axis = 2
+		}
+		t = tMax[axis]
+		cell[axis] += step[axis]
+		tMax[axis] += tDelta[axis]
+	}
+	return BlockCoord{}, Block{}, false
+}
@@ -0,0 +1,79 @@
+package world
+
+import (
+	"context"
+	"testing"
+)
+
+// wallGenerator builds a stone floor at z=0 and a wall filling the plane
+// x=10 up to z=5.
+type wallGenerator struct{}
+
+func (wallGenerator) Generate(ctx context.Context, coord ChunkCoord, bounds Bounds, dim Dimensions) (*Chunk, error) {
+	chunk := NewChunk(coord, bounds, dim)
+	for x := 0; x < dim.Width; x++ {
+		for y := 0; y < dim.Depth; y++ {
+			chunk.SetLocalBlock(x, y, 0, Block{Type: BlockSolid, Material: "stone"})
+			if bounds.Min.X+x == 10 {
+				for z := 1; z <= 5; z++ {
+					chunk.SetLocalBlock(x, y, z, Block{Type: BlockSolid, Material: "wall"})
+				}
+			}
+		}
+	}
+	return chunk, nil
+}
+
+func newRaycastManager(t *testing.T) *Manager {
+	t.Helper()
+	chdirTemp(t)
+	return NewManager(ServerRegion{
+		ChunksX:        2,
+		ChunksY:        2,
+		ChunkDimension: Dimensions{Width: 8, Depth: 8, Height: 12},
+	}, wallGenerator{})
+}
+
+func TestRaycastBlockHitsFirstSolidCell(t *testing.T) {
+	manager := newRaycastManager(t)
+	ctx := context.Background()
+
+	cases := []struct {
+		name   string
+		origin Vec3
+		dir    Vec3
+		want   BlockCoord
+	}{
+		{"straight across chunks", Vec3{X: 2.5, Y: 3.5, Z: 2.5}, Vec3{X: 1}, BlockCoord{X: 10, Y: 3, Z: 2}},
+		{"diagonal", Vec3{X: 4.5, Y: 4.5, Z: 2.5}, Vec3{X: 1, Y: 0.5}, BlockCoord{X: 10, Y: 7, Z: 2}},
+		{"down to the floor", Vec3{X: 3.5, Y: 3.5, Z: 6.5}, Vec3{X: 0.5, Z: -1}, BlockCoord{X: 6, Y: 3, Z: 0}},
+		{"from behind the wall", Vec3{X: 14.5, Y: 2.5, Z: 3.5}, Vec3{X: -1}, BlockCoord{X: 10, Y: 2, Z: 3}},
+	}
+	for _, tc := range cases {
+		coord, block, ok := manager.RaycastBlock(ctx, tc.origin, tc.dir, 20)
+		if !ok {
+			t.Fatalf("%s: ray missed", tc.name)
+		}
+		if coord != tc.want || block.Type != BlockSolid {
+			t.Fatalf("%s: hit %v (%s), want solid %v", tc.name, coord, block.Type, tc.want)
+		}
+	}
+}
+
+func TestRaycastBlockMissesWithinMaxDist(t *testing.T) {
+	manager := newRaycastManager(t)
+	ctx := context.Background()
+
+	if coord, _, ok := manager.RaycastBlock(ctx, Vec3{X: 3.5, Y: 3.5, Z: 2.5}, Vec3{Z: 1}, 50); ok {
+		t.Fatalf("ray into open sky hit %v", coord)
+	}
+	if coord, _, ok := manager.RaycastBlock(ctx, Vec3{X: 2.5, Y: 3.5, Z: 2.5}, Vec3{X: 1}, 7); ok {
+		t.Fatalf("ray stopping short of the wall hit %v", coord)
+	}
+	if _, _, ok := manager.RaycastBlock(ctx, Vec3{X: 2.5, Y: 3.5, Z: 2.5}, Vec3{X: 1}, 7.5); !ok {
+		t.Fatal("ray reaching the wall face missed it")
+	}
+	if _, _, ok := manager.RaycastBlock(ctx, Vec3{X: 2.5, Y: 3.5, Z: 2.5}, Vec3{}, 20); ok {
+		t.Fatal("zero direction hit something")
+	}
+}
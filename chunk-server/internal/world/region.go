@@ -38,37 +38,71 @@ type Bounds struct {
 	Max BlockCoord
 }
 
-// ServerRegion delineates the contiguous grid of chunks owned by this chunk server.
+// ServerRegion delineates the contiguous grid of chunks owned by this chunk
+// server: ChunksX chunks along X by ChunksY along Y, starting at Origin.
 type ServerRegion struct {
 	Origin         ChunkCoord
-	ChunksPerAxis  int
+	ChunksX        int
+	ChunksY        int
 	ChunkDimension Dimensions
 }
 
-func NewServerRegion(cfg *config.Config) ServerRegion {
-	return ServerRegion{
+// NewServerRegion builds the region described by cfg. It rejects regions with
+// no chunks or with chunks that have no blocks along some axis.
+func NewServerRegion(cfg *config.Config) (ServerRegion, error) {
+	chunksX, chunksY := cfg.Chunk.RegionSize()
+	region := ServerRegion{
 		Origin: ChunkCoord{
 			X: cfg.Server.GlobalChunkOrigin.X,
 			Y: cfg.Server.GlobalChunkOrigin.Y,
 		},
-		ChunksPerAxis: cfg.Chunk.ChunksPerAxis,
+		ChunksX: chunksX,
+		ChunksY: chunksY,
 		ChunkDimension: Dimensions{
 			Width:  cfg.Chunk.Width,
 			Depth:  cfg.Chunk.Depth,
 			Height: cfg.Chunk.Height,
 		},
 	}
+	if err := region.Validate(); err != nil {
+		return ServerRegion{}, err
+	}
+	return region, nil
+}
+
+// Validate reports why the region cannot hold any blocks: a non-positive
+// chunk count along X or Y, or a non-positive chunk width, depth, or height.
+func (r ServerRegion) Validate() error {
+	if r.ChunksX <= 0 || r.ChunksY <= 0 {
+		return fmt.Errorf("server region must span at least one chunk along each axis, got %dx%d", r.ChunksX, r.ChunksY)
+	}
+	dim := r.ChunkDimension
+	if dim.Width <= 0 || dim.Depth <= 0 || dim.Height <= 0 {
+		return fmt.Errorf("server region chunk dimensions must be positive, got width %d depth %d height %d", dim.Width, dim.Depth, dim.Height)
+	}
+	return nil
+}
+
+// ChunksPerAxis returns the side of a square region. Regions that are not
+// square report their X span; use ChunksX and ChunksY for those.
+func (r ServerRegion) ChunksPerAxis() int {
+	return r.ChunksX
+}
+
+// ChunkCount returns how many chunks the region owns.
+func (r ServerRegion) ChunkCount() int {
+	return r.ChunksX * r.ChunksY
 }
 
 func (r ServerRegion) ContainsGlobalChunk(coord ChunkCoord) bool {
 	return coord.X >= r.Origin.X &&
 		coord.Y >= r.Origin.Y &&
-		coord.X < r.Origin.X+r.ChunksPerAxis &&
-		coord.Y < r.Origin.Y+r.ChunksPerAxis
+		coord.X < r.Origin.X+r.ChunksX &&
+		coord.Y < r.Origin.Y+r.ChunksY
 }
 
 func (r ServerRegion) LocalToGlobalChunk(local LocalChunkIndex) (ChunkCoord, error) {
-	if local.X < 0 || local.Y < 0 || local.X >= r.ChunksPerAxis || local.Y >= r.ChunksPerAxis {
+	if local.X < 0 || local.Y < 0 || local.X >= r.ChunksX || local.Y >= r.ChunksY {
 		return ChunkCoord{}, fmt.Errorf("local chunk index %v out of range", local)
 	}
 	return ChunkCoord{
@@ -105,18 +139,73 @@ func (r ServerRegion) ChunkBounds(global ChunkCoord) (Bounds, error) {
 	return Bounds{Min: min, Max: max}, nil
 }
 
+// GlobalBlockBounds returns the block-space extent of every chunk the region
+// owns, from the ground to the top of the chunks.
+func (r ServerRegion) GlobalBlockBounds() Bounds {
+	min := BlockCoord{
+		X: r.Origin.X * r.ChunkDimension.Width,
+		Y: r.Origin.Y * r.ChunkDimension.Depth,
+		Z: 0,
+	}
+	max := BlockCoord{
+		X: (r.Origin.X+r.ChunksX)*r.ChunkDimension.Width - 1,
+		Y: (r.Origin.Y+r.ChunksY)*r.ChunkDimension.Depth - 1,
+		Z: r.ChunkDimension.Height - 1,
+	}
+	return Bounds{Min: min, Max: max}
+}
+
+// BlockOrigin returns the global coordinate of the region's first block, the
+// point region-relative block coordinates count from.
+func (r ServerRegion) BlockOrigin() BlockCoord {
+	return BlockCoord{X: r.Origin.X * r.ChunkDimension.Width, Y: r.Origin.Y * r.ChunkDimension.Depth}
+}
+
+// RegionToGlobalBlock converts a block coordinate relative to the region's
+// origin into a global one.
+func (r ServerRegion) RegionToGlobalBlock(block BlockCoord) BlockCoord {
+	origin := r.BlockOrigin()
+	return BlockCoord{X: block.X + origin.X, Y: block.Y + origin.Y, Z: block.Z}
+}
+
+// GlobalToRegionBlock converts a global block coordinate into one relative to
+// the region's origin.
+func (r ServerRegion) GlobalToRegionBlock(block BlockCoord) BlockCoord {
+	origin := r.BlockOrigin()
+	return BlockCoord{X: block.X - origin.X, Y: block.Y - origin.Y, Z: block.Z}
+}
+
+// ContainsBlock reports whether block lies in a chunk owned by the region.
+func (r ServerRegion) ContainsBlock(block BlockCoord) bool {
+	_, ok := r.LocateBlock(block)
+	return ok
+}
+
+// ChunkOfBlock returns the chunk whose column holds block, whether or not the
+// region owns it. Blocks at negative coordinates belong to negative chunks:
+// x=-1 is in chunk -1, not chunk 0.
+func (r ServerRegion) ChunkOfBlock(block BlockCoord) ChunkCoord {
+	return ChunkCoord{
+		X: FloorDiv(block.X, r.ChunkDimension.Width),
+		Y: FloorDiv(block.Y, r.ChunkDimension.Depth),
+	}
+}
+
+// LocateBlock returns the chunk holding block and whether the region owns it.
+// Blocks below the ground or above the chunk height are never owned.
 func (r ServerRegion) LocateBlock(block BlockCoord) (ChunkCoord, bool) {
 	if block.Z < 0 || block.Z >= r.ChunkDimension.Height {
 		return ChunkCoord{}, false
 	}
-	chunk := ChunkCoord{
-		X: floorDiv(block.X, r.ChunkDimension.Width),
-		Y: floorDiv(block.Y, r.ChunkDimension.Depth),
-	}
+	chunk := r.ChunkOfBlock(block)
 	return chunk, r.ContainsGlobalChunk(chunk)
 }
 
-func floorDiv(value, size int) int {
+// FloorDiv divides value by size rounding towards negative infinity, which is
+// how block coordinates map onto chunks. Go's integer division truncates
+// towards zero and would fold blocks -size+1..-1 into chunk 0. A size of zero
+// or less yields 0.
+func FloorDiv(value, size int) int {
 	if size <= 0 {
 		return 0
 	}
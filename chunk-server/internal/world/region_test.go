@@ -0,0 +1,218 @@
+package world
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"chunkserver/internal/config"
+)
+
+func TestGlobalBlockBoundsSpansEveryChunk(t *testing.T) {
+	region := ServerRegion{
+		Origin:         ChunkCoord{X: -1, Y: 2},
+		ChunksX:        3,
+		ChunksY:        3,
+		ChunkDimension: Dimensions{Width: 16, Depth: 8, Height: 32},
+	}
+	bounds := region.GlobalBlockBounds()
+	want := Bounds{
+		Min: BlockCoord{X: -16, Y: 16, Z: 0},
+		Max: BlockCoord{X: 31, Y: 39, Z: 31},
+	}
+	if bounds != want {
+		t.Fatalf("GlobalBlockBounds() = %+v, want %+v", bounds, want)
+	}
+
+	for x := 0; x < region.ChunksX; x++ {
+		for y := 0; y < region.ChunksY; y++ {
+			coord, err := region.LocalToGlobalChunk(LocalChunkIndex{X: x, Y: y})
+			if err != nil {
+				t.Fatalf("LocalToGlobalChunk(%d,%d) error = %v", x, y, err)
+			}
+			chunk, err := region.ChunkBounds(coord)
+			if err != nil {
+				t.Fatalf("ChunkBounds(%v) error = %v", coord, err)
+			}
+			if chunk.Min.X < bounds.Min.X || chunk.Min.Y < bounds.Min.Y ||
+				chunk.Max.X > bounds.Max.X || chunk.Max.Y > bounds.Max.Y || chunk.Max.Z > bounds.Max.Z {
+				t.Fatalf("chunk %v bounds %+v fall outside region bounds %+v", coord, chunk, bounds)
+			}
+		}
+	}
+}
+
+func TestContainsBlockClassifiesEdges(t *testing.T) {
+	region := ServerRegion{
+		Origin:         ChunkCoord{X: -1, Y: 2},
+		ChunksX:        3,
+		ChunksY:        3,
+		ChunkDimension: Dimensions{Width: 16, Depth: 8, Height: 32},
+	}
+	bounds := region.GlobalBlockBounds()
+	cases := []struct {
+		name  string
+		block BlockCoord
+		want  bool
+	}{
+		{name: "min corner", block: bounds.Min, want: true},
+		{name: "max corner", block: bounds.Max, want: true},
+		{name: "chunk seam", block: BlockCoord{X: 0, Y: 24, Z: 5}, want: true},
+		{name: "west of region", block: BlockCoord{X: bounds.Min.X - 1, Y: 20, Z: 0}, want: false},
+		{name: "north of region", block: BlockCoord{X: 0, Y: bounds.Min.Y - 1, Z: 0}, want: false},
+		{name: "east of region", block: BlockCoord{X: bounds.Max.X + 1, Y: 20, Z: 0}, want: false},
+		{name: "south of region", block: BlockCoord{X: 0, Y: bounds.Max.Y + 1, Z: 0}, want: false},
+		{name: "below ground", block: BlockCoord{X: 0, Y: 20, Z: -1}, want: false},
+		{name: "above chunks", block: BlockCoord{X: 0, Y: 20, Z: bounds.Max.Z + 1}, want: false},
+	}
+	for _, tc := range cases {
+		if got := region.ContainsBlock(tc.block); got != tc.want {
+			t.Errorf("%s: ContainsBlock(%v) = %t, want %t", tc.name, tc.block, got, tc.want)
+		}
+	}
+}
+
+func TestLocateBlockFloorsNegativeCoordinates(t *testing.T) {
+	region := ServerRegion{
+		Origin:         ChunkCoord{X: -2, Y: -1},
+		ChunksX:        2,
+		ChunksY:        2,
+		ChunkDimension: Dimensions{Width: 4, Depth: 4, Height: 4},
+	}
+	cases := []struct {
+		block BlockCoord
+		want  ChunkCoord
+		owned bool
+	}{
+		{block: BlockCoord{X: -1, Y: -1}, want: ChunkCoord{X: -1, Y: -1}, owned: true},
+		{block: BlockCoord{X: -4, Y: -4}, want: ChunkCoord{X: -1, Y: -1}, owned: true},
+		{block: BlockCoord{X: -5, Y: -5}, want: ChunkCoord{X: -2, Y: -2}, owned: false},
+		{block: BlockCoord{X: -8, Y: 3}, want: ChunkCoord{X: -2, Y: 0}, owned: true},
+		{block: BlockCoord{X: 0, Y: 0}, want: ChunkCoord{X: 0, Y: 0}, owned: false},
+	}
+	for _, tc := range cases {
+		if got := region.ChunkOfBlock(tc.block); got != tc.want {
+			t.Errorf("ChunkOfBlock(%v) = %v, want %v", tc.block, got, tc.want)
+		}
+		got, owned := region.LocateBlock(tc.block)
+		if owned != tc.owned || (owned && got != tc.want) {
+			t.Errorf("LocateBlock(%v) = %v, %t, want %v, %t", tc.block, got, owned, tc.want, tc.owned)
+		}
+		bounds, err := region.ChunkBounds(tc.want)
+		if tc.owned && (err != nil || tc.block.X < bounds.Min.X || tc.block.X > bounds.Max.X) {
+			t.Errorf("block %v outside the bounds %+v of its chunk %v (err %v)", tc.block, bounds, tc.want, err)
+		}
+	}
+
+	for _, tc := range []struct{ value, size, want int }{
+		{value: -1, size: 4, want: -1},
+		{value: -4, size: 4, want: -1},
+		{value: -5, size: 4, want: -2},
+		{value: 3, size: 4, want: 0},
+		{value: 5, size: 0, want: 0},
+	} {
+		if got := FloorDiv(tc.value, tc.size); got != tc.want {
+			t.Errorf("FloorDiv(%d, %d) = %d, want %d", tc.value, tc.size, got, tc.want)
+		}
+	}
+}
+
+func TestAsymmetricRegionSpansEachAxisSeparately(t *testing.T) {
+	region := ServerRegion{
+		Origin:         ChunkCoord{X: 4, Y: -1},
+		ChunksX:        3,
+		ChunksY:        2,
+		ChunkDimension: Dimensions{Width: 8, Depth: 4, Height: 16},
+	}
+	if got := region.ChunkCount(); got != 6 {
+		t.Fatalf("ChunkCount() = %d, want 6", got)
+	}
+
+	for _, tc := range []struct {
+		coord ChunkCoord
+		want  bool
+	}{
+		{coord: ChunkCoord{X: 4, Y: -1}, want: true},
+		{coord: ChunkCoord{X: 6, Y: 0}, want: true},
+		{coord: ChunkCoord{X: 6, Y: -1}, want: true},
+		{coord: ChunkCoord{X: 7, Y: 0}, want: false},
+		{coord: ChunkCoord{X: 4, Y: 1}, want: false},
+		{coord: ChunkCoord{X: 3, Y: 0}, want: false},
+		{coord: ChunkCoord{X: 5, Y: -2}, want: false},
+	} {
+		if got := region.ContainsGlobalChunk(tc.coord); got != tc.want {
+			t.Errorf("ContainsGlobalChunk(%v) = %t, want %t", tc.coord, got, tc.want)
+		}
+	}
+
+	bounds, err := region.ChunkBounds(ChunkCoord{X: 6, Y: 0})
+	if err != nil {
+		t.Fatalf("ChunkBounds of far corner: %v", err)
+	}
+	wantChunk := Bounds{Min: BlockCoord{X: 48, Y: 0, Z: 0}, Max: BlockCoord{X: 55, Y: 3, Z: 15}}
+	if bounds != wantChunk {
+		t.Fatalf("ChunkBounds(6,0) = %+v, want %+v", bounds, wantChunk)
+	}
+	if _, err := region.ChunkBounds(ChunkCoord{X: 4, Y: 1}); err == nil {
+		t.Fatalf("expected ChunkBounds to reject a chunk past the Y span")
+	}
+
+	wantRegion := Bounds{Min: BlockCoord{X: 32, Y: -4, Z: 0}, Max: BlockCoord{X: 55, Y: 3, Z: 15}}
+	if got := region.GlobalBlockBounds(); got != wantRegion {
+		t.Fatalf("GlobalBlockBounds() = %+v, want %+v", got, wantRegion)
+	}
+
+	coord, err := region.LocalToGlobalChunk(LocalChunkIndex{X: 2, Y: 1})
+	if err != nil || coord != (ChunkCoord{X: 6, Y: 0}) {
+		t.Fatalf("LocalToGlobalChunk(2,1) = %v, %v, want (6,0)", coord, err)
+	}
+	for _, local := range []LocalChunkIndex{{X: 3, Y: 0}, {X: 0, Y: 2}} {
+		if _, err := region.LocalToGlobalChunk(local); err == nil {
+			t.Errorf("expected LocalToGlobalChunk(%v) to be out of range", local)
+		}
+	}
+}
+
+func TestNewServerRegionRejectsDegenerateRegions(t *testing.T) {
+	cases := []struct {
+		name    string
+		mutate  func(*config.Config)
+		wantErr string
+	}{
+		{name: "zero width", mutate: func(cfg *config.Config) { cfg.Chunk.Width = 0 }, wantErr: "width 0"},
+		{name: "zero depth", mutate: func(cfg *config.Config) { cfg.Chunk.Depth = 0 }, wantErr: "depth 0"},
+		{name: "zero height", mutate: func(cfg *config.Config) { cfg.Chunk.Height = 0 }, wantErr: "height 0"},
+		{name: "no chunks", mutate: func(cfg *config.Config) { cfg.Chunk.ChunksPerAxis = 0 }, wantErr: "at least one chunk"},
+	}
+	for _, tc := range cases {
+		cfg := config.Default()
+		tc.mutate(cfg)
+		_, err := NewServerRegion(cfg)
+		if err == nil {
+			t.Fatalf("%s: expected an error", tc.name)
+		}
+		if !strings.Contains(err.Error(), tc.wantErr) {
+			t.Fatalf("%s: error %q does not mention %q", tc.name, err, tc.wantErr)
+		}
+	}
+
+	if _, err := NewServerRegion(config.Default()); err != nil {
+		t.Fatalf("default config region: %v", err)
+	}
+}
+
+func TestManagerRefusesToLoadChunksOfDegenerateRegion(t *testing.T) {
+	region := ServerRegion{
+		ChunksX:        1,
+		ChunksY:        1,
+		ChunkDimension: Dimensions{Width: 4, Depth: 4, Height: 0},
+	}
+	manager := NewManager(region, floorGenerator{})
+	_, err := manager.Chunk(context.Background(), ChunkCoord{})
+	if err == nil || !strings.Contains(err.Error(), "chunk dimensions must be positive") {
+		t.Fatalf("Chunk() error = %v, want one naming the chunk dimensions", err)
+	}
+	if resident, pending := manager.ChunkCounts(); resident != 0 || pending != 0 {
+		t.Fatalf("degenerate region left %d resident and %d pending chunks", resident, pending)
+	}
+}
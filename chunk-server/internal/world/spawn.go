@@ -0,0 +1,73 @@
+package world
+
+import (
+	"context"
+	"math"
+)
+
+// spawnSearchRadius is how far, in blocks along each axis, FindSpawn looks
+// from the requested point before giving up.
+const spawnSearchRadius = 16
+
+// SpawnProfile is the room a unit needs to be placed in the world.
+type SpawnProfile struct {
+	// Clearance is how many blocks tall the unit is. Zero means one.
+	Clearance int
+	// Flying units need no load-bearing block beneath them.
+	Flying bool
+}
+
+// FindSpawn returns the cell nearest to near, by straight-line distance, where
+// a unit with profile fits: every block of its clearance is passable and,
+// unless it flies, the block beneath it supports load. A point that already
+// fits is returned unchanged. The search covers spawnSearchRadius blocks
+// around near within the region; ok is false when nothing there fits.
+func (m *Manager) FindSpawn(ctx context.Context, near BlockCoord, profile SpawnProfile) (BlockCoord, bool) {
+	cache := make(map[ChunkCoord]*Chunk)
+	best, bestDist, found := BlockCoord{}, math.MaxInt, false
+	// Cells at Chebyshev radius r are at least r blocks away, so once r
+	// squared passes the best distance found no later shell can beat it.
+	for r := 0; r <= spawnSearchRadius && r*r <= bestDist; r++ {
+		if ctx.Err() != nil {
+			return BlockCoord{}, false
+		}
+		for dz := -r; dz <= r; dz++ {
+			for dy := -r; dy <= r; dy++ {
+				for dx := -r; dx <= r; dx++ {
+					if max(absInt(dx), max(absInt(dy), absInt(dz))) != r {
+						continue
+					}
+					dist := dx*dx + dy*dy + dz*dz
+					if dist >= bestDist {
+						continue
+					}
+					cell := BlockCoord{X: near.X + dx, Y: near.Y + dy, Z: near.Z + dz}
+					if m.spawnFits(ctx, cache, cell, profile) {
+						best, bestDist, found = cell, dist, true
+					}
+				}
+			}
+		}
+	}
+	return best, found
+}
+
+// SpawnFits reports whether a unit with profile fits at cell as it is, the
+// test FindSpawn applies to each cell it searches.
+func (m *Manager) SpawnFits(ctx context.Context, cell BlockCoord, profile SpawnProfile) bool {
+	return m.spawnFits(ctx, make(map[ChunkCoord]*Chunk), cell, profile)
+}
+
+func (m *Manager) spawnFits(ctx context.Context, cache map[ChunkCoord]*Chunk, cell BlockCoord, profile SpawnProfile) bool {
+	for i := 0; i < max(profile.Clearance, 1); i++ {
+		block, ok := m.cachedBlock(ctx, cache, BlockCoord{X: cell.X, Y: cell.Y, Z: cell.Z + i})
+		if !ok || !block.Type.Properties().Passable {
+			return false
+		}
+	}
+	if profile.Flying {
+		return true
+	}
+	below, ok := m.cachedBlock(ctx, cache, BlockCoord{X: cell.X, Y: cell.Y, Z: cell.Z - 1})
+	return ok && below.Type.Properties().SupportsLoad
+}
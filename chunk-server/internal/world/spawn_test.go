@@ -0,0 +1,95 @@
+package world
+
+import (
+	"context"
+	"testing"
+)
+
+// plateauGenerator fills every column with stone up to z=top.
+type plateauGenerator struct{ top int }
+
+func (g plateauGenerator) Generate(ctx context.Context, coord ChunkCoord, bounds Bounds, dim Dimensions) (*Chunk, error) {
+	chunk := NewChunk(coord, bounds, dim)
+	for x := 0; x < dim.Width; x++ {
+		for y := 0; y < dim.Depth; y++ {
+			for z := 0; z <= g.top && z < dim.Height; z++ {
+				chunk.SetLocalBlock(x, y, z, Block{Type: BlockSolid, Material: "stone"})
+			}
+		}
+	}
+	return chunk, nil
+}
+
+func newSpawnManager(t *testing.T, top int) *Manager {
+	t.Helper()
+	chdirTemp(t)
+	return NewManager(ServerRegion{
+		ChunksX:        2,
+		ChunksY:        2,
+		ChunkDimension: Dimensions{Width: 8, Depth: 8, Height: 12},
+	}, plateauGenerator{top: top})
+}
+
+func TestFindSpawnLeavesRockForTheSurface(t *testing.T) {
+	manager := newSpawnManager(t, 4)
+	ctx := context.Background()
+
+	got, ok := manager.FindSpawn(ctx, BlockCoord{X: 9, Y: 6, Z: 2}, SpawnProfile{Clearance: 2})
+	if !ok {
+		t.Fatal("expected a spawn above the buried point")
+	}
+	if want := (BlockCoord{X: 9, Y: 6, Z: 5}); got != want {
+		t.Fatalf("spawn = %v, want the surface cell %v", got, want)
+	}
+}
+
+func TestFindSpawnKeepsValidPoint(t *testing.T) {
+	manager := newSpawnManager(t, 4)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		near    BlockCoord
+		profile SpawnProfile
+	}{
+		{BlockCoord{X: 3, Y: 12, Z: 5}, SpawnProfile{Clearance: 2}},
+		{BlockCoord{X: 3, Y: 12, Z: 9}, SpawnProfile{Flying: true}},
+	} {
+		got, ok := manager.FindSpawn(ctx, tc.near, tc.profile)
+		if !ok || got != tc.near {
+			t.Fatalf("FindSpawn(%v, %+v) = %v, %v; want the point unchanged", tc.near, tc.profile, got, ok)
+		}
+	}
+
+	// Standing units drop to the ground rather than hover.
+	got, ok := manager.FindSpawn(ctx, BlockCoord{X: 3, Y: 12, Z: 7}, SpawnProfile{})
+	if !ok || got != (BlockCoord{X: 3, Y: 12, Z: 5}) {
+		t.Fatalf("spawn in the air = %v, %v; want the ground beneath", got, ok)
+	}
+}
+
+func TestSpawnFitsChecksOnlyTheCell(t *testing.T) {
+	manager := newSpawnManager(t, 4)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		cell    BlockCoord
+		profile SpawnProfile
+		want    bool
+	}{
+		{BlockCoord{X: 3, Y: 12, Z: 5}, SpawnProfile{Clearance: 2}, true},
+		{BlockCoord{X: 3, Y: 12, Z: 7}, SpawnProfile{}, false},
+		{BlockCoord{X: 3, Y: 12, Z: 7}, SpawnProfile{Flying: true}, true},
+		{BlockCoord{X: 3, Y: 12, Z: 4}, SpawnProfile{Flying: true}, false},
+	} {
+		if got := manager.SpawnFits(ctx, tc.cell, tc.profile); got != tc.want {
+			t.Fatalf("SpawnFits(%v, %+v) = %v, want %v", tc.cell, tc.profile, got, tc.want)
+		}
+	}
+}
+
+func TestFindSpawnFailsWithoutRoom(t *testing.T) {
+	manager := newSpawnManager(t, 11)
+	if got, ok := manager.FindSpawn(context.Background(), BlockCoord{X: 4, Y: 4, Z: 4}, SpawnProfile{}); ok {
+		t.Fatalf("spawn = %v in a region of solid rock", got)
+	}
+}
@@ -5,12 +5,32 @@ import (
 	"math"
 )
 
-const (
-	groundSupportForce = 1e6
-	hangingPenalty     = 0.72
-)
+// StabilityParams tunes the column stability model.
+type StabilityParams struct {
+	// GroundSupport is the extra support the bottom layer of a column gets
+	// from bedrock.
+	GroundSupport float64
+	// HangingPenalty scales the support of each successive block hanging
+	// over air.
+	HangingPenalty float64
+	// SupportFactor is how many times its load a block's support must reach
+	// for it to stand.
+	SupportFactor float64
+}
+
+// DefaultStabilityParams returns the parameters the stability model uses
+// unless a manager is configured otherwise.
+func DefaultStabilityParams() StabilityParams {
+	return StabilityParams{
+		GroundSupport:  1e6,
+		HangingPenalty: 0.72,
+		SupportFactor:  1,
+	}
+}
 
 // StabilityReport captures the state of a block after evaluating column stability.
+// Margin is SupportForce minus RequiredForce; a block collapses once it goes
+// negative, so small positive margins mark blocks close to failing.
 type StabilityReport struct {
 	Global        BlockCoord
 	LocalZ        int
@@ -21,6 +41,7 @@ type StabilityReport struct {
 	ChainDepth    int
 	SupportForce  float64
 	RequiredForce float64
+	Margin        float64
 }
 
 type columnNode struct {
@@ -39,19 +60,43 @@ type columnNode struct {
 	chainDepth  int
 }
 
-func evaluateColumnStability(chunk *Chunk, localX, localY int) ([]StabilityReport, error) {
+func evaluateColumnStability(chunk *Chunk, localX, localY int, params StabilityParams) ([]StabilityReport, error) {
+	blocks, err := columnBlocks(chunk, localX, localY)
+	if err != nil {
+		return nil, err
+	}
+	base := BlockCoord{
+		X: chunk.Bounds.Min.X + localX,
+		Y: chunk.Bounds.Min.Y + localY,
+		Z: chunk.Bounds.Min.Z,
+	}
+	return evaluateColumn(blocks, base, params), nil
+}
+
+// columnBlocks reads the full height of a column, with air for empty cells.
+func columnBlocks(chunk *Chunk, localX, localY int) ([]Block, error) {
 	dim := chunk.dimension
 	if localX < 0 || localY < 0 || localX >= dim.Width || localY >= dim.Depth {
 		return nil, errors.New("column coordinates out of bounds")
 	}
-
-	nodes := make([]columnNode, dim.Height)
+	blocks := make([]Block, dim.Height)
 	for z := 0; z < dim.Height; z++ {
 		block, ok := chunk.LocalBlock(localX, localY, z)
 		if !ok {
 			return nil, errors.New("block coordinates out of bounds")
 		}
-		present := block.Type != BlockAir
+		blocks[z] = block
+	}
+	return blocks, nil
+}
+
+// evaluateColumn runs the stability model over a column of blocks whose lowest
+// cell sits at base. It does not modify the column.
+func evaluateColumn(blocks []Block, base BlockCoord, params StabilityParams) []StabilityReport {
+	height := len(blocks)
+	nodes := make([]columnNode, height)
+	for z, block := range blocks {
+		present := block.Type.Properties().SupportsLoad
 		nodes[z] = columnNode{
 			block:          block,
 			initialPresent: present,
@@ -65,7 +110,7 @@ func evaluateColumnStability(chunk *Chunk, localX, localY int) ([]StabilityRepor
 
 		// Pass 1: accumulate load from top to bottom.
 		var weightAbove float64
-		for z := dim.Height - 1; z >= 0; z-- {
+		for z := height - 1; z >= 0; z-- {
 			node := &nodes[z]
 			if !node.present {
 				node.load = 0
@@ -79,7 +124,7 @@ func evaluateColumnStability(chunk *Chunk, localX, localY int) ([]StabilityRepor
 		// Pass 2: evaluate support from bottom to top without mutating presence yet.
 		var chainDepth int
 		chainPenalty := 1.0
-		for z := 0; z < dim.Height; z++ {
+		for z := 0; z < height; z++ {
 			node := &nodes[z]
 			if !node.present {
 				if !node.collapsed {
@@ -98,7 +143,7 @@ func evaluateColumnStability(chunk *Chunk, localX, localY int) ([]StabilityRepor
 
 			if z == 0 {
 				// Base layer anchored to bedrock.
-				support += groundSupportForce
+				support += params.GroundSupport
 				chainDepth = 0
 				chainPenalty = 1.0
 			} else {
@@ -106,7 +151,7 @@ func evaluateColumnStability(chunk *Chunk, localX, localY int) ([]StabilityRepor
 				if !below.present {
 					hanging = true
 					chainDepth++
-					chainPenalty *= hangingPenalty
+					chainPenalty *= params.HangingPenalty
 					support *= chainPenalty
 				} else {
 					chainDepth = 0
@@ -121,17 +166,17 @@ func evaluateColumnStability(chunk *Chunk, localX, localY int) ([]StabilityRepor
 			node.lastSupport = support
 			node.hanging = hanging
 			node.chainDepth = chainDepth
-			node.nextStable = support >= node.load
+			node.nextStable = support >= node.load*params.SupportFactor
 		}
 
 		// Pass 3: commit collapses determined in this iteration.
-		for z := 0; z < dim.Height; z++ {
+		for z := 0; z < height; z++ {
 			node := &nodes[z]
 			if !node.present {
 				continue
 			}
 			node.support = node.lastSupport
-			node.required = node.load
+			node.required = node.load * params.SupportFactor
 			if !node.nextStable {
 				node.present = false
 				if node.initialPresent && !node.collapsed {
@@ -146,19 +191,15 @@ func evaluateColumnStability(chunk *Chunk, localX, localY int) ([]StabilityRepor
 	}
 
 	// Produce reports for blocks that existed or collapsed.
-	reports := make([]StabilityReport, 0, dim.Height)
-	for z := 0; z < dim.Height; z++ {
+	reports := make([]StabilityReport, 0, height)
+	for z := 0; z < height; z++ {
 		node := nodes[z]
 		if !node.initialPresent && !node.collapsed {
 			continue
 		}
 
 		reports = append(reports, StabilityReport{
-			Global: BlockCoord{
-				X: chunk.Bounds.Min.X + localX,
-				Y: chunk.Bounds.Min.Y + localY,
-				Z: chunk.Bounds.Min.Z + z,
-			},
+			Global:        BlockCoord{X: base.X, Y: base.Y, Z: base.Z + z},
 			LocalZ:        z,
 			Block:         node.block,
 			Stable:        node.stable && !node.collapsed,
@@ -167,8 +208,9 @@ func evaluateColumnStability(chunk *Chunk, localX, localY int) ([]StabilityRepor
 			ChainDepth:    node.chainDepth,
 			SupportForce:  node.support,
 			RequiredForce: node.required,
+			Margin:        node.support - node.required,
 		})
 	}
 
-	return reports, nil
+	return reports
 }
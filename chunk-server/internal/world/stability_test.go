@@ -0,0 +1,119 @@
+package world
+
+import (
+	"context"
+	"sort"
+	"testing"
+)
+
+func TestPredictStabilityMatchesDestroyingKeystone(t *testing.T) {
+	manager := newPlacementManager(t)
+	ctx := context.Background()
+	if _, err := manager.ApplyBlockEdits(ctx, towerEdits()); err != nil {
+		t.Fatalf("build tower: %v", err)
+	}
+	keystone := BlockCoord{X: 1, Y: 1, Z: 0}
+
+	reports, err := manager.PredictStability(ctx, keystone)
+	if err != nil {
+		t.Fatalf("PredictStability() error = %v", err)
+	}
+	var predicted []BlockCoord
+	for _, report := range reports {
+		if report.Global == keystone {
+			t.Fatalf("prediction reported the removed block: %+v", report)
+		}
+		if report.Collapsed {
+			if report.Margin >= 0 {
+				t.Fatalf("collapsed block %v has non-negative margin %v", report.Global, report.Margin)
+			}
+			predicted = append(predicted, report.Global)
+		}
+	}
+	if len(predicted) == 0 {
+		t.Fatalf("expected removing the keystone to be predicted to collapse the tower")
+	}
+
+	chunk, err := manager.Chunk(ctx, ChunkCoord{})
+	if err != nil {
+		t.Fatalf("load chunk: %v", err)
+	}
+	if block, _ := chunk.LocalBlock(1, 1, 0); block.Type != BlockSolid {
+		t.Fatalf("prediction modified the keystone: %q", block.Type)
+	}
+
+	summary, err := manager.ApplyBlockDamage(ctx, keystone, 1)
+	if err != nil {
+		t.Fatalf("ApplyBlockDamage() error = %v", err)
+	}
+	actual := summary.CollapsedBlocks()
+	sortCoords(predicted)
+	sortCoords(actual)
+	if len(predicted) != len(actual) {
+		t.Fatalf("predicted collapse %v, actual %v", predicted, actual)
+	}
+	for i := range predicted {
+		if predicted[i] != actual[i] {
+			t.Fatalf("predicted collapse %v, actual %v", predicted, actual)
+		}
+	}
+}
+
+func TestPredictStabilityReportsMargins(t *testing.T) {
+	manager := newPlacementManager(t)
+	ctx := context.Background()
+	if _, err := manager.ApplyBlockEdits(ctx, towerEdits()); err != nil {
+		t.Fatalf("build tower: %v", err)
+	}
+
+	reports, err := manager.PredictStability(ctx, BlockCoord{X: 1, Y: 1, Z: 2})
+	if err != nil {
+		t.Fatalf("PredictStability() error = %v", err)
+	}
+	if len(reports) != 2 {
+		t.Fatalf("expected reports for the two remaining blocks, got %d", len(reports))
+	}
+	for _, report := range reports {
+		if !report.Stable || report.Margin < 0 {
+			t.Fatalf("expected %v to stand, got %+v", report.Global, report)
+		}
+		if report.Margin != report.SupportForce-report.RequiredForce {
+			t.Fatalf("margin %v does not match support %v minus load %v", report.Margin, report.SupportForce, report.RequiredForce)
+		}
+	}
+}
+
+func TestSupportFactorDrivesCollapse(t *testing.T) {
+	ctx := context.Background()
+
+	standing := newPlacementManager(t)
+	summary, err := standing.ApplyBlockEdits(ctx, towerEdits())
+	if err != nil {
+		t.Fatalf("build tower: %v", err)
+	}
+	if collapsed := summary.CollapsedBlocks(); len(collapsed) != 0 {
+		t.Fatalf("tower collapsed with default parameters: %v", collapsed)
+	}
+
+	strict := newPlacementManager(t)
+	params := DefaultStabilityParams()
+	params.SupportFactor = 2
+	strict.SetStabilityParams(params)
+	summary, err = strict.ApplyBlockEdits(ctx, towerEdits())
+	if err != nil {
+		t.Fatalf("build tower: %v", err)
+	}
+	collapsed := summary.CollapsedBlocks()
+	if len(collapsed) == 0 {
+		t.Fatalf("expected tower to collapse with support factor 2")
+	}
+	for _, coord := range collapsed {
+		if coord.Z == 0 {
+			t.Fatalf("grounded base should still stand, got collapse at %v", coord)
+		}
+	}
+}
+
+func sortCoords(coords []BlockCoord) {
+	sort.Slice(coords, func(i, j int) bool { return coords[i].Z < coords[j].Z })
+}
@@ -1,6 +1,9 @@
 package world
 
-import "sync"
+import (
+	"context"
+	"sync"
+)
 
 // BlockStorage provides persistent storage for chunk block data.
 type BlockStorage interface {
@@ -11,17 +14,40 @@ type BlockStorage interface {
 	Close() error
 }
 
+// snapshotter is implemented by block storage that can commit its bookkeeping
+// to durable media on demand.
+type snapshotter interface {
+	Snapshot() error
+}
+
+// generationMarker is implemented by block storage that outlives the chunk
+// and so can be left holding a partly generated chunk when generation is
+// cancelled or the process dies. The mark is set before generation writes its
+// first column and cleared once the last one is stored.
+type generationMarker interface {
+	SetGenerating(generating bool) error
+	Generating() bool
+}
+
 // StorageProvider creates block storage instances for chunks.
 type StorageProvider interface {
 	NewStorage(key ChunkCoord, bounds Bounds, dim Dimensions) (BlockStorage, error)
 }
 
+// storageProvider is the fallback for chunks created without a provider of
+// their own: NewChunk, generators run outside a manager, and managers that
+// were never given one. It starts as memory storage. Reads and writes are
+// guarded by storageMu, but a chunk takes whichever provider is current when
+// it is created, so code that needs a particular backend should set it on the
+// Manager or pass it to NewChunkWithStorage rather than swapping the global.
 var (
 	storageProvider StorageProvider = newMemoryStorageProvider()
 	storageMu       sync.RWMutex
 )
 
-// SetStorageProvider overrides the global storage provider used for new chunks.
+// SetStorageProvider overrides the global storage provider used for new chunks
+// that have no provider of their own. Set it once during startup, before any
+// chunk is created; chunks that already exist keep their storage.
 func SetStorageProvider(provider StorageProvider) {
 	storageMu.Lock()
 	storageProvider = provider
@@ -41,3 +67,27 @@ func getStorageProvider() StorageProvider {
 func CurrentStorageProvider() StorageProvider {
 	return getStorageProvider()
 }
+
+type storageProviderContextKey struct{}
+
+// ContextWithStorageProvider returns a context under which generators create
+// chunks with provider. A nil provider leaves ctx unchanged.
+func ContextWithStorageProvider(ctx context.Context, provider StorageProvider) context.Context {
+	if provider == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, storageProviderContextKey{}, provider)
+}
+
+// StorageProviderFromContext returns the provider set by
+// ContextWithStorageProvider, or the global provider when ctx carries none.
+// Generators pass it to NewChunkWithStorage so the chunks they build land in
+// the storage of the manager that asked for them.
+func StorageProviderFromContext(ctx context.Context) StorageProvider {
+	if ctx != nil {
+		if provider, ok := ctx.Value(storageProviderContextKey{}).(StorageProvider); ok {
+			return provider
+		}
+	}
+	return getStorageProvider()
+}
@@ -8,12 +8,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"sync"
+
+	"chunkserver/internal/logging"
 )
 
 const (
@@ -21,7 +22,9 @@ const (
 	diskOpSet    byte = 1
 )
 
-var maxChunkFileSize int64 = 128 * 1024 * 1024
+// DefaultMaxChunkFileSize is how large a chunk data file may grow before
+// records roll over into the next part file.
+const DefaultMaxChunkFileSize int64 = 128 * 1024 * 1024
 
 const (
 	columnEncodingVersion = 1
@@ -33,8 +36,9 @@ func init() {
 }
 
 type DiskStorageProvider struct {
-	basePath string
-	region   ServerRegion
+	basePath    string
+	region      ServerRegion
+	maxFileSize int64
 }
 
 // NewDiskStorageProvider creates a provider that persists chunk data beneath basePath.
@@ -45,7 +49,19 @@ func NewDiskStorageProvider(basePath string, region ServerRegion) *DiskStoragePr
 	}
 }
 
+// SetMaxFileSize sets how large each chunk data file may grow before records
+// roll over into a new part file. It applies to storage opened afterwards, so
+// call it before the provider is in use. Zero or less restores
+// DefaultMaxChunkFileSize.
+func (p *DiskStorageProvider) SetMaxFileSize(n int64) {
+	p.maxFileSize = n
+}
+
 func (p *DiskStorageProvider) NewStorage(key ChunkCoord, bounds Bounds, dim Dimensions) (BlockStorage, error) {
+	return p.newLoggedStorage(key, bounds, dim, defaultLogger)
+}
+
+func (p *DiskStorageProvider) newLoggedStorage(key ChunkCoord, bounds Bounds, dim Dimensions, logger *logging.Logger) (BlockStorage, error) {
 	path, err := p.chunkPath(key)
 	if err != nil {
 		return nil, err
@@ -53,7 +69,10 @@ func (p *DiskStorageProvider) NewStorage(key ChunkCoord, bounds Bounds, dim Dime
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return nil, fmt.Errorf("create chunk directory: %w", err)
 	}
-	return newDiskBlockStorage(path)
+	if err := MigrateChunkDir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	return openDiskBlockStorage(path, p.maxFileSize, logger)
 }
 
 func (p *DiskStorageProvider) chunkPath(key ChunkCoord) (string, error) {
@@ -61,7 +80,7 @@ func (p *DiskStorageProvider) chunkPath(key ChunkCoord) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	index := local.Y*p.region.ChunksPerAxis + local.X + 1
+	index := local.Y*p.region.ChunksX + local.X + 1
 	dir := filepath.Join(p.basePath, strconv.Itoa(key.X), strconv.Itoa(key.Y))
 	filename := fmt.Sprintf("chunk%02d.bin", index)
 	return filepath.Join(dir, filename), nil
@@ -75,15 +94,26 @@ type diskRecordMeta struct {
 
 type diskBlockStorage struct {
 	basePath string
-	mu       sync.RWMutex
-	records  map[int]diskRecordMeta
-	lastPart int
+	// maxFileSize caps each part file; zero means DefaultMaxChunkFileSize.
+	maxFileSize int64
+	mu          sync.RWMutex
+	records     map[int]diskRecordMeta
+	lastPart    int
+	logger      *logging.Logger
 }
 
-func newDiskBlockStorage(path string) (*diskBlockStorage, error) {
+func newDiskBlockStorage(path string, maxFileSize int64) (*diskBlockStorage, error) {
+	return openDiskBlockStorage(path, maxFileSize, defaultLogger)
+}
+
+// openDiskBlockStorage opens the chunk file at path, reporting index repairs
+// and truncated records to logger.
+func openDiskBlockStorage(path string, maxFileSize int64, logger *logging.Logger) (*diskBlockStorage, error) {
 	storage := &diskBlockStorage{
-		basePath: path,
-		records:  make(map[int]diskRecordMeta),
+		basePath:    path,
+		maxFileSize: maxFileSize,
+		records:     make(map[int]diskRecordMeta),
+		logger:      logger,
 	}
 	if err := storage.ensureBaseFile(); err != nil {
 		return nil, err
@@ -113,16 +143,48 @@ func (s *diskBlockStorage) indexPath() string {
 	return fmt.Sprintf("%s.idx", s.basePath)
 }
 
+// generatingPath names the file whose presence marks a generation in
+// progress. Chunk files written before the mark existed have none and count
+// as complete.
+func (s *diskBlockStorage) generatingPath() string {
+	return fmt.Sprintf("%s.generating", s.basePath)
+}
+
+func (s *diskBlockStorage) SetGenerating(generating bool) error {
+	if generating {
+		if err := os.WriteFile(s.generatingPath(), nil, 0o644); err != nil {
+			return fmt.Errorf("mark chunk generating: %w", err)
+		}
+		return nil
+	}
+	if err := os.Remove(s.generatingPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("clear chunk generating mark: %w", err)
+	}
+	return nil
+}
+
+func (s *diskBlockStorage) Generating() bool {
+	_, err := os.Stat(s.generatingPath())
+	return err == nil
+}
+
 func (s *diskBlockStorage) loadIndex() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if err := s.loadIndexFromFileLocked(); err == nil {
-		return nil
+		err = s.verifyIndexLocked()
+		if err == nil {
+			return nil
+		}
+		s.logger.Warnf("chunk storage index does not match its files, rebuilding from scan: %v", err)
 	} else if !errors.Is(err, os.ErrNotExist) {
-		log.Printf("chunk storage index fallback to scan: %v", err)
+		s.logger.Warnf("chunk storage index fallback to scan: %v", err)
 	}
 
+	// A part missing from the middle of the set is skipped rather than ending
+	// the scan, as long as the index claimed records beyond it.
+	indexedParts := s.lastPart
 	s.records = make(map[int]diskRecordMeta)
 	s.lastPart = 0
 
@@ -136,6 +198,9 @@ func (s *diskBlockStorage) loadIndex() error {
 					// Base file should always exist due to ensureBaseFile.
 					return nil
 				}
+				if part < indexedParts {
+					continue
+				}
 				break
 			}
 			return fmt.Errorf("open chunk file %s: %w", path, err)
@@ -154,7 +219,14 @@ func (s *diskBlockStorage) loadIndex() error {
 	return s.persistIndexLocked()
 }
 
+// scanPart replays the records of one part file into s.records. A record cut
+// short by the end of the file, as a crash mid-append leaves, is cut off so
+// later appends start on a record boundary; the records before it are kept.
 func (s *diskBlockStorage) scanPart(f *os.File, part int, header []byte) error {
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("stat chunk file %s: %w", f.Name(), err)
+	}
 	var offset int64
 	for {
 		if _, err := io.ReadFull(f, header); err != nil {
@@ -162,7 +234,7 @@ func (s *diskBlockStorage) scanPart(f *os.File, part int, header []byte) error {
 				return nil
 			}
 			if err == io.ErrUnexpectedEOF {
-				return fmt.Errorf("truncated chunk header in %s: %w", f.Name(), err)
+				return s.dropTruncatedTail(f, offset)
 			}
 			return fmt.Errorf("read chunk header in %s: %w", f.Name(), err)
 		}
@@ -171,6 +243,9 @@ func (s *diskBlockStorage) scanPart(f *os.File, part int, header []byte) error {
 		size := binary.LittleEndian.Uint32(header[5:9])
 		recordOffset := offset
 		offset += int64(len(header)) + int64(size)
+		if offset > info.Size() {
+			return s.dropTruncatedTail(f, recordOffset)
+		}
 
 		if _, err := f.Seek(int64(size), io.SeekCurrent); err != nil {
 			return fmt.Errorf("seek past payload in %s: %w", f.Name(), err)
@@ -183,6 +258,14 @@ func (s *diskBlockStorage) scanPart(f *os.File, part int, header []byte) error {
 	}
 }
 
+func (s *diskBlockStorage) dropTruncatedTail(f *os.File, offset int64) error {
+	s.logger.Warnf("chunk file %s: dropping truncated record at %d", f.Name(), offset)
+	if err := os.Truncate(f.Name(), offset); err != nil {
+		return fmt.Errorf("truncate chunk file %s: %w", f.Name(), err)
+	}
+	return nil
+}
+
 func (s *diskBlockStorage) LoadColumn(index int) ([]Block, bool, error) {
 	s.mu.RLock()
 	meta, ok := s.records[index]
@@ -272,7 +355,7 @@ func (s *diskBlockStorage) ForEach(fn func(index int, blocks []Block) bool) erro
 	for _, entry := range metas {
 		blocks, ok, err := s.LoadColumn(entry.index)
 		if err != nil {
-			log.Printf("disk block storage load index %d: %v", entry.index, err)
+			s.logger.Warnf("disk block storage load index %d: %v", entry.index, err)
 			continue
 		}
 		if !ok {
@@ -285,14 +368,30 @@ func (s *diskBlockStorage) ForEach(fn func(index int, blocks []Block) bool) erro
 	return nil
 }
 
+// Snapshot rewrites the column index so it matches the data files and the next
+// start does not need to fall back to scanning them.
+func (s *diskBlockStorage) Snapshot() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.persistIndexLocked()
+}
+
 func (s *diskBlockStorage) Close() error {
 	return nil
 }
 
+func (s *diskBlockStorage) fileLimit() int64 {
+	if s.maxFileSize <= 0 {
+		return DefaultMaxChunkFileSize
+	}
+	return s.maxFileSize
+}
+
 func (s *diskBlockStorage) appendRecordLocked(header, payload []byte) (diskRecordMeta, error) {
+	limit := s.fileLimit()
 	entrySize := int64(len(header) + len(payload))
-	if entrySize > maxChunkFileSize {
-		return diskRecordMeta{}, fmt.Errorf("chunk entry size %d exceeds max chunk file size %d", entrySize, maxChunkFileSize)
+	if entrySize > limit {
+		return diskRecordMeta{}, fmt.Errorf("chunk entry size %d exceeds max chunk file size %d", entrySize, limit)
 	}
 
 	for {
@@ -306,7 +405,7 @@ func (s *diskBlockStorage) appendRecordLocked(header, payload []byte) (diskRecor
 			f.Close()
 			return diskRecordMeta{}, fmt.Errorf("seek chunk end: %w", err)
 		}
-		if offset+entrySize > maxChunkFileSize {
+		if offset+entrySize > limit {
 			if err := f.Close(); err != nil {
 				return diskRecordMeta{}, fmt.Errorf("close chunk file %s: %w", path, err)
 			}
@@ -390,6 +489,28 @@ func (s *diskBlockStorage) loadIndexFromFileLocked() error {
 	return nil
 }
 
+// verifyIndexLocked checks that every record the index names lies within its
+// part file. An index that outlived a deleted or truncated part would
+// otherwise fail each affected column at read time.
+func (s *diskBlockStorage) verifyIndexLocked() error {
+	sizes := make(map[int]int64)
+	for index, meta := range s.records {
+		size, ok := sizes[meta.part]
+		if !ok {
+			info, err := os.Stat(s.partPath(meta.part))
+			if err != nil {
+				return fmt.Errorf("index entry %d: %w", index, err)
+			}
+			size = info.Size()
+			sizes[meta.part] = size
+		}
+		if end := meta.offset + 9 + int64(meta.size); end > size {
+			return fmt.Errorf("index entry %d ends at %d past the %d bytes of %s", index, end, size, s.partPath(meta.part))
+		}
+	}
+	return nil
+}
+
 func (s *diskBlockStorage) persistIndexLocked() error {
 	path := s.indexPath()
 	tmp := path + ".tmp"
@@ -464,14 +585,15 @@ func (s *diskBlockStorage) persistIndexLocked() error {
 	return nil
 }
 
-type columnRun struct {
+// ColumnRun is Count consecutive copies of Block in a column, bottom first.
+type ColumnRun struct {
 	Count int
 	Block Block
 }
 
 type columnEncoding struct {
 	Version int
-	Runs    []columnRun
+	Runs    []ColumnRun
 }
 
 func encodeColumnPayload(blocks []Block) ([]byte, error) {
@@ -496,11 +618,19 @@ func encodeColumnPayload(blocks []Block) ([]byte, error) {
 }
 
 func decodeColumnPayload(payload []byte) ([]Block, error) {
+	return decodeBoundedColumnPayload(payload, 0)
+}
+
+// decodeBoundedColumnPayload decodes payload like decodeColumnPayload. A
+// positive maxBlocks rejects columns taller than maxBlocks before they are
+// expanded and stops inflating compressed payloads past
+// maxInflatedColumnBytes, so untrusted payloads cannot exhaust memory.
+func decodeBoundedColumnPayload(payload []byte, maxBlocks int) ([]Block, error) {
 	if len(payload) == 0 {
 		return nil, nil
 	}
 
-	if blocks, err := decodeCompressedColumnPayload(payload); err == nil {
+	if blocks, err := decodeCompressedColumnPayload(payload, maxBlocks); err == nil {
 		return blocks, nil
 	} else if err != errNotCompressed {
 		return nil, err
@@ -508,12 +638,7 @@ func decodeColumnPayload(payload []byte) ([]Block, error) {
 
 	var encoding columnEncoding
 	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&encoding); err == nil {
-		switch encoding.Version {
-		case columnEncodingVersion:
-			return expandColumn(encoding.Runs), nil
-		default:
-			return nil, fmt.Errorf("unsupported column encoding version %d", encoding.Version)
-		}
+		return expandEncodedColumn(encoding, maxBlocks)
 	}
 
 	// Backwards compatibility: attempt to decode the legacy []Block payload.
@@ -521,9 +646,31 @@ func decodeColumnPayload(payload []byte) ([]Block, error) {
 	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&legacy); err != nil {
 		return nil, err
 	}
+	if maxBlocks > 0 && len(legacy) > maxBlocks {
+		return nil, fmt.Errorf("column holds %d blocks, limit is %d", len(legacy), maxBlocks)
+	}
 	return legacy, nil
 }
 
+// expandEncodedColumn checks encoding's version and run total against
+// maxBlocks, when positive, and expands its runs.
+func expandEncodedColumn(encoding columnEncoding, maxBlocks int) ([]Block, error) {
+	if encoding.Version != columnEncodingVersion {
+		return nil, fmt.Errorf("unsupported column encoding version %d", encoding.Version)
+	}
+	total := 0
+	for _, run := range encoding.Runs {
+		if run.Count < 0 {
+			return nil, fmt.Errorf("column run of %d blocks", run.Count)
+		}
+		if maxBlocks > 0 && run.Count > maxBlocks-total {
+			return nil, fmt.Errorf("column runs exceed %d blocks", maxBlocks)
+		}
+		total += run.Count
+	}
+	return expandColumn(encoding.Runs), nil
+}
+
 var errNotCompressed = errors.New("column payload not compressed")
 
 func compressColumnPayload(data []byte) ([]byte, error) {
@@ -538,7 +685,7 @@ func compressColumnPayload(data []byte) ([]byte, error) {
 	return compressed.Bytes(), nil
 }
 
-func decodeCompressedColumnPayload(payload []byte) ([]Block, error) {
+func decodeCompressedColumnPayload(payload []byte, maxBlocks int) ([]Block, error) {
 	zr, err := zlib.NewReader(bytes.NewReader(payload))
 	if err != nil {
 		if errors.Is(err, zlib.ErrHeader) {
@@ -548,29 +695,31 @@ func decodeCompressedColumnPayload(payload []byte) ([]Block, error) {
 	}
 	defer zr.Close()
 
-	decoded, err := io.ReadAll(zr)
+	var src io.Reader = zr
+	if maxBlocks > 0 {
+		src = io.LimitReader(zr, maxInflatedColumnBytes+1)
+	}
+	decoded, err := io.ReadAll(src)
 	if err != nil {
 		return nil, err
 	}
+	if maxBlocks > 0 && len(decoded) > maxInflatedColumnBytes {
+		return nil, fmt.Errorf("column inflates past %d bytes", maxInflatedColumnBytes)
+	}
 
 	var encoding columnEncoding
 	if err := gob.NewDecoder(bytes.NewReader(decoded)).Decode(&encoding); err != nil {
 		return nil, err
 	}
-	switch encoding.Version {
-	case columnEncodingVersion:
-		return expandColumn(encoding.Runs), nil
-	default:
-		return nil, fmt.Errorf("unsupported column encoding version %d", encoding.Version)
-	}
+	return expandEncodedColumn(encoding, maxBlocks)
 }
 
-func compressColumn(blocks []Block) []columnRun {
+func compressColumn(blocks []Block) []ColumnRun {
 	if len(blocks) == 0 {
 		return nil
 	}
 
-	runs := make([]columnRun, 0, 8)
+	runs := make([]ColumnRun, 0, 8)
 	for _, block := range blocks {
 		block = sanitizeBlock(block)
 		n := len(runs)
@@ -578,12 +727,12 @@ func compressColumn(blocks []Block) []columnRun {
 			runs[n-1].Count++
 			continue
 		}
-		runs = append(runs, columnRun{Count: 1, Block: duplicateBlock(block)})
+		runs = append(runs, ColumnRun{Count: 1, Block: duplicateBlock(block)})
 	}
 	return runs
 }
 
-func expandColumn(runs []columnRun) []Block {
+func expandColumn(runs []ColumnRun) []Block {
 	if len(runs) == 0 {
 		return nil
 	}
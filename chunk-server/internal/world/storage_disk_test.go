@@ -14,12 +14,6 @@ func TestDiskBlockStorageRotatesChunkFiles(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "chunk.bin")
 
-	storage, err := newDiskBlockStorage(path)
-	if err != nil {
-		t.Fatalf("newDiskBlockStorage: %v", err)
-	}
-	defer storage.Close()
-
 	blocks := make([]Block, 4)
 	for i := range blocks {
 		blocks[i] = Block{Type: BlockSolid, Material: strings.Repeat("m", 64), Texture: strings.Repeat("t", 64)}
@@ -30,9 +24,11 @@ func TestDiskBlockStorageRotatesChunkFiles(t *testing.T) {
 		t.Fatalf("encode blocks: %v", err)
 	}
 
-	originalLimit := maxChunkFileSize
-	maxChunkFileSize = int64(9 + len(payload))
-	defer func() { maxChunkFileSize = originalLimit }()
+	storage, err := newDiskBlockStorage(path, int64(9+len(payload)))
+	if err != nil {
+		t.Fatalf("newDiskBlockStorage: %v", err)
+	}
+	defer storage.Close()
 
 	if err := storage.SaveColumn(0, blocks); err != nil {
 		t.Fatalf("SaveColumn first: %v", err)
@@ -84,7 +80,7 @@ func TestDiskBlockStoragePersistsIndex(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "chunk.bin")
 
-	storage, err := newDiskBlockStorage(path)
+	storage, err := newDiskBlockStorage(path, 0)
 	if err != nil {
 		t.Fatalf("newDiskBlockStorage: %v", err)
 	}
@@ -98,7 +94,7 @@ func TestDiskBlockStoragePersistsIndex(t *testing.T) {
 		t.Fatalf("expected index file to exist: %v", err)
 	}
 
-	reopened, err := newDiskBlockStorage(path)
+	reopened, err := newDiskBlockStorage(path, 0)
 	if err != nil {
 		t.Fatalf("reopen storage: %v", err)
 	}
@@ -120,12 +116,6 @@ func TestDiskBlockStorageRejectsOversizedEntry(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "chunk.bin")
 
-	storage, err := newDiskBlockStorage(path)
-	if err != nil {
-		t.Fatalf("newDiskBlockStorage: %v", err)
-	}
-	defer storage.Close()
-
 	blocks := []Block{{Type: BlockSolid, Material: strings.Repeat("m", 8)}}
 
 	payload, err := encodeColumnPayload(blocks)
@@ -133,9 +123,11 @@ func TestDiskBlockStorageRejectsOversizedEntry(t *testing.T) {
 		t.Fatalf("encode blocks: %v", err)
 	}
 
-	originalLimit := maxChunkFileSize
-	maxChunkFileSize = int64(9 + len(payload) - 1)
-	defer func() { maxChunkFileSize = originalLimit }()
+	storage, err := newDiskBlockStorage(path, int64(9+len(payload)-1))
+	if err != nil {
+		t.Fatalf("newDiskBlockStorage: %v", err)
+	}
+	defer storage.Close()
 
 	if err := storage.SaveColumn(0, blocks); err == nil {
 		t.Fatalf("expected SaveColumn to fail for oversized entry")
@@ -201,3 +193,184 @@ func TestDecodeColumnPayloadLegacyFallback(t *testing.T) {
 		t.Fatalf("legacy decode mismatch")
 	}
 }
+
+func TestDiskBlockStorageGeneratingMarkSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chunk.bin")
+
+	storage, err := newDiskBlockStorage(path, 0)
+	if err != nil {
+		t.Fatalf("newDiskBlockStorage: %v", err)
+	}
+	if storage.Generating() {
+		t.Fatal("expected a new chunk file not to be marked generating")
+	}
+	if err := storage.SetGenerating(true); err != nil {
+		t.Fatalf("SetGenerating(true): %v", err)
+	}
+	storage.Close()
+
+	reopened, err := newDiskBlockStorage(path, 0)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	if !reopened.Generating() {
+		t.Fatal("expected the generating mark to survive a reopen")
+	}
+	if err := reopened.SetGenerating(false); err != nil {
+		t.Fatalf("SetGenerating(false): %v", err)
+	}
+	if err := reopened.SetGenerating(false); err != nil {
+		t.Fatalf("clearing an absent mark: %v", err)
+	}
+	if reopened.Generating() {
+		t.Fatal("expected the generating mark to be cleared")
+	}
+}
+
+func TestDiskStorageProviderMaxFileSizeIsPerProvider(t *testing.T) {
+	region := ServerRegion{ChunksX: 1, ChunksY: 1, ChunkDimension: Dimensions{Width: 2, Depth: 2, Height: 2}}
+	blocks := []Block{{Type: BlockSolid, Material: strings.Repeat("m", 32)}}
+	payload, err := encodeColumnPayload(blocks)
+	if err != nil {
+		t.Fatalf("encode blocks: %v", err)
+	}
+	record := int64(9 + len(payload))
+
+	open := func(provider *DiskStorageProvider) *diskBlockStorage {
+		t.Helper()
+		storage, err := provider.NewStorage(ChunkCoord{}, Bounds{}, region.ChunkDimension)
+		if err != nil {
+			t.Fatalf("NewStorage: %v", err)
+		}
+		return storage.(*diskBlockStorage)
+	}
+
+	// Room for exactly two records: the third rolls over into part 1.
+	small := NewDiskStorageProvider(t.TempDir(), region)
+	small.SetMaxFileSize(2 * record)
+	roomy := NewDiskStorageProvider(t.TempDir(), region)
+	smallStore, roomyStore := open(small), open(roomy)
+	for index := 0; index < 3; index++ {
+		if err := smallStore.SaveColumn(index, blocks); err != nil {
+			t.Fatalf("small SaveColumn %d: %v", index, err)
+		}
+		if err := roomyStore.SaveColumn(index, blocks); err != nil {
+			t.Fatalf("roomy SaveColumn %d: %v", index, err)
+		}
+	}
+
+	for index, want := range []int{0, 0, 1} {
+		if got := smallStore.records[index].part; got != want {
+			t.Fatalf("small provider column %d in part %d, want %d", index, got, want)
+		}
+		if got := roomyStore.records[index].part; got != 0 {
+			t.Fatalf("default provider column %d in part %d, want 0", index, got)
+		}
+	}
+	if info, err := os.Stat(smallStore.partPath(0)); err != nil || info.Size() != 2*record {
+		t.Fatalf("small provider part 0 = %v (%v), want exactly %d bytes", info, err, 2*record)
+	}
+	if got := roomyStore.fileLimit(); got != DefaultMaxChunkFileSize {
+		t.Fatalf("default provider limit = %d, want %d", got, DefaultMaxChunkFileSize)
+	}
+}
+
+func TestDiskBlockStorageRescansWhenPartFileIsMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chunk.bin")
+	column := func(material string) []Block {
+		return []Block{{Type: BlockSolid, Material: material + strings.Repeat("-", 32)}}
+	}
+	payload, err := encodeColumnPayload(column("base"))
+	if err != nil {
+		t.Fatalf("encode blocks: %v", err)
+	}
+
+	// One record per file: column 0 lands in the base file, 1 in part 1 and
+	// 2 in part 2.
+	storage, err := newDiskBlockStorage(path, int64(9+len(payload)))
+	if err != nil {
+		t.Fatalf("newDiskBlockStorage: %v", err)
+	}
+	for index, material := range []string{"base", "lost", "last"} {
+		if err := storage.SaveColumn(index, column(material)); err != nil {
+			t.Fatalf("SaveColumn %d: %v", index, err)
+		}
+	}
+	storage.Close()
+	if err := os.Remove(path + ".part1"); err != nil {
+		t.Fatalf("remove part file: %v", err)
+	}
+
+	reopened, err := newDiskBlockStorage(path, int64(9+len(payload)))
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	for index, want := range map[int]string{0: "base", 2: "last"} {
+		blocks, ok, err := reopened.LoadColumn(index)
+		if err != nil || !ok || blocks[0].Material != column(want)[0].Material {
+			t.Fatalf("column %d = %v, %v, %v; want the %s record", index, blocks, ok, err, want)
+		}
+	}
+	if _, ok, err := reopened.LoadColumn(1); ok || err != nil {
+		t.Fatalf("expected the column in the deleted part to be gone without an error, got ok=%v err=%v", ok, err)
+	}
+
+	// The rebuilt index is persisted, so the next open trusts it again.
+	again, err := newDiskBlockStorage(path, int64(9+len(payload)))
+	if err != nil {
+		t.Fatalf("reopen rebuilt index: %v", err)
+	}
+	defer again.Close()
+	if len(again.records) != 2 {
+		t.Fatalf("rebuilt index holds %d records, want 2", len(again.records))
+	}
+}
+
+func TestDiskBlockStorageRescansWhenPartFileIsTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chunk.bin")
+	storage, err := newDiskBlockStorage(path, 0)
+	if err != nil {
+		t.Fatalf("newDiskBlockStorage: %v", err)
+	}
+	for index := 0; index < 2; index++ {
+		if err := storage.SaveColumn(index, []Block{{Type: BlockSolid, Material: strings.Repeat("m", 32)}}); err != nil {
+			t.Fatalf("SaveColumn %d: %v", index, err)
+		}
+	}
+	cut := storage.records[1].offset + 4
+	storage.Close()
+	if err := os.Truncate(path, cut); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+
+	reopened, err := newDiskBlockStorage(path, 0)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	if _, ok, err := reopened.LoadColumn(0); !ok || err != nil {
+		t.Fatalf("expected the intact column to survive, got ok=%v err=%v", ok, err)
+	}
+	if _, ok, err := reopened.LoadColumn(1); ok || err != nil {
+		t.Fatalf("expected the truncated column to be dropped, got ok=%v err=%v", ok, err)
+	}
+
+	// The partial record is cut off, so a column written now still scans
+	// cleanly once the index is lost.
+	if err := reopened.SaveColumn(1, []Block{{Type: BlockSolid, Material: "again"}}); err != nil {
+		t.Fatalf("SaveColumn after recovery: %v", err)
+	}
+	if err := os.Remove(path + ".idx"); err != nil {
+		t.Fatalf("remove index: %v", err)
+	}
+	rescanned, err := newDiskBlockStorage(path, 0)
+	if err != nil {
+		t.Fatalf("rescan: %v", err)
+	}
+	defer rescanned.Close()
+	if blocks, ok, err := rescanned.LoadColumn(1); !ok || err != nil || blocks[0].Material != "again" {
+		t.Fatalf("expected the rewritten column after a rescan, got %v ok=%v err=%v", blocks, ok, err)
+	}
+}
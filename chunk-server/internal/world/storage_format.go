@@ -0,0 +1,200 @@
+package world
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// chunkFormatVersion is the on-disk layout of a chunk directory. Version 1
+	// directories predate the format file and may hold columns in the legacy
+	// []Block encoding; version 2 stores every column as a columnEncoding.
+	chunkFormatVersion = 2
+	// legacyChunkFormatVersion is assumed for directories that hold chunk
+	// files but no format file.
+	legacyChunkFormatVersion = 1
+	chunkFormatFile          = "format"
+)
+
+// chunkMigrations upgrades a chunk directory from the keyed version to the
+// next one.
+var chunkMigrations = map[int]func(dir string) error{
+	1: rewriteChunkFiles,
+}
+
+// MigrateChunkDir upgrades the chunk files in dir to the current on-disk
+// format, one version at a time, and records the version reached. Directories
+// already at the current version are left alone, and ones written by a newer
+// server are rejected rather than risk misreading them.
+func MigrateChunkDir(dir string) error {
+	version, ok, err := readChunkFormatVersion(dir)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		files, err := chunkBaseFiles(dir)
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return writeChunkFormatVersion(dir, chunkFormatVersion)
+		}
+		version = legacyChunkFormatVersion
+	}
+	if version > chunkFormatVersion {
+		return fmt.Errorf("chunk directory %s has format version %d, newer than supported %d", dir, version, chunkFormatVersion)
+	}
+	if version == chunkFormatVersion {
+		return nil
+	}
+	for ; version < chunkFormatVersion; version++ {
+		migrate, ok := chunkMigrations[version]
+		if !ok {
+			return fmt.Errorf("no migration from chunk format version %d", version)
+		}
+		if err := migrate(dir); err != nil {
+			return fmt.Errorf("migrate chunk directory %s from version %d: %w", dir, version, err)
+		}
+	}
+	return writeChunkFormatVersion(dir, chunkFormatVersion)
+}
+
+func readChunkFormatVersion(dir string) (int, bool, error) {
+	data, err := os.ReadFile(filepath.Join(dir, chunkFormatFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("read chunk format: %w", err)
+	}
+	version, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, false, fmt.Errorf("parse chunk format in %s: %w", dir, err)
+	}
+	return version, true, nil
+}
+
+func writeChunkFormatVersion(dir string, version int) error {
+	path := filepath.Join(dir, chunkFormatFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.Itoa(version)+"\n"), 0o644); err != nil {
+		return fmt.Errorf("write chunk format: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("replace chunk format: %w", err)
+	}
+	return nil
+}
+
+func chunkBaseFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "chunk*.bin"))
+	if err != nil {
+		return nil, fmt.Errorf("list chunk files: %w", err)
+	}
+	return files, nil
+}
+
+// rewriteChunkFiles re-encodes every live column of every chunk file in dir
+// with the current column encoding, dropping superseded records on the way.
+func rewriteChunkFiles(dir string) error {
+	files, err := chunkBaseFiles(dir)
+	if err != nil {
+		return err
+	}
+	for _, path := range files {
+		if err := rewriteChunkFile(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rewriteChunkFile writes the columns of the chunk file at path to a fresh
+// set of files beside it and then moves them over the originals. The old index
+// goes first, so an interrupted swap falls back to scanning the data files,
+// which still hold every column.
+func rewriteChunkFile(path string) error {
+	old, err := newDiskBlockStorage(path, 0)
+	if err != nil {
+		return err
+	}
+	indices := make([]int, 0, len(old.records))
+	for index := range old.records {
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+
+	fresh := &diskBlockStorage{basePath: path + ".migrate", records: make(map[int]diskRecordMeta, len(indices))}
+	removeChunkFileSet(fresh)
+	if err := fresh.ensureBaseFile(); err != nil {
+		return err
+	}
+	for _, index := range indices {
+		blocks, ok, err := old.LoadColumn(index)
+		if err != nil {
+			removeChunkFileSet(fresh)
+			return fmt.Errorf("read column %d of %s: %w", index, path, err)
+		}
+		if !ok {
+			continue
+		}
+		payload, err := encodeColumnPayload(blocks)
+		if err != nil {
+			removeChunkFileSet(fresh)
+			return fmt.Errorf("encode column %d of %s: %w", index, path, err)
+		}
+		header := make([]byte, 9)
+		header[0] = diskOpSet
+		binary.LittleEndian.PutUint32(header[1:5], uint32(index))
+		binary.LittleEndian.PutUint32(header[5:9], uint32(len(payload)))
+		meta, err := fresh.appendRecordLocked(header, payload)
+		if err != nil {
+			removeChunkFileSet(fresh)
+			return err
+		}
+		fresh.records[index] = meta
+	}
+	if err := fresh.persistIndexLocked(); err != nil {
+		removeChunkFileSet(fresh)
+		return err
+	}
+
+	if err := os.Remove(old.indexPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove chunk index: %w", err)
+	}
+	for part := 0; part <= fresh.lastPart; part++ {
+		if err := os.Rename(fresh.partPath(part), old.partPath(part)); err != nil {
+			return fmt.Errorf("replace chunk file: %w", err)
+		}
+	}
+	for part := fresh.lastPart + 1; ; part++ {
+		if err := os.Remove(old.partPath(part)); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				break
+			}
+			return fmt.Errorf("remove chunk file: %w", err)
+		}
+	}
+	if err := os.Rename(fresh.indexPath(), old.indexPath()); err != nil {
+		return fmt.Errorf("replace chunk index: %w", err)
+	}
+	return nil
+}
+
+// removeChunkFileSet deletes the data and index files of s, such as those left
+// behind by an interrupted migration.
+func removeChunkFileSet(s *diskBlockStorage) {
+	os.Remove(s.indexPath())
+	for part := 0; ; part++ {
+		if err := os.Remove(s.partPath(part)); err != nil {
+			return
+		}
+	}
+}
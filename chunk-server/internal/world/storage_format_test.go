@@ -0,0 +1,110 @@
+package world
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMigrateChunkDirUpgradesLegacyChunk(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "chunk01.bin")
+
+	// A version 1 directory: no format file and a column in the legacy []Block
+	// encoding, followed by a later record that supersedes it.
+	stale := []Block{{Type: BlockAir}}
+	legacy := []Block{{Type: BlockSolid, Material: "stone", HitPoints: 40}, {Type: BlockMineral}}
+	var data bytes.Buffer
+	for _, blocks := range [][]Block{stale, legacy} {
+		var payload bytes.Buffer
+		if err := gob.NewEncoder(&payload).Encode(blocks); err != nil {
+			t.Fatalf("encode legacy column: %v", err)
+		}
+		header := make([]byte, 9)
+		header[0] = diskOpSet
+		binary.LittleEndian.PutUint32(header[1:5], 3)
+		binary.LittleEndian.PutUint32(header[5:9], uint32(payload.Len()))
+		data.Write(header)
+		data.Write(payload.Bytes())
+	}
+	if err := os.WriteFile(path, data.Bytes(), 0o644); err != nil {
+		t.Fatalf("write legacy chunk: %v", err)
+	}
+
+	if err := MigrateChunkDir(dir); err != nil {
+		t.Fatalf("MigrateChunkDir: %v", err)
+	}
+
+	format, err := os.ReadFile(filepath.Join(dir, chunkFormatFile))
+	if err != nil {
+		t.Fatalf("read format file: %v", err)
+	}
+	if got := strings.TrimSpace(string(format)); got != "2" {
+		t.Fatalf("expected format version 2, got %q", got)
+	}
+
+	storage, err := newDiskBlockStorage(path, 0)
+	if err != nil {
+		t.Fatalf("open migrated chunk: %v", err)
+	}
+	meta, ok := storage.records[3]
+	if !ok {
+		t.Fatal("expected migrated column to be indexed")
+	}
+	if meta.offset != 0 {
+		t.Fatalf("expected superseded record to be dropped, column found at offset %d", meta.offset)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read migrated chunk: %v", err)
+	}
+	blocks, err := decodeCompressedColumnPayload(raw[9:9+meta.size], 0)
+	if err == errNotCompressed {
+		var encoding columnEncoding
+		err = gob.NewDecoder(bytes.NewReader(raw[9 : 9+meta.size])).Decode(&encoding)
+		blocks = expandColumn(encoding.Runs)
+	}
+	if err != nil {
+		t.Fatalf("expected column rewritten in the current encoding: %v", err)
+	}
+	if !reflect.DeepEqual(blocks, legacy) {
+		t.Fatalf("migrated column mismatch: got %+v", blocks)
+	}
+
+	column, ok, err := storage.LoadColumn(3)
+	if err != nil || !ok {
+		t.Fatalf("LoadColumn after migration: ok=%v err=%v", ok, err)
+	}
+	if !reflect.DeepEqual(column, legacy) {
+		t.Fatalf("reloaded column mismatch: got %+v", column)
+	}
+	if _, err := os.Stat(path + ".migrate"); !os.IsNotExist(err) {
+		t.Fatalf("expected migration files to be moved into place, stat err %v", err)
+	}
+}
+
+func TestMigrateChunkDirStampsNewDirectory(t *testing.T) {
+	dir := t.TempDir()
+	if err := MigrateChunkDir(dir); err != nil {
+		t.Fatalf("MigrateChunkDir: %v", err)
+	}
+	version, ok, err := readChunkFormatVersion(dir)
+	if err != nil || !ok || version != chunkFormatVersion {
+		t.Fatalf("expected current format recorded, got version=%d ok=%v err=%v", version, ok, err)
+	}
+}
+
+func TestMigrateChunkDirRejectsNewerFormat(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeChunkFormatVersion(dir, chunkFormatVersion+1); err != nil {
+		t.Fatalf("write format: %v", err)
+	}
+	if err := MigrateChunkDir(dir); err == nil {
+		t.Fatal("expected a newer chunk format to be rejected")
+	}
+}
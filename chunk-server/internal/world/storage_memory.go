@@ -4,6 +4,12 @@ import "sync"
 
 type memoryStorageProvider struct{}
 
+// NewMemoryStorageProvider returns a provider whose chunks live only in memory
+// and are lost when the chunk is dropped.
+func NewMemoryStorageProvider() StorageProvider {
+	return newMemoryStorageProvider()
+}
+
 func newMemoryStorageProvider() StorageProvider {
 	return &memoryStorageProvider{}
 }
@@ -0,0 +1,84 @@
+package world
+
+import (
+	"context"
+	"testing"
+)
+
+// hillGenerator raises each column to a height that varies across the chunk,
+// leaving some columns empty.
+type hillGenerator struct{}
+
+func (hillGenerator) Generate(ctx context.Context, coord ChunkCoord, bounds Bounds, dim Dimensions) (*Chunk, error) {
+	chunk := NewChunk(coord, bounds, dim)
+	for x := 0; x < dim.Width; x++ {
+		for y := 0; y < dim.Depth; y++ {
+			top := (bounds.Min.X+x)*3 + (bounds.Min.Y+y)*5
+			top = top%(dim.Height+1) - 1
+			for z := 0; z <= top; z++ {
+				chunk.SetLocalBlock(x, y, z, Block{Type: BlockSolid, Material: "stone"})
+			}
+		}
+	}
+	return chunk, nil
+}
+
+func TestSurfaceHeightMatchesColumnScan(t *testing.T) {
+	chdirTemp(t)
+	region := ServerRegion{
+		ChunksX:        2,
+		ChunksY:        2,
+		ChunkDimension: Dimensions{Width: 4, Depth: 4, Height: 8},
+	}
+	manager := NewManager(region, hillGenerator{})
+	ctx := context.Background()
+
+	extent := region.GlobalBlockBounds()
+	for x := extent.Min.X; x <= extent.Max.X; x++ {
+		for y := extent.Min.Y; y <= extent.Max.Y; y++ {
+			want := -1
+			for z := extent.Max.Z; z >= 0; z-- {
+				if !blockIsAir(blockAt(t, manager, BlockCoord{X: x, Y: y, Z: z})) {
+					want = z
+					break
+				}
+			}
+			got, ok := manager.SurfaceHeight(ctx, x, y)
+			if want < 0 {
+				if ok {
+					t.Fatalf("SurfaceHeight(%d,%d) = %d, want an empty column", x, y, got)
+				}
+				continue
+			}
+			if !ok || got != want {
+				t.Fatalf("SurfaceHeight(%d,%d) = %d, %t, want %d", x, y, got, ok, want)
+			}
+		}
+	}
+
+	if _, ok := manager.SurfaceHeight(ctx, extent.Max.X+1, 0); ok {
+		t.Fatalf("expected no surface outside the region")
+	}
+}
+
+func TestSurfaceHeightFollowsRemovedTopBlock(t *testing.T) {
+	chdirTemp(t)
+	manager := NewManager(ServerRegion{
+		ChunksX:        1,
+		ChunksY:        1,
+		ChunkDimension: Dimensions{Width: 4, Depth: 4, Height: 8},
+	}, hillGenerator{})
+	ctx := context.Background()
+
+	// Column (1,1) is generated up to z=7.
+	top, ok := manager.SurfaceHeight(ctx, 1, 1)
+	if !ok || top != 7 {
+		t.Fatalf("SurfaceHeight(1,1) = %d, %t, want 7", top, ok)
+	}
+	if _, err := manager.ApplyBlockEdits(ctx, []BlockEdit{{Coord: BlockCoord{X: 1, Y: 1, Z: top}}}); err != nil {
+		t.Fatalf("remove top block: %v", err)
+	}
+	if got, ok := manager.SurfaceHeight(ctx, 1, 1); !ok || got != top-1 {
+		t.Fatalf("SurfaceHeight(1,1) after removal = %d, %t, want %d", got, ok, top-1)
+	}
+}
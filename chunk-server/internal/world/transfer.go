@@ -0,0 +1,207 @@
+package world
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// chunkExportMagic opens every chunk export stream.
+const chunkExportMagic = "VXC1"
+
+// chunkExportEnd marks the end of the column records in an export stream.
+const chunkExportEnd = ^uint32(0)
+
+const (
+	// MaxChunkExportBytes bounds the size of one export stream. Receivers
+	// use it to size the parts of a transfer they are willing to buffer.
+	MaxChunkExportBytes = 16 << 20
+	// maxExportColumnBytes bounds one encoded column record in an export
+	// stream.
+	maxExportColumnBytes = 1 << 20
+	// maxInflatedColumnBytes bounds what a compressed column from an export
+	// stream may inflate to.
+	maxInflatedColumnBytes = 4 * maxExportColumnBytes
+)
+
+// ExportColumns writes the chunk's block data to w so another server can take
+// over the chunk with Manager.ImportChunk. The stream starts with the chunk
+// dimensions followed by one record per non-empty column, each holding the
+// column index and the same run-length, compressed payload disk storage uses.
+func (c *Chunk) ExportColumns(w io.Writer) error {
+	c.mu.RLock()
+	store := c.store
+	dim := c.dimension
+	c.mu.RUnlock()
+	if store == nil {
+		return fmt.Errorf("chunk %v has no storage", c.Key)
+	}
+
+	columns := make(map[int][]Block)
+	if err := store.ForEach(func(idx int, blocks []Block) bool {
+		columns[idx] = blocks
+		return true
+	}); err != nil {
+		return fmt.Errorf("chunk %v read columns: %w", c.Key, err)
+	}
+	indexes := make([]int, 0, len(columns))
+	for idx := range columns {
+		indexes = append(indexes, idx)
+	}
+	sort.Ints(indexes)
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(chunkExportMagic); err != nil {
+		return err
+	}
+	for _, n := range []int{dim.Width, dim.Depth, dim.Height} {
+		if err := binary.Write(bw, binary.LittleEndian, uint32(n)); err != nil {
+			return err
+		}
+	}
+	for _, idx := range indexes {
+		payload, err := encodeColumnPayload(columns[idx])
+		if err != nil {
+			return fmt.Errorf("chunk %v encode column %d: %w", c.Key, idx, err)
+		}
+		if err := binary.Write(bw, binary.LittleEndian, uint32(idx)); err != nil {
+			return err
+		}
+		if err := binary.Write(bw, binary.LittleEndian, uint32(len(payload))); err != nil {
+			return err
+		}
+		if _, err := bw.Write(payload); err != nil {
+			return err
+		}
+	}
+	if err := binary.Write(bw, binary.LittleEndian, chunkExportEnd); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// ImportChunk replaces the blocks of coord with a stream written by
+// ExportColumns and makes the chunk resident. Chunks that are still being
+// generated cannot be imported over. The stream is validated in full before
+// any block is written, and streams longer than MaxChunkExportBytes are
+// rejected.
+func (m *Manager) ImportChunk(coord ChunkCoord, r io.Reader) (*Chunk, error) {
+	bounds, err := m.region.ChunkBounds(coord)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := readChunkExport(io.LimitReader(r, MaxChunkExportBytes), m.region.ChunkDimension)
+	if err != nil {
+		return nil, fmt.Errorf("import chunk %v: %w", coord, err)
+	}
+
+	m.mu.Lock()
+	if _, loading := m.pending[coord]; loading {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("import chunk %v: chunk is still loading", coord)
+	}
+	chunk, ok := m.chunks[coord]
+	if !ok {
+		chunk = NewChunkWithStorage(coord, bounds, m.region.ChunkDimension, m.chunkStorage())
+		m.chunks[coord] = chunk
+	}
+	m.mu.Unlock()
+	m.touch(chunk)
+
+	if err := chunk.replaceColumns(columns); err != nil {
+		return nil, fmt.Errorf("import chunk %v: %w", coord, err)
+	}
+	return chunk, nil
+}
+
+// replaceColumns swaps every stored column for columns.
+func (c *Chunk) replaceColumns(columns map[int][]Block) error {
+	c.mu.RLock()
+	store := c.store
+	c.mu.RUnlock()
+	if store == nil {
+		return errors.New("chunk has no storage")
+	}
+
+	var stale []int
+	if err := store.ForEach(func(idx int, _ []Block) bool {
+		if _, ok := columns[idx]; !ok {
+			stale = append(stale, idx)
+		}
+		return true
+	}); err != nil {
+		return err
+	}
+	for _, idx := range stale {
+		if err := store.Delete(idx); err != nil {
+			return err
+		}
+	}
+	for idx, blocks := range columns {
+		if err := store.SaveColumn(idx, blocks); err != nil {
+			return err
+		}
+	}
+	c.version.Add(1)
+	return nil
+}
+
+func readChunkExport(r io.Reader, dim Dimensions) (map[int][]Block, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(chunkExportMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	if string(magic) != chunkExportMagic {
+		return nil, errors.New("not a chunk export stream")
+	}
+	var header [3]uint32
+	if err := binary.Read(br, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("read dimensions: %w", err)
+	}
+	if int(header[0]) != dim.Width || int(header[1]) != dim.Depth || int(header[2]) != dim.Height {
+		return nil, fmt.Errorf("exported chunk is %dx%dx%d, want %dx%dx%d",
+			header[0], header[1], header[2], dim.Width, dim.Depth, dim.Height)
+	}
+
+	columns := make(map[int][]Block)
+	maxIndex := dim.Width * dim.Depth
+	for {
+		var idx uint32
+		if err := binary.Read(br, binary.LittleEndian, &idx); err != nil {
+			return nil, fmt.Errorf("read column index: %w", err)
+		}
+		if idx == chunkExportEnd {
+			return columns, nil
+		}
+		if int(idx) >= maxIndex {
+			return nil, fmt.Errorf("column index %d out of range", idx)
+		}
+		var size uint32
+		if err := binary.Read(br, binary.LittleEndian, &size); err != nil {
+			return nil, fmt.Errorf("read column %d size: %w", idx, err)
+		}
+		if size > maxExportColumnBytes {
+			return nil, fmt.Errorf("column %d is %d bytes, limit is %d", idx, size, maxExportColumnBytes)
+		}
+		// Read through a limit rather than allocating size up front so a
+		// record claiming more than the stream holds fails cheaply.
+		payload, err := io.ReadAll(io.LimitReader(br, int64(size)))
+		if err != nil {
+			return nil, fmt.Errorf("read column %d: %w", idx, err)
+		}
+		if len(payload) != int(size) {
+			return nil, fmt.Errorf("read column %d: %w", idx, io.ErrUnexpectedEOF)
+		}
+		blocks, err := decodeBoundedColumnPayload(payload, dim.Height)
+		if err != nil {
+			return nil, fmt.Errorf("decode column %d: %w", idx, err)
+		}
+		if len(blocks) > 0 {
+			columns[int(idx)] = blocks
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package world
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"strings"
+	"testing"
+)
+
+func TestImportChunkMatchesExport(t *testing.T) {
+	chdirTemp(t)
+	region := ServerRegion{
+		ChunksX:        1,
+		ChunksY:        1,
+		ChunkDimension: Dimensions{Width: 4, Depth: 4, Height: 6},
+	}
+	ctx := context.Background()
+	coord := ChunkCoord{}
+
+	source := NewManager(region, floorGenerator{})
+	chunk, err := source.Chunk(ctx, coord)
+	if err != nil {
+		t.Fatalf("load source chunk: %v", err)
+	}
+	chunk.SetLocalBlock(2, 2, 0, Block{Type: BlockAir})
+	chunk.SetLocalBlock(1, 1, 1, Block{Type: BlockSolid, Material: "wood", HitPoints: 7, MaxHitPoints: 12, Weight: 3, ConnectingForce: 40})
+	chunk.SetLocalBlock(1, 1, 4, Block{Type: BlockMineral, Material: "iron", Color: "#aabbcc", HitPoints: 30, MaxHitPoints: 30})
+	chunk.SetLocalBlock(3, 0, 5, Block{Type: BlockSolid, Material: "glass", LightEmission: 4})
+
+	var buf bytes.Buffer
+	if err := chunk.ExportColumns(&buf); err != nil {
+		t.Fatalf("ExportColumns() error = %v", err)
+	}
+
+	// The destination already holds a different copy of the chunk, which the
+	// import has to replace outright.
+	chdirTemp(t)
+	dest := NewManager(region, floorGenerator{})
+	if _, err := dest.Chunk(ctx, coord); err != nil {
+		t.Fatalf("load destination chunk: %v", err)
+	}
+	imported, err := dest.ImportChunk(coord, &buf)
+	if err != nil {
+		t.Fatalf("ImportChunk() error = %v", err)
+	}
+	if resident, ok, _ := dest.ChunkIfReady(coord); !ok || resident != imported {
+		t.Fatalf("expected the imported chunk to be resident")
+	}
+
+	if diff := ChunkDiff(chunk, imported); len(diff) != 0 {
+		t.Fatalf("imported chunk differs from its source: %+v", diff)
+	}
+}
+
+func TestImportChunkRejectsMismatchedDimensions(t *testing.T) {
+	chdirTemp(t)
+	ctx := context.Background()
+	source := NewManager(ServerRegion{
+		ChunksX:        1,
+		ChunksY:        1,
+		ChunkDimension: Dimensions{Width: 4, Depth: 4, Height: 4},
+	}, floorGenerator{})
+	chunk, err := source.Chunk(ctx, ChunkCoord{})
+	if err != nil {
+		t.Fatalf("load source chunk: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := chunk.ExportColumns(&buf); err != nil {
+		t.Fatalf("ExportColumns() error = %v", err)
+	}
+
+	dest := NewManager(ServerRegion{
+		ChunksX:        1,
+		ChunksY:        1,
+		ChunkDimension: Dimensions{Width: 8, Depth: 8, Height: 4},
+	}, emptyGenerator{})
+	_, err = dest.ImportChunk(ChunkCoord{}, &buf)
+	if err == nil || !strings.Contains(err.Error(), "4x4x4") {
+		t.Fatalf("ImportChunk() error = %v, want dimension mismatch", err)
+	}
+	if resident, _ := dest.ChunkCounts(); resident != 0 {
+		t.Fatalf("rejected import left %d chunks resident", resident)
+	}
+}
+
+// exportStream builds an export stream for dim holding one column record at
+// index 0 with the given size field and payload.
+func exportStream(t *testing.T, dim Dimensions, size uint32, payload []byte) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	buf.WriteString(chunkExportMagic)
+	for _, v := range []uint32{uint32(dim.Width), uint32(dim.Depth), uint32(dim.Height), 0, size} {
+		if err := binary.Write(&buf, binary.LittleEndian, v); err != nil {
+			t.Fatalf("write stream: %v", err)
+		}
+	}
+	buf.Write(payload)
+	if err := binary.Write(&buf, binary.LittleEndian, chunkExportEnd); err != nil {
+		t.Fatalf("write stream: %v", err)
+	}
+	return &buf
+}
+
+func TestImportChunkRejectsColumnRunsTallerThanChunk(t *testing.T) {
+	chdirTemp(t)
+	dim := Dimensions{Width: 4, Depth: 4, Height: 4}
+	var payload bytes.Buffer
+	// A single run claiming a billion blocks must be refused before it is
+	// expanded.
+	encoding := columnEncoding{Version: columnEncodingVersion, Runs: []ColumnRun{{Count: 1 << 30, Block: Block{Type: BlockSolid}}}}
+	if err := gob.NewEncoder(&payload).Encode(&encoding); err != nil {
+		t.Fatalf("encode column: %v", err)
+	}
+
+	dest := NewManager(ServerRegion{ChunksX: 1, ChunksY: 1, ChunkDimension: dim}, emptyGenerator{})
+	_, err := dest.ImportChunk(ChunkCoord{}, exportStream(t, dim, uint32(payload.Len()), payload.Bytes()))
+	if err == nil || !strings.Contains(err.Error(), "exceed 4 blocks") {
+		t.Fatalf("ImportChunk() error = %v, want run total rejected", err)
+	}
+}
+
+func TestImportChunkRejectsOversizedColumnRecord(t *testing.T) {
+	chdirTemp(t)
+	dim := Dimensions{Width: 4, Depth: 4, Height: 4}
+	dest := NewManager(ServerRegion{ChunksX: 1, ChunksY: 1, ChunkDimension: dim}, emptyGenerator{})
+
+	_, err := dest.ImportChunk(ChunkCoord{}, exportStream(t, dim, ^uint32(0)-1, nil))
+	if err == nil || !strings.Contains(err.Error(), "limit") {
+		t.Fatalf("ImportChunk() error = %v, want oversized column rejected", err)
+	}
+	_, err = dest.ImportChunk(ChunkCoord{}, exportStream(t, dim, maxExportColumnBytes, []byte{1, 2, 3}))
+	if err == nil || !strings.Contains(err.Error(), "unexpected EOF") {
+		t.Fatalf("ImportChunk() error = %v, want short column rejected", err)
+	}
+}
@@ -0,0 +1,103 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"chunkserver/internal/clock"
+)
+
+// SetClock sets the clock chunk accesses are stamped with. Nil restores the
+// system clock.
+func (m *Manager) SetClock(c clock.Clock) {
+	m.clockMu.Lock()
+	m.clock = c
+	m.clockMu.Unlock()
+}
+
+func (m *Manager) now() time.Time {
+	m.clockMu.RLock()
+	c := m.clock
+	m.clockMu.RUnlock()
+	if c == nil {
+		return time.Now()
+	}
+	return c.Now()
+}
+
+// touch records that chunk was just handed out.
+func (m *Manager) touch(chunk *Chunk) {
+	chunk.lastAccess.Store(m.now().UnixNano())
+}
+
+// UnloadIdle snapshots and then drops every resident chunk that has not been
+// handed out for at least idle and that keep does not hold on to, and returns
+// how many it dropped. A chunk that fails to snapshot, or is read while being
+// snapshotted, stays resident, and so does every chunk whose storage cannot
+// persist it, since dropping it would lose its edits. Dropped chunks are
+// loaded afresh from storage the next time they are requested.
+func (m *Manager) UnloadIdle(idle time.Duration, keep func(ChunkCoord) bool) (int, error) {
+	cutoff := m.now().Add(-idle).UnixNano()
+
+	m.mu.RLock()
+	var idleChunks []*Chunk
+	for _, chunk := range m.chunks {
+		if chunk.lastAccess.Load() <= cutoff {
+			idleChunks = append(idleChunks, chunk)
+		}
+	}
+	m.mu.RUnlock()
+
+	var errs []error
+	unloaded := 0
+	for _, chunk := range idleChunks {
+		if keep != nil && keep(chunk.Key) {
+			continue
+		}
+		if !chunk.persistent() {
+			continue
+		}
+		version := chunk.Version()
+		if err := chunk.Snapshot(); err != nil {
+			errs = append(errs, fmt.Errorf("snapshot chunk %v: %w", chunk.Key, err))
+			continue
+		}
+		store, err := m.evict(chunk, cutoff, version)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("snapshot chunk %v: %w", chunk.Key, err))
+			continue
+		}
+		if store == nil {
+			continue
+		}
+		unloaded++
+		if err := store.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close chunk %v: %w", chunk.Key, err))
+		}
+	}
+	return unloaded, errors.Join(errs...)
+}
+
+// evict removes chunk from the resident set if it is still the resident copy
+// and has not been handed out since cutoff, and returns its detached storage.
+// Writes that landed after the chunk was snapshotted at version are committed
+// before the manager lock is released, so a reload of the chunk cannot read
+// storage that is missing them. A chunk that is kept, or whose second
+// snapshot fails, stays resident with its storage and evict returns nil.
+func (m *Manager) evict(chunk *Chunk, cutoff int64, version uint64) (BlockStorage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.chunks[chunk.Key] != chunk || chunk.lastAccess.Load() > cutoff {
+		return nil, nil
+	}
+	store := chunk.detach()
+	if chunk.Version() != version {
+		if err := store.(snapshotter).Snapshot(); err != nil {
+			chunk.attach(store)
+			return nil, err
+		}
+	}
+	delete(m.chunks, chunk.Key)
+	return store, nil
+}
@@ -0,0 +1,114 @@
+package world
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chunkserver/internal/clock"
+)
+
+func TestUnloadIdlePersistsAndDropsChunksPastTheTTL(t *testing.T) {
+	chdirTemp(t)
+	region := ServerRegion{
+		ChunksX:        2,
+		ChunksY:        1,
+		ChunkDimension: Dimensions{Width: 4, Depth: 4, Height: 4},
+	}
+	manager := NewManager(region, floorGenerator{})
+	manager.SetStorageProvider(NewDiskStorageProvider(t.TempDir(), region))
+	clk := clock.NewManual(time.Unix(1000, 0))
+	manager.SetClock(clk)
+	ctx := context.Background()
+
+	idle, err := manager.Chunk(ctx, ChunkCoord{X: 0, Y: 0})
+	if err != nil {
+		t.Fatalf("load idle chunk: %v", err)
+	}
+	marker := Block{Type: BlockSolid, Material: "marker"}
+	idle.SetLocalBlock(1, 1, 2, marker)
+	if _, err := manager.Chunk(ctx, ChunkCoord{X: 1, Y: 0}); err != nil {
+		t.Fatalf("load busy chunk: %v", err)
+	}
+
+	clk.Advance(30 * time.Second)
+	if _, err := manager.Chunk(ctx, ChunkCoord{X: 1, Y: 0}); err != nil {
+		t.Fatalf("read busy chunk: %v", err)
+	}
+	clk.Advance(40 * time.Second)
+
+	unloaded, err := manager.UnloadIdle(time.Minute, nil)
+	if err != nil {
+		t.Fatalf("UnloadIdle: %v", err)
+	}
+	if unloaded != 1 {
+		t.Fatalf("unloaded %d chunks, want 1", unloaded)
+	}
+	if resident, _ := manager.ChunkCounts(); resident != 1 {
+		t.Fatalf("%d chunks resident after the sweep, want 1", resident)
+	}
+	if _, ok := manager.cachedChunk(ChunkCoord{X: 0, Y: 0}); ok {
+		t.Fatalf("idle chunk is still resident")
+	}
+
+	reloaded, err := manager.Chunk(ctx, ChunkCoord{X: 0, Y: 0})
+	if err != nil {
+		t.Fatalf("reload idle chunk: %v", err)
+	}
+	if reloaded == idle {
+		t.Fatalf("reload returned the unloaded chunk")
+	}
+	if got, _ := reloaded.LocalBlock(1, 1, 2); got.Material != marker.Material {
+		t.Fatalf("reloaded block = %+v, want the persisted marker", got)
+	}
+	if idle.SetLocalBlock(2, 2, 2, marker) {
+		t.Fatalf("expected writes through the unloaded chunk to fail")
+	}
+	// Summaries sent before the unload must not match the reloaded chunk
+	// once it is edited again.
+	if reloaded.Version() <= idle.Version() {
+		t.Fatalf("reloaded version %d, want above the unloaded chunk's %d", reloaded.Version(), idle.Version())
+	}
+}
+
+func TestUnloadIdleKeepsChunksTheCallerHolds(t *testing.T) {
+	manager := newPlacementManager(t)
+	clk := clock.NewManual(time.Unix(1000, 0))
+	manager.SetClock(clk)
+	if _, err := manager.Chunk(context.Background(), ChunkCoord{X: 0, Y: 0}); err != nil {
+		t.Fatalf("load chunk: %v", err)
+	}
+	clk.Advance(time.Hour)
+
+	unloaded, err := manager.UnloadIdle(time.Minute, func(ChunkCoord) bool { return true })
+	if err != nil {
+		t.Fatalf("UnloadIdle: %v", err)
+	}
+	if resident, _ := manager.ChunkCounts(); unloaded != 0 || resident != 1 {
+		t.Fatalf("unloaded %d, %d resident; want the held chunk kept", unloaded, resident)
+	}
+}
+
+func TestUnloadIdleKeepsChunksMemoryStorageCannotRestore(t *testing.T) {
+	manager := newPlacementManager(t)
+	manager.SetStorageProvider(NewMemoryStorageProvider())
+	clk := clock.NewManual(time.Unix(1000, 0))
+	manager.SetClock(clk)
+	chunk, err := manager.Chunk(context.Background(), ChunkCoord{X: 0, Y: 0})
+	if err != nil {
+		t.Fatalf("load chunk: %v", err)
+	}
+	chunk.SetLocalBlock(1, 1, 1, Block{Type: BlockSolid, Material: "marker"})
+	clk.Advance(time.Hour)
+
+	unloaded, err := manager.UnloadIdle(time.Minute, nil)
+	if err != nil {
+		t.Fatalf("UnloadIdle: %v", err)
+	}
+	if resident, _ := manager.ChunkCounts(); unloaded != 0 || resident != 1 {
+		t.Fatalf("unloaded %d, %d resident; want the memory-backed chunk kept", unloaded, resident)
+	}
+	if got, _ := chunk.LocalBlock(1, 1, 1); got.Material != "marker" {
+		t.Fatalf("block = %+v, want the edit kept", got)
+	}
+}
@@ -0,0 +1,60 @@
+package world
+
+import "math"
+
+// Vec3 represents positions scaled to world blocks (float precision supports 1/20th block entities).
+type Vec3 struct {
+	X float64
+	Y float64
+	Z float64
+}
+
+// BlockFromVec returns the block containing pos. Each axis is floored, so a
+// position just below a block boundary belongs to the lower block on both
+// sides of zero: -0.5 lies in block -1, not block 0.
+func BlockFromVec(pos Vec3) BlockCoord {
+	return BlockCoord{
+		X: int(math.Floor(pos.X)),
+		Y: int(math.Floor(pos.Y)),
+		Z: int(math.Floor(pos.Z)),
+	}
+}
+
+// NearestBlock returns the block whose minimum corner lies nearest pos,
+// rounding each axis half away from zero. Planners that snap a point to the
+// grid, rather than ask which block holds it, use it instead of BlockFromVec.
+func NearestBlock(pos Vec3) BlockCoord {
+	return BlockCoord{
+		X: int(math.Round(pos.X)),
+		Y: int(math.Round(pos.Y)),
+		Z: int(math.Round(pos.Z)),
+	}
+}
+
+// ToVec returns the position of the block's minimum corner.
+func (c BlockCoord) ToVec() Vec3 {
+	return Vec3{X: float64(c.X), Y: float64(c.Y), Z: float64(c.Z)}
+}
+
+// Add returns c offset by o.
+func (c BlockCoord) Add(o BlockCoord) BlockCoord {
+	return BlockCoord{X: c.X + o.X, Y: c.Y + o.Y, Z: c.Z + o.Z}
+}
+
+// Sub returns the offset from o to c.
+func (c BlockCoord) Sub(o BlockCoord) BlockCoord {
+	return BlockCoord{X: c.X - o.X, Y: c.Y - o.Y, Z: c.Z - o.Z}
+}
+
+// Manhattan returns the taxicab distance between c and o in blocks.
+func (c BlockCoord) Manhattan(o BlockCoord) int {
+	d := c.Sub(o)
+	return absInt(d.X) + absInt(d.Y) + absInt(d.Z)
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,56 @@
+package world
+
+import "testing"
+
+func TestBlockFromVecFloorsNegativeCoordinates(t *testing.T) {
+	cases := []struct {
+		pos  Vec3
+		want BlockCoord
+	}{
+		{pos: Vec3{X: 0.5, Y: 0.5, Z: 0.5}, want: BlockCoord{X: 0, Y: 0, Z: 0}},
+		{pos: Vec3{X: 2.99, Y: 3, Z: 4.5}, want: BlockCoord{X: 2, Y: 3, Z: 4}},
+		// Rounding would put these in block 0, -1 and -2.
+		{pos: Vec3{X: -0.4, Y: -0.5, Z: -1.6}, want: BlockCoord{X: -1, Y: -1, Z: -2}},
+		{pos: Vec3{X: -1, Y: -2.5, Z: -0.01}, want: BlockCoord{X: -1, Y: -3, Z: -1}},
+	}
+	for _, tc := range cases {
+		if got := BlockFromVec(tc.pos); got != tc.want {
+			t.Errorf("BlockFromVec(%+v) = %v, want %v", tc.pos, got, tc.want)
+		}
+	}
+}
+
+func TestNearestBlockRoundsEachAxis(t *testing.T) {
+	pos := Vec3{X: 2.5, Y: -0.4, Z: -1.6}
+	if got, want := NearestBlock(pos), (BlockCoord{X: 3, Y: 0, Z: -2}); got != want {
+		t.Fatalf("NearestBlock(%+v) = %v, want %v", pos, got, want)
+	}
+}
+
+func TestBlockCoordRoundTripsThroughVec(t *testing.T) {
+	for _, coord := range []BlockCoord{{X: 0, Y: 0, Z: 0}, {X: -3, Y: 7, Z: 2}, {X: -1, Y: -1, Z: -1}} {
+		if got := BlockFromVec(coord.ToVec()); got != coord {
+			t.Errorf("BlockFromVec(%v.ToVec()) = %v", coord, got)
+		}
+	}
+}
+
+func TestBlockCoordArithmetic(t *testing.T) {
+	a := BlockCoord{X: 2, Y: -3, Z: 5}
+	b := BlockCoord{X: -1, Y: 4, Z: 5}
+	if got := a.Add(b); got != (BlockCoord{X: 1, Y: 1, Z: 10}) {
+		t.Fatalf("Add() = %v", got)
+	}
+	if got := a.Sub(b); got != (BlockCoord{X: 3, Y: -7, Z: 0}) {
+		t.Fatalf("Sub() = %v", got)
+	}
+	if got := a.Sub(b).Add(b); got != a {
+		t.Fatalf("Sub then Add = %v, want %v", got, a)
+	}
+	if got := a.Manhattan(b); got != 10 {
+		t.Fatalf("Manhattan() = %d, want 10", got)
+	}
+	if a.Manhattan(b) != b.Manhattan(a) {
+		t.Fatalf("Manhattan() is not symmetric")
+	}
+}
@@ -0,0 +1,62 @@
+package world
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Warmup generates every chunk of the region inside the inclusive box from
+// from to to and commits them to storage, so play does not pay for terrain
+// generation on first access. Chunks already resident are only committed.
+// Generations queue at PriorityLow behind the SetMaxConcurrentLoads bound, so
+// chunks callers are waiting on still go first. progress, when set, is called
+// after each chunk is ready with the count so far and the total. Warmup
+// returns how many chunks it covered; the box must overlap the region.
+func (m *Manager) Warmup(ctx context.Context, from, to ChunkCoord, progress func(done, total int)) (int, error) {
+	coords := m.region.chunksWithin(from, to)
+	if len(coords) == 0 {
+		return 0, fmt.Errorf("warmup box %v-%v does not overlap the region", from, to)
+	}
+
+	futures := make([]*chunkFuture, len(coords))
+	for i, coord := range coords {
+		future, err := m.ensureChunkFuture(ctx, coord, PriorityLow, false)
+		if err != nil {
+			return 0, fmt.Errorf("warm chunk %v: %w", coord, err)
+		}
+		futures[i] = future
+	}
+
+	var errs []error
+	for i, future := range futures {
+		select {
+		case <-ctx.Done():
+			return i, ctx.Err()
+		case <-future.ready:
+		}
+		if future.err != nil {
+			errs = append(errs, fmt.Errorf("warm chunk %v: %w", coords[i], future.err))
+		} else if err := future.chunk.Snapshot(); err != nil {
+			errs = append(errs, fmt.Errorf("snapshot chunk %v: %w", coords[i], err))
+		}
+		if progress != nil {
+			progress(i+1, len(coords))
+		}
+	}
+	return len(coords), errors.Join(errs...)
+}
+
+// chunksWithin lists the region's chunks inside the inclusive box from from
+// to to, row by row.
+func (r ServerRegion) chunksWithin(from, to ChunkCoord) []ChunkCoord {
+	x0, x1 := max(from.X, r.Origin.X), min(to.X, r.Origin.X+r.ChunksX-1)
+	y0, y1 := max(from.Y, r.Origin.Y), min(to.Y, r.Origin.Y+r.ChunksY-1)
+	var coords []ChunkCoord
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			coords = append(coords, ChunkCoord{X: x, Y: y})
+		}
+	}
+	return coords
+}
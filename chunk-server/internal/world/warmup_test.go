@@ -0,0 +1,86 @@
+package world
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWarmupGeneratesAndPersistsRegion(t *testing.T) {
+	chdirTemp(t)
+	region := ServerRegion{
+		Origin:         ChunkCoord{X: 2, Y: -1},
+		ChunksX:        3,
+		ChunksY:        2,
+		ChunkDimension: Dimensions{Width: 4, Depth: 4, Height: 4},
+	}
+	dir := t.TempDir()
+	manager := NewManager(region, floorGenerator{})
+	manager.SetStorageProvider(NewDiskStorageProvider(dir, region))
+	manager.SetMaxConcurrentLoads(2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var reported []int
+	warmed, err := manager.Warmup(ctx, ChunkCoord{X: -100, Y: -100}, ChunkCoord{X: 100, Y: 100}, func(done, total int) {
+		if total != region.ChunkCount() {
+			t.Errorf("progress total = %d, want %d", total, region.ChunkCount())
+		}
+		reported = append(reported, done)
+	})
+	if err != nil {
+		t.Fatalf("Warmup: %v", err)
+	}
+	if warmed != region.ChunkCount() {
+		t.Fatalf("warmed %d chunks, want %d", warmed, region.ChunkCount())
+	}
+	for i, done := range reported {
+		if done != i+1 {
+			t.Fatalf("progress reported %v, want 1..%d", reported, region.ChunkCount())
+		}
+	}
+	if len(reported) != region.ChunkCount() {
+		t.Fatalf("progress called %d times, want %d", len(reported), region.ChunkCount())
+	}
+	if resident, pending := manager.ChunkCounts(); resident != region.ChunkCount() || pending != 0 {
+		t.Fatalf("expected %d resident chunks and none pending, got %d and %d", region.ChunkCount(), resident, pending)
+	}
+
+	indexes, err := filepath.Glob(filepath.Join(dir, "*", "*", "*.idx"))
+	if err != nil {
+		t.Fatalf("glob chunk indexes: %v", err)
+	}
+	if len(indexes) != region.ChunkCount() {
+		t.Fatalf("expected %d persisted chunk indexes, got %v", region.ChunkCount(), indexes)
+	}
+}
+
+func TestWarmupClipsBoxToRegion(t *testing.T) {
+	chdirTemp(t)
+	region := ServerRegion{
+		ChunksX:        3,
+		ChunksY:        3,
+		ChunkDimension: Dimensions{Width: 2, Depth: 2, Height: 2},
+	}
+	manager := NewManager(region, floorGenerator{})
+	ctx := context.Background()
+
+	warmed, err := manager.Warmup(ctx, ChunkCoord{X: 2, Y: 1}, ChunkCoord{X: 9, Y: 1}, nil)
+	if err != nil {
+		t.Fatalf("Warmup: %v", err)
+	}
+	if warmed != 1 {
+		t.Fatalf("warmed %d chunks, want 1", warmed)
+	}
+	if _, ready, _ := manager.ChunkIfReady(ChunkCoord{X: 2, Y: 1}); !ready {
+		t.Fatalf("expected chunk (2,1) to be resident")
+	}
+	if resident, _ := manager.ChunkCounts(); resident != 1 {
+		t.Fatalf("expected only the boxed chunk resident, got %d", resident)
+	}
+
+	if _, err := manager.Warmup(ctx, ChunkCoord{X: 5, Y: 5}, ChunkCoord{X: 6, Y: 6}, nil); err == nil {
+		t.Fatalf("expected a box outside the region to fail")
+	}
+}
@@ -12,7 +12,9 @@ export type MessageType =
   | 'transferRequest'
   | 'transferAck'
   | 'neighborHello'
-  | 'neighborAck';
+  | 'neighborAck'
+  | 'environment'
+  | 'chunkTransfer';
 
 export interface Envelope<TPayload = unknown> {
   type: MessageType;
@@ -26,6 +28,8 @@ export interface ChunkSummaryPayload {
   chunkY: number;
   version: number;
   blockCount: number;
+  fingerprint?: string;
+  weather?: WeatherPayload;
 }
 
 export type BlockType =
@@ -56,13 +60,14 @@ const BLOCK_TYPE_NAMES: ReadonlyArray<BlockType> = [
   'explosive'
 ];
 
-export type ChangeReason = 'unknown' | 'damage' | 'destroy' | 'collapse';
+export type ChangeReason = 'unknown' | 'damage' | 'destroy' | 'collapse' | 'edit';
 
 export const ChangeReasonCodes = {
   Unknown: 0,
   Damage: 1,
   Destroy: 2,
-  Collapse: 3
+  Collapse: 3,
+  Edit: 4
 } as const;
 
 export type ChangeReasonCode =
@@ -72,7 +77,8 @@ const CHANGE_REASON_NAMES: ReadonlyArray<ChangeReason> = [
   'unknown',
   'damage',
   'destroy',
-  'collapse'
+  'collapse',
+  'edit'
 ];
 
 export interface EncodedBlockChangePayload {
@@ -151,6 +157,30 @@ export interface WorldTimeState {
   ambientIntensity: number;
 }
 
+export type WeatherKind = 'clear' | 'rain' | 'storm';
+
+export interface WeatherPayload {
+  kind: WeatherKind;
+  intensity: number;
+  windSpeed: number;
+  windDirection: number;
+  precipitation: number;
+}
+
+export interface EnvironmentPayload {
+  serverId: string;
+  timestamp: string;
+  timeOfDay: number;
+  phase: DayPhase;
+  weather: WeatherPayload;
+  lighting: {
+    ambient: number;
+    sunAngle: number;
+    fogDensity: number;
+    weatherTint: number;
+  };
+}
+
 export function encodeEnvelope(
   type: MessageType,
   payload: unknown,